@@ -0,0 +1,454 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Gentleman-Programming/engram/internal/project"
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// ─── Command Registry ────────────────────────────────────────────────────────
+//
+// Every command, subcommand, and flag the CLI accepts is declared here. main()
+// validates os.Args against this table before dispatching, so typos fail with
+// a per-command usage instead of being silently ignored, and the shell
+// completion scripts are generated from the same source of truth.
+//
+// The per-command parsers in main.go still read os.Args. Validation rewrites
+// the arguments into one canonical form first (`--flag=value` is split, short
+// aliases are expanded), so those parsers only ever see long flags.
+
+// cliFlag describes one flag of a command.
+type cliFlag struct {
+	name    string   // long form without dashes, e.g. "project"
+	short   string   // optional one-letter alias, e.g. "p"
+	aliases []string // extra long spellings accepted for name
+	value   string   // placeholder when the flag takes a value; empty for switches
+	help    string
+}
+
+// cliCommand describes one command or subcommand.
+type cliCommand struct {
+	name    string
+	aliases []string
+	args    string // positional arguments shown in usage, e.g. "<query>"
+	summary string
+	flags   []cliFlag
+	subs    []cliCommand
+	choices func() []string // completion candidates for the first positional
+	run     func(cfg store.Config)
+}
+
+var (
+	projectFlag = cliFlag{name: "project", short: "p", value: "NAME", help: "Project name"}
+	typeFlag    = cliFlag{name: "type", short: "t", value: "TYPE", help: "Observation type"}
+	scopeFlag   = cliFlag{name: "scope", short: "s", value: "SCOPE", help: "Scope: project or personal"}
+	limitFlag   = cliFlag{name: "limit", short: "n", value: "N", help: "Maximum number of results"}
+	dryRunFlag  = cliFlag{name: "dry-run", help: "Preview changes without applying them"}
+)
+
+// commandRegistry returns the full command table. It is a function rather
+// than a package var because the completion command reads the table itself.
+func commandRegistry() []cliCommand {
+	return []cliCommand{
+		{name: "serve", args: "[port]", summary: "Start HTTP API server (default: 7437)", run: cmdServe, flags: []cliFlag{
+			{name: "data-dir", value: "[NAME=]DIR", help: "Serve the store in DIR under /u/NAME/ instead of the default store (repeatable)"},
+			{name: "mounts", value: "FILE", help: "JSON mapping of mount name to data dir"},
+		}},
+		{name: "mcp", summary: "Start MCP server (stdio transport)", run: cmdMCP, flags: []cliFlag{
+			{name: "tools", value: "PROFILE", help: "Tool profiles or names: agent, admin, all"},
+			{name: "project", short: "p", value: "NAME", help: "Override detected project name"},
+			{name: "ephemeral", help: "Keep memories in memory only; nothing is written to disk"},
+			{name: "tools-file", value: "FILE", help: "Tool description overrides (default: <data dir>/tools.toml, or $ENGRAM_TOOLS_FILE)"},
+			{name: "read-only", help: "Offer only read tools and refuse every write, for untrusted agents"},
+		}},
+		{name: "tui", summary: "Launch interactive terminal UI", run: cmdTUI},
+		{name: "search", args: "<query>", summary: "Search memories", run: cmdSearch, flags: []cliFlag{
+			{name: "interactive", short: "i", help: "Incremental picker (enter prints, ctrl+y copies the ID)"},
+			{name: "all", help: "Also search saved user prompts; results are tagged observation or prompt"},
+			{name: "output", short: "o", value: "FORMAT", help: "text, csv, or tsv (default: text)"},
+			typeFlag, projectFlag, scopeFlag, limitFlag,
+			{name: "ref", value: "REF", help: "Memories linked to an issue or PR (#123, owner/repo#123, URL)"},
+			{name: "file", value: "PATH", help: "Memories that mention a file (src/auth/middleware.ts, middleware.ts)"},
+			{name: "source", value: "SOURCE", help: "Memories saved through one path (cli, mcp, mcp:<client>, http, passive, sync-import)"},
+			{name: "meta", value: "KEY=VALUE", help: "Memories whose metadata has KEY set to VALUE (repeatable)"},
+			{name: "session", value: "ID", help: "Memories saved in one session"},
+			{name: "started-after", aliases: []string{"after"}, value: "DATE", help: "Memories created at or after a date or RFC3339 time"},
+			{name: "started-before", aliases: []string{"before"}, value: "DATE", help: "Memories created before a date or RFC3339 time"},
+			{name: "include-quarantined", help: "Also match passive captures held in quarantine"},
+			{name: "include-archive", help: "Also search observations moved to the archive database"},
+		}},
+		{name: "save", args: "<title> <content>", summary: "Save a memory", run: cmdSave, flags: []cliFlag{
+			typeFlag, projectFlag, scopeFlag,
+			{name: "topic", aliases: []string{"topic-key"}, value: "KEY", help: "Topic key; saves with the same key update one memory"},
+			{name: "template", value: "NAME", help: "Lay the content out as a template (adr, incident, or one from [templates]); sets type and topic key"},
+			{name: "meta", value: "KEY=VALUE", help: "Attach metadata, e.g. --meta pr=412 --meta model=gpt-5 (repeatable)"},
+		}},
+		{name: "save-summary", summary: "Save a machine-written session summary (CI) and end the session", run: cmdSaveSummary, flags: []cliFlag{
+			{name: "session", value: "ID", help: "Session to create or reuse, e.g. ci-nightly (required)"},
+			{name: "file", value: "FILE", help: "Markdown file with the summary, or - for stdin (required)"},
+			projectFlag,
+			{name: "title", value: "TITLE", help: "Observation title (default: Session summary: <project>)"},
+			{name: "url", value: "URL", help: "Post to a running engram serve instead of the local database"},
+		}},
+		{name: "run", args: "-- <command> [args...]", summary: "Run a command and save its outcome and learnings", run: cmdRun, flags: []cliFlag{
+			projectFlag,
+		}},
+		{name: "timeline", args: "<obs_id>", summary: "Chronological context around an observation", run: cmdTimeline, flags: []cliFlag{
+			{name: "before", value: "N", help: "Observations before the anchor"},
+			{name: "after", value: "N", help: "Observations after the anchor"},
+		}},
+		{name: "context", args: "[project]", summary: "Recent context from previous sessions", run: cmdContext, flags: []cliFlag{scopeFlag,
+			{name: "include-parents", help: "Also show decisions saved on parent projects (platform for platform/api)"},
+			{name: "all", help: "Ignore the [context.exclude] rules and show every observation"},
+			{name: "estimate", help: "Print the size of each section instead of the text"},
+			{name: "json", help: "With --estimate, print the estimate as JSON"},
+		}},
+		{name: "stats", summary: "Memory system statistics", run: cmdStats, flags: []cliFlag{
+			{name: "watch", short: "w", help: "Live dashboard that refreshes until q"},
+			{name: "interval", value: "DURATION", help: "Refresh interval for --watch (default: 2s)"},
+		}},
+		{name: "topics", summary: "Topic keys in use with their latest revision", run: cmdTopics, flags: []cliFlag{projectFlag, scopeFlag}},
+		{name: "status", summary: "Health and readiness of a running server", run: func(store.Config) { cmdStatus() }, flags: []cliFlag{
+			{name: "url", value: "URL", help: "Server URL (default: http://127.0.0.1:$ENGRAM_PORT)"},
+			{name: "port", value: "N", help: "Server port on localhost (default: 7437)"},
+		}},
+		{name: "export", args: "[file]", summary: "Export all memories to JSON", run: cmdExport},
+		{name: "import", args: "<file>", summary: "Import memories from a JSON export", run: cmdImport, flags: []cliFlag{
+			{name: "on-conflict", value: "MODE", help: "Existing records: skip, merge, or duplicate (default: skip)"},
+			{name: "from", value: "APP", help: "Import local chat history from cursor or windsurf instead of a file"},
+			{name: "dir", value: "DIR", help: "With --from, the editor's User directory (default: its standard location)"},
+			projectFlag,
+		}},
+		{name: "sync", summary: "Export new memories as a compressed chunk to .engram/", run: cmdSync, flags: []cliFlag{
+			{name: "import", help: "Import new chunks from .engram/ into the local DB"},
+			{name: "status", help: "Show sync status (local vs remote chunks)"},
+			{name: "all", help: "Export all projects (ignore directory-based filter)"},
+			{name: "include-personal", help: "Also share personal-scope memories, which team sync leaves out by default"},
+			{name: "prune-remote", help: "Delete imported chunks older than --older-than days and drop them from the manifest"},
+			{name: "older-than", value: "DAYS", help: "Chunk age for --prune-remote (default: 30)"},
+			{name: "dry-run", help: "With --import, preview the pending chunks; with --prune-remote, list what would be removed"},
+			{name: "personal", help: "Sync personal-scope memories through the encrypted personal relay instead of .engram/"},
+			{name: "relay", value: "URL|DIR", help: "With --personal, the relay to use (default: [personal_sync] relay)"},
+			{name: "recursive", value: "DIR", help: "Status, import, or export every repo under DIR that has a .engram/ directory"},
+			projectFlag,
+		}},
+		{name: "relay", summary: "Serve encrypted personal sync blobs for engram sync --personal", run: cmdRelay, flags: []cliFlag{
+			{name: "dir", value: "DIR", help: "Where blobs are kept (default: <data dir>/relay)"},
+			{name: "addr", value: "ADDR", help: "Listen address (default: :7438)"},
+		}},
+		{name: "obsidian-export", summary: "Export memories to an Obsidian vault (beta)", run: cmdObsidianExport, flags: []cliFlag{
+			{name: "vault", value: "DIR", help: "Path to Obsidian vault root (required)"},
+			projectFlag, limitFlag,
+			{name: "since", value: "DATE", help: "Only observations after this date, e.g. 2026-01-01"},
+			{name: "force", help: "Ignore incremental state, full re-export"},
+			{name: "graph-config", value: "MODE", help: "Graph layout mode: preserve, force, or skip"},
+			{name: "watch", help: "Re-export on an interval until Ctrl+C"},
+			{name: "interval", value: "DURATION", help: "Interval for --watch (default: 10m)"},
+		}},
+		{name: "projects", summary: "List and clean up project names", run: cmdProjects, subs: []cliCommand{
+			{name: "list", summary: "Projects with observation, session, and prompt counts"},
+			{name: "consolidate", summary: "Merge similar project names into one canonical name", flags: []cliFlag{
+				{name: "all", help: "Scan all projects for similar name groups"},
+				dryRunFlag,
+			}},
+			{name: "prune", summary: "Remove projects with no observations", flags: []cliFlag{dryRunFlag}},
+		}},
+		{name: "session", summary: "Merge, split, or export sessions", run: cmdSession, subs: []cliCommand{
+			{name: "merge", args: "<target> <source>...", summary: "Fold duplicate sessions into target"},
+			{name: "split", args: "<session> <new-id> <obs-id>...", summary: "Move observations into a new session"},
+			{name: "export", args: "<id>", summary: "Markdown transcript of prompts, observations, and summary", flags: []cliFlag{
+				{name: "md", help: "Markdown output (the default and only format)"},
+				{name: "redact", help: "Mask secrets, emails, and home directories for sharing"},
+				{name: "out", value: "FILE", help: `Output file, "-" for stdout (default: -)`},
+			}},
+			{name: "summarize", args: "<id>", summary: "Compose a summary from decisions, bugfixes, and file changes"},
+		}},
+		{name: "quarantine", summary: "Review low-confidence passive captures", run: cmdQuarantine, subs: []cliCommand{
+			{name: "list", summary: "Captures awaiting review", flags: []cliFlag{projectFlag, limitFlag}},
+			{name: "approve", args: "<obs-id>...", summary: "Release captures into memory"},
+			{name: "reject", args: "<obs-id>...", summary: "Permanently delete captures"},
+		}},
+		{name: "archive", summary: "Move old observations into the searchable archive database", run: cmdArchive, subs: []cliCommand{
+			{name: "run", summary: "Archive observations untouched for N days", flags: []cliFlag{projectFlag,
+				{name: "older-than", value: "DAYS", help: "Minimum age since the last update (default: 180)"},
+				{name: "dry-run", help: "Count and list matches without moving them"},
+			}},
+		}},
+		{name: "seed", summary: "Fill the store with synthetic memories for demos and plugin development", run: cmdSeed, flags: []cliFlag{
+			{name: "projects", value: "N", help: "Projects to spread sessions over (default: 3)"},
+			{name: "sessions", value: "N", help: "Sessions in total (default: 20)"},
+			{name: "observations", value: "N", help: "Observations in total (default: 500)"},
+			{name: "seed", value: "N", help: "Random seed; the same seed generates the same data (default: 1)"},
+		}},
+		{name: "dedupe", summary: "Report how much deduplication absorbs", run: cmdDedupe, subs: []cliCommand{
+			{name: "report", summary: "Deduped vs inserted saves, the biggest duplicate clusters, and storage saved", flags: []cliFlag{
+				projectFlag,
+				{name: "limit", short: "n", value: "N", help: "Clusters to list (default: 10)"},
+				{name: "json", help: "Print the report as JSON"},
+			}},
+		}},
+		{name: "gc", summary: "Drop orphaned full-text index rows, optimize the index, and reclaim free pages", run: cmdGC},
+		{name: "compress", summary: "Gzip observation content stored as text above a size", run: cmdCompress, flags: []cliFlag{
+			{name: "above", value: "SIZE", help: "Compress content larger than this, e.g. 8KB (default: [storage] compress_above, then 4KB)"},
+			{name: "dry-run", help: "Report the savings without rewriting rows"},
+		}},
+		{name: "doctor", summary: "Check the database for records that reference missing sessions", run: cmdDoctor, flags: []cliFlag{
+			{name: "fix-sessions", help: "Create placeholder sessions for the missing session IDs"},
+			{name: "json", help: "Print the findings as JSON"},
+		}},
+		{name: "query", args: "<sql>", summary: "Run read-only SQL against the store", run: cmdQuery, flags: []cliFlag{
+			{name: "format", value: "FORMAT", help: "table, csv, or json (default: table)"},
+			{name: "limit", short: "n", value: "N", help: "Maximum rows to print (default: all)"},
+		}},
+		{name: "migrate", summary: "Inspect or roll back the database schema", run: cmdMigrate, subs: []cliCommand{
+			{name: "status", summary: "Applied and pending schema migrations", flags: []cliFlag{
+				{name: "json", help: "Print the migrations as JSON"},
+			}},
+			{name: "up", summary: "Apply pending migrations"},
+			{name: "down", summary: "Roll back migrations above a version, before downgrading engram", flags: []cliFlag{
+				{name: "to", value: "N", help: "Schema version to keep"},
+			}},
+		}},
+		{name: "enrich", summary: "Review LLM-suggested titles, topic keys, and tags", run: cmdEnrich, subs: []cliCommand{
+			{name: "run", summary: "Enrich observations without a suggestion through the [enrich] endpoint", flags: []cliFlag{
+				{name: "limit", short: "n", value: "N", help: "Observations to enrich (default: [enrich] batch, then 20)"},
+			}},
+			{name: "review", summary: "List suggestions next to the current values", flags: []cliFlag{
+				{name: "status", value: "STATUS", help: "pending, accepted, rejected, or failed (default: pending)"},
+				limitFlag,
+			}},
+			{name: "accept", args: "<suggestion-id>...", summary: "Apply suggested titles and topic keys"},
+			{name: "reject", args: "<suggestion-id>...", summary: "Discard suggestions"},
+		}},
+		{name: "replicate", summary: "Copy memories into PostgreSQL for analytics", run: cmdReplicate, flags: []cliFlag{
+			{name: "to", value: "URL", help: "Target postgres:// URL (default: $ENGRAM_REPLICA_URL)"},
+			{name: "full", help: "Re-send every row instead of changes since the last run"},
+			{name: "batch", value: "N", help: "Rows per transaction (default: 500)"},
+			{name: "psql", value: "PATH", help: "psql client to run (default: psql on PATH)"},
+			{name: "dry-run", help: "Print the SQL instead of running it"},
+		}},
+		{name: "service", summary: "Run engram serve as a background service", run: cmdService, subs: []cliCommand{
+			{name: "install", summary: "Write and register the service (systemd, launchd, or a Windows logon task)"},
+			{name: "uninstall", summary: "Stop and remove the service"},
+			{name: "start", summary: "Start the service"},
+			{name: "stop", summary: "Stop the service"},
+			{name: "status", summary: "Whether the service is installed and running"},
+		}},
+		{name: "emit", summary: "Write memories into agent files", run: cmdEmit, subs: []cliCommand{
+			{name: "rules", summary: "Write decisions, patterns, and conventions to a rules file", flags: []cliFlag{
+				projectFlag,
+				{name: "out", value: "FILE", help: `Output file, "-" for stdout (default: AGENTS.md)`},
+				limitFlag,
+			}},
+		}},
+		{name: "setup", args: "[agent]", summary: "Install agent integration", run: func(store.Config) { cmdSetup() }, choices: setupAgentNames},
+		{name: "completion", args: "<bash|zsh|fish>", summary: "Print a shell completion script", run: func(store.Config) { cmdCompletion() }, choices: func() []string { return completionShells }},
+		{name: "version", aliases: []string{"--version", "-v"}, summary: "Print version", run: func(store.Config) { fmt.Printf("engram %s\n", version) }},
+		{name: "help", aliases: []string{"--help", "-h"}, summary: "Show help", run: func(store.Config) { printUsage() }},
+	}
+}
+
+func setupAgentNames() []string {
+	var names []string
+	for _, a := range setupSupportedAgents() {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// findCommand returns the top-level command called name, or nil.
+func findCommand(commands []cliCommand, name string) *cliCommand {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+		for _, alias := range commands[i].aliases {
+			if alias == name {
+				return &commands[i]
+			}
+		}
+	}
+	return nil
+}
+
+// lookupFlag resolves a flag token (without its =value) to its spec.
+func (c *cliCommand) lookupFlag(token string) (cliFlag, bool) {
+	for _, f := range c.flags {
+		if token == "--"+f.name || (f.short != "" && token == "-"+f.short) {
+			return f, true
+		}
+		for _, alias := range f.aliases {
+			if token == "--"+alias {
+				return f, true
+			}
+		}
+	}
+	return cliFlag{}, false
+}
+
+// isFlagToken reports whether arg should be treated as a flag. A bare "-"
+// and negative numbers are positional.
+func isFlagToken(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+	if arg[1] >= '0' && arg[1] <= '9' {
+		return false
+	}
+	return true
+}
+
+// normalizeArgs validates args against the command (descending into a
+// subcommand when the first argument names one) and returns them in
+// canonical form. It also returns the command whose usage applies, its full
+// path for messages, and whether --help was requested.
+func normalizeArgs(cmd *cliCommand, args []string) (target *cliCommand, path string, out []string, help bool, err error) {
+	target, path = cmd, "engram "+cmd.name
+	if len(cmd.subs) > 0 && len(args) > 0 {
+		for i := range cmd.subs {
+			if cmd.subs[i].name == args[0] {
+				target = &cmd.subs[i]
+				path += " " + args[0]
+				out = append(out, args[0])
+				args = args[1:]
+				break
+			}
+		}
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			// Everything after "--" is positional, e.g. the command
+			// `engram run` wraps.
+			out = append(out, args[i:]...)
+			break
+		}
+		if !isFlagToken(arg) {
+			out = append(out, arg)
+			continue
+		}
+		if arg == "--help" || arg == "-h" {
+			return target, path, nil, true, nil
+		}
+
+		token, value, hasValue := strings.Cut(arg, "=")
+		f, ok := target.lookupFlag(token)
+		if !ok {
+			return target, path, nil, false, target.unknownFlagError(token)
+		}
+		switch {
+		case f.value == "" && hasValue:
+			return target, path, nil, false, fmt.Errorf("flag --%s does not take a value", f.name)
+		case f.value == "":
+			out = append(out, "--"+f.name)
+		case hasValue:
+			out = append(out, "--"+f.name, value)
+		case i+1 < len(args):
+			out = append(out, "--"+f.name, args[i+1])
+			i++
+		default:
+			return target, path, nil, false, fmt.Errorf("flag --%s needs a value (%s)", f.name, f.value)
+		}
+	}
+	return target, path, out, false, nil
+}
+
+func (c *cliCommand) unknownFlagError(token string) error {
+	var names []string
+	for _, f := range c.flags {
+		names = append(names, f.name)
+	}
+	name := strings.TrimLeft(token, "-")
+	if matches := project.FindSimilar(name, names, 2); len(matches) > 0 {
+		return fmt.Errorf("unknown flag %s (did you mean --%s?)", token, matches[0].Name)
+	}
+	return fmt.Errorf("unknown flag %s", token)
+}
+
+// suggestCommand returns the closest command name to a mistyped one.
+func suggestCommand(commands []cliCommand, name string) string {
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+	if matches := project.FindSimilar(name, names, 2); len(matches) > 0 {
+		return matches[0].Name
+	}
+	return ""
+}
+
+// writeUsage prints the usage of one command or subcommand.
+func (c *cliCommand) writeUsage(w io.Writer, path string) {
+	line := path
+	if len(c.subs) > 0 {
+		line += " <subcommand>"
+	}
+	if c.args != "" {
+		line += " " + c.args
+	}
+	if len(c.flags) > 0 {
+		line += " [flags]"
+	}
+	fmt.Fprintf(w, "usage: %s\n\n%s\n", line, c.summary)
+
+	if len(c.subs) > 0 {
+		fmt.Fprintln(w, "\nSubcommands:")
+		for _, sub := range c.subs {
+			fmt.Fprintf(w, "  %-14s %s\n", strings.TrimSpace(sub.name+" "+sub.args), sub.summary)
+		}
+	}
+	if len(c.flags) > 0 {
+		fmt.Fprintln(w, "\nFlags:")
+		for _, f := range c.flags {
+			fmt.Fprintf(w, "  %-28s %s\n", f.usageName(), f.help)
+		}
+	}
+}
+
+func (f cliFlag) usageName() string {
+	name := "    --" + f.name
+	if f.short != "" {
+		name = "-" + f.short + ", --" + f.name
+	}
+	if f.value != "" {
+		name += " " + f.value
+	}
+	return name
+}
+
+// dispatch validates os.Args, normalizes them for the command parsers, and
+// runs the command. Unknown commands and flags exit 1 with usage.
+func dispatch(cfg store.Config) {
+	commands := commandRegistry()
+	cmd := findCommand(commands, os.Args[1])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n", os.Args[1])
+		if suggestion := suggestCommand(commands, os.Args[1]); suggestion != "" {
+			fmt.Fprintf(os.Stderr, "did you mean: engram %s?\n", suggestion)
+		}
+		fmt.Fprintln(os.Stderr)
+		printUsage()
+		exitFunc(1)
+		return
+	}
+
+	target, path, args, help, err := normalizeArgs(cmd, os.Args[2:])
+	if help {
+		target.writeUsage(os.Stdout, path)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n\n", path, err)
+		target.writeUsage(os.Stderr, path)
+		exitFunc(1)
+		return
+	}
+
+	os.Args = append([]string{os.Args[0], os.Args[1]}, args...)
+	cmd.run(cfg)
+}
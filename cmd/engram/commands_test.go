@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeArgsCanonicalizesFlags(t *testing.T) {
+	commands := commandRegistry()
+
+	tests := []struct {
+		name string
+		cmd  string
+		args []string
+		path string
+		want []string
+	}{
+		{name: "short aliases", cmd: "search", args: []string{"auth", "-t", "bugfix", "-p", "engram", "-n", "5", "-i"}, path: "engram search",
+			want: []string{"auth", "--type", "bugfix", "--project", "engram", "--limit", "5", "--interactive"}},
+		{name: "equals form", cmd: "mcp", args: []string{"--tools=agent,admin", "--project=engram"}, path: "engram mcp",
+			want: []string{"--tools", "agent,admin", "--project", "engram"}},
+		{name: "long alias", cmd: "save", args: []string{"Title", "Body", "--topic-key", "auth/model"}, path: "engram save",
+			want: []string{"Title", "Body", "--topic", "auth/model"}},
+		{name: "subcommand flags", cmd: "projects", args: []string{"consolidate", "--dry-run"}, path: "engram projects consolidate",
+			want: []string{"consolidate", "--dry-run"}},
+		{name: "dash and negative numbers are positional", cmd: "emit", args: []string{"rules", "--out", "-", "-n", "-1"}, path: "engram emit rules",
+			want: []string{"rules", "--out", "-", "--limit", "-1"}},
+		{name: "arguments after -- are left alone", cmd: "run", args: []string{"-p", "engram", "--", "go", "test", "-run", "X", "--count=1"}, path: "engram run",
+			want: []string{"--project", "engram", "--", "go", "test", "-run", "X", "--count=1"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, path, got, help, err := normalizeArgs(findCommand(commands, tc.cmd), tc.args)
+			if err != nil || help {
+				t.Fatalf("normalizeArgs: help=%v err=%v", help, err)
+			}
+			if path != tc.path || strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Fatalf("got path=%q args=%q, want %q %q", path, got, tc.path, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeArgsRejectsBadFlags(t *testing.T) {
+	commands := commandRegistry()
+
+	tests := []struct {
+		cmd  string
+		args []string
+		want string
+	}{
+		{cmd: "search", args: []string{"auth", "--tpye", "bugfix"}, want: "unknown flag --tpye (did you mean --type?)"},
+		{cmd: "sync", args: []string{"--verbose"}, want: "unknown flag --verbose"},
+		{cmd: "timeline", args: []string{"42", "--before"}, want: "flag --before needs a value"},
+		{cmd: "projects", args: []string{"prune", "--dry-run=yes"}, want: "flag --dry-run does not take a value"},
+		{cmd: "projects", args: []string{"list", "--all"}, want: "unknown flag --all"},
+	}
+
+	for _, tc := range tests {
+		_, _, _, _, err := normalizeArgs(findCommand(commands, tc.cmd), tc.args)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s %v: expected %q, got %v", tc.cmd, tc.args, tc.want, err)
+		}
+	}
+}
+
+func TestMainRejectsUnknownFlagWithCommandUsage(t *testing.T) {
+	stubRuntimeHooks(t)
+	stubExitWithPanic(t)
+	t.Setenv("ENGRAM_DATA_DIR", t.TempDir())
+
+	withArgs(t, "engram", "topics", "--projcet", "engram")
+	_, stderr, recovered := captureOutputAndRecover(t, func() { main() })
+	if code, ok := recovered.(exitCode); !ok || code != 1 {
+		t.Fatalf("expected exit 1, got %v", recovered)
+	}
+	if !strings.Contains(stderr, "engram topics: unknown flag --projcet (did you mean --project?)") ||
+		!strings.Contains(stderr, "usage: engram topics [flags]") {
+		t.Fatalf("unexpected stderr: %q", stderr)
+	}
+
+	withArgs(t, "engram", "serch", "auth")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { main() })
+	if _, ok := recovered.(exitCode); !ok || !strings.Contains(stderr, "did you mean: engram search?") {
+		t.Fatalf("expected command suggestion, panic=%v stderr=%q", recovered, stderr)
+	}
+}
+
+func TestMainPrintsPerCommandHelp(t *testing.T) {
+	stubRuntimeHooks(t)
+	t.Setenv("ENGRAM_DATA_DIR", t.TempDir())
+
+	withArgs(t, "engram", "quarantine", "list", "--help")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { main() })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("help failed: panic=%v stderr=%q", recovered, stderr)
+	}
+	for _, want := range []string{"usage: engram quarantine list [flags]", "-p, --project NAME", "-n, --limit N"} {
+		if !strings.Contains(stdout, want) {
+			t.Fatalf("help missing %q: %q", want, stdout)
+		}
+	}
+}
+
+func TestCmdCompletionGeneratesScripts(t *testing.T) {
+	stubExitWithPanic(t)
+
+	want := map[string][]string{
+		"bash": {"complete -o default -F _engram engram", "obsidian-export", "--include-quarantined", "words='list consolidate prune'"},
+		"zsh":  {"#compdef engram", "'quarantine:Review low-confidence passive captures'", "'--dry-run:Preview changes without applying them'"},
+		"fish": {"complete -c engram -n '__fish_seen_subcommand_from search' -l type -s t -r", "-a 'bash zsh fish'"},
+	}
+	for shell, fragments := range want {
+		withArgs(t, "engram", "completion", shell)
+		stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdCompletion() })
+		if recovered != nil || stderr != "" {
+			t.Fatalf("%s completion failed: panic=%v stderr=%q", shell, recovered, stderr)
+		}
+		for _, fragment := range fragments {
+			if !strings.Contains(stdout, fragment) {
+				t.Fatalf("%s completion missing %q", shell, fragment)
+			}
+		}
+	}
+
+	withArgs(t, "engram", "completion", "powershell")
+	_, stderr, recovered := captureOutputAndRecover(t, func() { cmdCompletion() })
+	if _, ok := recovered.(exitCode); !ok || !strings.Contains(stderr, "unsupported shell") {
+		t.Fatalf("expected unsupported shell error, panic=%v stderr=%q", recovered, stderr)
+	}
+}
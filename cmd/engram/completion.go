@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ─── Shell Completion ────────────────────────────────────────────────────────
+//
+// `engram completion bash|zsh|fish` prints a script generated from the
+// command registry. Commands and subcommands complete by name, flags complete
+// when the current word starts with "-", and everything else falls back to
+// file names.
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+func cmdCompletion() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "usage: engram completion <bash|zsh|fish>")
+		exitFunc(1)
+		return
+	}
+	commands := commandRegistry()
+	switch os.Args[2] {
+	case "bash":
+		writeBashCompletion(os.Stdout, commands)
+	case "zsh":
+		writeZshCompletion(os.Stdout, commands)
+	case "fish":
+		writeFishCompletion(os.Stdout, commands)
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported shell: %s (want bash, zsh, or fish)\n", os.Args[2])
+		exitFunc(1)
+	}
+}
+
+func commandNames(commands []cliCommand) string {
+	names := make([]string, 0, len(commands))
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+	return strings.Join(names, " ")
+}
+
+func flagWords(c cliCommand) string {
+	var words []string
+	for _, f := range c.flags {
+		words = append(words, "--"+f.name)
+		if f.short != "" {
+			words = append(words, "-"+f.short)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func choiceWords(c cliCommand) string {
+	if c.choices == nil {
+		return ""
+	}
+	return strings.Join(c.choices(), " ")
+}
+
+// singleQuote quotes s for bash, zsh, and fish alike.
+func singleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+func writeBashCompletion(w io.Writer, commands []cliCommand) {
+	fmt.Fprintln(w, "# bash completion for engram")
+	fmt.Fprintln(w, "# Load with: source <(engram completion bash)")
+	fmt.Fprintln(w, "_engram() {")
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" words=""`)
+	fmt.Fprintln(w, `    if [[ $COMP_CWORD -eq 1 ]]; then`)
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", singleQuote(commandNames(commands)))
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, `    case "${COMP_WORDS[1]}" in`)
+	for _, c := range commands {
+		if len(c.subs) == 0 {
+			if c.flags == nil && c.choices == nil {
+				continue
+			}
+			fmt.Fprintf(w, "        %s)\n", c.name)
+			if choices := choiceWords(c); choices != "" {
+				fmt.Fprintf(w, "            [[ $COMP_CWORD -eq 2 ]] && words=%s\n", singleQuote(choices))
+			}
+			fmt.Fprintf(w, "            [[ $cur == -* ]] && words=%s\n", singleQuote(flagWords(c)))
+			fmt.Fprintln(w, "            ;;")
+			continue
+		}
+		fmt.Fprintf(w, "        %s)\n", c.name)
+		fmt.Fprintf(w, "            if [[ $COMP_CWORD -eq 2 ]]; then words=%s; fi\n", singleQuote(commandNames(c.subs)))
+		fmt.Fprintln(w, `            case "${COMP_WORDS[2]}" in`)
+		for _, sub := range c.subs {
+			if len(sub.flags) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "                %s) [[ $cur == -* ]] && words=%s ;;\n", sub.name, singleQuote(flagWords(sub)))
+		}
+		fmt.Fprintln(w, "            esac")
+		fmt.Fprintln(w, "            ;;")
+	}
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, `    if [[ -n $words ]]; then`)
+	fmt.Fprintln(w, `        COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _engram engram")
+}
+
+func zshDescribed(name, summary string) string {
+	return singleQuote(strings.ReplaceAll(name, ":", `\:`) + ":" + summary)
+}
+
+func writeZshFlags(w io.Writer, indent string, c cliCommand) {
+	if len(c.flags) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%sflags=(", indent)
+	for _, f := range c.flags {
+		fmt.Fprintf(w, " %s", zshDescribed("--"+f.name, f.help))
+		if f.short != "" {
+			fmt.Fprintf(w, " %s", zshDescribed("-"+f.short, f.help))
+		}
+	}
+	fmt.Fprintln(w, " )")
+}
+
+func writeZshCompletion(w io.Writer, commands []cliCommand) {
+	fmt.Fprintln(w, "#compdef engram")
+	fmt.Fprintln(w, "# zsh completion for engram")
+	fmt.Fprintln(w, "# Load with: source <(engram completion zsh)")
+	fmt.Fprintln(w, "_engram() {")
+	fmt.Fprintln(w, "    local -a commands subs flags choices")
+	fmt.Fprint(w, "    commands=(")
+	for _, c := range commands {
+		fmt.Fprintf(w, " %s", zshDescribed(c.name, c.summary))
+	}
+	fmt.Fprintln(w, " )")
+	fmt.Fprintln(w, "    if (( CURRENT == 2 )); then")
+	fmt.Fprintln(w, "        _describe -t commands 'engram command' commands")
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "    case $words[2] in")
+	for _, c := range commands {
+		if len(c.subs) == 0 {
+			if c.flags == nil && c.choices == nil {
+				continue
+			}
+			fmt.Fprintf(w, "        %s)\n", c.name)
+			if choices := choiceWords(c); choices != "" {
+				fmt.Fprintf(w, "            choices=(%s)\n", choices)
+			}
+			writeZshFlags(w, "            ", c)
+			fmt.Fprintln(w, "            ;;")
+			continue
+		}
+		fmt.Fprintf(w, "        %s)\n", c.name)
+		fmt.Fprint(w, "            subs=(")
+		for _, sub := range c.subs {
+			fmt.Fprintf(w, " %s", zshDescribed(sub.name, sub.summary))
+		}
+		fmt.Fprintln(w, " )")
+		fmt.Fprintln(w, "            if (( CURRENT == 3 )); then")
+		fmt.Fprintf(w, "                _describe -t subcommands %s subs\n", singleQuote(c.name+" subcommand"))
+		fmt.Fprintln(w, "                return")
+		fmt.Fprintln(w, "            fi")
+		fmt.Fprintln(w, "            case $words[3] in")
+		for _, sub := range c.subs {
+			if len(sub.flags) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "                %s)\n", sub.name)
+			writeZshFlags(w, "                    ", sub)
+			fmt.Fprintln(w, "                    ;;")
+		}
+		fmt.Fprintln(w, "            esac")
+		fmt.Fprintln(w, "            ;;")
+	}
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, "    if [[ $PREFIX == -* ]] && (( ${#flags} )); then")
+	fmt.Fprintln(w, "        _describe -t flags 'flag' flags")
+	fmt.Fprintln(w, "    elif (( CURRENT == 3 && ${#choices} )); then")
+	fmt.Fprintln(w, "        compadd -a choices")
+	fmt.Fprintln(w, "    else")
+	fmt.Fprintln(w, "        _files")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, `if [[ $funcstack[1] == _engram ]]; then _engram "$@"; else compdef _engram engram; fi`)
+}
+
+func writeFishFlags(w io.Writer, condition string, c cliCommand) {
+	for _, f := range c.flags {
+		line := fmt.Sprintf("complete -c engram -n %s -l %s", singleQuote(condition), f.name)
+		if f.short != "" {
+			line += " -s " + f.short
+		}
+		if f.value != "" {
+			line += " -r"
+		}
+		fmt.Fprintf(w, "%s -d %s\n", line, singleQuote(f.help))
+	}
+}
+
+func writeFishCompletion(w io.Writer, commands []cliCommand) {
+	fmt.Fprintln(w, "# fish completion for engram")
+	fmt.Fprintln(w, "# Load with: engram completion fish | source")
+	for _, c := range commands {
+		fmt.Fprintf(w, "complete -c engram -n '__fish_use_subcommand' -f -a %s -d %s\n", c.name, singleQuote(c.summary))
+	}
+	for _, c := range commands {
+		condition := "__fish_seen_subcommand_from " + c.name
+		if choices := choiceWords(c); choices != "" {
+			fmt.Fprintf(w, "complete -c engram -n %s -f -a %s\n", singleQuote(condition), singleQuote(choices))
+		}
+		writeFishFlags(w, condition, c)
+		if len(c.subs) == 0 {
+			continue
+		}
+		subNames := commandNames(c.subs)
+		for _, sub := range c.subs {
+			fmt.Fprintf(w, "complete -c engram -n %s -f -a %s -d %s\n",
+				singleQuote(condition+"; and not __fish_seen_subcommand_from "+subNames), sub.name, singleQuote(sub.summary))
+			writeFishFlags(w, condition+"; and __fish_seen_subcommand_from "+sub.name, sub)
+		}
+	}
+}
@@ -1,18 +1,29 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/Gentleman-Programming/engram/internal/config"
 	"github.com/Gentleman-Programming/engram/internal/mcp"
+	"github.com/Gentleman-Programming/engram/internal/notify"
+	"github.com/Gentleman-Programming/engram/internal/replicate"
 	engramsrv "github.com/Gentleman-Programming/engram/internal/server"
+	"github.com/Gentleman-Programming/engram/internal/service"
 	"github.com/Gentleman-Programming/engram/internal/setup"
 	"github.com/Gentleman-Programming/engram/internal/store"
 	engramsync "github.com/Gentleman-Programming/engram/internal/sync"
@@ -99,7 +110,15 @@ func stubRuntimeHooks(t *testing.T) {
 	oldSyncImport := syncImport
 	oldSyncExport := syncExport
 	oldCheckForUpdates := checkForUpdates
+	oldSetupLogging := setupLogging
+	oldFindConfigFile := findConfigFile
+	oldNewSearchPicker := newSearchPicker
+	oldClipboardWrite := clipboardWrite
 
+	findConfigFile = func() string { return "" }
+	setupLogging = func(string) (*slog.Logger, io.Closer, error) {
+		return slog.New(slog.NewTextHandler(io.Discard, nil)), io.NopCloser(nil), nil
+	}
 	storeNew = store.New
 	newHTTPServer = func(s *store.Store, _ int) *engramsrv.Server { return engramsrv.New(s, 0) }
 	startHTTP = func(_ *engramsrv.Server) error { return nil }
@@ -125,7 +144,7 @@ func stubRuntimeHooks(t *testing.T) {
 	storeTimeline = func(s *store.Store, observationID int64, before, after int) (*store.TimelineResult, error) {
 		return s.Timeline(observationID, before, after)
 	}
-	storeFormatContext = func(s *store.Store, project, scope string) (string, error) {
+	storeFormatContext = func(s *store.Store, project, scope string, _ store.ContextOptions) (string, error) {
 		return s.FormatContext(project, scope)
 	}
 	storeStats = func(s *store.Store) (*store.Stats, error) { return s.Stats() }
@@ -166,6 +185,10 @@ func stubRuntimeHooks(t *testing.T) {
 		syncImport = oldSyncImport
 		syncExport = oldSyncExport
 		checkForUpdates = oldCheckForUpdates
+		setupLogging = oldSetupLogging
+		findConfigFile = oldFindConfigFile
+		newSearchPicker = oldNewSearchPicker
+		clipboardWrite = oldClipboardWrite
 	})
 }
 
@@ -629,11 +652,11 @@ func TestCmdSyncAdditionalBranches(t *testing.T) {
 	})
 }
 
-func TestCmdImportStoreImportFailure(t *testing.T) {
+func TestCmdImportCreatesPlaceholderSessions(t *testing.T) {
 	stubExitWithPanic(t)
 	cfg := testConfig(t)
 
-	badImport := filepath.Join(t.TempDir(), "bad-import.json")
+	badImport := filepath.Join(t.TempDir(), "partial-import.json")
 	badJSON := `{
 		"version":"0.1.0",
 		"exported_at":"2026-01-01T00:00:00Z",
@@ -646,12 +669,56 @@ func TestCmdImportStoreImportFailure(t *testing.T) {
 	}
 
 	withArgs(t, "engram", "import", badImport)
-	_, stderr, recovered := captureOutputAndRecover(t, func() { cmdImport(cfg) })
-	if _, ok := recovered.(exitCode); !ok {
-		t.Fatalf("expected fatal exit, got %v", recovered)
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdImport(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("import failed: panic=%v stderr=%q", recovered, stderr)
+	}
+	if !strings.Contains(stdout, "Placeholder sessions: 1") {
+		t.Fatalf("expected the missing session to be recreated, got %q", stdout)
+	}
+}
+
+func TestCmdImportFromCursor(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+
+	user := t.TempDir()
+	ws := filepath.Join(user, "workspaceStorage", "abc")
+	if err := os.MkdirAll(ws, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "workspace.json"), []byte(`{"folder":"file:///nonexistent/billing-api"}`), 0o644); err != nil {
+		t.Fatalf("write workspace.json: %v", err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(ws, "state.vscdb"))
+	if err != nil {
+		t.Fatalf("open state.vscdb: %v", err)
+	}
+	chat := `{"tabs":[{"tabId":"t1","chatTitle":"Queue","lastSendTime":1735725600000,"bubbles":[{"type":"user","text":"Pick a queue"},{"type":"ai","text":"We'll use Redis streams instead of SQS because the team already runs Redis."}]}]}`
+	if _, err := db.Exec(`CREATE TABLE ItemTable (key TEXT, value BLOB); INSERT INTO ItemTable VALUES ('workbench.panel.aichat.view.aichat.chatdata', ?)`, chat); err != nil {
+		t.Fatalf("seed state.vscdb: %v", err)
+	}
+	db.Close()
+
+	withArgs(t, "engram", "import", "--from", "cursor", "--dir", user)
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdImport(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "Imported 1 cursor conversations from 1 workspaces") || !strings.Contains(stdout, "Observations: 1 (0 skipped") {
+		t.Fatalf("expected the chat imported, got panic=%v stdout=%q stderr=%q", recovered, stdout, stderr)
+	}
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	obs, err := s.RecentObservations("billing-api", "", 10)
+	s.Close()
+	if err != nil || len(obs) != 1 || obs[0].Type != "decision" || obs[0].Source == nil || *obs[0].Source != "import:cursor" {
+		t.Fatalf("expected the decision saved with its source, got %+v err=%v", obs, err)
 	}
-	if !strings.Contains(stderr, "import observation") {
-		t.Fatalf("unexpected stderr: %q", stderr)
+
+	withArgs(t, "engram", "import", "--from", "vim")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdImport(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "unknown app") {
+		t.Fatalf("expected an unknown app to be refused, got %v %q", recovered, stderr)
 	}
 }
 
@@ -677,6 +744,160 @@ func TestCmdSearchAndSaveDanglingFlags(t *testing.T) {
 	}
 }
 
+func TestCmdSearchAllIncludesPrompts(t *testing.T) {
+	cfg := testConfig(t)
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if _, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "decision", Title: "Queue retries", Content: "retry the queue", Project: "engram"}); err != nil {
+		t.Fatalf("AddObservation: %v", err)
+	}
+	if _, err := s.AddPrompt(store.AddPromptParams{SessionID: "s1", Content: "why does the queue stall?", Project: "engram"}); err != nil {
+		t.Fatalf("AddPrompt: %v", err)
+	}
+	_ = s.Close()
+
+	withArgs(t, "engram", "search", "queue", "--all")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("search --all failed, panic=%v stderr=%q", recovered, stderr)
+	}
+	if !strings.Contains(stdout, "Found 2 memories and prompts") || !strings.Contains(stdout, "observation #") || !strings.Contains(stdout, "prompt #") {
+		t.Fatalf("unexpected search --all output: %q", stdout)
+	}
+}
+
+func TestCmdSearchOutputCSVAndTSV(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	id, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "bugfix", Title: "Fix race", Content: "guard the map", Project: "engram"})
+	if err != nil {
+		t.Fatalf("AddObservation: %v", err)
+	}
+	_ = s.Close()
+
+	withArgs(t, "engram", "search", "race", "--output", "csv")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("search --output csv failed, panic=%v stderr=%q", recovered, stderr)
+	}
+	header := "id,type,title,project,scope,created_at,content\n"
+	if !strings.HasPrefix(stdout, header) || !strings.Contains(stdout, fmt.Sprintf("%d,bugfix,Fix race,engram,project,", id)) {
+		t.Fatalf("unexpected csv output: %q", stdout)
+	}
+
+	withArgs(t, "engram", "search", "race", "--output", "tsv")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if !strings.Contains(stdout, fmt.Sprintf("%d\tbugfix\tFix race\t", id)) {
+		t.Fatalf("unexpected tsv output: %q", stdout)
+	}
+
+	withArgs(t, "engram", "search", "race", "--output", "xml")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "unknown output") {
+		t.Fatalf("expected an unknown output to exit 1: stderr=%q recovered=%v", stderr, recovered)
+	}
+}
+
+func TestCmdSaveWithTemplate(t *testing.T) {
+	cfg := testConfig(t)
+
+	withArgs(t, "engram", "save", "Use WAL mode", "## Decision\nEnable WAL on open", "--template", "adr")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSave(cfg) })
+	if recovered != nil || stderr != "" || !strings.Contains(stdout, "(architecture)") {
+		t.Fatalf("save --template failed: stdout=%q stderr=%q panic=%v", stdout, stderr, recovered)
+	}
+
+	withArgs(t, "engram", "save", "Checkout outage", "--template", "incident", "--type", "incident")
+	if stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSave(cfg) }); recovered != nil || !strings.Contains(stdout, "(incident)") {
+		t.Fatalf("save --template without content failed: stdout=%q stderr=%q panic=%v", stdout, stderr, recovered)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	obs, err := s.RecentObservations("", "", 10)
+	if err != nil || len(obs) != 2 {
+		t.Fatalf("expected two saves, got %d err=%v", len(obs), err)
+	}
+	for _, o := range obs {
+		switch o.Title {
+		case "Use WAL mode":
+			if o.TopicKey == nil || *o.TopicKey != "architecture/use-wal-mode" || !strings.HasPrefix(o.Content, "## Status\n\n## Context\n\n## Decision\nEnable WAL on open") {
+				t.Fatalf("unexpected adr save %+v", o)
+			}
+		case "Checkout outage":
+			if o.TopicKey == nil || *o.TopicKey != "bug/checkout-outage" || !strings.HasPrefix(o.Content, "## Summary\n\n## Impact") {
+				t.Fatalf("unexpected incident save %+v", o)
+			}
+		}
+	}
+}
+
+func TestCmdRunSavesCommandOutcomeAndExitsWithItsCode(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+
+	script := `echo building; echo "Root cause: the cache key ignored the tenant ID" >&2; exit 3`
+	withArgs(t, "engram", "run", "--project", "engram", "--", "sh", "-c", script)
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdRun(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 3 {
+		t.Fatalf("expected engram run to exit 3 like the command, got %v (stderr=%q)", recovered, stderr)
+	}
+	if !strings.Contains(stdout, "building") || !strings.Contains(stderr, "Root cause") || !strings.Contains(stderr, "saved run #") {
+		t.Fatalf("expected the output passed through and the run saved, stdout=%q stderr=%q", stdout, stderr)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	obs, err := s.RecentObservations("engram", "", 10)
+	if err != nil {
+		t.Fatalf("RecentObservations: %v", err)
+	}
+	var command, finding bool
+	for _, o := range obs {
+		switch {
+		case o.Type == "command":
+			command = strings.HasSuffix(o.Title, "→ exit 3") && strings.Contains(o.Content, "**Exit code**: 3") && strings.Contains(o.Content, "building")
+		case strings.Contains(o.Content, "cache key ignored the tenant ID"):
+			finding = true
+		}
+	}
+	if !command || !finding {
+		t.Fatalf("expected a command observation and a captured finding, got %+v", obs)
+	}
+
+	withArgs(t, "engram", "run")
+	if _, stderr, recovered := captureOutputAndRecover(t, func() { cmdRun(cfg) }); recovered == nil || !strings.Contains(stderr, "usage: engram run") {
+		t.Fatalf("expected usage without a command, got %v %q", recovered, stderr)
+	}
+}
+
+func TestTailBufferKeepsTheEnd(t *testing.T) {
+	b := &tailBuffer{limit: 8}
+	fmt.Fprint(b, "0123456789")
+	fmt.Fprint(b, "ab")
+	if b.String() != "456789ab" || b.dropped != 4 {
+		t.Fatalf("got %q dropped=%d", b.String(), b.dropped)
+	}
+}
+
 func TestCmdSetupHyphenArgFallsBackToInteractive(t *testing.T) {
 	stubRuntimeHooks(t)
 	stubExitWithPanic(t)
@@ -729,6 +950,135 @@ func TestCmdStatsNoProjectsYet(t *testing.T) {
 	}
 }
 
+func TestCmdStatsShowsQuotaUsageAndPruneSuggestion(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Quotas = map[string]store.Quota{"billing": {MaxObservations: 2}}
+	mustSeedObservation(t, cfg, "q-1", "billing", "tool_use", "run", "go test ./...", "project")
+
+	withArgs(t, "engram", "stats")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdStats(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("stats failed: panic=%v stderr=%q", recovered, stderr)
+	}
+	if !strings.Contains(stdout, "billing:         1/2 observations (50%)") || strings.Contains(stdout, "prune:") {
+		t.Fatalf("expected quota usage without prune hint, got: %q", stdout)
+	}
+
+	mustSeedObservation(t, cfg, "q-1", "billing", "tool_use", "run again", "go vet ./...", "project")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdStats(cfg) })
+	if !strings.Contains(stdout, "2/2 observations (100%)") || !strings.Contains(stdout, "prune: 2 tool_use observations take") {
+		t.Fatalf("expected prune suggestion near the limit, got: %q", stdout)
+	}
+}
+
+func TestCmdStatsWatch(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+
+	oldNewStatsWatch, oldNewTeaProgram, oldRunTeaProgram := newStatsWatch, newTeaProgram, runTeaProgram
+	t.Cleanup(func() {
+		newStatsWatch, newTeaProgram, runTeaProgram = oldNewStatsWatch, oldNewTeaProgram, oldRunTeaProgram
+	})
+	var gotInterval time.Duration
+	newStatsWatch = func(s *store.Store, interval time.Duration) tea.Model {
+		gotInterval = interval
+		return tui.NewStatsWatch(s, interval)
+	}
+	newTeaProgram = func(tea.Model, ...tea.ProgramOption) *tea.Program { return &tea.Program{} }
+	runTeaProgram = func(*tea.Program) (tea.Model, error) { return nil, nil }
+
+	withArgs(t, "engram", "stats", "--watch", "--interval", "5s")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdStats(cfg) })
+	if recovered != nil || stderr != "" || stdout != "" {
+		t.Fatalf("stats --watch failed: panic=%v stdout=%q stderr=%q", recovered, stdout, stderr)
+	}
+	if gotInterval != 5*time.Second {
+		t.Fatalf("expected a 5s interval, got %s", gotInterval)
+	}
+
+	withArgs(t, "engram", "stats", "--watch", "--interval", "10ms")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdStats(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "at least 500ms") {
+		t.Fatalf("expected a too-short interval to fail, got %v %q", recovered, stderr)
+	}
+
+	withArgs(t, "engram", "stats", "--interval", "5s")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdStats(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "--interval requires --watch") {
+		t.Fatalf("expected --interval without --watch to fail, got %v %q", recovered, stderr)
+	}
+}
+
+func TestCmdReplicate(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	mustSeedObservation(t, cfg, "r-1", "billing", "decision", "Retry webhooks", "with backoff", "project")
+
+	var gotURL string
+	var out strings.Builder
+	oldExecutor := newReplicaExecutor
+	newReplicaExecutor = func(url, psql string) replicate.Executor {
+		gotURL = url
+		return replicate.Writer{W: &out}
+	}
+	t.Cleanup(func() { newReplicaExecutor = oldExecutor })
+
+	withArgs(t, "engram", "replicate", "--to", "postgres://bi:pw@db/memory", "--batch", "10")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdReplicate(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("replicate failed: panic=%v stderr=%q", recovered, stderr)
+	}
+	if gotURL != "postgres://bi:pw@db/memory" || !strings.Contains(out.String(), "'Retry webhooks'") {
+		t.Fatalf("executor got url=%q sql=%q", gotURL, out.String())
+	}
+	if !strings.Contains(stdout, "Replicated to postgres://bi@db/memory: 1 sessions, 1 observations, 0 prompts") {
+		t.Fatalf("unexpected output: %q", stdout)
+	}
+
+	withArgs(t, "engram", "replicate", "--to", "postgres://bi:pw@db/memory", "--dry-run", "--full")
+	stdout, _, recovered = captureOutputAndRecover(t, func() { cmdReplicate(cfg) })
+	if recovered != nil || !strings.HasPrefix(stdout, "BEGIN;\nCREATE TABLE IF NOT EXISTS engram_sessions") {
+		t.Fatalf("dry run should print SQL: panic=%v stdout=%q", recovered, stdout)
+	}
+
+	withArgs(t, "engram", "replicate", "--to", "mysql://db/memory")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdReplicate(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "unsupported target") {
+		t.Fatalf("expected unsupported target error, panic=%v stderr=%q", recovered, stderr)
+	}
+}
+
+func TestCmdSyncPruneRemote(t *testing.T) {
+	var gotAge time.Duration
+	var gotDryRun bool
+	old := syncPrune
+	syncPrune = func(_ *engramsync.Syncer, olderThan time.Duration, dryRun bool) (*engramsync.PruneResult, error) {
+		gotAge, gotDryRun = olderThan, dryRun
+		return &engramsync.PruneResult{Pruned: []string{"a1b2c3d4"}, Retained: 3, NotImported: 1, DryRun: dryRun}, nil
+	}
+	t.Cleanup(func() { syncPrune = old })
+	withCwd(t, t.TempDir())
+	cfg := testConfig(t)
+
+	withArgs(t, "engram", "sync", "--prune-remote", "--older-than", "7", "--dry-run")
+	stdout, stderr := captureOutput(t, func() { cmdSync(cfg) })
+	if stderr != "" {
+		t.Fatalf("expected no stderr, got: %q", stderr)
+	}
+	if gotAge != 7*24*time.Hour || !gotDryRun {
+		t.Fatalf("unexpected prune args: age=%v dryRun=%v", gotAge, gotDryRun)
+	}
+	if !strings.Contains(stdout, "Would prune 1 chunk(s) older than 7 days: a1b2c3d4") || !strings.Contains(stdout, "1 old chunk(s) not imported") {
+		t.Fatalf("unexpected prune output: %q", stdout)
+	}
+
+	withArgs(t, "engram", "sync", "--prune-remote")
+	stdout, _ = captureOutput(t, func() { cmdSync(cfg) })
+	if gotAge != defaultPruneDays*24*time.Hour || gotDryRun || !strings.Contains(stdout, "Commit the removal") {
+		t.Fatalf("unexpected default prune: age=%v output=%q", gotAge, stdout)
+	}
+}
+
 func TestCmdSyncImportEmptyAndMixedChunks(t *testing.T) {
 	stubExitWithPanic(t)
 
@@ -847,7 +1197,7 @@ func TestCommandErrorSeamsAndUncoveredBranches(t *testing.T) {
 
 	t.Run("context seam error", func(t *testing.T) {
 		withArgs(t, "engram", "context")
-		storeFormatContext = func(*store.Store, string, string) (string, error) {
+		storeFormatContext = func(*store.Store, string, string, store.ContextOptions) (string, error) {
 			return "", errors.New("forced context error")
 		}
 		_, stderr, recovered := captureOutputAndRecover(t, func() { cmdContext(cfg) })
@@ -1009,3 +1359,1427 @@ func TestCmdMCP(t *testing.T) {
 		assertFatal(t, stderr, recovered, "stdio failed")
 	})
 }
+
+func TestParseServeMounts(t *testing.T) {
+	dir := t.TempDir()
+	mountsFile := filepath.Join(dir, "mounts.json")
+	if err := os.WriteFile(mountsFile, []byte(`{"zed":"/data/zed","bob":"/data/bob"}`), 0644); err != nil {
+		t.Fatalf("write mounts file: %v", err)
+	}
+
+	mounts, err := parseServeMounts([]string{"8080", "--data-dir", "/srv/.alice", "--data-dir=carol=/srv/c", "--mounts", mountsFile})
+	if err != nil {
+		t.Fatalf("parseServeMounts: %v", err)
+	}
+	want := []serveMount{
+		{Name: "alice", DataDir: "/srv/.alice"},
+		{Name: "carol", DataDir: "/srv/c"},
+		{Name: "bob", DataDir: "/data/bob"},
+		{Name: "zed", DataDir: "/data/zed"},
+	}
+	if len(mounts) != len(want) {
+		t.Fatalf("mounts=%+v want=%+v", mounts, want)
+	}
+	for i := range want {
+		if mounts[i] != want[i] {
+			t.Fatalf("mount[%d]=%+v want=%+v", i, mounts[i], want[i])
+		}
+	}
+
+	if mounts, err := parseServeMounts([]string{"8080"}); err != nil || len(mounts) != 0 {
+		t.Fatalf("expected no mounts without flags, got %+v err=%v", mounts, err)
+	}
+	if _, err := parseServeMounts([]string{"--data-dir"}); err == nil {
+		t.Fatalf("expected error for dangling --data-dir")
+	}
+	for _, args := range [][]string{
+		{"--data-dir", "a/b=/x"},
+		{"--data-dir", "..=/x"},
+		{"--data-dir", "=/x"},
+		{"--data-dir", "/"},
+		{"--data-dir", "a b=/x"},
+	} {
+		if _, err := parseServeMounts(args); err == nil || !strings.Contains(err.Error(), "mount name") {
+			t.Fatalf("expected %v to be rejected as an invalid mount name, got %v", args, err)
+		}
+	}
+	if _, err := parseServeMounts([]string{"--data-dir", "/srv/a/data", "--data-dir", "/srv/b/data"}); err == nil || !strings.Contains(err.Error(), `"data" is used by both`) {
+		t.Fatalf("expected a repeated default name to be rejected, got %v", err)
+	}
+	if _, err := parseServeMounts([]string{"--mounts", filepath.Join(dir, "missing.json")}); err == nil {
+		t.Fatalf("expected error for missing mounts file")
+	}
+}
+
+func TestCmdServeMultiMountsEachDataDir(t *testing.T) {
+	cfg := testConfig(t)
+	stubRuntimeHooks(t)
+	stubExitWithPanic(t)
+
+	oldNewMulti := newMultiHTTPServer
+	oldStartMulti := startMultiHTTP
+	t.Cleanup(func() {
+		newMultiHTTPServer = oldNewMulti
+		startMultiHTTP = oldStartMulti
+	})
+
+	alice := filepath.Join(t.TempDir(), "alice")
+	bob := filepath.Join(t.TempDir(), "bob")
+	withArgs(t, "engram", "serve", "9000", "--data-dir", alice, "--data-dir", "b="+bob)
+
+	var seenNames []string
+	seenPort := -1
+	newMultiHTTPServer = func(mounts []engramsrv.Mount, port int) (*engramsrv.MultiServer, error) {
+		seenPort = port
+		for _, m := range mounts {
+			seenNames = append(seenNames, m.Name)
+		}
+		return engramsrv.NewMulti(mounts, 0)
+	}
+	startMultiHTTP = func(*engramsrv.MultiServer) error { return nil }
+
+	_, _, recovered := captureOutputAndRecover(t, func() { cmdServe(cfg) })
+	if recovered != nil {
+		t.Fatalf("expected no panic, got %v", recovered)
+	}
+	if seenPort != 9000 || strings.Join(seenNames, ",") != "alice,b" {
+		t.Fatalf("unexpected multi serve wiring: port=%d names=%v", seenPort, seenNames)
+	}
+	for _, dir := range []string{alice, bob} {
+		if _, err := os.Stat(filepath.Join(dir, "engram.db")); err != nil {
+			t.Fatalf("expected store created in %s: %v", dir, err)
+		}
+	}
+}
+
+func TestCmdServeTenantsFromConfig(t *testing.T) {
+	cfg := testConfig(t)
+	stubRuntimeHooks(t)
+	stubExitWithPanic(t)
+	t.Setenv("ENGRAM_HTTP_TOKEN", "")
+	t.Setenv("TOKEN_BOB", "tok-b")
+
+	oldNewMulti := newMultiHTTPServer
+	oldStartMulti := startMultiHTTP
+	t.Cleanup(func() {
+		newMultiHTTPServer = oldNewMulti
+		startMultiHTTP = oldStartMulti
+	})
+
+	root := t.TempDir()
+	path := filepath.Join(root, ".engram.toml")
+	body := fmt.Sprintf("[server.tenants.alice]\ntoken = \"tok-a\"\ndata_dir = %q\n\n[server.tenants.bob]\ntoken_env = \"TOKEN_BOB\"\ndata_dir = %q\n",
+		filepath.Join(root, "alice"), filepath.Join(root, "bob"))
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	findConfigFile = func() string { return path }
+	withArgs(t, "engram", "serve", "9000")
+
+	var handler http.Handler
+	newMultiHTTPServer = func(mounts []engramsrv.Mount, port int) (*engramsrv.MultiServer, error) {
+		return engramsrv.NewMulti(mounts, 0)
+	}
+	startMultiHTTP = func(ms *engramsrv.MultiServer) error {
+		handler = ms.Handler()
+		return nil
+	}
+
+	_, _, recovered := captureOutputAndRecover(t, func() { cmdServe(cfg) })
+	if recovered != nil {
+		t.Fatalf("expected no panic, got %v", recovered)
+	}
+	for token, want := range map[string]int{"tok-a": http.StatusOK, "tok-b": http.StatusOK, "": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Fatalf("token %q: expected %d, got %d", token, want, rec.Code)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "bob", "engram.db")); err != nil {
+		t.Fatalf("expected bob's store created: %v", err)
+	}
+
+	withArgs(t, "engram", "serve", "--data-dir", filepath.Join(root, "carol"))
+	_, stderr, recovered := captureOutputAndRecover(t, func() { cmdServe(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "[server.tenants]") {
+		t.Fatalf("expected --data-dir with tenants to be refused, got %v %q", recovered, stderr)
+	}
+}
+
+func TestApplyConfigFileOverlaysDedupeSettings(t *testing.T) {
+	stubRuntimeHooks(t)
+
+	path := filepath.Join(t.TempDir(), ".engram.toml")
+	if err := os.WriteFile(path, []byte("[dedupe]\nwindow = \"45m\"\n\n[dedupe.types.note]\nstrategy = \"off\"\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	findConfigFile = func() string { return path }
+
+	cfg := testConfig(t)
+	if err := applyConfigFile(&cfg); err != nil {
+		t.Fatalf("applyConfigFile: %v", err)
+	}
+	if cfg.DedupeWindow != 45*time.Minute {
+		t.Fatalf("expected dedupe window 45m, got %v", cfg.DedupeWindow)
+	}
+	if cfg.DedupeByType["note"].Strategy != store.DedupeOff {
+		t.Fatalf("expected note dedupe off, got %+v", cfg.DedupeByType["note"])
+	}
+
+	if err := os.WriteFile(path, []byte("[dedupe]\nwindow = \"never\"\n"), 0644); err != nil {
+		t.Fatalf("rewrite config: %v", err)
+	}
+	if err := applyConfigFile(&cfg); err == nil {
+		t.Fatalf("expected invalid window error")
+	}
+}
+
+func TestServeMiddlewareAppliesCORSAndTokenAuth(t *testing.T) {
+	stubRuntimeHooks(t)
+	t.Setenv("ENGRAM_CORS_ORIGINS", "")
+	t.Setenv("ENGRAM_HTTP_TOKEN", "")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	middleware, err := serveMiddleware(logger)
+	if err != nil || len(middleware) != 1 {
+		t.Fatalf("expected only the request logger without config, got %d, %v", len(middleware), err)
+	}
+
+	path := filepath.Join(t.TempDir(), ".engram.toml")
+	if err := os.WriteFile(path, []byte("[server]\nauth_token = \"from-file\"\n\n[server.cors]\nallowed_origins = [\"http://file.local\"]\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	findConfigFile = func() string { return path }
+	t.Setenv("ENGRAM_CORS_ORIGINS", "http://a.local, http://b.local")
+	t.Setenv("ENGRAM_HTTP_TOKEN", "from-env")
+
+	middleware, err = serveMiddleware(logger)
+	if err != nil || len(middleware) != 3 {
+		t.Fatalf("expected logger, cors and auth middleware, got %d, %v", len(middleware), err)
+	}
+
+	s, err := store.New(testConfig(t))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	srv := engramsrv.New(s, 0)
+	srv.Use(middleware...)
+	h := srv.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("Origin", "http://b.local")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "http://b.local" {
+		t.Fatalf("expected env origins to override the file, got %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("Authorization", "Bearer from-file")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected env token to override the file, got %d", rec.Code)
+	}
+
+	if err := os.WriteFile(path, []byte("[server.cors]\nallowed_origins = [\"*\"]\nallow_credentials = true\n"), 0644); err != nil {
+		t.Fatalf("rewrite config: %v", err)
+	}
+	t.Setenv("ENGRAM_CORS_ORIGINS", "")
+	if _, err := serveMiddleware(logger); err == nil {
+		t.Fatalf("expected wildcard origin with credentials to be rejected")
+	}
+}
+
+func TestCmdStatusReportsRunningServer(t *testing.T) {
+	stubRuntimeHooks(t)
+	stubExitWithPanic(t)
+	t.Setenv("ENGRAM_HTTP_TOKEN", "secret")
+
+	cfg := testConfig(t)
+	mustSeedObservation(t, cfg, "s1", "engram", "decision", "status", "seed a write", "project")
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	srv := engramsrv.New(s, 0)
+	srv.Use(engramsrv.TokenAuth("secret"))
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	withArgs(t, "engram", "status", "--url", ts.URL+"/")
+	stdout, stderr, recovered := captureOutputAndRecover(t, cmdStatus)
+	if recovered != nil {
+		t.Fatalf("expected ready server, got exit %v stderr=%q", recovered, stderr)
+	}
+	for _, want := range []string{"URL:          " + ts.URL, "Status:       ok (ready)", "Database:     reachable", "journal_mode=wal", "Last write:"} {
+		if !strings.Contains(stdout, want) {
+			t.Fatalf("expected %q in output, got %q", want, stdout)
+		}
+	}
+
+	_ = s.Close()
+	_, _, recovered = captureOutputAndRecover(t, cmdStatus)
+	if code, ok := recovered.(exitCode); !ok || code != 1 {
+		t.Fatalf("expected exit 1 for a not-ready server, got %v", recovered)
+	}
+
+	ts.Close()
+	_, stderr, recovered = captureOutputAndRecover(t, cmdStatus)
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "not reachable") {
+		t.Fatalf("expected exit 1 for an unreachable server, got %v stderr=%q", recovered, stderr)
+	}
+}
+
+type fakeServiceManager struct {
+	spec    service.Spec
+	status  service.Status
+	started bool
+}
+
+func (m *fakeServiceManager) Install(spec service.Spec) (string, error) {
+	m.spec = spec
+	return "/units/engram.service", nil
+}
+func (m *fakeServiceManager) Uninstall() error                { return nil }
+func (m *fakeServiceManager) Start() error                    { m.started = true; return nil }
+func (m *fakeServiceManager) Stop() error                     { return nil }
+func (m *fakeServiceManager) Status() (service.Status, error) { return m.status, nil }
+
+func TestCmdServiceInstallUsesConfiguredPortAndDataDir(t *testing.T) {
+	stubRuntimeHooks(t)
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+
+	configPath := filepath.Join(t.TempDir(), ".engram.toml")
+	if err := os.WriteFile(configPath, []byte("[server]\nport = 9123\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	findConfigFile = func() string { return configPath }
+	t.Setenv("ENGRAM_PORT", "")
+
+	mgr := &fakeServiceManager{}
+	oldNew, oldExe := newServiceManager, osExecutable
+	newServiceManager = func(home, dataDir string) (service.Manager, error) { return mgr, nil }
+	osExecutable = func() (string, error) { return "/usr/local/bin/engram", nil }
+	t.Cleanup(func() { newServiceManager, osExecutable = oldNew, oldExe })
+
+	withArgs(t, "engram", "service", "install")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdService(cfg) })
+	if recovered != nil {
+		t.Fatalf("install exited: %v stderr=%q", recovered, stderr)
+	}
+	if mgr.spec.Port != 9123 || mgr.spec.DataDir != cfg.DataDir || mgr.spec.Executable != "/usr/local/bin/engram" {
+		t.Fatalf("unexpected spec: %+v", mgr.spec)
+	}
+	if !strings.Contains(stdout, "Installed engram service: /units/engram.service") || !strings.Contains(stdout, "port 9123") {
+		t.Fatalf("unexpected output: %q", stdout)
+	}
+
+	withArgs(t, "engram", "service", "start")
+	if _, _, recovered := captureOutputAndRecover(t, func() { cmdService(cfg) }); recovered != nil || !mgr.started {
+		t.Fatalf("expected start to succeed, got %v", recovered)
+	}
+
+	mgr.status = service.Status{Installed: true, Path: "/units/engram.service", Detail: "failed"}
+	withArgs(t, "engram", "service", "status")
+	stdout, _, recovered = captureOutputAndRecover(t, func() { cmdService(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stdout, "engram service: stopped") {
+		t.Fatalf("expected exit 1 for a stopped service, got %v %q", recovered, stdout)
+	}
+}
+
+func TestCmdSearchInteractivePrintsOrCopiesSelection(t *testing.T) {
+	stubRuntimeHooks(t)
+	cfg := testConfig(t)
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	id, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "bugfix", Title: "picked title", Content: "full picked content", Project: "engram"})
+	if err != nil {
+		t.Fatalf("AddObservation: %v", err)
+	}
+	_ = s.Close()
+
+	var gotQuery string
+	var gotOpts store.SearchOptions
+	newSearchPicker = func(s *store.Store, opts store.SearchOptions, query string) tea.Model {
+		gotQuery, gotOpts = query, opts
+		return tui.NewPicker(s, opts, query)
+	}
+	pick := func(action tui.PickAction) {
+		runTeaProgram = func(*tea.Program) (tea.Model, error) {
+			return tui.Picker{Selected: &store.SearchResult{Observation: store.Observation{ID: id}}, Action: action}, nil
+		}
+	}
+
+	pick(tui.PickPrint)
+	withArgs(t, "engram", "search", "-i", "picked", "--type", "bugfix")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("unexpected failure: recovered=%v stderr=%q", recovered, stderr)
+	}
+	if gotQuery != "picked" || gotOpts.Type != "bugfix" {
+		t.Fatalf("expected query and filters forwarded to picker, got %q %+v", gotQuery, gotOpts)
+	}
+	if !strings.Contains(stdout, "picked title") || !strings.Contains(stdout, "full picked content") {
+		t.Fatalf("expected full observation printed, got %q", stdout)
+	}
+
+	var copied string
+	clipboardWrite = func(text string) error { copied = text; return nil }
+	pick(tui.PickCopyID)
+	withArgs(t, "engram", "search", "--interactive")
+	stdout, _, recovered = captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if recovered != nil || copied != strconv.FormatInt(id, 10) || !strings.Contains(stdout, "Copied observation") {
+		t.Fatalf("expected id copied, got copied=%q stdout=%q recovered=%v", copied, stdout, recovered)
+	}
+
+	clipboardWrite = func(string) error { return errors.New("no clipboard") }
+	stdout, stderr, _ = captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if !strings.Contains(stderr, "could not copy") || strings.TrimSpace(stdout) != strconv.FormatInt(id, 10) {
+		t.Fatalf("expected clipboard fallback to print id, got stdout=%q stderr=%q", stdout, stderr)
+	}
+
+	runTeaProgram = func(*tea.Program) (tea.Model, error) { return tui.Picker{}, nil }
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if stdout != "" {
+		t.Fatalf("expected cancelled picker to print nothing, got %q", stdout)
+	}
+}
+
+func TestCmdSessionMergeAndSplit(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	for _, id := range []string{"s-main", "s-dup"} {
+		if err := s.CreateSession(id, "engram", "/tmp"); err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+	}
+	var ids []int64
+	for _, title := range []string{"one", "two"} {
+		id, err := s.AddObservation(store.AddObservationParams{SessionID: "s-dup", Type: "bugfix", Title: title, Content: title, Project: "engram"})
+		if err != nil {
+			t.Fatalf("AddObservation: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	_ = s.Close()
+
+	withArgs(t, "engram", "session", "merge", "s-main", "s-dup")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSession(cfg) })
+	if recovered != nil || stderr != "" || !strings.Contains(stdout, "Merged 1 session(s) into s-main: 2 observations, 0 prompts moved") {
+		t.Fatalf("unexpected merge output: stdout=%q stderr=%q recovered=%v", stdout, stderr, recovered)
+	}
+
+	withArgs(t, "engram", "session", "split", "s-main", "s-new", "#"+strconv.FormatInt(ids[1], 10))
+	stdout, stderr, recovered = captureOutputAndRecover(t, func() { cmdSession(cfg) })
+	if recovered != nil || stderr != "" || !strings.Contains(stdout, "Split 1 observation(s) from s-main into new session s-new") {
+		t.Fatalf("unexpected split output: stdout=%q stderr=%q recovered=%v", stdout, stderr, recovered)
+	}
+
+	withArgs(t, "engram", "session", "split", "s-main", "s-other", "abc")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdSession(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "invalid observation id") {
+		t.Fatalf("expected invalid id exit, got recovered=%v stderr=%q", recovered, stderr)
+	}
+
+	withArgs(t, "engram", "session", "bogus")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdSession(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "unknown session subcommand") {
+		t.Fatalf("expected usage exit, got recovered=%v stderr=%q", recovered, stderr)
+	}
+}
+
+func TestCmdSessionExport(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	mustSeedObservation(t, cfg, "s-export", "engram", "config", "Staging creds", "db password=hunter22 for alice@example.com", "project")
+
+	withArgs(t, "engram", "session", "export", "s-export", "--md")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSession(cfg) })
+	if recovered != nil || stderr != "" || !strings.Contains(stdout, "# Session s-export") || !strings.Contains(stdout, "password=hunter22") {
+		t.Fatalf("unexpected export output: stdout=%q stderr=%q recovered=%v", stdout, stderr, recovered)
+	}
+
+	out := filepath.Join(t.TempDir(), "transcript.md")
+	withArgs(t, "engram", "session", "export", "s-export", "--redact", "--out", out)
+	stdout, stderr, recovered = captureOutputAndRecover(t, func() { cmdSession(cfg) })
+	if recovered != nil || stderr != "" || !strings.Contains(stdout, "Wrote transcript of session s-export to "+out) {
+		t.Fatalf("unexpected export output: stdout=%q stderr=%q recovered=%v", stdout, stderr, recovered)
+	}
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read transcript: %v", err)
+	}
+	if !strings.Contains(string(written), "password=[REDACTED] for [EMAIL]") {
+		t.Fatalf("expected redacted transcript, got:\n%s", written)
+	}
+
+	withArgs(t, "engram", "session", "export", "missing")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdSession(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "session not found") {
+		t.Fatalf("expected not found exit, got recovered=%v stderr=%q", recovered, stderr)
+	}
+}
+
+func TestCmdArchiveRunAndSearchIncludeArchive(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if _, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "bugfix", Title: "Legacy cron fix", Content: "cron ran twice after DST", Project: "engram"}); err != nil {
+		t.Fatalf("AddObservation: %v", err)
+	}
+	_ = s.Close()
+
+	db, err := sql.Open("sqlite", cfg.DatabasePath())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE observations SET updated_at = '2021-03-01T00:00:00Z', last_seen_at = '2021-03-01T00:00:00Z'`); err != nil {
+		t.Fatalf("age observations: %v", err)
+	}
+	_ = db.Close()
+
+	withArgs(t, "engram", "archive", "run", "--older-than", "30", "--dry-run")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdArchive(cfg) })
+	if recovered != nil || stderr != "" || !strings.Contains(stdout, "Would archive 1 observations") {
+		t.Fatalf("unexpected dry run output: stdout=%q stderr=%q recovered=%v", stdout, stderr, recovered)
+	}
+
+	withArgs(t, "engram", "archive", "run")
+	stdout, _, recovered = captureOutputAndRecover(t, func() { cmdArchive(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "Archived 1 observations into "+cfg.ArchivePath()) {
+		t.Fatalf("unexpected archive output: stdout=%q recovered=%v", stdout, recovered)
+	}
+
+	withArgs(t, "engram", "search", "cron")
+	stdout, _, recovered = captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "No memories found") {
+		t.Fatalf("expected the archived memory to leave default search: stdout=%q recovered=%v", stdout, recovered)
+	}
+	withArgs(t, "engram", "search", "cron", "--include-archive")
+	stdout, _, recovered = captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "Legacy cron fix [archived]") {
+		t.Fatalf("expected the archived memory with --include-archive: stdout=%q recovered=%v", stdout, recovered)
+	}
+
+	withArgs(t, "engram", "archive", "run", "--older-than", "soon")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdArchive(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "--older-than must be a positive number") {
+		t.Fatalf("expected invalid --older-than to exit 1: stderr=%q recovered=%v", stderr, recovered)
+	}
+}
+
+func TestCmdQuarantineListApproveReject(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	result, err := s.PassiveCapture(store.PassiveCaptureParams{
+		SessionID: "s1",
+		Project:   "engram",
+		Content:   "## Key Learnings:\n\n1. 2026-01-02 10:11:12 ERROR noisy line copied from the log\n2. and then the fragment trailed off without an ending\n",
+	})
+	if err != nil || result.Quarantined != 2 {
+		t.Fatalf("expected 2 quarantined captures, got %+v err=%v", result, err)
+	}
+	items, err := s.ListQuarantine("", 10)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("ListQuarantine: %d items err=%v", len(items), err)
+	}
+	_ = s.Close()
+
+	withArgs(t, "engram", "quarantine", "list", "--project", "engram")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdQuarantine(cfg) })
+	if recovered != nil || stderr != "" || !strings.Contains(stdout, "Quarantined captures (2)") || !strings.Contains(stdout, "reason: looks like log output") {
+		t.Fatalf("unexpected list output: stdout=%q stderr=%q recovered=%v", stdout, stderr, recovered)
+	}
+
+	approveID := strconv.FormatInt(items[0].ID, 10)
+	rejectID := strconv.FormatInt(items[1].ID, 10)
+	withArgs(t, "engram", "quarantine", "approve", "#"+approveID)
+	stdout, _, recovered = captureOutputAndRecover(t, func() { cmdQuarantine(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "Approved #"+approveID) {
+		t.Fatalf("unexpected approve output: stdout=%q recovered=%v", stdout, recovered)
+	}
+
+	withArgs(t, "engram", "quarantine", "reject", rejectID)
+	stdout, _, recovered = captureOutputAndRecover(t, func() { cmdQuarantine(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "Rejected #"+rejectID) {
+		t.Fatalf("unexpected reject output: stdout=%q recovered=%v", stdout, recovered)
+	}
+
+	withArgs(t, "engram", "quarantine", "list")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdQuarantine(cfg) })
+	if !strings.Contains(stdout, "Quarantine is empty.") {
+		t.Fatalf("expected empty quarantine, got %q", stdout)
+	}
+
+	withArgs(t, "engram", "quarantine", "approve", rejectID)
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdQuarantine(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "not in quarantine") {
+		t.Fatalf("expected error for a rejected id, got recovered=%v stderr=%q", recovered, stderr)
+	}
+
+	withArgs(t, "engram", "quarantine")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdQuarantine(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "usage: engram quarantine list") {
+		t.Fatalf("expected usage exit, got recovered=%v stderr=%q", recovered, stderr)
+	}
+}
+
+func TestCmdEmitRulesWritesAndRegeneratesInPlace(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	mustSeedObservation(t, cfg, "s-rules", "engram", "decision", "Use SQLite", "Single file database.", "project")
+	mustSeedObservation(t, cfg, "s-rules", "engram", "decision", "Private taste", "Only mine.", "personal")
+
+	out := filepath.Join(t.TempDir(), "AGENTS.md")
+	withArgs(t, "engram", "emit", "rules", "--project", "engram", "--out", out)
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdEmit(cfg) })
+	if recovered != nil || stderr != "" || !strings.Contains(stdout, "1 decisions, 0 patterns, 0 conventions") {
+		t.Fatalf("unexpected emit output: stdout=%q stderr=%q recovered=%v", stdout, stderr, recovered)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read rules file: %v", err)
+	}
+	if !strings.Contains(string(data), "### Use SQLite") || strings.Contains(string(data), "Private taste") {
+		t.Fatalf("expected only project-scoped decisions in rules file:\n%s", data)
+	}
+
+	if err := os.WriteFile(out, append([]byte("Hand-written intro.\n\n"), data...), 0644); err != nil {
+		t.Fatalf("edit rules file: %v", err)
+	}
+	mustSeedObservation(t, cfg, "s-rules-2", "engram", "pattern", "Injectable hooks", "Stub package vars in tests.", "project")
+
+	withArgs(t, "engram", "emit", "rules", "--project", "engram", "--out", out)
+	if _, _, recovered := captureOutputAndRecover(t, func() { cmdEmit(cfg) }); recovered != nil {
+		t.Fatalf("unexpected regenerate panic: %v", recovered)
+	}
+	data, _ = os.ReadFile(out)
+	if !strings.HasPrefix(string(data), "Hand-written intro.") || !strings.Contains(string(data), "### Injectable hooks") || strings.Count(string(data), "### Use SQLite") != 1 {
+		t.Fatalf("expected in-place regeneration:\n%s", data)
+	}
+
+	withArgs(t, "engram", "emit", "bogus")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdEmit(cfg) })
+	if _, ok := recovered.(exitCode); !ok || !strings.Contains(stderr, "usage: engram emit rules") {
+		t.Fatalf("expected emit usage exit, panic=%v stderr=%q", recovered, stderr)
+	}
+}
+
+func TestCmdSearchByRef(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	mustSeedObservation(t, cfg, "s-ref", "engram", "bugfix", "Fix flaky sync", "Tracked in #321", "project")
+	mustSeedObservation(t, cfg, "s-ref", "engram", "bugfix", "Unrelated", "nothing linked", "project")
+
+	withArgs(t, "engram", "search", "--ref", "#321")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("unexpected failure: stderr=%q recovered=%v", stderr, recovered)
+	}
+	if !strings.Contains(stdout, "Found 1 memories") || !strings.Contains(stdout, "Fix flaky sync") || !strings.Contains(stdout, "refs: #321") {
+		t.Fatalf("expected the linked observation with its refs, got %q", stdout)
+	}
+
+	withArgs(t, "engram", "search", "--ref", "#999")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if !strings.Contains(stdout, "No memories found for ref: #999") {
+		t.Fatalf("expected ref-specific empty message, got %q", stdout)
+	}
+}
+
+func TestCmdSearchByFile(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	mustSeedObservation(t, cfg, "s-file", "engram", "bugfix", "Token refresh race", "**Where**: src/auth/middleware.ts", "project")
+	mustSeedObservation(t, cfg, "s-file", "engram", "bugfix", "Unrelated", "**Where**: src/billing/invoice.ts", "project")
+
+	withArgs(t, "engram", "search", "--file", "middleware.ts")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("unexpected failure: stderr=%q recovered=%v", stderr, recovered)
+	}
+	if !strings.Contains(stdout, "Found 1 memories") || !strings.Contains(stdout, "Token refresh race") {
+		t.Fatalf("expected the observation mentioning the file, got %q", stdout)
+	}
+
+	withArgs(t, "engram", "search", "--file", "src/missing.go")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdSearch(cfg) })
+	if !strings.Contains(stdout, "No memories found for file: src/missing.go") {
+		t.Fatalf("expected file-specific empty message, got %q", stdout)
+	}
+}
+
+func TestCmdTopicsListsTopicKeys(t *testing.T) {
+	cfg := testConfig(t)
+
+	withArgs(t, "engram", "topics")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdTopics(cfg) })
+	if recovered != nil || stderr != "" || !strings.Contains(stdout, "No topic keys in use.") {
+		t.Fatalf("unexpected empty output: stdout=%q stderr=%q recovered=%v", stdout, stderr, recovered)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := s.CreateSession("s-topics", "engram", "/tmp"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	for _, p := range []store.AddObservationParams{
+		{Title: "Auth model", Content: "v1", Project: "engram", TopicKey: "architecture/auth-model"},
+		{Title: "Auth model v2", Content: "v2", Project: "engram", TopicKey: "architecture/auth-model"},
+		{Title: "Billing", Content: "elsewhere", Project: "billing", TopicKey: "architecture/billing"},
+	} {
+		p.SessionID = "s-topics"
+		p.Type = "architecture"
+		if _, err := s.AddObservation(p); err != nil {
+			t.Fatalf("AddObservation: %v", err)
+		}
+	}
+	_ = s.Close()
+
+	withArgs(t, "engram", "topics", "--project", "engram", "--scope", "project")
+	stdout, stderr, recovered = captureOutputAndRecover(t, func() { cmdTopics(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("unexpected failure: stderr=%q recovered=%v", stderr, recovered)
+	}
+	if !strings.Contains(stdout, "Topics (1):") || !strings.Contains(stdout, "architecture/auth-model") ||
+		!strings.Contains(stdout, "engram/project") || !strings.Contains(stdout, "Auth model v2 — 2 revisions") {
+		t.Fatalf("unexpected topics output: %q", stdout)
+	}
+	if strings.Contains(stdout, "architecture/billing") {
+		t.Fatalf("expected project filter to hide other projects, got %q", stdout)
+	}
+}
+
+func TestServeBackupsFromConfigAndStatsReport(t *testing.T) {
+	stubRuntimeHooks(t)
+	t.Setenv("ENGRAM_BACKUP_INTERVAL", "")
+	cfg := testConfig(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	scheduler, err := serveBackups(s, cfg.DataDir, "", logger)
+	if err != nil || scheduler != nil {
+		t.Fatalf("expected backups off without config, got %v, %v", scheduler, err)
+	}
+
+	t.Setenv("ENGRAM_BACKUP_INTERVAL", "2h")
+	scheduler, err = serveBackups(s, cfg.DataDir, "", logger)
+	if err != nil || scheduler == nil {
+		t.Fatalf("expected env interval to enable backups, got %v", err)
+	}
+	if st := scheduler.Status(); st.Interval != "2h0m0s" || st.Dir != filepath.Join(cfg.DataDir, "backups") {
+		t.Fatalf("unexpected backup status: %+v", st)
+	}
+	if _, err := scheduler.BackupNow(); err != nil {
+		t.Fatalf("BackupNow: %v", err)
+	}
+
+	withArgs(t, "engram", "stats")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdStats(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("stats failed: panic=%v stderr=%q", recovered, stderr)
+	}
+	if !strings.Contains(stdout, "Last backup:") || !strings.Contains(stdout, "Snapshots:    1 in ") {
+		t.Fatalf("expected backup section in stats, got %q", stdout)
+	}
+
+	path := filepath.Join(t.TempDir(), ".engram.toml")
+	if err := os.WriteFile(path, []byte("[backup]\ninterval = \"5s\"\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	findConfigFile = func() string { return path }
+	t.Setenv("ENGRAM_BACKUP_INTERVAL", "")
+	if _, err := serveBackups(s, cfg.DataDir, "", logger); err == nil || !strings.Contains(err.Error(), "backup.interval") {
+		t.Fatalf("expected interval validation error, got %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("[backup]\ninterval = \"1h\"\ndir = %q\n", dir)), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	scheduler, err = serveBackups(s, cfg.DataDir, "alice", logger)
+	if err != nil || scheduler.Status().Dir != filepath.Join(dir, "alice") {
+		t.Fatalf("expected per-mount backup dir, got %v", err)
+	}
+}
+
+func TestConfigReloaderAppliesSafeSettings(t *testing.T) {
+	stubRuntimeHooks(t)
+	t.Setenv("ENGRAM_BACKUP_INTERVAL", "")
+	cfg := testConfig(t)
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	path := filepath.Join(t.TempDir(), ".engram.toml")
+	writeFile := func(body string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	}
+	findConfigFile = func() string { return path }
+	writeFile("[quota]\nmax_observations = 100\n\n[backup]\ninterval = \"1h\"\nretention = 5\n")
+
+	current, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	backups, err := serveBackups(s, cfg.DataDir, "", logger)
+	if err != nil || backups == nil {
+		t.Fatalf("serveBackups: %v", err)
+	}
+	reloader := &configReloader{
+		current: current,
+		targets: []serveTarget{{Store: s, DataDir: cfg.DataDir, Backups: backups}},
+		logger:  logger,
+	}
+
+	writeFile("[server]\nport = 9999\n\n[quota]\nmax_observations = 200\n\n[backup]\ninterval = \"2h\"\nretention = 2\n")
+	if err := reloader.reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if usage, _ := s.QuotaUsage("engram"); usage.Quota.MaxObservations != 200 {
+		t.Fatalf("expected the reloaded quota, got %+v", usage.Quota)
+	}
+	if st := backups.Status(); st.Interval != "2h0m0s" || st.Retention != 2 {
+		t.Fatalf("expected the reloaded backup schedule, got %+v", st)
+	}
+	if !strings.Contains(logs.String(), "config reloaded") || !strings.Contains(logs.String(), "sections=server") {
+		t.Fatalf("expected the reload and the restart-only server change to be logged, got %q", logs.String())
+	}
+
+	// A bad edit is rejected as a whole and the running settings kept.
+	writeFile("[quota]\nmax_observations = 300\n\n[backup]\ninterval = \"5s\"\n")
+	if err := reloader.reload(); err == nil || !strings.Contains(err.Error(), "backup.interval") {
+		t.Fatalf("expected the invalid backup interval to fail the reload, got %v", err)
+	}
+	if usage, _ := s.QuotaUsage("engram"); usage.Quota.MaxObservations != 200 {
+		t.Fatalf("expected the quota to survive a failed reload, got %+v", usage.Quota)
+	}
+
+	// watchConfig reloads when the file changes.
+	oldInterval := configPollInterval
+	configPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { configPollInterval = oldInterval })
+	reloads := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watchConfig(ctx, func() error {
+			select {
+			case reloads <- struct{}{}:
+			default:
+			}
+			return nil
+		}, logger)
+	}()
+	defer func() { cancel(); <-done }()
+	time.Sleep(50 * time.Millisecond)
+	writeFile("[quota]\nmax_observations = 400\n# changed size\n")
+	select {
+	case <-reloads:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a config file change to trigger a reload")
+	}
+}
+
+func TestCmdGCAndServeGC(t *testing.T) {
+	stubRuntimeHooks(t)
+	stubExitWithPanic(t)
+	t.Setenv("ENGRAM_GC_INTERVAL", "")
+	cfg := testConfig(t)
+
+	withArgs(t, "engram", "gc")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdGC(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("gc failed: panic=%v stderr=%q", recovered, stderr)
+	}
+	if !strings.Contains(stdout, "No orphaned full-text index rows") || !strings.Contains(stdout, "converted to auto_vacuum=INCREMENTAL") ||
+		!strings.Contains(stdout, "reclaimed ") {
+		t.Fatalf("unexpected gc output: %q", stdout)
+	}
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdGC(cfg) })
+	if !strings.Contains(stdout, "Vacuum: incremental") {
+		t.Fatalf("expected an incremental second run, got %q", stdout)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if collector, err := serveGC(s, "", logger); err != nil || collector != nil {
+		t.Fatalf("expected scheduled gc off without config, got %v, %v", collector, err)
+	}
+	t.Setenv("ENGRAM_GC_INTERVAL", "12h")
+	if collector, err := serveGC(s, "", logger); err != nil || collector == nil {
+		t.Fatalf("expected env interval to enable scheduled gc, got %v", err)
+	}
+	t.Setenv("ENGRAM_GC_INTERVAL", "1m")
+	if _, err := serveGC(s, "", logger); err == nil || !strings.Contains(err.Error(), "gc.interval") {
+		t.Fatalf("expected interval validation error, got %v", err)
+	}
+}
+
+func TestCmdCompressAndStats(t *testing.T) {
+	stubRuntimeHooks(t)
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	mustSeedObservation(t, cfg, "s1", "engram", "session_summary", "Long summary", strings.Repeat("a long and repetitive summary line\n", 300)+"End.", "project")
+
+	withArgs(t, "engram", "compress", "--above", "1KB", "--dry-run")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdCompress(cfg) })
+	if recovered != nil || stderr != "" || !strings.Contains(stdout, "Would compress 1 observations above 1.0 KB") {
+		t.Fatalf("unexpected dry run: panic=%v stderr=%q stdout=%q", recovered, stderr, stdout)
+	}
+	withArgs(t, "engram", "compress", "--above", "1KB")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdCompress(cfg) })
+	if !strings.Contains(stdout, "Compressed 1 observations") || !strings.Contains(stdout, "engram gc") {
+		t.Fatalf("unexpected compress output: %q", stdout)
+	}
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdCompress(cfg) })
+	if !strings.Contains(stdout, "Nothing to compress above 1.0 KB") {
+		t.Fatalf("expected nothing left to compress, got %q", stdout)
+	}
+
+	withArgs(t, "engram", "stats")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdStats(cfg) })
+	if !strings.Contains(stdout, "Compressed:   1 observations") {
+		t.Fatalf("expected stats to report the compressed row, got %q", stdout)
+	}
+
+	withArgs(t, "engram", "compress", "--above", "lots")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdCompress(cfg) })
+	if recovered == nil || !strings.Contains(stderr, "--above must be a positive size") {
+		t.Fatalf("expected an invalid size to be rejected, got panic=%v stderr=%q", recovered, stderr)
+	}
+}
+
+type stubNotifier struct{ got []notify.Notification }
+
+func (n *stubNotifier) Notify(msg notify.Notification) error {
+	n.got = append(n.got, msg)
+	return nil
+}
+
+func TestServeNotify(t *testing.T) {
+	stubRuntimeHooks(t)
+	t.Setenv("ENGRAM_NOTIFY", "")
+	oldNewNotifier := newNotifier
+	t.Cleanup(func() { newNotifier = oldNewNotifier })
+	stub := &stubNotifier{}
+	newNotifier = func() (notify.Notifier, error) { return stub, nil }
+
+	s, err := store.New(testConfig(t))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if w, err := serveNotify(s, "", logger); err != nil || w != nil {
+		t.Fatalf("expected notifications off without config, got %v, %v", w, err)
+	}
+	t.Setenv("ENGRAM_NOTIFY", "true")
+	w, err := serveNotify(s, "alice", logger)
+	if err != nil || w == nil {
+		t.Fatalf("expected ENGRAM_NOTIFY to enable notifications, got %v", err)
+	}
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "session_summary", Title: "Summary", Content: "Wrapped up", Project: "engram"}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	if _, err := w.Poll(); err != nil || len(stub.got) != 1 || !strings.Contains(stub.got[0].Title, "[alice]") {
+		t.Fatalf("expected one labelled notification, got %+v, %v", stub.got, err)
+	}
+
+	newNotifier = func() (notify.Notifier, error) { return nil, errors.New("notify-send not found") }
+	if w, err := serveNotify(s, "", logger); err != nil || w != nil {
+		t.Fatalf("expected a missing notification tool to disable notifications, got %v, %v", w, err)
+	}
+	t.Setenv("ENGRAM_NOTIFY", "maybe")
+	if _, err := serveNotify(s, "", logger); err == nil || !strings.Contains(err.Error(), "ENGRAM_NOTIFY") {
+		t.Fatalf("expected ENGRAM_NOTIFY parse error, got %v", err)
+	}
+}
+
+func TestMainHonorsDBPathAndEphemeralMCP(t *testing.T) {
+	stubRuntimeHooks(t)
+	dataDir := filepath.Join(t.TempDir(), "data")
+	t.Setenv("ENGRAM_DATA_DIR", dataDir)
+
+	var opened []store.Config
+	storeNew = func(cfg store.Config) (*store.Store, error) {
+		opened = append(opened, cfg)
+		return store.New(cfg)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "ci.db")
+	t.Setenv("ENGRAM_DB_PATH", dbPath)
+	withArgs(t, "engram", "stats")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { main() })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("stats failed: panic=%v stderr=%q", recovered, stderr)
+	}
+	if !strings.Contains(stdout, "Database:     "+dbPath) {
+		t.Fatalf("expected stats to report ENGRAM_DB_PATH, got %q", stdout)
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		t.Fatalf("expected database at ENGRAM_DB_PATH: %v", err)
+	}
+
+	t.Setenv("ENGRAM_DB_PATH", "")
+	withArgs(t, "engram", "mcp", "--ephemeral")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { main() })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("mcp --ephemeral failed: panic=%v stderr=%q", recovered, stderr)
+	}
+	if last := opened[len(opened)-1]; !last.InMemory() {
+		t.Fatalf("expected an in-memory store, got DBPath=%q", last.DBPath)
+	}
+	if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+		t.Fatalf("expected mcp --ephemeral to leave the data dir alone, stat err=%v", err)
+	}
+}
+
+func TestCmdEnrichRunReviewAccept(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer from-env" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reply := `{"title": "Fix DST double cron run", "topic_key": "bug/cron-dst", "tags": ["cron"]}`
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	defer llm.Close()
+
+	configPath := filepath.Join(t.TempDir(), ".engram.toml")
+	if err := os.WriteFile(configPath, []byte("[enrich]\nendpoint = \""+llm.URL+"\"\nmodel = \"test\"\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("ENGRAM_CONFIG", configPath)
+	t.Setenv("ENGRAM_ENRICH_API_KEY", "from-env")
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	id, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "bugfix", Title: "cron", Content: "cron ran twice after DST", Project: "engram"})
+	if err != nil {
+		t.Fatalf("AddObservation: %v", err)
+	}
+	_ = s.Close()
+
+	withArgs(t, "engram", "enrich", "run")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdEnrich(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "Suggested 1, failed 0") {
+		t.Fatalf("unexpected run output: stdout=%q stderr=%q recovered=%v", stdout, stderr, recovered)
+	}
+
+	withArgs(t, "engram", "enrich", "review")
+	stdout, _, recovered = captureOutputAndRecover(t, func() { cmdEnrich(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "→ Fix DST double cron run") || !strings.Contains(stdout, "→ bug/cron-dst") {
+		t.Fatalf("unexpected review output: stdout=%q recovered=%v", stdout, recovered)
+	}
+
+	withArgs(t, "engram", "enrich", "accept", "1")
+	stdout, _, recovered = captureOutputAndRecover(t, func() { cmdEnrich(cfg) })
+	if recovered != nil || !strings.Contains(stdout, fmt.Sprintf("Accepted 1: #%d %q", id, "Fix DST double cron run")) {
+		t.Fatalf("unexpected accept output: stdout=%q recovered=%v", stdout, recovered)
+	}
+
+	withArgs(t, "engram", "enrich", "reject", "1")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdEnrich(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "not pending") {
+		t.Fatalf("expected rejecting an accepted suggestion to fail: stderr=%q recovered=%v", stderr, recovered)
+	}
+}
+
+func TestCmdQueryFormatsAndRefusesWrites(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	for i, title := range []string{"Fix race", "Fix leak", "Use locks"} {
+		typ := "bugfix"
+		if i == 2 {
+			typ = "decision"
+		}
+		if _, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: typ, Title: title, Content: title + ", in detail", Project: "engram"}); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+	_ = s.Close()
+
+	const sql = "SELECT type, COUNT(*) AS n FROM observations GROUP BY 1 ORDER BY 1"
+	withArgs(t, "engram", "query", sql)
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdQuery(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("query failed: panic=%v stderr=%q", recovered, stderr)
+	}
+	if stdout != "type      n\n--------  -\nbugfix    2\ndecision  1\n(2 rows)\n" {
+		t.Fatalf("unexpected table output: %q", stdout)
+	}
+
+	withArgs(t, "engram", "query", "--format", "csv", "SELECT title, NULL AS missing FROM observations ORDER BY id LIMIT 1")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdQuery(cfg) })
+	if stdout != "title,missing\nFix race,\n" {
+		t.Fatalf("unexpected csv output: %q", stdout)
+	}
+
+	withArgs(t, "engram", "query", "--format", "json", "--limit", "1", sql)
+	stdout, stderr, _ = captureOutputAndRecover(t, func() { cmdQuery(cfg) })
+	var result store.QueryResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil || len(result.Rows) != 1 || !result.Truncated {
+		t.Fatalf("unexpected json output: %q (%v)", stdout, err)
+	}
+	if !strings.Contains(stderr, "stopped at 1 rows") {
+		t.Fatalf("expected a truncation note on stderr, got %q", stderr)
+	}
+
+	withArgs(t, "engram", "query", "DELETE FROM observations")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdQuery(cfg) })
+	if recovered == nil || !strings.Contains(stderr, "readonly") {
+		t.Fatalf("expected the write to be refused: stderr=%q recovered=%v", stderr, recovered)
+	}
+
+	withArgs(t, "engram", "query", "--format", "xml", sql)
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdQuery(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "unknown format") {
+		t.Fatalf("expected an unknown format to exit 1: stderr=%q recovered=%v", stderr, recovered)
+	}
+}
+
+func TestCmdSeedPopulatesStoreDeterministically(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+
+	withArgs(t, "engram", "seed", "--projects", "2", "--sessions", "4", "--observations", "30", "--seed", "9")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSeed(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("seed failed: panic=%v stderr=%q", recovered, stderr)
+	}
+	if !strings.Contains(stdout, "Seeded 4 sessions, 30 observations") || !strings.Contains(stdout, "atlas-api, orbit-web (seed 9)") {
+		t.Fatalf("unexpected seed output: %q", stdout)
+	}
+
+	stdout, _, recovered = captureOutputAndRecover(t, func() { cmdSeed(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "Seeded 0 sessions, 0 observations") || !strings.Contains(stdout, "already seeded") {
+		t.Fatalf("expected a re-run with the same seed to add nothing: %q", stdout)
+	}
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	results, err := s.Search("", store.SearchOptions{Source: store.SourceSeed, Limit: 100})
+	_ = s.Close()
+	if err != nil || len(results) == 0 {
+		t.Fatalf("expected seeded observations tagged with the seed source, got %d, %v", len(results), err)
+	}
+
+	withArgs(t, "engram", "seed", "--observations", "lots")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdSeed(cfg) })
+	if code, ok := recovered.(exitCode); !ok || code != 1 || !strings.Contains(stderr, "--observations must be a positive number") {
+		t.Fatalf("expected invalid --observations to exit 1: stderr=%q recovered=%v", stderr, recovered)
+	}
+}
+
+func TestCmdMigrateStatusDownAndUp(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	_ = s.Close()
+
+	withArgs(t, "engram", "migrate", "down", "--to", "2")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdMigrate(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "Rolled back migration 4 (translations)") || !strings.Contains(stdout, "Rolled back migration 3") {
+		t.Fatalf("unexpected down output: %q stderr=%q panic=%v", stdout, stderr, recovered)
+	}
+
+	withArgs(t, "engram", "migrate", "status")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdMigrate(cfg) })
+	if !strings.Contains(stdout, fmt.Sprintf("Schema version 2 (this engram knows up to %d)", store.SchemaVersion)) || !strings.Contains(stdout, "translations             pending") {
+		t.Fatalf("expected pending steps in status, got %q", stdout)
+	}
+
+	withArgs(t, "engram", "migrate", "up")
+	captureOutputAndRecover(t, func() { cmdMigrate(cfg) })
+	withArgs(t, "engram", "migrate", "status", "--json")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdMigrate(cfg) })
+	var list []store.Migration
+	if err := json.Unmarshal([]byte(stdout), &list); err != nil || len(list) != store.SchemaVersion || !list[len(list)-1].Applied {
+		t.Fatalf("expected every step applied after up, got %q (%v)", stdout, err)
+	}
+
+	withArgs(t, "engram", "migrate", "down")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdMigrate(cfg) })
+	if recovered == nil || !strings.Contains(stderr, "--to N") {
+		t.Fatalf("expected usage without --to, got stderr=%q", stderr)
+	}
+}
+
+func TestCmdSessionSummarize(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "bugfix", Title: "Fix leak", Content: "Close rows in search", Project: "engram"}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	_ = s.Close()
+
+	withArgs(t, "engram", "session", "summarize", "s1")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSession(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "Saved auto-generated summary") || !strings.Contains(stdout, "- Fixed: Fix leak — Close rows in search") {
+		t.Fatalf("unexpected output: %q stderr=%q panic=%v", stdout, stderr, recovered)
+	}
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdSession(cfg) })
+	if !strings.Contains(stdout, `Session "s1" already has summary`) {
+		t.Fatalf("expected the existing summary to be reported, got %q", stdout)
+	}
+
+	withArgs(t, "engram", "session", "summarize", "missing")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdSession(cfg) })
+	if recovered == nil || !strings.Contains(stderr, "session not found") {
+		t.Fatalf("expected a missing session error, got stderr=%q", stderr)
+	}
+}
+
+func TestCmdSaveSummary(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(summary, []byte("## Changed\n- Bumped Go to 1.24\n"), 0o644); err != nil {
+		t.Fatalf("write summary: %v", err)
+	}
+
+	withArgs(t, "engram", "save-summary", "--project", "billing", "--file", summary, "--session", "ci-nightly")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdSaveSummary(cfg) })
+	if recovered != nil || !strings.Contains(stdout, `session "ci-nightly" ended`) {
+		t.Fatalf("unexpected output: %q stderr=%q panic=%v", stdout, stderr, recovered)
+	}
+
+	// The same summary pushed to a server that requires a token.
+	remoteCfg := testConfig(t)
+	remote, err := store.New(remoteCfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer remote.Close()
+	srv := engramsrv.New(remote, 0)
+	srv.Use(engramsrv.TokenAuth("ci-secret"))
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	t.Setenv("ENGRAM_HTTP_TOKEN", "ci-secret")
+
+	withArgs(t, "engram", "save-summary", "--project", "billing", "--file", summary, "--session", "ci-nightly", "--url", ts.URL+"/")
+	stdout, stderr, recovered = captureOutputAndRecover(t, func() { cmdSaveSummary(cfg) })
+	if recovered != nil || !strings.Contains(stdout, "Session summary saved") {
+		t.Fatalf("unexpected remote output: %q stderr=%q panic=%v", stdout, stderr, recovered)
+	}
+	local, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer local.Close()
+	for _, st := range []*store.Store{local, remote} {
+		obs, err := st.SessionObservations("ci-nightly", 0)
+		if err != nil || len(obs) != 1 || obs[0].Source == nil || *obs[0].Source != store.SourceCI {
+			t.Fatalf("expected one ci summary, got %+v err=%v", obs, err)
+		}
+	}
+
+	t.Setenv("ENGRAM_HTTP_TOKEN", "wrong")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdSaveSummary(cfg) })
+	if recovered == nil || !strings.Contains(stderr, "401") {
+		t.Fatalf("expected a rejected token, got stderr=%q", stderr)
+	}
+
+	withArgs(t, "engram", "save-summary", "--project", "billing")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdSaveSummary(cfg) })
+	if recovered == nil || !strings.Contains(stderr, "usage: engram save-summary") {
+		t.Fatalf("expected usage, got stderr=%q", stderr)
+	}
+}
+
+func TestCmdDoctorFixSessions(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	_ = s.Close()
+
+	// Rows written with foreign keys off, as older databases were.
+	db, err := sql.Open("sqlite", cfg.DatabasePath())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	for _, q := range []string{
+		`PRAGMA foreign_keys = OFF`,
+		`INSERT INTO observations (session_id, type, title, content, project, scope, created_at, updated_at)
+		 VALUES ('gone', 'bugfix', 'Fix leak', 'Close rows', 'engram', 'project', '2025-01-01T00:00:00Z', '2025-01-01T00:00:00Z')`,
+		`INSERT INTO user_prompts (session_id, content, project, created_at) VALUES ('gone', 'why?', 'engram', '2025-01-02T00:00:00Z')`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+	_ = db.Close()
+
+	withArgs(t, "engram", "doctor")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdDoctor(cfg) })
+	if recovered != nil || stderr != "" || !strings.Contains(stdout, "1 missing session(s)") ||
+		!strings.Contains(stdout, "gone  engram  first seen 2025-01-01T00:00:00Z  (1 observations, 1 prompts)") {
+		t.Fatalf("unexpected doctor output: panic=%v stderr=%q stdout=%q", recovered, stderr, stdout)
+	}
+
+	withArgs(t, "engram", "doctor", "--fix-sessions")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdDoctor(cfg) })
+	if !strings.Contains(stdout, "Created 1 placeholder session(s)") {
+		t.Fatalf("unexpected fix output: %q", stdout)
+	}
+
+	withArgs(t, "engram", "doctor", "--json")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdDoctor(cfg) })
+	if !strings.Contains(stdout, `"orphaned_sessions": []`) {
+		t.Fatalf("expected nothing left to fix, got %q", stdout)
+	}
+}
+
+func TestCmdDedupeReport(t *testing.T) {
+	stubExitWithPanic(t)
+	cfg := testConfig(t)
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	for _, title := range []string{"Fix leak", "Fix leak", "Fix leak", "Leak fix"} {
+		if _, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "bugfix", Title: title, Content: "Close rows in search", Project: "engram"}); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+	_ = s.Close()
+
+	withArgs(t, "engram", "dedupe", "report")
+	stdout, stderr, recovered := captureOutputAndRecover(t, func() { cmdDedupe(cfg) })
+	if recovered != nil || stderr != "" {
+		t.Fatalf("dedupe report failed: panic=%v stderr=%q", recovered, stderr)
+	}
+	for _, want := range []string{
+		"Deduped:      2 (50%)",
+		"  engram   bugfix       4         2        2   50%  56 B",
+		"#2 [bugfix] Leak fix — engram",
+		"4 saves in 2 rows; the extra copies take 28 B",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Fatalf("expected %q in:\n%s", want, stdout)
+		}
+	}
+
+	withArgs(t, "engram", "dedupe", "report", "--json", "--project", "other")
+	stdout, _, _ = captureOutputAndRecover(t, func() { cmdDedupe(cfg) })
+	var report store.DedupeReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil || report.Saves != 0 {
+		t.Fatalf("unexpected json report: %q (%v)", stdout, err)
+	}
+
+	withArgs(t, "engram", "dedupe")
+	_, stderr, recovered = captureOutputAndRecover(t, func() { cmdDedupe(cfg) })
+	if recovered == nil || !strings.Contains(stderr, "usage: engram dedupe report") {
+		t.Fatalf("expected usage, got stderr=%q", stderr)
+	}
+}
+
+func TestTUIProfilesMarksTheOpenStore(t *testing.T) {
+	profiles := []config.Profile{{Name: "personal", DataDir: "/home/me/.engram"}, {Name: "work", DataDir: "/home/me/.engram-work"}}
+
+	list, active := tuiProfiles(store.Config{DataDir: "/home/me/.engram-work/"}, profiles)
+	if len(list) != 2 || active != 1 || list[active].Name != "work" {
+		t.Fatalf("expected the work profile marked open, got %+v active=%d", list, active)
+	}
+
+	list, active = tuiProfiles(store.Config{DataDir: "/tmp/scratch"}, profiles)
+	if len(list) != 3 || active != 0 || list[0] != (tui.Profile{Name: "default", DataDir: "/tmp/scratch"}) {
+		t.Fatalf("expected an unlisted data dir first as default, got %+v active=%d", list, active)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
@@ -405,6 +406,13 @@ func TestCmdExportAndImport(t *testing.T) {
 		t.Fatalf("unexpected import output: %q", importOut)
 	}
 
+	// Importing the same file again skips everything by default.
+	withArgs(t, "engram", "import", exportPath, "--on-conflict=skip")
+	againOut, _ := captureOutput(t, func() { cmdImport(targetCfg) })
+	if !strings.Contains(againOut, "Observations: 0 (1 skipped, 0 merged)") {
+		t.Fatalf("expected re-import to skip, got: %q", againOut)
+	}
+
 	s, err := store.New(targetCfg)
 	if err != nil {
 		t.Fatalf("store.New target: %v", err)
@@ -447,6 +455,16 @@ func TestCmdSyncStatusExportAndImport(t *testing.T) {
 		t.Fatalf("unexpected sync export output: %q", exportOut)
 	}
 
+	withArgs(t, "engram", "sync", "--import", "--dry-run")
+	previewOut, previewErr := captureOutput(t, func() { cmdSync(importCfg) })
+	if previewErr != "" {
+		t.Fatalf("expected no stderr from sync import preview, got: %q", previewErr)
+	}
+	if !strings.Contains(previewOut, "Would import 1 new chunk(s) from .engram/ (dry run, nothing written)") ||
+		!strings.Contains(previewOut, "observations: 1, prompts: 0  [sync-project]") || !strings.Contains(previewOut, "Projects: sync-project") {
+		t.Fatalf("unexpected sync import preview output: %q", previewOut)
+	}
+
 	withArgs(t, "engram", "sync", "--import")
 	importOut, importErr := captureOutput(t, func() { cmdSync(importCfg) })
 	if importErr != "" {
@@ -466,6 +484,46 @@ func TestCmdSyncStatusExportAndImport(t *testing.T) {
 	}
 }
 
+func TestCmdSyncRecursiveStatusExportAndImport(t *testing.T) {
+	root := t.TempDir()
+	for _, repo := range []string{"api", "web"} {
+		if err := os.MkdirAll(filepath.Join(root, repo, ".engram"), 0755); err != nil {
+			t.Fatalf("mkdir %s: %v", repo, err)
+		}
+		if err := os.WriteFile(filepath.Join(root, repo, ".engram", "manifest.json"), []byte(`{"version":1,"chunks":[]}`), 0644); err != nil {
+			t.Fatalf("write %s manifest: %v", repo, err)
+		}
+	}
+	old := detectProject
+	detectProject = func(dir string) string { return filepath.Base(dir) }
+	t.Cleanup(func() { detectProject = old })
+
+	cfg := testConfig(t)
+	mustSeedObservation(t, cfg, "s-api", "api", "decision", "Use gRPC", "gRPC between services", "project")
+
+	withArgs(t, "engram", "sync", "--status", "--recursive", root)
+	statusOut, statusErr := captureOutput(t, func() { cmdSync(cfg) })
+	if statusErr != "" || !strings.Contains(statusOut, "Sync status for 2 repo(s)") {
+		t.Fatalf("unexpected status output: %q stderr=%q", statusOut, statusErr)
+	}
+	if !regexp.MustCompile(`api\s+api\s+0\s+0\s+2\n`).MatchString(statusOut) || !regexp.MustCompile(`web\s+web\s+0\s+0\s+0\n`).MatchString(statusOut) {
+		t.Fatalf("expected api's session and observation pending export, got:\n%s", statusOut)
+	}
+
+	withArgs(t, "engram", "sync", "--recursive", root)
+	exportOut, _ := captureOutput(t, func() { cmdSync(cfg) })
+	if !strings.Contains(exportOut, "created chunk") || !regexp.MustCompile(`web\s+nothing new`).MatchString(exportOut) {
+		t.Fatalf("unexpected export output: %q", exportOut)
+	}
+
+	importCfg := testConfig(t)
+	withArgs(t, "engram", "sync", "--import", "--recursive", root)
+	importOut, _ := captureOutput(t, func() { cmdSync(importCfg) })
+	if !strings.Contains(importOut, "imported 1 chunk(s): 1 observations") || !regexp.MustCompile(`web\s+no new chunks`).MatchString(importOut) {
+		t.Fatalf("unexpected import output: %q", importOut)
+	}
+}
+
 func TestCmdSyncDefaultProjectNoData(t *testing.T) {
 	workDir := filepath.Join(t.TempDir(), "repo-name")
 	if err := os.MkdirAll(workDir, 0755); err != nil {
@@ -906,6 +964,122 @@ func TestCmdMCPDetectsProjectFromEnv(t *testing.T) {
 	}
 }
 
+func TestCmdMCPReadOnlyFlag(t *testing.T) {
+	cfg := testConfig(t)
+
+	var tools map[string]*mcpserver.ServerTool
+	oldNew := newMCPServerWithConfig
+	t.Cleanup(func() { newMCPServerWithConfig = oldNew })
+	newMCPServerWithConfig = func(s *store.Store, mcpCfg mcp.MCPConfig, allowlist map[string]bool) *mcpserver.MCPServer {
+		srv := mcp.NewServerWithConfig(s, mcpCfg, allowlist)
+		tools = srv.ListTools()
+		return srv
+	}
+	oldServe := serveMCP
+	t.Cleanup(func() { serveMCP = oldServe })
+	serveMCP = func(srv *mcpserver.MCPServer, opts ...mcpserver.StdioOption) error { return nil }
+
+	withArgs(t, "engram", "mcp", "--read-only")
+	_, _ = captureOutput(t, func() { cmdMCP(cfg) })
+	if tools["mem_search"] == nil || tools["mem_save"] != nil || len(tools) != len(mcp.ReadOnlyTools) {
+		t.Fatalf("expected only read tools with --read-only, got %d tools", len(tools))
+	}
+}
+
+func TestCmdMCPLoadsToolOverrides(t *testing.T) {
+	cfg := testConfig(t)
+	t.Setenv("ENGRAM_TOOLS_FILE", "")
+
+	var capturedCfg mcp.MCPConfig
+	oldNew := newMCPServerWithConfig
+	t.Cleanup(func() { newMCPServerWithConfig = oldNew })
+	newMCPServerWithConfig = func(s *store.Store, mcpCfg mcp.MCPConfig, allowlist map[string]bool) *mcpserver.MCPServer {
+		capturedCfg = mcpCfg
+		return oldNew(s, mcpCfg, allowlist)
+	}
+	oldServe := serveMCP
+	t.Cleanup(func() { serveMCP = oldServe })
+	serveMCP = func(srv *mcpserver.MCPServer, opts ...mcpserver.StdioOption) error { return nil }
+
+	// The data dir default is picked up when present.
+	if err := os.WriteFile(filepath.Join(cfg.DataDir, "tools.toml"), []byte("[mem_save]\ndescription = \"Save briefly.\"\n"), 0644); err != nil {
+		t.Fatalf("write tools.toml: %v", err)
+	}
+	withArgs(t, "engram", "mcp")
+	_, _ = captureOutput(t, func() { cmdMCP(cfg) })
+	if capturedCfg.ToolOverrides["mem_save"].Description != "Save briefly." {
+		t.Fatalf("expected data dir tools.toml to load, got %+v", capturedCfg.ToolOverrides)
+	}
+
+	// --tools-file wins and must exist.
+	other := filepath.Join(t.TempDir(), "claude-tools.toml")
+	if err := os.WriteFile(other, []byte("[mem_search]\ntitle = \"Recall\"\n"), 0644); err != nil {
+		t.Fatalf("write tools file: %v", err)
+	}
+	withArgs(t, "engram", "mcp", "--tools-file", other)
+	_, _ = captureOutput(t, func() { cmdMCP(cfg) })
+	if capturedCfg.ToolOverrides["mem_search"].Title != "Recall" || len(capturedCfg.ToolOverrides) != 1 {
+		t.Fatalf("expected --tools-file overrides, got %+v", capturedCfg.ToolOverrides)
+	}
+
+	oldExit := exitFunc
+	t.Cleanup(func() { exitFunc = oldExit })
+	var code int
+	exitFunc = func(c int) { code = c; panic("exit") }
+	withArgs(t, "engram", "mcp", "--tools-file", filepath.Join(t.TempDir(), "missing.toml"))
+	_, stderr := captureOutput(t, func() {
+		defer func() { _ = recover() }()
+		cmdMCP(cfg)
+	})
+	if code != 1 || !strings.Contains(stderr, "missing.toml") {
+		t.Fatalf("expected a missing --tools-file to exit 1, code=%d stderr=%q", code, stderr)
+	}
+}
+
+func TestCmdMCPLoadsRateLimits(t *testing.T) {
+	cfg := testConfig(t)
+
+	var capturedCfg mcp.MCPConfig
+	oldNew := newMCPServerWithConfig
+	t.Cleanup(func() { newMCPServerWithConfig = oldNew })
+	newMCPServerWithConfig = func(s *store.Store, mcpCfg mcp.MCPConfig, allowlist map[string]bool) *mcpserver.MCPServer {
+		capturedCfg = mcpCfg
+		return oldNew(s, mcpCfg, allowlist)
+	}
+	oldServe := serveMCP
+	t.Cleanup(func() { serveMCP = oldServe })
+	serveMCP = func(srv *mcpserver.MCPServer, opts ...mcpserver.StdioOption) error { return nil }
+
+	path := filepath.Join(t.TempDir(), ".engram.toml")
+	oldFind := findConfigFile
+	t.Cleanup(func() { findConfigFile = oldFind })
+	findConfigFile = func() string { return path }
+
+	if err := os.WriteFile(path, []byte("[mcp.rate_limits]\nmem_save = \"5/1m\"\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	withArgs(t, "engram", "mcp")
+	_, _ = captureOutput(t, func() { cmdMCP(cfg) })
+	if got := capturedCfg.RateLimits; got["mem_save"] != (mcp.RateLimit{Calls: 5, Per: time.Minute}) || got["mem_capture_passive"] != mcp.DefaultRateLimits["mem_capture_passive"] {
+		t.Fatalf("expected the configured limit over the defaults, got %+v", got)
+	}
+
+	oldExit := exitFunc
+	t.Cleanup(func() { exitFunc = oldExit })
+	var code int
+	exitFunc = func(c int) { code = c; panic("exit") }
+	if err := os.WriteFile(path, []byte("[mcp.rate_limits]\nmem_sav = \"5/1m\"\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	_, stderr := captureOutput(t, func() {
+		defer func() { _ = recover() }()
+		cmdMCP(cfg)
+	})
+	if code != 1 || !strings.Contains(stderr, "mem_sav") {
+		t.Fatalf("expected an unknown tool to exit 1, code=%d stderr=%q", code, stderr)
+	}
+}
+
 func TestCmdMCPDetectsProjectFromGit(t *testing.T) {
 	cfg := testConfig(t)
 
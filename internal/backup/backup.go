@@ -0,0 +1,293 @@
+// Package backup takes periodic snapshots of the engram database while
+// `engram serve` is running.
+//
+// Each snapshot is written with VACUUM INTO to a temporary file, checked with
+// PRAGMA integrity_check, and only then renamed to its final name, so a file
+// named engram-*.db in the backup directory is always a verified, openable
+// database. Older snapshots beyond the retention count are deleted.
+//
+// Restore by stopping engram and copying a snapshot over {data dir}/engram.db.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+const (
+	// DirName is the default backup directory inside the data dir.
+	DirName = "backups"
+
+	// DefaultRetention is how many snapshots are kept when none is configured.
+	DefaultRetention = 7
+
+	// MinInterval guards against configs that would snapshot continuously.
+	MinInterval = time.Minute
+
+	filePrefix = "engram-"
+	fileSuffix = ".db"
+	timeLayout = "20060102T150405Z"
+)
+
+// now is injectable for testing.
+var now = time.Now
+
+// Options configures a Scheduler.
+type Options struct {
+	// Dir receives the snapshots; it is created when missing.
+	Dir string
+	// Interval between snapshots.
+	Interval time.Duration
+	// Retention is the number of snapshots kept; <= 0 means DefaultRetention.
+	Retention int
+}
+
+// Snapshot is one verified backup file.
+type Snapshot struct {
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+	SizeBytes int64     `json:"size_bytes"`
+}
+
+// Status reports the scheduler state for /health and stats.
+type Status struct {
+	Dir        string     `json:"dir"`
+	Interval   string     `json:"interval"`
+	Retention  int        `json:"retention"`
+	Count      int        `json:"count"`
+	Last       *Snapshot  `json:"last,omitempty"`
+	NextAt     *time.Time `json:"next_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+	LastFailAt *time.Time `json:"last_fail_at,omitempty"`
+}
+
+// Scheduler snapshots a store on a fixed interval.
+type Scheduler struct {
+	store  *store.Store
+	opts   Options
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	status Status
+
+	// reconfigured wakes Run when Reconfigure changes the interval.
+	reconfigured chan struct{}
+}
+
+// New returns a Scheduler for s. The status starts from the snapshots
+// already in opts.Dir, so restarts keep reporting the last backup.
+func New(s *store.Store, opts Options, logger *slog.Logger) (*Scheduler, error) {
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("engram backup: directory is required")
+	}
+	if opts.Interval < MinInterval {
+		return nil, fmt.Errorf("engram backup: interval %s is shorter than %s", opts.Interval, MinInterval)
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = DefaultRetention
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	sc := &Scheduler{store: s, opts: opts, logger: logger, reconfigured: make(chan struct{}, 1)}
+	sc.status = Status{Dir: opts.Dir, Interval: opts.Interval.String(), Retention: opts.Retention}
+	snapshots, err := List(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	sc.status.Count = len(snapshots)
+	if len(snapshots) > 0 {
+		last := snapshots[0]
+		sc.status.Last = &last
+	}
+	return sc, nil
+}
+
+// Run takes snapshots until ctx is cancelled. The first snapshot is due one
+// interval after the newest existing one, so frequent restarts do not pile
+// up backups.
+func (sc *Scheduler) Run(ctx context.Context) {
+	for {
+		wait := sc.untilNext()
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-sc.reconfigured:
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+		if _, err := sc.BackupNow(); err != nil {
+			sc.logger.Error("backup failed", "dir", sc.opts.Dir, "err", err)
+		}
+	}
+}
+
+func (sc *Scheduler) untilNext() time.Duration {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	next := now()
+	if sc.status.Last != nil {
+		next = sc.status.Last.CreatedAt.Add(sc.opts.Interval)
+	}
+	if sc.status.LastFailAt != nil && sc.status.LastFailAt.Add(sc.opts.Interval).After(next) {
+		next = sc.status.LastFailAt.Add(sc.opts.Interval)
+	}
+	sc.status.NextAt = &next
+	return max(time.Until(next), 0)
+}
+
+// Reconfigure changes the interval and retention of a running scheduler, for
+// a config reload. The next snapshot is rescheduled from the last one, and a
+// lower retention prunes on the next snapshot. The directory cannot change.
+func (sc *Scheduler) Reconfigure(interval time.Duration, retention int) error {
+	if interval < MinInterval {
+		return fmt.Errorf("engram backup: interval %s is shorter than %s", interval, MinInterval)
+	}
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+
+	sc.mu.Lock()
+	sc.opts.Interval = interval
+	sc.opts.Retention = retention
+	sc.status.Interval = interval.String()
+	sc.status.Retention = retention
+	sc.mu.Unlock()
+
+	select {
+	case sc.reconfigured <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// BackupNow takes a snapshot immediately, verifies it, and prunes old ones.
+func (sc *Scheduler) BackupNow() (*Snapshot, error) {
+	snap, err := sc.snapshot()
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if err != nil {
+		failedAt := now()
+		sc.status.LastError = err.Error()
+		sc.status.LastFailAt = &failedAt
+		return nil, err
+	}
+	sc.status.Last = snap
+	sc.status.LastError = ""
+	sc.status.LastFailAt = nil
+
+	removed, pruneErr := prune(sc.opts.Dir, sc.opts.Retention)
+	if pruneErr != nil {
+		sc.logger.Warn("backup prune failed", "dir", sc.opts.Dir, "err", pruneErr)
+	}
+	if snapshots, err := List(sc.opts.Dir); err == nil {
+		sc.status.Count = len(snapshots)
+	}
+	sc.logger.Info("backup written", "path", snap.Path, "bytes", snap.SizeBytes, "pruned", removed)
+	return snap, nil
+}
+
+func (sc *Scheduler) snapshot() (*Snapshot, error) {
+	if err := os.MkdirAll(sc.opts.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("engram backup: create %s: %w", sc.opts.Dir, err)
+	}
+
+	createdAt := now().UTC().Truncate(time.Second)
+	final := filepath.Join(sc.opts.Dir, filePrefix+createdAt.Format(timeLayout)+fileSuffix)
+	tmp := final + ".tmp"
+	_ = os.Remove(tmp) // leftover from a crash mid-backup
+
+	if err := sc.store.Backup(tmp); err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+	if err := store.VerifyDatabase(tmp); err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, final); err != nil {
+		_ = os.Remove(tmp)
+		return nil, fmt.Errorf("engram backup: %w", err)
+	}
+
+	info, err := os.Stat(final)
+	if err != nil {
+		return nil, fmt.Errorf("engram backup: %w", err)
+	}
+	return &Snapshot{Path: final, CreatedAt: createdAt, SizeBytes: info.Size()}, nil
+}
+
+// Status returns a copy of the current scheduler state.
+func (sc *Scheduler) Status() Status {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	st := sc.status
+	if st.Last != nil {
+		last := *st.Last
+		st.Last = &last
+	}
+	return st
+}
+
+// List returns the snapshots in dir, newest first. A missing dir has none.
+func List(dir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("engram backup: list %s: %w", dir, err)
+	}
+
+	var snapshots []Snapshot
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		createdAt, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{Path: filepath.Join(dir, name), CreatedAt: createdAt, SizeBytes: info.Size()})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt) })
+	return snapshots, nil
+}
+
+// prune deletes snapshots beyond the newest keep and returns how many it
+// removed.
+func prune(dir string, keep int) (int, error) {
+	snapshots, err := List(dir)
+	if err != nil || len(snapshots) <= keep {
+		return 0, err
+	}
+	removed := 0
+	for _, snap := range snapshots[keep:] {
+		if err := os.Remove(snap.Path); err != nil {
+			return removed, fmt.Errorf("engram backup: remove %s: %w", snap.Path, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,230 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+	"github.com/Gentleman-Programming/engram/internal/store/storetest"
+)
+
+// newSeededStore opens a store holding one decision, so a snapshot has
+// something to verify.
+func newSeededStore(t *testing.T) *store.Store {
+	t.Helper()
+	s := storetest.NewWithSession(t)
+	if _, err := s.AddObservation(store.AddObservationParams{
+		SessionID: "s1",
+		Type:      "decision",
+		Title:     "Keep backups",
+		Content:   "Snapshots are verified before they are kept",
+		Project:   "engram",
+	}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	return s
+}
+
+func quietLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// stubNow makes the clock advance by step on every call.
+func stubNow(t *testing.T, start time.Time, step time.Duration) {
+	t.Helper()
+	prev := now
+	current := start
+	now = func() time.Time {
+		current = current.Add(step)
+		return current
+	}
+	t.Cleanup(func() { now = prev })
+}
+
+func TestNewValidatesOptions(t *testing.T) {
+	s := newSeededStore(t)
+
+	if _, err := New(s, Options{Interval: time.Hour}, nil); err == nil {
+		t.Fatalf("expected error without a directory")
+	}
+	if _, err := New(s, Options{Dir: t.TempDir(), Interval: time.Second}, nil); err == nil {
+		t.Fatalf("expected error for an interval below the minimum")
+	}
+
+	sc, err := New(s, Options{Dir: filepath.Join(t.TempDir(), "missing"), Interval: time.Hour}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	st := sc.Status()
+	if st.Retention != DefaultRetention || st.Count != 0 || st.Last != nil || st.Interval != "1h0m0s" {
+		t.Fatalf("unexpected initial status: %+v", st)
+	}
+}
+
+func TestBackupNowWritesVerifiedSnapshotsAndPrunes(t *testing.T) {
+	stubNow(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), time.Minute)
+	s := newSeededStore(t)
+	dir := filepath.Join(t.TempDir(), DirName)
+
+	sc, err := New(s, Options{Dir: dir, Interval: time.Hour, Retention: 2}, quietLogger())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var last *Snapshot
+	for range 3 {
+		last, err = sc.BackupNow()
+		if err != nil {
+			t.Fatalf("BackupNow: %v", err)
+		}
+	}
+	if err := store.VerifyDatabase(last.Path); err != nil {
+		t.Fatalf("snapshot does not verify: %v", err)
+	}
+	if !strings.HasPrefix(filepath.Base(last.Path), "engram-20260102T") || last.SizeBytes == 0 {
+		t.Fatalf("unexpected snapshot: %+v", last)
+	}
+
+	snapshots, err := List(dir)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Path != last.Path {
+		t.Fatalf("expected the 2 newest snapshots, newest first, got %+v", snapshots)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Fatalf("temporary file left behind: %s", e.Name())
+		}
+	}
+
+	st := sc.Status()
+	if st.Count != 2 || st.Last == nil || st.Last.Path != last.Path || st.LastError != "" {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+
+	// A new scheduler over the same directory picks up the last snapshot.
+	again, err := New(s, Options{Dir: dir, Interval: time.Hour}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if st := again.Status(); st.Count != 2 || st.Last == nil || st.Last.Path != last.Path {
+		t.Fatalf("expected restart to report existing snapshots, got %+v", st)
+	}
+}
+
+func TestReconfigureChangesIntervalAndRetention(t *testing.T) {
+	stubNow(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), time.Minute)
+	s := newSeededStore(t)
+	dir := filepath.Join(t.TempDir(), DirName)
+
+	sc, err := New(s, Options{Dir: dir, Interval: time.Hour, Retention: 3}, quietLogger())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for range 3 {
+		if _, err := sc.BackupNow(); err != nil {
+			t.Fatalf("BackupNow: %v", err)
+		}
+	}
+
+	if err := sc.Reconfigure(time.Second, 1); err == nil {
+		t.Fatalf("expected an interval below the minimum to be rejected")
+	}
+	if err := sc.Reconfigure(2*time.Hour, 1); err != nil {
+		t.Fatalf("Reconfigure: %v", err)
+	}
+	if st := sc.Status(); st.Interval != "2h0m0s" || st.Retention != 1 || st.Dir != dir {
+		t.Fatalf("unexpected status after reconfigure: %+v", st)
+	}
+	if _, err := sc.BackupNow(); err != nil {
+		t.Fatalf("BackupNow: %v", err)
+	}
+	if snapshots, _ := List(dir); len(snapshots) != 1 {
+		t.Fatalf("expected the lower retention to prune to 1 snapshot, got %d", len(snapshots))
+	}
+}
+
+func TestBackupNowRecordsFailure(t *testing.T) {
+	s := newSeededStore(t)
+	dir := filepath.Join(t.TempDir(), DirName)
+
+	sc, err := New(s, Options{Dir: dir, Interval: time.Hour}, quietLogger())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// A regular file where the backup dir should be makes MkdirAll fail.
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatalf("write blocker: %v", err)
+	}
+	if _, err := sc.BackupNow(); err == nil {
+		t.Fatalf("expected backup into a file path to fail")
+	}
+	if st := sc.Status(); st.LastError == "" || st.LastFailAt == nil {
+		t.Fatalf("expected failure in status, got %+v", st)
+	}
+}
+
+func TestListIgnoresUnrelatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"engram-20260101T000000Z.db", "engram-20260102T000000Z.db", "engram-bad.db", "notes.txt", "engram-20260103T000000Z.db.tmp"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	snapshots, err := List(dir)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(snapshots) != 2 || filepath.Base(snapshots[0].Path) != "engram-20260102T000000Z.db" {
+		t.Fatalf("unexpected snapshots: %+v", snapshots)
+	}
+
+	missing, err := List(filepath.Join(dir, "nope"))
+	if err != nil || missing != nil {
+		t.Fatalf("expected no snapshots for a missing dir, got %v err=%v", missing, err)
+	}
+}
+
+func TestRunTakesDueSnapshotAndStopsOnCancel(t *testing.T) {
+	s := newSeededStore(t)
+	dir := t.TempDir()
+
+	sc, err := New(s, Options{Dir: dir, Interval: time.Hour}, quietLogger())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sc.Run(ctx)
+		close(done)
+	}()
+
+	// No previous snapshot: the first one is due immediately.
+	deadline := time.Now().Add(5 * time.Second)
+	for sc.Status().Last == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the first snapshot")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Run did not stop after cancel")
+	}
+	if st := sc.Status(); st.Count != 1 {
+		t.Fatalf("expected exactly one snapshot, got %+v", st)
+	}
+}
@@ -0,0 +1,598 @@
+// Package chatimport reads the local chat history of other coding agents —
+// Cursor and Windsurf — so memory is not empty after switching to engram.
+//
+// Both editors are VS Code forks and keep chat state in SQLite files named
+// state.vscdb under their User directory:
+//
+//	User/workspaceStorage/<hash>/workspace.json  the workspace folder
+//	User/workspaceStorage/<hash>/state.vscdb     ItemTable: chat tabs and composer IDs
+//	User/globalStorage/state.vscdb               cursorDiskKV: composer conversations and bubbles
+//
+// The JSON inside changes between releases, so conversations are found by
+// shape rather than by schema: any object holding a list of messages
+// (bubbles, conversation, or messages) whose items carry a role and text.
+// Each conversation becomes a session holding its user prompts, and
+// assistant paragraphs that state a decision or a fix become observations.
+// Session IDs are derived from conversation IDs, so importing again skips
+// what is already there.
+package chatimport
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Gentleman-Programming/engram/internal/project"
+	"github.com/Gentleman-Programming/engram/internal/store"
+
+	_ "modernc.org/sqlite"
+)
+
+// Apps that can be imported.
+const (
+	Cursor   = "cursor"
+	Windsurf = "windsurf"
+)
+
+// appNames maps an app to its display name, which is also the name of its
+// config directory.
+var appNames = map[string]string{Cursor: "Cursor", Windsurf: "Windsurf"}
+
+// maxObservationsPerChat caps the decisions taken from one conversation so
+// a long chat cannot flood the project.
+const maxObservationsPerChat = 5
+
+// Options selects what Load reads.
+type Options struct {
+	App string // Cursor or Windsurf
+	// Dir is the editor's User directory; empty means DefaultDir.
+	Dir string
+	// Project keeps only workspaces detected as this project; empty keeps
+	// all of them.
+	Project string
+	// Now stamps conversations without any timestamp or file time. Zero
+	// means time.Now.
+	Now time.Time
+}
+
+// Result is what Load found, ready for Store.ImportWithOptions.
+type Result struct {
+	Data          *store.ExportData
+	Workspaces    int // workspaces whose chat state was read
+	Conversations int // conversations turned into sessions
+	Skipped       int // conversations with no messages or no known workspace
+}
+
+// DefaultDir returns the User directory app uses on goos: under
+// ~/Library/Application Support on macOS, %APPDATA% on Windows, and
+// $XDG_CONFIG_HOME (default ~/.config) elsewhere.
+func DefaultDir(app, goos, home string, getenv func(string) string) (string, error) {
+	name, ok := appNames[app]
+	if !ok {
+		return "", fmt.Errorf("unknown app %q (want cursor or windsurf)", app)
+	}
+	var base string
+	switch goos {
+	case "darwin":
+		base = filepath.Join(home, "Library", "Application Support")
+	case "windows":
+		base = cmp.Or(getenv("APPDATA"), filepath.Join(home, "AppData", "Roaming"))
+	default:
+		base = cmp.Or(getenv("XDG_CONFIG_HOME"), filepath.Join(home, ".config"))
+	}
+	return filepath.Join(base, name, "User"), nil
+}
+
+// SourceFor is the observation source recorded for app's imports.
+func SourceFor(app string) string {
+	return store.SourceImport + ":" + app
+}
+
+// Load reads every workspace under opts.Dir and converts its conversations.
+func Load(opts Options) (*Result, error) {
+	name, ok := appNames[opts.App]
+	if !ok {
+		return nil, fmt.Errorf("unknown app %q (want cursor or windsurf)", opts.App)
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.Project != "" {
+		opts.Project, _ = store.NormalizeProject(opts.Project)
+	}
+
+	workspaces, err := filepath.Glob(filepath.Join(opts.Dir, "workspaceStorage", "*", "state.vscdb"))
+	if err != nil {
+		return nil, err
+	}
+	if len(workspaces) == 0 {
+		return nil, fmt.Errorf("no %s workspaces found in %s", name, opts.Dir)
+	}
+	slices.Sort(workspaces)
+
+	global, err := readKV(filepath.Join(opts.Dir, "globalStorage", "state.vscdb"), "cursorDiskKV", func(key string) bool {
+		return strings.HasPrefix(key, "composerData:") || strings.HasPrefix(key, "bubbleId:")
+	})
+	if err != nil {
+		return nil, err
+	}
+	bubble := func(composerID, bubbleID string) map[string]any {
+		var m map[string]any
+		_ = json.Unmarshal(global["bubbleId:"+composerID+":"+bubbleID], &m)
+		return m
+	}
+
+	result := &Result{Data: &store.ExportData{Version: opts.App, ExportedAt: opts.Now.UTC().Format(store.TimestampLayout)}}
+	used := map[string]bool{}
+	for _, dbPath := range workspaces {
+		dir := workspaceFolder(filepath.Dir(dbPath))
+		if dir == "" {
+			continue
+		}
+		proj := project.DetectProject(dir)
+		if opts.Project != "" && proj != opts.Project {
+			continue
+		}
+		items, err := readKV(dbPath, "ItemTable", isChatKey)
+		if err != nil {
+			return nil, err
+		}
+		result.Workspaces++
+		fallback := opts.Now
+		if info, err := os.Stat(dbPath); err == nil {
+			fallback = info.ModTime()
+		}
+
+		var convs []conversation
+		for _, key := range sortedKeys(items) {
+			var v any
+			if json.Unmarshal(items[key], &v) != nil {
+				continue
+			}
+			convs = append(convs, findConversations(v, bubble)...)
+			// Composer headers live here; their messages live globally.
+			for _, header := range composerHeaders(v) {
+				used[header.id] = true
+				var data any
+				if json.Unmarshal(global["composerData:"+header.id], &data) != nil {
+					continue
+				}
+				for _, c := range findConversations(data, bubble) {
+					c.id = cmp.Or(c.id, header.id)
+					c.title = cmp.Or(c.title, header.title)
+					convs = append(convs, c)
+				}
+			}
+		}
+		for _, c := range convs {
+			if !result.add(opts.App, proj, dir, c, fallback) {
+				result.Skipped++
+			}
+		}
+	}
+	for key := range global {
+		if id, ok := strings.CutPrefix(key, "composerData:"); ok && !used[id] && opts.Project == "" {
+			result.Skipped++
+		}
+	}
+	return result, nil
+}
+
+// add appends c as a session with its prompts and decisions. It reports
+// false for conversations with nothing to import.
+func (r *Result) add(app, proj, dir string, c conversation, fallback time.Time) bool {
+	var hasUser bool
+	for _, m := range c.messages {
+		hasUser = hasUser || m.user
+	}
+	if !hasUser {
+		return false
+	}
+
+	// Best-effort timestamps: messages without one follow the last known
+	// time a second apart, starting from the conversation's own time.
+	at := c.at
+	for _, m := range c.messages {
+		if at.IsZero() && !m.at.IsZero() {
+			at = m.at
+		}
+	}
+	if at.IsZero() {
+		at = fallback
+	}
+	for i := range c.messages {
+		if c.messages[i].at.IsZero() || c.messages[i].at.Before(at) {
+			c.messages[i].at = at
+		}
+		at = c.messages[i].at.Add(time.Second)
+	}
+
+	id := c.id
+	if id == "" {
+		sum := sha256.Sum256([]byte(dir + "\x00" + c.title + "\x00" + c.messages[0].text))
+		id = hex.EncodeToString(sum[:6])
+	}
+	sess := store.Session{
+		ID:        app + "-" + id,
+		Project:   proj,
+		Directory: dir,
+		StartedAt: stamp(c.messages[0].at),
+	}
+	ended := stamp(c.messages[len(c.messages)-1].at)
+	sess.EndedAt = &ended
+	if c.title != "" {
+		summary := appNames[app] + " chat: " + c.title
+		sess.Summary = &summary
+	}
+	r.Data.Sessions = append(r.Data.Sessions, sess)
+
+	saved := 0
+	for _, m := range c.messages {
+		if m.user {
+			r.Data.Prompts = append(r.Data.Prompts, store.Prompt{
+				SessionID: sess.ID,
+				Content:   m.text,
+				Project:   proj,
+				CreatedAt: stamp(m.at),
+			})
+			continue
+		}
+		if saved >= maxObservationsPerChat {
+			continue
+		}
+		typ, paragraph := notableParagraph(m.text)
+		if paragraph == "" {
+			continue
+		}
+		content := paragraph
+		if c.title != "" {
+			content += fmt.Sprintf("\n\nFrom %s chat %q.", appNames[app], c.title)
+		}
+		r.Data.Observations = append(r.Data.Observations, store.Observation{
+			SessionID: sess.ID,
+			Type:      typ,
+			Title:     titleOf(paragraph),
+			Content:   content,
+			Project:   &proj,
+			Scope:     "project",
+			CreatedAt: stamp(m.at),
+			UpdatedAt: stamp(m.at),
+		})
+		saved++
+	}
+	r.Conversations++
+	return true
+}
+
+func stamp(t time.Time) string {
+	return t.UTC().Format(store.TimestampLayout)
+}
+
+// ─── Storage ─────────────────────────────────────────────────────────────────
+
+// readKV loads the key/value rows of table whose key passes keep. A
+// missing file or table yields an empty map. The database is opened
+// read-only and immutable so a running editor is not disturbed.
+func readKV(path, table string, keep func(string) bool) (map[string][]byte, error) {
+	out := map[string][]byte{}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return out, nil
+	}
+	db, err := sql.Open("sqlite", "file:"+(&url.URL{Path: filepath.ToSlash(path)}).EscapedPath()+"?mode=ro&immutable=1")
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer db.Close()
+
+	var exists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if exists == 0 {
+		return out, nil
+	}
+	rows, err := db.Query(`SELECT key, value FROM "` + table + `"`)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		if keep(key) {
+			out[key] = value
+		}
+	}
+	return out, rows.Err()
+}
+
+// isChatKey picks the ItemTable entries that may hold chat state.
+func isChatKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"chat", "composer", "cascade", "conversation"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// workspaceFolder reads the folder a workspace storage dir belongs to from
+// its workspace.json, or "" when there is none (e.g. an empty window).
+func workspaceFolder(dir string) string {
+	raw, err := os.ReadFile(filepath.Join(dir, "workspace.json"))
+	if err != nil {
+		return ""
+	}
+	var ws struct {
+		Folder    string `json:"folder"`
+		Workspace string `json:"workspace"`
+	}
+	if json.Unmarshal(raw, &ws) != nil {
+		return ""
+	}
+	uri := cmp.Or(ws.Folder, ws.Workspace)
+	u, err := url.Parse(uri)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	path := filepath.FromSlash(u.Path)
+	if ws.Folder == "" {
+		path = filepath.Dir(path) // a .code-workspace file
+	}
+	// file:///c%3A/src/app on Windows.
+	if len(path) > 2 && path[0] == filepath.Separator && path[2] == ':' {
+		path = path[1:]
+	}
+	return path
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// ─── Conversations ───────────────────────────────────────────────────────────
+
+type message struct {
+	user bool
+	text string
+	at   time.Time
+}
+
+type conversation struct {
+	id, title string
+	at        time.Time
+	messages  []message
+}
+
+type composerHeader struct {
+	id, title string
+}
+
+// composerHeaders lists the composers a workspace's composer.composerData
+// entry points at.
+func composerHeaders(v any) []composerHeader {
+	obj, _ := v.(map[string]any)
+	list, _ := obj["allComposers"].([]any)
+	var headers []composerHeader
+	for _, item := range list {
+		m, _ := item.(map[string]any)
+		if id := str(m, "composerId"); id != "" {
+			headers = append(headers, composerHeader{id: id, title: str(m, "name")})
+		}
+	}
+	return headers
+}
+
+// findConversations walks v for objects holding a message list. bubble
+// resolves Cursor's split storage, where a composer lists bubble IDs and
+// each bubble is stored under its own key.
+func findConversations(v any, bubble func(composerID, bubbleID string) map[string]any) []conversation {
+	switch v := v.(type) {
+	case []any:
+		var out []conversation
+		for _, item := range v {
+			out = append(out, findConversations(item, bubble)...)
+		}
+		return out
+	case map[string]any:
+		if messages := messageList(v, bubble); len(messages) > 0 {
+			return []conversation{{
+				id:       firstStr(v, "tabId", "composerId", "sessionId", "conversationId", "id"),
+				title:    firstStr(v, "chatTitle", "name", "title"),
+				at:       firstTime(v, "createdAt", "lastSendTime", "lastUpdatedAt", "timestamp"),
+				messages: messages,
+			}}
+		}
+		var out []conversation
+		for _, key := range sortedKeys(v) {
+			out = append(out, findConversations(v[key], bubble)...)
+		}
+		return out
+	}
+	return nil
+}
+
+func messageList(obj map[string]any, bubble func(composerID, bubbleID string) map[string]any) []message {
+	var items []map[string]any
+	for _, key := range []string{"bubbles", "conversation", "messages"} {
+		list, ok := obj[key].([]any)
+		if !ok {
+			continue
+		}
+		for _, item := range list {
+			if m, ok := item.(map[string]any); ok {
+				items = append(items, m)
+			}
+		}
+		break
+	}
+	if headers, ok := obj["fullConversationHeadersOnly"].([]any); ok && len(items) == 0 {
+		composerID := str(obj, "composerId")
+		for _, h := range headers {
+			hm, _ := h.(map[string]any)
+			if m := bubble(composerID, str(hm, "bubbleId")); m != nil {
+				items = append(items, m)
+			}
+		}
+	}
+
+	var messages []message
+	for _, m := range items {
+		if msg, ok := parseMessage(m); ok {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+func parseMessage(m map[string]any) (message, bool) {
+	var msg message
+	switch role := m["role"].(type) {
+	case string:
+		switch strings.ToLower(role) {
+		case "user", "human":
+			msg.user = true
+		case "assistant", "ai", "bot", "model":
+		default:
+			return msg, false
+		}
+	default:
+		switch t := m["type"].(type) {
+		case string:
+			switch strings.ToLower(t) {
+			case "user", "human":
+				msg.user = true
+			case "ai", "assistant", "bot":
+			default:
+				return msg, false
+			}
+		case float64: // Cursor composer bubbles: 1 user, 2 assistant
+			if t != 1 && t != 2 {
+				return msg, false
+			}
+			msg.user = t == 1
+		default:
+			return msg, false
+		}
+	}
+
+	msg.text = strings.TrimSpace(firstStr(m, "text", "rawText", "message"))
+	if msg.text == "" {
+		// OpenAI-style content: a string or a list of {type, text} parts.
+		switch content := m["content"].(type) {
+		case string:
+			msg.text = strings.TrimSpace(content)
+		case []any:
+			var parts []string
+			for _, p := range content {
+				pm, _ := p.(map[string]any)
+				if t := str(pm, "text"); t != "" {
+					parts = append(parts, t)
+				}
+			}
+			msg.text = strings.TrimSpace(strings.Join(parts, "\n"))
+		}
+	}
+	msg.at = firstTime(m, "createdAt", "timestamp", "time")
+	return msg, msg.text != ""
+}
+
+func str(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func firstStr(m map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s := strings.TrimSpace(str(m, key)); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// firstTime reads the first of keys holding a Unix time in seconds or
+// milliseconds, or an RFC 3339 string.
+func firstTime(m map[string]any, keys ...string) time.Time {
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case float64:
+			if v > 1e12 {
+				return time.UnixMilli(int64(v))
+			}
+			if v > 0 {
+				return time.Unix(int64(v), 0)
+			}
+		case string:
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// ─── Decisions ───────────────────────────────────────────────────────────────
+
+var (
+	fixCue      = regexp.MustCompile(`(?i)\b(root cause|the (fix|bug|issue) (is|was)|fixed (it|this|the)|caused by)\b`)
+	decisionCue = regexp.MustCompile(`(?i)\b(decided|decision|going with|chose|opted|we('ll| will) use|i('ll| will) use|instead of|switch(ed)? to|recommend(ed)? using)\b`)
+	sentenceEnd = regexp.MustCompile(`[.!?](\s|$)`)
+)
+
+// notableParagraph returns the first paragraph of an assistant reply that
+// states a fix (bugfix) or a decision, skipping code blocks.
+func notableParagraph(text string) (typ, paragraph string) {
+	inCode := false
+	for _, p := range strings.Split(text, "\n\n") {
+		p = strings.TrimSpace(p)
+		if strings.Count(p, "```")%2 == 1 {
+			inCode = !inCode
+			continue
+		}
+		if inCode || p == "" || strings.HasPrefix(p, "```") || utf8.RuneCountInString(p) < 40 {
+			continue
+		}
+		switch {
+		case fixCue.MatchString(p):
+			return "bugfix", p
+		case decisionCue.MatchString(p):
+			return "decision", p
+		}
+	}
+	return "", ""
+}
+
+// titleOf is the paragraph's first sentence, cut to 80 characters.
+func titleOf(paragraph string) string {
+	line, _, _ := strings.Cut(paragraph, "\n")
+	if loc := sentenceEnd.FindStringIndex(line); loc != nil {
+		line = line[:loc[0]]
+	}
+	line = strings.TrimLeft(strings.TrimSpace(line), "#*- ")
+	if r := []rune(line); len(r) > 80 {
+		line = strings.TrimSpace(string(r[:77])) + "..."
+	}
+	return line
+}
@@ -0,0 +1,156 @@
+package chatimport
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+	"github.com/Gentleman-Programming/engram/internal/store/storetest"
+)
+
+// writeVSCDB creates a state.vscdb holding rows in table.
+func writeVSCDB(t *testing.T, path, table string, rows map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE "` + table + `" (key TEXT UNIQUE ON CONFLICT REPLACE, value BLOB)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	for k, v := range rows {
+		if _, err := db.Exec(`INSERT INTO "`+table+`" (key, value) VALUES (?, ?)`, k, []byte(v)); err != nil {
+			t.Fatalf("insert %s: %v", k, err)
+		}
+	}
+}
+
+func writeWorkspace(t *testing.T, userDir, hash, folder string, rows map[string]string) {
+	t.Helper()
+	dir := filepath.Join(userDir, "workspaceStorage", hash)
+	writeVSCDB(t, filepath.Join(dir, "state.vscdb"), "ItemTable", rows)
+	if err := os.WriteFile(filepath.Join(dir, "workspace.json"), []byte(`{"folder":"file://`+folder+`"}`), 0o644); err != nil {
+		t.Fatalf("write workspace.json: %v", err)
+	}
+}
+
+func TestLoadCursorChatTabsAndComposers(t *testing.T) {
+	user := t.TempDir()
+	writeWorkspace(t, user, "ws1", "/nonexistent/src/billing-api", map[string]string{
+		"workbench.panel.aichat.view.aichat.chatdata": `{"tabs":[{"tabId":"tab1","chatTitle":"Webhook retries","lastSendTime":1735725600000,"bubbles":[
+			{"type":"user","text":"Why do webhooks retry forever?"},
+			{"type":"ai","text":"Let me look.\n\nThe root cause is that the retry loop never checks the attempt counter, so failed webhooks are retried forever."}]}]}`,
+		"composer.composerData": `{"allComposers":[{"composerId":"c1","name":"Queue choice"}]}`,
+		"editor.fontSize":       `14`,
+	})
+	writeWorkspace(t, user, "ws2", "/nonexistent/src/docs-site", map[string]string{
+		"workbench.panel.aichat.view.aichat.chatdata": `{"tabs":[{"tabId":"tab2","bubbles":[{"type":"user","text":"Fix the typo in the header"}]}]}`,
+	})
+	writeVSCDB(t, filepath.Join(user, "globalStorage", "state.vscdb"), "cursorDiskKV", map[string]string{
+		"composerData:c1":     `{"composerId":"c1","createdAt":1735812000000,"fullConversationHeadersOnly":[{"bubbleId":"b1","type":1},{"bubbleId":"b2","type":2}]}`,
+		"bubbleId:c1:b1":      `{"type":1,"text":"Should we use SQS or Redis streams for the job queue?"}`,
+		"bubbleId:c1:b2":      `{"type":2,"text":"We'll use Redis streams instead of SQS because the team already runs Redis and needs replay."}`,
+		"composerData:orphan": `{"composerId":"orphan","conversation":[{"type":1,"text":"hi"}]}`,
+	})
+
+	result, err := Load(Options{App: Cursor, Dir: user, Project: "billing-api"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	data := result.Data
+	if result.Workspaces != 1 || result.Conversations != 2 || len(data.Sessions) != 2 {
+		t.Fatalf("expected the two billing-api conversations, got %+v sessions=%+v", result, data.Sessions)
+	}
+	// Entries are read in key order: composer.composerData before the chat tabs.
+	if data.Sessions[0].ID != "cursor-c1" || data.Sessions[0].StartedAt != "2025-01-02T10:00:00Z" {
+		t.Fatalf("expected the composer resolved from global storage, got %+v", data.Sessions[0])
+	}
+	sess := data.Sessions[1]
+	if sess.ID != "cursor-tab1" || sess.Project != "billing-api" || sess.StartedAt != "2025-01-01T10:00:00Z" || sess.Summary == nil || *sess.Summary != "Cursor chat: Webhook retries" {
+		t.Fatalf("unexpected chat tab session: %+v", sess)
+	}
+	if len(data.Prompts) != 2 || data.Prompts[0].Content != "Should we use SQS or Redis streams for the job queue?" {
+		t.Fatalf("expected one prompt per user message, got %+v", data.Prompts)
+	}
+	if len(data.Observations) != 2 || data.Observations[0].Type != "decision" || data.Observations[1].Type != "bugfix" ||
+		!strings.HasPrefix(data.Observations[0].Title, "We'll use Redis streams instead of SQS") || !strings.HasSuffix(data.Observations[0].Title, "...") {
+		t.Fatalf("expected a decision and a bugfix, got %+v", data.Observations)
+	}
+	if !strings.Contains(data.Observations[1].Content, `From Cursor chat "Webhook retries".`) || data.Observations[1].CreatedAt != "2025-01-01T10:00:01Z" {
+		t.Fatalf("expected the observation to cite its chat one second after the prompt, got %+v", data.Observations[1])
+	}
+
+	s := storetest.New(t)
+	imported, err := s.ImportWithOptions(data, store.ImportOptions{Source: SourceFor(Cursor)})
+	if err != nil || imported.SessionsImported != 2 || imported.ObservationsImported != 2 || imported.PromptsImported != 2 {
+		t.Fatalf("expected everything imported, got %+v err=%v", imported, err)
+	}
+	again, err := Load(Options{App: Cursor, Dir: user, Project: "billing-api"})
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	imported, err = s.ImportWithOptions(again.Data, store.ImportOptions{Source: SourceFor(Cursor)})
+	if err != nil || imported.SessionsImported != 0 || imported.ObservationsImported != 0 || imported.PromptsImported != 0 {
+		t.Fatalf("expected a second import to skip everything, got %+v err=%v", imported, err)
+	}
+
+	all, err := Load(Options{App: Cursor, Dir: user})
+	if err != nil || all.Workspaces != 2 || all.Conversations != 3 || all.Skipped != 1 {
+		t.Fatalf("expected every workspace and the orphan composer skipped, got %+v err=%v", all, err)
+	}
+}
+
+func TestLoadWindsurfRoleContentMessages(t *testing.T) {
+	user := t.TempDir()
+	writeWorkspace(t, user, "ws", "/nonexistent/src/engram", map[string]string{
+		"windsurf.cascade.history": `{"conversations":[{"id":"w1","title":"Lint setup","messages":[
+			{"role":"user","content":"Set up golangci-lint for this repo","timestamp":"2025-01-03T10:00:00Z"},
+			{"role":"assistant","content":[{"type":"text","text":"I decided to enable errcheck and staticcheck instead of the default preset, since they catch the bugs this repo keeps hitting."}]},
+			{"role":"tool","content":"ignored"}]}]}`,
+	})
+
+	result, err := Load(Options{App: Windsurf, Dir: user})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	data := result.Data
+	if len(data.Sessions) != 1 || data.Sessions[0].ID != "windsurf-w1" || *data.Sessions[0].EndedAt != "2025-01-03T10:00:01Z" {
+		t.Fatalf("unexpected sessions: %+v", data.Sessions)
+	}
+	if len(data.Prompts) != 1 || len(data.Observations) != 1 || data.Observations[0].Type != "decision" {
+		t.Fatalf("expected one prompt and one decision, got %+v %+v", data.Prompts, data.Observations)
+	}
+
+	if _, err := Load(Options{App: Windsurf, Dir: t.TempDir()}); err == nil || !strings.Contains(err.Error(), "no Windsurf workspaces") {
+		t.Fatalf("expected an empty dir to be reported, got %v", err)
+	}
+	if _, err := Load(Options{App: "vim", Dir: user}); err == nil {
+		t.Fatal("expected an unknown app to be rejected")
+	}
+}
+
+func TestDefaultDir(t *testing.T) {
+	env := map[string]string{"APPDATA": `C:\Users\me\AppData\Roaming`}
+	getenv := func(k string) string { return env[k] }
+	tests := map[string]string{
+		"darwin":  filepath.Join("/home/me", "Library", "Application Support", "Cursor", "User"),
+		"windows": filepath.Join(`C:\Users\me\AppData\Roaming`, "Cursor", "User"),
+		"linux":   filepath.Join("/home/me", ".config", "Cursor", "User"),
+	}
+	for goos, want := range tests {
+		if got, err := DefaultDir(Cursor, goos, "/home/me", getenv); err != nil || got != want {
+			t.Errorf("DefaultDir(%s) = %q, %v; want %q", goos, got, err, want)
+		}
+	}
+	env["XDG_CONFIG_HOME"] = "/xdg"
+	if got, _ := DefaultDir(Windsurf, "linux", "/home/me", getenv); got != filepath.Join("/xdg", "Windsurf", "User") {
+		t.Errorf("expected XDG_CONFIG_HOME to win, got %q", got)
+	}
+}
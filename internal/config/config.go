@@ -0,0 +1,1115 @@
+// Package config loads the optional .engram.toml configuration file and
+// applies it on top of the built-in store defaults.
+//
+// Lookup order (first existing file wins):
+//
+//	ENGRAM_CONFIG       explicit path
+//	./.engram.toml      per-project file in the working directory
+//	~/.engram.toml      user-wide file
+//
+// A missing file is not an error; engram runs on defaults.
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/Gentleman-Programming/engram/internal/backup"
+	"github.com/Gentleman-Programming/engram/internal/enrich"
+	"github.com/Gentleman-Programming/engram/internal/gc"
+	"github.com/Gentleman-Programming/engram/internal/i18n"
+	"github.com/Gentleman-Programming/engram/internal/mcp"
+	"github.com/Gentleman-Programming/engram/internal/notify"
+	"github.com/Gentleman-Programming/engram/internal/server"
+	"github.com/Gentleman-Programming/engram/internal/store"
+	engramsync "github.com/Gentleman-Programming/engram/internal/sync"
+	"github.com/Gentleman-Programming/engram/internal/translate"
+)
+
+// FileName is the config file name searched in the working and home dirs.
+const FileName = ".engram.toml"
+
+// File mirrors the TOML layout of .engram.toml.
+//
+//	[dedupe]
+//	window = "15m"
+//
+//	[dedupe.types.tool_use]
+//	strategy = "content"
+//	window = "2h"
+//
+//	[dedupe.types.decision]
+//	strategy = "off"
+//
+//	[server]
+//	port = 7437
+//	auth_token = "s3cret"
+//
+//	[server.tenants.alice]
+//	token_env = "ENGRAM_TOKEN_ALICE"
+//	data_dir = "/srv/engram/alice"
+//
+//	[server.cors]
+//	allowed_origins = ["http://localhost:3000"]
+//	allow_credentials = true
+//	max_age = "10m"
+//
+//	[backup]
+//	interval = "6h"
+//	retention = 14
+//
+//	[gc]
+//	interval = "24h"
+//
+//	[notify]
+//	enabled = true
+//	events = ["session_summary", "sync_import"]
+//	sync_types = ["decision", "architecture"]
+//
+//	[capture]
+//	learning_headers = ['Retro\s+notes', 'Aprendido']
+//
+//	[search.weights]
+//	title = 12.0
+//	content = 0.5
+//
+//	[[context.sections]]
+//	title = "Decisions"
+//	types = ["decision", "architecture"]
+//	limit = 5
+//
+//	[[context.sections]]
+//	title = "Recent Activity"
+//
+//	[context.exclude]
+//	types = ["file_read", "search"]
+//	tool_names = ["Grep"]
+//	min_content_length = 40
+//
+//	[display]
+//	timezone = "America/Argentina/Buenos_Aires"
+//	locale = "es"
+//
+//	[quota]
+//	max_observations = 50000
+//	max_bytes = "500MB"
+//
+//	[quota.projects.billing]
+//	max_bytes = "50MB"
+//
+//	[working_memory]
+//	ttl = "8h"
+//
+//	[storage]
+//	compress_above = "4KB"
+//	event_retention = "720h"
+//
+//	[enrich]
+//	endpoint = "http://localhost:11434/v1/chat/completions"
+//	model = "llama3.1"
+//	requests_per_minute = 10
+//
+//	[translate]
+//	endpoint = "http://localhost:11434/v1/chat/completions"
+//	model = "llama3.1"
+//	target = "en"
+//	languages = ["en", "es"]
+//
+//	[sync]
+//	personal = "deny"
+//
+//	[audit]
+//	append_only = true
+//
+//	[personal_sync]
+//	relay = "https://relay.example.com/engram/"
+//
+//	[mcp.rate_limits]
+//	mem_save = "60/1m"
+//	mem_capture_passive = "off"
+//
+//	[profiles.work]
+//	data_dir = "/home/me/.engram-work"
+//
+//	[templates.postmortem]
+//	description = "Blameless postmortem"
+//	type = "bugfix"
+//	topic_family = "bug"
+//	sections = ["Summary", "Timeline", "Root Cause", "Action Items"]
+type File struct {
+	Dedupe        DedupeSection              `toml:"dedupe"`
+	Server        ServerSection              `toml:"server"`
+	Backup        BackupSection              `toml:"backup"`
+	GC            GCSection                  `toml:"gc"`
+	Notify        NotifySection              `toml:"notify"`
+	Capture       CaptureSection             `toml:"capture"`
+	Search        SearchSection              `toml:"search"`
+	Context       ContextSection             `toml:"context"`
+	Display       DisplaySection             `toml:"display"`
+	Quota         QuotaSection               `toml:"quota"`
+	WorkingMemory WorkingMemorySection       `toml:"working_memory"`
+	Storage       StorageSection             `toml:"storage"`
+	Enrich        EnrichSection              `toml:"enrich"`
+	Translate     TranslateSection           `toml:"translate"`
+	Sync          SyncSection                `toml:"sync"`
+	Audit         AuditSection               `toml:"audit"`
+	PersonalSync  PersonalSyncSection        `toml:"personal_sync"`
+	MCP           MCPSection                 `toml:"mcp"`
+	Profiles      map[string]ProfileSection  `toml:"profiles"`
+	Templates     map[string]TemplateSection `toml:"templates"`
+
+	// Path is the file the config was read from ("" when none was found).
+	Path string `toml:"-"`
+}
+
+// DedupeSection configures AddObservation duplicate detection.
+type DedupeSection struct {
+	Window string                      `toml:"window"`
+	Types  map[string]DedupeTypeConfig `toml:"types"`
+}
+
+// DedupeTypeConfig is the per-observation-type override.
+type DedupeTypeConfig struct {
+	Strategy string `toml:"strategy"`
+	Window   string `toml:"window"`
+}
+
+// ServerSection configures `engram serve`. ENGRAM_PORT, ENGRAM_HTTP_TOKEN,
+// and ENGRAM_CORS_ORIGINS override it at startup.
+type ServerSection struct {
+	Port      int           `toml:"port"`
+	AuthToken string        `toml:"auth_token"`
+	CORS      CORSSection   `toml:"cors"`
+	Ingest    IngestSection `toml:"ingest"`
+	// Tenants, keyed by tenant name, turn on tenant mode; see TenantSection.
+	Tenants map[string]TenantSection `toml:"tenants"`
+}
+
+// TenantSection gives one teammate a private store in tenant mode. Requests
+// carrying the tenant's token are served from data_dir and nothing else;
+// auth_token becomes the operator token.
+//
+//	[server.tenants.alice]
+//	token_env = "ENGRAM_TOKEN_ALICE"
+//	data_dir = "/srv/engram/alice"
+type TenantSection struct {
+	Token    string `toml:"token"`
+	TokenEnv string `toml:"token_env"` // wins over token when set
+	DataDir  string `toml:"data_dir"`
+}
+
+// Tenant is one resolved [server.tenants] entry.
+type Tenant struct {
+	Name    string
+	Token   string
+	DataDir string
+}
+
+// ResolveTenants returns the configured tenants sorted by name, with tokens
+// read from their token_env variables.
+func (s ServerSection) ResolveTenants(getenv func(string) string) ([]Tenant, error) {
+	names := slices.Sorted(maps.Keys(s.Tenants))
+	tenants := make([]Tenant, 0, len(names))
+	for _, name := range names {
+		t := s.Tenants[name]
+		if err := server.ValidateMountName(name); err != nil {
+			return nil, fmt.Errorf("engram config: server.tenants: %w", err)
+		}
+		if strings.TrimSpace(t.DataDir) == "" {
+			return nil, fmt.Errorf("engram config: server.tenants.%s.data_dir is required", name)
+		}
+		token := t.Token
+		if t.TokenEnv != "" {
+			token = getenv(t.TokenEnv)
+			if token == "" {
+				return nil, fmt.Errorf("engram config: server.tenants.%s: $%s is empty", name, t.TokenEnv)
+			}
+		}
+		if token == "" {
+			return nil, fmt.Errorf("engram config: server.tenants.%s needs token or token_env", name)
+		}
+		tenants = append(tenants, Tenant{Name: name, Token: token, DataDir: t.DataDir})
+	}
+	return tenants, nil
+}
+
+// CORSSection configures cross-origin access for browser clients.
+type CORSSection struct {
+	AllowedOrigins   []string `toml:"allowed_origins"`
+	AllowCredentials bool     `toml:"allow_credentials"`
+	MaxAge           string   `toml:"max_age"`
+}
+
+// Options converts the section into server middleware options.
+func (c CORSSection) Options() (server.CORSOptions, error) {
+	opts := server.CORSOptions{
+		AllowedOrigins:   c.AllowedOrigins,
+		AllowCredentials: c.AllowCredentials,
+	}
+	if c.MaxAge != "" {
+		maxAge, err := time.ParseDuration(c.MaxAge)
+		if err != nil {
+			return opts, fmt.Errorf("engram config: server.cors.max_age: %w", err)
+		}
+		opts.MaxAge = maxAge
+	}
+	return opts, nil
+}
+
+// IngestSection configures the write-ahead queue for POST /observations.
+// The queue is off unless queue_size is set; ENGRAM_INGEST_QUEUE overrides
+// queue_size at startup.
+type IngestSection struct {
+	QueueSize      int    `toml:"queue_size"`
+	BatchSize      int    `toml:"batch_size"`
+	EnqueueTimeout string `toml:"enqueue_timeout"`
+}
+
+// Options converts the section into server ingestion options.
+func (i IngestSection) Options() (server.IngestOptions, error) {
+	opts := server.IngestOptions{QueueSize: i.QueueSize, BatchSize: i.BatchSize}
+	if i.QueueSize < 0 {
+		return opts, fmt.Errorf("engram config: server.ingest.queue_size: must not be negative")
+	}
+	if i.EnqueueTimeout != "" {
+		timeout, err := time.ParseDuration(i.EnqueueTimeout)
+		if err != nil {
+			return opts, fmt.Errorf("engram config: server.ingest.enqueue_timeout: %w", err)
+		}
+		opts.EnqueueTimeout = timeout
+	}
+	return opts, nil
+}
+
+// BackupSection configures scheduled snapshots while `engram serve` runs.
+// Backups are off unless an interval is set; ENGRAM_BACKUP_INTERVAL
+// overrides it at startup.
+type BackupSection struct {
+	Interval  string `toml:"interval"`
+	Retention int    `toml:"retention"`
+	// Dir defaults to {data dir}/backups.
+	Dir string `toml:"dir"`
+}
+
+// Options converts the section into scheduler options for the store in
+// dataDir. A zero Interval in the result means backups are disabled.
+func (b BackupSection) Options(dataDir string) (backup.Options, error) {
+	opts := backup.Options{Dir: b.Dir, Retention: b.Retention}
+	if opts.Dir == "" {
+		opts.Dir = filepath.Join(dataDir, backup.DirName)
+	}
+	if b.Interval == "" {
+		return opts, nil
+	}
+	interval, err := time.ParseDuration(b.Interval)
+	if err != nil {
+		return opts, fmt.Errorf("engram config: backup.interval: %w", err)
+	}
+	if interval < backup.MinInterval {
+		return opts, fmt.Errorf("engram config: backup.interval %q is shorter than %s", b.Interval, backup.MinInterval)
+	}
+	if b.Retention < 0 {
+		return opts, fmt.Errorf("engram config: backup.retention must not be negative")
+	}
+	opts.Interval = interval
+	return opts, nil
+}
+
+// GCSection schedules `engram gc` while `engram serve` runs. It is off
+// unless an interval is set; ENGRAM_GC_INTERVAL overrides it at startup.
+type GCSection struct {
+	Interval string `toml:"interval"`
+}
+
+// Options converts the section into scheduler options. A zero Interval in
+// the result means scheduled GC is disabled.
+func (g GCSection) Options() (gc.Options, error) {
+	if g.Interval == "" {
+		return gc.Options{}, nil
+	}
+	interval, err := time.ParseDuration(g.Interval)
+	if err != nil {
+		return gc.Options{}, fmt.Errorf("engram config: gc.interval: %w", err)
+	}
+	if interval < gc.MinInterval {
+		return gc.Options{}, fmt.Errorf("engram config: gc.interval %q is shorter than %s", g.Interval, gc.MinInterval)
+	}
+	return gc.Options{Interval: interval}, nil
+}
+
+// NotifySection configures desktop notifications from `engram serve`. They
+// are off unless enabled; ENGRAM_NOTIFY overrides enabled at startup.
+type NotifySection struct {
+	Enabled bool `toml:"enabled"`
+	// Events defaults to every kind: session_summary and sync_import.
+	Events []string `toml:"events"`
+	// SyncTypes are the imported observation types that notify (default
+	// decision and architecture).
+	SyncTypes []string `toml:"sync_types"`
+	Interval  string   `toml:"interval"`
+}
+
+// Options converts the section into watcher options. Disabled sections
+// return ok=false.
+func (n NotifySection) Options() (opts notify.Options, ok bool, err error) {
+	if !n.Enabled {
+		return opts, false, nil
+	}
+	opts = notify.Options{Events: n.Events, SyncTypes: n.SyncTypes}
+	for _, kind := range n.Events {
+		if !slices.Contains(notify.Kinds, kind) {
+			return opts, false, fmt.Errorf("engram config: notify.events: unknown event %q (want one of %s)", kind, strings.Join(notify.Kinds, ", "))
+		}
+	}
+	if n.Interval != "" {
+		interval, err := time.ParseDuration(n.Interval)
+		if err != nil {
+			return opts, false, fmt.Errorf("engram config: notify.interval: %w", err)
+		}
+		if interval < notify.MinInterval {
+			return opts, false, fmt.Errorf("engram config: notify.interval %q is shorter than %s", n.Interval, notify.MinInterval)
+		}
+		opts.Interval = interval
+	}
+	return opts, true, nil
+}
+
+// CaptureSection configures passive learning capture.
+type CaptureSection struct {
+	// LearningHeaders are extra section-title regexes recognized on top of
+	// store.DefaultLearningHeaders.
+	LearningHeaders []string `toml:"learning_headers"`
+}
+
+// SearchSection configures search ranking.
+type SearchSection struct {
+	// Weights override store.DefaultSearchWeights per column: title,
+	// content, tool_name, type, project, topic_key. Columns not listed keep
+	// their default.
+	Weights map[string]float64 `toml:"weights"`
+}
+
+// ContextSection configures the memory context block.
+type ContextSection struct {
+	// Sections split observations into headed sections, in this order
+	// (see store.ContextGroup). Empty keeps one "Recent Observations" list.
+	Sections []ContextSectionEntry `toml:"sections"`
+	// Exclude keeps noisy observations out of context unless a reader asks
+	// for everything (see store.ContextExclusion).
+	Exclude ContextExcludeSection `toml:"exclude"`
+}
+
+// ContextExcludeSection is the [context.exclude] table.
+type ContextExcludeSection struct {
+	Types            []string `toml:"types"`
+	ToolNames        []string `toml:"tool_names"`
+	MinContentLength int      `toml:"min_content_length"`
+}
+
+// ContextSectionEntry is one [[context.sections]] table.
+type ContextSectionEntry struct {
+	Title string   `toml:"title"`
+	Types []string `toml:"types"` // empty collects every type no other section lists
+	Limit int      `toml:"limit"` // 0 uses the store's MaxContextResults
+}
+
+// DisplaySection configures how timestamps and messages are shown. ENGRAM_TZ
+// and ENGRAM_LOCALE override it at startup.
+type DisplaySection struct {
+	// Timezone is an IANA zone name, "UTC", or "Local" (the default).
+	Timezone string `toml:"timezone"`
+	// Locale is the language of CLI, TUI, and MCP text: "en" (default) or "es".
+	Locale string `toml:"locale"`
+}
+
+// QuotaSection caps how much each project may store. The top-level limits
+// apply to every project without its own entry under projects.
+type QuotaSection struct {
+	MaxObservations int                    `toml:"max_observations"`
+	MaxBytes        string                 `toml:"max_bytes"`
+	Projects        map[string]QuotaLimits `toml:"projects"`
+}
+
+// QuotaLimits is one project's quota. MaxBytes takes a size such as
+// "500MB"; zero or empty fields are unlimited.
+type QuotaLimits struct {
+	MaxObservations int    `toml:"max_observations"`
+	MaxBytes        string `toml:"max_bytes"`
+}
+
+func (l QuotaLimits) quota() (store.Quota, error) {
+	if l.MaxObservations < 0 {
+		return store.Quota{}, fmt.Errorf("max_observations must not be negative")
+	}
+	size, err := ParseSize(l.MaxBytes)
+	if err != nil {
+		return store.Quota{}, fmt.Errorf("max_bytes: %w", err)
+	}
+	return store.Quota{MaxObservations: l.MaxObservations, MaxBytes: size}, nil
+}
+
+// TemplateSection defines an observation template, keyed by name. A name
+// matching a built-in template (adr, incident) replaces it.
+type TemplateSection struct {
+	Description string `toml:"description"`
+	// Type defaults to manual.
+	Type        string   `toml:"type"`
+	TopicFamily string   `toml:"topic_family"`
+	Sections    []string `toml:"sections"`
+}
+
+// WorkingMemorySection configures the per-session scratchpad.
+type WorkingMemorySection struct {
+	// TTL is how long items live unless set with their own (default 24h).
+	TTL string `toml:"ttl"`
+}
+
+// StorageSection configures how the SQLite database stores rows.
+type StorageSection struct {
+	// CompressAbove gzips observation content larger than this size, such
+	// as "4KB". Empty or zero stores all content as text.
+	CompressAbove string `toml:"compress_above"`
+	// EventRetention is how long `engram gc` keeps changefeed events, such
+	// as "720h" (the default).
+	EventRetention string `toml:"event_retention"`
+}
+
+// EnrichSection configures LLM enrichment of new observations. It is off
+// unless an endpoint is set. The API key is read from the environment
+// variable named by api_key_env (default ENGRAM_ENRICH_API_KEY), never from
+// the file.
+type EnrichSection struct {
+	Endpoint          string `toml:"endpoint"`
+	Model             string `toml:"model"`
+	APIKeyEnv         string `toml:"api_key_env"`
+	RequestsPerMinute int    `toml:"requests_per_minute"`
+	Interval          string `toml:"interval"`
+	Batch             int    `toml:"batch"`
+	Timeout           string `toml:"timeout"`
+}
+
+// DefaultEnrichAPIKeyEnv holds the enrichment API key unless api_key_env
+// names another variable.
+const DefaultEnrichAPIKeyEnv = "ENGRAM_ENRICH_API_KEY"
+
+// Options converts the section into enrichment options. A result without
+// an endpoint means enrichment is disabled.
+func (e EnrichSection) Options(getenv func(string) string) (enrich.Options, error) {
+	opts := enrich.Options{
+		Endpoint:          strings.TrimSpace(e.Endpoint),
+		Model:             strings.TrimSpace(e.Model),
+		RequestsPerMinute: e.RequestsPerMinute,
+		BatchSize:         e.Batch,
+	}
+	if !opts.Enabled() {
+		return opts, nil
+	}
+	if opts.Model == "" {
+		return opts, fmt.Errorf("engram config: enrich.model is required with enrich.endpoint")
+	}
+	if e.RequestsPerMinute < 0 || e.Batch < 0 {
+		return opts, fmt.Errorf("engram config: enrich.requests_per_minute and enrich.batch must not be negative")
+	}
+	if e.Interval != "" {
+		interval, err := time.ParseDuration(e.Interval)
+		if err != nil {
+			return opts, fmt.Errorf("engram config: enrich.interval: %w", err)
+		}
+		opts.Interval = interval
+	}
+	if e.Timeout != "" {
+		timeout, err := time.ParseDuration(e.Timeout)
+		if err != nil {
+			return opts, fmt.Errorf("engram config: enrich.timeout: %w", err)
+		}
+		opts.Timeout = timeout
+	}
+	keyEnv := e.APIKeyEnv
+	if keyEnv == "" {
+		keyEnv = DefaultEnrichAPIKeyEnv
+	}
+	opts.APIKey = getenv(keyEnv)
+	return opts, nil
+}
+
+// TranslateSection configures translation of memories on retrieval. It is
+// off unless an endpoint is set. The API key is read from the environment
+// variable named by api_key_env (default ENGRAM_TRANSLATE_API_KEY).
+type TranslateSection struct {
+	Endpoint  string   `toml:"endpoint"`
+	Model     string   `toml:"model"`
+	APIKeyEnv string   `toml:"api_key_env"`
+	Target    string   `toml:"target"`
+	Languages []string `toml:"languages"`
+	Timeout   string   `toml:"timeout"`
+}
+
+// DefaultTranslateAPIKeyEnv holds the translation API key unless
+// api_key_env names another variable.
+const DefaultTranslateAPIKeyEnv = "ENGRAM_TRANSLATE_API_KEY"
+
+// Options converts the section into translation options. A result without
+// an endpoint means translation is disabled.
+func (t TranslateSection) Options(getenv func(string) string) (translate.Options, error) {
+	opts := translate.Options{
+		Endpoint:  strings.TrimSpace(t.Endpoint),
+		Model:     strings.TrimSpace(t.Model),
+		Target:    strings.TrimSpace(t.Target),
+		Languages: t.Languages,
+	}
+	if !opts.Enabled() {
+		return opts, nil
+	}
+	if opts.Model == "" {
+		return opts, fmt.Errorf("engram config: translate.model is required with translate.endpoint")
+	}
+	if t.Timeout != "" {
+		timeout, err := time.ParseDuration(t.Timeout)
+		if err != nil {
+			return opts, fmt.Errorf("engram config: translate.timeout: %w", err)
+		}
+		opts.Timeout = timeout
+	}
+	keyEnv := t.APIKeyEnv
+	if keyEnv == "" {
+		keyEnv = DefaultTranslateAPIKeyEnv
+	}
+	opts.APIKey = getenv(keyEnv)
+	return opts, nil
+}
+
+// SyncSection configures team sharing: `engram sync` and GET /export.
+type SyncSection struct {
+	// Personal is the personal-scope policy: "exclude" (default) leaves
+	// personal observations out unless --include-personal is given,
+	// "include" always shares them, "deny" never does.
+	Personal string `toml:"personal"`
+}
+
+// AuditSection configures compliance settings.
+type AuditSection struct {
+	// AppendOnly turns on store.Config.AppendOnly: edits add superseding
+	// revisions and nothing is hard-deleted.
+	AppendOnly bool `toml:"append_only"`
+}
+
+// MCPSection configures `engram mcp`.
+type MCPSection struct {
+	// RateLimits caps calls per tool and MCP session, written CALLS/PER
+	// ("30/1m") or "off". Entries overlay mcp.DefaultRateLimits.
+	RateLimits map[string]string `toml:"rate_limits"`
+}
+
+// Limits returns mcp.DefaultRateLimits with the configured entries applied.
+func (m MCPSection) Limits() (map[string]mcp.RateLimit, error) {
+	limits := maps.Clone(mcp.DefaultRateLimits)
+	for name, raw := range m.RateLimits {
+		limit, err := mcp.ParseRateLimit(raw)
+		if err != nil {
+			return nil, fmt.Errorf("engram config: mcp.rate_limits.%s: %w", name, err)
+		}
+		limits[name] = limit
+	}
+	if err := mcp.ValidateRateLimits(limits); err != nil {
+		return nil, fmt.Errorf("engram config: mcp.rate_limits: %w", err)
+	}
+	return limits, nil
+}
+
+// PersonalSyncSection configures `engram sync --personal`. The passphrase
+// and the relay token are read from the environment variables named by
+// passphrase_env and token_env, never from the file.
+type PersonalSyncSection struct {
+	Relay         string `toml:"relay"`
+	TokenEnv      string `toml:"token_env"`
+	PassphraseEnv string `toml:"passphrase_env"`
+}
+
+// Default variables for the personal sync secrets and relay override.
+const (
+	DefaultPersonalSyncPassphraseEnv = "ENGRAM_SYNC_PASSPHRASE"
+	DefaultPersonalSyncTokenEnv      = "ENGRAM_RELAY_TOKEN"
+	PersonalSyncRelayEnv             = "ENGRAM_SYNC_RELAY"
+)
+
+// Options converts the section into personal sync options. ENGRAM_SYNC_RELAY
+// overrides relay.
+func (p PersonalSyncSection) Options(getenv func(string) string) (engramsync.PersonalOptions, error) {
+	opts := engramsync.PersonalOptions{Relay: strings.TrimSpace(p.Relay)}
+	if v := strings.TrimSpace(getenv(PersonalSyncRelayEnv)); v != "" {
+		opts.Relay = v
+	}
+	tokenEnv := cmp.Or(p.TokenEnv, DefaultPersonalSyncTokenEnv)
+	passphraseEnv := cmp.Or(p.PassphraseEnv, DefaultPersonalSyncPassphraseEnv)
+	opts.Token = getenv(tokenEnv)
+	opts.Passphrase = getenv(passphraseEnv)
+	if opts.Relay == "" {
+		return opts, fmt.Errorf("engram config: personal_sync.relay is not set (or set %s)", PersonalSyncRelayEnv)
+	}
+	if opts.Passphrase == "" {
+		return opts, fmt.Errorf("engram config: personal sync needs a passphrase in $%s", passphraseEnv)
+	}
+	return opts, nil
+}
+
+// ProfileSection is one [profiles.<name>] table: a store `engram tui` can
+// switch to at runtime.
+type ProfileSection struct {
+	DataDir string `toml:"data_dir"`
+}
+
+// Profile is one resolved [profiles] entry.
+type Profile struct {
+	Name    string
+	DataDir string
+}
+
+// ResolveProfiles returns the configured profiles sorted by name.
+func (f *File) ResolveProfiles() ([]Profile, error) {
+	names := slices.Sorted(maps.Keys(f.Profiles))
+	profiles := make([]Profile, 0, len(names))
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("engram config: profiles: invalid profile name %q", name)
+		}
+		dir := strings.TrimSpace(f.Profiles[name].DataDir)
+		if dir == "" {
+			return nil, fmt.Errorf("engram config: profiles.%s.data_dir is required", name)
+		}
+		profiles = append(profiles, Profile{Name: name, DataDir: dir})
+	}
+	return profiles, nil
+}
+
+// ToolsFileName is the MCP tool override file `engram mcp` reads from the
+// data dir when neither --tools-file nor ENGRAM_TOOLS_FILE names another.
+const ToolsFileName = "tools.toml"
+
+// ToolSection is one tool's entry in tools.toml, keyed by tool name:
+//
+//	[mem_save]
+//	description = """Save what you learned. Keep it short."""
+//	title = "Remember"
+//
+//	[mem_save.params]
+//	content = "What, Why, Where, Learned — one line each"
+//
+//	[mem_delete]
+//	destructive = true
+type ToolSection struct {
+	Description string            `toml:"description"`
+	Title       string            `toml:"title"`
+	ReadOnly    *bool             `toml:"read_only"`
+	Destructive *bool             `toml:"destructive"`
+	Idempotent  *bool             `toml:"idempotent"`
+	OpenWorld   *bool             `toml:"open_world"`
+	Params      map[string]string `toml:"params"`
+}
+
+// LoadTools parses the tool override file at path and checks it against
+// the built-in tools. A missing file at an optional path (required=false)
+// returns no overrides.
+func LoadTools(path string, required bool) (map[string]mcp.ToolOverride, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !required {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("engram tools: read %s: %w", path, err)
+	}
+	var sections map[string]ToolSection
+	if err := toml.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("engram tools: parse %s: %w", path, err)
+	}
+	overrides := make(map[string]mcp.ToolOverride, len(sections))
+	for name, t := range sections {
+		overrides[name] = mcp.ToolOverride{
+			Description: strings.TrimSpace(t.Description),
+			Title:       strings.TrimSpace(t.Title),
+			ReadOnly:    t.ReadOnly,
+			Destructive: t.Destructive,
+			Idempotent:  t.Idempotent,
+			OpenWorld:   t.OpenWorld,
+			Params:      t.Params,
+		}
+	}
+	if err := mcp.ValidateToolOverrides(overrides); err != nil {
+		return nil, fmt.Errorf("%w (in %s)", err, path)
+	}
+	return overrides, nil
+}
+
+// Find returns the first config file in the lookup order, or "" when none
+// exists. getenv and homeDir are passed in so callers can test it.
+func Find(getenv func(string) string, workDir, homeDir string) string {
+	if explicit := getenv("ENGRAM_CONFIG"); explicit != "" {
+		return explicit
+	}
+	for _, dir := range []string{workDir, homeDir} {
+		if dir == "" {
+			continue
+		}
+		candidate := filepath.Join(dir, FileName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// Load parses the config file at path. An empty path returns an empty File.
+func Load(path string) (*File, error) {
+	f := &File{}
+	if path == "" {
+		return f, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("engram config: %s not found", path)
+		}
+		return nil, fmt.Errorf("engram config: read %s: %w", path, err)
+	}
+	if err := toml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("engram config: parse %s: %w", path, err)
+	}
+	f.Path = path
+	return f, nil
+}
+
+// ReloadableSections are the sections `engram serve` applies without a
+// restart when its config file changes. backup.dir and turning backups or
+// notifications on or off still need one.
+var ReloadableSections = []string{"dedupe", "quota", "working_memory", "backup", "notify"}
+
+// RestartSections returns the sections that differ between f and next and
+// only take effect on restart, in file order.
+func (f *File) RestartSections(next *File) []string {
+	var changed []string
+	cur, nxt := reflect.ValueOf(*f), reflect.ValueOf(*next)
+	for i := range cur.NumField() {
+		name, _, _ := strings.Cut(cur.Type().Field(i).Tag.Get("toml"), ",")
+		if name == "" || name == "-" || slices.Contains(ReloadableSections, name) {
+			continue
+		}
+		if !reflect.DeepEqual(cur.Field(i).Interface(), nxt.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// Apply overlays the file settings on cfg. Per-type dedupe entries are
+// merged with the built-in ones, so a file only needs to list the types it
+// changes.
+func (f *File) Apply(cfg *store.Config) error {
+	if f.Dedupe.Window != "" {
+		window, err := parseWindow(f.Dedupe.Window)
+		if err != nil {
+			return fmt.Errorf("engram config: dedupe.window: %w", err)
+		}
+		cfg.DedupeWindow = window
+	}
+
+	if len(f.Capture.LearningHeaders) > 0 {
+		if _, err := store.LearningHeaderPattern(f.Capture.LearningHeaders); err != nil {
+			return fmt.Errorf("engram config: capture.learning_headers: %w", err)
+		}
+		cfg.LearningHeaders = append(cfg.LearningHeaders, f.Capture.LearningHeaders...)
+	}
+
+	if f.Display.Timezone != "" {
+		loc, err := store.LoadDisplayLocation(f.Display.Timezone)
+		if err != nil {
+			return fmt.Errorf("engram config: display.timezone: %w", err)
+		}
+		cfg.DisplayLocation = loc
+	}
+
+	if f.Display.Locale != "" {
+		locale, err := i18n.Parse(f.Display.Locale)
+		if err != nil {
+			return fmt.Errorf("engram config: display.locale: %w", err)
+		}
+		cfg.Locale = locale
+	}
+
+	if len(f.Search.Weights) > 0 {
+		weights := cfg.SearchWeights
+		if weights == (store.SearchWeights{}) {
+			weights = store.DefaultSearchWeights()
+		}
+		columns := map[string]*float64{
+			"title":     &weights.Title,
+			"content":   &weights.Content,
+			"tool_name": &weights.ToolName,
+			"type":      &weights.Type,
+			"project":   &weights.Project,
+			"topic_key": &weights.TopicKey,
+		}
+		for column, weight := range f.Search.Weights {
+			target, ok := columns[column]
+			if !ok {
+				return fmt.Errorf("engram config: search.weights: unknown column %q (want title, content, tool_name, type, project, topic_key)", column)
+			}
+			if weight < 0 {
+				return fmt.Errorf("engram config: search.weights.%s must not be negative", column)
+			}
+			*target = weight
+		}
+		cfg.SearchWeights = weights
+	}
+
+	if err := f.applyContext(cfg); err != nil {
+		return err
+	}
+
+	if f.Sync.Personal != "" {
+		policy, err := store.ParsePersonalSyncPolicy(f.Sync.Personal)
+		if err != nil {
+			return fmt.Errorf("engram config: sync.personal: %w", err)
+		}
+		cfg.PersonalSync = policy
+	}
+
+	if f.Audit.AppendOnly {
+		cfg.AppendOnly = true
+	}
+
+	if f.WorkingMemory.TTL != "" {
+		ttl, err := parseWindow(f.WorkingMemory.TTL)
+		if err != nil {
+			return fmt.Errorf("engram config: working_memory.ttl: %w", err)
+		}
+		cfg.ScratchTTL = ttl
+	}
+
+	if f.Storage.CompressAbove != "" {
+		size, err := ParseSize(f.Storage.CompressAbove)
+		if err != nil {
+			return fmt.Errorf("engram config: storage.compress_above: %w", err)
+		}
+		cfg.CompressAbove = size
+	}
+
+	if f.Storage.EventRetention != "" {
+		retention, err := parseWindow(f.Storage.EventRetention)
+		if err != nil {
+			return fmt.Errorf("engram config: storage.event_retention: %w", err)
+		}
+		cfg.EventRetention = retention
+	}
+
+	if err := f.applyQuota(cfg); err != nil {
+		return err
+	}
+
+	if err := f.applyTemplates(cfg); err != nil {
+		return err
+	}
+
+	if len(f.Dedupe.Types) == 0 {
+		return nil
+	}
+	merged := make(map[string]store.DedupePolicy, len(cfg.DedupeByType)+len(f.Dedupe.Types))
+	for typ, policy := range cfg.DedupeByType {
+		merged[typ] = policy
+	}
+	for typ, tc := range f.Dedupe.Types {
+		strategy, err := store.ParseDedupeStrategy(tc.Strategy)
+		if err != nil {
+			return fmt.Errorf("engram config: dedupe.types.%s.strategy: %w", typ, err)
+		}
+		policy := store.DedupePolicy{Strategy: strategy}
+		if tc.Window != "" {
+			window, err := parseWindow(tc.Window)
+			if err != nil {
+				return fmt.Errorf("engram config: dedupe.types.%s.window: %w", typ, err)
+			}
+			policy.Window = window
+		}
+		merged[typ] = policy
+	}
+	cfg.DedupeByType = merged
+	return nil
+}
+
+func (f *File) applyQuota(cfg *store.Config) error {
+	q := f.Quota
+	if q.MaxObservations != 0 || q.MaxBytes != "" {
+		quota, err := QuotaLimits{MaxObservations: q.MaxObservations, MaxBytes: q.MaxBytes}.quota()
+		if err != nil {
+			return fmt.Errorf("engram config: quota.%w", err)
+		}
+		cfg.DefaultQuota = quota
+	}
+	if len(q.Projects) == 0 {
+		return nil
+	}
+	quotas := make(map[string]store.Quota, len(cfg.Quotas)+len(q.Projects))
+	for project, quota := range cfg.Quotas {
+		quotas[project] = quota
+	}
+	for project, limits := range q.Projects {
+		quota, err := limits.quota()
+		if err != nil {
+			return fmt.Errorf("engram config: quota.projects.%s.%w", project, err)
+		}
+		name, _ := store.NormalizeProject(project)
+		quotas[name] = quota
+	}
+	cfg.Quotas = quotas
+	return nil
+}
+
+func (f *File) applyTemplates(cfg *store.Config) error {
+	if len(f.Templates) == 0 {
+		return nil
+	}
+	templates := make(map[string]store.Template, len(cfg.Templates)+len(f.Templates))
+	for name, t := range cfg.Templates {
+		templates[name] = t
+	}
+	for name, t := range f.Templates {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" {
+			return fmt.Errorf("engram config: templates: empty template name")
+		}
+		var sections []string
+		for _, section := range t.Sections {
+			if section = strings.TrimSpace(section); section != "" {
+				sections = append(sections, section)
+			}
+		}
+		if len(sections) == 0 {
+			return fmt.Errorf("engram config: templates.%s.sections must list at least one section", name)
+		}
+		templates[key] = store.Template{
+			Name:        key,
+			Description: strings.TrimSpace(t.Description),
+			Type:        cmp.Or(strings.TrimSpace(t.Type), "manual"),
+			TopicFamily: strings.Trim(strings.TrimSpace(t.TopicFamily), "/"),
+			Sections:    sections,
+		}
+	}
+	cfg.Templates = templates
+	return nil
+}
+
+// applyContext applies [context.exclude] and validates
+// [[context.sections]]: every section needs a title, a type may be listed by
+// one section only, and at most one section may collect the unlisted types.
+func (f *File) applyContext(cfg *store.Config) error {
+	if err := f.applyContextExclude(cfg); err != nil {
+		return err
+	}
+	if len(f.Context.Sections) == 0 {
+		return nil
+	}
+	groups := make([]store.ContextGroup, 0, len(f.Context.Sections))
+	owner := map[string]string{}
+	catchAll := ""
+	for i, section := range f.Context.Sections {
+		title := strings.TrimSpace(section.Title)
+		if title == "" {
+			return fmt.Errorf("engram config: context.sections[%d]: title is required", i)
+		}
+		if section.Limit < 0 {
+			return fmt.Errorf("engram config: context.sections[%d] (%s): limit must not be negative", i, title)
+		}
+		var types []string
+		for _, typ := range section.Types {
+			typ = strings.ToLower(strings.TrimSpace(typ))
+			if typ == "" {
+				continue
+			}
+			if other, ok := owner[typ]; ok {
+				return fmt.Errorf("engram config: context.sections[%d] (%s): type %q is already listed by %q", i, title, typ, other)
+			}
+			owner[typ] = title
+			types = append(types, typ)
+		}
+		if len(types) == 0 {
+			if catchAll != "" {
+				return fmt.Errorf("engram config: context.sections[%d] (%s): only one section may omit types, and %q already does", i, title, catchAll)
+			}
+			catchAll = title
+		}
+		groups = append(groups, store.ContextGroup{Title: title, Types: types, Limit: section.Limit})
+	}
+	cfg.ContextGroups = groups
+	return nil
+}
+
+// applyContextExclude validates [context.exclude]. Types are matched in
+// lower case, tool names as written.
+func (f *File) applyContextExclude(cfg *store.Config) error {
+	exclude := f.Context.Exclude
+	if exclude.MinContentLength < 0 {
+		return errors.New("engram config: context.exclude.min_content_length: must not be negative")
+	}
+	var rules store.ContextExclusion
+	for _, typ := range exclude.Types {
+		if typ = strings.ToLower(strings.TrimSpace(typ)); typ != "" {
+			rules.Types = append(rules.Types, typ)
+		}
+	}
+	for _, name := range exclude.ToolNames {
+		if name = strings.TrimSpace(name); name != "" {
+			rules.ToolNames = append(rules.ToolNames, name)
+		}
+	}
+	rules.MinContentLength = exclude.MinContentLength
+	cfg.ContextExclude = rules
+	return nil
+}
+
+// ParseSize reads a byte size: a plain number of bytes or one suffixed
+// with B, KB, MB, or GB (powers of 1024). Empty means zero.
+func ParseSize(raw string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(raw))
+	if value == "" {
+		return 0, nil
+	}
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, multiplier = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix)), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (want e.g. 500MB)", raw)
+	}
+	return n * multiplier, nil
+}
+
+func parseWindow(value string) (time.Duration, error) {
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if window < time.Minute {
+		return 0, fmt.Errorf("window %q is shorter than one minute", value)
+	}
+	return window, nil
+}
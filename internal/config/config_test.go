@@ -0,0 +1,782 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/mcp"
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+func writeConfig(t *testing.T, dir, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestFindLookupOrder(t *testing.T) {
+	work := t.TempDir()
+	home := t.TempDir()
+	noEnv := func(string) string { return "" }
+
+	if got := Find(noEnv, work, home); got != "" {
+		t.Fatalf("expected no config, got %q", got)
+	}
+
+	homePath := writeConfig(t, home, "")
+	if got := Find(noEnv, work, home); got != homePath {
+		t.Fatalf("expected home config %q, got %q", homePath, got)
+	}
+
+	workPath := writeConfig(t, work, "")
+	if got := Find(noEnv, work, home); got != workPath {
+		t.Fatalf("expected working dir config %q to win, got %q", workPath, got)
+	}
+
+	env := func(key string) string {
+		if key == "ENGRAM_CONFIG" {
+			return "/explicit.toml"
+		}
+		return ""
+	}
+	if got := Find(env, work, home); got != "/explicit.toml" {
+		t.Fatalf("expected ENGRAM_CONFIG to win, got %q", got)
+	}
+}
+
+func TestLoadAndApplyDedupe(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), `
+[dedupe]
+window = "30m"
+
+[dedupe.types.tool_use]
+strategy = "hash"
+
+[dedupe.types.bugfix]
+strategy = "content"
+window = "2h"
+`)
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if f.Path != path {
+		t.Fatalf("expected Path %q, got %q", path, f.Path)
+	}
+
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if cfg.DedupeWindow != 30*time.Minute {
+		t.Fatalf("expected global window 30m, got %v", cfg.DedupeWindow)
+	}
+	if got := cfg.DedupeByType["tool_use"]; got.Strategy != store.DedupeHash || got.Window != 0 {
+		t.Fatalf("expected tool_use override to replace default, got %+v", got)
+	}
+	if got := cfg.DedupeByType["bugfix"]; got.Strategy != store.DedupeContent || got.Window != 2*time.Hour {
+		t.Fatalf("unexpected bugfix policy: %+v", got)
+	}
+	if got := cfg.DedupeByType["decision"]; got.Strategy != store.DedupeOff {
+		t.Fatalf("expected built-in decision policy to survive merge, got %+v", got)
+	}
+}
+
+func TestLoadAndApplyErrors(t *testing.T) {
+	if f, err := Load(""); err != nil || f.Path != "" {
+		t.Fatalf("expected empty config for empty path, got %+v, %v", f, err)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.toml")); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	badSyntax := writeConfig(t, t.TempDir(), "[dedupe\n")
+	if _, err := Load(badSyntax); err == nil || !strings.Contains(err.Error(), "parse") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+
+	cases := map[string]string{
+		"dedupe.window":              `[dedupe]` + "\n" + `window = "soon"`,
+		"dedupe.types.note.strategy": `[dedupe.types.note]` + "\n" + `strategy = "fuzzy"`,
+		"dedupe.types.note.window":   `[dedupe.types.note]` + "\n" + `window = "10s"`,
+		"capture.learning_headers":   `[capture]` + "\n" + `learning_headers = ['Retro(']`,
+		"search.weights: unknown":    `[search.weights]` + "\n" + `body = 2.0`,
+		"search.weights.title":       `[search.weights]` + "\n" + `title = -1.0`,
+		"display.timezone":           `[display]` + "\n" + `timezone = "Mars/Olympus_Mons"`,
+		"display.locale":             `[display]` + "\n" + `locale = "tlh"`,
+		"sync.personal":              `[sync]` + "\n" + `personal = "sometimes"`,
+	}
+	for field, body := range cases {
+		f, err := Load(writeConfig(t, t.TempDir(), body))
+		if err != nil {
+			t.Fatalf("Load(%s): %v", field, err)
+		}
+		cfg := store.FallbackConfig(t.TempDir())
+		if err := f.Apply(&cfg); err == nil || !strings.Contains(err.Error(), field) {
+			t.Fatalf("expected %s error, got %v", field, err)
+		}
+	}
+}
+
+func TestServerSectionOptions(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), `
+[server]
+auth_token = "s3cret"
+
+[server.cors]
+allowed_origins = ["http://localhost:3000"]
+allow_credentials = true
+max_age = "10m"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if f.Server.AuthToken != "s3cret" {
+		t.Fatalf("expected auth token, got %q", f.Server.AuthToken)
+	}
+	opts, err := f.Server.CORS.Options()
+	if err != nil {
+		t.Fatalf("Options: %v", err)
+	}
+	if len(opts.AllowedOrigins) != 1 || opts.AllowedOrigins[0] != "http://localhost:3000" || !opts.AllowCredentials || opts.MaxAge != 10*time.Minute {
+		t.Fatalf("unexpected cors options: %+v", opts)
+	}
+
+	if _, err := (CORSSection{MaxAge: "soon"}).Options(); err == nil || !strings.Contains(err.Error(), "server.cors.max_age") {
+		t.Fatalf("expected max_age error, got %v", err)
+	}
+}
+
+func TestServerSectionResolveTenants(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), `
+[server.tenants.bob]
+token = "tok-b"
+data_dir = "/srv/engram/bob"
+
+[server.tenants.alice]
+token = "ignored"
+token_env = "TOKEN_ALICE"
+data_dir = "/srv/engram/alice"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	env := map[string]string{"TOKEN_ALICE": "tok-a"}
+	tenants, err := f.Server.ResolveTenants(func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatalf("ResolveTenants: %v", err)
+	}
+	want := []Tenant{{Name: "alice", Token: "tok-a", DataDir: "/srv/engram/alice"}, {Name: "bob", Token: "tok-b", DataDir: "/srv/engram/bob"}}
+	if !slices.Equal(tenants, want) {
+		t.Fatalf("expected tenants sorted by name with env tokens, got %+v", tenants)
+	}
+
+	for _, bad := range []map[string]TenantSection{
+		{"alice": {Token: "x"}},
+		{"alice": {DataDir: "/d"}},
+		{"alice": {TokenEnv: "UNSET", DataDir: "/d"}},
+		{"a/b": {Token: "x", DataDir: "/d"}},
+	} {
+		if _, err := (ServerSection{Tenants: bad}).ResolveTenants(func(string) string { return "" }); err == nil || !strings.Contains(err.Error(), "server.tenants") {
+			t.Errorf("expected a server.tenants error for %+v, got %v", bad, err)
+		}
+	}
+}
+
+func TestResolveProfiles(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), `
+[profiles.work]
+data_dir = "/home/me/.engram-work"
+
+[profiles.personal]
+data_dir = " /home/me/.engram "
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	profiles, err := f.ResolveProfiles()
+	if err != nil {
+		t.Fatalf("ResolveProfiles: %v", err)
+	}
+	want := []Profile{{Name: "personal", DataDir: "/home/me/.engram"}, {Name: "work", DataDir: "/home/me/.engram-work"}}
+	if !slices.Equal(profiles, want) {
+		t.Fatalf("expected profiles sorted by name, got %+v", profiles)
+	}
+
+	bad := &File{Profiles: map[string]ProfileSection{"work": {}}}
+	if _, err := bad.ResolveProfiles(); err == nil || !strings.Contains(err.Error(), "profiles.work.data_dir") {
+		t.Fatalf("expected a missing data_dir to be rejected, got %v", err)
+	}
+}
+
+func TestIngestSectionOptions(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), `
+[server.ingest]
+queue_size = 2048
+batch_size = 128
+enqueue_timeout = "500ms"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	opts, err := f.Server.Ingest.Options()
+	if err != nil {
+		t.Fatalf("Options: %v", err)
+	}
+	if opts.QueueSize != 2048 || opts.BatchSize != 128 || opts.EnqueueTimeout != 500*time.Millisecond {
+		t.Fatalf("unexpected ingest options: %+v", opts)
+	}
+
+	for _, bad := range []IngestSection{{QueueSize: -1}, {QueueSize: 8, EnqueueTimeout: "soon"}} {
+		if _, err := bad.Options(); err == nil || !strings.Contains(err.Error(), "server.ingest.") {
+			t.Fatalf("expected ingest config error for %+v, got %v", bad, err)
+		}
+	}
+}
+
+func TestBackupSectionOptions(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), `
+[backup]
+interval = "6h"
+retention = 14
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	dataDir := t.TempDir()
+	opts, err := f.Backup.Options(dataDir)
+	if err != nil {
+		t.Fatalf("Options: %v", err)
+	}
+	if opts.Interval != 6*time.Hour || opts.Retention != 14 || opts.Dir != filepath.Join(dataDir, "backups") {
+		t.Fatalf("unexpected backup options: %+v", opts)
+	}
+
+	disabled, err := (BackupSection{}).Options(dataDir)
+	if err != nil || disabled.Interval != 0 {
+		t.Fatalf("expected backups disabled without an interval, got %+v err=%v", disabled, err)
+	}
+	custom, _ := (BackupSection{Interval: "1h", Dir: "/srv/engram-backups"}).Options(dataDir)
+	if custom.Dir != "/srv/engram-backups" {
+		t.Fatalf("expected explicit dir, got %q", custom.Dir)
+	}
+
+	for _, bad := range []BackupSection{{Interval: "often"}, {Interval: "10s"}, {Interval: "1h", Retention: -1}} {
+		if _, err := bad.Options(dataDir); err == nil || !strings.Contains(err.Error(), "backup.") {
+			t.Fatalf("expected backup config error for %+v, got %v", bad, err)
+		}
+	}
+}
+
+func TestRestartSections(t *testing.T) {
+	dir := t.TempDir()
+	current, err := Load(writeConfig(t, dir, `
+[server]
+port = 7437
+
+[quota]
+max_observations = 100
+
+[backup]
+interval = "6h"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	next, err := Load(writeConfig(t, dir, `
+[server]
+port = 8080
+
+[quota]
+max_observations = 200
+
+[backup]
+interval = "1h"
+retention = 3
+
+[display]
+timezone = "UTC"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := current.RestartSections(next); !slices.Equal(got, []string{"server", "display"}) {
+		t.Fatalf("expected server and display to need a restart, got %v", got)
+	}
+	if got := next.RestartSections(next); len(got) != 0 {
+		t.Fatalf("expected an unchanged file to need nothing, got %v", got)
+	}
+}
+
+func TestGCSectionOptions(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[gc]\ninterval = \"24h\"\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	opts, err := f.GC.Options()
+	if err != nil || opts.Interval != 24*time.Hour {
+		t.Fatalf("unexpected gc options: %+v err=%v", opts, err)
+	}
+	if disabled, err := (GCSection{}).Options(); err != nil || disabled.Interval != 0 {
+		t.Fatalf("expected gc disabled without an interval, got %+v err=%v", disabled, err)
+	}
+	for _, bad := range []GCSection{{Interval: "nightly"}, {Interval: "5m"}} {
+		if _, err := bad.Options(); err == nil || !strings.Contains(err.Error(), "gc.interval") {
+			t.Fatalf("expected gc config error for %+v, got %v", bad, err)
+		}
+	}
+}
+
+func TestNotifySectionOptions(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[notify]\nenabled = true\nevents = [\"sync_import\"]\nsync_types = [\"decision\"]\ninterval = \"10s\"\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	opts, ok, err := f.Notify.Options()
+	if err != nil || !ok || opts.Interval != 10*time.Second || len(opts.Events) != 1 || opts.SyncTypes[0] != "decision" {
+		t.Fatalf("unexpected notify options: %+v ok=%v err=%v", opts, ok, err)
+	}
+	if _, ok, err := (NotifySection{Events: []string{"bogus"}}).Options(); ok || err != nil {
+		t.Fatalf("expected a disabled section to skip validation, got ok=%v err=%v", ok, err)
+	}
+	for _, bad := range []NotifySection{{Enabled: true, Events: []string{"deploy"}}, {Enabled: true, Interval: "soon"}, {Enabled: true, Interval: "1ms"}} {
+		if _, _, err := bad.Options(); err == nil || !strings.Contains(err.Error(), "notify.") {
+			t.Fatalf("expected notify config error for %+v, got %v", bad, err)
+		}
+	}
+}
+
+func TestLoadAndApplyCaptureHeaders(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[capture]\nlearning_headers = ['Retro\\s+notes']\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(cfg.LearningHeaders) != 1 || cfg.LearningHeaders[0] != `Retro\s+notes` {
+		t.Fatalf("unexpected learning headers: %q", cfg.LearningHeaders)
+	}
+}
+
+func TestLoadAndApplySearchWeights(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[search.weights]\ntitle = 12.0\ncontent = 0.5\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	want := store.DefaultSearchWeights()
+	want.Title, want.Content = 12, 0.5
+	if cfg.SearchWeights != want {
+		t.Fatalf("search weights = %+v, want %+v", cfg.SearchWeights, want)
+	}
+}
+
+func TestLoadAndApplyContextSections(t *testing.T) {
+	body := `
+[[context.sections]]
+title = "Decisions"
+types = ["decision", " Architecture "]
+limit = 5
+
+[[context.sections]]
+title = "Recent Activity"
+`
+	f, err := Load(writeConfig(t, t.TempDir(), body))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	want := []store.ContextGroup{
+		{Title: "Decisions", Types: []string{"decision", "architecture"}, Limit: 5},
+		{Title: "Recent Activity"},
+	}
+	if !reflect.DeepEqual(cfg.ContextGroups, want) {
+		t.Fatalf("context groups = %+v, want %+v", cfg.ContextGroups, want)
+	}
+
+	for name, bad := range map[string]string{
+		"title is required":    "[[context.sections]]\ntypes = [\"decision\"]\n",
+		"must not be negative": "[[context.sections]]\ntitle = \"A\"\nlimit = -1\n",
+		"already listed by":    "[[context.sections]]\ntitle = \"A\"\ntypes = [\"bugfix\"]\n[[context.sections]]\ntitle = \"B\"\ntypes = [\"bugfix\"]\n",
+		"only one section":     "[[context.sections]]\ntitle = \"A\"\n[[context.sections]]\ntitle = \"B\"\n",
+	} {
+		f, err := Load(writeConfig(t, t.TempDir(), bad))
+		if err != nil {
+			t.Fatalf("Load(%s): %v", name, err)
+		}
+		cfg := store.FallbackConfig(t.TempDir())
+		if err := f.Apply(&cfg); err == nil || !strings.Contains(err.Error(), name) {
+			t.Fatalf("expected %q error, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadAndApplyContextExclude(t *testing.T) {
+	body := `
+[context.exclude]
+types = ["File_Read", " search ", ""]
+tool_names = ["Grep"]
+min_content_length = 40
+`
+	f, err := Load(writeConfig(t, t.TempDir(), body))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	want := store.ContextExclusion{Types: []string{"file_read", "search"}, ToolNames: []string{"Grep"}, MinContentLength: 40}
+	if !reflect.DeepEqual(cfg.ContextExclude, want) {
+		t.Fatalf("context exclude = %+v, want %+v", cfg.ContextExclude, want)
+	}
+
+	f, err = Load(writeConfig(t, t.TempDir(), "[context.exclude]\nmin_content_length = -1\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := f.Apply(&cfg); err == nil || !strings.Contains(err.Error(), "context.exclude.min_content_length") {
+		t.Fatalf("expected a negative length to be rejected, got %v", err)
+	}
+}
+
+func TestLoadAndApplyDisplayTimezone(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[display]\ntimezone = \"America/Argentina/Buenos_Aires\"\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if cfg.DisplayLocation == nil || cfg.DisplayLocation.String() != "America/Argentina/Buenos_Aires" {
+		t.Fatalf("unexpected display location: %v", cfg.DisplayLocation)
+	}
+	if got := cfg.FormatTime("2026-03-01T14:05:09Z"); got != "2026-03-01 11:05:09 -03" {
+		t.Fatalf("FormatTime = %q", got)
+	}
+}
+
+func TestLoadAndApplyDisplayLocale(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[display]\nlocale = \"es_AR.UTF-8\"\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if cfg.Locale != "es" {
+		t.Fatalf("expected locale es, got %q", cfg.Locale)
+	}
+}
+
+func TestLoadAndApplyQuota(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), `[quota]
+max_observations = 1000
+max_bytes = "500MB"
+
+[quota.projects.Billing]
+max_bytes = "2 KB"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if cfg.DefaultQuota != (store.Quota{MaxObservations: 1000, MaxBytes: 500 << 20}) {
+		t.Fatalf("unexpected default quota: %+v", cfg.DefaultQuota)
+	}
+	if got := cfg.Quotas["billing"]; got != (store.Quota{MaxBytes: 2048}) {
+		t.Fatalf("project quota should be keyed by normalized name, got %+v", cfg.Quotas)
+	}
+
+	f, err = Load(writeConfig(t, t.TempDir(), "[quota.projects.billing]\nmax_bytes = \"lots\"\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := f.Apply(&cfg); err == nil || !strings.Contains(err.Error(), `quota.projects.billing.max_bytes: invalid size "lots"`) {
+		t.Fatalf("expected invalid size error, got %v", err)
+	}
+}
+
+func TestLoadAndApplyWorkingMemoryTTL(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[working_memory]\nttl = \"8h\"\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if cfg.ScratchTTL != 8*time.Hour {
+		t.Fatalf("ScratchTTL = %v", cfg.ScratchTTL)
+	}
+}
+
+func TestLoadAndApplyStorageCompressAbove(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[storage]\ncompress_above = \"8KB\"\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if cfg.CompressAbove != 8<<10 {
+		t.Fatalf("CompressAbove = %d", cfg.CompressAbove)
+	}
+
+	f.Storage.CompressAbove = "big"
+	if err := f.Apply(&cfg); err == nil || !strings.Contains(err.Error(), "storage.compress_above") {
+		t.Fatalf("expected an invalid size to be rejected, got %v", err)
+	}
+}
+
+func TestLoadAndApplyStorageEventRetention(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[storage]\nevent_retention = \"168h\"\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if cfg.EventRetention != 168*time.Hour {
+		t.Fatalf("EventRetention = %v", cfg.EventRetention)
+	}
+
+	f.Storage.EventRetention = "forever"
+	if err := f.Apply(&cfg); err == nil || !strings.Contains(err.Error(), "storage.event_retention") {
+		t.Fatalf("expected an invalid duration to be rejected, got %v", err)
+	}
+}
+
+func TestLoadMCPRateLimits(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[mcp.rate_limits]\nmem_save = \"60/1m\"\nmem_capture_passive = \"off\"\nmem_search = \"100/1h\"\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	limits, err := f.MCP.Limits()
+	if err != nil {
+		t.Fatalf("Limits: %v", err)
+	}
+	if limits["mem_save"] != (mcp.RateLimit{Calls: 60, Per: time.Minute}) || !limits["mem_capture_passive"].Unlimited() || limits["mem_search"] != (mcp.RateLimit{Calls: 100, Per: time.Hour}) {
+		t.Fatalf("unexpected limits: %+v", limits)
+	}
+	if mcp.DefaultRateLimits["mem_save"].Calls != 30 {
+		t.Fatal("expected the defaults not to be modified")
+	}
+
+	f.MCP.RateLimits = map[string]string{"mem_save": "lots"}
+	if _, err := f.MCP.Limits(); err == nil || !strings.Contains(err.Error(), "mcp.rate_limits.mem_save") {
+		t.Fatalf("expected an invalid limit to be rejected, got %v", err)
+	}
+	f.MCP.RateLimits = map[string]string{"mem_sav": "5/1m"}
+	if _, err := f.MCP.Limits(); err == nil || !strings.Contains(err.Error(), "unknown tool") {
+		t.Fatalf("expected an unknown tool to be rejected, got %v", err)
+	}
+}
+
+func TestLoadAndApplySyncPersonalPolicy(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[sync]\npersonal = \"Deny\"\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if cfg.PersonalSync != store.PersonalSyncDeny {
+		t.Fatalf("PersonalSync = %q", cfg.PersonalSync)
+	}
+}
+
+func TestLoadAndApplyTemplates(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), `[templates.Postmortem]
+description = "Blameless postmortem"
+topic_family = "bug/"
+sections = ["Summary", " ", "Action Items"]
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	got := cfg.Templates["postmortem"]
+	if got.Type != "manual" || got.TopicFamily != "bug" || !slices.Equal(got.Sections, []string{"Summary", "Action Items"}) {
+		t.Fatalf("unexpected template %+v", got)
+	}
+
+	f, err = Load(writeConfig(t, t.TempDir(), "[templates.empty]\ntype = \"decision\"\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := f.Apply(&cfg); err == nil || !strings.Contains(err.Error(), "templates.empty.sections") {
+		t.Fatalf("expected a template without sections to be rejected, got %v", err)
+	}
+}
+
+func TestEnrichSectionOptions(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), `[enrich]
+endpoint = "http://localhost:11434/v1/chat/completions"
+model = "llama3.1"
+api_key_env = "MY_LLM_KEY"
+requests_per_minute = 30
+interval = "10m"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	env := map[string]string{"MY_LLM_KEY": "secret", DefaultEnrichAPIKeyEnv: "ignored"}
+	opts, err := f.Enrich.Options(func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatalf("Options: %v", err)
+	}
+	if !opts.Enabled() || opts.Model != "llama3.1" || opts.APIKey != "secret" || opts.RequestsPerMinute != 30 || opts.Interval != 10*time.Minute {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	if opts, err := (EnrichSection{}).Options(os.Getenv); err != nil || opts.Enabled() {
+		t.Fatalf("expected enrichment off without an endpoint, got %+v err=%v", opts, err)
+	}
+	if _, err := (EnrichSection{Endpoint: "http://x"}).Options(os.Getenv); err == nil || !strings.Contains(err.Error(), "enrich.model") {
+		t.Fatalf("expected a missing model error, got %v", err)
+	}
+}
+
+func TestTranslateSectionOptions(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), `[translate]
+endpoint = "http://localhost:11434/v1/chat/completions"
+model = "llama3.1"
+target = "en"
+languages = ["en", "es"]
+timeout = "5s"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	env := map[string]string{DefaultTranslateAPIKeyEnv: "secret"}
+	opts, err := f.Translate.Options(func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatalf("Options: %v", err)
+	}
+	if !opts.Enabled() || opts.Target != "en" || len(opts.Languages) != 2 || opts.APIKey != "secret" || opts.Timeout != 5*time.Second {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	if opts, err := (TranslateSection{}).Options(os.Getenv); err != nil || opts.Enabled() {
+		t.Fatalf("expected translation off without an endpoint, got %+v err=%v", opts, err)
+	}
+	if _, err := (TranslateSection{Endpoint: "http://x"}).Options(os.Getenv); err == nil || !strings.Contains(err.Error(), "translate.model") {
+		t.Fatalf("expected a missing model error, got %v", err)
+	}
+}
+
+func TestPersonalSyncSectionOptions(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), `[personal_sync]
+relay = "https://relay.example.com/engram/"
+passphrase_env = "MY_PASSPHRASE"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	env := map[string]string{"MY_PASSPHRASE": "correct horse", DefaultPersonalSyncTokenEnv: "tok"}
+	opts, err := f.PersonalSync.Options(func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatalf("Options: %v", err)
+	}
+	if opts.Relay != "https://relay.example.com/engram/" || opts.Passphrase != "correct horse" || opts.Token != "tok" {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	env[PersonalSyncRelayEnv] = "/mnt/sync/engram"
+	if opts, _ := f.PersonalSync.Options(func(k string) string { return env[k] }); opts.Relay != "/mnt/sync/engram" {
+		t.Fatalf("expected %s to override the relay, got %q", PersonalSyncRelayEnv, opts.Relay)
+	}
+	if _, err := (PersonalSyncSection{Relay: "/tmp/relay"}).Options(func(string) string { return "" }); err == nil || !strings.Contains(err.Error(), DefaultPersonalSyncPassphraseEnv) {
+		t.Fatalf("expected a missing passphrase error, got %v", err)
+	}
+}
+
+func TestLoadTools(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ToolsFileName)
+	if err := os.WriteFile(path, []byte(`
+[mem_save]
+description = """
+Save what you learned. Keep it short.
+"""
+title = "Remember"
+
+[mem_save.params]
+content = "What, Why, Where — one line each"
+
+[mem_delete]
+destructive = true
+`), 0644); err != nil {
+		t.Fatalf("write tools: %v", err)
+	}
+	overrides, err := LoadTools(path, true)
+	if err != nil {
+		t.Fatalf("LoadTools: %v", err)
+	}
+	save := overrides["mem_save"]
+	if save.Description != "Save what you learned. Keep it short." || save.Title != "Remember" || save.Params["content"] == "" {
+		t.Fatalf("unexpected mem_save override: %+v", save)
+	}
+	if d := overrides["mem_delete"].Destructive; d == nil || !*d {
+		t.Fatalf("expected destructive hint, got %+v", overrides["mem_delete"])
+	}
+
+	missing := filepath.Join(t.TempDir(), ToolsFileName)
+	if got, err := LoadTools(missing, false); err != nil || got != nil {
+		t.Fatalf("expected an optional missing file to be ignored, got %v, %v", got, err)
+	}
+	if _, err := LoadTools(missing, true); err == nil {
+		t.Fatalf("expected a required missing file to fail")
+	}
+
+	if err := os.WriteFile(path, []byte("[mem_sav]\ndescription = \"typo\"\n"), 0644); err != nil {
+		t.Fatalf("write tools: %v", err)
+	}
+	if _, err := LoadTools(path, true); err == nil || !strings.Contains(err.Error(), `unknown tool "mem_sav"`) {
+		t.Fatalf("expected unknown tool error, got %v", err)
+	}
+}
+
+func TestLoadAndApplyAuditAppendOnly(t *testing.T) {
+	f, err := Load(writeConfig(t, t.TempDir(), "[audit]\nappend_only = true\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := store.FallbackConfig(t.TempDir())
+	if err := f.Apply(&cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if !cfg.AppendOnly {
+		t.Fatal("expected [audit] append_only to turn on append-only mode")
+	}
+}
@@ -0,0 +1,304 @@
+// Package enrich post-processes new observations through an LLM to suggest
+// better titles, topic keys, and tags.
+//
+// The worker talks to any OpenAI-compatible chat completions endpoint
+// (OpenAI, OpenRouter, Ollama, llama.cpp, ...) at a fixed maximum request
+// rate. Suggestions are stored next to the observation with
+// Store.AddEnrichmentSuggestion and only change it when accepted with
+// `engram enrich accept`, so agent-provided values are never overwritten
+// behind anyone's back.
+//
+// Enrichment is off unless an endpoint is configured. Observation content
+// is sent to that endpoint; <private> tags are already stripped at save
+// time, but point it at a local model if memories must not leave the
+// machine.
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/llm"
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+const (
+	// DefaultRequestsPerMinute caps calls to the endpoint when none is set.
+	DefaultRequestsPerMinute = 10
+
+	// DefaultInterval is the pause between passes in `engram serve`.
+	DefaultInterval = 5 * time.Minute
+
+	// DefaultBatchSize is how many observations one pass looks at.
+	DefaultBatchSize = 20
+
+	// DefaultTimeout bounds a single request.
+	DefaultTimeout = 30 * time.Second
+
+	// maxPromptContent keeps long tool output from blowing the context.
+	maxPromptContent = 4000
+)
+
+// Options configures the client and the worker.
+type Options struct {
+	// Endpoint is the chat completions URL, e.g.
+	// https://api.openai.com/v1/chat/completions or
+	// http://localhost:11434/v1/chat/completions.
+	Endpoint string
+	Model    string
+	// APIKey is sent as a bearer token when set.
+	APIKey string
+	// RequestsPerMinute caps calls to the endpoint; <= 0 means
+	// DefaultRequestsPerMinute.
+	RequestsPerMinute int
+	// Interval between passes of Run; <= 0 means DefaultInterval.
+	Interval time.Duration
+	// BatchSize is the number of observations per pass; <= 0 means
+	// DefaultBatchSize.
+	BatchSize int
+	// Timeout bounds each request; <= 0 means DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Enabled reports whether an endpoint is configured.
+func (o Options) Enabled() bool { return o.Endpoint != "" }
+
+func (o Options) withDefaults() Options {
+	if o.RequestsPerMinute <= 0 {
+		o.RequestsPerMinute = DefaultRequestsPerMinute
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultInterval
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	return o
+}
+
+// Suggestion is what the model proposes for one observation.
+type Suggestion struct {
+	Title    string   `json:"title"`
+	TopicKey string   `json:"topic_key"`
+	Tags     []string `json:"tags"`
+}
+
+// Suggester produces a suggestion for an observation.
+type Suggester interface {
+	Suggest(ctx context.Context, obs store.Observation) (Suggestion, error)
+}
+
+// ErrTransient marks failures worth retrying on a later pass (rate limits,
+// server errors, timeouts) rather than recording against the observation.
+var ErrTransient = errors.New("enrich: transient failure")
+
+// ─── Client ──────────────────────────────────────────────────────────────────
+
+// Client is a Suggester backed by an OpenAI-compatible endpoint.
+type Client struct {
+	chat *llm.Client
+}
+
+// NewClient returns a client for opts.Endpoint.
+func NewClient(opts Options) (*Client, error) {
+	opts = opts.withDefaults()
+	chat, err := llm.NewClient(llm.Options{Endpoint: opts.Endpoint, Model: opts.Model, APIKey: opts.APIKey, Timeout: opts.Timeout})
+	if err != nil {
+		return nil, fmt.Errorf("enrich: %w", err)
+	}
+	return &Client{chat: chat}, nil
+}
+
+const systemPrompt = `You improve metadata for entries in a developer's long-term memory.
+Given one entry, reply with a single JSON object and nothing else:
+{"title": "...", "topic_key": "...", "tags": ["..."]}
+- title: at most 80 characters, specific and searchable, in the language of the entry.
+- topic_key: a stable lowercase slug "family/short-name", where family is one of
+  architecture, bug, decision, pattern, config, discovery, learning. Keep the
+  current topic_key if it is already good.
+- tags: 1 to 5 short lowercase keywords (technologies, components, concepts).`
+
+// Suggest asks the model for a title, topic key, and tags.
+func (c *Client) Suggest(ctx context.Context, obs store.Observation) (Suggestion, error) {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "type: %s\ntitle: %s\n", obs.Type, obs.Title)
+	if obs.TopicKey != nil {
+		fmt.Fprintf(&prompt, "topic_key: %s\n", *obs.TopicKey)
+	}
+	if obs.Project != nil {
+		fmt.Fprintf(&prompt, "project: %s\n", *obs.Project)
+	}
+	content := obs.Content
+	if len(content) > maxPromptContent {
+		content = content[:maxPromptContent] + "..."
+	}
+	fmt.Fprintf(&prompt, "content:\n%s\n", content)
+
+	reply, err := c.chat.Complete(ctx, systemPrompt, prompt.String())
+	if err != nil {
+		// Only a response the endpoint refused for good, or could not make
+		// sense of, is recorded against the observation.
+		var status *llm.StatusError
+		if (errors.As(err, &status) && !status.Retryable()) || errors.Is(err, llm.ErrUnexpectedResponse) {
+			return Suggestion{}, fmt.Errorf("enrich: %w", err)
+		}
+		return Suggestion{}, fmt.Errorf("%w: %v", ErrTransient, err)
+	}
+	return parseSuggestion(reply)
+}
+
+// parseSuggestion reads the JSON object from a model reply, tolerating the
+// prose or code fences some models wrap it in.
+func parseSuggestion(reply string) (Suggestion, error) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return Suggestion{}, fmt.Errorf("enrich: no JSON object in reply: %s", truncate(reply, 200))
+	}
+	var sug Suggestion
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &sug); err != nil {
+		return Suggestion{}, fmt.Errorf("enrich: invalid JSON in reply: %w", err)
+	}
+	sug.Title = truncate(strings.TrimSpace(sug.Title), 120)
+	if len(sug.Tags) > 5 {
+		sug.Tags = sug.Tags[:5]
+	}
+	return sug, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// ─── Worker ──────────────────────────────────────────────────────────────────
+
+// Result summarizes one pass.
+type Result struct {
+	Suggested int `json:"suggested"`
+	Failed    int `json:"failed"`
+	// Deferred counts observations left for a later pass after a transient
+	// failure stopped this one.
+	Deferred int `json:"deferred"`
+}
+
+// Worker enriches observations that have no suggestion yet.
+type Worker struct {
+	store     *store.Store
+	suggester Suggester
+	opts      Options
+	logger    *slog.Logger
+	limiter   *limiter
+}
+
+// New returns a Worker. opts.Model is recorded on every suggestion.
+func New(s *store.Store, suggester Suggester, opts Options, logger *slog.Logger) *Worker {
+	opts = opts.withDefaults()
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Worker{
+		store:     s,
+		suggester: suggester,
+		opts:      opts,
+		logger:    logger,
+		limiter:   &limiter{gap: time.Minute / time.Duration(opts.RequestsPerMinute)},
+	}
+}
+
+// Run enriches a batch every interval until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		if res, err := w.RunOnce(ctx, 0); err != nil {
+			w.logger.Warn("enrichment pass stopped", "err", err, "suggested", res.Suggested, "deferred", res.Deferred)
+		} else if res.Suggested+res.Failed > 0 {
+			w.logger.Info("enrichment pass", "suggested", res.Suggested, "failed", res.Failed)
+		}
+		timer := time.NewTimer(w.opts.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// RunOnce enriches up to limit observations (the batch size when limit is
+// 0), waiting between requests to honor the rate limit. A transient
+// failure ends the pass early and is returned; the remaining observations
+// are picked up next time.
+func (w *Worker) RunOnce(ctx context.Context, limit int) (Result, error) {
+	if limit <= 0 {
+		limit = w.opts.BatchSize
+	}
+	var res Result
+	candidates, err := w.store.EnrichmentCandidates(limit)
+	if err != nil {
+		return res, err
+	}
+	for i, obs := range candidates {
+		if err := w.limiter.wait(ctx); err != nil {
+			res.Deferred = len(candidates) - i
+			return res, err
+		}
+		sug, err := w.suggester.Suggest(ctx, obs)
+		if errors.Is(err, ErrTransient) || ctx.Err() != nil {
+			res.Deferred = len(candidates) - i
+			return res, err
+		}
+		params := store.EnrichmentParams{ObservationID: obs.ID, Model: w.opts.Model}
+		if err != nil {
+			params.Error = err.Error()
+			res.Failed++
+		} else {
+			params.Title, params.TopicKey, params.Tags = sug.Title, sug.TopicKey, sug.Tags
+			res.Suggested++
+		}
+		if _, err := w.store.AddEnrichmentSuggestion(params); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// limiter spaces requests at least gap apart.
+type limiter struct {
+	gap  time.Duration
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := now
+	if l.next.After(now) {
+		at = l.next
+	}
+	l.next = at.Add(l.gap)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
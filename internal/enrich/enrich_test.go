@@ -0,0 +1,164 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/llm"
+	"github.com/Gentleman-Programming/engram/internal/store"
+	"github.com/Gentleman-Programming/engram/internal/store/storetest"
+)
+
+func addObservation(t *testing.T, s *store.Store, title, content string) int64 {
+	t.Helper()
+	id, err := s.AddObservation(store.AddObservationParams{
+		SessionID: "s1", Type: "bugfix", Title: title, Content: content, Project: "engram",
+	})
+	if err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	return id
+}
+
+func TestClientSuggestParsesReply(t *testing.T) {
+	var gotAuth string
+	var gotReq llm.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&gotReq)
+		reply := "Sure:\n```json\n{\"title\": \"Fix nil panic in auth middleware\", \"topic_key\": \"bug/auth-nil-panic\", \"tags\": [\"auth\", \"go\"]}\n```"
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Options{Endpoint: srv.URL, Model: "test-model", APIKey: "k3y"})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	sug, err := client.Suggest(context.Background(), store.Observation{Type: "bugfix", Title: "fix", Content: "nil pointer in middleware"})
+	if err != nil {
+		t.Fatalf("suggest: %v", err)
+	}
+	if sug.Title != "Fix nil panic in auth middleware" || sug.TopicKey != "bug/auth-nil-panic" || len(sug.Tags) != 2 {
+		t.Fatalf("unexpected suggestion: %+v", sug)
+	}
+	if gotAuth != "Bearer k3y" || gotReq.Model != "test-model" || !strings.Contains(gotReq.Messages[1].Content, "nil pointer") {
+		t.Fatalf("unexpected request: auth=%q req=%+v", gotAuth, gotReq)
+	}
+}
+
+func TestClientSuggestClassifiesErrors(t *testing.T) {
+	status := http.StatusTooManyRequests
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"error":"nope"}`)
+	}))
+	defer srv.Close()
+
+	client, _ := NewClient(Options{Endpoint: srv.URL, Model: "m"})
+	if _, err := client.Suggest(context.Background(), store.Observation{}); !errors.Is(err, ErrTransient) {
+		t.Fatalf("429 should be transient, got %v", err)
+	}
+	status = http.StatusBadRequest
+	if _, err := client.Suggest(context.Background(), store.Observation{}); err == nil || errors.Is(err, ErrTransient) {
+		t.Fatalf("400 should be a permanent error, got %v", err)
+	}
+
+	if _, err := parseSuggestion("I cannot help with that."); err == nil {
+		t.Fatalf("expected an error for a reply without JSON")
+	}
+}
+
+type fakeSuggester struct {
+	calls atomic.Int32
+	fn    func(store.Observation) (Suggestion, error)
+}
+
+func (f *fakeSuggester) Suggest(_ context.Context, obs store.Observation) (Suggestion, error) {
+	f.calls.Add(1)
+	return f.fn(obs)
+}
+
+func TestWorkerRunOnceStoresSuggestionsWithoutOverwriting(t *testing.T) {
+	s := storetest.NewWithSession(t)
+	good := addObservation(t, s, "fix", "Fixed the nil panic in the auth middleware")
+	bad := addObservation(t, s, "stuff", "Something the model chokes on")
+
+	fake := &fakeSuggester{fn: func(obs store.Observation) (Suggestion, error) {
+		if obs.ID == bad {
+			return Suggestion{}, errors.New("enrich: invalid JSON in reply")
+		}
+		return Suggestion{Title: "Fix nil panic in auth middleware", TopicKey: "bug/auth-nil-panic", Tags: []string{"Auth", "auth", "go"}}, nil
+	}}
+	w := New(s, fake, Options{Model: "m", RequestsPerMinute: 6000}, nil)
+
+	res, err := w.RunOnce(context.Background(), 0)
+	if err != nil || res.Suggested != 1 || res.Failed != 1 {
+		t.Fatalf("unexpected pass: %+v err=%v", res, err)
+	}
+
+	obs, err := s.GetObservation(good)
+	if err != nil || obs.Title != "fix" {
+		t.Fatalf("the observation must not change before review, got %+v err=%v", obs, err)
+	}
+	pending, err := s.EnrichmentSuggestions(store.EnrichPending, 10)
+	if err != nil || len(pending) != 1 || pending[0].ObservationID != good || pending[0].Model != "m" {
+		t.Fatalf("unexpected pending suggestions: %+v err=%v", pending, err)
+	}
+	if strings.Join(pending[0].Tags, ",") != "auth,go" {
+		t.Fatalf("expected normalized tags, got %v", pending[0].Tags)
+	}
+
+	// Both observations have a suggestion now, so a second pass has nothing to do.
+	res, err = w.RunOnce(context.Background(), 0)
+	if err != nil || res.Suggested+res.Failed != 0 || fake.calls.Load() != 2 {
+		t.Fatalf("expected an idle second pass, got %+v err=%v calls=%d", res, err, fake.calls.Load())
+	}
+}
+
+func TestWorkerRunOnceDefersOnTransientFailure(t *testing.T) {
+	s := storetest.NewWithSession(t)
+	addObservation(t, s, "one", "First observation to enrich")
+	addObservation(t, s, "two", "Second observation to enrich")
+
+	fake := &fakeSuggester{fn: func(store.Observation) (Suggestion, error) {
+		return Suggestion{}, fmt.Errorf("%w: 429 Too Many Requests", ErrTransient)
+	}}
+	res, err := New(s, fake, Options{Model: "m", RequestsPerMinute: 6000}, nil).RunOnce(context.Background(), 0)
+	if !errors.Is(err, ErrTransient) || res.Deferred != 2 || fake.calls.Load() != 1 {
+		t.Fatalf("expected the pass to stop on the first transient error, got %+v err=%v calls=%d", res, err, fake.calls.Load())
+	}
+	if candidates, _ := s.EnrichmentCandidates(10); len(candidates) != 2 {
+		t.Fatalf("transient failures must not be recorded, got %d candidates", len(candidates))
+	}
+}
+
+func TestLimiterSpacesRequests(t *testing.T) {
+	l := &limiter{gap: 30 * time.Millisecond}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("expected at least two gaps, got %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.next = time.Now().Add(time.Hour)
+	if err := l.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
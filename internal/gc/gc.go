@@ -0,0 +1,89 @@
+// Package gc runs store garbage collection on a schedule while
+// `engram serve` is running.
+//
+// Each run calls Store.GC: expired changefeed events are pruned, orphaned
+// full-text index rows are dropped, the indexes are optimized, and free
+// pages are returned with incremental_vacuum. The first run on an older database converts it to
+// auto_vacuum=INCREMENTAL with a full VACUUM.
+package gc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// MinInterval guards against configs that would vacuum continuously.
+const MinInterval = time.Hour
+
+// Options configures a Scheduler.
+type Options struct {
+	// Interval between runs. The first run is due one interval after start.
+	Interval time.Duration
+}
+
+// Scheduler runs Store.GC on a fixed interval.
+type Scheduler struct {
+	store  *store.Store
+	opts   Options
+	logger *slog.Logger
+
+	mu   sync.Mutex
+	last *store.GCResult
+}
+
+// New returns a Scheduler for s.
+func New(s *store.Store, opts Options, logger *slog.Logger) (*Scheduler, error) {
+	if opts.Interval < MinInterval {
+		return nil, fmt.Errorf("engram gc: interval %s is shorter than %s", opts.Interval, MinInterval)
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Scheduler{store: s, opts: opts, logger: logger}, nil
+}
+
+// Run collects garbage every interval until ctx is cancelled.
+func (sc *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(sc.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := sc.RunNow(); err != nil {
+			sc.logger.Error("gc failed", "err", err)
+		}
+	}
+}
+
+// RunNow collects garbage immediately and logs what was reclaimed.
+func (sc *Scheduler) RunNow() (*store.GCResult, error) {
+	result, err := sc.store.GC()
+	if err != nil {
+		return nil, err
+	}
+	sc.mu.Lock()
+	sc.last = result
+	sc.mu.Unlock()
+	sc.logger.Info("gc finished",
+		"orphaned_fts_rows", result.OrphanedFTSRows,
+		"pruned_events", result.PrunedEvents,
+		"vacuum", result.VacuumMode,
+		"reclaimed_bytes", result.ReclaimedBytes(),
+	)
+	return result, nil
+}
+
+// Last returns the result of the most recent run, or nil before the first.
+func (sc *Scheduler) Last() *store.GCResult {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.last
+}
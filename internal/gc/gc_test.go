@@ -0,0 +1,48 @@
+package gc
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store/storetest"
+)
+
+func quietLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestNewRejectsShortInterval(t *testing.T) {
+	_, err := New(storetest.New(t), Options{Interval: time.Minute}, quietLogger())
+	if err == nil || !strings.Contains(err.Error(), "shorter than") {
+		t.Fatalf("expected short interval error, got %v", err)
+	}
+}
+
+func TestRunNowRecordsLastResult(t *testing.T) {
+	sc, err := New(storetest.New(t), Options{Interval: 24 * time.Hour}, quietLogger())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if sc.Last() != nil {
+		t.Fatalf("expected no result before the first run")
+	}
+
+	result, err := sc.RunNow()
+	if err != nil {
+		t.Fatalf("RunNow: %v", err)
+	}
+	if result.VacuumMode != "full" {
+		t.Fatalf("expected the first run on a new database to convert it, got %+v", result)
+	}
+	if sc.Last() != result {
+		t.Fatalf("expected Last to return the latest result")
+	}
+
+	result, err = sc.RunNow()
+	if err != nil || result.VacuumMode != "incremental" {
+		t.Fatalf("expected an incremental second run, got %+v, %v", result, err)
+	}
+}
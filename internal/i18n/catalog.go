@@ -0,0 +1,263 @@
+package i18n
+
+// catalog maps each English message to its translation, per locale. Keep
+// the printf verbs of a translation in the same order as the English key;
+// the tests check it.
+var catalog = map[string]map[string]string{
+	Spanish: {
+		// ─── CLI ─────────────────────────────────────────────────────────
+		"No memories found for file: %s":       "No se encontraron memorias para el archivo: %s",
+		"No memories found for source: %s":     "No se encontraron memorias para el origen: %s",
+		"No memories found for ref: %s":        "No se encontraron memorias para la referencia: %s",
+		"No memories found for: %q":            "No se encontraron memorias para: %q",
+		"Found %d memories:":                   "Se encontraron %d memorias:",
+		"No memories or prompts found for: %q": "No se encontraron memorias ni prompts para: %q",
+		"Found %d memories and prompts:":       "Se encontraron %d memorias y prompts:",
+		"observation":                          "observación",
+		"prompt":                               "prompt",
+		"Memory saved: #%d %q (%s)":            "Memoria guardada: #%d %q (%s)",
+		"created":                              "creada",
+		"scope":                                "alcance",
+		"session":                              "sesión",
+		"project":                              "proyecto",
+		"topic":                                "tema",
+		"refs":                                 "referencias",
+		"source":                               "origen",
+		"archived":                             "archivada",
+		"Before":                               "Antes",
+		"After":                                "Después",
+		"Tool runs":                            "Ejecuciones de herramientas",
+		"Total observations in session: %d":    "Observaciones totales en la sesión: %d",
+		"Continues: %s":                        "Continúa: %s",
+		"No previous session memories found.":  "No se encontraron memorias de sesiones anteriores.",
+		"none yet":                             "ninguno todavía",
+		"Engram Memory Stats":                  "Estadísticas de memoria de Engram",
+		"  Sessions:     %d":                   "  Sesiones:      %d",
+		"  Observations: %d":                   "  Observaciones: %d",
+		"  Prompts:      %d":                   "  Prompts:       %d",
+		"  Projects:     %s":                   "  Proyectos:     %s",
+		"  Database:     %s":                   "  Base de datos: %s",
+		"Observations by type":                 "Observaciones por tipo",
+		"Health":                               "Salud",
+		"  Oldest:       %s":                   "  Más antigua:   %s",
+		"  Newest:       %s":                   "  Más reciente:  %s",
+		"  Deduped:      %d saves absorbed":    "  Deduplicadas:  %d guardados absorbidos",
+		"  Quarantined:  %d (review with `engram quarantine list`)": "  En cuarentena: %d (revisalas con `engram quarantine list`)",
+		"Nothing to archive.":                                         "Nada para archivar.",
+		"  … and %d more":                                             "  … y %d más",
+		"Would archive %d observations into %s":                       "Se archivarían %d observaciones en %s",
+		"Archived %d observations into %s":                            "Se archivaron %d observaciones en %s",
+		"Search them with `engram search --include-archive <query>`.": "Buscalas con `engram search --include-archive <consulta>`.",
+		"Session summary saved: #%d, session %q ended":                "Resumen de sesión guardado: #%d, sesión %q finalizada",
+
+		// ─── TUI ─────────────────────────────────────────────────────────
+		"Unknown screen":                  "Pantalla desconocida",
+		"Error: ":                         "Error: ",
+		"sessions":                        "sesiones",
+		"observations":                    "observaciones",
+		"prompts":                         "prompts",
+		"projects":                        "proyectos",
+		"  Projects":                      "  Proyectos",
+		"  Actions":                       "  Acciones",
+		"Loading stats...":                "Cargando estadísticas...",
+		"Loading activity...":             "Cargando actividad...",
+		"Loading...":                      "Cargando...",
+		"...and %d more projects":         "...y %d proyectos más",
+		"  Activity — %s (last 12 weeks)": "  Actividad — %s (últimas 12 semanas)",
+		"Search memories":                 "Buscar memorias",
+		"Recent observations":             "Observaciones recientes",
+		"Browse sessions":                 "Explorar sesiones",
+		"Review quarantine":               "Revisar cuarentena",
+		"Activity calendar":               "Calendario de actividad",
+		"Browse topics":                   "Explorar temas",
+		"Setup agent plugin":              "Instalar plugin de agente",
+		"Quit":                            "Salir",
+		"\n  j/k navigate • enter select • s search • p activity project • P profiles • ctrl+p commands • q quit": "\n  j/k navegar • enter elegir • s buscar • p proyecto de actividad • P perfiles • ctrl+p comandos • q salir",
+		"New memory":           "Nueva memoria",
+		"Dashboard":            "Inicio",
+		"  Commands":           "  Comandos",
+		"No matching command.": "Ningún comando coincide.",
+		"\n  type to filter • ↑/↓ select • enter run • esc close": "\n  escribí para filtrar • ↑/↓ elegir • enter ejecutar • esc cerrar",
+		"  Search Memories": "  Buscar memorias",
+		"  Type a query and press enter • esc go back": "  Escribí una consulta y presioná enter • esc volver",
+		"  Search: %q — %d result":                     "  Búsqueda: %q — %d resultado",
+		"  Search: %q — %d results":                    "  Búsqueda: %q — %d resultados",
+		"No memories found. Try a different query.":    "No se encontraron memorias. Probá otra consulta.",
+		"  / new search • f filter • esc back":         "  / nueva búsqueda • f filtrar • esc volver",
+		"showing %d-%d of %d":                          "mostrando %d-%d de %d",
+		"line %d-%d of %d":                             "línea %d-%d de %d",
+		"\n  j/k navigate • enter detail • t timeline • / search • f filter • esc back": "\n  j/k navegar • enter detalle • t línea de tiempo • / buscar • f filtrar • esc volver",
+		"\n  j/k navigate • enter detail • t timeline • f filter • esc back":            "\n  j/k navegar • enter detalle • t línea de tiempo • f filtrar • esc volver",
+		"  f filter • esc back":             "  f filtrar • esc volver",
+		"No observations match the filter.": "Ninguna observación coincide con el filtro.",
+		"filter:":                           "filtro:",
+		"Search: %q":                        "Búsqueda: %q",
+		"  Filter — %s":                     "  Filtro — %s",
+		"  Types":                           "  Tipos",
+		"any":                               "cualquiera",
+		"Scope:":                            "Alcance:",
+		"\n  j/k navigate • space toggle type • h/l change project/scope • c clear • f/esc apply": "\n  j/k navegar • espacio marcar tipo • h/l cambiar proyecto/alcance • c limpiar • f/esc aplicar",
+		"  Recent Observations — %d total": "  Observaciones recientes — %d en total",
+		"No observations yet.":             "Todavía no hay observaciones.",
+		"  esc back":                       "  esc volver",
+		"\n  j/k navigate • enter detail • t timeline • esc back": "\n  j/k navegar • enter detalle • t línea de tiempo • esc volver",
+		"all projects": "todos los proyectos",
+		"  Activity — %s — %d observations in %d days": "  Actividad — %s — %d observaciones en %d días",
+		"%d observations": "%d observaciones",
+		"1 observation":   "1 observación",
+		"\n  j/k day • h/l week • enter day's observations • p project • esc back": "\n  j/k día • h/l semana • enter observaciones del día • p proyecto • esc volver",
+		"  %s — %s — %d observations":                                              "  %s — %s — %d observaciones",
+		"No observations on this day.":                                             "No hay observaciones en este día.",
+		"  Quarantine — %d awaiting review":                                        "  Cuarentena — %d pendientes de revisión",
+		"Nothing in quarantine. Low-confidence passive captures land here.":        "La cuarentena está vacía. Acá llegan las capturas pasivas de baja confianza.",
+		"reason:": "motivo:",
+		"\n  j/k navigate • enter detail • a approve • x reject (delete) • esc back": "\n  j/k navegar • enter detalle • a aprobar • x rechazar (borrar) • esc volver",
+		"  Observation Detail": "  Detalle de la observación",
+		"  Observation #%d":    "  Observación #%d",
+		"Type:":                "Tipo:",
+		"Title:":               "Título:",
+		"Session:":             "Sesión:",
+		"Continues:":           "Continúa:",
+		"Created:":             "Creada:",
+		"Tool:":                "Herramienta:",
+		"Source:":              "Origen:",
+		"Project:":             "Proyecto:",
+		"Summary:":             "Resumen:",
+		"  Content":            "  Contenido",
+		"\n  j/k scroll • t timeline • y copy • e edit • esc back": "\n  j/k desplazar • t línea de tiempo • y copiar • e editar • esc volver",
+		"  Timeline": "  Línea de tiempo",
+		"  Timeline — Observation #%d (%d total in session)": "  Línea de tiempo — Observación #%d (%d en total en la sesión)",
+		"  Before":                  "  Antes",
+		"  After":                   "  Después",
+		"\n  j/k scroll • esc back": "\n  j/k desplazar • esc volver",
+		"  Sessions — %d total":     "  Sesiones — %d en total",
+		"No sessions yet.":          "Todavía no hay sesiones.",
+		"\n  j/k navigate • enter view session • esc back": "\n  j/k navegar • enter ver sesión • esc volver",
+		"  Session Detail":                    "  Detalle de la sesión",
+		"Session not found.":                  "No se encontró la sesión.",
+		"  Session: %s — %s":                  "  Sesión: %s — %s",
+		"  Observations (%d)":                 "  Observaciones (%d)",
+		"No observations in this session.":    "No hay observaciones en esta sesión.",
+		"other":                               "otros",
+		"  Topics — %d topics in %d families": "  Temas — %d temas en %d familias",
+		"No topics yet. Saves with a topic_key show up here.": "Todavía no hay temas. Acá aparecen los guardados con topic_key.",
+		"rev %d": "rev %d",
+		"\n  j/k navigate • enter content and history • esc back": "\n  j/k navegar • enter contenido e historial • esc volver",
+		"  Topic":                          "  Tema",
+		"  Topic: %s":                      "  Tema: %s",
+		"Revisions:":                       "Revisiones:",
+		"Updated:":                         "Actualizada:",
+		"  Current content":                "  Contenido actual",
+		"  Revision history (%d recorded)": "  Historial de revisiones (%d registradas)",
+		"    ...and %d older":              "    ...y %d anteriores",
+		"updated":                          "editada",
+		"deleted":                          "borrada",
+		"Earlier revisions were saved before engram recorded change events.":  "Las revisiones anteriores se guardaron antes de que engram registrara eventos de cambio.",
+		"\n  j/k scroll • enter observation • t timeline • y copy • esc back": "\n  j/k desplazar • enter observación • t línea de tiempo • y copiar • esc volver",
+		"  Engram Live Stats — every %s":                                      "  Estadísticas en vivo de Engram — cada %s",
+		"updated %s":                                                          "actualizado %s",
+		"writes/min":                                                          "escrituras/min",
+		"writes since start":                                                  "escrituras desde el inicio",
+		"  Recent observations":                                               "  Observaciones recientes",
+		"new":                                                                 "nueva",
+		"  q quit":                                                            "  q salir",
+
+		"Switch profile":         "Cambiar de perfil",
+		"  Profile: %s":          "  Perfil: %s",
+		"  Profiles":             "  Perfiles",
+		"(current)":              "(actual)",
+		"Switched to profile %s": "Perfil cambiado a %s",
+		"\n  j/k navigate • enter switch • esc back":                                            "\n  j/k navegar • enter cambiar • esc volver",
+		"No profiles configured. Add [profiles.<name>] tables with a data_dir to .engram.toml.": "No hay perfiles configurados. Agregá tablas [profiles.<nombre>] con un data_dir a .engram.toml.",
+
+		"Trash":                             "Papelera",
+		"  Trash — %d deleted observations": "  Papelera — %d observaciones borradas",
+		"The trash is empty. Soft-deleted observations land here.": "La papelera está vacía. Acá llegan las observaciones borradas.",
+		"deleted:": "borrada:",
+		"\n  j/k navigate • r restore • esc back": "\n  j/k navegar • r restaurar • esc volver",
+
+		// ─── MCP ─────────────────────────────────────────────────────────
+		"Found %d memories:\n\n":               "Se encontraron %d memorias:\n\n",
+		"Found %d memories mentioning %s:\n\n": "Se encontraron %d memorias que mencionan %s:\n\n",
+		"No memories mention %s.":              "Ninguna memoria menciona %s.",
+		"Results above are previews (300 chars). To read the full content of a specific memory, call mem_get_observation(id: <ID>).": "Los resultados son vistas previas (300 caracteres). Para leer el contenido completo de una memoria, llamá a mem_get_observation(id: <ID>).",
+		"More results exist. For the next page, call mem_search again with the same query and filters and cursor: %q.":               "Hay más resultados. Para la página siguiente, llamá de nuevo a mem_search con la misma consulta y filtros y cursor: %q.",
+		"No existing topic key resembles %q. Use mem_suggest_topic_key to pick a new one.":                                           "Ningún topic key existente se parece a %q. Usá mem_suggest_topic_key para elegir uno nuevo.",
+		"Found %d matching topic keys (reuse one to update that memory):":                                                            "Se encontraron %d topic keys parecidos (reusá uno para actualizar esa memoria):",
+		"Memory saved: %q (%s)":                                   "Memoria guardada: %q (%s)",
+		"\nType inferred: %s (no type given).":                    "\nTipo inferido: %s (no se indicó tipo).",
+		"\nSuggested topic_key: %s":                               "\ntopic_key sugerido: %s",
+		"Suggested topic_key: %s":                                 "topic_key sugerido: %s",
+		"Memory updated: #%d %q (%s, scope=%s)":                   "Memoria actualizada: #%d %q (%s, scope=%s)",
+		"Memory #%d soft-deleted":                                 "Memoria #%d borrada (recuperable)",
+		"Memory #%d permanently deleted":                          "Memoria #%d borrada definitivamente",
+		"Dry run: %d memories match and would be soft-deleted:\n": "Simulación: %d memorias coinciden y se borrarían (recuperables):\n",
+		"Soft-deleted %d memories:\n":                             "Se borraron %d memorias (recuperables):\n",
+		"  ... and %d more\n":                                     "  ... y %d más\n",
+		"Call mem_delete again with the same filter and confirm=true to delete them.": "Llamá de nuevo a mem_delete con el mismo filtro y confirm=true para borrarlas.",
+		"Prompt saved: %q":         "Prompt guardado: %q",
+		"No prompts found for: %q": "No se encontraron prompts para: %q",
+		"No prompts saved yet.":    "Todavía no hay prompts guardados.",
+		"Section %q is empty.":     "La sección %q está vacía.",
+		"Memory System Stats:\n- Sessions: %d\n- Observations: %d\n- Prompts: %d\n- Projects: %s": "Estadísticas de memoria:\n- Sesiones: %d\n- Observaciones: %d\n- Prompts: %d\n- Proyectos: %s",
+		"\n- Oldest observation: %s\n- Newest observation: %s":                                    "\n- Observación más antigua: %s\n- Observación más reciente: %s",
+		"\n- Duplicates avoided: %d":                                                              "\n- Duplicados evitados: %d",
+		"Observation #%d not found":                                                               "No se encontró la observación #%d",
+		"\nProject: %s":                                                                           "\nProyecto: %s",
+		"\nScope: %s":                                                                             "\nAlcance: %s",
+		"\nTopic: %s":                                                                             "\nTema: %s",
+		"\nTool: %s":                                                                              "\nHerramienta: %s",
+		"\nSource: %s":                                                                            "\nOrigen: %s",
+		"\nDuplicates: %d":                                                                        "\nDuplicados: %d",
+		"\nRevisions: %d":                                                                         "\nRevisiones: %d",
+		"\nStale since: %s":                                                                       "\nDesactualizada desde: %s",
+		"\nLast verified: %s":                                                                     "\nÚltima verificación: %s",
+		"\nEvidence: %s":                                                                          "\nEvidencia: %s",
+		"\nAssembled from %d parts":                                                               "\nReensamblada a partir de %d partes",
+		"#%d [%s] %s\n%s\nSession: %s%s%s\nCreated: %s%s":                                         "#%d [%s] %s\n%s\nSesión: %s%s%s\nCreada: %s%s",
+		"Session summary saved for project %q":                                                    "Resumen de sesión guardado para el proyecto %q",
+		"Session %q started for project %q":                                                       "Sesión %q iniciada para el proyecto %q",
+		", continuing %q":                                                                         ", continuando %q",
+		"Session %q completed":                                                                    "Sesión %q completada",
+		"Compaction recorded for project %q (marker #%d); the next mem_context call resurfaces the latest session summary": "Compactación registrada para el proyecto %q (marcador #%d); la próxima llamada a mem_context mostrará el último resumen de sesión",
+		"; %d durable working memory item(s) saved as observations":                                                        "; %d elemento(s) duradero(s) de la memoria de trabajo guardado(s) como observaciones",
+		"Working memory %q set":                             "Memoria de trabajo %q guardada",
+		"No working memory item %q in session %s.":          "No hay un elemento %q en la memoria de trabajo de la sesión %s.",
+		"Working memory of session %s is empty.":            "La memoria de trabajo de la sesión %s está vacía.",
+		"Cleared %d working memory item(s) from session %s": "Se borraron %d elemento(s) de la memoria de trabajo de la sesión %s",
+		"Tool run #%d recorded: %s":                         "Ejecución #%d registrada: %s",
+		"Marked #%d %q as stale. It now ranks below fresh memories; rewrite it with mem_update or confirm it again with mem_verify.": "#%d %q quedó marcada como desactualizada. Ahora aparece debajo de las memorias vigentes; reescribila con mem_update o confirmala de nuevo con mem_verify.",
+		"Confirmed #%d %q (verified %s)":                                                               "#%d %q confirmada (verificada %s)",
+		"\nTranslated to: %s (lang=original for the stored text)":                                      "\nTraducido a: %s (lang=original para el texto guardado)",
+		"\nTranslation to %s failed, showing the original: %s":                                         "\nFalló la traducción a %s, se muestra el original: %s",
+		"Rolled back migration %d (%s)":                                                                "Migración %d (%s) revertida",
+		"Already at schema version %d or below.":                                                       "El esquema ya está en la versión %d o anterior.",
+		"Any other engram command migrates the database up again; switch binaries before running one.": "Cualquier otro comando de engram vuelve a migrar la base; cambiá de binario antes de ejecutar uno.",
+		"Schema version %d (this engram knows up to %d)":                                               "Versión de esquema %d (este engram conoce hasta la %d)",
+		"pending":                   "pendiente",
+		"applied by a newer engram": "aplicada por un engram más nuevo",
+		"Session %q has no decisions, bugfixes, or file changes to summarize.": "La sesión %q no tiene decisiones, correcciones ni cambios de archivos para resumir.",
+		"Saved auto-generated summary #%d for session %q:":                     "Resumen autogenerado #%d guardado para la sesión %q:",
+		"Session %q already has summary #%d:":                                  "La sesión %q ya tiene el resumen #%d:",
+		"Dedupe report":                                                        "Reporte de deduplicación",
+		"  Saves:        %d":                                                   "  Guardados:    %d",
+		"  Inserted:     %d":                                                   "  Insertados:   %d",
+		"  Deduped:      %d (%.0f%%)":                                          "  Deduplicados: %d (%.0f%%)",
+		"  Saved:        ~%s of titles and content":                            "  Ahorro:       ~%s de títulos y contenido",
+		"By project and type":                                                  "Por proyecto y tipo",
+		"Biggest duplicate clusters":                                           "Grupos de duplicados más grandes",
+		"      %d saves in %d rows":                                            "      %d guardados en %d filas",
+		"; the extra copies take %s":                                           "; las copias extra ocupan %s",
+
+		"Context estimate: %d chars, ~%d tokens": "Estimación del contexto: %d caracteres, ~%d tokens",
+		"- %s: %d items, %d chars, ~%d tokens":   "- %s: %d elementos, %d caracteres, ~%d tokens",
+
+		"Existing topic, latest #%d %q; saving with it updates that memory.": "Tema existente, último #%d %q; guardar con él actualiza esa memoria.",
+		"A new key would have been %s.":                                      "Una clave nueva habría sido %s.",
+		"Closest existing topic keys:":                                       "Claves de tema existentes más parecidas:",
+
+		"\nMetadata: %s":                     "\nMetadatos: %s",
+		"metadata":                           "metadatos",
+		"No memories found for metadata: %s": "No se encontraron memorias para los metadatos: %s",
+	},
+}
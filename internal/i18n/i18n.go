@@ -0,0 +1,77 @@
+// Package i18n localizes the human-readable text engram prints: CLI output,
+// TUI labels, and the text block of MCP tool results.
+//
+// Messages are keyed by their English text, gettext style, so call sites
+// stay readable and English needs no catalog. Only prose is translated:
+// JSON, MCP structured content, tool names and schemas, flags, and
+// observation types stay in English so scripts and agents see stable keys.
+// A message missing from a locale falls back to English, so the catalog can
+// grow one string at a time.
+package i18n
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// Supported locales.
+const (
+	English = "en"
+	Spanish = "es"
+)
+
+var current atomic.Value // string
+
+func init() { current.Store(English) }
+
+// Locales lists the supported locales, English first.
+func Locales() []string {
+	return []string{English, Spanish}
+}
+
+// Parse normalizes a locale name. It accepts a bare language ("es") or a
+// POSIX or BCP 47 name ("es_AR.UTF-8", "es-MX"); empty means English.
+func Parse(name string) (string, error) {
+	lang := strings.ToLower(strings.TrimSpace(name))
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if lang == "" {
+		return English, nil
+	}
+	if !slices.Contains(Locales(), lang) {
+		return "", fmt.Errorf("unsupported locale %q (want %s)", name, strings.Join(Locales(), ", "))
+	}
+	return lang, nil
+}
+
+// SetLocale selects the locale used by T.
+func SetLocale(name string) error {
+	lang, err := Parse(name)
+	if err != nil {
+		return err
+	}
+	current.Store(lang)
+	return nil
+}
+
+// Locale returns the selected locale.
+func Locale() string {
+	return current.Load().(string)
+}
+
+// T translates an English message into the selected locale. Messages
+// without a translation are returned as is.
+func T(msg string) string {
+	if translated, ok := catalog[Locale()][msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// Tf translates an English format string and formats it like fmt.Sprintf.
+func Tf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
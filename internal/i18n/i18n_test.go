@@ -0,0 +1,66 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]string{
+		"":            English,
+		"en":          English,
+		"EN_us.UTF-8": English,
+		"es":          Spanish,
+		"es_AR.UTF-8": Spanish,
+		"es-MX":       Spanish,
+		" Es ":        Spanish,
+	}
+	for in, want := range cases {
+		got, err := Parse(in)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := Parse("fr"); err == nil {
+		t.Fatalf("expected an error for an unsupported locale")
+	}
+}
+
+func TestTranslateFallsBackToEnglish(t *testing.T) {
+	t.Cleanup(func() { _ = SetLocale(English) })
+
+	if got := Tf("Found %d memories:", 3); got != "Found 3 memories:" {
+		t.Fatalf("english: got %q", got)
+	}
+	if err := SetLocale("es_AR.UTF-8"); err != nil {
+		t.Fatalf("set locale: %v", err)
+	}
+	if Locale() != Spanish {
+		t.Fatalf("expected es, got %q", Locale())
+	}
+	if got := Tf("Found %d memories:", 3); got != "Se encontraron 3 memorias:" {
+		t.Fatalf("spanish: got %q", got)
+	}
+	if got := T("a message nobody translated"); got != "a message nobody translated" {
+		t.Fatalf("expected english fallback, got %q", got)
+	}
+	if err := SetLocale("klingon"); err == nil || Locale() != Spanish {
+		t.Fatalf("an invalid locale must fail and keep the current one, got %v %q", err, Locale())
+	}
+}
+
+var verbRe = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestCatalogKeepsFormatVerbs(t *testing.T) {
+	for locale, messages := range catalog {
+		if !slices.Contains(Locales(), locale) {
+			t.Errorf("catalog has unsupported locale %q", locale)
+		}
+		for key, translated := range messages {
+			if want, got := verbRe.FindAllString(key, -1), verbRe.FindAllString(translated, -1); !slices.Equal(want, got) {
+				t.Errorf("%s: %q has verbs %v, translation %q has %v", locale, key, want, translated, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Package llm is the client for OpenAI-compatible chat completions
+// endpoints (OpenAI, OpenRouter, Ollama, llama.cpp, ...) shared by
+// enrichment and translation.
+//
+// It sends one system and one user message and returns the reply text. What
+// to ask and how to read the answer, and which failures are worth retrying,
+// stay with the caller.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxResponse caps how much of a response body is read.
+const maxResponse = 1 << 20
+
+// Options configures a Client.
+type Options struct {
+	// Endpoint is the chat completions URL, e.g.
+	// https://api.openai.com/v1/chat/completions or
+	// http://localhost:11434/v1/chat/completions.
+	Endpoint string
+	Model    string
+	// APIKey is sent as a bearer token when set.
+	APIKey string
+	// Timeout bounds each request; <= 0 leaves it to ctx.
+	Timeout time.Duration
+}
+
+// ErrUnexpectedResponse is returned when a 200 response is not a chat
+// completion with at least one choice.
+var ErrUnexpectedResponse = errors.New("unexpected response")
+
+// StatusError is returned for a response other than 200 OK.
+type StatusError struct {
+	Code   int
+	Status string // e.g. "429 Too Many Requests"
+	Body   string // trimmed response body
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Status, e.Body)
+}
+
+// Retryable reports whether the endpoint may accept the same request later:
+// rate limits and server errors.
+func (e *StatusError) Retryable() bool {
+	return e.Code == http.StatusTooManyRequests || e.Code >= 500
+}
+
+// Message is one chat message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is the body posted to the endpoint.
+type Request struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+}
+
+type response struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+}
+
+// Client talks to one endpoint and model.
+type Client struct {
+	opts Options
+	http *http.Client
+}
+
+// NewClient returns a client for opts.Endpoint.
+func NewClient(opts Options) (*Client, error) {
+	if opts.Endpoint == "" {
+		return nil, errors.New("endpoint is required")
+	}
+	if opts.Model == "" {
+		return nil, errors.New("model is required")
+	}
+	return &Client{opts: opts, http: &http.Client{Timeout: opts.Timeout}}, nil
+}
+
+// Complete sends system and user as one chat and returns the first choice's
+// reply. Transport failures are returned as they are, a non-200 response as
+// a *StatusError.
+func (c *Client) Complete(ctx context.Context, system, user string) (string, error) {
+	body, err := json.Marshal(Request{
+		Model: c.opts.Model,
+		Messages: []Message{
+			{Role: "system", Content: system},
+			{Role: "user", Content: user},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.APIKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{Code: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(raw))}
+	}
+
+	var chat response
+	if err := json.Unmarshal(raw, &chat); err != nil || len(chat.Choices) == 0 {
+		return "", fmt.Errorf("%w: %.200s", ErrUnexpectedResponse, raw)
+	}
+	return chat.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientComplete(t *testing.T) {
+	var gotAuth, gotType string
+	var gotReq Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth, gotType = r.Header.Get("Authorization"), r.Header.Get("Content-Type")
+		_ = json.NewDecoder(r.Body).Decode(&gotReq)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"role": "assistant", "content": "pong"}}},
+		})
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(Options{Endpoint: srv.URL, Model: "test-model", APIKey: "k3y"})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	reply, err := c.Complete(context.Background(), "be brief", "ping")
+	if err != nil || reply != "pong" {
+		t.Fatalf("complete = %q err=%v", reply, err)
+	}
+	if gotAuth != "Bearer k3y" || gotType != "application/json" {
+		t.Fatalf("unexpected headers: auth=%q content-type=%q", gotAuth, gotType)
+	}
+	want := []Message{{Role: "system", Content: "be brief"}, {Role: "user", Content: "ping"}}
+	if gotReq.Model != "test-model" || len(gotReq.Messages) != 2 || gotReq.Messages[0] != want[0] || gotReq.Messages[1] != want[1] {
+		t.Fatalf("unexpected request: %+v", gotReq)
+	}
+
+	if _, err := NewClient(Options{Endpoint: srv.URL}); err == nil {
+		t.Fatalf("expected an error without a model")
+	}
+	if _, err := NewClient(Options{Model: "m"}); err == nil {
+		t.Fatalf("expected an error without an endpoint")
+	}
+}
+
+func TestClientCompleteErrors(t *testing.T) {
+	status, body := http.StatusTooManyRequests, `{"error":"slow down"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+	c, _ := NewClient(Options{Endpoint: srv.URL, Model: "m"})
+
+	var se *StatusError
+	if _, err := c.Complete(context.Background(), "", ""); !errors.As(err, &se) || se.Code != status || !se.Retryable() || se.Body != body {
+		t.Fatalf("429 should be a retryable status error, got %v", err)
+	}
+	status = http.StatusBadRequest
+	if _, err := c.Complete(context.Background(), "", ""); !errors.As(err, &se) || se.Retryable() {
+		t.Fatalf("400 should not be retryable, got %v", err)
+	}
+	status, body = http.StatusOK, `{"choices": []}`
+	if _, err := c.Complete(context.Background(), "", ""); !errors.Is(err, ErrUnexpectedResponse) {
+		t.Fatalf("expected ErrUnexpectedResponse without choices, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Package logging configures structured logging for the engram binary.
+//
+// Everything goes through log/slog. Setup installs the configured logger as
+// the slog default, which also routes the standard library log package
+// through it, so existing log.Printf call sites become structured records.
+//
+// Logs are always written to stderr — stdout belongs to the MCP stdio
+// transport and must never carry log lines.
+//
+// Environment:
+//
+//	ENGRAM_LOG_LEVEL  debug | info (default) | warn | error
+//	ENGRAM_LOG_FILE   "1"/"true" → {data dir}/engram.log, or an explicit path
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultFileName is the log file created in the data dir when
+// ENGRAM_LOG_FILE is set to a boolean true value.
+const DefaultFileName = "engram.log"
+
+// stderr is injectable for testing.
+var stderr io.Writer = os.Stderr
+
+// ParseLevel maps a level name to a slog.Level. Unknown or empty values
+// fall back to info.
+func ParseLevel(name string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// FilePath resolves ENGRAM_LOG_FILE against the data dir. It returns ""
+// when file logging is disabled.
+func FilePath(dataDir, value string) string {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "", "0", "false", "no", "off":
+		return ""
+	case "1", "true", "yes", "on":
+		return filepath.Join(dataDir, DefaultFileName)
+	}
+	return value
+}
+
+// Setup builds the logger from the environment, installs it as the slog
+// default, and returns it together with a closer for the optional log file.
+func Setup(dataDir string) (*slog.Logger, io.Closer, error) {
+	opts := &slog.HandlerOptions{Level: ParseLevel(os.Getenv("ENGRAM_LOG_LEVEL"))}
+	handlers := []slog.Handler{slog.NewTextHandler(stderr, opts)}
+
+	var closer io.Closer = nopCloser{}
+	if path := FilePath(dataDir, os.Getenv("ENGRAM_LOG_FILE")); path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, nil, fmt.Errorf("engram: create log dir: %w", err)
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("engram: open log file: %w", err)
+		}
+		handlers = append(handlers, slog.NewJSONHandler(f, opts))
+		closer = f
+	}
+
+	var h slog.Handler = handlers[0]
+	if len(handlers) > 1 {
+		h = fanout(handlers)
+	}
+	logger := slog.New(h)
+	slog.SetDefault(logger)
+	return logger, closer, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// fanout sends every record to all handlers that accept its level.
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"":        slog.LevelInfo,
+		"info":    slog.LevelInfo,
+		"DEBUG":   slog.LevelDebug,
+		" warn ":  slog.LevelWarn,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+		"verbose": slog.LevelInfo,
+	}
+	for in, want := range tests {
+		if got := ParseLevel(in); got != want {
+			t.Errorf("ParseLevel(%q)=%v want=%v", in, got, want)
+		}
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	if got := FilePath("/data", ""); got != "" {
+		t.Fatalf("expected disabled file logging, got %q", got)
+	}
+	if got := FilePath("/data", "false"); got != "" {
+		t.Fatalf("expected disabled file logging for false, got %q", got)
+	}
+	if got := FilePath("/data", "true"); got != filepath.Join("/data", DefaultFileName) {
+		t.Fatalf("expected default log file in data dir, got %q", got)
+	}
+	if got := FilePath("/data", "/var/log/engram.log"); got != "/var/log/engram.log" {
+		t.Fatalf("expected explicit path, got %q", got)
+	}
+}
+
+func TestSetupWritesStderrAndFileRespectingLevel(t *testing.T) {
+	oldDefault := slog.Default()
+	oldStderr := stderr
+	var buf bytes.Buffer
+	stderr = &buf
+	t.Cleanup(func() {
+		slog.SetDefault(oldDefault)
+		stderr = oldStderr
+	})
+
+	dir := t.TempDir()
+	t.Setenv("ENGRAM_LOG_LEVEL", "warn")
+	t.Setenv("ENGRAM_LOG_FILE", "1")
+
+	logger, closer, err := Setup(dir)
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	logger.Info("hidden")
+	logger.Warn("visible", "tool", "mem_save")
+	if err := closer.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "tool=mem_save") {
+		t.Fatalf("unexpected stderr output: %q", buf.String())
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, DefaultFileName))
+	if err != nil {
+		t.Fatalf("read log file: %v", err)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(raw), &rec); err != nil {
+		t.Fatalf("expected single JSON record in log file, got %q: %v", raw, err)
+	}
+	if rec["msg"] != "visible" || rec["tool"] != "mem_save" {
+		t.Fatalf("unexpected file record: %v", rec)
+	}
+}
@@ -14,24 +14,49 @@
 package mcp
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Gentleman-Programming/engram/internal/i18n"
 	projectpkg "github.com/Gentleman-Programming/engram/internal/project"
 	"github.com/Gentleman-Programming/engram/internal/store"
+	"github.com/Gentleman-Programming/engram/internal/translate"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 // MCPConfig holds configuration for the MCP server.
 type MCPConfig struct {
-	DefaultProject string // Auto-detected project name, used when LLM sends empty project
+	DefaultProject string             // Auto-detected project name, used when LLM sends empty project
+	Translate      *translate.Service // Retrieval translation from [translate]; nil when off
+	// ToolOverrides replace advertised tool text, keyed by tool name (see
+	// ValidateToolOverrides).
+	ToolOverrides map[string]ToolOverride
+	// ReadOnly registers only ReadOnlyTools and refuses calls to any other
+	// tool, for agents that must never change memory. Translations are then
+	// not cached either.
+	ReadOnly bool
+	// RateLimits caps calls per tool and MCP session; nil means
+	// DefaultRateLimits. Tools without an entry are unlimited.
+	RateLimits map[string]RateLimit
 }
 
 var suggestTopicKey = store.SuggestTopicKey
 
+// observationTypes is the taxonomy mem_save reports back when it has to
+// infer a type.
+var observationTypes = []string{"decision", "architecture", "bugfix", "pattern", "config", "discovery", "learning"}
+
 var loadMCPStats = func(s *store.Store) (*store.Stats, error) {
 	return s.Stats()
 }
@@ -41,7 +66,11 @@ var loadMCPStats = func(s *store.Store) (*store.Stats, error) {
 // "agent" — tools AI agents use during coding sessions:
 //   mem_save, mem_search, mem_context, mem_session_summary,
 //   mem_session_start, mem_session_end, mem_get_observation,
-//   mem_suggest_topic_key, mem_capture_passive, mem_save_prompt
+//   mem_suggest_topic_key, mem_capture_passive, mem_save_prompt,
+//   mem_search_prompts, mem_recent_prompts, mem_topics, mem_topic_search,
+//   mem_for_file, mem_context_outline, mem_context_section, mem_scratch_set,
+//   mem_scratch_get, mem_scratch_clear, mem_tool_run, mem_verify,
+//   mem_compaction_event, mem_templates, mem_quota
 //
 // "admin" — tools for manual curation, TUI, and dashboards:
 //   mem_update, mem_delete, mem_stats, mem_timeline, mem_merge_projects
@@ -60,8 +89,23 @@ var ProfileAgent = map[string]bool{
 	"mem_session_end":       true, // mark session completed
 	"mem_get_observation":   true, // full observation content after search — referenced 4 times
 	"mem_suggest_topic_key": true, // stable topic key for upserts — referenced 3 times
+	"mem_topics":            true, // discover existing topic keys before choosing one
+	"mem_topic_search":      true, // fuzzy-find an existing topic key instead of forking a near-duplicate
+	"mem_for_file":          true, // recall what is known about a file before editing it
+	"mem_context_outline":   true, // compact context headings, expanded on demand
+	"mem_context_section":   true, // expand one heading from mem_context_outline
 	"mem_capture_passive":   true, // extract learnings from text — referenced in Gemini/Codex protocol
 	"mem_save_prompt":       true, // save user prompts
+	"mem_search_prompts":    true, // recall what the user asked, by keyword
+	"mem_recent_prompts":    true, // recall what the user asked, newest first
+	"mem_scratch_set":       true, // session scratchpad, promoted at session end when durable
+	"mem_scratch_get":       true, // read the session scratchpad back after a compaction
+	"mem_scratch_clear":     true, // drop scratchpad items
+	"mem_tool_run":          true, // structured tool run records from plugins and hooks
+	"mem_compaction_event":  true, // plugins report compaction so mem_context resurfaces the last summary
+	"mem_templates":         true, // ADR and incident skeletons so recurring write-ups share a layout
+	"mem_quota":             true, // check the project's storage budget before saving low-value memories
+	"mem_verify":            true, // confirm a memory still holds, or flag it stale
 	"mem_update":            true, // update observation by ID — skills say "use mem_update when you have an exact ID to correct"
 }
 
@@ -74,6 +118,27 @@ var ProfileAdmin = map[string]bool{
 	"mem_merge_projects": true, // destructive curation tool — not for agent use
 }
 
+// ReadOnlyTools are the tools that never write to the store. They are all
+// that a read-only server (MCPConfig.ReadOnly) registers or answers.
+var ReadOnlyTools = map[string]bool{
+	"mem_search":            true,
+	"mem_context":           true,
+	"mem_context_outline":   true,
+	"mem_context_section":   true,
+	"mem_get_observation":   true,
+	"mem_timeline":          true,
+	"mem_stats":             true,
+	"mem_for_file":          true,
+	"mem_topics":            true,
+	"mem_topic_search":      true,
+	"mem_suggest_topic_key": true,
+	"mem_search_prompts":    true,
+	"mem_recent_prompts":    true,
+	"mem_scratch_get":       true,
+	"mem_templates":         true,
+	"mem_quota":             true,
+}
+
 // Profiles maps profile names to their tool sets.
 var Profiles = map[string]map[string]bool{
 	"agent": ProfileAgent,
@@ -134,9 +199,36 @@ CORE TOOLS (always available — use without ToolSearch):
 
 DEFERRED TOOLS (use ToolSearch when needed):
   mem_update, mem_suggest_topic_key, mem_session_start, mem_session_end,
-  mem_stats, mem_delete, mem_timeline, mem_capture_passive, mem_merge_projects
+  mem_stats, mem_delete, mem_timeline, mem_capture_passive, mem_merge_projects,
+  mem_search_prompts, mem_recent_prompts, mem_topics, mem_for_file,
+  mem_topic_search (find an existing topic_key before inventing one),
+  mem_context_outline, mem_context_section (load only the parts of mem_context you need),
+  mem_scratch_set, mem_scratch_get, mem_scratch_clear (session working memory),
+  mem_tool_run (structured tool run records),
+  mem_compaction_event (report a context compaction; the next mem_context resurfaces the latest summary),
+  mem_verify (confirm a memory still holds, or mark it stale),
+  mem_templates (section layout, type, and topic key for ADRs, incidents, and project templates),
+  mem_quota (how much of the project's storage quota is used)
+
+PROACTIVE SAVE RULE: Call mem_save immediately after ANY decision, bug fix, discovery, or convention — not just when asked.
+
+WORKING MEMORY RULE: Keep task state and todo lists in mem_scratch_set, not mem_save. Mark an item durable=true only if it should outlive the session.
+
+FILE RECALL RULE: Before editing a file for the first time in a session, call mem_for_file(path) to recall decisions and bugs recorded against it. List touched files in the **Where** section of mem_save so they can be recalled this way.
+
+FRESHNESS RULE: Before acting on a recalled memory that the code contradicts (a removed library, a renamed file), check it and call mem_verify(id, status="stale", evidence=...). Call mem_verify(id) when you confirm one still holds.`
+
+// readOnlyInstructions replaces serverInstructions on a read-only server,
+// which has no tools to save with.
+const readOnlyInstructions = `Engram provides persistent memory that survives across sessions and compactions. This server is READ-ONLY: you can recall memories but not save, update, or delete them.
 
-PROACTIVE SAVE RULE: Call mem_save immediately after ANY decision, bug fix, discovery, or convention — not just when asked.`
+  mem_search — find past work, decisions, or context from previous sessions
+  mem_context — get recent session history (call at session start or after compaction)
+  mem_get_observation — get full untruncated content of a search result by ID
+  mem_timeline — chronological context around a search result
+  mem_stats — memory system statistics
+
+Also available: mem_context_outline, mem_context_section, mem_for_file, mem_topics, mem_topic_search, mem_suggest_topic_key, mem_search_prompts, mem_recent_prompts, mem_scratch_get, mem_templates, mem_quota.`
 
 // NewServerWithTools creates an MCP server registering only the tools in
 // the allowlist. If allowlist is nil, all tools are registered.
@@ -151,17 +243,55 @@ func NewServerWithConfig(s *store.Store, cfg MCPConfig, allowlist map[string]boo
 }
 
 func newServerWithActivity(s *store.Store, cfg MCPConfig, allowlist map[string]bool, activity *SessionActivity) *server.MCPServer {
-	srv := server.NewMCPServer(
-		"engram",
-		"0.1.0",
+	instructions := serverInstructions
+	opts := []server.ServerOption{
 		server.WithToolCapabilities(true),
-		server.WithInstructions(serverInstructions),
-	)
+		server.WithToolHandlerMiddleware(logToolCalls),
+	}
+	if cfg.ReadOnly {
+		instructions = readOnlyInstructions
+		allowlist = readOnlyAllowlist(allowlist)
+		opts = append(opts, server.WithToolHandlerMiddleware(rejectWrites))
+		if cfg.Translate != nil {
+			cfg.Translate = cfg.Translate.ReadOnly()
+		}
+	}
+	limits := cfg.RateLimits
+	if limits == nil {
+		limits = DefaultRateLimits
+	}
+	opts = append(opts, server.WithToolHandlerMiddleware(newRateLimiter(limits).middleware))
+	opts = append(opts, server.WithInstructions(instructions))
+	srv := server.NewMCPServer("engram", "0.1.0", opts...)
 
 	registerTools(srv, s, cfg, allowlist, activity)
+	applyToolOverrides(srv, cfg.ToolOverrides)
 	return srv
 }
 
+// readOnlyAllowlist narrows allowlist (nil meaning every tool) to
+// ReadOnlyTools.
+func readOnlyAllowlist(allowlist map[string]bool) map[string]bool {
+	narrowed := make(map[string]bool, len(ReadOnlyTools))
+	for name := range ReadOnlyTools {
+		if allowlist == nil || allowlist[name] {
+			narrowed[name] = true
+		}
+	}
+	return narrowed
+}
+
+// rejectWrites refuses every tool outside ReadOnlyTools, so a write tool
+// cannot run on a read-only server even if something registers it.
+func rejectWrites(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !ReadOnlyTools[req.Params.Name] {
+			return mcp.NewToolResultError(fmt.Sprintf("%s is not available: this engram MCP server is read-only", req.Params.Name)), nil
+		}
+		return next(ctx, req)
+	}
+}
+
 // shouldRegister returns true if the tool should be registered given the
 // allowlist. If allowlist is nil, everything is allowed.
 func shouldRegister(name string, allowlist map[string]bool) bool {
@@ -184,7 +314,7 @@ func registerTools(srv *server.MCPServer, s *store.Store, cfg MCPConfig, allowli
 				mcp.WithOpenWorldHintAnnotation(false),
 				mcp.WithString("query",
 					mcp.Required(),
-					mcp.Description("Search query — natural language or keywords"),
+					mcp.Description(`Search query — natural language or keywords. Supports "exact phrase", OR, NOT / -term, and prefix*`),
 				),
 				mcp.WithString("type",
 					mcp.Description("Filter by type: tool_use, file_change, command, file_read, search, manual, decision, architecture, bugfix, pattern"),
@@ -195,14 +325,71 @@ func registerTools(srv *server.MCPServer, s *store.Store, cfg MCPConfig, allowli
 				mcp.WithString("scope",
 					mcp.Description("Filter by scope: project (default) or personal"),
 				),
+				mcp.WithString("ref",
+					mcp.Description("Only memories linked to this issue/PR: #123, owner/repo#123, !45, or a full URL"),
+				),
+				mcp.WithString("source",
+					mcp.Description("Only memories that entered through this path: cli, mcp (or mcp:<client>), http, passive, scratch, import, sync-import"),
+				),
+				mcp.WithString("session_id",
+					mcp.Description("Only memories saved in this session, e.g. the current one from mem_session_start"),
+				),
+				mcp.WithString("started_after",
+					mcp.Description("Only memories created at or after this date (YYYY-MM-DD) or RFC3339 time"),
+				),
+				mcp.WithString("started_before",
+					mcp.Description("Only memories created before this date (YYYY-MM-DD) or RFC3339 time"),
+				),
 				mcp.WithNumber("limit",
-					mcp.Description("Max results (default: 10, max: 20)"),
+					mcp.Description("Max results per page (default: 10, max: 20)"),
+				),
+				mcp.WithString("cursor",
+					mcp.Description("Continuation token from a previous mem_search result (next_cursor). Repeat the same query and filters to get the next page."),
+				),
+				mcp.WithString("target",
+					mcp.Description("What to search: observations (default) or all, which also searches saved user prompts and tags each result with its kind. Not paginated"),
+					mcp.Enum("observations", "all"),
+				),
+				mcp.WithBoolean("explain",
+					mcp.Description("Also report why each result ranked where it did: matched columns, FTS rank, age, and ranking modifiers (default: false). For tuning and debugging recall"),
+				),
+				mcp.WithObject("metadata",
+					mcp.Description(`Only memories whose metadata has all of these key/value pairs, e.g. {"pr": 412, "model": "gpt-5"}. Numbers and booleans match their text form`),
 				),
 			),
 			handleSearch(s, cfg, activity),
 		)
 	}
 
+	// ─── mem_for_file (profile: agent, deferred) ────────────────────────
+	if shouldRegister("mem_for_file", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_for_file",
+				mcp.WithDescription("Recall every memory that mentions a file path — decisions, bugs, and gotchas recorded in the **Where** section of past saves. Call this BEFORE editing a file you have not touched in this session. Accepts a relative path, an absolute path, or just the file name."),
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Memories for File"),
+				mcp.WithReadOnlyHintAnnotation(true),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithString("path",
+					mcp.Required(),
+					mcp.Description("File path, e.g. src/auth/middleware.ts, /abs/path/to/middleware.ts, or middleware.ts"),
+				),
+				mcp.WithString("project",
+					mcp.Description("Filter by project name"),
+				),
+				mcp.WithString("scope",
+					mcp.Description("Filter by scope: project or personal (default: both)"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Max results (default: 10, max: 20)"),
+				),
+			),
+			handleForFile(s, cfg),
+		)
+	}
+
 	// ─── mem_save (profile: agent, core — always in context) ───────────
 	if shouldRegister("mem_save", allowlist) {
 		srv.AddTool(
@@ -247,7 +434,7 @@ Examples:
 					mcp.Description("Structured content using **What**, **Why**, **Where**, **Learned** format"),
 				),
 				mcp.WithString("type",
-					mcp.Description("Category: decision, architecture, bugfix, pattern, config, discovery, learning (default: manual)"),
+					mcp.Description("Category: decision, architecture, bugfix, pattern, config, discovery, learning (default: inferred from title/content, else manual)"),
 				),
 				mcp.WithString("session_id",
 					mcp.Description("Session ID to associate with (default: manual-save-{project})"),
@@ -261,6 +448,12 @@ Examples:
 				mcp.WithString("topic_key",
 					mcp.Description("Optional topic identifier for upserts (e.g. architecture/auth-model). Reuses and updates the latest observation in same project+scope."),
 				),
+				mcp.WithString("refs",
+					mcp.Description("Comma-separated issue/PR refs (URLs or #123). Refs mentioned in title/content are detected automatically."),
+				),
+				mcp.WithObject("metadata",
+					mcp.Description(`Structured data to keep beside the content, e.g. {"pr": 412, "model": "gpt-5", "tokens": 1830}. Values must be strings, numbers, or booleans; keys use letters, digits, _ . : -. A topic_key upsert without metadata keeps the existing metadata`),
+				),
 			),
 			handleSave(s, cfg, activity),
 		)
@@ -299,6 +492,9 @@ Examples:
 				mcp.WithString("topic_key",
 					mcp.Description("New topic key (normalized internally)"),
 				),
+				mcp.WithObject("metadata",
+					mcp.Description("New metadata, replacing the existing metadata; {} clears it"),
+				),
 			),
 			handleUpdate(s),
 		)
@@ -308,7 +504,7 @@ Examples:
 	if shouldRegister("mem_suggest_topic_key", allowlist) {
 		srv.AddTool(
 			mcp.NewTool("mem_suggest_topic_key",
-				mcp.WithDescription("Suggest a stable topic_key for memory upserts. Use this before mem_save when you want evolving topics (like architecture decisions) to update a single observation over time."),
+				mcp.WithDescription("Suggest a stable topic_key for memory upserts. Use this before mem_save when you want evolving topics (like architecture decisions) to update a single observation over time. Prefers an existing key of the project that nearly matches, and lists the closest existing keys."),
 				mcp.WithDeferLoading(true),
 				mcp.WithTitleAnnotation("Suggest Topic Key"),
 				mcp.WithReadOnlyHintAnnotation(true),
@@ -324,8 +520,106 @@ Examples:
 				mcp.WithString("content",
 					mcp.Description("Observation content used as fallback if title is empty"),
 				),
+				mcp.WithString("project",
+					mcp.Description("Project whose existing topic keys are checked (default: the detected project)"),
+				),
+				mcp.WithString("scope",
+					mcp.Description("Scope whose existing topic keys are checked: project (default) or personal"),
+				),
 			),
-			handleSuggestTopicKey(),
+			handleSuggestTopicKey(s, cfg),
+		)
+	}
+
+	// ─── mem_topics (profile: agent, deferred) ──────────────────────────
+	if shouldRegister("mem_topics", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_topics",
+				mcp.WithDescription("List existing topic keys with the latest observation for each. Check this before choosing a topic_key so evolving topics keep upserting into the same memory instead of forking a near-duplicate key."),
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("List Topics"),
+				mcp.WithReadOnlyHintAnnotation(true),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithString("project",
+					mcp.Description("Filter by project name"),
+				),
+				mcp.WithString("scope",
+					mcp.Description("Filter by scope: project or personal (default: both)"),
+				),
+			),
+			handleTopics(s, cfg),
+		)
+	}
+
+	// ─── mem_templates (profile: agent, deferred) ───────────────────────
+	if shouldRegister("mem_templates", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_templates",
+				mcp.WithDescription("List observation templates for recurring write-ups — adr, incident, and any defined in the project's .engram.toml. Each template gives the ## sections to fill in, the type, and the topic key to save with. Pass name to get one template's skeleton, then call mem_save with that content, type, and topic_key."),
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("List Templates"),
+				mcp.WithReadOnlyHintAnnotation(true),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithString("name",
+					mcp.Description("Template name, e.g. adr or incident (default: list all)"),
+				),
+				mcp.WithString("title",
+					mcp.Description("Title of the memory you are about to save; fills in the suggested topic_key"),
+				),
+			),
+			handleTemplates(s),
+		)
+	}
+
+	// ─── mem_quota (profile: agent, deferred) ───────────────────────────
+	if shouldRegister("mem_quota", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_quota",
+				mcp.WithDescription("Show how much of its storage quota a project uses: observation count and bytes against the configured limits, what is left, and whether usage is near the limit. When status is near_limit or full, skip low-value saves (routine tool output, passive captures) and prefer updating an existing memory with topic_key over adding new ones."),
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Memory Quota"),
+				mcp.WithReadOnlyHintAnnotation(true),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithString("project",
+					mcp.Description("Project name (default: the server's project)"),
+				),
+			),
+			handleQuota(s, cfg),
+		)
+	}
+
+	// ─── mem_topic_search (profile: agent, deferred) ────────────────────
+	if shouldRegister("mem_topic_search", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_topic_search",
+				mcp.WithDescription("Fuzzy-match existing topic keys against a partial or guessed key (e.g. \"auth\" or \"architecture/auth-design\"). Call it before saving with a new topic_key: if a close match exists, reuse it so the save upserts that memory instead of creating a near-duplicate."),
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Search Topic Keys"),
+				mcp.WithReadOnlyHintAnnotation(true),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithString("query",
+					mcp.Required(),
+					mcp.Description("Partial topic key or words, e.g. auth or architecture/auth-design"),
+				),
+				mcp.WithString("project",
+					mcp.Description("Filter by project name"),
+				),
+				mcp.WithString("scope",
+					mcp.Description("Filter by scope: project or personal (default: both)"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Max suggestions (default: 5)"),
+				),
+			),
+			handleTopicSearch(s, cfg),
 		)
 	}
 
@@ -333,7 +627,7 @@ Examples:
 	if shouldRegister("mem_delete", allowlist) {
 		srv.AddTool(
 			mcp.NewTool("mem_delete",
-				mcp.WithDescription("Delete an observation by ID. Soft-delete by default; set hard_delete=true for permanent deletion."),
+				mcp.WithDescription("Delete an observation by ID, or soft-delete many at once by topic_key, session_id, or search query. Soft-delete by default; set hard_delete=true for permanent deletion of a single ID. Bulk deletes first return a dry-run count; call again with confirm=true to execute."),
 				mcp.WithDeferLoading(true),
 				mcp.WithTitleAnnotation("Delete Memory"),
 				mcp.WithReadOnlyHintAnnotation(false),
@@ -341,11 +635,31 @@ Examples:
 				mcp.WithIdempotentHintAnnotation(false),
 				mcp.WithOpenWorldHintAnnotation(false),
 				mcp.WithNumber("id",
-					mcp.Required(),
 					mcp.Description("Observation ID to delete"),
 				),
 				mcp.WithBoolean("hard_delete",
-					mcp.Description("If true, permanently deletes the observation"),
+					mcp.Description("If true, permanently deletes the observation (single ID only)"),
+				),
+				mcp.WithString("topic_key",
+					mcp.Description("Bulk: delete every memory with this topic_key"),
+				),
+				mcp.WithString("session_id",
+					mcp.Description("Bulk: delete every memory saved in this session"),
+				),
+				mcp.WithString("query",
+					mcp.Description("Bulk: delete every memory matching this full-text search"),
+				),
+				mcp.WithString("project",
+					mcp.Description("Bulk: only delete in this project (includes sub-projects)"),
+				),
+				mcp.WithString("scope",
+					mcp.Description("Bulk: only delete in this scope (project or personal)"),
+				),
+				mcp.WithString("type",
+					mcp.Description("Bulk: only delete this observation type"),
+				),
+				mcp.WithBoolean("confirm",
+					mcp.Description("Bulk: set true to delete the matches reported by the dry run"),
 				),
 			),
 			handleDelete(s),
@@ -377,6 +691,54 @@ Examples:
 		)
 	}
 
+	// ─── mem_search_prompts (profile: agent, deferred) ─────────────────
+	if shouldRegister("mem_search_prompts", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_search_prompts",
+				mcp.WithDescription("Search saved user prompts by keyword. Use this to answer \"what exactly did I ask you to do about X?\" with the user's own words instead of a summarized observation."),
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Search User Prompts"),
+				mcp.WithReadOnlyHintAnnotation(true),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithString("query",
+					mcp.Required(),
+					mcp.Description("Search query — keywords from the original request"),
+				),
+				mcp.WithString("project",
+					mcp.Description("Filter by project name"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Max results (default: 10, max: 50)"),
+				),
+			),
+			handleSearchPrompts(s, cfg),
+		)
+	}
+
+	// ─── mem_recent_prompts (profile: agent, deferred) ─────────────────
+	if shouldRegister("mem_recent_prompts", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_recent_prompts",
+				mcp.WithDescription("List the most recent saved user prompts, newest first. Use this to recall what the user asked in previous sessions."),
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Recent User Prompts"),
+				mcp.WithReadOnlyHintAnnotation(true),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithString("project",
+					mcp.Description("Filter by project name"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Max results (default: 20, max: 50)"),
+				),
+			),
+			handleRecentPrompts(s, cfg),
+		)
+	}
+
 	// ─── mem_context (profile: agent, core — always in context) ────────
 	if shouldRegister("mem_context", allowlist) {
 		srv.AddTool(
@@ -388,7 +750,7 @@ Examples:
 				mcp.WithIdempotentHintAnnotation(true),
 				mcp.WithOpenWorldHintAnnotation(false),
 				mcp.WithString("project",
-					mcp.Description("Filter by project (omit for all projects)"),
+					mcp.Description("Filter by project (omit for all projects). Sub-projects are included: \"platform\" also covers \"platform/api\""),
 				),
 				mcp.WithString("scope",
 					mcp.Description("Filter observations by scope: project (default) or personal"),
@@ -396,16 +758,76 @@ Examples:
 				mcp.WithNumber("limit",
 					mcp.Description("Number of observations to retrieve (default: 20)"),
 				),
+				mcp.WithBoolean("include_parents",
+					mcp.Description("For a sub-project like \"platform/api\", also include decisions and architecture notes saved on its parent projects (default: false)"),
+				),
+				mcp.WithBoolean("include_all",
+					mcp.Description("Include observations the configured exclusion rules normally leave out, such as file reads (default: false)"),
+				),
+				mcp.WithBoolean("estimate",
+					mcp.Description("Return only the size of the context per section (characters and approximate tokens) instead of the text, to decide whether to load it (default: false)"),
+				),
+				mcp.WithString("lang",
+					mcp.Description("Show memories translated into this language, e.g. \"en\" or \"es\" (needs [translate] configured; \"original\" disables the configured default)"),
+				),
 			),
 			handleContext(s, cfg, activity),
 		)
 	}
 
+	// ─── mem_context_outline (profile: agent, deferred) ─────────────────
+	if shouldRegister("mem_context_outline", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_context_outline",
+				mcp.WithDescription("Get a compact outline of previous-session memory: section headings, session and topic IDs, and observation titles grouped by type — a fraction of mem_context's tokens. Expand only what you need with mem_context_section."),
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Get Memory Outline"),
+				mcp.WithReadOnlyHintAnnotation(true),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithString("project",
+					mcp.Description("Filter by project (omit for all projects)"),
+				),
+				mcp.WithString("scope",
+					mcp.Description("Filter observations by scope: project (default) or personal"),
+				),
+			),
+			handleContextOutline(s, cfg, activity),
+		)
+	}
+
+	// ─── mem_context_section (profile: agent, deferred) ─────────────────
+	if shouldRegister("mem_context_section", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_context_section",
+				mcp.WithDescription("Expand one section of mem_context_outline by its id: sessions, prompts, observations, topics, session:<id>, type:<type>, or topic:<key>."),
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Expand Memory Section"),
+				mcp.WithReadOnlyHintAnnotation(true),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithString("id",
+					mcp.Required(),
+					mcp.Description("Section id from mem_context_outline, e.g. session:abc123, type:bugfix, topic:architecture/auth-model"),
+				),
+				mcp.WithString("project",
+					mcp.Description("Same project filter used for the outline"),
+				),
+				mcp.WithString("scope",
+					mcp.Description("Same scope filter used for the outline"),
+				),
+			),
+			handleContextSection(s, cfg, activity),
+		)
+	}
+
 	// ─── mem_stats (profile: admin, deferred) ───────────────────────────
 	if shouldRegister("mem_stats", allowlist) {
 		srv.AddTool(
 			mcp.NewTool("mem_stats",
-				mcp.WithDescription("Show memory system statistics — total sessions, observations, and projects tracked."),
+				mcp.WithDescription("Show memory system statistics — total sessions, observations, and projects tracked, plus per-type counts, oldest/newest observation, dedupe savings, and database/WAL/FTS index sizes."),
 				mcp.WithDeferLoading(true),
 				mcp.WithTitleAnnotation("Memory Stats"),
 				mcp.WithReadOnlyHintAnnotation(true),
@@ -457,8 +879,11 @@ Examples:
 					mcp.Required(),
 					mcp.Description("The observation ID to retrieve"),
 				),
+				mcp.WithString("lang",
+					mcp.Description("Return the title and content translated into this language, e.g. \"en\" or \"es\" (needs [translate] configured; \"original\" disables the configured default)"),
+				),
 			),
-			handleGetObservation(s),
+			handleGetObservation(s, cfg),
 		)
 	}
 
@@ -512,6 +937,9 @@ GUIDELINES:
 					mcp.Required(),
 					mcp.Description("Project name"),
 				),
+				mcp.WithBoolean("end_session",
+					mcp.Description("Also mark the session ended, as mem_session_end does (default: true when session_id is given, false otherwise)"),
+				),
 			),
 			handleSessionSummary(s, cfg, activity),
 		)
@@ -539,6 +967,9 @@ GUIDELINES:
 				mcp.WithString("directory",
 					mcp.Description("Working directory"),
 				),
+				mcp.WithString("parent_session_id",
+					mcp.Description("ID of the session this one continues, e.g. the session before a compaction or crash. Its summary is then carried into mem_context."),
+				),
 			),
 			handleSessionStart(s, cfg, activity),
 		)
@@ -570,72 +1001,328 @@ GUIDELINES:
 		)
 	}
 
-	// ─── mem_capture_passive (profile: agent, deferred) ─────────────────
-	if shouldRegister("mem_capture_passive", allowlist) {
+	// ─── mem_scratch_set (profile: agent, deferred) ─────────────────────
+	if shouldRegister("mem_scratch_set", allowlist) {
 		srv.AddTool(
-			mcp.NewTool("mem_capture_passive",
+			mcp.NewTool("mem_scratch_set",
+				mcp.WithDescription("Write a working memory item: a per-session scratchpad for current task state, todo lists, or half-formed notes that should NOT go into long-term memory. Items are invisible to mem_search and mem_context and expire after a TTL (default 24h). Set durable=true for an item worth keeping: it is saved as an observation when the session ends. Setting an existing key replaces it."),
 				mcp.WithDeferLoading(true),
-				mcp.WithTitleAnnotation("Capture Learnings"),
+				mcp.WithTitleAnnotation("Set Working Memory"),
 				mcp.WithReadOnlyHintAnnotation(false),
 				mcp.WithDestructiveHintAnnotation(false),
 				mcp.WithIdempotentHintAnnotation(true),
 				mcp.WithOpenWorldHintAnnotation(false),
-				mcp.WithDescription(`Extract and save structured learnings from text output. Use this at the end of a task to capture knowledge automatically.
-
-The tool looks for sections like "## Key Learnings:" or "## Aprendizajes Clave:" and extracts numbered or bulleted items. Each item is saved as a separate observation.
-
-Duplicates are automatically detected and skipped — safe to call multiple times with the same content.`),
-				mcp.WithString("content",
+				mcp.WithString("key",
 					mcp.Required(),
-					mcp.Description("The text output containing a '## Key Learnings:' section with numbered or bulleted items"),
+					mcp.Description("Item name, e.g. todo, current-task, or a short title (the observation title if promoted)"),
+				),
+				mcp.WithString("value",
+					mcp.Required(),
+					mcp.Description("Item content"),
+				),
+				mcp.WithBoolean("durable",
+					mcp.Description("Promote into an observation at session end (default: false)"),
+				),
+				mcp.WithString("type",
+					mcp.Description("Observation type used on promotion (default: inferred)"),
+				),
+				mcp.WithString("ttl",
+					mcp.Description("How long a non-durable item lives, e.g. 30m or 4h (default: 24h)"),
 				),
 				mcp.WithString("session_id",
-					mcp.Description("Session ID (default: manual-save-{project})"),
+					mcp.Description("Session the item belongs to (default: manual-save-{project}); pass the id given to mem_session_start"),
 				),
 				mcp.WithString("project",
 					mcp.Description("Project name"),
 				),
-				mcp.WithString("source",
-					mcp.Description("Source identifier (e.g. 'subagent-stop', 'session-end')"),
-				),
 			),
-			handleCapturePassive(s, cfg, activity),
+			handleScratchSet(s, cfg),
 		)
 	}
 
-	// ─── mem_merge_projects (profile: admin, deferred) ──────────────────
-	if shouldRegister("mem_merge_projects", allowlist) {
+	// ─── mem_scratch_get (profile: agent, deferred) ─────────────────────
+	if shouldRegister("mem_scratch_get", allowlist) {
 		srv.AddTool(
-			mcp.NewTool("mem_merge_projects",
-				mcp.WithDescription("Merge memories from multiple project name variants into one canonical name. Use when you discover project name drift (e.g. 'Engram' and 'engram' should be the same project). DESTRUCTIVE — moves all records from source names to the canonical name."),
+			mcp.NewTool("mem_scratch_get",
+				mcp.WithDescription("Read working memory: one item by key, or every live item of the session. Use it after a compaction to pick up task state saved with mem_scratch_set."),
 				mcp.WithDeferLoading(true),
-				mcp.WithTitleAnnotation("Merge Projects"),
-				mcp.WithReadOnlyHintAnnotation(false),
-				mcp.WithDestructiveHintAnnotation(true),
+				mcp.WithTitleAnnotation("Get Working Memory"),
+				mcp.WithReadOnlyHintAnnotation(true),
+				mcp.WithDestructiveHintAnnotation(false),
 				mcp.WithIdempotentHintAnnotation(true),
 				mcp.WithOpenWorldHintAnnotation(false),
-				mcp.WithString("from",
-					mcp.Required(),
-					mcp.Description("Comma-separated list of project names to merge FROM (e.g. 'Engram,engram-memory,ENGRAM')"),
+				mcp.WithString("key",
+					mcp.Description("Item to read (default: all items)"),
 				),
-				mcp.WithString("to",
-					mcp.Required(),
-					mcp.Description("The canonical project name to merge INTO (e.g. 'engram')"),
+				mcp.WithString("session_id",
+					mcp.Description("Session to read (default: manual-save-{project})"),
+				),
+				mcp.WithString("project",
+					mcp.Description("Project name"),
 				),
 			),
-			handleMergeProjects(s),
+			handleScratchGet(s, cfg),
 		)
 	}
-}
 
-// ─── Tool Handlers ───────────────────────────────────────────────────────────
-
-func handleSearch(s *store.Store, cfg MCPConfig, activity *SessionActivity) server.ToolHandlerFunc {
-	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// ─── mem_scratch_clear (profile: agent, deferred) ───────────────────
+	if shouldRegister("mem_scratch_clear", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_scratch_clear",
+				mcp.WithDescription("Delete a working memory item by key, or all of the session's working memory when no key is given. Durable items cleared this way are NOT promoted."),
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Clear Working Memory"),
+				mcp.WithReadOnlyHintAnnotation(false),
+				mcp.WithDestructiveHintAnnotation(true),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithString("key",
+					mcp.Description("Item to delete (default: all items)"),
+				),
+				mcp.WithString("session_id",
+					mcp.Description("Session to clear (default: manual-save-{project})"),
+				),
+				mcp.WithString("project",
+					mcp.Description("Project name"),
+				),
+			),
+			handleScratchClear(s, cfg),
+		)
+	}
+
+	// ─── mem_capture_passive (profile: agent, deferred) ─────────────────
+	if shouldRegister("mem_capture_passive", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_capture_passive",
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Capture Learnings"),
+				mcp.WithReadOnlyHintAnnotation(false),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithDescription(`Extract and save structured learnings from text output. Use this at the end of a task to capture knowledge automatically.
+
+The tool looks for sections like "## Key Learnings:", "## Lessons Learned", "**TIL:**", or their Spanish, Portuguese, French, German, and Japanese equivalents ("## Aprendizajes Clave:", "## 学んだこと") and extracts numbered or bulleted items. Each item is saved as a separate observation.
+
+Debugging output in the text is mined too: Go panics, Python tracebacks, and thrown JS/Java errors with their stack become "discovery" observations, failing tests (go test, pytest, jest) are grouped into one, and "Root cause:" lines become "bugfix" observations.
+
+Duplicates are automatically detected and skipped — safe to call multiple times with the same content. Items that look like log output or sentence fragments are held in quarantine for the user to review instead of being saved.`),
+				mcp.WithString("content",
+					mcp.Required(),
+					mcp.Description("The text output containing a '## Key Learnings:' section with numbered or bulleted items, and/or error traces, failing tests, and 'Root cause:' lines"),
+				),
+				mcp.WithString("session_id",
+					mcp.Description("Session ID (default: manual-save-{project})"),
+				),
+				mcp.WithString("project",
+					mcp.Description("Project name"),
+				),
+				mcp.WithString("source",
+					mcp.Description("Source identifier (e.g. 'subagent-stop', 'session-end')"),
+				),
+			),
+			handleCapturePassive(s, cfg, activity),
+		)
+	}
+
+	// ─── mem_tool_run (profile: agent, deferred) ────────────────────────
+	if shouldRegister("mem_tool_run", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_tool_run",
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Record Tool Run"),
+				mcp.WithReadOnlyHintAnnotation(false),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(false),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithDescription("Record a structured tool run — command, exit code, duration, and files changed — for the session timeline and transcript. Meant for plugins and hooks reporting tool activity; use mem_save for what was learned."),
+				mcp.WithString("tool_name",
+					mcp.Required(),
+					mcp.Description("Tool that ran (e.g. bash, edit, write)"),
+				),
+				mcp.WithString("command",
+					mcp.Description("Command line or tool input, shortened"),
+				),
+				mcp.WithNumber("exit_code",
+					mcp.Description("Process exit code, when there is one"),
+				),
+				mcp.WithNumber("duration_ms",
+					mcp.Description("Wall-clock duration in milliseconds"),
+				),
+				mcp.WithString("files",
+					mcp.Description("Comma-separated files changed, each optionally with line counts: 'src/auth.go:+12/-3, README.md'"),
+				),
+				mcp.WithString("summary",
+					mcp.Description("Short summary of the output (failing test names, error line)"),
+				),
+				mcp.WithString("session_id",
+					mcp.Description("Session ID (default: manual-save-{project})"),
+				),
+				mcp.WithString("project",
+					mcp.Description("Project name"),
+				),
+			),
+			handleToolRun(s, cfg, activity),
+		)
+	}
+
+	// ─── mem_compaction_event (profile: agent, deferred) ────────────────
+	if shouldRegister("mem_compaction_event", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_compaction_event",
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Record Compaction"),
+				mcp.WithReadOnlyHintAnnotation(false),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(false),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithDescription("Record that the context was just compacted. Saves a compaction marker in the session, and the next mem_context call opens with the latest session summary so work resumes where it stopped. Meant for plugins and hooks that detect compaction."),
+				mcp.WithString("project",
+					mcp.Description("Project name"),
+				),
+				mcp.WithString("session_id",
+					mcp.Description("Session ID (default: manual-save-{project})"),
+				),
+				mcp.WithString("trigger",
+					mcp.Description("What caused the compaction, e.g. auto or manual"),
+				),
+				mcp.WithString("note",
+					mcp.Description("Optional note saved with the marker"),
+				),
+			),
+			handleCompactionEvent(s, cfg, activity),
+		)
+	}
+
+	// ─── mem_verify (profile: agent, deferred) ───────────────────────────
+	if shouldRegister("mem_verify", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_verify",
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Verify Memory"),
+				mcp.WithReadOnlyHintAnnotation(false),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithDescription("Record whether a memory still matches the code. 'confirmed' stamps it as verified now; 'stale' flags it as outdated so search and context rank it below fresh memories until it is confirmed again or rewritten with mem_update."),
+				mcp.WithNumber("id",
+					mcp.Required(),
+					mcp.Description("Observation ID to verify"),
+				),
+				mcp.WithString("status",
+					mcp.Description("confirmed (default) or stale"),
+				),
+				mcp.WithString("evidence",
+					mcp.Description("What you checked, e.g. 'go.mod no longer requires gorm'"),
+				),
+				mcp.WithString("paths",
+					mcp.Description("Comma-separated file paths you looked at"),
+				),
+			),
+			handleVerify(s, cfg, activity),
+		)
+	}
+
+	// ─── mem_merge_projects (profile: admin, deferred) ──────────────────
+	if shouldRegister("mem_merge_projects", allowlist) {
+		srv.AddTool(
+			mcp.NewTool("mem_merge_projects",
+				mcp.WithDescription("Merge memories from multiple project name variants into one canonical name. Use when you discover project name drift (e.g. 'Engram' and 'engram' should be the same project). DESTRUCTIVE — moves all records from source names to the canonical name."),
+				mcp.WithDeferLoading(true),
+				mcp.WithTitleAnnotation("Merge Projects"),
+				mcp.WithReadOnlyHintAnnotation(false),
+				mcp.WithDestructiveHintAnnotation(true),
+				mcp.WithIdempotentHintAnnotation(true),
+				mcp.WithOpenWorldHintAnnotation(false),
+				mcp.WithString("from",
+					mcp.Required(),
+					mcp.Description("Comma-separated list of project names to merge FROM (e.g. 'Engram,engram-memory,ENGRAM')"),
+				),
+				mcp.WithString("to",
+					mcp.Required(),
+					mcp.Description("The canonical project name to merge INTO (e.g. 'engram')"),
+				),
+			),
+			handleMergeProjects(s),
+		)
+	}
+}
+
+// ─── Structured Output ───────────────────────────────────────────────────────
+
+// Read tools return structuredContent next to the human-readable text so
+// agents can consume results without re-parsing the formatted output. The
+// text block stays first and unchanged for clients that ignore structured
+// content.
+
+// searchOutput is the structured result of mem_search.
+type searchOutput struct {
+	Query   string      `json:"query"`
+	Ref     string      `json:"ref,omitempty"`
+	Count   int         `json:"count"`
+	Results []searchHit `json:"results"`
+	// NextCursor is set when more results exist; pass it back as cursor.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// searchHit mirrors one search result. Content is the same 300-char preview
+// shown in the text output; ContentTruncated tells agents to follow up with
+// mem_get_observation for the full body.
+type searchHit struct {
+	// Kind is "observation" or "prompt"; set only for target=all.
+	Kind             string   `json:"kind,omitempty"`
+	ID               int64    `json:"id"`
+	Type             string   `json:"type"`
+	Title            string   `json:"title"`
+	Content          string   `json:"content"`
+	ContentTruncated bool     `json:"content_truncated"`
+	Project          *string  `json:"project,omitempty"`
+	Scope            string   `json:"scope"`
+	TopicKey         *string  `json:"topic_key,omitempty"`
+	Refs             []string `json:"refs,omitempty"`
+	CreatedAt        string   `json:"created_at"`
+	VerifiedAt       *string  `json:"verified_at,omitempty"`
+	StaleAt          *string  `json:"stale_at,omitempty"`
+	Source           *string  `json:"source,omitempty"`
+	SessionID        string   `json:"session_id,omitempty"` // prompts only
+	Rank             float64  `json:"rank"`
+	// Explain says why the hit ranked here; set with explain=true.
+	Explain *store.SearchExplain `json:"explain,omitempty"`
+	// Metadata is the structured data saved with the memory.
+	Metadata store.Metadata `json:"metadata,omitempty"`
+}
+
+// explainLine renders a SearchExplain as one line of mem_search text.
+func explainLine(rank float64, e *store.SearchExplain) string {
+	matched := "no column (matched by filter)"
+	if len(e.Matched) > 0 {
+		matched = strings.Join(e.Matched, ", ")
+	}
+	parts := []string{"matched " + matched}
+	if !e.TopicKeyMatch {
+		parts = append(parts, fmt.Sprintf("rank %.2f", rank))
+	}
+	parts = append(parts, fmt.Sprintf("%dd old", e.AgeDays))
+	if e.DuplicateCount > 1 {
+		parts = append(parts, fmt.Sprintf("saved %dx", e.DuplicateCount))
+	}
+	parts = append(parts, e.Modifiers...)
+	return "    why: " + strings.Join(parts, " | ") + "\n"
+}
+
+// ─── Tool Handlers ───────────────────────────────────────────────────────────
+
+func handleSearch(s *store.Store, cfg MCPConfig, activity *SessionActivity) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		query, _ := req.GetArguments()["query"].(string)
 		typ, _ := req.GetArguments()["type"].(string)
 		project, _ := req.GetArguments()["project"].(string)
 		scope, _ := req.GetArguments()["scope"].(string)
+		ref, _ := req.GetArguments()["ref"].(string)
+		source, _ := req.GetArguments()["source"].(string)
+		inSession, _ := req.GetArguments()["session_id"].(string)
+		startedAfter, _ := req.GetArguments()["started_after"].(string)
+		startedBefore, _ := req.GetArguments()["started_before"].(string)
 		limit := intArg(req, "limit", 10)
 
 		// Apply default project when LLM sends empty
@@ -648,22 +1335,57 @@ func handleSearch(s *store.Store, cfg MCPConfig, activity *SessionActivity) serv
 		sessionID := defaultSessionID(project)
 		activity.RecordToolCall(sessionID)
 
-		results, err := s.Search(query, store.SearchOptions{
-			Type:    typ,
-			Project: project,
-			Scope:   scope,
-			Limit:   limit,
-		})
+		searchOpts := store.SearchOptions{
+			Type:          typ,
+			Project:       project,
+			Scope:         scope,
+			Limit:         limit,
+			Ref:           ref,
+			Source:        source,
+			SessionID:     inSession,
+			StartedAfter:  startedAfter,
+			StartedBefore: startedBefore,
+			Explain:       boolArg(req, "explain", false),
+			Metadata:      metadataFilterArg(req),
+		}
+		switch target, _ := req.GetArguments()["target"].(string); target {
+		case "", "observations":
+		case "all":
+			return searchAll(s, query, searchOpts, activity, sessionID), nil
+		default:
+			return errorResult(store.CodeValidation, fmt.Sprintf("Invalid target %q. Use observations or all.", target)), nil
+		}
+		if cursor, _ := req.GetArguments()["cursor"].(string); cursor != "" {
+			offset, err := decodeSearchCursor(cursor, query, searchOpts)
+			if err != nil {
+				return errorResult(store.CodeValidation, err.Error()), nil
+			}
+			searchOpts.Offset = offset
+		}
+		var page *store.SearchPage
+		var err error
+		if cfg.Translate != nil {
+			// Also search the query's translations so a note saved in
+			// Spanish answers an English query and vice versa.
+			page, err = cfg.Translate.SearchPage(ctx, query, searchOpts)
+		} else {
+			page, err = s.SearchPage(query, searchOpts)
+		}
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Search error: %s. Try simpler keywords.", err)), nil
+			return errorResult(store.ErrorCode(err), fmt.Sprintf("Search error: %s. Try simpler keywords.", err)), nil
 		}
+		results := page.Results
 
+		out := searchOutput{Query: query, Ref: ref, Count: len(results), Results: make([]searchHit, 0, len(results))}
 		if len(results) == 0 {
-			return mcp.NewToolResultText(fmt.Sprintf("No memories found for: %q", query)), nil
+			return mcp.NewToolResultStructured(out, i18n.Tf("No memories found for: %q", query)), nil
+		}
+		if page.HasMore {
+			out.NextCursor = encodeSearchCursor(page.Offset+len(results), query, searchOpts)
 		}
 
 		var b strings.Builder
-		fmt.Fprintf(&b, "Found %d memories:\n\n", len(results))
+		b.WriteString(i18n.Tf("Found %d memories:\n\n", len(results)))
 		anyTruncated := false
 		for i, r := range results {
 			projectDisplay := ""
@@ -671,24 +1393,212 @@ func handleSearch(s *store.Store, cfg MCPConfig, activity *SessionActivity) serv
 				projectDisplay = fmt.Sprintf(" | project: %s", *r.Project)
 			}
 			preview := truncate(r.Content, 300)
-			if len(r.Content) > 300 {
+			truncated := len(r.Content) > 300
+			out.Results = append(out.Results, searchHit{
+				ID:               r.ID,
+				Type:             r.Type,
+				Title:            r.Title,
+				Content:          preview,
+				ContentTruncated: truncated,
+				Project:          r.Project,
+				Scope:            r.Scope,
+				TopicKey:         r.TopicKey,
+				Refs:             r.Refs,
+				CreatedAt:        r.CreatedAt,
+				VerifiedAt:       r.VerifiedAt,
+				StaleAt:          r.StaleAt,
+				Source:           r.Source,
+				Rank:             r.Rank,
+				Explain:          r.Explain,
+				Metadata:         r.Metadata,
+			})
+			if truncated {
 				anyTruncated = true
 				preview += " [preview]"
 			}
-			fmt.Fprintf(&b, "[%d] #%d (%s) — %s\n    %s\n    %s%s | scope: %s\n\n",
-				i+1, r.ID, r.Type, r.Title,
+			refsDisplay := ""
+			if len(r.Refs) > 0 {
+				refsDisplay = " | refs: " + strings.Join(r.Refs, ", ")
+			}
+			if len(r.Metadata) > 0 {
+				refsDisplay += " | metadata: " + strings.Join(r.Metadata.Pairs(), ", ")
+			}
+			fmt.Fprintf(&b, "[%d] #%d (%s) — %s%s\n    %s\n    %s%s | scope: %s%s%s\n",
+				page.Offset+i+1, r.ID, r.Type, r.Title, staleMarker(r.Observation),
 				preview,
-				r.CreatedAt, projectDisplay, r.Scope)
+				r.CreatedAt, projectDisplay, r.Scope, refsDisplay, verifiedDisplay(r.Observation))
+			if r.Explain != nil {
+				b.WriteString(explainLine(r.Rank, r.Explain))
+			}
+			b.WriteString("\n")
 		}
 		if anyTruncated {
-			fmt.Fprintf(&b, "---\nResults above are previews (300 chars). To read the full content of a specific memory, call mem_get_observation(id: <ID>).\n")
+			b.WriteString("---\n" + i18n.T("Results above are previews (300 chars). To read the full content of a specific memory, call mem_get_observation(id: <ID>).") + "\n")
+		}
+		if out.NextCursor != "" {
+			b.WriteString("---\n" + i18n.Tf("More results exist. For the next page, call mem_search again with the same query and filters and cursor: %q.", out.NextCursor) + "\n")
 		}
 
 		if nudge := activity.NudgeIfNeeded(sessionID); nudge != "" {
 			b.WriteString(nudge)
 		}
 
-		return mcp.NewToolResultText(b.String()), nil
+		return mcp.NewToolResultStructured(out, b.String()), nil
+	}
+}
+
+// searchAll answers mem_search with target=all: observations and prompts
+// in one ranked list, each tagged with its kind.
+func searchAll(s *store.Store, query string, opts store.SearchOptions, activity *SessionActivity, sessionID string) *mcp.CallToolResult {
+	results, err := s.SearchAll(query, opts)
+	if err != nil {
+		return errorResult(store.ErrorCode(err), fmt.Sprintf("Search error: %s. Try simpler keywords.", err))
+	}
+
+	out := searchOutput{Query: query, Ref: opts.Ref, Count: len(results), Results: make([]searchHit, 0, len(results))}
+	if len(results) == 0 {
+		return mcp.NewToolResultStructured(out, i18n.Tf("No memories or prompts found for: %q", query))
+	}
+
+	var b strings.Builder
+	b.WriteString(i18n.Tf("Found %d memories and prompts:", len(results)) + "\n\n")
+	for i, r := range results {
+		if p := r.Prompt; p != nil {
+			preview := truncate(p.Content, 300)
+			hit := searchHit{Kind: r.Kind, ID: p.ID, Content: preview, ContentTruncated: len(p.Content) > 300, CreatedAt: p.CreatedAt, SessionID: p.SessionID, Rank: r.Rank}
+			if p.Project != "" {
+				hit.Project = &p.Project
+			}
+			out.Results = append(out.Results, hit)
+			fmt.Fprintf(&b, "[%d] prompt #%d\n    %s\n    %s | session: %s\n\n", i+1, p.ID, preview, p.CreatedAt, p.SessionID)
+			continue
+		}
+		o := r.Observation
+		preview := truncate(o.Content, 300)
+		out.Results = append(out.Results, searchHit{
+			Kind:             r.Kind,
+			ID:               o.ID,
+			Type:             o.Type,
+			Title:            o.Title,
+			Content:          preview,
+			ContentTruncated: len(o.Content) > 300,
+			Project:          o.Project,
+			Scope:            o.Scope,
+			TopicKey:         o.TopicKey,
+			Refs:             o.Refs,
+			CreatedAt:        o.CreatedAt,
+			VerifiedAt:       o.VerifiedAt,
+			StaleAt:          o.StaleAt,
+			Source:           o.Source,
+			Rank:             r.Rank,
+			Explain:          o.Explain,
+			Metadata:         o.Metadata,
+		})
+		fmt.Fprintf(&b, "[%d] observation #%d (%s) — %s%s\n    %s\n    %s | scope: %s\n",
+			i+1, o.ID, o.Type, o.Title, staleMarker(o.Observation), preview, o.CreatedAt, o.Scope)
+		if o.Explain != nil {
+			b.WriteString(explainLine(r.Rank, o.Explain))
+		}
+		b.WriteString("\n")
+	}
+	if nudge := activity.NudgeIfNeeded(sessionID); nudge != "" {
+		b.WriteString(nudge)
+	}
+	return mcp.NewToolResultStructured(out, b.String())
+}
+
+// Search cursors are opaque to agents: "v1:<offset>:<fingerprint>", base64
+// encoded. The fingerprint covers the query and filters, so a cursor replayed
+// against a different search is rejected instead of skipping into unrelated
+// results. The page size is left out; agents may change it between pages.
+const searchCursorVersion = "v1"
+
+func searchFingerprint(query string, opts store.SearchOptions) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		query, opts.Type, opts.Project, opts.Scope, opts.Ref, opts.Source, opts.SessionID, opts.StartedAfter, opts.StartedBefore,
+	}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodeSearchCursor(offset int, query string, opts store.SearchOptions) string {
+	raw := fmt.Sprintf("%s:%d:%s", searchCursorVersion, offset, searchFingerprint(query, opts))
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSearchCursor(cursor, query string, opts store.SearchOptions) (int, error) {
+	invalid := fmt.Errorf("Invalid cursor %q. Start again without a cursor.", cursor)
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, invalid
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || parts[0] != searchCursorVersion {
+		return 0, invalid
+	}
+	offset, err := strconv.Atoi(parts[1])
+	if err != nil || offset < 0 {
+		return 0, invalid
+	}
+	if parts[2] != searchFingerprint(query, opts) {
+		return 0, errors.New("Cursor belongs to a different search. Repeat the original query and filters, or start again without a cursor.")
+	}
+	return offset, nil
+}
+
+// forFileOutput is the structured result of mem_for_file.
+type forFileOutput struct {
+	Path    string      `json:"path"`
+	Count   int         `json:"count"`
+	Results []searchHit `json:"results"`
+}
+
+func handleForFile(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, _ := req.GetArguments()["path"].(string)
+		project, _ := req.GetArguments()["project"].(string)
+		scope, _ := req.GetArguments()["scope"].(string)
+		limit := intArg(req, "limit", 10)
+
+		path = store.NormalizeFilePath(path)
+		if path == "" {
+			return errorResult(store.CodeValidation, "path is required"), nil
+		}
+		if project == "" {
+			project = cfg.DefaultProject
+		}
+		project, _ = store.NormalizeProject(project)
+
+		results, err := s.Search("", store.SearchOptions{Project: project, Scope: scope, Limit: limit, File: path})
+		if err != nil {
+			return storeErrorResult("Failed to recall file memories: ", err), nil
+		}
+
+		out := forFileOutput{Path: path, Count: len(results), Results: make([]searchHit, 0, len(results))}
+		if len(results) == 0 {
+			return mcp.NewToolResultStructured(out, i18n.Tf("No memories mention %s.", path)), nil
+		}
+
+		var b strings.Builder
+		b.WriteString(i18n.Tf("Found %d memories mentioning %s:\n\n", len(results), path))
+		for i, r := range results {
+			preview := truncate(r.Content, 300)
+			out.Results = append(out.Results, searchHit{
+				ID:               r.ID,
+				Type:             r.Type,
+				Title:            r.Title,
+				Content:          preview,
+				ContentTruncated: len(r.Content) > 300,
+				Project:          r.Project,
+				Scope:            r.Scope,
+				TopicKey:         r.TopicKey,
+				Refs:             r.Refs,
+				CreatedAt:        r.CreatedAt,
+			})
+			fmt.Fprintf(&b, "[%d] #%d (%s) — %s\n    %s\n    %s | scope: %s\n\n",
+				i+1, r.ID, r.Type, r.Title, preview, r.UpdatedAt, r.Scope)
+		}
+		fmt.Fprintf(&b, "---\nCall mem_get_observation(id: <ID>) for the full content of a memory.\n")
+		return mcp.NewToolResultStructured(out, b.String()), nil
 	}
 }
 
@@ -701,6 +1611,8 @@ func handleSave(s *store.Store, cfg MCPConfig, activity *SessionActivity) server
 		project, _ := req.GetArguments()["project"].(string)
 		scope, _ := req.GetArguments()["scope"].(string)
 		topicKey, _ := req.GetArguments()["topic_key"].(string)
+		refsArg, _ := req.GetArguments()["refs"].(string)
+		metadata, _ := req.GetArguments()["metadata"].(map[string]any)
 
 		// Apply default project when LLM sends empty
 		if project == "" {
@@ -710,8 +1622,10 @@ func handleSave(s *store.Store, cfg MCPConfig, activity *SessionActivity) server
 		normalized, normWarning := store.NormalizeProject(project)
 		project = normalized
 
+		typeInferred := false
 		if typ == "" {
-			typ = "manual"
+			typ = store.InferObservationType(title, content)
+			typeInferred = true
 		}
 		if sessionID == "" {
 			sessionID = defaultSessionID(project)
@@ -753,16 +1667,27 @@ func handleSave(s *store.Store, cfg MCPConfig, activity *SessionActivity) server
 			Project:   project,
 			Scope:     scope,
 			TopicKey:  topicKey,
+			Refs:      strings.Split(refsArg, ","),
+			Metadata:  metadata,
+			Source:    clientSource(ctx),
 		})
 		if err != nil {
-			return mcp.NewToolResultError("Failed to save: " + err.Error()), nil
+			return storeErrorResult("Failed to save: ", err), nil
 		}
 
 		activity.RecordSave(defaultSessionID(project))
 
-		msg := fmt.Sprintf("Memory saved: %q (%s)", title, typ)
+		msg := i18n.Tf("Memory saved: %q (%s)", title, typ)
+		if typeInferred {
+			if typ == "manual" {
+				msg += "\nNo type given and none could be inferred; saved as manual."
+			} else {
+				msg += i18n.Tf("\nType inferred: %s (no type given).", typ)
+			}
+			msg += " Pass type explicitly next time: " + strings.Join(observationTypes, ", ")
+		}
 		if topicKey == "" && suggestedTopicKey != "" {
-			msg += fmt.Sprintf("\nSuggested topic_key: %s", suggestedTopicKey)
+			msg += i18n.Tf("\nSuggested topic_key: %s", suggestedTopicKey)
 		}
 		if truncated {
 			msg += fmt.Sprintf("\n⚠ WARNING: Content was truncated from %d to %d chars. Consider splitting into smaller observations.", len(content), s.MaxObservationLength())
@@ -777,22 +1702,243 @@ func handleSave(s *store.Store, cfg MCPConfig, activity *SessionActivity) server
 	}
 }
 
-func handleSuggestTopicKey() server.ToolHandlerFunc {
+func handleTopics(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		project, _ := req.GetArguments()["project"].(string)
+		scope, _ := req.GetArguments()["scope"].(string)
+
+		if project == "" {
+			project = cfg.DefaultProject
+		}
+		project, _ = store.NormalizeProject(project)
+
+		topics, err := s.Topics(project, scope)
+		if err != nil {
+			return storeErrorResult("Failed to list topics: ", err), nil
+		}
+		out := map[string]any{"project": project, "count": len(topics), "topics": topics}
+		if len(topics) == 0 {
+			out["topics"] = []store.TopicSummary{}
+			return mcp.NewToolResultStructured(out, "No topic keys in use yet. Use mem_suggest_topic_key to pick one."), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Found %d topics:\n\n", len(topics))
+		for _, t := range topics {
+			projectDisplay := ""
+			if t.Project != nil {
+				projectDisplay = " | project: " + *t.Project
+			}
+			fmt.Fprintf(&b, "%s (%s%s)\n    latest: #%d [%s] %s | revisions: %d | updated: %s\n",
+				t.TopicKey, t.Scope, projectDisplay,
+				t.LatestID, t.LatestType, t.LatestTitle, t.RevisionCount, t.UpdatedAt)
+		}
+		return mcp.NewToolResultStructured(out, b.String()), nil
+	}
+}
+
+// templateHit is one template in mem_templates output, with its rendered
+// skeleton and the topic key to save under.
+type templateHit struct {
+	store.Template
+	Skeleton string `json:"skeleton"`
+	TopicKey string `json:"topic_key,omitempty"`
+}
+
+func handleTemplates(s *store.Store) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, _ := req.GetArguments()["name"].(string)
+		title, _ := req.GetArguments()["title"].(string)
+
+		templates := s.Templates()
+		if strings.TrimSpace(name) != "" {
+			t, err := s.Template(name)
+			if err != nil {
+				return storeErrorResult("", err), nil
+			}
+			templates = []store.Template{t}
+		}
+
+		hits := make([]templateHit, 0, len(templates))
+		var b strings.Builder
+		fmt.Fprintf(&b, "Found %d templates:\n\n", len(templates))
+		for _, t := range templates {
+			hit := templateHit{Template: t, Skeleton: t.Render(""), TopicKey: t.TopicKey(cmp.Or(title, "<title>"))}
+			hits = append(hits, hit)
+			fmt.Fprintf(&b, "%s — %s\n    type: %s", t.Name, t.Description, t.Type)
+			if hit.TopicKey != "" {
+				fmt.Fprintf(&b, " | topic_key: %s", hit.TopicKey)
+			}
+			fmt.Fprintf(&b, "\n    sections: %s\n", strings.Join(t.Sections, ", "))
+			if len(templates) == 1 {
+				fmt.Fprintf(&b, "\nSkeleton:\n%s\n", hit.Skeleton)
+			}
+		}
+		b.WriteString("\nFill in the sections and call mem_save with the template's type and topic_key.")
+		return mcp.NewToolResultStructured(map[string]any{"count": len(hits), "templates": hits}, b.String()), nil
+	}
+}
+
+// quotaReport is mem_quota output: a project's usage, what its quota
+// leaves, and a status agents can branch on.
+type quotaReport struct {
+	*store.QuotaUsage
+	Status                string  `json:"status"` // unlimited, ok, near_limit, or full
+	Ratio                 float64 `json:"ratio"`
+	WarnRatio             float64 `json:"warn_ratio"`
+	RemainingObservations *int    `json:"remaining_observations,omitempty"`
+	RemainingBytes        *int64  `json:"remaining_bytes,omitempty"`
+}
+
+func handleQuota(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		project, _ := req.GetArguments()["project"].(string)
+		if project == "" {
+			project = cfg.DefaultProject
+		}
+
+		usage, err := s.QuotaUsage(project)
+		if err != nil {
+			return storeErrorResult("Failed to read quota: ", err), nil
+		}
+		report := quotaReport{QuotaUsage: usage, Status: "unlimited", Ratio: usage.Ratio(), WarnRatio: store.QuotaWarnRatio}
+		name := cmp.Or(usage.Project, "(no project)")
+		if usage.Quota == (store.Quota{}) {
+			text := fmt.Sprintf("%s has no quota: %d observations, %s. Save freely.", name, usage.Observations, store.FormatBytes(usage.Bytes))
+			return mcp.NewToolResultStructured(report, text), nil
+		}
+
+		var limits []string
+		if q := usage.Quota.MaxObservations; q > 0 {
+			left := max(q-usage.Observations, 0)
+			report.RemainingObservations = &left
+			limits = append(limits, fmt.Sprintf("%d/%d observations (%d left)", usage.Observations, q, left))
+		}
+		if q := usage.Quota.MaxBytes; q > 0 {
+			left := max(q-usage.Bytes, 0)
+			report.RemainingBytes = &left
+			limits = append(limits, fmt.Sprintf("%s/%s (%s left)", store.FormatBytes(usage.Bytes), store.FormatBytes(q), store.FormatBytes(left)))
+		}
+		switch {
+		case report.Ratio >= 1:
+			report.Status = "full"
+		case usage.NearLimit:
+			report.Status = "near_limit"
+		default:
+			report.Status = "ok"
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Quota for %s: %s — %.0f%% used, status %s.", name, strings.Join(limits, ", "), report.Ratio*100, report.Status)
+		switch report.Status {
+		case "full":
+			b.WriteString("\nNew saves will be refused. Update existing memories with topic_key, or ask the user to prune or raise the quota.")
+		case "near_limit":
+			b.WriteString("\nSkip low-value saves (routine tool output, passive captures) and prefer updating existing memories with topic_key.")
+		}
+		if report.Status != "ok" && usage.LargestType != "" {
+			fmt.Fprintf(&b, "\nLargest type: %s (%d observations, %s).", usage.LargestType, usage.LargestTypeCount, store.FormatBytes(usage.LargestTypeBytes))
+		}
+		return mcp.NewToolResultStructured(report, b.String()), nil
+	}
+}
+
+func handleTopicSearch(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query, _ := req.GetArguments()["query"].(string)
+		project, _ := req.GetArguments()["project"].(string)
+		scope, _ := req.GetArguments()["scope"].(string)
+		limit := intArg(req, "limit", 5)
+
+		if strings.TrimSpace(query) == "" {
+			return errorResult(store.CodeValidation, "query is required"), nil
+		}
+		if project == "" {
+			project = cfg.DefaultProject
+		}
+		project, _ = store.NormalizeProject(project)
+
+		matches, err := s.SuggestTopics(query, project, scope, limit)
+		if err != nil {
+			return storeErrorResult("Failed to search topics: ", err), nil
+		}
+		out := map[string]any{"query": query, "project": project, "count": len(matches), "matches": matches}
+		if len(matches) == 0 {
+			out["matches"] = []store.TopicMatch{}
+			return mcp.NewToolResultStructured(out, i18n.Tf("No existing topic key resembles %q. Use mem_suggest_topic_key to pick a new one.", query)), nil
+		}
+
+		var b strings.Builder
+		b.WriteString(i18n.Tf("Found %d matching topic keys (reuse one to update that memory):", len(matches)) + "\n\n")
+		for _, m := range matches {
+			projectDisplay := ""
+			if m.Project != nil {
+				projectDisplay = " | project: " + *m.Project
+			}
+			fmt.Fprintf(&b, "%s (%s%s) — %s %.2f\n    latest: #%d [%s] %s\n",
+				m.TopicKey, m.Scope, projectDisplay, m.MatchType, m.Score,
+				m.LatestID, m.LatestType, m.LatestTitle)
+		}
+		return mcp.NewToolResultStructured(out, b.String()), nil
+	}
+}
+
+// topicKeyAlternatives caps the existing keys mem_suggest_topic_key lists.
+const topicKeyAlternatives = 5
+
+func handleSuggestTopicKey(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		typ, _ := req.GetArguments()["type"].(string)
 		title, _ := req.GetArguments()["title"].(string)
 		content, _ := req.GetArguments()["content"].(string)
+		project, _ := req.GetArguments()["project"].(string)
+		scope, _ := req.GetArguments()["scope"].(string)
 
 		if strings.TrimSpace(title) == "" && strings.TrimSpace(content) == "" {
-			return mcp.NewToolResultError("provide title or content to suggest a topic_key"), nil
+			return errorResult(store.CodeValidation, "provide title or content to suggest a topic_key"), nil
 		}
 
 		topicKey := suggestTopicKey(typ, title, content)
 		if topicKey == "" {
-			return mcp.NewToolResultError("could not suggest topic_key from input"), nil
+			return errorResult(store.CodeValidation, "could not suggest topic_key from input"), nil
+		}
+		if project == "" {
+			project = cfg.DefaultProject
+		}
+		project, _ = store.NormalizeProject(project)
+		if scope == "" {
+			scope = "project"
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Suggested topic_key: %s", topicKey)), nil
+		// Check the generated key against the keys already in use, so a
+		// near-duplicate updates the existing memory instead of forking it.
+		alternatives, err := s.SuggestTopics(topicKey, project, scope, topicKeyAlternatives)
+		if err != nil {
+			return storeErrorResult("Failed to check existing topics: ", err), nil
+		}
+		if alternatives == nil {
+			alternatives = []store.TopicMatch{}
+		}
+		out := map[string]any{"topic_key": topicKey, "generated": topicKey, "existing": false, "alternatives": alternatives}
+
+		var b strings.Builder
+		if match, ok := store.PreferExistingTopic(project, alternatives); ok {
+			out["topic_key"], out["existing"] = match.TopicKey, true
+			b.WriteString(i18n.Tf("Suggested topic_key: %s", match.TopicKey) + "\n")
+			b.WriteString(i18n.Tf("Existing topic, latest #%d %q; saving with it updates that memory.", match.LatestID, match.LatestTitle) + "\n")
+			if match.TopicKey != topicKey {
+				b.WriteString(i18n.Tf("A new key would have been %s.", topicKey) + "\n")
+			}
+		} else {
+			b.WriteString(i18n.Tf("Suggested topic_key: %s", topicKey) + "\n")
+		}
+		if len(alternatives) > 0 {
+			b.WriteString("\n" + i18n.T("Closest existing topic keys:") + "\n")
+			for _, m := range alternatives {
+				fmt.Fprintf(&b, "- %s — %s %.2f, latest #%d %s\n", m.TopicKey, m.MatchType, m.Score, m.LatestID, m.LatestTitle)
+			}
+		}
+		return mcp.NewToolResultStructured(out, b.String()), nil
 	}
 }
 
@@ -800,7 +1946,7 @@ func handleUpdate(s *store.Store) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		id := int64(intArg(req, "id", 0))
 		if id == 0 {
-			return mcp.NewToolResultError("id is required"), nil
+			return errorResult(store.CodeValidation, "id is required"), nil
 		}
 
 		update := store.UpdateObservationParams{}
@@ -822,9 +1968,13 @@ func handleUpdate(s *store.Store) server.ToolHandlerFunc {
 		if v, ok := req.GetArguments()["topic_key"].(string); ok {
 			update.TopicKey = &v
 		}
+		if v, ok := req.GetArguments()["metadata"].(map[string]any); ok {
+			meta := store.Metadata(v)
+			update.Metadata = &meta
+		}
 
-		if update.Title == nil && update.Content == nil && update.Type == nil && update.Project == nil && update.Scope == nil && update.TopicKey == nil {
-			return mcp.NewToolResultError("provide at least one field to update"), nil
+		if update.Title == nil && update.Content == nil && update.Type == nil && update.Project == nil && update.Scope == nil && update.TopicKey == nil && update.Metadata == nil {
+			return errorResult(store.CodeValidation, "provide at least one field to update"), nil
 		}
 
 		var contentLen int
@@ -834,10 +1984,10 @@ func handleUpdate(s *store.Store) server.ToolHandlerFunc {
 
 		obs, err := s.UpdateObservation(id, update)
 		if err != nil {
-			return mcp.NewToolResultError("Failed to update memory: " + err.Error()), nil
+			return storeErrorResult("Failed to update memory: ", err), nil
 		}
 
-		msg := fmt.Sprintf("Memory updated: #%d %q (%s, scope=%s)", obs.ID, obs.Title, obs.Type, obs.Scope)
+		msg := i18n.Tf("Memory updated: #%d %q (%s, scope=%s)", obs.ID, obs.Title, obs.Type, obs.Scope)
 		if contentLen > s.MaxObservationLength() {
 			msg += fmt.Sprintf("\n⚠ WARNING: Content was truncated from %d to %d chars. Consider splitting into smaller observations.", contentLen, s.MaxObservationLength())
 		}
@@ -848,53 +1998,164 @@ func handleUpdate(s *store.Store) server.ToolHandlerFunc {
 func handleDelete(s *store.Store) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		id := int64(intArg(req, "id", 0))
+		topicKey, _ := req.GetArguments()["topic_key"].(string)
+		sessionID, _ := req.GetArguments()["session_id"].(string)
+		query, _ := req.GetArguments()["query"].(string)
+
+		bulk := topicKey != "" || sessionID != "" || query != ""
+		if id != 0 && bulk {
+			return errorResult(store.CodeValidation, "pass either id or a bulk filter (topic_key, session_id, query), not both"), nil
+		}
+		if bulk {
+			return handleBulkDelete(s, req, store.BulkDeleteFilter{TopicKey: topicKey, SessionID: sessionID, Query: query})
+		}
 		if id == 0 {
-			return mcp.NewToolResultError("id is required"), nil
+			return errorResult(store.CodeValidation, "id, topic_key, session_id, or query is required"), nil
 		}
 
 		hardDelete := boolArg(req, "hard_delete", false)
 		if err := s.DeleteObservation(id, hardDelete); err != nil {
-			return mcp.NewToolResultError("Failed to delete memory: " + err.Error()), nil
+			return storeErrorResult("Failed to delete memory: ", err), nil
+		}
+
+		msg := i18n.Tf("Memory #%d soft-deleted", id)
+		if hardDelete {
+			msg = i18n.Tf("Memory #%d permanently deleted", id)
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+// handleBulkDelete soft-deletes every match of f. Without confirm=true it
+// only reports what would be deleted.
+func handleBulkDelete(s *store.Store, req mcp.CallToolRequest, f store.BulkDeleteFilter) (*mcp.CallToolResult, error) {
+	if boolArg(req, "hard_delete", false) {
+		return errorResult(store.CodeValidation, "hard_delete is only supported for a single id; bulk deletes are soft"), nil
+	}
+	f.Project, _ = req.GetArguments()["project"].(string)
+	f.Scope, _ = req.GetArguments()["scope"].(string)
+	f.Type, _ = req.GetArguments()["type"].(string)
+	confirm := boolArg(req, "confirm", false)
+
+	result, err := s.DeleteObservations(f, !confirm)
+	if err != nil {
+		return storeErrorResult("Failed to delete memories: ", err), nil
+	}
+	if result.Matched == 0 {
+		return mcp.NewToolResultStructured(result, "No memories match that filter; nothing deleted."), nil
+	}
+
+	var b strings.Builder
+	if result.DryRun {
+		b.WriteString(i18n.Tf("Dry run: %d memories match and would be soft-deleted:\n", result.Matched))
+	} else {
+		b.WriteString(i18n.Tf("Soft-deleted %d memories:\n", result.Deleted))
+	}
+	for _, o := range result.Sample {
+		fmt.Fprintf(&b, "  #%d [%s] %s\n", o.ID, o.Type, o.Title)
+	}
+	if more := result.Matched - len(result.Sample); more > 0 {
+		b.WriteString(i18n.Tf("  ... and %d more\n", more))
+	}
+	if result.DryRun {
+		b.WriteString(i18n.T("Call mem_delete again with the same filter and confirm=true to delete them."))
+	}
+	return mcp.NewToolResultStructured(result, b.String()), nil
+}
+
+func handleSavePrompt(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		content, _ := req.GetArguments()["content"].(string)
+		sessionID, _ := req.GetArguments()["session_id"].(string)
+		project, _ := req.GetArguments()["project"].(string)
+
+		// Apply default project when LLM sends empty
+		if project == "" {
+			project = cfg.DefaultProject
+		}
+		project, _ = store.NormalizeProject(project)
+
+		if sessionID == "" {
+			sessionID = defaultSessionID(project)
+		}
+
+		// Ensure the session exists
+		s.CreateSession(sessionID, project, "")
+
+		_, err := s.AddPrompt(store.AddPromptParams{
+			SessionID: sessionID,
+			Content:   content,
+			Project:   project,
+		})
+		if err != nil {
+			return storeErrorResult("Failed to save prompt: ", err), nil
 		}
 
-		mode := "soft-deleted"
-		if hardDelete {
-			mode = "permanently deleted"
-		}
-		return mcp.NewToolResultText(fmt.Sprintf("Memory #%d %s", id, mode)), nil
+		return mcp.NewToolResultText(i18n.Tf("Prompt saved: %q", truncate(content, 80))), nil
 	}
 }
 
-func handleSavePrompt(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
+// maxPromptResults caps mem_search_prompts and mem_recent_prompts.
+const maxPromptResults = 50
+
+func handleSearchPrompts(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		content, _ := req.GetArguments()["content"].(string)
-		sessionID, _ := req.GetArguments()["session_id"].(string)
+		query, _ := req.GetArguments()["query"].(string)
 		project, _ := req.GetArguments()["project"].(string)
+		limit := min(intArg(req, "limit", 10), maxPromptResults)
 
-		// Apply default project when LLM sends empty
 		if project == "" {
 			project = cfg.DefaultProject
 		}
 		project, _ = store.NormalizeProject(project)
 
-		if sessionID == "" {
-			sessionID = defaultSessionID(project)
+		prompts, err := s.SearchPrompts(query, project, limit)
+		if err != nil {
+			return errorResult(store.ErrorCode(err), fmt.Sprintf("Prompt search error: %s. Try simpler keywords.", err)), nil
 		}
+		if len(prompts) == 0 {
+			return mcp.NewToolResultText(i18n.Tf("No prompts found for: %q", query)), nil
+		}
+		return mcp.NewToolResultText(formatPrompts(prompts)), nil
+	}
+}
 
-		// Ensure the session exists
-		s.CreateSession(sessionID, project, "")
+func handleRecentPrompts(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		project, _ := req.GetArguments()["project"].(string)
+		limit := min(intArg(req, "limit", 20), maxPromptResults)
 
-		_, err := s.AddPrompt(store.AddPromptParams{
-			SessionID: sessionID,
-			Content:   content,
-			Project:   project,
-		})
+		if project == "" {
+			project = cfg.DefaultProject
+		}
+		project, _ = store.NormalizeProject(project)
+
+		prompts, err := s.RecentPrompts(project, limit)
 		if err != nil {
-			return mcp.NewToolResultError("Failed to save prompt: " + err.Error()), nil
+			return storeErrorResult("Failed to get recent prompts: ", err), nil
+		}
+		if len(prompts) == 0 {
+			return mcp.NewToolResultText(i18n.T("No prompts saved yet.")), nil
 		}
+		return mcp.NewToolResultText(formatPrompts(prompts)), nil
+	}
+}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Prompt saved: %q", truncate(content, 80))), nil
+// formatPrompts renders prompts verbatim (up to 1000 chars each) — the point
+// of these tools is the user's exact wording, so they are not previewed as
+// aggressively as observations.
+func formatPrompts(prompts []store.Prompt) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d prompts:\n\n", len(prompts))
+	for i, p := range prompts {
+		projectDisplay := ""
+		if p.Project != "" {
+			projectDisplay = " | project: " + p.Project
+		}
+		fmt.Fprintf(&b, "[%d] #%d — %s | session: %s%s\n    %s\n\n",
+			i+1, p.ID, p.CreatedAt, p.SessionID, projectDisplay, truncate(p.Content, 1000))
 	}
+	return b.String()
 }
 
 func handleContext(s *store.Store, cfg MCPConfig, activity *SessionActivity) server.ToolHandlerFunc {
@@ -911,13 +2172,34 @@ func handleContext(s *store.Store, cfg MCPConfig, activity *SessionActivity) ser
 		sessionID := defaultSessionID(project)
 		activity.RecordToolCall(sessionID)
 
-		context, err := s.FormatContext(project, scope)
+		opts := store.ContextOptions{
+			IncludeParents:      boolArg(req, "include_parents", false),
+			IncludeAll:          boolArg(req, "include_all", false),
+			ResurfaceCompaction: true,
+			ReadOnly:            cfg.ReadOnly,
+		}
+		if boolArg(req, "estimate", false) {
+			// Measured untranslated: translating only to count would cost
+			// the LLM calls the estimate is meant to save.
+			estimate, err := s.EstimateContext(project, scope, opts)
+			if err != nil {
+				return storeErrorResult("Failed to estimate context: ", err), nil
+			}
+			return mcp.NewToolResultStructured(estimate, formatContextEstimate(estimate)), nil
+		}
+		if cfg.Translate != nil {
+			lang, _ := req.GetArguments()["lang"].(string)
+			if lang = cfg.Translate.Lang(lang); lang != "" {
+				opts.Translate = cfg.Translate.Func(ctx, lang)
+			}
+		}
+		context, err := s.FormatContextWith(project, scope, opts)
 		if err != nil {
-			return mcp.NewToolResultError("Failed to get context: " + err.Error()), nil
+			return storeErrorResult("Failed to get context: ", err), nil
 		}
 
 		if context == "" {
-			return mcp.NewToolResultText("No previous session memories found."), nil
+			return mcp.NewToolResultText(i18n.T("No previous session memories found.")), nil
 		}
 
 		stats, _ := s.Stats()
@@ -939,24 +2221,129 @@ func handleContext(s *store.Store, cfg MCPConfig, activity *SessionActivity) ser
 	}
 }
 
+// formatContextEstimate renders a context estimate as one line per section.
+func formatContextEstimate(estimate *store.ContextEstimate) string {
+	if len(estimate.Sections) == 0 {
+		return i18n.T("No previous session memories found.")
+	}
+	var b strings.Builder
+	b.WriteString(i18n.Tf("Context estimate: %d chars, ~%d tokens", estimate.Chars, estimate.Tokens))
+	b.WriteString("\n")
+	for _, section := range estimate.Sections {
+		b.WriteString(i18n.Tf("- %s: %d items, %d chars, ~%d tokens", section.Title, section.Items, section.Chars, section.Tokens))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func handleContextOutline(s *store.Store, cfg MCPConfig, activity *SessionActivity) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		project, _ := req.GetArguments()["project"].(string)
+		scope, _ := req.GetArguments()["scope"].(string)
+
+		if project == "" {
+			project = cfg.DefaultProject
+		}
+		project, _ = store.NormalizeProject(project)
+		activity.RecordToolCall(defaultSessionID(project))
+
+		outline, err := s.ContextOutline(project, scope)
+		if err != nil {
+			return storeErrorResult("Failed to get outline: ", err), nil
+		}
+		if len(outline.Sections) == 0 {
+			return mcp.NewToolResultStructured(outline, "No previous session memories found."), nil
+		}
+		text := outline.Markdown() + "---\nExpand a heading with mem_context_section(id: <id>).\n"
+		return mcp.NewToolResultStructured(outline, text), nil
+	}
+}
+
+func handleContextSection(s *store.Store, cfg MCPConfig, activity *SessionActivity) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, _ := req.GetArguments()["id"].(string)
+		project, _ := req.GetArguments()["project"].(string)
+		scope, _ := req.GetArguments()["scope"].(string)
+
+		if strings.TrimSpace(id) == "" {
+			return errorResult(store.CodeValidation, "id is required; get one from mem_context_outline"), nil
+		}
+		if project == "" {
+			project = cfg.DefaultProject
+		}
+		project, _ = store.NormalizeProject(project)
+		activity.RecordToolCall(defaultSessionID(project))
+
+		section, err := s.ContextSection(project, scope, id)
+		if err != nil {
+			return storeErrorResult("Failed to expand section: ", err), nil
+		}
+		if section == "" {
+			return mcp.NewToolResultText(i18n.Tf("Section %q is empty.", id)), nil
+		}
+		return mcp.NewToolResultText(section), nil
+	}
+}
+
 func handleStats(s *store.Store) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		stats, err := loadMCPStats(s)
 		if err != nil {
-			return mcp.NewToolResultError("Failed to get stats: " + err.Error()), nil
+			return storeErrorResult("Failed to get stats: ", err), nil
 		}
 
 		var projects string
 		if len(stats.Projects) > 0 {
 			projects = strings.Join(stats.Projects, ", ")
 		} else {
-			projects = "none yet"
+			projects = i18n.T("none yet")
 		}
 
-		result := fmt.Sprintf("Memory System Stats:\n- Sessions: %d\n- Observations: %d\n- Prompts: %d\n- Projects: %s",
+		result := i18n.Tf("Memory System Stats:\n- Sessions: %d\n- Observations: %d\n- Prompts: %d\n- Projects: %s",
 			stats.TotalSessions, stats.TotalObservations, stats.TotalPrompts, projects)
 
-		return mcp.NewToolResultText(result), nil
+		if len(stats.ObservationsByType) > 0 {
+			types := make([]string, 0, len(stats.ObservationsByType))
+			for typ := range stats.ObservationsByType {
+				types = append(types, typ)
+			}
+			sort.Strings(types)
+			parts := make([]string, len(types))
+			for i, typ := range types {
+				parts[i] = fmt.Sprintf("%s=%d", typ, stats.ObservationsByType[typ])
+			}
+			result += "\n- By type: " + strings.Join(parts, ", ")
+		}
+		if stats.OldestObservationAt != nil && stats.NewestObservationAt != nil {
+			result += i18n.Tf("\n- Oldest observation: %s\n- Newest observation: %s", *stats.OldestObservationAt, *stats.NewestObservationAt)
+		}
+		result += i18n.Tf("\n- Duplicates avoided: %d", stats.DuplicatesAvoided)
+		result += fmt.Sprintf("\n- Storage: db %s, wal %s, fts index %s",
+			store.FormatBytes(stats.DBSizeBytes), store.FormatBytes(stats.WALSizeBytes), store.FormatBytes(stats.FTSSizeBytes))
+		if c := stats.Compression; c.Rows > 0 {
+			result += fmt.Sprintf("\n- Compressed content: %d observations, %s saved", c.Rows, store.FormatBytes(c.SavedBytes))
+		}
+		result += fmt.Sprintf("\n- Context cache: %d hits, %d misses, %d invalidated, %d cached",
+			stats.Cache.Hits, stats.Cache.Misses, stats.Cache.Invalidations, stats.Cache.Entries)
+		if stats.FTS.Fallbacks > 0 || stats.FTS.Failures > 0 {
+			result += fmt.Sprintf("\n- Search syntax: %d queries rescued by fallback, %d failed",
+				stats.FTS.Fallbacks, stats.FTS.Failures)
+		}
+		for _, u := range stats.Quotas {
+			var limits []string
+			if u.Quota.MaxObservations > 0 {
+				limits = append(limits, fmt.Sprintf("%d/%d observations", u.Observations, u.Quota.MaxObservations))
+			}
+			if u.Quota.MaxBytes > 0 {
+				limits = append(limits, store.FormatBytes(u.Bytes)+"/"+store.FormatBytes(u.Quota.MaxBytes))
+			}
+			result += fmt.Sprintf("\n- Quota %s: %s (%.0f%%)", u.Project, strings.Join(limits, ", "), u.Ratio()*100)
+			if u.NearLimit && u.LargestType != "" {
+				result += fmt.Sprintf(" — near the limit; %d %s observations take %s, prune old ones", u.LargestTypeCount, u.LargestType, store.FormatBytes(u.LargestTypeBytes))
+			}
+		}
+
+		return mcp.NewToolResultStructured(stats, result), nil
 	}
 }
 
@@ -964,14 +2351,14 @@ func handleTimeline(s *store.Store) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		observationID := int64(intArg(req, "observation_id", 0))
 		if observationID == 0 {
-			return mcp.NewToolResultError("observation_id is required"), nil
+			return errorResult(store.CodeValidation, "observation_id is required"), nil
 		}
 		before := intArg(req, "before", 5)
 		after := intArg(req, "after", 5)
 
 		result, err := s.Timeline(observationID, before, after)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Timeline error: %s", err)), nil
+			return errorResult(store.ErrorCode(err), fmt.Sprintf("Timeline error: %s", err)), nil
 		}
 
 		var b strings.Builder
@@ -983,12 +2370,15 @@ func handleTimeline(s *store.Store) server.ToolHandlerFunc {
 				summary = fmt.Sprintf(" — %s", truncate(*result.SessionInfo.Summary, 100))
 			}
 			fmt.Fprintf(&b, "Session: %s (%s)%s\n", result.SessionInfo.Project, result.SessionInfo.StartedAt, summary)
+			if len(result.SessionChain) > 0 {
+				b.WriteString(i18n.Tf("Continues: %s", store.FormatSessionChain(result.SessionChain)) + "\n")
+			}
 			fmt.Fprintf(&b, "Total observations in session: %d\n\n", result.TotalInRange)
 		}
 
 		// Before entries
 		if len(result.Before) > 0 {
-			b.WriteString("─── Before ───\n")
+			b.WriteString("─── " + i18n.T("Before") + " ───\n")
 			for _, e := range result.Before {
 				fmt.Fprintf(&b, "  #%d [%s] %s — %s\n", e.ID, e.Type, e.Title, truncate(e.Content, 150))
 			}
@@ -1002,52 +2392,92 @@ func handleTimeline(s *store.Store) server.ToolHandlerFunc {
 
 		// After entries
 		if len(result.After) > 0 {
-			b.WriteString("─── After ───\n")
+			b.WriteString("─── " + i18n.T("After") + " ───\n")
 			for _, e := range result.After {
 				fmt.Fprintf(&b, "  #%d [%s] %s — %s\n", e.ID, e.Type, e.Title, truncate(e.Content, 150))
 			}
 		}
 
-		return mcp.NewToolResultText(b.String()), nil
+		if len(result.ToolRuns) > 0 {
+			b.WriteString("\n─── " + i18n.T("Tool runs") + " ───\n")
+			for _, r := range result.ToolRuns {
+				fmt.Fprintf(&b, "  %s %s\n", r.CreatedAt, r.Headline())
+			}
+		}
+
+		return mcp.NewToolResultStructured(result, b.String()), nil
 	}
 }
 
-func handleGetObservation(s *store.Store) server.ToolHandlerFunc {
+func handleGetObservation(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		id := int64(intArg(req, "id", 0))
 		if id == 0 {
-			return mcp.NewToolResultError("id is required"), nil
+			return errorResult(store.CodeValidation, "id is required"), nil
 		}
 
-		obs, err := s.GetObservation(id)
+		// Content saved in several parts comes back whole, from any part.
+		obs, parts, err := s.GetFullObservation(id)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Observation #%d not found", id)), nil
+			return errorResult(store.CodeNotFound, i18n.Tf("Observation #%d not found", id)), nil
+		}
+
+		translated := ""
+		if cfg.Translate != nil {
+			lang, _ := req.GetArguments()["lang"].(string)
+			if lang = cfg.Translate.Lang(lang); lang != "" {
+				if t, err := cfg.Translate.Observation(ctx, *obs, lang); err != nil {
+					translated = i18n.Tf("\nTranslation to %s failed, showing the original: %s", lang, err)
+				} else {
+					*obs = t
+					translated = i18n.Tf("\nTranslated to: %s (lang=original for the stored text)", lang)
+				}
+			}
 		}
 
 		project := ""
 		if obs.Project != nil {
-			project = fmt.Sprintf("\nProject: %s", *obs.Project)
+			project = i18n.Tf("\nProject: %s", *obs.Project)
 		}
-		scope := fmt.Sprintf("\nScope: %s", obs.Scope)
+		scope := i18n.Tf("\nScope: %s", obs.Scope)
 		topic := ""
 		if obs.TopicKey != nil {
-			topic = fmt.Sprintf("\nTopic: %s", *obs.TopicKey)
+			topic = i18n.Tf("\nTopic: %s", *obs.TopicKey)
 		}
 		toolName := ""
 		if obs.ToolName != nil {
-			toolName = fmt.Sprintf("\nTool: %s", *obs.ToolName)
+			toolName = i18n.Tf("\nTool: %s", *obs.ToolName)
+		}
+		if obs.Source != nil {
+			toolName += i18n.Tf("\nSource: %s", *obs.Source)
+		}
+		if len(obs.Metadata) > 0 {
+			toolName += i18n.Tf("\nMetadata: %s", strings.Join(obs.Metadata.Pairs(), ", "))
+		}
+		duplicateMeta := i18n.Tf("\nDuplicates: %d", obs.DuplicateCount)
+		revisionMeta := i18n.Tf("\nRevisions: %d", obs.RevisionCount)
+		verification := ""
+		if obs.StaleAt != nil {
+			verification += i18n.Tf("\nStale since: %s", *obs.StaleAt)
+		}
+		if obs.VerifiedAt != nil {
+			verification += i18n.Tf("\nLast verified: %s", *obs.VerifiedAt)
+		}
+		if obs.VerificationNote != nil {
+			verification += i18n.Tf("\nEvidence: %s", *obs.VerificationNote)
+		}
+		if parts > 1 {
+			verification += i18n.Tf("\nAssembled from %d parts", parts)
 		}
-		duplicateMeta := fmt.Sprintf("\nDuplicates: %d", obs.DuplicateCount)
-		revisionMeta := fmt.Sprintf("\nRevisions: %d", obs.RevisionCount)
 
-		result := fmt.Sprintf("#%d [%s] %s\n%s\nSession: %s%s%s\nCreated: %s",
+		result := i18n.Tf("#%d [%s] %s\n%s\nSession: %s%s%s\nCreated: %s%s",
 			obs.ID, obs.Type, obs.Title,
 			obs.Content,
 			obs.SessionID, project+scope+topic, toolName+duplicateMeta+revisionMeta,
-			obs.CreatedAt,
+			obs.CreatedAt, verification+translated,
 		)
 
-		return mcp.NewToolResultText(result), nil
+		return mcp.NewToolResultStructured(obs, result), nil
 	}
 }
 
@@ -1063,6 +2493,9 @@ func handleSessionSummary(s *store.Store, cfg MCPConfig, activity *SessionActivi
 		}
 		project, _ = store.NormalizeProject(project)
 
+		// Only a named session is ended by default: the shared
+		// manual-save-{project} session collects saves from every run.
+		endSession := boolArg(req, "end_session", sessionID != "")
 		if sessionID == "" {
 			sessionID = defaultSessionID(project)
 		}
@@ -1076,15 +2509,29 @@ func handleSessionSummary(s *store.Store, cfg MCPConfig, activity *SessionActivi
 			Title:     fmt.Sprintf("Session summary: %s", project),
 			Content:   content,
 			Project:   project,
+			Source:    clientSource(ctx),
 		})
 		if err != nil {
-			return mcp.NewToolResultError("Failed to save session summary: " + err.Error()), nil
+			return storeErrorResult("Failed to save session summary: ", err), nil
 		}
 
-		msg := fmt.Sprintf("Session summary saved for project %q", project)
+		msg := i18n.Tf("Session summary saved for project %q", project)
 		if score := activity.ActivityScore(defaultSessionID(project)); score != "" {
 			msg += "\n" + score
 		}
+		if !endSession {
+			return mcp.NewToolResultText(msg), nil
+		}
+
+		durable := durableScratchCount(s, sessionID)
+		if err := s.EndSession(sessionID, content); err != nil {
+			return storeErrorResult(msg+"\nFailed to end session: ", err), nil
+		}
+		activity.ClearSession(defaultSessionID(project))
+		msg += "\n" + i18n.Tf("Session %q completed", sessionID)
+		if durable > 0 {
+			msg += i18n.Tf("; %d durable working memory item(s) saved as observations", durable)
+		}
 		return mcp.NewToolResultText(msg), nil
 	}
 }
@@ -1094,6 +2541,7 @@ func handleSessionStart(s *store.Store, cfg MCPConfig, activity *SessionActivity
 		id, _ := req.GetArguments()["id"].(string)
 		project, _ := req.GetArguments()["project"].(string)
 		directory, _ := req.GetArguments()["directory"].(string)
+		parentID, _ := req.GetArguments()["parent_session_id"].(string)
 
 		// Apply default project when LLM sends empty
 		if project == "" {
@@ -1103,11 +2551,15 @@ func handleSessionStart(s *store.Store, cfg MCPConfig, activity *SessionActivity
 
 		activity.RecordToolCall(defaultSessionID(project))
 
-		if err := s.CreateSession(id, project, directory); err != nil {
-			return mcp.NewToolResultError("Failed to start session: " + err.Error()), nil
+		if err := s.CreateSessionWithParent(id, project, directory, parentID); err != nil {
+			return storeErrorResult("Failed to start session: ", err), nil
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Session %q started for project %q", id, project)), nil
+		msg := i18n.Tf("Session %q started for project %q", id, project)
+		if parentID != "" {
+			msg += i18n.Tf(", continuing %q", parentID)
+		}
+		return mcp.NewToolResultText(msg), nil
 	}
 }
 
@@ -1116,8 +2568,9 @@ func handleSessionEnd(s *store.Store, cfg MCPConfig, activity *SessionActivity)
 		id, _ := req.GetArguments()["id"].(string)
 		summary, _ := req.GetArguments()["summary"].(string)
 
+		durable := durableScratchCount(s, id)
 		if err := s.EndSession(id, summary); err != nil {
-			return mcp.NewToolResultError("Failed to end session: " + err.Error()), nil
+			return storeErrorResult("Failed to end session: ", err), nil
 		}
 
 		// Determine the project for this session to clean up activity tracking
@@ -1128,7 +2581,173 @@ func handleSessionEnd(s *store.Store, cfg MCPConfig, activity *SessionActivity)
 		project, _ = store.NormalizeProject(project)
 		activity.ClearSession(defaultSessionID(project))
 
-		return mcp.NewToolResultText(fmt.Sprintf("Session %q completed", id)), nil
+		msg := i18n.Tf("Session %q completed", id)
+		if durable > 0 {
+			msg += i18n.Tf("; %d durable working memory item(s) saved as observations", durable)
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+func handleCompactionEvent(s *store.Store, cfg MCPConfig, activity *SessionActivity) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sessionID, _ := req.GetArguments()["session_id"].(string)
+		project, _ := req.GetArguments()["project"].(string)
+		trigger, _ := req.GetArguments()["trigger"].(string)
+		note, _ := req.GetArguments()["note"].(string)
+
+		if project == "" {
+			project = cfg.DefaultProject
+		}
+		project, _ = store.NormalizeProject(project)
+		if sessionID == "" {
+			sessionID = defaultSessionID(project)
+		}
+		activity.RecordToolCall(defaultSessionID(project))
+
+		event, err := s.RecordCompaction(store.CompactionParams{
+			SessionID: sessionID,
+			Project:   project,
+			Trigger:   trigger,
+			Note:      note,
+			Source:    clientSource(ctx),
+		})
+		if err != nil {
+			return storeErrorResult("Failed to record compaction: ", err), nil
+		}
+		return mcp.NewToolResultText(i18n.Tf("Compaction recorded for project %q (marker #%d); the next mem_context call resurfaces the latest session summary", project, event.ObservationID)), nil
+	}
+}
+
+// durableScratchCount counts the working memory items ending sessionID will
+// promote to observations.
+func durableScratchCount(s *store.Store, sessionID string) int {
+	durable := 0
+	if items, err := s.Scratch(sessionID, ""); err == nil {
+		for _, item := range items {
+			if item.Durable {
+				durable++
+			}
+		}
+	}
+	return durable
+}
+
+// errorResult is a tool error whose structured content carries a
+// machine-readable code (one of the store.Code constants), so clients can
+// branch on it instead of parsing the text.
+func errorResult(code, text string) *mcp.CallToolResult {
+	res := mcp.NewToolResultError(text)
+	res.StructuredContent = map[string]string{"code": code, "error": text}
+	return res
+}
+
+// storeErrorResult reports a failed store call, prefixed with what failed.
+func storeErrorResult(prefix string, err error) *mcp.CallToolResult {
+	return errorResult(store.ErrorCode(err), prefix+err.Error())
+}
+
+// scratchSession resolves the session a working memory tool call targets.
+func scratchSession(req mcp.CallToolRequest, cfg MCPConfig) string {
+	if id, _ := req.GetArguments()["session_id"].(string); id != "" {
+		return id
+	}
+	project, _ := req.GetArguments()["project"].(string)
+	if project == "" {
+		project = cfg.DefaultProject
+	}
+	project, _ = store.NormalizeProject(project)
+	return defaultSessionID(project)
+}
+
+func handleScratchSet(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		key, _ := req.GetArguments()["key"].(string)
+		value, _ := req.GetArguments()["value"].(string)
+		durable := boolArg(req, "durable", false)
+		typ, _ := req.GetArguments()["type"].(string)
+		ttlArg, _ := req.GetArguments()["ttl"].(string)
+
+		var ttl time.Duration
+		if ttlArg != "" {
+			parsed, err := time.ParseDuration(ttlArg)
+			if err != nil || parsed <= 0 {
+				return errorResult(store.CodeValidation, fmt.Sprintf("invalid ttl %q (want e.g. 30m or 4h)", ttlArg)), nil
+			}
+			ttl = parsed
+		}
+
+		item, err := s.SetScratch(store.SetScratchParams{
+			SessionID: scratchSession(req, cfg),
+			Key:       key,
+			Value:     value,
+			Durable:   durable,
+			Type:      typ,
+			TTL:       ttl,
+		})
+		if err != nil {
+			return storeErrorResult("Failed to set working memory: ", err), nil
+		}
+
+		msg := i18n.Tf("Working memory %q set", item.Key)
+		if item.Durable {
+			msg += " (durable — saved as an observation when the session ends)"
+		} else if item.ExpiresAt != nil {
+			msg += " (expires " + s.FormatTime(*item.ExpiresAt) + ")"
+		}
+		return mcp.NewToolResultStructured(item, msg), nil
+	}
+}
+
+// scratchOutput is the structured result of mem_scratch_get.
+type scratchOutput struct {
+	SessionID string              `json:"session_id"`
+	Items     []store.ScratchItem `json:"items"`
+}
+
+func handleScratchGet(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		key, _ := req.GetArguments()["key"].(string)
+		sessionID := scratchSession(req, cfg)
+
+		items, err := s.Scratch(sessionID, key)
+		if err != nil {
+			return storeErrorResult("Failed to read working memory: ", err), nil
+		}
+		out := scratchOutput{SessionID: sessionID, Items: items}
+		if out.Items == nil {
+			out.Items = []store.ScratchItem{}
+		}
+		if len(items) == 0 {
+			if key != "" {
+				return mcp.NewToolResultStructured(out, i18n.Tf("No working memory item %q in session %s.", key, sessionID)), nil
+			}
+			return mcp.NewToolResultStructured(out, i18n.Tf("Working memory of session %s is empty.", sessionID)), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Working memory of session %s (%d items):\n", sessionID, len(items))
+		for _, item := range items {
+			marker := ""
+			if item.Durable {
+				marker = " [durable]"
+			}
+			fmt.Fprintf(&b, "\n## %s%s\n%s\n", item.Key, marker, item.Value)
+		}
+		return mcp.NewToolResultStructured(out, b.String()), nil
+	}
+}
+
+func handleScratchClear(s *store.Store, cfg MCPConfig) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		key, _ := req.GetArguments()["key"].(string)
+		sessionID := scratchSession(req, cfg)
+
+		n, err := s.ClearScratch(sessionID, key)
+		if err != nil {
+			return storeErrorResult("Failed to clear working memory: ", err), nil
+		}
+		return mcp.NewToolResultText(i18n.Tf("Cleared %d working memory item(s) from session %s", n, sessionID)), nil
 	}
 }
 
@@ -1148,7 +2767,7 @@ func handleCapturePassive(s *store.Store, cfg MCPConfig, activity *SessionActivi
 		activity.RecordToolCall(defaultSessionID(project))
 
 		if content == "" {
-			return mcp.NewToolResultError("content is required — include text with a '## Key Learnings:' section"), nil
+			return errorResult(store.CodeValidation, "content is required — include text with a '## Key Learnings:' section or error output"), nil
 		}
 
 		if sessionID == "" {
@@ -1167,23 +2786,150 @@ func handleCapturePassive(s *store.Store, cfg MCPConfig, activity *SessionActivi
 			Source:    source,
 		})
 		if err != nil {
-			return mcp.NewToolResultError("Passive capture failed: " + err.Error()), nil
+			return storeErrorResult("Passive capture failed: ", err), nil
 		}
 
 		return mcp.NewToolResultText(fmt.Sprintf(
-			"Passive capture complete: extracted=%d saved=%d duplicates=%d",
-			result.Extracted, result.Saved, result.Duplicates,
+			"Passive capture complete: extracted=%d errors=%d saved=%d quarantined=%d duplicates=%d",
+			result.Extracted, result.Errors, result.Saved, result.Quarantined, result.Duplicates,
 		)), nil
 	}
 }
 
+func handleToolRun(s *store.Store, cfg MCPConfig, activity *SessionActivity) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		toolName, _ := req.GetArguments()["tool_name"].(string)
+		command, _ := req.GetArguments()["command"].(string)
+		filesArg, _ := req.GetArguments()["files"].(string)
+		summary, _ := req.GetArguments()["summary"].(string)
+		sessionID, _ := req.GetArguments()["session_id"].(string)
+		project, _ := req.GetArguments()["project"].(string)
+
+		if project == "" {
+			project = cfg.DefaultProject
+		}
+		project, _ = store.NormalizeProject(project)
+
+		activity.RecordToolCall(defaultSessionID(project))
+
+		if strings.TrimSpace(toolName) == "" {
+			return errorResult(store.CodeValidation, "tool_name is required"), nil
+		}
+		files, err := parseFileDeltas(filesArg)
+		if err != nil {
+			return errorResult(store.CodeValidation, err.Error()), nil
+		}
+
+		if sessionID == "" {
+			sessionID = defaultSessionID(project)
+			_ = s.CreateSession(sessionID, project, "")
+		}
+
+		params := store.AddToolRunParams{
+			SessionID:  sessionID,
+			ToolName:   toolName,
+			Command:    command,
+			DurationMs: int64(intArg(req, "duration_ms", 0)),
+			Files:      files,
+			Summary:    summary,
+			Project:    project,
+		}
+		if _, ok := req.GetArguments()["exit_code"].(float64); ok {
+			code := intArg(req, "exit_code", 0)
+			params.ExitCode = &code
+		}
+
+		id, err := s.AddToolRun(params)
+		if err != nil {
+			return storeErrorResult("Failed to record tool run: ", err), nil
+		}
+		run := store.ToolRun{ToolName: toolName, Command: command, ExitCode: params.ExitCode, DurationMs: params.DurationMs, Files: files}
+		return mcp.NewToolResultText(i18n.Tf("Tool run #%d recorded: %s", id, run.Headline())), nil
+	}
+}
+
+// parseFileDeltas reads "path:+added/-removed" entries separated by commas;
+// the counts are optional.
+func parseFileDeltas(raw string) ([]store.FileDelta, error) {
+	var files []store.FileDelta
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		delta := store.FileDelta{Path: item}
+		if i := strings.LastIndex(item, ":+"); i > 0 {
+			added, removed, ok := strings.Cut(item[i+2:], "/-")
+			a, errA := strconv.Atoi(added)
+			r, errR := strconv.Atoi(removed)
+			if !ok || errA != nil || errR != nil {
+				return nil, fmt.Errorf("invalid file delta %q: use path:+added/-removed", item)
+			}
+			delta = store.FileDelta{Path: item[:i], Added: a, Removed: r}
+		}
+		files = append(files, delta)
+	}
+	return files, nil
+}
+
+func handleVerify(s *store.Store, cfg MCPConfig, activity *SessionActivity) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id := int64(intArg(req, "id", 0))
+		status, _ := req.GetArguments()["status"].(string)
+		evidence, _ := req.GetArguments()["evidence"].(string)
+		pathsArg, _ := req.GetArguments()["paths"].(string)
+
+		project, _ := store.NormalizeProject(cfg.DefaultProject)
+		activity.RecordToolCall(defaultSessionID(project))
+
+		if id == 0 {
+			return errorResult(store.CodeValidation, "id is required"), nil
+		}
+		var paths []string
+		for _, p := range strings.Split(pathsArg, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				paths = append(paths, p)
+			}
+		}
+
+		obs, err := s.VerifyObservation(id, store.VerifyParams{Status: status, Evidence: evidence, Paths: paths})
+		if errors.Is(err, store.ErrObservationNotFound) {
+			return errorResult(store.CodeNotFound, i18n.Tf("Observation #%d not found", id)), nil
+		}
+		if err != nil {
+			return storeErrorResult("Failed to verify: ", err), nil
+		}
+
+		if obs.StaleAt != nil {
+			return mcp.NewToolResultText(i18n.Tf("Marked #%d %q as stale. It now ranks below fresh memories; rewrite it with mem_update or confirm it again with mem_verify.", obs.ID, obs.Title)), nil
+		}
+		return mcp.NewToolResultText(i18n.Tf("Confirmed #%d %q (verified %s)", obs.ID, obs.Title, *obs.VerifiedAt)), nil
+	}
+}
+
+// staleMarker flags stale memories in compact listings.
+func staleMarker(o store.Observation) string {
+	if o.StaleAt != nil {
+		return " [stale]"
+	}
+	return ""
+}
+
+// verifiedDisplay is the "last verified" suffix for compact listings.
+func verifiedDisplay(o store.Observation) string {
+	if o.VerifiedAt == nil {
+		return ""
+	}
+	return " | verified: " + *o.VerifiedAt
+}
+
 func handleMergeProjects(s *store.Store) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		fromStr, _ := req.GetArguments()["from"].(string)
 		to, _ := req.GetArguments()["to"].(string)
 
 		if fromStr == "" || to == "" {
-			return mcp.NewToolResultError("both 'from' and 'to' are required"), nil
+			return errorResult(store.CodeValidation, "both 'from' and 'to' are required"), nil
 		}
 
 		var sources []string
@@ -1195,12 +2941,12 @@ func handleMergeProjects(s *store.Store) server.ToolHandlerFunc {
 		}
 
 		if len(sources) == 0 {
-			return mcp.NewToolResultError("at least one source project name is required in 'from'"), nil
+			return errorResult(store.CodeValidation, "at least one source project name is required in 'from'"), nil
 		}
 
 		result, err := s.MergeProjects(sources, to)
 		if err != nil {
-			return mcp.NewToolResultError("Merge failed: " + err.Error()), nil
+			return storeErrorResult("Merge failed: ", err), nil
 		}
 
 		msg := fmt.Sprintf("Merged %d source(s) into %q:\n", len(result.SourcesMerged), result.Canonical)
@@ -1214,6 +2960,48 @@ func handleMergeProjects(s *store.Store) server.ToolHandlerFunc {
 
 // ─── Helpers ─────────────────────────────────────────────────────────────────
 
+// logToolCalls records one structured log line per tool call with the tool
+// name, duration, and outcome ("ok", "tool_error", or "error").
+func logToolCalls(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		res, err := next(ctx, req)
+
+		outcome, level := "ok", slog.LevelInfo
+		switch {
+		case err != nil:
+			outcome, level = "error", slog.LevelError
+		case res != nil && res.IsError:
+			outcome, level = "tool_error", slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("tool", req.Params.Name),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("outcome", outcome),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("err", err.Error()))
+		}
+		slog.Default().LogAttrs(ctx, level, "mcp tool call", attrs...)
+		return res, err
+	}
+}
+
+// clientSource is the observation source for a save made over MCP:
+// "mcp:<client>" using the name the client sent in initialize, or "mcp"
+// when it sent none.
+func clientSource(ctx context.Context) string {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	if !ok {
+		return store.SourceMCP
+	}
+	name := strings.ToLower(strings.Join(strings.Fields(session.GetClientInfo().Name), "-"))
+	if name == "" {
+		return store.SourceMCP
+	}
+	return store.SourceMCP + ":" + name
+}
+
 // defaultSessionID returns a project-scoped default session ID.
 // If project is non-empty: "manual-save-{project}"
 // If project is empty: "manual-save"
@@ -1240,6 +3028,20 @@ func boolArg(req mcp.CallToolRequest, key string, defaultVal bool) bool {
 	return v
 }
 
+// metadataFilterArg reads the metadata filter of mem_search. Values are
+// compared as text, so 412 and "412" filter the same.
+func metadataFilterArg(req mcp.CallToolRequest) map[string]string {
+	meta, _ := req.GetArguments()["metadata"].(map[string]any)
+	if len(meta) == 0 {
+		return nil
+	}
+	filter := make(map[string]string, len(meta))
+	for key := range meta {
+		filter[key], _ = store.Metadata(meta).String(key)
+	}
+	return filter
+}
+
 func truncate(s string, max int) string {
 	runes := []rune(s)
 	if len(runes) <= max {
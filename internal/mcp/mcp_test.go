@@ -1,14 +1,20 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/Gentleman-Programming/engram/internal/store"
+	"github.com/Gentleman-Programming/engram/internal/translate"
 	mcppkg "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 )
 
 func newMCPTestStore(t *testing.T) *store.Store {
@@ -50,7 +56,7 @@ func TestNewServerRegistersTools(t *testing.T) {
 }
 
 func TestHandleSuggestTopicKeyReturnsFamilyBasedKey(t *testing.T) {
-	h := handleSuggestTopicKey()
+	h := handleSuggestTopicKey(newMCPTestStore(t), MCPConfig{})
 	req := mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
 		"type":  "architecture",
 		"title": "Auth model",
@@ -70,8 +76,52 @@ func TestHandleSuggestTopicKeyReturnsFamilyBasedKey(t *testing.T) {
 	}
 }
 
+func TestHandleSuggestTopicKeyPrefersExistingNearMatch(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s1", "engram", "/work"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	for _, key := range []string{"architecture/auth-model", "architecture/auth-design", "bug/logout-crash"} {
+		if _, err := s.AddObservation(store.AddObservationParams{
+			SessionID: "s1", Type: "architecture", Title: key, Content: "notes on " + key, Project: "engram", TopicKey: key,
+		}); err != nil {
+			t.Fatalf("add %s: %v", key, err)
+		}
+	}
+	h := handleSuggestTopicKey(s, MCPConfig{DefaultProject: "engram"})
+	suggest := func(typ, title string) (map[string]any, string) {
+		t.Helper()
+		res, err := h(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"type": typ, "title": title}}})
+		if err != nil || res.IsError {
+			t.Fatalf("suggest %q: err=%v res=%v", title, err, res)
+		}
+		out, ok := res.StructuredContent.(map[string]any)
+		if !ok {
+			t.Fatalf("expected structured content, got %#v", res.StructuredContent)
+		}
+		return out, callResultText(t, res)
+	}
+
+	out, text := suggest("architecture", "Auth models")
+	if out["topic_key"] != "architecture/auth-model" || out["existing"] != true || out["generated"] != "architecture/auth-models" {
+		t.Fatalf("expected the plural to reuse the existing key, got %+v", out)
+	}
+	if !strings.Contains(text, "Suggested topic_key: architecture/auth-model\n") || !strings.Contains(text, "A new key would have been architecture/auth-models.") {
+		t.Fatalf("unexpected suggestion text:\n%s", text)
+	}
+	if alternatives := out["alternatives"].([]store.TopicMatch); len(alternatives) < 2 || alternatives[1].TopicKey != "architecture/auth-design" {
+		t.Fatalf("expected the other auth key among the alternatives, got %+v", alternatives)
+	}
+
+	// Sharing the family and one word is not the same topic.
+	out, text = suggest("bugfix", "Login crash")
+	if out["topic_key"] != "bug/login-crash" || out["existing"] != false || !strings.Contains(text, "- bug/logout-crash") {
+		t.Fatalf("expected a new key with the near miss listed, got %+v\n%s", out, text)
+	}
+}
+
 func TestHandleSuggestTopicKeyRequiresInput(t *testing.T) {
-	h := handleSuggestTopicKey()
+	h := handleSuggestTopicKey(newMCPTestStore(t), MCPConfig{})
 	req := mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{}}}
 
 	res, err := h(context.Background(), req)
@@ -134,6 +184,39 @@ func TestHandleSaveDoesNotSuggestWhenTopicKeyProvided(t *testing.T) {
 	}
 }
 
+func TestHandleSaveInfersTypeWhenOmitted(t *testing.T) {
+	s := newMCPTestStore(t)
+	h := handleSave(s, MCPConfig{}, NewSessionActivity(10*time.Minute))
+
+	save := func(args map[string]any) string {
+		t.Helper()
+		res, err := h(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: args}})
+		if err != nil || res.IsError {
+			t.Fatalf("save failed: err=%v res=%+v", err, res)
+		}
+		return callResultText(t, res)
+	}
+
+	text := save(map[string]any{"title": "Fixed nil panic in auth", "content": "Guard the token before use", "project": "engram"})
+	if !strings.Contains(text, `(bugfix)`) || !strings.Contains(text, "Type inferred: bugfix") || !strings.Contains(text, "decision, architecture, bugfix") {
+		t.Fatalf("expected inferred bugfix type with taxonomy, got %q", text)
+	}
+	results, err := s.Search("nil panic", store.SearchOptions{Project: "engram"})
+	if err != nil || len(results) != 1 || results[0].Type != "bugfix" {
+		t.Fatalf("expected stored type bugfix, got %+v err=%v", results, err)
+	}
+
+	text = save(map[string]any{"title": "Weekly sync notes", "content": "Talked about the roadmap", "project": "engram"})
+	if !strings.Contains(text, "(manual)") || !strings.Contains(text, "saved as manual") {
+		t.Fatalf("expected manual fallback, got %q", text)
+	}
+
+	text = save(map[string]any{"title": "Fixed nil panic again", "content": "Another guard", "type": "learning", "project": "engram"})
+	if strings.Contains(text, "inferred") || strings.Contains(text, "Pass type explicitly") {
+		t.Fatalf("explicit type must not be reported as inferred, got %q", text)
+	}
+}
+
 func TestHandleCapturePassiveExtractsAndSaves(t *testing.T) {
 	s := newMCPTestStore(t)
 	h := handleCapturePassive(s, MCPConfig{}, NewSessionActivity(10*time.Minute))
@@ -200,6 +283,25 @@ func TestHandleCapturePassiveWithNoLearningSection(t *testing.T) {
 	}
 }
 
+func TestHandleCapturePassiveReportsErrorFindings(t *testing.T) {
+	s := newMCPTestStore(t)
+	h := handleCapturePassive(s, MCPConfig{}, NewSessionActivity(10*time.Minute))
+
+	req := mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"content": "--- FAIL: TestSync (0.02s)\n    sync_test.go:30: chunk missing\n\nRoot cause: the manifest was written before the chunk file was flushed",
+		"project": "engram",
+	}}}
+
+	res, err := h(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	text := callResultText(t, res)
+	if res.IsError || !strings.Contains(text, "extracted=2 errors=2 saved=2") {
+		t.Fatalf("expected two error findings saved, got %q", text)
+	}
+}
+
 func TestHandleCapturePassiveDefaultsSourceAndSession(t *testing.T) {
 	s := newMCPTestStore(t)
 	h := handleCapturePassive(s, MCPConfig{}, NewSessionActivity(10*time.Minute))
@@ -335,7 +437,7 @@ func TestHandleSearchAndCRUDHandlers(t *testing.T) {
 		t.Fatalf("unexpected update error: %s", callResultText(t, updateRes))
 	}
 
-	getObs := handleGetObservation(s)
+	getObs := handleGetObservation(s, MCPConfig{})
 	getReq := mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
 		"id": float64(obsID),
 	}}}
@@ -531,7 +633,7 @@ func TestMCPHandlersErrorBranches(t *testing.T) {
 		t.Fatalf("expected timeline missing id to return tool error")
 	}
 
-	getObs := handleGetObservation(s)
+	getObs := handleGetObservation(s, MCPConfig{})
 	getMissingIDRes, err := getObs(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{}}})
 	if err != nil {
 		t.Fatalf("get observation missing id error: %v", err)
@@ -627,7 +729,7 @@ func TestMCPHandlersReturnErrorsWhenStoreClosed(t *testing.T) {
 		t.Fatalf("expected timeline to return tool error when store is closed")
 	}
 
-	getObsRes, err := handleGetObservation(s)(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"id": 1.0}}})
+	getObsRes, err := handleGetObservation(s, MCPConfig{})(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"id": 1.0}}})
 	if err != nil {
 		t.Fatalf("closed store get observation call: %v", err)
 	}
@@ -745,7 +847,7 @@ func TestHandleSuggestTopicKeyReturnsErrorWhenSuggestionEmpty(t *testing.T) {
 		suggestTopicKey = prev
 	})
 
-	h := handleSuggestTopicKey()
+	h := handleSuggestTopicKey(newMCPTestStore(t), MCPConfig{})
 	req := mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
 		"title": "valid title",
 	}}}
@@ -813,6 +915,60 @@ func TestHandleContextWithSessionOnlyUsesNoneProjects(t *testing.T) {
 	}
 }
 
+func TestHandleContextIncludeParents(t *testing.T) {
+	s := newMCPTestStore(t)
+	for _, p := range []string{"platform", "platform/api"} {
+		if err := s.CreateSession("s-"+p, p, "/work"); err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+		if _, err := s.AddObservation(store.AddObservationParams{
+			SessionID: "s-" + p, Type: "architecture", Title: "Layout of " + p, Content: "hexagonal", Project: p,
+		}); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+
+	res, err := handleContext(s, MCPConfig{}, NewSessionActivity(10*time.Minute))(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"project":         "platform/api",
+		"include_parents": true,
+	}}})
+	if err != nil || res.IsError {
+		t.Fatalf("context handler: err=%v res=%v", err, res)
+	}
+	text := callResultText(t, res)
+	if !strings.Contains(text, "### Parent Project Decisions") || !strings.Contains(text, "Layout of platform**") {
+		t.Fatalf("expected parent decisions in context:\n%s", text)
+	}
+}
+
+func TestHandleContextEstimateReturnsSizesOnly(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s1", "engram", "/work"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := s.AddObservation(store.AddObservationParams{
+		SessionID: "s1", Type: "decision", Title: "Use SQLite", Content: "single binary", Project: "engram",
+	}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+
+	res, err := handleContext(s, MCPConfig{}, NewSessionActivity(10*time.Minute))(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"project":  "engram",
+		"estimate": true,
+	}}})
+	if err != nil || res.IsError {
+		t.Fatalf("context handler: err=%v res=%v", err, res)
+	}
+	text := callResultText(t, res)
+	if !strings.Contains(text, "Context estimate:") || !strings.Contains(text, "- Recent Observations: 1 items") || strings.Contains(text, "single binary") {
+		t.Fatalf("expected sizes without the context text:\n%s", text)
+	}
+	estimate, ok := res.StructuredContent.(*store.ContextEstimate)
+	if !ok || estimate.Tokens == 0 || len(estimate.Sections) != 2 {
+		t.Fatalf("expected a structured estimate, got %#v", res.StructuredContent)
+	}
+}
+
 func TestHandleStatsReturnsErrorWhenLoaderFails(t *testing.T) {
 	prev := loadMCPStats
 	loadMCPStats = func(s *store.Store) (*store.Stats, error) {
@@ -888,7 +1044,7 @@ func TestHandleGetObservationIncludesTopicAndToolMetadata(t *testing.T) {
 		t.Fatalf("add observation: %v", err)
 	}
 
-	res, err := handleGetObservation(s)(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+	res, err := handleGetObservation(s, MCPConfig{})(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
 		"id": float64(id),
 	}}})
 	if err != nil {
@@ -903,6 +1059,108 @@ func TestHandleGetObservationIncludesTopicAndToolMetadata(t *testing.T) {
 	}
 }
 
+// prefixTranslator marks text with the target language instead of
+// translating it.
+type prefixTranslator struct{}
+
+func (prefixTranslator) Translate(_ context.Context, text, lang string) (string, error) {
+	return "[" + lang + "] " + text, nil
+}
+
+func TestHandleGetObservationReassemblesParts(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s-long", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	content := strings.Repeat("Accomplished a lot.\n", s.MaxObservationLength()/20+10) + "## Next Steps\n- keep going"
+	id, err := s.AddObservation(store.AddObservationParams{
+		SessionID: "s-long", Type: "session_summary", Title: "Session summary", Content: content, Project: "engram",
+	})
+	if err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	parts, err := s.ObservationParts(id)
+	if err != nil || len(parts) != 2 {
+		t.Fatalf("expected two parts, got %+v, %v", parts, err)
+	}
+
+	res, err := handleGetObservation(s, MCPConfig{})(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"id": float64(parts[1].ObservationID),
+	}}})
+	if err != nil || res.IsError {
+		t.Fatalf("get observation: %v %+v", err, res)
+	}
+	text := callResultText(t, res)
+	if !strings.Contains(text, fmt.Sprintf("#%d [session_summary] Session summary\n", id)) || !strings.Contains(text, "- keep going") || !strings.Contains(text, "Assembled from 2 parts") {
+		t.Fatalf("expected the whole summary from the continuation, got %q", text[len(text)-200:])
+	}
+}
+
+func TestHandleGetObservationAndContextTranslate(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s-translate", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	id, err := s.AddObservation(store.AddObservationParams{
+		SessionID: "s-translate", Type: "decision", Title: "Usamos JWT", Content: "Tokens de refresco", Project: "engram",
+	})
+	if err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	cfg := MCPConfig{DefaultProject: "engram", Translate: translate.New(s, prefixTranslator{}, translate.Options{Target: "en"})}
+
+	get := handleGetObservation(s, cfg)
+	res, _ := get(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"id": float64(id)}}})
+	if text := callResultText(t, res); !strings.Contains(text, "[en] Usamos JWT") || !strings.Contains(text, "[en] Tokens de refresco") || !strings.Contains(text, "Translated to: en") {
+		t.Fatalf("expected the configured target language, got %q", text)
+	}
+	res, _ = get(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"id": float64(id), "lang": "original"}}})
+	if text := callResultText(t, res); strings.Contains(text, "[en]") || !strings.Contains(text, "Usamos JWT") {
+		t.Fatalf("expected the stored text with lang=original, got %q", text)
+	}
+
+	res, _ = handleContext(s, cfg, NewSessionActivity(10*time.Minute))(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"lang": "de"}}})
+	if text := callResultText(t, res); !strings.Contains(text, "[de] Usamos JWT") {
+		t.Fatalf("expected a translated context block, got %q", text)
+	}
+}
+
+func TestReadOnlyServerDoesNotCacheTranslations(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s-translate", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	id, err := s.AddObservation(store.AddObservationParams{
+		SessionID: "s-translate", Type: "decision", Title: "Usamos JWT", Content: "Tokens de refresco", Project: "engram",
+	})
+	if err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	cfg := MCPConfig{DefaultProject: "engram", ReadOnly: true, Translate: translate.New(s, prefixTranslator{}, translate.Options{Target: "en", Languages: []string{"en", "es"}})}
+	tools := NewServerWithConfig(s, cfg, nil).ListTools()
+
+	before := snapshotRows(t, s)
+	for name, args := range map[string]map[string]any{
+		"mem_context":         {},
+		"mem_search":          {"query": "JWT"},
+		"mem_get_observation": {"id": float64(id)},
+	} {
+		res, err := tools[name].Handler(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Name: name, Arguments: args}})
+		if err != nil || res.IsError {
+			t.Fatalf("%s: err=%v text=%s", name, err, callResultText(t, res))
+		}
+		if name != "mem_search" && !strings.Contains(callResultText(t, res), "[en] Usamos JWT") {
+			t.Fatalf("%s: expected translated output, got %q", name, callResultText(t, res))
+		}
+	}
+	if after := snapshotRows(t, s); after != before {
+		t.Fatalf("expected read-only translations to change no rows, got %s, was %s", after, before)
+	}
+	if _, ok, err := s.CachedTranslation("Usamos JWT", "en"); err != nil || ok {
+		t.Fatalf("expected nothing cached, got ok=%v err=%v", ok, err)
+	}
+}
+
 // ─── Tool Profile Tests ─────────────────────────────────────────────────────
 
 func TestResolveToolsEmpty(t *testing.T) {
@@ -930,6 +1188,9 @@ func TestResolveToolsAgentProfile(t *testing.T) {
 		"mem_session_start", "mem_session_end", "mem_get_observation",
 		"mem_suggest_topic_key", "mem_capture_passive", "mem_save_prompt",
 		"mem_update", // skills explicitly say "use mem_update when you have an exact ID to correct"
+		"mem_search_prompts", "mem_recent_prompts", "mem_topics", "mem_topic_search", "mem_for_file",
+		"mem_context_outline", "mem_context_section",
+		"mem_scratch_set", "mem_scratch_get", "mem_scratch_clear", "mem_tool_run", "mem_verify", "mem_compaction_event", "mem_templates", "mem_quota",
 	}
 	for _, tool := range expectedTools {
 		if !result[tool] {
@@ -974,12 +1235,15 @@ func TestResolveToolsCombinedProfiles(t *testing.T) {
 		t.Fatal("expected non-nil allowlist for combined profiles")
 	}
 
-	// Should have all 15 tools
+	// Should have all 28 tools
 	allTools := []string{
 		"mem_save", "mem_search", "mem_context", "mem_session_summary",
 		"mem_session_start", "mem_session_end", "mem_get_observation",
 		"mem_suggest_topic_key", "mem_capture_passive", "mem_save_prompt",
 		"mem_update", "mem_delete", "mem_stats", "mem_timeline", "mem_merge_projects",
+		"mem_search_prompts", "mem_recent_prompts", "mem_topics", "mem_topic_search", "mem_for_file",
+		"mem_context_outline", "mem_context_section",
+		"mem_scratch_set", "mem_scratch_get", "mem_scratch_clear", "mem_tool_run", "mem_verify", "mem_compaction_event", "mem_templates", "mem_quota",
 	}
 	for _, tool := range allTools {
 		if !result[tool] {
@@ -1165,6 +1429,9 @@ func TestNewServerWithToolsNilRegistersAll(t *testing.T) {
 		"mem_session_start", "mem_session_end", "mem_get_observation",
 		"mem_suggest_topic_key", "mem_capture_passive", "mem_save_prompt",
 		"mem_update", "mem_delete", "mem_stats", "mem_timeline", "mem_merge_projects",
+		"mem_search_prompts", "mem_recent_prompts", "mem_topics", "mem_topic_search", "mem_for_file",
+		"mem_context_outline", "mem_context_section",
+		"mem_scratch_set", "mem_scratch_get", "mem_scratch_clear", "mem_tool_run", "mem_verify", "mem_compaction_event", "mem_templates", "mem_quota",
 	}
 
 	for _, name := range allTools {
@@ -1203,14 +1470,14 @@ func TestNewServerBackwardsCompatible(t *testing.T) {
 	srv := NewServer(s)
 	tools := srv.ListTools()
 
-	// 11 agent + 4 admin = 15 total
-	if len(tools) != 15 {
-		t.Errorf("NewServer should register all 15 tools, got %d", len(tools))
+	// 24 agent + 4 admin = 28 total
+	if len(tools) != 30 {
+		t.Errorf("NewServer should register all 30 tools, got %d", len(tools))
 	}
 }
 
 func TestProfileConsistency(t *testing.T) {
-	// Verify that agent + admin = all 15 tools
+	// Verify that agent + admin = all 30 tools
 	combined := make(map[string]bool)
 	for tool := range ProfileAgent {
 		combined[tool] = true
@@ -1219,8 +1486,8 @@ func TestProfileConsistency(t *testing.T) {
 		combined[tool] = true
 	}
 
-	if len(combined) != 15 {
-		t.Errorf("agent + admin should cover all 15 tools, got %d", len(combined))
+	if len(combined) != 30 {
+		t.Errorf("agent + admin should cover all 30 tools, got %d", len(combined))
 	}
 
 	// Verify no overlap between profiles
@@ -1277,7 +1544,9 @@ func TestNonCoreToolsAreDeferred(t *testing.T) {
 		"mem_update", "mem_suggest_topic_key",
 		"mem_session_start", "mem_session_end",
 		"mem_stats", "mem_delete", "mem_timeline",
-		"mem_capture_passive", "mem_merge_projects",
+		"mem_capture_passive", "mem_merge_projects", "mem_topics", "mem_topic_search", "mem_for_file",
+		"mem_context_outline", "mem_context_section",
+		"mem_scratch_set", "mem_scratch_get", "mem_scratch_clear", "mem_tool_run", "mem_verify", "mem_compaction_event", "mem_templates", "mem_quota",
 	}
 	for _, name := range deferredTools {
 		tool := tools[name]
@@ -1318,7 +1587,8 @@ func TestReadOnlyToolAnnotations(t *testing.T) {
 
 	readOnlyTools := []string{
 		"mem_search", "mem_context", "mem_get_observation",
-		"mem_suggest_topic_key", "mem_stats", "mem_timeline",
+		"mem_suggest_topic_key", "mem_stats", "mem_timeline", "mem_topics", "mem_topic_search", "mem_for_file",
+		"mem_context_outline", "mem_context_section", "mem_scratch_get", "mem_quota",
 	}
 	for _, name := range readOnlyTools {
 		tool := tools[name]
@@ -1426,6 +1696,56 @@ func TestHandleSavePromptCreatesProjectScopedSession(t *testing.T) {
 	}
 }
 
+func TestHandleSearchAndRecentPrompts(t *testing.T) {
+	s := newMCPTestStore(t)
+	save := handleSavePrompt(s, MCPConfig{})
+	for _, args := range []map[string]any{
+		{"content": "Refactor the billing webhook retries", "project": "alpha"},
+		{"content": "Add dark mode to settings", "project": "alpha"},
+		{"content": "Billing export for beta", "project": "beta"},
+	} {
+		res, err := save(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: args}})
+		if err != nil || res.IsError {
+			t.Fatalf("save prompt: err=%v isError=%v", err, res.IsError)
+		}
+	}
+
+	search := handleSearchPrompts(s, MCPConfig{DefaultProject: "alpha"})
+	res, err := search(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"query": "billing",
+	}}})
+	if err != nil || res.IsError {
+		t.Fatalf("search prompts: err=%v isError=%v", err, res.IsError)
+	}
+	text := callResultText(t, res)
+	if !strings.Contains(text, "Found 1 prompts") || !strings.Contains(text, "webhook retries") || strings.Contains(text, "export for beta") {
+		t.Fatalf("expected default-project billing prompt only, got %q", text)
+	}
+
+	res, _ = search(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"query": "kubernetes",
+	}}})
+	if text := callResultText(t, res); !strings.Contains(text, "No prompts found") {
+		t.Fatalf("expected no results message, got %q", text)
+	}
+
+	recent := handleRecentPrompts(s, MCPConfig{})
+	res, err = recent(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"project": "Beta",
+	}}})
+	if err != nil || res.IsError {
+		t.Fatalf("recent prompts: err=%v isError=%v", err, res.IsError)
+	}
+	if text := callResultText(t, res); !strings.Contains(text, "Found 1 prompts") || !strings.Contains(text, "project: beta") {
+		t.Fatalf("expected normalized beta project filter, got %q", text)
+	}
+
+	res, _ = handleRecentPrompts(newMCPTestStore(t), MCPConfig{})(context.Background(), mcppkg.CallToolRequest{})
+	if text := callResultText(t, res); !strings.Contains(text, "No prompts saved yet") {
+		t.Fatalf("expected empty message, got %q", text)
+	}
+}
+
 func TestHandleSessionSummaryCreatesProjectScopedSession(t *testing.T) {
 	s := newMCPTestStore(t)
 	h := handleSessionSummary(s, MCPConfig{}, NewSessionActivity(10*time.Minute))
@@ -1444,6 +1764,113 @@ func TestHandleSessionSummaryCreatesProjectScopedSession(t *testing.T) {
 	}
 }
 
+func TestHandleSessionSummaryEndsNamedSession(t *testing.T) {
+	s := newMCPTestStore(t)
+	h := handleSessionSummary(s, MCPConfig{}, NewSessionActivity(10*time.Minute))
+	call := func(args map[string]any) string {
+		t.Helper()
+		res, err := h(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: args}})
+		if err != nil || res.IsError {
+			t.Fatalf("session summary: err=%v text=%s", err, callResultText(t, res))
+		}
+		return callResultText(t, res)
+	}
+
+	text := call(map[string]any{"content": "## Goal\nShip caching", "project": "engram", "session_id": "s-ends"})
+	if !strings.Contains(text, `Session "s-ends" completed`) {
+		t.Fatalf("expected the session reported ended, got %q", text)
+	}
+	sess, err := s.GetSession("s-ends")
+	if err != nil || sess.EndedAt == nil || sess.Summary == nil || *sess.Summary != "## Goal\nShip caching" {
+		t.Fatalf("expected s-ends ended with the summary, got %+v err=%v", sess, err)
+	}
+
+	call(map[string]any{"content": "## Goal\nKeep going", "project": "engram", "session_id": "s-open", "end_session": false})
+	if sess, err := s.GetSession("s-open"); err != nil || sess.EndedAt != nil {
+		t.Fatalf("expected end_session=false to leave s-open open, got %+v err=%v", sess, err)
+	}
+
+	// The shared default session is not ended unless asked.
+	call(map[string]any{"content": "## Goal\nNotes", "project": "engram"})
+	if sess, err := s.GetSession("manual-save-engram"); err != nil || sess.EndedAt != nil {
+		t.Fatalf("expected manual-save-engram left open, got %+v err=%v", sess, err)
+	}
+}
+
+func TestToolErrorsCarryCodes(t *testing.T) {
+	s := newMCPTestStore(t)
+	call := func(h server.ToolHandlerFunc, args map[string]any) map[string]string {
+		t.Helper()
+		res, err := h(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: args}})
+		if err != nil || !res.IsError {
+			t.Fatalf("expected a tool error, got err=%v res=%+v", err, res)
+		}
+		payload, _ := res.StructuredContent.(map[string]string)
+		return payload
+	}
+
+	if got := call(handleGetObservation(s, MCPConfig{}), map[string]any{"id": float64(9999)}); got["code"] != store.CodeNotFound {
+		t.Fatalf("expected not_found, got %v", got)
+	}
+	if got := call(handleGetObservation(s, MCPConfig{}), map[string]any{}); got["code"] != store.CodeValidation {
+		t.Fatalf("expected validation for a missing id, got %v", got)
+	}
+	if got := call(handleUpdate(s), map[string]any{"id": float64(9999), "title": "x"}); got["code"] != store.CodeNotFound || !strings.Contains(got["error"], "observation not found") {
+		t.Fatalf("expected not_found from the store, got %v", got)
+	}
+}
+
+func TestCompactionEventResurfacesSummaryInNextContext(t *testing.T) {
+	s := newMCPTestStore(t)
+	activity := NewSessionActivity(10 * time.Minute)
+	cfg := MCPConfig{DefaultProject: "engram"}
+	call := func(h server.ToolHandlerFunc, args map[string]any) string {
+		t.Helper()
+		res, err := h(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: args}})
+		if err != nil || res.IsError {
+			t.Fatalf("tool call: err=%v text=%s", err, callResultText(t, res))
+		}
+		return callResultText(t, res)
+	}
+
+	call(handleSessionSummary(s, cfg, activity), map[string]any{"content": "## Goal\nFinish caching", "project": "engram", "session_id": "s1"})
+	if text := call(handleCompactionEvent(s, cfg, activity), map[string]any{"session_id": "s2", "trigger": "auto"}); !strings.Contains(text, "Compaction recorded") {
+		t.Fatalf("unexpected response %q", text)
+	}
+
+	readOnly := MCPConfig{DefaultProject: "engram", ReadOnly: true}
+	before := snapshotRows(t, s)
+	for range 2 {
+		if text := call(handleContext(s, readOnly, activity), map[string]any{}); !strings.Contains(text, "Resumed After Compaction") {
+			t.Fatalf("expected a read-only server to show the pending summary, got %q", text)
+		}
+	}
+	if after := snapshotRows(t, s); after != before {
+		t.Fatalf("expected a read-only mem_context to change no rows, got %s, was %s", after, before)
+	}
+	if text := call(handleContext(s, cfg, activity), map[string]any{}); !strings.Contains(text, "Resumed After Compaction") || !strings.Contains(text, "Finish caching") {
+		t.Fatalf("expected the summary resurfaced, got %q", text)
+	}
+	if text := call(handleContext(s, cfg, activity), map[string]any{}); strings.Contains(text, "Resumed After Compaction") {
+		t.Fatalf("expected the summary resurfaced once, got %q", text)
+	}
+}
+
+// snapshotRows summarizes the tables a read could touch, for asserting
+// that it wrote nothing.
+func snapshotRows(t *testing.T, s *store.Store) string {
+	t.Helper()
+	res, err := s.Query(`SELECT
+		(SELECT COUNT(*) FROM compaction_events WHERE resurfaced_at IS NULL),
+		(SELECT ifnull(MAX(seq), 0) FROM events),
+		(SELECT COUNT(*) FROM observations),
+		(SELECT COUNT(*) FROM translations)`, 0)
+	if err != nil {
+		t.Fatalf("snapshot rows: %v", err)
+	}
+	return fmt.Sprint(res.Rows)
+}
+
 func TestHandleCapturePassiveCreatesProjectScopedSession(t *testing.T) {
 	s := newMCPTestStore(t)
 	h := handleCapturePassive(s, MCPConfig{}, NewSessionActivity(10*time.Minute))
@@ -1518,9 +1945,9 @@ func TestNewServerWithConfig(t *testing.T) {
 		t.Fatal("expected MCP server instance")
 	}
 	tools := srv.ListTools()
-	// Should have all 15 tools
-	if len(tools) != 15 {
-		t.Errorf("NewServerWithConfig should register all 15 tools, got %d", len(tools))
+	// Should have all 28 tools
+	if len(tools) != 30 {
+		t.Errorf("NewServerWithConfig should register all 30 tools, got %d", len(tools))
 	}
 }
 
@@ -2007,3 +2434,915 @@ func TestSessionStartUsesDefaultSessionID(t *testing.T) {
 		t.Fatalf("expected no activity under real session ID, got: %q", realScore)
 	}
 }
+
+func TestLogToolCallsRecordsToolAndOutcome(t *testing.T) {
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	ok := logToolCalls(func(ctx context.Context, req mcppkg.CallToolRequest) (*mcppkg.CallToolResult, error) {
+		return mcppkg.NewToolResultText("fine"), nil
+	})
+	failed := logToolCalls(func(ctx context.Context, req mcppkg.CallToolRequest) (*mcppkg.CallToolResult, error) {
+		return mcppkg.NewToolResultError("nope"), nil
+	})
+
+	req := mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Name: "mem_search"}}
+	if _, err := ok(context.Background(), req); err != nil {
+		t.Fatalf("ok handler: %v", err)
+	}
+	req.Params.Name = "mem_save"
+	if _, err := failed(context.Background(), req); err != nil {
+		t.Fatalf("failed handler: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "tool=mem_search") || !strings.Contains(out, "outcome=ok") {
+		t.Fatalf("expected ok record for mem_search, got %q", out)
+	}
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "tool=mem_save") || !strings.Contains(out, "outcome=tool_error") {
+		t.Fatalf("expected tool_error record for mem_save, got %q", out)
+	}
+}
+
+func TestHandleStatsIncludesHealthDetails(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "bugfix", Title: "t", Content: "c", Project: "engram"}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+
+	res, err := handleStats(s)(context.Background(), mcppkg.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	text := callResultText(t, res)
+	for _, want := range []string{"By type: bugfix=1", "Oldest observation:", "Duplicates avoided: 0", "Storage: db "} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("stats output missing %q: %q", want, text)
+		}
+	}
+}
+
+func TestHandleSaveAndSearchWithRefs(t *testing.T) {
+	s := newMCPTestStore(t)
+	activity := NewSessionActivity(10 * time.Minute)
+
+	save := handleSave(s, MCPConfig{}, activity)
+	res, err := save(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"title":   "Fix webhook retries",
+		"content": "Root cause tracked in #88",
+		"type":    "bugfix",
+		"project": "engram",
+		"refs":    "https://github.com/acme/api/pull/90, ",
+	}}})
+	if err != nil || res.IsError {
+		t.Fatalf("save: err=%v isError=%v", err, res.IsError)
+	}
+
+	search := handleSearch(s, MCPConfig{}, activity)
+	res, err = search(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"query":   "webhook",
+		"project": "engram",
+		"ref":     "acme/api#90",
+	}}})
+	if err != nil || res.IsError {
+		t.Fatalf("search: err=%v isError=%v", err, res.IsError)
+	}
+	text := callResultText(t, res)
+	if !strings.Contains(text, "Found 1 memories") || !strings.Contains(text, "refs: https://github.com/acme/api/pull/90, #88") {
+		t.Fatalf("expected ref-filtered result with refs listed, got %q", text)
+	}
+
+	res, _ = search(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"query":   "webhook",
+		"project": "engram",
+		"ref":     "#9",
+	}}})
+	if text := callResultText(t, res); !strings.Contains(text, "No memories found") {
+		t.Fatalf("expected unrelated ref to filter everything out, got %q", text)
+	}
+}
+
+func TestHandleSaveAndSearchWithMetadata(t *testing.T) {
+	s := newMCPTestStore(t)
+	activity := NewSessionActivity(10 * time.Minute)
+
+	save := handleSave(s, MCPConfig{}, activity)
+	for _, args := range []map[string]any{
+		{"title": "Fix webhook retries", "content": "Backoff with jitter", "type": "bugfix", "project": "engram", "metadata": map[string]any{"pr": float64(412), "model": "gpt-5"}},
+		{"title": "Fix webhook signatures", "content": "Compare in constant time", "type": "bugfix", "project": "engram", "metadata": map[string]any{"pr": float64(413)}},
+	} {
+		res, err := save(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: args}})
+		if err != nil || res.IsError {
+			t.Fatalf("save: err=%v isError=%v", err, res.IsError)
+		}
+	}
+	res, _ := save(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"title": "Bad", "content": "x", "project": "engram", "metadata": map[string]any{"nested": map[string]any{"a": 1}},
+	}}})
+	if !res.IsError {
+		t.Fatal("expected nested metadata to be rejected")
+	}
+
+	search := handleSearch(s, MCPConfig{}, activity)
+	res, err := search(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"query":    "webhook",
+		"project":  "engram",
+		"metadata": map[string]any{"pr": float64(412)},
+	}}})
+	if err != nil || res.IsError {
+		t.Fatalf("search: err=%v isError=%v", err, res.IsError)
+	}
+	text := callResultText(t, res)
+	if !strings.Contains(text, "Found 1 memories") || !strings.Contains(text, "metadata: model=gpt-5, pr=412") {
+		t.Fatalf("expected the metadata-filtered result with its metadata listed, got %q", text)
+	}
+	out, ok := res.StructuredContent.(searchOutput)
+	if !ok || len(out.Results) != 1 || out.Results[0].Metadata["model"] != "gpt-5" {
+		t.Fatalf("expected metadata in the structured hit, got %+v", res.StructuredContent)
+	}
+}
+
+func TestHandleSearchTargetAllIncludesPrompts(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "decision", Title: "Billing retries", Content: "retry billing webhooks", Project: "engram"}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	if _, err := s.AddPrompt(store.AddPromptParams{SessionID: "s1", Content: "fix the billing webhook", Project: "engram"}); err != nil {
+		t.Fatalf("add prompt: %v", err)
+	}
+
+	search := handleSearch(s, MCPConfig{DefaultProject: "engram"}, NewSessionActivity(10*time.Minute))
+	call := func(args map[string]any) *mcppkg.CallToolResult {
+		res, err := search(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: args}})
+		if err != nil {
+			t.Fatalf("search: %v", err)
+		}
+		return res
+	}
+
+	res := call(map[string]any{"query": "billing", "target": "all"})
+	out, _ := res.StructuredContent.(searchOutput)
+	if res.IsError || out.Count != 2 {
+		t.Fatalf("expected two results, got %+v", out)
+	}
+	kinds := map[string]bool{}
+	for _, hit := range out.Results {
+		kinds[hit.Kind] = true
+	}
+	text := callResultText(t, res)
+	if !kinds["observation"] || !kinds["prompt"] || !strings.Contains(text, "prompt #") {
+		t.Fatalf("expected tagged observation and prompt hits, got %+v %q", out.Results, text)
+	}
+
+	if res := call(map[string]any{"query": "billing"}); res.StructuredContent.(searchOutput).Count != 1 {
+		t.Fatalf("expected the default target to skip prompts, got %+v", res.StructuredContent)
+	}
+	if res := call(map[string]any{"query": "billing", "target": "prompts"}); !res.IsError {
+		t.Fatalf("expected an unknown target to be rejected, got %q", callResultText(t, res))
+	}
+}
+
+func TestHandleSearchExplain(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "decision", Title: "Billing retries", Content: "retry webhooks with backoff", Project: "engram"}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+
+	search := handleSearch(s, MCPConfig{DefaultProject: "engram"}, NewSessionActivity(10*time.Minute))
+	res, err := search(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"query": "billing", "explain": true}}})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	out, _ := res.StructuredContent.(searchOutput)
+	if res.IsError || out.Count != 1 || out.Results[0].Explain == nil || out.Results[0].Explain.Matched[0] != "title" {
+		t.Fatalf("expected an explained hit, got %+v", out)
+	}
+	if text := callResultText(t, res); !strings.Contains(text, "why: matched title | rank ") {
+		t.Fatalf("expected the explanation in the text, got %q", text)
+	}
+}
+
+func TestHandleTemplatesListsAndRendersSkeleton(t *testing.T) {
+	s := newMCPTestStore(t)
+	h := handleTemplates(s)
+	call := func(args map[string]any) *mcppkg.CallToolResult {
+		res, err := h(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: args}})
+		if err != nil {
+			t.Fatalf("templates: %v", err)
+		}
+		return res
+	}
+
+	if text := callResultText(t, call(nil)); !strings.Contains(text, "Found 2 templates") || !strings.Contains(text, "adr") || !strings.Contains(text, "incident") {
+		t.Fatalf("expected the built-in templates listed, got %q", text)
+	}
+
+	text := callResultText(t, call(map[string]any{"name": "adr", "title": "Use WAL mode"}))
+	if !strings.Contains(text, "topic_key: architecture/use-wal-mode") || !strings.Contains(text, "## Context\n\n## Decision") {
+		t.Fatalf("expected the adr skeleton and topic key, got %q", text)
+	}
+
+	if res := call(map[string]any{"name": "rfc"}); !res.IsError || !strings.Contains(callResultText(t, res), "unknown template") {
+		t.Fatalf("expected an unknown template error, got %q", callResultText(t, res))
+	}
+}
+
+func TestHandleQuotaReportsStatusAndRemaining(t *testing.T) {
+	cfg, err := store.DefaultConfig()
+	if err != nil {
+		t.Fatalf("DefaultConfig: %v", err)
+	}
+	cfg.DataDir = t.TempDir()
+	cfg.Quotas = map[string]store.Quota{"engram": {MaxObservations: 5}}
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	h := handleQuota(s, MCPConfig{DefaultProject: "engram"})
+	call := func(args map[string]any) (string, quotaReport) {
+		res, err := h(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: args}})
+		if err != nil {
+			t.Fatalf("quota: %v", err)
+		}
+		report, _ := res.StructuredContent.(quotaReport)
+		return callResultText(t, res), report
+	}
+
+	if _, report := call(nil); report.Status != "ok" || *report.RemainingObservations != 5 {
+		t.Fatalf("expected an empty project to have its full quota left, got %+v", report)
+	}
+
+	if err := s.CreateSession("s1", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	for i := range 4 {
+		if _, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "tool_use", Title: fmt.Sprintf("Ran tests %d", i), Content: fmt.Sprintf("go test passed on run %d", i), Project: "engram"}); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+	text, report := call(nil)
+	if report.Status != "near_limit" || *report.RemainingObservations != 1 || !strings.Contains(text, "Skip low-value saves") || !strings.Contains(text, "Largest type: tool_use") {
+		t.Fatalf("expected a near-limit warning naming tool_use, got %q %+v", text, report)
+	}
+
+	if text, report := call(map[string]any{"project": "other"}); report.Status != "unlimited" || !strings.Contains(text, "no quota") {
+		t.Fatalf("expected a project without a quota to be unlimited, got %q %+v", text, report)
+	}
+}
+
+func TestHandleSearchPagesWithCursor(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s-page", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	for i := range 5 {
+		if _, err := s.AddObservation(store.AddObservationParams{
+			SessionID: "s-page", Type: "bugfix", Project: "engram",
+			Title: fmt.Sprintf("Retry fix %d", i), Content: fmt.Sprintf("retry loop bug %d", i),
+		}); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+
+	search := handleSearch(s, MCPConfig{}, NewSessionActivity(10*time.Minute))
+	call := func(args map[string]any) (*mcppkg.CallToolResult, searchOutput) {
+		t.Helper()
+		res, err := search(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: args}})
+		if err != nil {
+			t.Fatalf("search: %v", err)
+		}
+		out, _ := res.StructuredContent.(searchOutput)
+		return res, out
+	}
+
+	seen := map[int64]bool{}
+	cursor := ""
+	for pageNum := 1; ; pageNum++ {
+		args := map[string]any{"query": "retry", "project": "engram", "limit": 2.0}
+		if cursor != "" {
+			args["cursor"] = cursor
+		}
+		res, out := call(args)
+		if res.IsError {
+			t.Fatalf("page %d: %s", pageNum, callResultText(t, res))
+		}
+		for _, hit := range out.Results {
+			if seen[hit.ID] {
+				t.Fatalf("page %d repeated #%d", pageNum, hit.ID)
+			}
+			seen[hit.ID] = true
+		}
+		if out.NextCursor == "" {
+			if pageNum != 3 {
+				t.Fatalf("expected 3 pages, stopped at %d", pageNum)
+			}
+			break
+		}
+		if !strings.Contains(callResultText(t, res), out.NextCursor) {
+			t.Fatalf("expected the text result to carry the cursor")
+		}
+		cursor = out.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Fatalf("expected to page through 5 results, saw %d", len(seen))
+	}
+
+	// The second page's cursor, replayed against another query, is refused.
+	_, first := call(map[string]any{"query": "retry", "project": "engram", "limit": 2.0})
+	res, _ := call(map[string]any{"query": "loop", "project": "engram", "cursor": first.NextCursor})
+	if !res.IsError || !strings.Contains(callResultText(t, res), "different search") {
+		t.Fatalf("expected a mismatched cursor error, got %q", callResultText(t, res))
+	}
+	res, _ = call(map[string]any{"query": "retry", "project": "engram", "cursor": "not-a-cursor"})
+	if !res.IsError || !strings.Contains(callResultText(t, res), "Invalid cursor") {
+		t.Fatalf("expected an invalid cursor error, got %q", callResultText(t, res))
+	}
+}
+
+func TestReadToolsReturnStructuredContent(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s-structured", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	long := strings.Repeat("x", 400)
+	obsID, err := s.AddObservation(store.AddObservationParams{
+		SessionID: "s-structured",
+		Type:      "decision",
+		Title:     "Structured output",
+		Content:   "structured payload " + long,
+		Project:   "engram",
+		Refs:      []string{"#42"},
+	})
+	if err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+
+	// structured round-trips the structuredContent through JSON, as a client
+	// would see it.
+	structured := func(t *testing.T, res *mcppkg.CallToolResult, out any) {
+		t.Helper()
+		if res.IsError {
+			t.Fatalf("unexpected tool error: %s", callResultText(t, res))
+		}
+		if res.StructuredContent == nil {
+			t.Fatalf("expected structured content")
+		}
+		raw, err := json.Marshal(res.StructuredContent)
+		if err != nil {
+			t.Fatalf("marshal structured content: %v", err)
+		}
+		if err := json.Unmarshal(raw, out); err != nil {
+			t.Fatalf("unmarshal structured content: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	searchRes, err := handleSearch(s, MCPConfig{}, NewSessionActivity(10*time.Minute))(ctx, mcppkg.CallToolRequest{
+		Params: mcppkg.CallToolParams{Arguments: map[string]any{"query": "structured", "project": "engram"}},
+	})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if !strings.Contains(callResultText(t, searchRes), "Found 1 memories") {
+		t.Fatalf("expected text block to stay first, got %q", callResultText(t, searchRes))
+	}
+	var search searchOutput
+	structured(t, searchRes, &search)
+	if search.Query != "structured" || search.Count != 1 || len(search.Results) != 1 {
+		t.Fatalf("unexpected search output: %+v", search)
+	}
+	hit := search.Results[0]
+	if hit.ID != obsID || hit.Type != "decision" || !hit.ContentTruncated || len(hit.Refs) != 1 || hit.Refs[0] != "#42" {
+		t.Fatalf("unexpected search hit: %+v", hit)
+	}
+
+	emptyRes, err := handleSearch(s, MCPConfig{}, NewSessionActivity(10*time.Minute))(ctx, mcppkg.CallToolRequest{
+		Params: mcppkg.CallToolParams{Arguments: map[string]any{"query": "nothingmatches", "project": "engram"}},
+	})
+	if err != nil {
+		t.Fatalf("empty search: %v", err)
+	}
+	var empty map[string]any
+	structured(t, emptyRes, &empty)
+	if results, ok := empty["results"].([]any); !ok || len(results) != 0 {
+		t.Fatalf("expected empty results array, got %v", empty["results"])
+	}
+
+	getRes, err := handleGetObservation(s, MCPConfig{})(ctx, mcppkg.CallToolRequest{
+		Params: mcppkg.CallToolParams{Arguments: map[string]any{"id": float64(obsID)}},
+	})
+	if err != nil {
+		t.Fatalf("get observation: %v", err)
+	}
+	var obs store.Observation
+	structured(t, getRes, &obs)
+	if obs.ID != obsID || !strings.HasSuffix(obs.Content, long) {
+		t.Fatalf("expected full observation content, got %+v", obs)
+	}
+
+	timelineRes, err := handleTimeline(s)(ctx, mcppkg.CallToolRequest{
+		Params: mcppkg.CallToolParams{Arguments: map[string]any{"observation_id": float64(obsID)}},
+	})
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	var timeline store.TimelineResult
+	structured(t, timelineRes, &timeline)
+	if timeline.Focus.ID != obsID || timeline.SessionInfo == nil || timeline.SessionInfo.ID != "s-structured" {
+		t.Fatalf("unexpected timeline output: %+v", timeline)
+	}
+
+	statsRes, err := handleStats(s)(ctx, mcppkg.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	var stats store.Stats
+	structured(t, statsRes, &stats)
+	if stats.TotalObservations != 1 || stats.ObservationsByType["decision"] != 1 {
+		t.Fatalf("unexpected stats output: %+v", stats)
+	}
+}
+
+func TestHandleForFileRecallsLinkedMemories(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s-file", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	id, err := s.AddObservation(store.AddObservationParams{
+		SessionID: "s-file", Type: "bugfix", Title: "Token refresh race",
+		Content: "**What**: lock around refresh\n**Where**: src/auth/middleware.ts", Project: "engram",
+	})
+	if err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+
+	handler := handleForFile(s, MCPConfig{DefaultProject: "engram"})
+	res, err := handler(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"path": "/home/dev/engram/src/auth/middleware.ts",
+	}}})
+	if err != nil {
+		t.Fatalf("for_file handler error: %v", err)
+	}
+	text := callResultText(t, res)
+	if !strings.Contains(text, "Found 1 memories mentioning /home/dev/engram/src/auth/middleware.ts") || !strings.Contains(text, "(bugfix) — Token refresh race") {
+		t.Fatalf("unexpected for_file output: %q", text)
+	}
+	out, ok := res.StructuredContent.(forFileOutput)
+	if !ok || out.Count != 1 || out.Results[0].ID != id {
+		t.Fatalf("unexpected structured content: %#v", res.StructuredContent)
+	}
+
+	res, err = handler(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"path": "src/other.go",
+	}}})
+	if err != nil || !strings.Contains(callResultText(t, res), "No memories mention src/other.go.") {
+		t.Fatalf("expected empty result, got %q %v", callResultText(t, res), err)
+	}
+
+	res, _ = handler(context.Background(), mcppkg.CallToolRequest{})
+	if !res.IsError {
+		t.Fatalf("expected error without path")
+	}
+}
+
+func TestHandleContextOutlineAndSection(t *testing.T) {
+	s := newMCPTestStore(t)
+	activity := NewSessionActivity(10 * time.Minute)
+	cfg := MCPConfig{DefaultProject: "engram"}
+
+	outlineHandler := handleContextOutline(s, cfg, activity)
+	res, err := outlineHandler(context.Background(), mcppkg.CallToolRequest{})
+	if err != nil || !strings.Contains(callResultText(t, res), "No previous session memories found.") {
+		t.Fatalf("expected empty outline, got %q %v", callResultText(t, res), err)
+	}
+
+	if err := s.CreateSession("s-outline", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := s.AddObservation(store.AddObservationParams{
+		SessionID: "s-outline", Type: "bugfix", Title: "Token expiry off by one", Content: "full bugfix body", Project: "engram",
+	}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+
+	res, err = outlineHandler(context.Background(), mcppkg.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("outline handler error: %v", err)
+	}
+	text := callResultText(t, res)
+	if !strings.Contains(text, "id: type:bugfix") || strings.Contains(text, "full bugfix body") || !strings.Contains(text, "mem_context_section") {
+		t.Fatalf("unexpected outline: %q", text)
+	}
+	if outline, ok := res.StructuredContent.(*store.ContextOutline); !ok || len(outline.Sections) != 2 {
+		t.Fatalf("unexpected structured outline: %#v", res.StructuredContent)
+	}
+
+	sectionHandler := handleContextSection(s, cfg, activity)
+	res, err = sectionHandler(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"id": "type:bugfix"}}})
+	if err != nil || !strings.Contains(callResultText(t, res), "full bugfix body") {
+		t.Fatalf("expected expanded section, got %q %v", callResultText(t, res), err)
+	}
+
+	res, _ = sectionHandler(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"id": "nope"}}})
+	if !res.IsError || !strings.Contains(callResultText(t, res), "unknown context section") {
+		t.Fatalf("expected unknown section error, got %q", callResultText(t, res))
+	}
+	res, _ = sectionHandler(context.Background(), mcppkg.CallToolRequest{})
+	if !res.IsError {
+		t.Fatalf("expected error without id")
+	}
+}
+
+func TestHandleTopicsListsTopicKeys(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s-topics", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	handler := handleTopics(s, MCPConfig{DefaultProject: "engram"})
+	emptyRes, err := handler(context.Background(), mcppkg.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("topics handler error: %v", err)
+	}
+	if !strings.Contains(callResultText(t, emptyRes), "No topic keys in use yet") {
+		t.Fatalf("expected empty topics message, got %q", callResultText(t, emptyRes))
+	}
+
+	for _, content := range []string{"first", "second"} {
+		if _, err := s.AddObservation(store.AddObservationParams{
+			SessionID: "s-topics",
+			Type:      "architecture",
+			Title:     "Auth model",
+			Content:   content,
+			Project:   "engram",
+			TopicKey:  "architecture/auth-model",
+		}); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+
+	res, err := handler(context.Background(), mcppkg.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("topics handler error: %v", err)
+	}
+	text := callResultText(t, res)
+	if !strings.Contains(text, "Found 1 topics") || !strings.Contains(text, "architecture/auth-model (project | project: engram)") || !strings.Contains(text, "revisions: 2") {
+		t.Fatalf("unexpected topics output: %q", text)
+	}
+	if res.StructuredContent == nil {
+		t.Fatalf("expected structured content")
+	}
+
+	otherRes, err := handler(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+		"project": "other",
+	}}})
+	if err != nil {
+		t.Fatalf("topics handler error: %v", err)
+	}
+	if !strings.Contains(callResultText(t, otherRes), "No topic keys") {
+		t.Fatalf("expected explicit project to override default, got %q", callResultText(t, otherRes))
+	}
+}
+
+func TestHandleTopicSearchSuggestsExistingKeys(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s-topic-search", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := s.AddObservation(store.AddObservationParams{
+		SessionID: "s-topic-search", Type: "architecture", Title: "Auth model",
+		Content: "JWT with refresh tokens", Project: "engram", TopicKey: "architecture/auth-model",
+	}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+
+	handler := handleTopicSearch(s, MCPConfig{DefaultProject: "engram"})
+	res, err := handler(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"query": "auth"}}})
+	if err != nil || res.IsError {
+		t.Fatalf("topic search failed: %q %v", callResultText(t, res), err)
+	}
+	text := callResultText(t, res)
+	if !strings.Contains(text, "Found 1 matching topic keys") || !strings.Contains(text, "architecture/auth-model") {
+		t.Fatalf("unexpected topic search output: %q", text)
+	}
+	if res.StructuredContent == nil {
+		t.Fatalf("expected structured content")
+	}
+
+	res, _ = handler(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"query": "billing"}}})
+	if !strings.Contains(callResultText(t, res), "mem_suggest_topic_key") {
+		t.Fatalf("expected a pointer to mem_suggest_topic_key, got %q", callResultText(t, res))
+	}
+	res, _ = handler(context.Background(), mcppkg.CallToolRequest{})
+	if !res.IsError {
+		t.Fatalf("expected error without query")
+	}
+}
+
+func TestHandleScratchLifecycle(t *testing.T) {
+	s := newMCPTestStore(t)
+	activity := NewSessionActivity(10 * time.Minute)
+	cfg := MCPConfig{DefaultProject: "engram"}
+	if err := s.CreateSession("s-scratch", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	args := func(m map[string]any) mcppkg.CallToolRequest {
+		m["session_id"] = "s-scratch"
+		return mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: m}}
+	}
+
+	set := handleScratchSet(s, cfg)
+	res, err := set(context.Background(), args(map[string]any{"key": "todo", "value": "- wire retries\n- add tests", "ttl": "2h"}))
+	if err != nil || res.IsError || !strings.Contains(callResultText(t, res), `Working memory "todo" set (expires`) {
+		t.Fatalf("set todo: %q %v", callResultText(t, res), err)
+	}
+	res, _ = set(context.Background(), args(map[string]any{"key": "Retries cap at five", "value": "Backoff beyond five attempts hid outages", "durable": true, "type": "decision"}))
+	if res.IsError || !strings.Contains(callResultText(t, res), "durable") {
+		t.Fatalf("set durable: %q", callResultText(t, res))
+	}
+	res, _ = set(context.Background(), args(map[string]any{"key": "x", "value": "y", "ttl": "soon"}))
+	if !res.IsError || !strings.Contains(callResultText(t, res), `invalid ttl "soon"`) {
+		t.Fatalf("expected ttl error, got %q", callResultText(t, res))
+	}
+
+	res, _ = handleScratchGet(s, cfg)(context.Background(), args(map[string]any{}))
+	text := callResultText(t, res)
+	if !strings.Contains(text, "(2 items)") || !strings.Contains(text, "## Retries cap at five [durable]") || !strings.Contains(text, "- wire retries") {
+		t.Fatalf("unexpected working memory: %q", text)
+	}
+
+	// Working memory stays out of long-term search.
+	if results, _ := s.Search("retries", store.SearchOptions{Project: "engram"}); len(results) != 0 {
+		t.Fatalf("working memory leaked into search: %+v", results)
+	}
+
+	res, _ = handleSessionEnd(s, cfg, activity)(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"id": "s-scratch"}}})
+	if !strings.Contains(callResultText(t, res), "1 durable working memory item(s) saved as observations") {
+		t.Fatalf("unexpected session end: %q", callResultText(t, res))
+	}
+	results, err := s.Search("retries", store.SearchOptions{Project: "engram", Type: "decision"})
+	if err != nil || len(results) != 1 || results[0].Title != "Retries cap at five" {
+		t.Fatalf("expected the durable item promoted, got %+v %v", results, err)
+	}
+	// Ending without a summary composes one from the promoted decision.
+	if results, _ := s.Search("retries", store.SearchOptions{Project: "engram", Type: "session_summary"}); len(results) != 1 {
+		t.Fatalf("expected an auto-generated session summary, got %+v", results)
+	}
+	res, _ = handleScratchGet(s, cfg)(context.Background(), args(map[string]any{}))
+	if !strings.Contains(callResultText(t, res), "is empty") {
+		t.Fatalf("working memory should be cleared at session end: %q", callResultText(t, res))
+	}
+
+	_, _ = set(context.Background(), args(map[string]any{"key": "a", "value": "1"}))
+	res, _ = handleScratchClear(s, cfg)(context.Background(), args(map[string]any{"key": "a"}))
+	if !strings.Contains(callResultText(t, res), "Cleared 1 working memory item(s)") {
+		t.Fatalf("unexpected clear: %q", callResultText(t, res))
+	}
+}
+
+func TestHandleToolRunRecordsStructuredRun(t *testing.T) {
+	s := newMCPTestStore(t)
+	cfg := MCPConfig{DefaultProject: "engram"}
+	h := handleToolRun(s, cfg, NewSessionActivity(10*time.Minute))
+	args := func(m map[string]any) mcppkg.CallToolRequest {
+		return mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: m}}
+	}
+
+	res, err := h(context.Background(), args(map[string]any{
+		"tool_name": "bash", "command": "go test ./...", "exit_code": 2.0, "duration_ms": 1200.0,
+		"files": "internal/a.go:+5/-1, README.md", "summary": "build failed",
+	}))
+	if err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if text := callResultText(t, res); res.IsError || !strings.Contains(text, "bash `go test ./...` → exit 2 in 1.2s · 2 files (+5/-1)") {
+		t.Fatalf("unexpected result: %q", text)
+	}
+	runs, err := s.SessionToolRuns(defaultSessionID("engram"), 0)
+	if err != nil || len(runs) != 1 || runs[0].Files[1].Path != "README.md" || runs[0].Summary != "build failed" {
+		t.Fatalf("unexpected stored runs: %+v err=%v", runs, err)
+	}
+
+	res, _ = h(context.Background(), args(map[string]any{"tool_name": "edit", "files": "a.go:+x/-1"}))
+	if !res.IsError || !strings.Contains(callResultText(t, res), "invalid file delta") {
+		t.Fatalf("expected invalid delta error, got %q", callResultText(t, res))
+	}
+	res, _ = h(context.Background(), args(map[string]any{"command": "ls"}))
+	if !res.IsError {
+		t.Fatalf("expected tool_name to be required")
+	}
+}
+
+func TestHandleVerifyMarksStaleAndShowsMetadata(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	id, err := s.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "decision", Title: "Use zap for logging", Content: "structured logging via zap", Project: "engram"})
+	if err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	cfg := MCPConfig{DefaultProject: "engram"}
+	args := func(m map[string]any) mcppkg.CallToolRequest {
+		return mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: m}}
+	}
+
+	res, _ := handleVerify(s, cfg, NewSessionActivity(10*time.Minute))(context.Background(), args(map[string]any{
+		"id": float64(id), "status": "stale", "evidence": "switched to slog", "paths": "internal/logging/logging.go, go.mod",
+	}))
+	if res.IsError || !strings.Contains(callResultText(t, res), "as stale") {
+		t.Fatalf("unexpected verify result: %q", callResultText(t, res))
+	}
+
+	res, _ = handleGetObservation(s, MCPConfig{})(context.Background(), args(map[string]any{"id": float64(id)}))
+	text := callResultText(t, res)
+	if !strings.Contains(text, "Stale since: ") || !strings.Contains(text, "Last verified: ") ||
+		!strings.Contains(text, "Evidence: switched to slog\nChecked: internal/logging/logging.go, go.mod") {
+		t.Fatalf("expected verification metadata, got %q", text)
+	}
+
+	res, _ = handleSearch(s, cfg, NewSessionActivity(10*time.Minute))(context.Background(), args(map[string]any{"query": "logging"}))
+	if text := callResultText(t, res); !strings.Contains(text, "Use zap for logging [stale]") || !strings.Contains(text, "| verified: ") {
+		t.Fatalf("expected stale marker in search output, got %q", text)
+	}
+
+	res, _ = handleVerify(s, cfg, NewSessionActivity(10*time.Minute))(context.Background(), args(map[string]any{"id": 4242.0}))
+	if !res.IsError || !strings.Contains(callResultText(t, res), "not found") {
+		t.Fatalf("expected not found error, got %q", callResultText(t, res))
+	}
+}
+
+func TestHandleDeleteBulkRequiresConfirm(t *testing.T) {
+	s := newMCPTestStore(t)
+	if err := s.CreateSession("s-bulk", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	for _, title := range []string{"Auth v1", "Auth v2"} {
+		if _, err := s.AddObservation(store.AddObservationParams{SessionID: "s-bulk", Type: "decision", Title: title, Content: "auth notes", Project: "engram"}); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+	call := func(m map[string]any) *mcppkg.CallToolResult {
+		t.Helper()
+		res, err := handleDelete(s)(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: m}})
+		if err != nil {
+			t.Fatalf("handler error: %v", err)
+		}
+		return res
+	}
+
+	res := call(map[string]any{"session_id": "s-bulk"})
+	if text := callResultText(t, res); !strings.Contains(text, "Dry run: 2 memories match") || !strings.Contains(text, "confirm=true") {
+		t.Fatalf("expected a dry run, got %q", text)
+	}
+	if obs, _ := s.AllObservations("engram", "", 10); len(obs) != 2 {
+		t.Fatalf("dry run deleted memories")
+	}
+
+	if res := call(map[string]any{"session_id": "s-bulk", "hard_delete": true, "confirm": true}); !res.IsError {
+		t.Fatalf("expected hard bulk delete to be rejected")
+	}
+	if res := call(map[string]any{"id": 1.0, "session_id": "s-bulk"}); !res.IsError {
+		t.Fatalf("expected id plus filter to be rejected")
+	}
+
+	res = call(map[string]any{"session_id": "s-bulk", "confirm": true})
+	if text := callResultText(t, res); !strings.Contains(text, "Soft-deleted 2 memories") {
+		t.Fatalf("expected the delete to run, got %q", text)
+	}
+	if obs, _ := s.AllObservations("engram", "", 10); len(obs) != 0 {
+		t.Fatalf("expected everything deleted, got %d", len(obs))
+	}
+}
+
+func TestHandleSaveRecordsClientSource(t *testing.T) {
+	s := newMCPTestStore(t)
+	h := handleSave(s, MCPConfig{}, NewSessionActivity(10*time.Minute))
+
+	session := server.NewInProcessSession("client-1", nil)
+	session.SetClientInfo(mcppkg.Implementation{Name: "Claude Code", Version: "2.0"})
+	ctx := server.NewMCPServer("engram", "test").WithContext(context.Background(), session)
+
+	save := func(ctx context.Context, title string) {
+		t.Helper()
+		res, err := h(ctx, mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+			"title": title, "content": title + " source test", "type": "decision", "project": "engram",
+		}}})
+		if err != nil || res.IsError {
+			t.Fatalf("save %q failed: err=%v", title, err)
+		}
+	}
+	save(ctx, "Named client")
+	save(context.Background(), "Anonymous client")
+
+	search := handleSearch(s, MCPConfig{}, NewSessionActivity(10*time.Minute))
+	for source, want := range map[string]string{"mcp:claude-code": "Named client", "mcp": "Anonymous client"} {
+		res, err := search(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{
+			"query": "source", "project": "engram", "source": source,
+		}}})
+		if err != nil || res.IsError {
+			t.Fatalf("search failed: err=%v", err)
+		}
+		if text := callResultText(t, res); !strings.Contains(text, want) {
+			t.Fatalf("source %q: expected %q in results, got %q", source, want, text)
+		}
+	}
+
+	results, err := s.Search("anonymous", store.SearchOptions{Project: "engram"})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("search: %d results err=%v", len(results), err)
+	}
+	get := handleGetObservation(s, MCPConfig{})
+	res, err := get(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: map[string]any{"id": float64(results[0].ID)}}})
+	if err != nil || !strings.Contains(callResultText(t, res), "Source: mcp\n") {
+		t.Fatalf("expected the source in mem_get_observation, got %q err=%v", callResultText(t, res), err)
+	}
+}
+
+func TestHandleSessionStartLinksParent(t *testing.T) {
+	s := newMCPTestStore(t)
+	start := handleSessionStart(s, MCPConfig{}, NewSessionActivity(10*time.Minute))
+	call := func(args map[string]any) *mcppkg.CallToolResult {
+		t.Helper()
+		res, err := start(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Arguments: args}})
+		if err != nil {
+			t.Fatalf("session start handler error: %v", err)
+		}
+		return res
+	}
+
+	if res := call(map[string]any{"id": "before-compact", "project": "engram"}); res.IsError {
+		t.Fatalf("unexpected error: %s", callResultText(t, res))
+	}
+	res := call(map[string]any{"id": "after-compact", "project": "engram", "parent_session_id": "before-compact"})
+	if res.IsError || !strings.Contains(callResultText(t, res), `continuing "before-compact"`) {
+		t.Fatalf("unexpected result: %s", callResultText(t, res))
+	}
+	if sess, err := s.GetSession("after-compact"); err != nil || sess.ParentSessionID == nil || *sess.ParentSessionID != "before-compact" {
+		t.Fatalf("expected parent link, got %+v err=%v", sess, err)
+	}
+
+	if res := call(map[string]any{"id": "orphan", "project": "engram", "parent_session_id": "nope"}); !res.IsError {
+		t.Fatalf("expected an error for a missing parent session")
+	}
+}
+
+func TestReadOnlyServerRegistersAndAnswersOnlyReadTools(t *testing.T) {
+	s := newMCPTestStore(t)
+
+	all := NewServer(s).ListTools()
+	for name := range ReadOnlyTools {
+		tool := all[name]
+		if tool == nil {
+			t.Fatalf("read-only tool %q is not registered by the full server", name)
+		}
+		if hint := tool.Tool.Annotations.ReadOnlyHint; hint == nil || !*hint {
+			t.Fatalf("read-only tool %q is not annotated read-only", name)
+		}
+	}
+
+	tools := NewServerWithConfig(s, MCPConfig{ReadOnly: true}, nil).ListTools()
+	if len(tools) != len(ReadOnlyTools) {
+		t.Fatalf("expected %d read-only tools, got %d", len(ReadOnlyTools), len(tools))
+	}
+	for _, name := range []string{"mem_save", "mem_update", "mem_delete", "mem_session_summary", "mem_merge_projects"} {
+		if tools[name] != nil {
+			t.Fatalf("write tool %q registered on a read-only server", name)
+		}
+	}
+
+	// --tools still narrows within the read-only set.
+	tools = NewServerWithConfig(s, MCPConfig{ReadOnly: true}, ResolveTools("admin")).ListTools()
+	if len(tools) != 2 || tools["mem_stats"] == nil || tools["mem_timeline"] == nil {
+		t.Fatalf("expected only the read-only admin tools, got %d", len(tools))
+	}
+
+	// The handler-level guard refuses a write tool even if it gets registered.
+	called := false
+	guarded := rejectWrites(func(ctx context.Context, req mcppkg.CallToolRequest) (*mcppkg.CallToolResult, error) {
+		called = true
+		return mcppkg.NewToolResultText("ok"), nil
+	})
+	req := mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Name: "mem_save"}}
+	res, err := guarded(context.Background(), req)
+	if err != nil || !res.IsError || called || !strings.Contains(callResultText(t, res), "read-only") {
+		t.Fatalf("expected mem_save to be refused, got %+v, %v", res, err)
+	}
+	req.Params.Name = "mem_search"
+	if res, err := guarded(context.Background(), req); err != nil || res.IsError || !called {
+		t.Fatalf("expected mem_search to pass through, got %+v, %v", res, err)
+	}
+}
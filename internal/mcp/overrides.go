@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ─── Tool Overrides ──────────────────────────────────────────────────────────
+//
+// Different models respond to different phrasing, so the text agents read —
+// tool descriptions, titles, hints, and parameter descriptions — can be
+// replaced from a tools.toml file without rebuilding. Overrides only change
+// what is advertised; handlers and parameter names stay the same.
+
+// ToolOverride replaces parts of one tool's definition. Empty strings and
+// nil hints keep the built-in value.
+type ToolOverride struct {
+	Description string
+	Title       string
+	ReadOnly    *bool
+	Destructive *bool
+	Idempotent  *bool
+	OpenWorld   *bool
+	// Params maps a parameter name to its new description.
+	Params map[string]string
+}
+
+// ValidateToolOverrides checks every override against the built-in tool
+// definitions, so a typo in a tool or parameter name fails at startup
+// instead of silently doing nothing.
+func ValidateToolOverrides(overrides map[string]ToolOverride) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	tools := referenceTools()
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		st, ok := tools[name]
+		if !ok {
+			return fmt.Errorf("engram tools: unknown tool %q", name)
+		}
+		for param := range overrides[name].Params {
+			if _, ok := st.Tool.InputSchema.Properties[param]; !ok {
+				return fmt.Errorf("engram tools: %s has no parameter %q", name, param)
+			}
+		}
+	}
+	return nil
+}
+
+// referenceTools returns the built-in tool definitions. Handlers are never
+// called, so the reference set needs no store.
+func referenceTools() map[string]*server.ServerTool {
+	reference := server.NewMCPServer("engram", "0.1.0")
+	registerTools(reference, nil, MCPConfig{}, nil, nil)
+	return reference.ListTools()
+}
+
+// applyToolOverrides re-registers each overridden tool with its new
+// definition. Tools left out by the allowlist are skipped.
+func applyToolOverrides(srv *server.MCPServer, overrides map[string]ToolOverride) {
+	for name, o := range overrides {
+		st := srv.GetTool(name)
+		if st == nil {
+			continue
+		}
+		srv.AddTool(o.apply(st.Tool), st.Handler)
+	}
+}
+
+func (o ToolOverride) apply(tool mcp.Tool) mcp.Tool {
+	if o.Description != "" {
+		tool.Description = o.Description
+	}
+	if o.Title != "" {
+		tool.Annotations.Title = o.Title
+	}
+	for hint, value := range map[**bool]*bool{
+		&tool.Annotations.ReadOnlyHint:    o.ReadOnly,
+		&tool.Annotations.DestructiveHint: o.Destructive,
+		&tool.Annotations.IdempotentHint:  o.Idempotent,
+		&tool.Annotations.OpenWorldHint:   o.OpenWorld,
+	} {
+		if value != nil {
+			v := *value
+			*hint = &v
+		}
+	}
+	if len(o.Params) == 0 {
+		return tool
+	}
+
+	// The property maps are shared with the original definition; copy
+	// before editing.
+	props := maps.Clone(tool.InputSchema.Properties)
+	for param, desc := range o.Params {
+		prop, ok := props[param].(map[string]any)
+		if !ok {
+			continue
+		}
+		prop = maps.Clone(prop)
+		prop["description"] = desc
+		props[param] = prop
+	}
+	tool.InputSchema.Properties = props
+	return tool
+}
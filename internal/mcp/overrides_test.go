@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	mcppkg "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestToolOverridesReplaceAdvertisedText(t *testing.T) {
+	s := newMCPTestStore(t)
+	yes := true
+	srv := NewServerWithConfig(s, MCPConfig{ToolOverrides: map[string]ToolOverride{
+		"mem_save": {
+			Description: "Save what you learned. Keep it short.",
+			Title:       "Remember",
+			Params:      map[string]string{"content": "One line each: What, Why, Where"},
+		},
+		"mem_delete": {Destructive: &yes},
+	}}, ResolveTools("agent"))
+
+	save := srv.GetTool("mem_save")
+	if save == nil {
+		t.Fatal("expected mem_save to stay registered")
+	}
+	if save.Tool.Description != "Save what you learned. Keep it short." || save.Tool.Annotations.Title != "Remember" {
+		t.Fatalf("expected overridden description and title, got %q / %q", save.Tool.Description, save.Tool.Annotations.Title)
+	}
+	content := save.Tool.InputSchema.Properties["content"].(map[string]any)
+	if content["description"] != "One line each: What, Why, Where" {
+		t.Fatalf("expected overridden param description, got %v", content["description"])
+	}
+	title := save.Tool.InputSchema.Properties["title"].(map[string]any)
+	if !strings.Contains(title["description"].(string), "searchable") {
+		t.Fatalf("expected other params to keep their description, got %v", title["description"])
+	}
+	if srv.GetTool("mem_delete") != nil {
+		t.Fatalf("expected overrides not to register tools left out by the allowlist")
+	}
+
+	// The handler survives re-registration.
+	res, err := save.Handler(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{
+		Arguments: map[string]any{"title": "Override check", "content": "**What**: still saves", "project": "engram"},
+	}})
+	if err != nil || res.IsError {
+		t.Fatalf("expected mem_save to keep working, got %v, %v", res, err)
+	}
+
+	// The built-in definition is untouched for other servers.
+	plain := NewServerWithConfig(s, MCPConfig{}, nil).GetTool("mem_save")
+	if plain.Tool.Description == save.Tool.Description {
+		t.Fatalf("expected overrides not to leak into other servers")
+	}
+	plainContent := plain.Tool.InputSchema.Properties["content"].(map[string]any)
+	if plainContent["description"] == "One line each: What, Why, Where" {
+		t.Fatalf("expected param overrides not to leak into other servers")
+	}
+}
+
+func TestValidateToolOverrides(t *testing.T) {
+	if err := ValidateToolOverrides(map[string]ToolOverride{"mem_save": {Params: map[string]string{"content": "x"}}}); err != nil {
+		t.Fatalf("expected valid overrides, got %v", err)
+	}
+	if err := ValidateToolOverrides(map[string]ToolOverride{"mem_sav": {Description: "x"}}); err == nil || !strings.Contains(err.Error(), `unknown tool "mem_sav"`) {
+		t.Fatalf("expected unknown tool error, got %v", err)
+	}
+	if err := ValidateToolOverrides(map[string]ToolOverride{"mem_save": {Params: map[string]string{"body": "x"}}}); err == nil || !strings.Contains(err.Error(), `no parameter "body"`) {
+		t.Fatalf("expected unknown parameter error, got %v", err)
+	}
+}
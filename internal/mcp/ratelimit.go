@@ -0,0 +1,190 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ─── Rate Limits ─────────────────────────────────────────────────────────────
+//
+// An agent stuck in a loop can call mem_save thousands of times a minute.
+// Rate limits cap the calls to a tool per MCP session over a sliding
+// window; a call past the limit is not run and returns a tool error telling
+// the agent to back off, so the loop shows up in the transcript instead of
+// in the database.
+
+// RateLimit allows Calls calls to a tool within any window of Per. The
+// zero value is unlimited.
+type RateLimit struct {
+	Calls int
+	Per   time.Duration
+}
+
+// DefaultRateLimits cap the tools that write a row on every call. Other
+// tools are unlimited unless configured.
+var DefaultRateLimits = map[string]RateLimit{
+	"mem_save":            {Calls: 30, Per: time.Minute},
+	"mem_capture_passive": {Calls: 10, Per: time.Minute},
+}
+
+// ParseRateLimit reads a limit written as CALLS/PER, such as "30/1m" or
+// "500/1h". "off" and "0" are unlimited.
+func ParseRateLimit(raw string) (RateLimit, error) {
+	value := strings.TrimSpace(raw)
+	if value == "off" || value == "0" {
+		return RateLimit{}, nil
+	}
+	calls, per, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q (want CALLS/PER, e.g. 30/1m, or off)", raw)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(calls))
+	if err != nil || n <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: calls must be a positive number", raw)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || d < time.Second {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: period must be a duration of at least 1s", raw)
+	}
+	return RateLimit{Calls: n, Per: d}, nil
+}
+
+// Unlimited reports whether l lets every call through.
+func (l RateLimit) Unlimited() bool {
+	return l.Calls <= 0 || l.Per <= 0
+}
+
+// String formats l the way ParseRateLimit reads it.
+func (l RateLimit) String() string {
+	if l.Unlimited() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Calls, shortDuration(l.Per))
+}
+
+// ValidateRateLimits checks that every limit names a built-in tool, so a
+// typo fails at startup instead of leaving the tool unlimited.
+func ValidateRateLimits(limits map[string]RateLimit) error {
+	if len(limits) == 0 {
+		return nil
+	}
+	tools := referenceTools()
+	names := make([]string, 0, len(limits))
+	for name := range limits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := tools[name]; !ok {
+			return fmt.Errorf("unknown tool %q", name)
+		}
+	}
+	return nil
+}
+
+// rateLimiter counts recent calls per tool and MCP session. Sessions come
+// and go over a long-running server, so keys whose window has passed are
+// swept out at most once per the longest window.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limits map[string]RateLimit
+	calls  map[rateKey][]time.Time
+	now    func() time.Time // injectable for testing
+	swept  time.Time
+}
+
+type rateKey struct{ tool, session string }
+
+func newRateLimiter(limits map[string]RateLimit) *rateLimiter {
+	return &rateLimiter{limits: limits, calls: make(map[rateKey][]time.Time), now: time.Now}
+}
+
+// allow records a call to tool from session. Over the limit it records
+// nothing and returns how long until the oldest counted call leaves the
+// window.
+func (l *rateLimiter) allow(tool, session string) (time.Duration, bool) {
+	limit := l.limits[tool]
+	if limit.Unlimited() {
+		return 0, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	key := rateKey{tool: tool, session: session}
+	recent := l.calls[key]
+	cutoff := now.Add(-limit.Per)
+	for len(recent) > 0 && !recent[0].After(cutoff) {
+		recent = recent[1:]
+	}
+	if len(recent) >= limit.Calls {
+		l.calls[key] = recent
+		return recent[0].Add(limit.Per).Sub(now), false
+	}
+	l.calls[key] = append(recent, now)
+	return 0, true
+}
+
+// sweep drops the keys whose calls have all left their tool's window, once
+// the longest window has passed since the last sweep. l.mu must be held.
+func (l *rateLimiter) sweep(now time.Time) {
+	var longest time.Duration
+	for _, limit := range l.limits {
+		longest = max(longest, limit.Per)
+	}
+	if now.Sub(l.swept) < longest {
+		return
+	}
+	l.swept = now
+	for key, recent := range l.calls {
+		if len(recent) == 0 || !recent[len(recent)-1].After(now.Add(-l.limits[key.tool].Per)) {
+			delete(l.calls, key)
+		}
+	}
+}
+
+// middleware refuses calls over their tool's limit with a tool error the
+// agent can act on.
+func (l *rateLimiter) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := req.Params.Name
+		wait, ok := l.allow(name, clientSessionID(ctx))
+		if !ok {
+			limit := l.limits[name]
+			return mcp.NewToolResultError(fmt.Sprintf(
+				"rate limit exceeded: %s allows %d calls per %s and this call was not run. Stop calling it in a loop: wait %s before calling it again, and fold related findings into one call.",
+				name, limit.Calls, shortDuration(limit.Per), shortDuration((wait + time.Second - 1).Truncate(time.Second)))), nil
+		}
+		return next(ctx, req)
+	}
+}
+
+// clientSessionID identifies the MCP client session of a call, or "" when
+// there is none.
+func clientSessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// shortDuration formats whole hours, minutes, or seconds without the zero
+// units time.Duration.String adds: 1m rather than 1m0s.
+func shortDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.String()
+}
@@ -0,0 +1,123 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	mcppkg "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRateLimiterRefusesCallsPastTheLimit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(map[string]RateLimit{"mem_save": {Calls: 2, Per: time.Minute}})
+	limiter.now = func() time.Time { return now }
+
+	calls := 0
+	handler := limiter.middleware(func(ctx context.Context, req mcppkg.CallToolRequest) (*mcppkg.CallToolResult, error) {
+		calls++
+		return mcppkg.NewToolResultText("ok"), nil
+	})
+	call := func(name string) *mcppkg.CallToolResult {
+		t.Helper()
+		res, err := handler(context.Background(), mcppkg.CallToolRequest{Params: mcppkg.CallToolParams{Name: name}})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return res
+	}
+
+	for range 2 {
+		if res := call("mem_save"); res.IsError {
+			t.Fatalf("expected calls within the limit to run, got %s", callResultText(t, res))
+		}
+		now = now.Add(20 * time.Second)
+	}
+	res := call("mem_save")
+	text := callResultText(t, res)
+	if !res.IsError || calls != 2 || !strings.Contains(text, "mem_save allows 2 calls per 1m") || !strings.Contains(text, "wait 20s") {
+		t.Fatalf("expected the third call to be refused with a wait, got %q (calls=%d)", text, calls)
+	}
+	if res := call("mem_search"); res.IsError || calls != 3 {
+		t.Fatalf("expected tools without a limit to run, got %s", callResultText(t, res))
+	}
+
+	// Refused calls are not counted: once the first call leaves the window,
+	// exactly one more fits.
+	now = now.Add(20 * time.Second)
+	if res := call("mem_save"); res.IsError {
+		t.Fatalf("expected a call after the window slid to run, got %s", callResultText(t, res))
+	}
+	if res := call("mem_save"); !res.IsError {
+		t.Fatal("expected the next call to be refused again")
+	}
+
+	// Sessions are limited separately.
+	if _, ok := limiter.allow("mem_save", "other-session"); !ok {
+		t.Fatal("expected another session to have its own budget")
+	}
+}
+
+func TestRateLimiterEvictsExpiredSessions(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(map[string]RateLimit{
+		"mem_save":            {Calls: 5, Per: time.Minute},
+		"mem_capture_passive": {Calls: 5, Per: 10 * time.Minute},
+	})
+	limiter.now = func() time.Time { return now }
+
+	for _, session := range []string{"a", "b", "c"} {
+		limiter.allow("mem_save", session)
+	}
+	limiter.allow("mem_capture_passive", "a")
+	if len(limiter.calls) != 4 {
+		t.Fatalf("expected a key per tool and session, got %d", len(limiter.calls))
+	}
+
+	// Past the longest window, sessions that went quiet are dropped.
+	now = now.Add(10 * time.Minute)
+	limiter.allow("mem_save", "d")
+	if len(limiter.calls) != 1 {
+		t.Fatalf("expected only the live session to be kept, got %v", limiter.calls)
+	}
+
+	// A key still inside its window survives a sweep.
+	now = now.Add(9 * time.Minute)
+	limiter.allow("mem_capture_passive", "e")
+	now = now.Add(time.Minute)
+	limiter.allow("mem_save", "f")
+	if _, ok := limiter.calls[rateKey{tool: "mem_capture_passive", session: "e"}]; !ok || len(limiter.calls) != 2 {
+		t.Fatalf("expected the recent session to survive the sweep, got %v", limiter.calls)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	tests := map[string]RateLimit{
+		"30/1m":    {Calls: 30, Per: time.Minute},
+		" 5 / 1h ": {Calls: 5, Per: time.Hour},
+		"off":      {},
+		"0":        {},
+	}
+	for raw, want := range tests {
+		got, err := ParseRateLimit(raw)
+		if err != nil || got != want {
+			t.Errorf("ParseRateLimit(%q) = %+v, %v; want %+v", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"30", "x/1m", "-1/1m", "10/fast", "10/10ms"} {
+		if _, err := ParseRateLimit(raw); err == nil {
+			t.Errorf("expected %q to be rejected", raw)
+		}
+	}
+	if s := (RateLimit{Calls: 30, Per: time.Minute}).String(); s != "30/1m" {
+		t.Errorf("expected 30/1m, got %q", s)
+	}
+
+	if err := ValidateRateLimits(DefaultRateLimits); err != nil {
+		t.Fatalf("expected the defaults to name real tools: %v", err)
+	}
+	if err := ValidateRateLimits(map[string]RateLimit{"mem_sav": {Calls: 1, Per: time.Minute}}); err == nil || !strings.Contains(err.Error(), `"mem_sav"`) {
+		t.Fatalf("expected an unknown tool to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Desktop shows notifications with the platform's own tool: osascript on
+// macOS, notify-send on Linux and the BSDs, and a PowerShell toast on
+// Windows.
+type Desktop struct {
+	goos string
+	run  func(name string, args, env []string) error
+}
+
+// NewDesktop returns a Desktop for this platform, or an error when the
+// platform has no supported notification tool installed.
+func NewDesktop() (*Desktop, error) {
+	name, _, _ := desktopCommand(runtime.GOOS, Notification{})
+	if name == "" {
+		return nil, fmt.Errorf("engram notify: desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("engram notify: %s not found: %w", name, err)
+	}
+	return &Desktop{goos: runtime.GOOS, run: runCommand}, nil
+}
+
+// Notify shows n.
+func (d *Desktop) Notify(n Notification) error {
+	name, args, env := desktopCommand(d.goos, n)
+	if err := d.run(name, args, env); err != nil {
+		return fmt.Errorf("engram notify: %s: %w", name, err)
+	}
+	return nil
+}
+
+func runCommand(name string, args, env []string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// desktopCommand returns the command that shows n on goos, or an empty
+// name when goos is unsupported. Text reaches PowerShell through the
+// environment so it never needs quoting.
+func desktopCommand(goos string, n Notification) (name string, args, env []string) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(n.Body), appleScriptString(n.Title))
+		return "osascript", []string{"-e", script}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return "notify-send", []string{"--app-name=engram", n.Title, n.Body}, nil
+	case "windows":
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", windowsToast},
+			[]string{"ENGRAM_NOTIFY_TITLE=" + n.Title, "ENGRAM_NOTIFY_BODY=" + n.Body}
+	}
+	return "", nil, nil
+}
+
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+const windowsToast = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode($env:ENGRAM_NOTIFY_TITLE)) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode($env:ENGRAM_NOTIFY_BODY)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('engram').Show($toast)
+`
@@ -0,0 +1,272 @@
+// Package notify pops desktop notifications for memory events worth a
+// human's attention while `engram serve` is running.
+//
+// A Watcher polls the store for new observations, so events are seen no
+// matter which process wrote them: a session summary saved over MCP, or a
+// teammate's decision brought in by `engram sync --import`. Each event kind
+// can be switched off, and sync imports are filtered by observation type.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// Event kinds.
+const (
+	// EventSessionSummary fires when an agent saves a session summary.
+	EventSessionSummary = "session_summary"
+	// EventSyncImport fires when a sync import brings in observations of
+	// the watched types.
+	EventSyncImport = "sync_import"
+)
+
+// Kinds lists every event kind.
+var Kinds = []string{EventSessionSummary, EventSyncImport}
+
+// Defaults used for zero Options fields.
+const (
+	DefaultInterval = 30 * time.Second
+	// MinInterval keeps the poll from hammering the database.
+	MinInterval = time.Second
+)
+
+// DefaultSyncTypes are the imported observation types that notify.
+var DefaultSyncTypes = []string{"decision", "architecture"}
+
+// Options configures a Watcher.
+type Options struct {
+	// Events lists the enabled kinds; nil enables all of them.
+	Events []string
+	// SyncTypes limits EventSyncImport to these observation types.
+	SyncTypes []string
+	Interval  time.Duration
+	// Label is appended to notification titles, e.g. a mount name.
+	Label string
+}
+
+// validate fills defaults and rejects unknown kinds and short intervals.
+func (o Options) validate() (Options, error) {
+	o = o.withDefaults()
+	for _, kind := range o.Events {
+		if !slices.Contains(Kinds, kind) {
+			return o, fmt.Errorf("engram notify: unknown event %q (want one of %s)", kind, strings.Join(Kinds, ", "))
+		}
+	}
+	if o.Interval < MinInterval {
+		return o, fmt.Errorf("engram notify: interval %s is shorter than %s", o.Interval, MinInterval)
+	}
+	return o, nil
+}
+
+func (o Options) withDefaults() Options {
+	if o.Events == nil {
+		o.Events = Kinds
+	}
+	if len(o.SyncTypes) == 0 {
+		o.SyncTypes = DefaultSyncTypes
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultInterval
+	}
+	return o
+}
+
+// Notification is one message shown to the user.
+type Notification struct {
+	Kind  string
+	Title string
+	Body  string
+}
+
+// Notifier shows notifications.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// Watcher turns new observations into notifications.
+type Watcher struct {
+	store    *store.Store
+	notifier Notifier
+	logger   *slog.Logger
+	lastID   int64
+
+	mu   sync.Mutex // guards opts; see SetOptions
+	opts Options
+}
+
+// New returns a Watcher that only reports observations saved after it was
+// created.
+func New(s *store.Store, notifier Notifier, opts Options, logger *slog.Logger) (*Watcher, error) {
+	opts, err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	lastID, err := s.LastObservationID()
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{store: s, notifier: notifier, opts: opts, logger: logger, lastID: lastID}, nil
+}
+
+// Run polls every interval until ctx is cancelled. An interval changed by
+// SetOptions takes effect after the next poll.
+func (w *Watcher) Run(ctx context.Context) {
+	interval := w.interval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := w.Poll(); err != nil {
+			w.logger.Warn("notify poll failed", "err", err)
+		}
+		if next := w.interval(); next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+	}
+}
+
+// SetOptions replaces the watcher's options, for a config reload. Invalid
+// options are rejected and the current ones kept.
+func (w *Watcher) SetOptions(opts Options) error {
+	opts, err := opts.validate()
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.opts = opts
+	return nil
+}
+
+func (w *Watcher) interval() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.opts.Interval
+}
+
+// Poll sends notifications for observations saved since the last poll and
+// returns them. A notifier failure is logged, not returned: the desktop
+// being unavailable should not stall the watcher.
+func (w *Watcher) Poll() ([]Notification, error) {
+	var sent []Notification
+	for {
+		batch, err := w.store.ObservationsAfter(w.lastID, 200)
+		if err != nil {
+			return sent, err
+		}
+		if len(batch) == 0 {
+			return sent, nil
+		}
+		w.lastID = batch[len(batch)-1].ID
+		for _, n := range w.notifications(batch) {
+			if err := w.notifier.Notify(n); err != nil {
+				w.logger.Warn("notification failed", "kind", n.Kind, "err", err)
+				continue
+			}
+			sent = append(sent, n)
+		}
+	}
+}
+
+// notifications groups a batch: one per session summary, and one per
+// project for sync imports so a large pull does not flood the desktop.
+func (w *Watcher) notifications(batch []store.Observation) []Notification {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var out []Notification
+	imported := map[string][]store.Observation{}
+	var projects []string
+	for _, obs := range batch {
+		project := ""
+		if obs.Project != nil {
+			project = *obs.Project
+		}
+		switch {
+		case obs.Source != nil && *obs.Source == store.SourceSyncImport:
+			if !w.enabled(EventSyncImport) || !slices.Contains(w.opts.SyncTypes, obs.Type) {
+				continue
+			}
+			if _, ok := imported[project]; !ok {
+				projects = append(projects, project)
+			}
+			imported[project] = append(imported[project], obs)
+		case obs.Type == "session_summary":
+			if !w.enabled(EventSessionSummary) {
+				continue
+			}
+			out = append(out, Notification{
+				Kind:  EventSessionSummary,
+				Title: w.title("Session summary saved", project),
+				Body:  firstLine(obs.Content),
+			})
+		}
+	}
+	for _, project := range projects {
+		obs := imported[project]
+		body := obs[0].Title
+		if len(obs) > 1 {
+			body = fmt.Sprintf("%s (+%d more)", body, len(obs)-1)
+		}
+		out = append(out, Notification{
+			Kind:  EventSyncImport,
+			Title: w.title(fmt.Sprintf("%d new %s from sync", len(obs), plural(len(obs), "memory", "memories")), project),
+			Body:  body,
+		})
+	}
+	return out
+}
+
+func (w *Watcher) enabled(kind string) bool {
+	return slices.Contains(w.opts.Events, kind)
+}
+
+func (w *Watcher) title(what, project string) string {
+	title := "engram: " + what
+	if project != "" {
+		title += " — " + project
+	}
+	if w.opts.Label != "" {
+		title += " [" + w.opts.Label + "]"
+	}
+	return title
+}
+
+// firstLine returns the first line of content that is not a heading,
+// shortened for a notification body.
+func firstLine(content string) string {
+	for line := range strings.SplitSeq(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if r := []rune(line); len(r) > 120 {
+			line = string(r[:117]) + "..."
+		}
+		return line
+	}
+	return ""
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+	"github.com/Gentleman-Programming/engram/internal/store/storetest"
+)
+
+func quietLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+type recorder struct {
+	got []Notification
+	err error
+}
+
+func (r *recorder) Notify(n Notification) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.got = append(r.got, n)
+	return nil
+}
+
+func addObservation(t *testing.T, s *store.Store, typ, title, content string) {
+	t.Helper()
+	if _, err := s.AddObservation(store.AddObservationParams{
+		SessionID: "s1", Type: typ, Title: title, Content: content, Project: "engram",
+	}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+}
+
+func importDecisions(t *testing.T, s *store.Store, titles ...string) {
+	t.Helper()
+	project := "engram"
+	data := &store.ExportData{Version: "test"}
+	for _, title := range titles {
+		data.Observations = append(data.Observations, store.Observation{
+			SyncID: "sync-" + title, SessionID: "s1", Type: "decision", Title: title,
+			Content: title + " content", Project: &project, Scope: "project",
+			CreatedAt: "2026-01-01 10:00:00", UpdatedAt: "2026-01-01 10:00:00",
+		})
+	}
+	if _, err := s.ImportWithOptions(data, store.ImportOptions{Source: store.SourceSyncImport}); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+}
+
+func TestWatcherNotifiesNewSummariesAndSyncedDecisions(t *testing.T) {
+	s := storetest.New(t)
+	if err := s.CreateSession("s1", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	addObservation(t, s, "session_summary", "Old summary", "## Goal\nFrom before the watcher started")
+
+	rec := &recorder{}
+	w, err := New(s, rec, Options{}, quietLogger())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if sent, err := w.Poll(); err != nil || len(sent) != 0 {
+		t.Fatalf("expected existing observations to be skipped, got %+v, %v", sent, err)
+	}
+
+	addObservation(t, s, "session_summary", "Session summary: engram", "## Goal\nShip paging for mem_search\n\n## Accomplished\n- done")
+	addObservation(t, s, "bugfix", "Local fix", "not worth a notification")
+	importDecisions(t, s, "Use advisory locks", "Drop the queue")
+
+	sent, err := w.Poll()
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("expected a summary and one grouped sync notification, got %+v", sent)
+	}
+	if sent[0].Kind != EventSessionSummary || sent[0].Body != "Ship paging for mem_search" || !strings.Contains(sent[0].Title, "engram") {
+		t.Fatalf("unexpected summary notification: %+v", sent[0])
+	}
+	if sent[1].Kind != EventSyncImport || !strings.Contains(sent[1].Title, "2 new memories") || sent[1].Body != "Use advisory locks (+1 more)" {
+		t.Fatalf("unexpected sync notification: %+v", sent[1])
+	}
+	if !slices.Equal(rec.got, sent) {
+		t.Fatalf("expected every returned notification to be delivered")
+	}
+	if again, _ := w.Poll(); len(again) != 0 {
+		t.Fatalf("expected nothing new on the next poll, got %+v", again)
+	}
+}
+
+func TestWatcherHonorsEventAndTypeFilters(t *testing.T) {
+	s := storetest.New(t)
+	if err := s.CreateSession("s1", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	rec := &recorder{}
+	w, err := New(s, rec, Options{Events: []string{EventSyncImport}, SyncTypes: []string{"architecture"}, Label: "alice"}, quietLogger())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	addObservation(t, s, "session_summary", "Session summary: engram", "done")
+	importDecisions(t, s, "Not an architecture note")
+	if sent, _ := w.Poll(); len(sent) != 0 {
+		t.Fatalf("expected filtered events to stay quiet, got %+v", sent)
+	}
+
+	// A reload can switch event kinds on without a new watcher.
+	if err := w.SetOptions(Options{Events: []string{"deploy"}}); err == nil {
+		t.Fatalf("expected SetOptions to reject an unknown event")
+	}
+	if err := w.SetOptions(Options{Events: []string{EventSessionSummary}}); err != nil {
+		t.Fatalf("SetOptions: %v", err)
+	}
+	addObservation(t, s, "session_summary", "Session summary: engram", "shipped")
+	if sent, _ := w.Poll(); len(sent) != 1 || sent[0].Kind != EventSessionSummary {
+		t.Fatalf("expected the reloaded filter to let summaries through, got %+v", sent)
+	}
+
+	if _, err := New(s, rec, Options{Events: []string{"deploy"}}, quietLogger()); err == nil || !strings.Contains(err.Error(), "unknown event") {
+		t.Fatalf("expected unknown event error, got %v", err)
+	}
+	if _, err := New(s, rec, Options{Interval: time.Millisecond}, quietLogger()); err == nil {
+		t.Fatalf("expected short interval error")
+	}
+}
+
+func TestWatcherKeepsGoingWhenTheDesktopFails(t *testing.T) {
+	s := storetest.New(t)
+	if err := s.CreateSession("s1", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	rec := &recorder{err: errors.New("no display")}
+	w, err := New(s, rec, Options{}, quietLogger())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	addObservation(t, s, "session_summary", "Session summary: engram", "done")
+	if sent, err := w.Poll(); err != nil || len(sent) != 0 {
+		t.Fatalf("expected a delivery failure to be logged only, got %+v, %v", sent, err)
+	}
+}
+
+func TestDesktopCommandPerPlatform(t *testing.T) {
+	n := Notification{Title: `engram: "quoted"`, Body: `back\slash`}
+
+	name, args, _ := desktopCommand("darwin", n)
+	if name != "osascript" || args[1] != `display notification "back\\slash" with title "engram: \"quoted\""` {
+		t.Fatalf("unexpected macOS command: %s %q", name, args)
+	}
+	name, args, _ = desktopCommand("linux", n)
+	if name != "notify-send" || !slices.Equal(args[1:], []string{n.Title, n.Body}) {
+		t.Fatalf("unexpected Linux command: %s %q", name, args)
+	}
+	name, _, env := desktopCommand("windows", n)
+	if name != "powershell" || !slices.Contains(env, "ENGRAM_NOTIFY_TITLE="+n.Title) {
+		t.Fatalf("unexpected Windows command: %s %q", name, env)
+	}
+	if name, _, _ := desktopCommand("plan9", n); name != "" {
+		t.Fatalf("expected plan9 to be unsupported, got %s", name)
+	}
+
+	var ran []string
+	d := &Desktop{goos: "linux", run: func(name string, args, env []string) error {
+		ran = append([]string{name}, args...)
+		return nil
+	}}
+	if err := d.Notify(n); err != nil || ran[0] != "notify-send" {
+		t.Fatalf("expected notify-send to run, got %q, %v", ran, err)
+	}
+}
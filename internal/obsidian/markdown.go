@@ -33,6 +33,12 @@ func ObservationToMarkdown(obs store.Observation) string {
 	} else {
 		fmt.Fprintf(&sb, "topic_key: %s\n", topicKey)
 	}
+	if len(obs.Refs) > 0 {
+		sb.WriteString("refs:\n")
+		for _, ref := range obs.Refs {
+			fmt.Fprintf(&sb, "  - %q\n", ref)
+		}
+	}
 	fmt.Fprintf(&sb, "session_id: %s\n", obs.SessionID)
 	fmt.Fprintf(&sb, "created_at: %q\n", obs.CreatedAt)
 	fmt.Fprintf(&sb, "updated_at: %q\n", obs.UpdatedAt)
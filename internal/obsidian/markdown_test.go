@@ -139,6 +139,17 @@ func TestObservationToMarkdown(t *testing.T) {
 		}
 	})
 
+	t.Run("refs — listed in frontmatter only when present", func(t *testing.T) {
+		obs := store.Observation{ID: 5, Type: "bugfix", Title: "Linked", Content: "x", Refs: store.RefList{"#12", "https://github.com/a/b/pull/3"}}
+		got := ObservationToMarkdown(obs)
+		if !strings.Contains(got, "refs:\n  - \"#12\"\n  - \"https://github.com/a/b/pull/3\"\n") {
+			t.Errorf("expected refs list in frontmatter, got: %q", got)
+		}
+		if strings.Contains(ObservationToMarkdown(store.Observation{ID: 6, Title: "Plain"}), "refs:") {
+			t.Errorf("expected no refs key when observation has none")
+		}
+	})
+
 	t.Run("multi-segment topic_key — prefix uses last slash part", func(t *testing.T) {
 		topicKey := "sdd/obsidian-plugin/explore"
 		project := "engram"
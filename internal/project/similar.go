@@ -90,7 +90,7 @@ func FindSimilar(name string, existing []string, maxDistance int) []ProjectMatch
 		}
 
 		// Levenshtein distance (using scaled effectiveMax)
-		dist := levenshtein(nameLower, candidateLower)
+		dist := Levenshtein(nameLower, candidateLower)
 		if dist <= effectiveMax {
 			if !seen[candidate] {
 				seen[candidate] = true
@@ -115,10 +115,10 @@ func FindSimilar(name string, existing []string, maxDistance int) []ProjectMatch
 	return result
 }
 
-// levenshtein computes the Levenshtein (edit) distance between strings a and b.
+// Levenshtein computes the Levenshtein (edit) distance between strings a and b.
 // Uses the standard dynamic-programming approach with O(min(|a|,|b|)) space
 // by only keeping two rows of the DP table at a time.
-func levenshtein(a, b string) int {
+func Levenshtein(a, b string) int {
 	ra := []rune(a)
 	rb := []rune(b)
 	la, lb := len(ra), len(rb)
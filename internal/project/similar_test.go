@@ -24,14 +24,14 @@ func TestLevenshtein(t *testing.T) {
 	}
 
 	for _, tc := range tests {
-		got := levenshtein(tc.a, tc.b)
+		got := Levenshtein(tc.a, tc.b)
 		if got != tc.want {
-			t.Errorf("levenshtein(%q, %q) = %d; want %d", tc.a, tc.b, got, tc.want)
+			t.Errorf("Levenshtein(%q, %q) = %d; want %d", tc.a, tc.b, got, tc.want)
 		}
 	}
 }
 
-// TestLevenshtein_Symmetry verifies that levenshtein(a,b) == levenshtein(b,a).
+// TestLevenshtein_Symmetry verifies that Levenshtein(a,b) == Levenshtein(b,a).
 func TestLevenshtein_Symmetry(t *testing.T) {
 	pairs := [][2]string{
 		{"engram", "engam"},
@@ -40,10 +40,10 @@ func TestLevenshtein_Symmetry(t *testing.T) {
 		{"", "hello"},
 	}
 	for _, p := range pairs {
-		ab := levenshtein(p[0], p[1])
-		ba := levenshtein(p[1], p[0])
+		ab := Levenshtein(p[0], p[1])
+		ba := Levenshtein(p[1], p[0])
 		if ab != ba {
-			t.Errorf("levenshtein(%q,%q)=%d != levenshtein(%q,%q)=%d (symmetry broken)",
+			t.Errorf("Levenshtein(%q,%q)=%d != Levenshtein(%q,%q)=%d (symmetry broken)",
 				p[0], p[1], ab, p[1], p[0], ba)
 		}
 	}
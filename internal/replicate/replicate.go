@@ -0,0 +1,410 @@
+// Package replicate copies the engram store into PostgreSQL so memories can
+// be queried from SQL dashboards.
+//
+// The first run loads everything; later runs send only rows changed since
+// the last one, tracked per target in the local replication_state table.
+// Every row is written with INSERT ... ON CONFLICT DO UPDATE, so re-sending a
+// batch after a failure is harmless.
+//
+// SQL is applied through an Executor. The default one pipes each batch to
+// the psql client, which keeps engram free of a Postgres driver and works
+// with any connection string psql accepts.
+//
+// Schema mapping (SQLite → PostgreSQL):
+//
+//	sessions      → engram_sessions      id text PK, project, directory, started_at timestamptz,
+//	                                     ended_at timestamptz, summary
+//	observations  → engram_observations  id bigint PK, sync_id, session_id, type, title, content,
+//	                                     tool_name, project, scope, topic_key, refs text[],
+//	                                     revision_count, duplicate_count, last_seen_at,
+//	                                     created_at, updated_at, deleted_at (all timestamptz)
+//	user_prompts  → engram_prompts       id bigint PK, sync_id, session_id, content, project,
+//	                                     created_at timestamptz
+//
+// Soft deletes arrive as a deleted_at value. Hard deletes, quarantined
+// captures, and the FTS indexes are not replicated.
+package replicate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+const (
+	// DefaultBatchSize is how many rows go into one transaction.
+	DefaultBatchSize = 500
+
+	// DefaultAttempts is how often a failing batch is tried before giving up.
+	DefaultAttempts = 4
+
+	// DefaultBackoff is the wait after the first failure; it doubles on each
+	// retry.
+	DefaultBackoff = time.Second
+
+	entityObservations = "observations"
+	entityPrompts      = "prompts"
+)
+
+// sleep is injectable for testing.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Schema creates the replica tables. It is idempotent and sent before every
+// run.
+const Schema = `CREATE TABLE IF NOT EXISTS engram_sessions (
+	id         text PRIMARY KEY,
+	project    text NOT NULL,
+	directory  text NOT NULL,
+	started_at timestamptz NOT NULL,
+	ended_at   timestamptz,
+	summary    text
+);
+CREATE TABLE IF NOT EXISTS engram_observations (
+	id              bigint PRIMARY KEY,
+	sync_id         text,
+	session_id      text NOT NULL,
+	type            text NOT NULL,
+	title           text NOT NULL,
+	content         text NOT NULL,
+	tool_name       text,
+	project         text,
+	scope           text NOT NULL,
+	topic_key       text,
+	refs            text[] NOT NULL DEFAULT '{}',
+	revision_count  integer NOT NULL,
+	duplicate_count integer NOT NULL,
+	last_seen_at    timestamptz,
+	created_at      timestamptz NOT NULL,
+	updated_at      timestamptz NOT NULL,
+	deleted_at      timestamptz
+);
+CREATE INDEX IF NOT EXISTS engram_observations_project ON engram_observations (project, created_at);
+CREATE INDEX IF NOT EXISTS engram_observations_type ON engram_observations (type);
+CREATE TABLE IF NOT EXISTS engram_prompts (
+	id         bigint PRIMARY KEY,
+	sync_id    text,
+	session_id text NOT NULL,
+	content    text NOT NULL,
+	project    text,
+	created_at timestamptz NOT NULL
+);
+`
+
+// Executor runs a SQL script against the target database.
+type Executor interface {
+	Exec(ctx context.Context, script string) error
+}
+
+// PSQL runs scripts with the psql client in a single transaction that stops
+// at the first error.
+type PSQL struct {
+	URL string
+	// Command is the psql binary; empty means "psql" on PATH.
+	Command string
+}
+
+// Exec implements Executor.
+func (p PSQL) Exec(ctx context.Context, script string) error {
+	command := p.Command
+	if command == "" {
+		command = "psql"
+	}
+	cmd := exec.CommandContext(ctx, command, "--dbname", p.URL, "--no-psqlrc", "--quiet",
+		"--single-transaction", "--set", "ON_ERROR_STOP=1")
+	cmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("psql: %s", msg)
+		}
+		return fmt.Errorf("psql: %w", err)
+	}
+	return nil
+}
+
+// Writer prints scripts instead of running them, for --dry-run and for
+// piping into another client.
+type Writer struct {
+	W io.Writer
+}
+
+// Exec implements Executor.
+func (w Writer) Exec(_ context.Context, script string) error {
+	_, err := io.WriteString(w.W, "BEGIN;\n"+script+"COMMIT;\n")
+	return err
+}
+
+// Options configures Run.
+type Options struct {
+	// Target names the replica in replication_state; see TargetKey.
+	Target string
+	// Full ignores the stored watermarks and re-sends every row.
+	Full bool
+	// BatchSize is the rows per transaction; <= 0 means DefaultBatchSize.
+	BatchSize int
+	// Attempts per batch; <= 0 means DefaultAttempts.
+	Attempts int
+	// Backoff before the first retry; <= 0 means DefaultBackoff.
+	Backoff time.Duration
+	// DryRun builds the SQL and passes it to the executor but leaves the
+	// watermarks untouched.
+	DryRun bool
+}
+
+// Result counts the rows sent by Run.
+type Result struct {
+	Sessions     int `json:"sessions"`
+	Observations int `json:"observations"`
+	Prompts      int `json:"prompts"`
+	Batches      int `json:"batches"`
+	Retries      int `json:"retries"`
+}
+
+// TargetKey identifies a Postgres URL without its password or options, so
+// rotating credentials does not restart replication from scratch.
+func TargetKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	key := u.Scheme + "://"
+	if u.User != nil && u.User.Username() != "" {
+		key += u.User.Username() + "@"
+	}
+	return key + u.Host + u.Path
+}
+
+// Run sends the schema, every session, and the observations and prompts
+// changed since the last run to executor. Watermarks advance after each
+// batch that succeeds, so an interrupted run resumes where it stopped.
+func Run(ctx context.Context, s *store.Store, executor Executor, opts Options) (*Result, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Attempts <= 0 {
+		opts.Attempts = DefaultAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultBackoff
+	}
+	r := &runner{store: s, exec: executor, opts: opts, result: &Result{}}
+
+	// Sessions carry no updated_at and merges rewrite them, so all of them
+	// go out every run; there are few compared to observations.
+	sessions, err := s.ListSessions()
+	if err != nil {
+		return nil, fmt.Errorf("replicate: list sessions: %w", err)
+	}
+	var script strings.Builder
+	script.WriteString(Schema)
+	for _, sess := range sessions {
+		writeSession(&script, sess)
+	}
+	if err := r.send(ctx, script.String()); err != nil {
+		return r.result, fmt.Errorf("replicate: schema and sessions: %w", err)
+	}
+	r.result.Sessions = len(sessions)
+
+	if err := r.observations(ctx); err != nil {
+		return r.result, err
+	}
+	if err := r.prompts(ctx); err != nil {
+		return r.result, err
+	}
+	return r.result, nil
+}
+
+type runner struct {
+	store  *store.Store
+	exec   Executor
+	opts   Options
+	result *Result
+}
+
+func (r *runner) watermark(entity string) (string, error) {
+	if r.opts.Full {
+		return "", nil
+	}
+	// Rows that share the watermark second may have landed after the last
+	// run read it; start from that second again and let upserts absorb the
+	// repeats.
+	return r.store.ReplicationWatermark(r.opts.Target, entity)
+}
+
+func (r *runner) observations(ctx context.Context) error {
+	since, err := r.watermark(entityObservations)
+	if err != nil {
+		return fmt.Errorf("replicate: read watermark: %w", err)
+	}
+	var afterID int64 = -1
+	for {
+		batch, err := r.store.ObservationsChangedSince(since, afterID, r.opts.BatchSize)
+		if err != nil {
+			return fmt.Errorf("replicate: read observations: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		var script strings.Builder
+		for _, o := range batch {
+			writeObservation(&script, o)
+		}
+		if err := r.send(ctx, script.String()); err != nil {
+			return fmt.Errorf("replicate: observations: %w", err)
+		}
+		last := batch[len(batch)-1]
+		since, afterID = last.UpdatedAt, last.ID
+		r.result.Observations += len(batch)
+		if err := r.advance(entityObservations, since); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *runner) prompts(ctx context.Context) error {
+	since, err := r.watermark(entityPrompts)
+	if err != nil {
+		return fmt.Errorf("replicate: read watermark: %w", err)
+	}
+	var afterID int64 = -1
+	for {
+		batch, err := r.store.PromptsCreatedSince(since, afterID, r.opts.BatchSize)
+		if err != nil {
+			return fmt.Errorf("replicate: read prompts: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		var script strings.Builder
+		for _, p := range batch {
+			writePrompt(&script, p)
+		}
+		if err := r.send(ctx, script.String()); err != nil {
+			return fmt.Errorf("replicate: prompts: %w", err)
+		}
+		last := batch[len(batch)-1]
+		since, afterID = last.CreatedAt, last.ID
+		r.result.Prompts += len(batch)
+		if err := r.advance(entityPrompts, since); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *runner) advance(entity, watermark string) error {
+	if r.opts.DryRun {
+		return nil
+	}
+	if err := r.store.SetReplicationWatermark(r.opts.Target, entity, watermark); err != nil {
+		return fmt.Errorf("replicate: save watermark: %w", err)
+	}
+	return nil
+}
+
+// send executes one batch, retrying with exponential backoff.
+func (r *runner) send(ctx context.Context, script string) error {
+	backoff := r.opts.Backoff
+	var err error
+	for attempt := 1; attempt <= r.opts.Attempts; attempt++ {
+		if err = r.exec.Exec(ctx, script); err == nil {
+			r.result.Batches++
+			return nil
+		}
+		if attempt == r.opts.Attempts || ctx.Err() != nil {
+			break
+		}
+		r.result.Retries++
+		if sleepErr := sleep(ctx, backoff); sleepErr != nil {
+			return sleepErr
+		}
+		backoff *= 2
+	}
+	return fmt.Errorf("after %d attempts: %w", r.opts.Attempts, err)
+}
+
+// ─── SQL rendering ───────────────────────────────────────────────────────────
+
+func writeSession(b *strings.Builder, sess store.Session) {
+	fmt.Fprintf(b, `INSERT INTO engram_sessions (id, project, directory, started_at, ended_at, summary)
+VALUES (%s, %s, %s, %s, %s, %s)
+ON CONFLICT (id) DO UPDATE SET project = EXCLUDED.project, directory = EXCLUDED.directory,
+	started_at = EXCLUDED.started_at, ended_at = EXCLUDED.ended_at, summary = EXCLUDED.summary;
+`, literal(sess.ID), literal(sess.Project), literal(sess.Directory),
+		timestamp(&sess.StartedAt), timestamp(sess.EndedAt), nullable(sess.Summary))
+}
+
+func writeObservation(b *strings.Builder, o store.Observation) {
+	fmt.Fprintf(b, `INSERT INTO engram_observations (id, sync_id, session_id, type, title, content, tool_name, project, scope, topic_key, refs, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at)
+VALUES (%d, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %d, %d, %s, %s, %s, %s)
+ON CONFLICT (id) DO UPDATE SET sync_id = EXCLUDED.sync_id, session_id = EXCLUDED.session_id,
+	type = EXCLUDED.type, title = EXCLUDED.title, content = EXCLUDED.content, tool_name = EXCLUDED.tool_name,
+	project = EXCLUDED.project, scope = EXCLUDED.scope, topic_key = EXCLUDED.topic_key, refs = EXCLUDED.refs,
+	revision_count = EXCLUDED.revision_count, duplicate_count = EXCLUDED.duplicate_count,
+	last_seen_at = EXCLUDED.last_seen_at, created_at = EXCLUDED.created_at,
+	updated_at = EXCLUDED.updated_at, deleted_at = EXCLUDED.deleted_at;
+`, o.ID, literal(o.SyncID), literal(o.SessionID), literal(o.Type), literal(o.Title), literal(o.Content),
+		nullable(o.ToolName), nullable(o.Project), literal(o.Scope), nullable(o.TopicKey), textArray(o.Refs),
+		o.RevisionCount, o.DuplicateCount, timestamp(o.LastSeenAt), timestamp(&o.CreatedAt),
+		timestamp(&o.UpdatedAt), timestamp(o.DeletedAt))
+}
+
+func writePrompt(b *strings.Builder, p store.Prompt) {
+	project := &p.Project
+	if p.Project == "" {
+		project = nil
+	}
+	fmt.Fprintf(b, `INSERT INTO engram_prompts (id, sync_id, session_id, content, project, created_at)
+VALUES (%d, %s, %s, %s, %s, %s)
+ON CONFLICT (id) DO UPDATE SET sync_id = EXCLUDED.sync_id, session_id = EXCLUDED.session_id,
+	content = EXCLUDED.content, project = EXCLUDED.project, created_at = EXCLUDED.created_at;
+`, p.ID, literal(p.SyncID), literal(p.SessionID), literal(p.Content), nullable(project), timestamp(&p.CreatedAt))
+}
+
+// literal quotes s as a standard SQL string. Postgres text cannot hold NUL,
+// so NUL bytes are dropped.
+func literal(s string) string {
+	s = strings.ReplaceAll(s, "\x00", "")
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func nullable(s *string) string {
+	if s == nil {
+		return "NULL"
+	}
+	return literal(*s)
+}
+
+func timestamp(s *string) string {
+	if s == nil || *s == "" {
+		return "NULL"
+	}
+	return literal(store.NormalizeTimestamp(*s)) + "::timestamptz"
+}
+
+func textArray(values []string) string {
+	if len(values) == 0 {
+		return "'{}'::text[]"
+	}
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = literal(v)
+	}
+	return "ARRAY[" + strings.Join(quoted, ", ") + "]::text[]"
+}
@@ -0,0 +1,95 @@
+// Package rules distills project memories into a markdown rules file
+// (AGENTS.md, CONTEXT.md, ...) that agents read at startup.
+//
+// Every generated section is fenced by HTML comment markers:
+//
+//	<!-- engram:begin decisions -->
+//	## Decisions
+//	...
+//	<!-- engram:end decisions -->
+//
+// Regenerating replaces only the text between a section's markers, so
+// hand-written content elsewhere in the file survives and diffs stay small.
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// Section is one generated block of the rules file.
+type Section struct {
+	// Anchor is the stable marker id; never rename it once files exist.
+	Anchor string
+	Title  string
+	// Types are the observation types collected into this section.
+	Types []string
+}
+
+// DefaultSections is the section layout used by `engram emit rules`.
+var DefaultSections = []Section{
+	{Anchor: "decisions", Title: "Decisions", Types: []string{"decision", "architecture"}},
+	{Anchor: "patterns", Title: "Patterns", Types: []string{"pattern"}},
+	{Anchor: "conventions", Title: "Conventions", Types: []string{"convention", "config"}},
+}
+
+// Rendered is the generated body of one section.
+type Rendered struct {
+	Section Section
+	Count   int
+	Body    string
+}
+
+func beginMarker(anchor string) string { return "<!-- engram:begin " + anchor + " -->" }
+func endMarker(anchor string) string   { return "<!-- engram:end " + anchor + " -->" }
+
+// Render groups observations into sections. Entries keep the order they are
+// passed in, so callers should pass a stable order (oldest first) to keep
+// regenerated files diff-friendly.
+func Render(sections []Section, observations []store.Observation) []Rendered {
+	out := make([]Rendered, 0, len(sections))
+	for _, sec := range sections {
+		var b strings.Builder
+		fmt.Fprintf(&b, "## %s\n", sec.Title)
+		count := 0
+		for _, obs := range observations {
+			if !slices.Contains(sec.Types, obs.Type) {
+				continue
+			}
+			count++
+			fmt.Fprintf(&b, "\n### %s\n\n%s\n", strings.TrimSpace(obs.Title), strings.TrimSpace(obs.Content))
+		}
+		if count == 0 {
+			b.WriteString("\n_No entries yet._\n")
+		}
+		out = append(out, Rendered{Section: sec, Count: count, Body: b.String()})
+	}
+	return out
+}
+
+// Merge writes rendered sections into existing file content. Sections whose
+// markers are present are replaced in place; missing sections are appended.
+// When existing is empty a header naming the project is emitted first.
+func Merge(existing, project string, rendered []Rendered) string {
+	doc := existing
+	if strings.TrimSpace(doc) == "" {
+		doc = fmt.Sprintf("# %s — Agent Rules\n\n"+
+			"Generated from engram memory with `engram emit rules`. Text outside the\n"+
+			"`engram:begin`/`engram:end` markers is kept when regenerating.\n", project)
+	}
+
+	for _, r := range rendered {
+		block := beginMarker(r.Section.Anchor) + "\n" + r.Body + endMarker(r.Section.Anchor)
+		start := strings.Index(doc, beginMarker(r.Section.Anchor))
+		end := strings.Index(doc, endMarker(r.Section.Anchor))
+		if start >= 0 && end > start {
+			doc = doc[:start] + block + doc[end+len(endMarker(r.Section.Anchor)):]
+			continue
+		}
+		doc = strings.TrimRight(doc, "\n") + "\n\n" + block + "\n"
+	}
+	return doc
+}
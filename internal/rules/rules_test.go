@@ -0,0 +1,78 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+func TestRenderGroupsObservationsBySection(t *testing.T) {
+	rendered := Render(DefaultSections, []store.Observation{
+		{Type: "decision", Title: "Use SQLite", Content: "Single file, zero deps."},
+		{Type: "bugfix", Title: "Ignored", Content: "not a rule"},
+		{Type: "architecture", Title: "Hexagonal store", Content: "Store owns all SQL."},
+		{Type: "pattern", Title: "Injectable hooks", Content: "Package vars stubbed in tests."},
+	})
+
+	if len(rendered) != 3 {
+		t.Fatalf("expected 3 sections, got %d", len(rendered))
+	}
+	decisions := rendered[0]
+	if decisions.Count != 2 || !strings.Contains(decisions.Body, "### Use SQLite") || !strings.Contains(decisions.Body, "### Hexagonal store") {
+		t.Fatalf("unexpected decisions section: %+v", decisions)
+	}
+	if strings.Index(decisions.Body, "Use SQLite") > strings.Index(decisions.Body, "Hexagonal store") {
+		t.Fatalf("expected input order to be preserved:\n%s", decisions.Body)
+	}
+	if strings.Contains(decisions.Body, "Ignored") {
+		t.Fatalf("expected bugfix to be excluded:\n%s", decisions.Body)
+	}
+	if rendered[1].Count != 1 || rendered[2].Count != 0 || !strings.Contains(rendered[2].Body, "No entries yet") {
+		t.Fatalf("unexpected patterns/conventions: %+v", rendered[1:])
+	}
+}
+
+func TestMergeReplacesSectionsInPlace(t *testing.T) {
+	first := Merge("", "engram", Render(DefaultSections, []store.Observation{
+		{Type: "decision", Title: "Old decision", Content: "old"},
+	}))
+	if !strings.HasPrefix(first, "# engram — Agent Rules") {
+		t.Fatalf("expected generated header, got:\n%s", first)
+	}
+	for _, anchor := range []string{"decisions", "patterns", "conventions"} {
+		if !strings.Contains(first, beginMarker(anchor)) || !strings.Contains(first, endMarker(anchor)) {
+			t.Fatalf("expected markers for %s in:\n%s", anchor, first)
+		}
+	}
+
+	// Hand-written text around the generated blocks must survive.
+	edited := strings.Replace(first, beginMarker("patterns"), "Team note: keep PRs small.\n\n"+beginMarker("patterns"), 1) + "\n## Footer\nhand written\n"
+
+	second := Merge(edited, "engram", Render(DefaultSections, []store.Observation{
+		{Type: "decision", Title: "New decision", Content: "new"},
+	}))
+	if strings.Contains(second, "Old decision") || !strings.Contains(second, "New decision") {
+		t.Fatalf("expected decisions replaced in place:\n%s", second)
+	}
+	if !strings.Contains(second, "Team note: keep PRs small.") || !strings.Contains(second, "## Footer\nhand written") {
+		t.Fatalf("expected hand-written text preserved:\n%s", second)
+	}
+	if strings.Count(second, beginMarker("decisions")) != 1 {
+		t.Fatalf("expected decisions section not to be duplicated:\n%s", second)
+	}
+
+	// Re-running with identical input is a no-op.
+	if third := Merge(second, "engram", Render(DefaultSections, []store.Observation{
+		{Type: "decision", Title: "New decision", Content: "new"},
+	})); third != second {
+		t.Fatalf("expected idempotent regeneration")
+	}
+}
+
+func TestMergeAppendsMissingSections(t *testing.T) {
+	got := Merge("# Existing rules\n\nKeep this.\n", "engram", Render(DefaultSections[:1], nil))
+	if !strings.HasPrefix(got, "# Existing rules\n\nKeep this.\n\n"+beginMarker("decisions")) {
+		t.Fatalf("expected section appended after existing content:\n%s", got)
+	}
+}
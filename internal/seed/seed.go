@@ -0,0 +1,260 @@
+// Package seed generates synthetic memories for demos, screenshots, and
+// plugin development.
+//
+// Generate is deterministic: the same Options produce the same sessions,
+// observations, and prompts, byte for byte. Callers load the result with
+// Store.ImportWithOptions, so re-running a seed skips what is already there.
+package seed
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// Defaults used for zero Options fields.
+const (
+	DefaultProjects     = 3
+	DefaultSessions     = 20
+	DefaultObservations = 500
+	DefaultSpan         = 90 * 24 * time.Hour
+)
+
+// Options sizes the generated data set.
+type Options struct {
+	Projects     int
+	Sessions     int // total across all projects
+	Observations int // total across all sessions
+	// Seed selects the data set; equal seeds give equal output.
+	Seed int64
+	// Now anchors the timestamps: sessions are spread over the Span before
+	// it. Pass a fixed time for output that does not change between runs.
+	Now  time.Time
+	Span time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.Projects <= 0 {
+		o.Projects = DefaultProjects
+	}
+	if o.Sessions <= 0 {
+		o.Sessions = DefaultSessions
+	}
+	if o.Sessions < o.Projects {
+		o.Sessions = o.Projects
+	}
+	if o.Observations < 0 {
+		o.Observations = 0
+	}
+	if o.Span <= 0 {
+		o.Span = DefaultSpan
+	}
+	if o.Now.IsZero() {
+		o.Now = time.Now()
+	}
+	o.Now = o.Now.UTC().Truncate(time.Second)
+	return o
+}
+
+var projectNames = []string{"atlas-api", "orbit-web", "ledger-cli", "harbor-infra", "quill-docs", "relay-worker"}
+
+var components = []string{"auth", "billing", "search", "cache", "queue", "webhooks", "migrations", "sessions", "rate-limiter", "exports"}
+
+var files = []string{
+	"internal/auth/middleware.go", "internal/billing/invoice.go", "src/search/query.ts", "src/cache/lru.ts",
+	"cmd/worker/main.go", "deploy/helm/values.yaml", "db/migrations/0042_add_index.sql", "src/api/routes.ts",
+}
+
+// template is one kind of observation: titles and bodies take %[1]s for
+// the component and %[2]s for a file path.
+type template struct {
+	typ      string
+	family   string // topic key family; empty means no topic key
+	titles   []string
+	contents []string
+}
+
+var templates = []template{
+	{
+		typ:    "bugfix",
+		family: "bug",
+		titles: []string{"Fixed race in %[1]s refresh", "Fixed nil pointer in %[1]s handler", "Fixed off-by-one in %[1]s pagination"},
+		contents: []string{
+			"**What**: Guarded the shared state in %[2]s with a mutex.\n**Why**: Two requests refreshed %[1]s at once and one overwrote the other.\n**Where**: %[2]s\n**Learned**: Run the %[1]s tests with -race before merging.",
+			"**What**: Returned early when the %[1]s record is missing.\n**Why**: Deleted rows still reached the handler and panicked.\n**Where**: %[2]s\n**Learned**: Treat not-found as a normal path, not an error.",
+		},
+	},
+	{
+		typ:    "decision",
+		family: "decision",
+		titles: []string{"Chose Postgres advisory locks for %[1]s", "Keep %[1]s synchronous for now", "Moved %[1]s config to environment"},
+		contents: []string{
+			"**What**: %[1]s uses advisory locks instead of a lock table.\n**Why**: No cleanup job, and locks die with the connection.\n**Where**: %[2]s\n**Learned**: Lock keys must be stable across deploys.",
+			"**What**: Decided against a queue for %[1]s until load requires it.\n**Why**: p99 is under 40ms and a queue adds an operational dependency.\n**Where**: %[2]s",
+		},
+	},
+	{
+		typ:    "architecture",
+		family: "architecture",
+		titles: []string{"%[1]s module boundaries", "%[1]s data flow", "%[1]s storage model"},
+		contents: []string{
+			"**What**: %[1]s owns its tables; other modules call its service interface.\n**Why**: Cross-module joins made migrations risky.\n**Where**: %[2]s",
+			"**What**: Requests enter %[1]s through the router, are validated, then persisted in one transaction.\n**Where**: %[2]s\n**Learned**: Validation errors never reach the database layer.",
+		},
+	},
+	{
+		typ:    "discovery",
+		titles: []string{"%[1]s retries hide upstream timeouts", "%[1]s index is never used", "%[1]s logs contain request bodies"},
+		contents: []string{
+			"**What**: The %[1]s client retries three times with no backoff, so upstream timeouts show up as slow successes.\n**Where**: %[2]s",
+			"**What**: EXPLAIN shows the %[1]s query scanning the whole table; the index column order is wrong.\n**Where**: %[2]s",
+		},
+	},
+	{
+		typ:    "pattern",
+		family: "pattern",
+		titles: []string{"Table-driven tests for %[1]s", "Error wrapping convention in %[1]s"},
+		contents: []string{
+			"**What**: %[1]s tests list cases in a slice and run each with t.Run.\n**Where**: %[2]s\n**Learned**: New edge cases are one line each.",
+			"**What**: Errors in %[1]s are wrapped with the operation name: fmt.Errorf(\"%[1]s: load: %%w\", err).\n**Where**: %[2]s",
+		},
+	},
+	{
+		typ:    "config",
+		family: "config",
+		titles: []string{"%[1]s timeout settings", "%[1]s feature flags"},
+		contents: []string{
+			"**What**: %[1]s reads its timeout from the environment, default 30s.\n**Where**: %[2]s\n**Learned**: CI sets it to 5s to surface slow tests.",
+		},
+	},
+	{
+		typ:    "learning",
+		titles: []string{"Testing %[1]s against a real database", "Profiling %[1]s allocations"},
+		contents: []string{
+			"**What**: Mocks hid a transaction bug in %[1]s that the real database caught immediately.\n**Learned**: Integration tests for %[1]s run against a throwaway container.",
+			"**What**: pprof showed %[1]s allocating a buffer per request.\n**Where**: %[2]s\n**Learned**: Reuse buffers with sync.Pool on hot paths.",
+		},
+	},
+}
+
+var prompts = []string{
+	"why does the %[1]s test fail only in CI?",
+	"add pagination to the %[1]s endpoint",
+	"refactor %[1]s so it can be tested without the network",
+	"what did we decide about %[1]s last week?",
+	"review %[2]s for race conditions",
+}
+
+var summaries = []string{
+	"## Goal\nStabilize %[1]s.\n\n## Accomplished\n- Fixed the flaky test\n- Documented the decision\n\n## Relevant Files\n- %[2]s",
+	"## Goal\nShip the %[1]s change.\n\n## Discoveries\n- The old code path is still used by the worker\n\n## Relevant Files\n- %[2]s",
+}
+
+// Generate builds a synthetic export. Sessions are spread across projects
+// and over time, observations across sessions; some observations share a
+// topic key so topic views have revisions to show.
+func Generate(opts Options) *store.ExportData {
+	opts = opts.withDefaults()
+	rng := rand.New(rand.NewPCG(uint64(opts.Seed), uint64(opts.Seed)^0x9e3779b97f4a7c15))
+
+	data := &store.ExportData{Version: "seed", ExportedAt: opts.Now.Format(store.TimestampLayout)}
+
+	projects := make([]string, opts.Projects)
+	for i := range projects {
+		projects[i] = projectNames[i%len(projectNames)]
+		if i >= len(projectNames) {
+			projects[i] = fmt.Sprintf("%s-%d", projects[i], i/len(projectNames)+1)
+		}
+	}
+
+	start := opts.Now.Add(-opts.Span)
+	step := opts.Span / time.Duration(opts.Sessions)
+	starts := make([]time.Time, opts.Sessions)
+	for i := range opts.Sessions {
+		jitter := time.Duration(rng.Int64N(int64(max(step/2, time.Minute))))
+		starts[i] = start.Add(step*time.Duration(i) + jitter)
+		project := projects[i%len(projects)]
+		component := pick(rng, components)
+		file := pick(rng, files)
+
+		sess := store.Session{
+			ID:        fmt.Sprintf("seed-%d-%03d", opts.Seed, i+1),
+			Project:   project,
+			Directory: "/home/dev/" + project,
+			StartedAt: starts[i].Format(store.TimestampLayout),
+		}
+		if i < opts.Sessions-1 || rng.IntN(2) == 0 {
+			ended := starts[i].Add(time.Duration(20+rng.IntN(160)) * time.Minute).Format(store.TimestampLayout)
+			summary := fmt.Sprintf(pick(rng, summaries), component, file)
+			sess.EndedAt, sess.Summary = &ended, &summary
+		}
+		data.Sessions = append(data.Sessions, sess)
+
+		for p := range 1 + rng.IntN(3) {
+			data.Prompts = append(data.Prompts, store.Prompt{
+				SessionID: sess.ID,
+				Content:   fmt.Sprintf(pick(rng, prompts), component, file),
+				Project:   project,
+				CreatedAt: starts[i].Add(time.Duration(p*7+1) * time.Minute).Format(store.TimestampLayout),
+			})
+		}
+	}
+
+	for i := range opts.Observations {
+		si := rng.IntN(opts.Sessions)
+		sess := data.Sessions[si]
+		t := pick(rng, templates)
+		component := pick(rng, components)
+		file := pick(rng, files)
+		created := starts[si].Add(time.Duration(rng.IntN(120)) * time.Minute)
+		project := sess.Project
+
+		obs := store.Observation{
+			SessionID: sess.ID,
+			Type:      t.typ,
+			Title:     fmt.Sprintf(pick(rng, t.titles), component, file),
+			Content:   fmt.Sprintf(pick(rng, t.contents), component, file) + fmt.Sprintf("\n\n(seed observation %d)", i+1),
+			Project:   &project,
+			Scope:     "project",
+			CreatedAt: created.Format(store.TimestampLayout),
+			UpdatedAt: created.Format(store.TimestampLayout),
+		}
+		if rng.IntN(10) == 0 {
+			obs.Scope = "personal"
+		}
+		if t.family != "" && rng.IntN(3) == 0 {
+			key := t.family + "/" + component
+			obs.TopicKey = &key
+			obs.RevisionCount = 1 + rng.IntN(4)
+		}
+		if rng.IntN(8) == 0 {
+			obs.DuplicateCount = 2 + rng.IntN(3)
+			seen := created.Add(time.Duration(1+rng.IntN(48)) * time.Hour).Format(store.TimestampLayout)
+			obs.LastSeenAt = &seen
+		}
+		if rng.IntN(6) == 0 {
+			obs.Refs = store.RefList{fmt.Sprintf("#%d", 100+rng.IntN(900))}
+		}
+		data.Observations = append(data.Observations, obs)
+	}
+	return data
+}
+
+func pick[T any](rng *rand.Rand, items []T) T {
+	return items[rng.IntN(len(items))]
+}
+
+// Projects returns the project names in data in order of first use.
+func Projects(data *store.ExportData) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, s := range data.Sessions {
+		if !seen[s.Project] {
+			seen[s.Project] = true
+			names = append(names, s.Project)
+		}
+	}
+	return names
+}
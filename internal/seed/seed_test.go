@@ -0,0 +1,63 @@
+package seed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+var fixedNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	opts := Options{Projects: 2, Sessions: 6, Observations: 40, Seed: 7, Now: fixedNow}
+	a, b := Generate(opts), Generate(opts)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("expected equal output for equal options")
+	}
+	opts.Seed = 8
+	if reflect.DeepEqual(a.Observations, Generate(opts).Observations) {
+		t.Fatalf("expected a different seed to change the observations")
+	}
+}
+
+func TestGenerateSizesAndSpreadsData(t *testing.T) {
+	data := Generate(Options{Projects: 3, Sessions: 9, Observations: 120, Seed: 1, Now: fixedNow})
+	if len(data.Sessions) != 9 || len(data.Observations) != 120 || len(data.Prompts) < 9 {
+		t.Fatalf("unexpected sizes: %d sessions, %d observations, %d prompts", len(data.Sessions), len(data.Observations), len(data.Prompts))
+	}
+	if got := Projects(data); !reflect.DeepEqual(got, []string{"atlas-api", "orbit-web", "ledger-cli"}) {
+		t.Fatalf("unexpected projects: %v", got)
+	}
+
+	types := map[string]bool{}
+	topics := 0
+	for _, o := range data.Observations {
+		types[o.Type] = true
+		if o.TopicKey != nil {
+			topics++
+		}
+		created, err := store.ParseTimestamp(o.CreatedAt)
+		if err != nil || created.Before(fixedNow.Add(-DefaultSpan)) || created.After(fixedNow.Add(3*time.Hour)) {
+			t.Fatalf("timestamp %q outside the span: %v", o.CreatedAt, err)
+		}
+	}
+	if len(types) < 5 || topics == 0 {
+		t.Fatalf("expected varied types and some topic keys, got %v types and %d topic keys", types, topics)
+	}
+}
+
+func TestGenerateManyProjectsGetUniqueNames(t *testing.T) {
+	data := Generate(Options{Projects: 8, Sessions: 8, Observations: 1, Now: fixedNow})
+	seen := map[string]bool{}
+	for _, p := range Projects(data) {
+		if seen[p] {
+			t.Fatalf("duplicate project %q", p)
+		}
+		seen[p] = true
+	}
+	if len(seen) != 8 {
+		t.Fatalf("expected 8 projects, got %v", seen)
+	}
+}
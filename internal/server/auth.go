@@ -0,0 +1,111 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// AuthCookieName is the cookie TokenAuth accepts in place of an
+// Authorization header, so browser clients can authenticate without keeping
+// the token in JavaScript.
+const AuthCookieName = "engram_token"
+
+// authSessionPath exchanges a token for the auth cookie (POST) or clears it
+// (DELETE). It is handled by TokenAuth itself so it works the same on a
+// single Server and a MultiServer.
+const authSessionPath = "/auth/session"
+
+// publicPaths skip TokenAuth for GET requests.
+var publicPaths = map[string]bool{"/health": true, "/ready": true}
+
+// TokenAuth rejects requests that do not carry token, either as
+// "Authorization: Bearer <token>" (CLI, hooks, scripts) or as the
+// engram_token cookie (browsers). GET /health and GET /ready (and their /v1
+// forms) stay public so liveness and readiness probes keep working.
+//
+// Browsers obtain the cookie with POST /auth/session {"token": "..."}; the
+// cookie is HttpOnly and SameSite=Lax, which covers dashboards on the same
+// site (e.g. another port on localhost). DELETE /auth/session logs out.
+func TokenAuth(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == authSessionPath:
+				handleAuthSession(w, r, func(got string) bool { return validToken(got, token) })
+				return
+			case r.Method == http.MethodGet && publicPaths[strings.TrimPrefix(r.URL.Path, "/"+APIVersion)]:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !validToken(requestToken(r), token) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="engram"`)
+				jsonError(w, http.StatusUnauthorized, "missing or invalid token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// handleAuthSession serves POST and DELETE /auth/session. valid reports
+// whether a token may log in.
+func handleAuthSession(w http.ResponseWriter, r *http.Request, valid func(string) bool) {
+	switch r.Method {
+	case http.MethodPost:
+		var body struct {
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+			return
+		}
+		if !valid(body.Token) {
+			jsonError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     AuthCookieName,
+			Value:    body.Token,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   r.TLS != nil,
+		})
+		jsonResponse(w, http.StatusOK, map[string]string{"status": "authenticated"})
+	case http.MethodDelete:
+		http.SetCookie(w, &http.Cookie{
+			Name:     AuthCookieName,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		jsonResponse(w, http.StatusOK, map[string]string{"status": "logged_out"})
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		jsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// requestToken extracts the bearer token, falling back to the auth cookie.
+func requestToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, value, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(value)
+		}
+		return ""
+	}
+	if cookie, err := r.Cookie(AuthCookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func validToken(got, want string) bool {
+	return got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
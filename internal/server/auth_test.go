@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTokenAuthBearerAndCookie(t *testing.T) {
+	srv := New(newServerTestStore(t), 0)
+	srv.Use(CORS(CORSOptions{AllowedOrigins: []string{"http://dash.local"}}), TokenAuth("s3cret"))
+	h := srv.Handler()
+
+	do := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(httptest.NewRequest(http.MethodGet, "/health", nil)); rec.Code != http.StatusOK {
+		t.Fatalf("expected /health to stay public, got %d", rec.Code)
+	}
+	if rec := do(httptest.NewRequest(http.MethodGet, "/ready", nil)); rec.Code != http.StatusOK {
+		t.Fatalf("expected /ready to stay public, got %d", rec.Code)
+	}
+	if rec := do(httptest.NewRequest(http.MethodGet, "/v1/health", nil)); rec.Code != http.StatusOK {
+		t.Fatalf("expected /v1/health to stay public, got %d", rec.Code)
+	}
+	if rec := do(httptest.NewRequest(http.MethodGet, "/v1/stats", nil)); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 on /v1/stats without token, got %d", rec.Code)
+	}
+
+	if rec := do(httptest.NewRequest(http.MethodGet, "/stats", nil)); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", rec.Code)
+	}
+
+	wrong := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	wrong.Header.Set("Authorization", "Bearer nope")
+	if rec := do(wrong); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with wrong token, got %d", rec.Code)
+	}
+
+	bearer := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	bearer.Header.Set("Authorization", "Bearer s3cret")
+	if rec := do(bearer); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with bearer token, got %d", rec.Code)
+	}
+
+	// Preflights are answered by CORS before auth runs.
+	preflight := httptest.NewRequest(http.MethodOptions, "/stats", nil)
+	preflight.Header.Set("Origin", "http://dash.local")
+	preflight.Header.Set("Access-Control-Request-Method", "GET")
+	if rec := do(preflight); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected unauthenticated preflight to succeed, got %d", rec.Code)
+	}
+
+	badLogin := httptest.NewRequest(http.MethodPost, "/auth/session", strings.NewReader(`{"token":"nope"}`))
+	if rec := do(badLogin); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for bad login, got %d", rec.Code)
+	}
+
+	login := httptest.NewRequest(http.MethodPost, "/auth/session", strings.NewReader(`{"token":"s3cret"}`))
+	rec := do(login)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 login, got %d", rec.Code)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != AuthCookieName || !cookies[0].HttpOnly {
+		t.Fatalf("expected HttpOnly auth cookie, got %+v", cookies)
+	}
+
+	withCookie := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	withCookie.AddCookie(cookies[0])
+	if rec := do(withCookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with auth cookie, got %d", rec.Code)
+	}
+
+	logout := do(httptest.NewRequest(http.MethodDelete, "/auth/session", nil))
+	if cookies := logout.Result().Cookies(); logout.Code != http.StatusOK || len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Fatalf("expected logout to expire the cookie, got %d %+v", logout.Code, cookies)
+	}
+
+	if rec := do(httptest.NewRequest(http.MethodGet, "/auth/session", nil)); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for GET /auth/session, got %d", rec.Code)
+	}
+}
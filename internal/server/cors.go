@@ -0,0 +1,97 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// CORSOptions configures the CORS middleware for browser clients such as
+// internal dashboards served from another origin.
+type CORSOptions struct {
+	// AllowedOrigins lists exact origins ("https://dash.example.com"). "*"
+	// allows any origin.
+	AllowedOrigins []string
+
+	// AllowCredentials lets browsers send cookies and Authorization headers
+	// cross-origin. It cannot be combined with "*".
+	AllowCredentials bool
+
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+const (
+	corsAllowMethods = "GET, POST, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders = "Authorization, Content-Type"
+	// corsExposeHeaders lets browser clients see which API version answered
+	// and whether the path they used is deprecated.
+	corsExposeHeaders = APIVersionHeader + ", Deprecation, Link"
+)
+
+// Validate reports option combinations browsers would reject.
+func (o CORSOptions) Validate() error {
+	if o.AllowCredentials && slices.Contains(o.AllowedOrigins, "*") {
+		return errors.New("engram server: cors allow_credentials cannot be combined with origin \"*\"")
+	}
+	return nil
+}
+
+func (o CORSOptions) allows(origin string) bool {
+	return slices.Contains(o.AllowedOrigins, "*") || slices.Contains(o.AllowedOrigins, origin)
+}
+
+// CORS answers preflight requests and adds Access-Control-* headers for
+// allowed origins. Requests without an Origin header (CLI, hooks, plugins)
+// pass through untouched. Preflights are answered here and never reach the
+// handlers behind it, so auth middleware should be registered after CORS.
+func CORS(opts CORSOptions) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Add("Vary", "Origin")
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !opts.allows(origin) {
+				if preflight {
+					jsonError(w, http.StatusForbidden, "origin not allowed")
+					return
+				}
+				// No CORS headers: the browser blocks the response.
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", origin)
+			if opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				h.Set("Access-Control-Allow-Headers", requested)
+			} else {
+				h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			}
+			if opts.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
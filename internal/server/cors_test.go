@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORSPreflightAndSimpleRequests(t *testing.T) {
+	srv := New(newServerTestStore(t), 0)
+	srv.Use(CORS(CORSOptions{
+		AllowedOrigins:   []string{"http://dash.local"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}))
+	h := srv.Handler()
+
+	preflight := httptest.NewRequest(http.MethodOptions, "/observations/recent", nil)
+	preflight.Header.Set("Origin", "http://dash.local")
+	preflight.Header.Set("Access-Control-Request-Method", "GET")
+	preflight.Header.Set("Access-Control-Request-Headers", "authorization")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, preflight)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 preflight, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "http://dash.local" {
+		t.Fatalf("expected origin echoed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("expected credentials allowed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "authorization" {
+		t.Fatalf("expected requested headers allowed, got %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Fatalf("expected max age 600, got %q", got)
+	}
+
+	simple := httptest.NewRequest(http.MethodGet, "/health", nil)
+	simple.Header.Set("Origin", "http://dash.local")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, simple)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "http://dash.local" {
+		t.Fatalf("expected CORS headers on allowed request, got %d %v", rec.Code, rec.Header())
+	}
+	if !strings.Contains(rec.Header().Get("Access-Control-Expose-Headers"), "Deprecation") {
+		t.Fatalf("expected version headers to be exposed, got %v", rec.Header())
+	}
+
+	denied := httptest.NewRequest(http.MethodOptions, "/health", nil)
+	denied.Header.Set("Origin", "http://evil.local")
+	denied.Header.Set("Access-Control-Request-Method", "GET")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, denied)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected 403 without CORS headers for unknown origin, got %d %v", rec.Code, rec.Header())
+	}
+
+	noOrigin := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, noOrigin)
+	if rec.Code != http.StatusOK || rec.Header().Get("Vary") != "" {
+		t.Fatalf("expected non-browser request untouched, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestCORSOptionsValidate(t *testing.T) {
+	if err := (CORSOptions{AllowedOrigins: []string{"*"}}).Validate(); err != nil {
+		t.Fatalf("expected wildcard without credentials to be valid: %v", err)
+	}
+	err := (CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}).Validate()
+	if err == nil || !strings.Contains(err.Error(), "allow_credentials") {
+		t.Fatalf("expected wildcard+credentials error, got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// Ingest queue defaults.
+const (
+	DefaultIngestQueueSize      = 1024
+	DefaultIngestBatchSize      = 64
+	DefaultIngestEnqueueTimeout = 2 * time.Second
+)
+
+// errIngestQueueFull is returned when the queue stays full for longer than
+// the enqueue timeout.
+var errIngestQueueFull = errors.New("ingest queue is full")
+
+// errIngestQueueClosed is returned once the queue has been closed for
+// shutdown; retrying against this server will not help.
+var errIngestQueueClosed = errors.New("server is shutting down")
+
+// IngestOptions configures the write-ahead ingestion queue for
+// POST /observations. A QueueSize of 0 disables the queue.
+type IngestOptions struct {
+	// QueueSize bounds how many observations wait in memory.
+	QueueSize int
+	// BatchSize caps how many queued observations share one transaction;
+	// <= 0 means DefaultIngestBatchSize.
+	BatchSize int
+	// EnqueueTimeout is how long a request waits for room in a full queue
+	// before it gets a 503; <= 0 means DefaultIngestEnqueueTimeout.
+	EnqueueTimeout time.Duration
+}
+
+// IngestStatus is reported under "ingest" in /health.
+type IngestStatus struct {
+	Depth    int   `json:"depth"`
+	Capacity int   `json:"capacity"`
+	Written  int64 `json:"written"`
+	Failed   int64 `json:"failed"`
+	Rejected int64 `json:"rejected"`
+}
+
+// ingestQueue buffers observations in memory and writes them from a single
+// goroutine, batching whatever has piled up into one transaction. Under a
+// burst the batches grow; when traffic is light each write goes out alone,
+// so nothing waits on a timer.
+type ingestQueue struct {
+	store   *store.Store
+	opts    IngestOptions
+	items   chan store.AddObservationParams
+	onWrite func()
+
+	mu     sync.RWMutex // guards closed against concurrent enqueues
+	closed bool
+	done   chan struct{}
+
+	written  atomic.Int64
+	failed   atomic.Int64
+	rejected atomic.Int64
+}
+
+func newIngestQueue(s *store.Store, opts IngestOptions, onWrite func()) *ingestQueue {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultIngestBatchSize
+	}
+	if opts.EnqueueTimeout <= 0 {
+		opts.EnqueueTimeout = DefaultIngestEnqueueTimeout
+	}
+	q := &ingestQueue{
+		store:   s,
+		opts:    opts,
+		items:   make(chan store.AddObservationParams, opts.QueueSize),
+		onWrite: onWrite,
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// enqueue waits for room until the request is cancelled or the enqueue
+// timeout passes — the backpressure a bursty client sees as a 503.
+func (q *ingestQueue) enqueue(ctx context.Context, p store.AddObservationParams) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return errIngestQueueClosed
+	}
+
+	select {
+	case q.items <- p:
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(q.opts.EnqueueTimeout)
+	defer timer.Stop()
+	select {
+	case q.items <- p:
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	q.rejected.Add(1)
+	return errIngestQueueFull
+}
+
+func (q *ingestQueue) run() {
+	defer close(q.done)
+	batch := make([]store.AddObservationParams, 0, q.opts.BatchSize)
+	for p := range q.items {
+		batch = append(batch[:0], p)
+	fill:
+		for len(batch) < q.opts.BatchSize {
+			select {
+			case next, ok := <-q.items:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, next)
+			default:
+				break fill
+			}
+		}
+		q.write(batch)
+	}
+}
+
+func (q *ingestQueue) write(batch []store.AddObservationParams) {
+	_, errs, err := q.store.AddObservationBatch(batch)
+	if err != nil {
+		q.failed.Add(int64(len(batch)))
+		log.Printf("[engram] ingest: batch of %d failed: %v", len(batch), err)
+		return
+	}
+	written := 0
+	for i, err := range errs {
+		if err != nil {
+			q.failed.Add(1)
+			log.Printf("[engram] ingest: observation %q for session %s failed: %v", batch[i].Title, batch[i].SessionID, err)
+			continue
+		}
+		written++
+	}
+	q.written.Add(int64(written))
+	if written > 0 && q.onWrite != nil {
+		q.onWrite()
+	}
+}
+
+// close stops accepting observations and blocks until everything queued
+// has been written.
+func (q *ingestQueue) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.items)
+	}
+	q.mu.Unlock()
+	<-q.done
+}
+
+func (q *ingestQueue) status() IngestStatus {
+	return IngestStatus{
+		Depth:    len(q.items),
+		Capacity: cap(q.items),
+		Written:  q.written.Load(),
+		Failed:   q.failed.Load(),
+		Rejected: q.rejected.Load(),
+	}
+}
+
+// EnableIngestQueue makes POST /observations enqueue observations and answer
+// 202 Accepted instead of writing synchronously. Queued writes are batched
+// into transactions; call Close on shutdown to flush them.
+func (s *Server) EnableIngestQueue(opts IngestOptions) {
+	if opts.QueueSize <= 0 {
+		return
+	}
+	s.ingest = newIngestQueue(s.store, opts, s.notifyWrite)
+}
+
+// Close flushes the ingestion queue, if any. The server stops accepting
+// queued observations afterwards.
+func (s *Server) Close() {
+	if s.ingest != nil {
+		s.ingest.close()
+	}
+}
+
+func (s *Server) enqueueObservation(w http.ResponseWriter, r *http.Request, body store.AddObservationParams) {
+	if err := s.ingest.enqueue(r.Context(), body); err != nil {
+		// Only a full queue asks the client to come back; a closing server
+		// will not have room later.
+		if !errors.Is(err, errIngestQueueClosed) {
+			w.Header().Set("Retry-After", "1")
+		}
+		jsonError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	jsonResponse(w, http.StatusAccepted, map[string]any{"status": "queued"})
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+func TestIngestQueueAcceptsAndFlushesOnClose(t *testing.T) {
+	st := newServerTestStore(t)
+	if err := st.CreateSession("s-ingest", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	srv := New(st, 0)
+	var writes atomic.Int32
+	srv.SetOnWrite(func() { writes.Add(1) })
+	srv.EnableIngestQueue(IngestOptions{QueueSize: 100, BatchSize: 10})
+	h := srv.Handler()
+
+	for i := 0; i < 25; i++ {
+		body := fmt.Sprintf(`{"session_id":"s-ingest","type":"tool_use","title":"Edit %d","content":"edited file %d","project":"engram"}`, i, i)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/observations", strings.NewReader(body)))
+		if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"queued"`) {
+			t.Fatalf("expected 202 queued, got %d %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	// A write for a session that does not exist fails on its own.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/observations",
+		strings.NewReader(`{"session_id":"missing","title":"Orphan","content":"no session"}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 for orphan, got %d", rec.Code)
+	}
+
+	srv.Close()
+
+	obs, err := st.AllObservations("engram", "", 100)
+	if err != nil || len(obs) != 25 {
+		t.Fatalf("expected 25 flushed observations, got %d err=%v", len(obs), err)
+	}
+	status := srv.ingest.status()
+	if status.Written != 25 || status.Failed != 1 || status.Depth != 0 || writes.Load() == 0 {
+		t.Fatalf("unexpected ingest status: %+v writes=%d", status, writes.Load())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var health struct {
+		Ingest *IngestStatus `json:"ingest"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil || health.Ingest == nil || health.Ingest.Capacity != 100 {
+		t.Fatalf("expected ingest status in /health, got %s", rec.Body.String())
+	}
+
+	// After Close the queue refuses new work instead of losing it.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/observations",
+		strings.NewReader(`{"session_id":"s-ingest","title":"Late","content":"after shutdown"}`)))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "" || !strings.Contains(rec.Body.String(), "shutting down") {
+		t.Fatalf("expected a shutdown 503 without Retry-After after close, got %d %v %s", rec.Code, rec.Header(), rec.Body.String())
+	}
+}
+
+func TestIngestQueueBackpressure(t *testing.T) {
+	// No worker drains this queue, so the second observation has to wait.
+	q := &ingestQueue{
+		opts:  IngestOptions{QueueSize: 1, EnqueueTimeout: 20 * time.Millisecond},
+		items: make(chan store.AddObservationParams, 1),
+		done:  make(chan struct{}),
+	}
+	srv := &Server{ingest: q}
+
+	if err := q.enqueue(context.Background(), store.AddObservationParams{Title: "fits"}); err != nil {
+		t.Fatalf("first enqueue: %v", err)
+	}
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	srv.enqueueObservation(rec, httptest.NewRequest(http.MethodPost, "/observations", nil), store.AddObservationParams{Title: "waits"})
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected 503 with Retry-After, got %d %v", rec.Code, rec.Header())
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatalf("expected the request to wait for room before giving up")
+	}
+	if q.status().Rejected != 1 {
+		t.Fatalf("expected one rejection, got %+v", q.status())
+	}
+}
+
+func TestIngestQueueDisabledKeepsSynchronousWrites(t *testing.T) {
+	st := newServerTestStore(t)
+	if err := st.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	srv := New(st, 0)
+	srv.EnableIngestQueue(IngestOptions{})
+	defer srv.Close()
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/observations",
+		strings.NewReader(`{"session_id":"s1","title":"Sync","content":"written now"}`)))
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"id"`) {
+		t.Fatalf("expected synchronous 201 with id, got %d %s", rec.Code, rec.Body.String())
+	}
+}
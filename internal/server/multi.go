@@ -0,0 +1,330 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// Mount pairs a store with the name it is served under. A mount named
+// "alice" exposes the full single-store API at /u/alice/...
+type Mount struct {
+	Name  string
+	Store *store.Store
+}
+
+// MultiServer serves several stores behind one HTTP listener. Each store is
+// backed by a regular *Server mounted under /u/{name}/, so every single-store
+// route works unchanged below the prefix.
+type MultiServer struct {
+	mounts     []Mount
+	servers    map[string]*Server
+	mux        *http.ServeMux
+	middleware []Middleware
+	port       int
+	listen     func(network, address string) (net.Listener, error)
+	serve      func(net.Listener, http.Handler) error
+	version    string
+
+	// tenants is set in tenant mode; see SetTenants.
+	tenants       []tenantRoute
+	operatorToken string
+}
+
+// ValidateMountName checks that name can be served as /u/{name}/: one path
+// segment of letters, digits, dots, dashes, and underscores that does not
+// start with a dot, so "." and ".." are out.
+func ValidateMountName(name string) error {
+	if name == "" {
+		return fmt.Errorf("mount name must not be empty")
+	}
+	for i, r := range name {
+		ok := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' && i > 0
+		if !ok {
+			return fmt.Errorf("invalid mount name %q: use letters, digits, '.', '-', and '_', not starting with '.'", name)
+		}
+	}
+	return nil
+}
+
+// NewMulti builds a MultiServer for the given mounts. Mount names must pass
+// ValidateMountName and be unique.
+func NewMulti(mounts []Mount, port int) (*MultiServer, error) {
+	ms := &MultiServer{
+		servers: make(map[string]*Server, len(mounts)),
+		mux:     http.NewServeMux(),
+		port:    port,
+		listen:  net.Listen,
+		serve:   http.Serve,
+	}
+
+	for _, m := range mounts {
+		if err := ValidateMountName(m.Name); err != nil {
+			return nil, fmt.Errorf("engram server: %w", err)
+		}
+		if _, dup := ms.servers[m.Name]; dup {
+			return nil, fmt.Errorf("engram server: duplicate mount %q", m.Name)
+		}
+		srv := New(m.Store, port)
+		ms.servers[m.Name] = srv
+		ms.mounts = append(ms.mounts, m)
+
+		prefix := "/u/" + m.Name
+		ms.mux.Handle(prefix+"/", http.StripPrefix(prefix, srv.Handler()))
+	}
+
+	for _, path := range []string{"", "/" + APIVersion} {
+		ms.mux.Handle("GET "+path+"/health", negotiate(http.HandlerFunc(ms.handleHealth)))
+		ms.mux.Handle("GET "+path+"/ready", negotiate(http.HandlerFunc(ms.handleReady)))
+		ms.mux.Handle("GET "+path+"/version", negotiate(http.HandlerFunc(ms.handleVersion)))
+	}
+	ms.mux.Handle("GET /"+APIVersion+"/stats", negotiate(http.HandlerFunc(ms.handleStats)))
+	ms.mux.Handle("GET /stats", negotiate(deprecated(ms.handleStats)))
+	return ms, nil
+}
+
+// Use registers middleware shared by every mount. Middlewares run in the
+// order they were added (the first one is the outermost).
+func (ms *MultiServer) Use(mw ...Middleware) {
+	ms.middleware = append(ms.middleware, mw...)
+}
+
+// Mount returns the single-store server for a mount name, or nil.
+func (ms *MultiServer) Mount(name string) *Server {
+	return ms.servers[name]
+}
+
+// SetOnWrite configures the write callback on every mounted server.
+func (ms *MultiServer) SetOnWrite(fn func()) {
+	for _, srv := range ms.servers {
+		srv.SetOnWrite(fn)
+	}
+}
+
+// SetVersion records the engram release reported by /version on the root
+// and on every mount.
+func (ms *MultiServer) SetVersion(v string) {
+	ms.version = v
+	for _, srv := range ms.servers {
+		srv.SetVersion(v)
+	}
+}
+
+// EnableIngestQueue gives every mounted server its own ingestion queue.
+func (ms *MultiServer) EnableIngestQueue(opts IngestOptions) {
+	for _, srv := range ms.servers {
+		srv.EnableIngestQueue(opts)
+	}
+}
+
+// Close flushes the ingestion queue of every mounted server.
+func (ms *MultiServer) Close() {
+	for _, srv := range ms.servers {
+		srv.Close()
+	}
+}
+
+func (ms *MultiServer) Handler() http.Handler {
+	if ms.tenants != nil {
+		return chain(http.HandlerFunc(ms.serveTenant), ms.middleware)
+	}
+	return chain(ms.mux, ms.middleware)
+}
+
+func (ms *MultiServer) Start() error {
+	addr := fmt.Sprintf("127.0.0.1:%d", ms.port)
+	listenFn := ms.listen
+	if listenFn == nil {
+		listenFn = net.Listen
+	}
+	serveFn := ms.serve
+	if serveFn == nil {
+		serveFn = http.Serve
+	}
+
+	ln, err := listenFn("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("engram server: listen %s: %w", addr, err)
+	}
+	if ms.tenants != nil {
+		log.Printf("[engram] HTTP server listening on %s (%d tenants)", addr, len(ms.tenants))
+	} else {
+		log.Printf("[engram] HTTP server listening on %s (%d stores)", addr, len(ms.mounts))
+	}
+	return serveFn(ln, ms.Handler())
+}
+
+// ─── Tenant Mode ─────────────────────────────────────────────────────────────
+//
+// In tenant mode every request must carry a tenant token, and it is served
+// by that tenant's store at the plain API root: POST /observations with
+// alice's token writes to alice's store and nowhere else. The /u/{name}/
+// routes and the combined /stats are reachable only with the operator
+// token. GET /health and GET /ready stay public.
+
+// Tenant is an API token and the mount it unlocks in tenant mode.
+type Tenant struct {
+	Name  string
+	Token string
+}
+
+type tenantRoute struct {
+	Tenant
+	handler http.Handler
+}
+
+// SetTenants switches the server to tenant mode. Every tenant must name a
+// mount, and every token, operatorToken included, must be unique. An empty
+// operatorToken leaves the operator routes closed.
+func (ms *MultiServer) SetTenants(tenants []Tenant, operatorToken string) error {
+	routes := make([]tenantRoute, 0, len(tenants))
+	seen := map[string]string{}
+	if operatorToken != "" {
+		seen[operatorToken] = "the operator"
+	}
+	for _, t := range tenants {
+		srv := ms.servers[t.Name]
+		if srv == nil {
+			return fmt.Errorf("engram server: tenant %q has no mount", t.Name)
+		}
+		if t.Token == "" {
+			return fmt.Errorf("engram server: tenant %q has an empty token", t.Name)
+		}
+		if other, dup := seen[t.Token]; dup {
+			return fmt.Errorf("engram server: tenant %q shares its token with %s", t.Name, other)
+		}
+		seen[t.Token] = fmt.Sprintf("tenant %q", t.Name)
+		routes = append(routes, tenantRoute{Tenant: t, handler: srv.Handler()})
+	}
+	ms.tenants = routes
+	ms.operatorToken = operatorToken
+	return nil
+}
+
+// tenantFor returns the route whose token matches got. Every token is
+// compared in constant time so the lookup does not leak a prefix match.
+func (ms *MultiServer) tenantFor(got string) *tenantRoute {
+	var match *tenantRoute
+	for i := range ms.tenants {
+		if validToken(got, ms.tenants[i].Token) {
+			match = &ms.tenants[i]
+		}
+	}
+	return match
+}
+
+func (ms *MultiServer) serveTenant(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == authSessionPath:
+		handleAuthSession(w, r, func(got string) bool {
+			return ms.tenantFor(got) != nil || validToken(got, ms.operatorToken)
+		})
+		return
+	case r.Method == http.MethodGet && publicPaths[strings.TrimPrefix(r.URL.Path, "/"+APIVersion)]:
+		ms.mux.ServeHTTP(w, r)
+		return
+	}
+
+	token := requestToken(r)
+	if validToken(token, ms.operatorToken) {
+		ms.mux.ServeHTTP(w, r)
+		return
+	}
+	tenant := ms.tenantFor(token)
+	if tenant == nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="engram"`)
+		jsonError(w, http.StatusUnauthorized, "missing or invalid token")
+		return
+	}
+	tenant.handler.ServeHTTP(w, r)
+}
+
+// ─── Handlers ────────────────────────────────────────────────────────────────
+
+func (ms *MultiServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(ms.mounts))
+	for _, m := range ms.mounts {
+		names = append(names, m.Name)
+	}
+	sort.Strings(names)
+	status := "ok"
+	if len(ms.notReady()) > 0 {
+		status = "degraded"
+	}
+	body := map[string]any{
+		"status":  status,
+		"service": "engram",
+		"version": "0.1.0",
+		"stores":  names,
+	}
+	if ms.tenants != nil {
+		// Health is public; do not list who has a store here.
+		delete(body, "stores")
+		body["tenants"] = len(ms.tenants)
+	}
+	jsonResponse(w, http.StatusOK, body)
+}
+
+// handleReady answers 200 only when every mounted store is ready; the 503
+// body maps each failing mount to its error (in tenant mode, counts them).
+func (ms *MultiServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	if failing := ms.notReady(); len(failing) > 0 {
+		body := map[string]any{"status": "not_ready", "stores": failing}
+		if ms.tenants != nil {
+			delete(body, "stores")
+			body["failing"] = len(failing)
+		}
+		jsonResponse(w, http.StatusServiceUnavailable, body)
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]any{"status": "ready"})
+}
+
+func (ms *MultiServer) notReady() map[string]string {
+	failing := map[string]string{}
+	for _, m := range ms.mounts {
+		if h := m.Store.Health(); !h.Ready() {
+			failing[m.Name] = h.Error
+		}
+	}
+	return failing
+}
+
+func (ms *MultiServer) handleVersion(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, versionInfo(ms.version))
+}
+
+// MultiStats is the combined stats payload: totals across every mount plus
+// the per-mount breakdown.
+type MultiStats struct {
+	TotalSessions     int                     `json:"total_sessions"`
+	TotalObservations int                     `json:"total_observations"`
+	TotalPrompts      int                     `json:"total_prompts"`
+	Stores            map[string]*store.Stats `json:"stores"`
+	Errors            map[string]string       `json:"errors,omitempty"`
+}
+
+func (ms *MultiServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	combined := MultiStats{Stores: make(map[string]*store.Stats, len(ms.mounts))}
+	for _, m := range ms.mounts {
+		stats, err := loadServerStats(m.Store)
+		if err != nil {
+			if combined.Errors == nil {
+				combined.Errors = make(map[string]string)
+			}
+			combined.Errors[m.Name] = err.Error()
+			continue
+		}
+		combined.Stores[m.Name] = stats
+		combined.TotalSessions += stats.TotalSessions
+		combined.TotalObservations += stats.TotalObservations
+		combined.TotalPrompts += stats.TotalPrompts
+	}
+
+	jsonResponse(w, http.StatusOK, combined)
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMultiServerRoutesRequestsToMountedStores(t *testing.T) {
+	alice := newServerTestStore(t)
+	bob := newServerTestStore(t)
+
+	ms, err := NewMulti([]Mount{{Name: "alice", Store: alice}, {Name: "bob", Store: bob}}, 0)
+	if err != nil {
+		t.Fatalf("NewMulti: %v", err)
+	}
+
+	var hits int
+	ms.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			next.ServeHTTP(w, r)
+		})
+	})
+	h := ms.Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/u/alice/sessions", strings.NewReader(`{"id":"s1","project":"engram"}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 creating session on alice, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if _, err := alice.GetSession("s1"); err != nil {
+		t.Fatalf("expected session in alice store: %v", err)
+	}
+	if _, err := bob.GetSession("s1"); err == nil {
+		t.Fatalf("expected session to be absent from bob store")
+	}
+
+	statsRec := httptest.NewRecorder()
+	h.ServeHTTP(statsRec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if statsRec.Code != http.StatusOK {
+		t.Fatalf("expected combined stats 200, got %d", statsRec.Code)
+	}
+	var stats MultiStats
+	if err := json.NewDecoder(statsRec.Body).Decode(&stats); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	if stats.TotalSessions != 1 || len(stats.Stores) != 2 {
+		t.Fatalf("unexpected combined stats: %+v", stats)
+	}
+	if stats.Stores["alice"].TotalSessions != 1 || stats.Stores["bob"].TotalSessions != 0 {
+		t.Fatalf("unexpected per-store stats: alice=%+v bob=%+v", stats.Stores["alice"], stats.Stores["bob"])
+	}
+
+	unknownRec := httptest.NewRecorder()
+	h.ServeHTTP(unknownRec, httptest.NewRequest(http.MethodGet, "/u/carol/stats", nil))
+	if unknownRec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown mount, got %d", unknownRec.Code)
+	}
+
+	if hits != 3 {
+		t.Fatalf("expected shared middleware to see 3 requests, got %d", hits)
+	}
+}
+
+func TestMultiServerReadyRequiresEveryMount(t *testing.T) {
+	alice := newServerTestStore(t)
+	bob := newServerTestStore(t)
+	ms, err := NewMulti([]Mount{{Name: "alice", Store: alice}, {Name: "bob", Store: bob}}, 0)
+	if err != nil {
+		t.Fatalf("NewMulti: %v", err)
+	}
+	h := ms.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with all mounts ready, got %d", rec.Code)
+	}
+
+	_ = bob.Close()
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"bob"`) || strings.Contains(rec.Body.String(), `"alice"`) {
+		t.Fatalf("expected 503 naming bob, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"degraded"`) {
+		t.Fatalf("expected degraded multi health, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/u/alice/ready", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected alice mount ready, got %d", rec.Code)
+	}
+}
+
+func TestNewMultiRejectsInvalidMounts(t *testing.T) {
+	st := newServerTestStore(t)
+
+	if _, err := NewMulti([]Mount{{Name: "", Store: st}}, 0); err == nil {
+		t.Fatalf("expected error for empty mount name")
+	}
+	if _, err := NewMulti([]Mount{{Name: "a", Store: st}, {Name: "a", Store: st}}, 0); err == nil {
+		t.Fatalf("expected error for duplicate mount name")
+	}
+	for _, name := range []string{".", "..", ".hidden", "a/b", "a b", "100%", "a?b"} {
+		if _, err := NewMulti([]Mount{{Name: name, Store: st}}, 0); err == nil || !strings.Contains(err.Error(), "invalid mount name") {
+			t.Fatalf("expected mount name %q to be rejected, got %v", name, err)
+		}
+	}
+	if err := ValidateMountName("alice.work-2_b"); err != nil {
+		t.Fatalf("expected a plain name to be accepted, got %v", err)
+	}
+}
+
+func TestMultiServerTenantModeIsolatesStores(t *testing.T) {
+	alice := newServerTestStore(t)
+	bob := newServerTestStore(t)
+	ms, err := NewMulti([]Mount{{Name: "alice", Store: alice}, {Name: "bob", Store: bob}}, 0)
+	if err != nil {
+		t.Fatalf("NewMulti: %v", err)
+	}
+	if err := ms.SetTenants([]Tenant{{Name: "alice", Token: "tok-a"}, {Name: "bob", Token: "tok-b"}}, "ops"); err != nil {
+		t.Fatalf("SetTenants: %v", err)
+	}
+	h := ms.Handler()
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/sessions", "tok-a", `{"id":"s1","project":"engram"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected alice's token to write at the API root, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := alice.GetSession("s1"); err != nil {
+		t.Fatalf("expected the session in alice's store: %v", err)
+	}
+	if _, err := bob.GetSession("s1"); err == nil {
+		t.Fatal("expected bob's store untouched")
+	}
+
+	var own struct {
+		TotalSessions int `json:"total_sessions"`
+	}
+	rec := do(http.MethodGet, "/stats", "tok-b", "")
+	if err := json.NewDecoder(rec.Body).Decode(&own); err != nil || rec.Code != http.StatusOK || own.TotalSessions != 0 {
+		t.Fatalf("expected bob to see only his own stats, got %d %+v err=%v", rec.Code, own, err)
+	}
+	if rec := do(http.MethodGet, "/u/alice/sessions/recent", "tok-b", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected a tenant not to reach another mount, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/stats", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/stats", "nope", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an unknown token, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodGet, "/health", "", "")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "alice") || !strings.Contains(rec.Body.String(), `"tenants":2`) {
+		t.Fatalf("expected public health without tenant names, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	var combined MultiStats
+	rec = do(http.MethodGet, "/stats", "ops", "")
+	if err := json.NewDecoder(rec.Body).Decode(&combined); err != nil || combined.Stores["alice"].TotalSessions != 1 || combined.Stores["bob"].TotalSessions != 0 {
+		t.Fatalf("expected per-tenant stats for the operator, got %d %+v err=%v", rec.Code, combined, err)
+	}
+	if rec := do(http.MethodGet, "/u/alice/sessions/recent", "ops", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected the operator to reach every mount, got %d", rec.Code)
+	}
+
+	rec = do(http.MethodPost, "/auth/session", "", `{"token":"tok-b"}`)
+	if rec.Code != http.StatusOK || len(rec.Result().Cookies()) != 1 {
+		t.Fatalf("expected a tenant token to log in, got %d", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/sessions/recent", nil)
+	req.AddCookie(rec.Result().Cookies()[0])
+	cookieRec := httptest.NewRecorder()
+	h.ServeHTTP(cookieRec, req)
+	if cookieRec.Code != http.StatusOK || strings.Contains(cookieRec.Body.String(), `"s1"`) {
+		t.Fatalf("expected bob's cookie to reach bob's store only, got %d %s", cookieRec.Code, cookieRec.Body.String())
+	}
+}
+
+func TestSetTenantsRejectsInvalidTenants(t *testing.T) {
+	st := newServerTestStore(t)
+	ms, err := NewMulti([]Mount{{Name: "a", Store: st}, {Name: "b", Store: st}}, 0)
+	if err != nil {
+		t.Fatalf("NewMulti: %v", err)
+	}
+	for name, tenants := range map[string][]Tenant{
+		"unknown mount":  {{Name: "c", Token: "x"}},
+		"empty token":    {{Name: "a"}},
+		"shared token":   {{Name: "a", Token: "x"}, {Name: "b", Token: "x"}},
+		"operator clash": {{Name: "a", Token: "ops"}},
+	} {
+		if err := ms.SetTenants(tenants, "ops"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -10,11 +10,16 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
+	"mime"
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/Gentleman-Programming/engram/internal/backup"
 	"github.com/Gentleman-Programming/engram/internal/store"
 )
 
@@ -28,6 +33,12 @@ type SyncStatusProvider interface {
 	Status() SyncStatus
 }
 
+// BackupStatusProvider reports scheduled backup state for /health and
+// /stats. It is implemented by backup.Scheduler.
+type BackupStatusProvider interface {
+	Status() backup.Status
+}
+
 // SyncStatus mirrors autosync.Status to avoid a direct import cycle.
 type SyncStatus struct {
 	Phase               string     `json:"phase"`
@@ -45,8 +56,16 @@ type Server struct {
 	serve      func(net.Listener, http.Handler) error
 	onWrite    func() // called after successful local writes (for autosync notification)
 	syncStatus SyncStatusProvider
+	backups    BackupStatusProvider
+	middleware []Middleware
+	ingest     *ingestQueue // nil unless EnableIngestQueue was called
+	version    string       // reported by /version; see SetVersion
 }
 
+// Middleware wraps an http.Handler. On a MultiServer, middlewares run once
+// for every request regardless of which mount serves it.
+type Middleware func(http.Handler) http.Handler
+
 func New(s *store.Store, port int) *Server {
 	srv := &Server{store: s, port: port, listen: net.Listen, serve: http.Serve}
 	srv.mux = http.NewServeMux()
@@ -65,6 +84,12 @@ func (s *Server) SetSyncStatus(provider SyncStatusProvider) {
 	s.syncStatus = provider
 }
 
+// SetBackupStatus configures the backup scheduler reported by /health and
+// /stats. Without one, neither endpoint includes a backup field.
+func (s *Server) SetBackupStatus(provider BackupStatusProvider) {
+	s.backups = provider
+}
+
 // notifyWrite calls the onWrite callback if configured (best-effort, non-blocking).
 func (s *Server) notifyWrite() {
 	if s.onWrite != nil {
@@ -88,74 +113,143 @@ func (s *Server) Start() error {
 		return fmt.Errorf("engram server: listen %s: %w", addr, err)
 	}
 	log.Printf("[engram] HTTP server listening on %s", addr)
-	return serveFn(ln, s.mux)
+	return serveFn(ln, s.Handler())
+}
+
+// Use registers middleware around every route. Middlewares run in the order
+// they were added (the first one is the outermost).
+func (s *Server) Use(mw ...Middleware) {
+	s.middleware = append(s.middleware, mw...)
 }
 
 func (s *Server) Handler() http.Handler {
-	return s.mux
+	return chain(s.mux, s.middleware)
 }
 
+// routes registers every endpoint under /v1 (see handle) and at its legacy
+// unversioned path.
 func (s *Server) routes() {
-	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.handleStable("GET /health", s.handleHealth)
+	s.handleStable("GET /ready", s.handleReady)
+	s.handleStable("GET /version", s.handleVersion)
 
 	// Sessions
-	s.mux.HandleFunc("POST /sessions", s.handleCreateSession)
-	s.mux.HandleFunc("POST /sessions/{id}/end", s.handleEndSession)
-	s.mux.HandleFunc("GET /sessions/recent", s.handleRecentSessions)
-	s.mux.HandleFunc("DELETE /sessions/{id}", s.handleDeleteSession)
+	s.handle("POST /sessions", s.handleCreateSession)
+	s.handle("POST /sessions/{id}/end", s.handleEndSession)
+	s.handle("POST /sessions/{id}/summary", s.handleSessionSummary)
+	s.handle("GET /sessions/recent", s.handleRecentSessions)
+	s.handle("DELETE /sessions/{id}", s.handleDeleteSession)
+	s.handle("GET /sessions/{id}/transcript", s.handleSessionTranscript)
+	s.handle("GET /sessions/{id}/tool-runs", s.handleSessionToolRuns)
 
 	// Observations
-	s.mux.HandleFunc("POST /observations", s.handleAddObservation)
-	s.mux.HandleFunc("POST /observations/passive", s.handlePassiveCapture)
-	s.mux.HandleFunc("GET /observations/recent", s.handleRecentObservations)
-	s.mux.HandleFunc("PATCH /observations/{id}", s.handleUpdateObservation)
-	s.mux.HandleFunc("DELETE /observations/{id}", s.handleDeleteObservation)
+	s.handle("POST /observations", s.handleAddObservation)
+	s.handle("POST /observations/passive", s.handlePassiveCapture)
+	s.handle("GET /observations/recent", s.handleRecentObservations)
+	s.handle("PATCH /observations/{id}", s.handleUpdateObservation)
+	s.handle("DELETE /observations/{id}", s.handleDeleteObservation)
+	s.handle("POST /observations/{id}/verify", s.handleVerifyObservation)
+
+	// Tool runs
+	s.handle("POST /tool-runs", s.handleAddToolRun)
+
+	// Plugin events and the changefeed
+	s.handle("POST /events/compaction", s.handleCompactionEvent)
+	s.handle("GET /events", s.handleEvents)
 
 	// Search
-	s.mux.HandleFunc("GET /search", s.handleSearch)
+	s.handle("GET /search", s.handleSearch)
+
+	// Topics
+	s.handle("GET /topics", s.handleTopics)
+	s.handle("GET /topics/suggest", s.handleSuggestTopics)
 
 	// Timeline
-	s.mux.HandleFunc("GET /timeline", s.handleTimeline)
-	s.mux.HandleFunc("GET /observations/{id}", s.handleGetObservation)
+	s.handle("GET /timeline", s.handleTimeline)
+	s.handle("GET /observations/{id}", s.handleGetObservation)
 
 	// Prompts
-	s.mux.HandleFunc("POST /prompts", s.handleAddPrompt)
-	s.mux.HandleFunc("GET /prompts/recent", s.handleRecentPrompts)
-	s.mux.HandleFunc("GET /prompts/search", s.handleSearchPrompts)
-	s.mux.HandleFunc("DELETE /prompts/{id}", s.handleDeletePrompt)
+	s.handle("POST /prompts", s.handleAddPrompt)
+	s.handle("GET /prompts/recent", s.handleRecentPrompts)
+	s.handle("GET /prompts/search", s.handleSearchPrompts)
+	s.handle("DELETE /prompts/{id}", s.handleDeletePrompt)
+
+	// Batch writes
+	s.handle("POST /batch", s.handleBatch)
 
 	// Context
-	s.mux.HandleFunc("GET /context", s.handleContext)
+	s.handle("GET /context", s.handleContext)
 
 	// Export / Import
-	s.mux.HandleFunc("GET /export", s.handleExport)
-	s.mux.HandleFunc("POST /import", s.handleImport)
+	s.handle("GET /export", s.handleExport)
+	s.handle("POST /import", s.handleImport)
 
 	// Stats
-	s.mux.HandleFunc("GET /stats", s.handleStats)
+	s.handle("GET /stats", s.handleStats)
 
 	// Project migration
-	s.mux.HandleFunc("POST /projects/migrate", s.handleMigrateProject)
+	s.handle("POST /projects/migrate", s.handleMigrateProject)
 
 	// Sync status (degraded-state visibility for autosync)
-	s.mux.HandleFunc("GET /sync/status", s.handleSyncStatus)
+	s.handle("GET /sync/status", s.handleSyncStatus)
 }
 
 // ─── Handlers ────────────────────────────────────────────────────────────────
 
+// handleHealth always answers 200 while the process is up (liveness) and
+// reports database checks in the body; status is "degraded" when the store
+// is not ready. Supervisors that should only route traffic to a working
+// store use /ready instead.
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	jsonResponse(w, http.StatusOK, map[string]any{
-		"status":  "ok",
-		"service": "engram",
-		"version": "0.1.0",
-	})
+	health := s.store.Health()
+	status := "ok"
+	if !health.Ready() {
+		status = "degraded"
+	}
+	body := map[string]any{
+		"status":         status,
+		"service":        "engram",
+		"version":        "0.1.0",
+		"db_reachable":   health.DBReachable,
+		"journal_mode":   health.JournalMode,
+		"wal_ok":         health.WALOK,
+		"wal_size_bytes": health.WALSizeBytes,
+	}
+	if health.LastWriteAt != nil {
+		body["last_write_at"] = *health.LastWriteAt
+	}
+	if health.Error != "" {
+		body["error"] = health.Error
+	}
+	if s.backups != nil {
+		body["backup"] = s.backups.Status()
+	}
+	if s.ingest != nil {
+		body["ingest"] = s.ingest.status()
+	}
+	jsonResponse(w, http.StatusOK, body)
+}
+
+// handleReady answers 200 when the database is reachable and in WAL mode,
+// 503 otherwise.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	health := s.store.Health()
+	if !health.Ready() {
+		jsonResponse(w, http.StatusServiceUnavailable, map[string]any{
+			"status": "not_ready",
+			"error":  health.Error,
+		})
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]any{"status": "ready"})
 }
 
 func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	var body struct {
-		ID        string `json:"id"`
-		Project   string `json:"project"`
-		Directory string `json:"directory"`
+		ID              string `json:"id"`
+		Project         string `json:"project"`
+		Directory       string `json:"directory"`
+		ParentSessionID string `json:"parent_session_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
@@ -166,8 +260,15 @@ func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.store.CreateSession(body.ID, body.Project, body.Directory); err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+	if err := s.store.CreateSessionWithParent(body.ID, body.Project, body.Directory, body.ParentSessionID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrSessionNotFound):
+			// The parent named in the body is missing: a bad request,
+			// not a missing resource.
+			jsonError(w, http.StatusBadRequest, err.Error())
+		default:
+			storeError(w, err)
+		}
 		return
 	}
 
@@ -184,7 +285,7 @@ func (s *Server) handleEndSession(w http.ResponseWriter, r *http.Request) {
 	json.NewDecoder(r.Body).Decode(&body)
 
 	if err := s.store.EndSession(id, body.Summary); err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -192,13 +293,35 @@ func (s *Server) handleEndSession(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, map[string]string{"id": id, "status": "completed"})
 }
 
+// handleSessionSummary takes a machine-written summary (CI pipelines, see
+// `engram save-summary --url`): it creates the session if needed, saves the
+// summary with source "ci", and ends the session.
+func (s *Server) handleSessionSummary(w http.ResponseWriter, r *http.Request) {
+	var body store.SessionSummaryParams
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+		return
+	}
+	body.SessionID = r.PathValue("id")
+	body.Source = store.SourceCI
+
+	id, err := s.store.SaveSessionSummary(body)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	s.notifyWrite()
+	jsonResponse(w, http.StatusCreated, map[string]any{"id": id, "session_id": body.SessionID, "status": "completed"})
+}
+
 func (s *Server) handleRecentSessions(w http.ResponseWriter, r *http.Request) {
 	project := r.URL.Query().Get("project")
 	limit := queryInt(r, "limit", 5)
 
 	sessions, err := s.store.RecentSessions(project, limit)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -215,10 +338,15 @@ func (s *Server) handleAddObservation(w http.ResponseWriter, r *http.Request) {
 		jsonError(w, http.StatusBadRequest, "session_id, title, and content are required")
 		return
 	}
+	body.Source = store.SourceHTTP
+	if s.ingest != nil {
+		s.enqueueObservation(w, r, body)
+		return
+	}
 
 	id, err := s.store.AddObservation(body)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -239,7 +367,7 @@ func (s *Server) handlePassiveCapture(w http.ResponseWriter, r *http.Request) {
 
 	result, err := s.store.PassiveCapture(body)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -252,9 +380,11 @@ func (s *Server) handleRecentObservations(w http.ResponseWriter, r *http.Request
 	scope := r.URL.Query().Get("scope")
 	limit := queryInt(r, "limit", 20)
 
-	obs, err := s.store.RecentObservations(project, scope, limit)
+	obs, err := s.store.RecentObservationsWith(project, scope, limit, store.ContextOptions{
+		IncludeAll: queryBool(r, "include_all", false),
+	})
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -263,25 +393,107 @@ func (s *Server) handleRecentObservations(w http.ResponseWriter, r *http.Request
 
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
-	if query == "" {
-		jsonError(w, http.StatusBadRequest, "q parameter is required")
+	ref := r.URL.Query().Get("ref")
+	file := r.URL.Query().Get("file")
+	source := r.URL.Query().Get("source")
+	metadata, err := store.ParseMetadataFilter(r.URL.Query()["metadata"])
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	opts := store.SearchOptions{
+		Type:          r.URL.Query().Get("type"),
+		Project:       r.URL.Query().Get("project"),
+		Scope:         r.URL.Query().Get("scope"),
+		Limit:         queryInt(r, "limit", 10),
+		Ref:           ref,
+		File:          file,
+		Source:        source,
+		SessionID:     r.URL.Query().Get("session_id"),
+		StartedAfter:  r.URL.Query().Get("started_after"),
+		StartedBefore: r.URL.Query().Get("started_before"),
+		Explain:       queryBool(r, "explain", false),
+		Metadata:      metadata,
+	}
+	windowed := opts.SessionID != "" || opts.StartedAfter != "" || opts.StartedBefore != ""
+	if query == "" && ref == "" && file == "" && source == "" && len(metadata) == 0 && !windowed {
+		jsonError(w, http.StatusBadRequest, "q, ref, file, source, metadata, session_id, started_after, or started_before parameter is required")
+		return
+	}
+	for name, bound := range map[string]string{"started_after": opts.StartedAfter, "started_before": opts.StartedBefore} {
+		if _, err := store.ParseSearchTime(bound); bound != "" && err != nil {
+			jsonError(w, http.StatusBadRequest, name+": "+err.Error())
+			return
+		}
+	}
 
-	results, err := s.store.Search(query, store.SearchOptions{
-		Type:    r.URL.Query().Get("type"),
-		Project: r.URL.Query().Get("project"),
-		Scope:   r.URL.Query().Get("scope"),
-		Limit:   queryInt(r, "limit", 10),
-	})
+	results, err := s.store.Search(query, opts)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
+	if mediaType, comma, ok := delimitedType(r.Header.Get("Accept")); ok {
+		w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		store.WriteSearchCSV(w, results, comma)
+		return
+	}
 	jsonResponse(w, http.StatusOK, results)
 }
 
+// delimitedType reports whether Accept asks for CSV (text/csv) or TSV
+// (text/tab-separated-values) before JSON, returning the media type and
+// its separator.
+func delimitedType(accept string) (string, rune, bool) {
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv":
+			return mediaType, ',', true
+		case "text/tab-separated-values":
+			return mediaType, '\t', true
+		case "application/json", "*/*":
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
+
+func (s *Server) handleTopics(w http.ResponseWriter, r *http.Request) {
+	topics, err := s.store.Topics(r.URL.Query().Get("project"), r.URL.Query().Get("scope"))
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	if topics == nil {
+		topics = []store.TopicSummary{}
+	}
+
+	jsonResponse(w, http.StatusOK, topics)
+}
+
+func (s *Server) handleSuggestTopics(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		jsonError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	matches, err := s.store.SuggestTopics(query, r.URL.Query().Get("project"), r.URL.Query().Get("scope"), queryInt(r, "limit", 10))
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	if matches == nil {
+		matches = []store.TopicMatch{}
+	}
+
+	jsonResponse(w, http.StatusOK, matches)
+}
+
 func (s *Server) handleGetObservation(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -291,10 +503,14 @@ func (s *Server) handleGetObservation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	obs, err := s.store.GetObservation(id)
-	if err != nil {
+	if store.ErrorCode(err) == store.CodeNotFound {
 		jsonError(w, http.StatusNotFound, "observation not found")
 		return
 	}
+	if err != nil {
+		storeError(w, err)
+		return
+	}
 
 	jsonResponse(w, http.StatusOK, obs)
 }
@@ -320,7 +536,7 @@ func (s *Server) handleUpdateObservation(w http.ResponseWriter, r *http.Request)
 
 	obs, err := s.store.UpdateObservation(id, body)
 	if err != nil {
-		jsonError(w, http.StatusNotFound, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -338,7 +554,7 @@ func (s *Server) handleDeleteObservation(w http.ResponseWriter, r *http.Request)
 
 	hard := queryBool(r, "hard", false)
 	if err := s.store.DeleteObservation(id, hard); err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -350,6 +566,29 @@ func (s *Server) handleDeleteObservation(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+func (s *Server) handleVerifyObservation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid observation id")
+		return
+	}
+
+	var body store.VerifyParams
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+		return
+	}
+
+	obs, err := s.store.VerifyObservation(id, body)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	s.notifyWrite()
+	jsonResponse(w, http.StatusOK, obs)
+}
+
 func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get("observation_id")
 	if idStr == "" {
@@ -368,13 +607,114 @@ func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
 
 	result, err := s.store.Timeline(id, before, after)
 	if err != nil {
-		jsonError(w, http.StatusNotFound, err.Error())
+		storeError(w, err)
 		return
 	}
 
 	jsonResponse(w, http.StatusOK, result)
 }
 
+// ─── Tool Runs ───────────────────────────────────────────────────────────────
+
+func (s *Server) handleAddToolRun(w http.ResponseWriter, r *http.Request) {
+	var body store.AddToolRunParams
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+		return
+	}
+	if body.SessionID == "" || body.ToolName == "" {
+		jsonError(w, http.StatusBadRequest, "session_id and tool_name are required")
+		return
+	}
+
+	id, err := s.store.AddToolRun(body)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	s.notifyWrite()
+	jsonResponse(w, http.StatusCreated, map[string]any{"id": id, "status": "saved"})
+}
+
+func (s *Server) handleSessionToolRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := s.store.SessionToolRuns(r.PathValue("id"), queryInt(r, "limit", 0))
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	if runs == nil {
+		runs = []store.ToolRun{}
+	}
+	jsonResponse(w, http.StatusOK, runs)
+}
+
+// ─── Events ──────────────────────────────────────────────────────────────────
+
+// handleCompactionEvent records a context compaction a plugin detected, so
+// the next mem_context call resurfaces the latest session summary.
+func (s *Server) handleCompactionEvent(w http.ResponseWriter, r *http.Request) {
+	var body store.CompactionParams
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+		return
+	}
+	body.Source = store.SourceHTTP
+
+	event, err := s.store.RecordCompaction(body)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	s.notifyWrite()
+	jsonResponse(w, http.StatusCreated, map[string]any{
+		"id":             event.ID,
+		"observation_id": event.ObservationID,
+		"status":         "recorded",
+	})
+}
+
+// maxEventWait caps how long GET /events holds a long poll open.
+const maxEventWait = 60 * time.Second
+
+// handleEvents serves the changefeed after the since cursor. With wait
+// (seconds) and nothing new yet, it long-polls until an event is recorded or
+// wait elapses, then answers with no events and the same cursor. A cursor
+// whose events were pruned gets 410 Gone.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	var since int64
+	if v := r.URL.Query().Get("since"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			jsonError(w, http.StatusBadRequest, "since must be a non-negative event cursor")
+			return
+		}
+		since = n
+	}
+	wait := min(time.Duration(queryInt(r, "wait", 0))*time.Second, maxEventWait)
+
+	events, err := s.store.WaitEvents(r.Context(), since, queryInt(r, "limit", 100), wait)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return // client went away
+		}
+		if errors.Is(err, store.ErrEventCursorExpired) {
+			jsonError(w, http.StatusGone, err.Error())
+			return
+		}
+		storeError(w, err)
+		return
+	}
+	next := since
+	if len(events) > 0 {
+		next = events[len(events)-1].Seq
+	} else {
+		events = []store.Event{}
+	}
+	jsonResponse(w, http.StatusOK, map[string]any{"events": events, "next_cursor": next})
+}
+
 // ─── Prompts ─────────────────────────────────────────────────────────────────
 
 func (s *Server) handleAddPrompt(w http.ResponseWriter, r *http.Request) {
@@ -390,7 +730,7 @@ func (s *Server) handleAddPrompt(w http.ResponseWriter, r *http.Request) {
 
 	id, err := s.store.AddPrompt(body)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -398,13 +738,64 @@ func (s *Server) handleAddPrompt(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusCreated, map[string]any{"id": id, "status": "saved"})
 }
 
+// maxBatchItems bounds one POST /batch so a single request cannot hold the
+// write transaction for long.
+const maxBatchItems = 1000
+
+// handleBatch applies sessions, prompts, and observations in one
+// transaction. It answers 201 when every item was applied, 207 when some
+// failed and the rest were committed, and 422 when an atomic batch was
+// rolled back; the body always reports each item.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 50<<20)
+	var body struct {
+		Atomic bool              `json:"atomic"`
+		Items  []store.BatchItem `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+		return
+	}
+	if len(body.Items) == 0 {
+		jsonError(w, http.StatusBadRequest, "items is required")
+		return
+	}
+	if len(body.Items) > maxBatchItems {
+		jsonError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("at most %d items per batch", maxBatchItems))
+		return
+	}
+	for _, item := range body.Items {
+		if item.Observation != nil {
+			item.Observation.Source = store.SourceHTTP
+		}
+	}
+
+	result, err := s.store.BatchApply(body.Items, store.BatchOptions{Atomic: body.Atomic})
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	if result.Applied > 0 {
+		s.notifyWrite()
+	}
+
+	status := http.StatusCreated
+	switch {
+	case result.RolledBack:
+		status = http.StatusUnprocessableEntity
+	case result.Failed > 0:
+		status = http.StatusMultiStatus
+	}
+	jsonResponse(w, status, result)
+}
+
 func (s *Server) handleRecentPrompts(w http.ResponseWriter, r *http.Request) {
 	project := r.URL.Query().Get("project")
 	limit := queryInt(r, "limit", 20)
 
 	prompts, err := s.store.RecentPrompts(project, limit)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -424,7 +815,7 @@ func (s *Server) handleSearchPrompts(w http.ResponseWriter, r *http.Request) {
 		queryInt(r, "limit", 10),
 	)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -439,14 +830,7 @@ func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := s.store.DeleteSession(id); err != nil {
-		switch {
-		case errors.Is(err, store.ErrSessionHasObservations):
-			jsonError(w, http.StatusConflict, err.Error())
-		case errors.Is(err, store.ErrSessionNotFound):
-			jsonError(w, http.StatusNotFound, err.Error())
-		default:
-			jsonError(w, http.StatusInternalServerError, err.Error())
-		}
+		storeError(w, err)
 		return
 	}
 
@@ -455,6 +839,30 @@ func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, map[string]string{"id": id, "status": "deleted"})
 }
 
+func (s *Server) handleSessionTranscript(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	transcript, err := s.store.SessionTranscript(id, store.TranscriptOptions{Redact: queryBool(r, "redact", false)})
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "session-"+transcriptFileName(id)+".md"))
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, transcript)
+}
+
+// transcriptFileName keeps a session ID safe for a download file name.
+func transcriptFileName(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
 func (s *Server) handleDeletePrompt(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -464,11 +872,7 @@ func (s *Server) handleDeletePrompt(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := s.store.DeletePrompt(id); err != nil {
-		if errors.Is(err, store.ErrPromptNotFound) {
-			jsonError(w, http.StatusNotFound, err.Error())
-			return
-		}
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -477,10 +881,16 @@ func (s *Server) handleDeletePrompt(w http.ResponseWriter, r *http.Request) {
 
 // ─── Export / Import ─────────────────────────────────────────────────────────
 
+// handleExport shares the store with a team, so personal-scope observations
+// follow the [sync] personal policy: left out unless ?include_personal=true,
+// and refused outright under "deny".
 func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 	data, err := s.store.Export()
+	if err == nil {
+		data, err = s.store.SharedExport(data, queryBool(r, "include_personal", false))
+	}
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -505,9 +915,15 @@ func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := s.store.Import(&data)
+	mode, err := store.ParseImportConflict(r.URL.Query().Get("on_conflict"))
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := s.store.ImportWithOptions(&data, store.ImportOptions{OnConflict: mode})
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -521,9 +937,23 @@ func (s *Server) handleContext(w http.ResponseWriter, r *http.Request) {
 	project := r.URL.Query().Get("project")
 	scope := r.URL.Query().Get("scope")
 
-	context, err := s.store.FormatContext(project, scope)
+	opts := store.ContextOptions{
+		IncludeParents: queryBool(r, "include_parents", false),
+		IncludeAll:     queryBool(r, "include_all", false),
+	}
+	if queryBool(r, "estimate", false) {
+		estimate, err := s.store.EstimateContext(project, scope, opts)
+		if err != nil {
+			storeError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, estimate)
+		return
+	}
+
+	context, err := s.store.FormatContextWith(project, scope, opts)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
 		return
 	}
 
@@ -533,11 +963,19 @@ func (s *Server) handleContext(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	stats, err := loadServerStats(s.store)
 	if err != nil {
-		jsonError(w, http.StatusInternalServerError, err.Error())
+		storeError(w, err)
+		return
+	}
+	if s.backups == nil {
+		jsonResponse(w, http.StatusOK, stats)
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, stats)
+	backupStatus := s.backups.Status()
+	jsonResponse(w, http.StatusOK, struct {
+		*store.Stats
+		Backup *backup.Status `json:"backup"`
+	}{stats, &backupStatus})
 }
 
 // ─── Sync Status ─────────────────────────────────────────────────────────────
@@ -584,6 +1022,10 @@ func (s *Server) handleMigrateProject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := s.store.MigrateProject(body.OldProject, body.NewProject)
+	if errors.Is(err, store.ErrAppendOnly) {
+		storeError(w, err)
+		return
+	}
 	if err != nil {
 		log.Printf("[engram] project migration failed: %v", err)
 		jsonError(w, http.StatusInternalServerError, "migration failed")
@@ -609,6 +1051,57 @@ func (s *Server) handleMigrateProject(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ─── Middleware ──────────────────────────────────────────────────────────────
+
+// RequestLogger logs one structured record per request with method, path,
+// status, and duration. Server errors log at error level, client errors at
+// warn, everything else at info.
+func RequestLogger(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			level := slog.LevelInfo
+			switch {
+			case rec.status >= 500:
+				level = slog.LevelError
+			case rec.status >= 400:
+				level = slog.LevelWarn
+			}
+			logger.LogAttrs(r.Context(), level, "http request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.status),
+				slog.Duration("duration", time.Since(start)),
+			)
+		})
+	}
+}
+
+// statusRecorder captures the response status for RequestLogger.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func chain(h http.Handler, middleware []Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
 // ─── Helpers ─────────────────────────────────────────────────────────────────
 
 func jsonResponse(w http.ResponseWriter, status int, data any) {
@@ -617,8 +1110,59 @@ func jsonResponse(w http.ResponseWriter, status int, data any) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// Error codes for failures that do not come from the store; store errors
+// use the store.Code constants.
+const (
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "forbidden"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeTooLarge         = "too_large"
+	codeUnavailable      = "unavailable"
+	codeCursorExpired    = "cursor_expired"
+)
+
+// codeStatus is the HTTP status of each store error code.
+var codeStatus = map[string]int{
+	store.CodeNotFound:      http.StatusNotFound,
+	store.CodeValidation:    http.StatusBadRequest,
+	store.CodeConflict:      http.StatusConflict,
+	store.CodeQuotaExceeded: http.StatusInsufficientStorage,
+	store.CodeInternal:      http.StatusInternalServerError,
+}
+
+// jsonError writes {"error": msg, "code": code} with the code matching
+// status, so clients branch on the code instead of the message.
 func jsonError(w http.ResponseWriter, status int, msg string) {
-	jsonResponse(w, status, map[string]string{"error": msg})
+	jsonResponse(w, status, map[string]string{"error": msg, "code": statusCode(status)})
+}
+
+// storeError writes a store error with the status and code of its category.
+func storeError(w http.ResponseWriter, err error) {
+	code := store.ErrorCode(err)
+	jsonResponse(w, codeStatus[code], map[string]string{"error": err.Error(), "code": code})
+}
+
+func statusCode(status int) string {
+	for code, s := range codeStatus {
+		if s == status {
+			return code
+		}
+	}
+	switch status {
+	case http.StatusUnauthorized:
+		return codeUnauthorized
+	case http.StatusForbidden:
+		return codeForbidden
+	case http.StatusMethodNotAllowed:
+		return codeMethodNotAllowed
+	case http.StatusRequestEntityTooLarge:
+		return codeTooLarge
+	case http.StatusServiceUnavailable:
+		return codeUnavailable
+	case http.StatusGone:
+		return codeCursorExpired
+	}
+	return store.CodeInternal
 }
 
 func queryInt(r *http.Request, key string, defaultVal int) int {
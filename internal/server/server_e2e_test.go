@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
@@ -225,6 +226,118 @@ func TestPassiveCaptureEndpointE2E(t *testing.T) {
 	}
 }
 
+func TestObservationRefsEndpointsE2E(t *testing.T) {
+	_, ts := newE2EServer(t)
+	client := ts.Client()
+
+	sessionResp := postJSON(t, client, ts.URL+"/sessions", map[string]any{
+		"id":        "s-refs",
+		"project":   "engram",
+		"directory": "/tmp/engram",
+	})
+	sessionResp.Body.Close()
+
+	obsResp := postJSON(t, client, ts.URL+"/observations", map[string]any{
+		"session_id": "s-refs",
+		"type":       "bugfix",
+		"title":      "Fix token refresh",
+		"content":    "Closes #42",
+		"project":    "engram",
+		"refs":       []string{"https://github.com/acme/api/pull/43"},
+	})
+	if obsResp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201 creating observation, got %d", obsResp.StatusCode)
+	}
+	obsResp.Body.Close()
+
+	searchResp, err := client.Get(ts.URL + "/search?ref=" + url.QueryEscape("#43"))
+	if err != nil {
+		t.Fatalf("search by ref: %v", err)
+	}
+	if searchResp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 ref search, got %d", searchResp.StatusCode)
+	}
+	results := decodeJSON[[]map[string]any](t, searchResp)
+	if len(results) != 1 {
+		t.Fatalf("expected one observation for #43, got %d", len(results))
+	}
+	refs, _ := results[0]["refs"].([]any)
+	if len(refs) != 2 || refs[0] != "https://github.com/acme/api/pull/43" || refs[1] != "#42" {
+		t.Fatalf("expected explicit and detected refs in response, got %v", results[0]["refs"])
+	}
+}
+
+func TestTopicsEndpointE2E(t *testing.T) {
+	_, ts := newE2EServer(t)
+	client := ts.Client()
+
+	emptyResp, err := client.Get(ts.URL + "/topics")
+	if err != nil {
+		t.Fatalf("topics: %v", err)
+	}
+	if empty := decodeJSON[[]map[string]any](t, emptyResp); empty == nil || len(empty) != 0 {
+		t.Fatalf("expected empty JSON array before any topics, got %v", empty)
+	}
+
+	sessionResp := postJSON(t, client, ts.URL+"/sessions", map[string]any{
+		"id":        "s-topics",
+		"project":   "engram",
+		"directory": "/tmp/engram",
+	})
+	sessionResp.Body.Close()
+
+	for _, title := range []string{"Auth model", "Auth model v2"} {
+		resp := postJSON(t, client, ts.URL+"/observations", map[string]any{
+			"session_id": "s-topics",
+			"type":       "architecture",
+			"title":      title,
+			"content":    title + " details",
+			"project":    "engram",
+			"topic_key":  "architecture/auth-model",
+		})
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("expected 201 creating observation, got %d", resp.StatusCode)
+		}
+		resp.Body.Close()
+	}
+
+	topicsResp, err := client.Get(ts.URL + "/topics?project=engram&scope=project")
+	if err != nil {
+		t.Fatalf("topics: %v", err)
+	}
+	if topicsResp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 topics, got %d", topicsResp.StatusCode)
+	}
+	topics := decodeJSON[[]map[string]any](t, topicsResp)
+	if len(topics) != 1 {
+		t.Fatalf("expected one topic, got %v", topics)
+	}
+	if topics[0]["topic_key"] != "architecture/auth-model" || topics[0]["latest_title"] != "Auth model v2" || topics[0]["revision_count"] != float64(2) {
+		t.Fatalf("unexpected topic payload: %v", topics[0])
+	}
+
+	suggestResp, err := client.Get(ts.URL + "/topics/suggest?q=auth&project=engram")
+	if err != nil {
+		t.Fatalf("topics suggest: %v", err)
+	}
+	if suggestResp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 topics suggest, got %d", suggestResp.StatusCode)
+	}
+	matches := decodeJSON[[]map[string]any](t, suggestResp)
+	if len(matches) != 1 || matches[0]["topic_key"] != "architecture/auth-model" || matches[0]["match_type"] != "substring" {
+		t.Fatalf("unexpected suggest payload: %v", matches)
+	}
+
+	missingResp, err := client.Get(ts.URL + "/topics/suggest")
+	if err != nil {
+		t.Fatalf("topics suggest without q: %v", err)
+	}
+	missingResp.Body.Close()
+	if missingResp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 without q, got %d", missingResp.StatusCode)
+	}
+}
+
 func TestPassiveCaptureEndpointEmptyContentE2E(t *testing.T) {
 	_, ts := newE2EServer(t)
 	client := ts.Client()
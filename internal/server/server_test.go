@@ -2,9 +2,12 @@ package server
 
 import (
 	"bytes"
+	"database/sql"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -13,6 +16,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Gentleman-Programming/engram/internal/backup"
 	"github.com/Gentleman-Programming/engram/internal/store"
 )
 
@@ -145,6 +149,14 @@ func TestAdditionalServerErrorBranches(t *testing.T) {
 		t.Fatalf("expected 400 for oversize import body, got %d", importTooLargeRec.Code)
 	}
 
+	importBadModeReq := httptest.NewRequest(http.MethodPost, "/import?on_conflict=bogus", strings.NewReader(`{}`))
+	importBadModeReq.Header.Set("Content-Type", "application/json")
+	importBadModeRec := httptest.NewRecorder()
+	h.ServeHTTP(importBadModeRec, importBadModeReq)
+	if importBadModeRec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid on_conflict, got %d", importBadModeRec.Code)
+	}
+
 	if err := st.Close(); err != nil {
 		t.Fatalf("close store: %v", err)
 	}
@@ -417,6 +429,95 @@ func TestHandleStatsReturnsInternalServerErrorOnLoaderError(t *testing.T) {
 	}
 }
 
+func TestHealthAndReadyReportStoreChecks(t *testing.T) {
+	st := newServerTestStore(t)
+	h := New(st, 0).Handler()
+
+	get := func(path string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]any
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+		return rec.Code, body
+	}
+
+	if err := st.CreateSession("s-health", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	code, health := get("/health")
+	if code != http.StatusOK || health["status"] != "ok" || health["db_reachable"] != true || health["wal_ok"] != true {
+		t.Fatalf("unexpected healthy response: %d %v", code, health)
+	}
+	if last, _ := health["last_write_at"].(string); last == "" {
+		t.Fatalf("expected last_write_at after a write, got %v", health)
+	}
+	if code, ready := get("/ready"); code != http.StatusOK || ready["status"] != "ready" {
+		t.Fatalf("unexpected ready response: %d %v", code, ready)
+	}
+
+	_ = st.Close()
+	code, health = get("/health")
+	if code != http.StatusOK || health["status"] != "degraded" || health["db_reachable"] != false || health["error"] == nil {
+		t.Fatalf("expected degraded liveness response, got %d %v", code, health)
+	}
+	if code, ready := get("/ready"); code != http.StatusServiceUnavailable || ready["status"] != "not_ready" {
+		t.Fatalf("expected 503 not_ready, got %d %v", code, ready)
+	}
+}
+
+type stubBackupStatus backup.Status
+
+func (b stubBackupStatus) Status() backup.Status { return backup.Status(b) }
+
+func TestHealthAndStatsReportBackupStatus(t *testing.T) {
+	srv := New(newServerTestStore(t), 0)
+	h := srv.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if strings.Contains(rec.Body.String(), "backup") {
+		t.Fatalf("expected no backup field without a scheduler, got %s", rec.Body.String())
+	}
+
+	lastAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	srv.SetBackupStatus(stubBackupStatus{
+		Dir:       "/data/backups",
+		Interval:  "6h0m0s",
+		Retention: 7,
+		Count:     3,
+		Last:      &backup.Snapshot{Path: "/data/backups/engram-20260102T030405Z.db", CreatedAt: lastAt, SizeBytes: 4096},
+	})
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var health struct {
+		Status string        `json:"status"`
+		Backup backup.Status `json:"backup"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&health); err != nil {
+		t.Fatalf("decode health: %v", err)
+	}
+	if health.Status != "ok" || health.Backup.Count != 3 || health.Backup.Last == nil || !health.Backup.Last.CreatedAt.Equal(lastAt) {
+		t.Fatalf("unexpected health backup status: %+v", health)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	var stats map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	backupField, ok := stats["backup"].(map[string]any)
+	if !ok || backupField["count"] != float64(3) {
+		t.Fatalf("expected backup field in stats, got %v", stats["backup"])
+	}
+	if _, ok := stats["total_observations"]; !ok {
+		t.Fatalf("expected store stats fields to stay top-level, got %v", stats)
+	}
+}
+
 // ─── DELETE /sessions/{id} tests ─────────────────────────────────────────────
 
 func TestHandleDeleteSession_Success(t *testing.T) {
@@ -485,8 +586,428 @@ func TestHandleDeleteSession_HasObservations(t *testing.T) {
 	}
 }
 
+func TestErrorResponsesCarryCodes(t *testing.T) {
+	st := newServerTestStore(t)
+	if err := st.CreateSession("s1", "proj", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := st.AddObservation(store.AddObservationParams{SessionID: "s1", Title: "t", Content: "c", Project: "proj"}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	h := New(st, 0).Handler()
+
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{http.MethodDelete, "/sessions/missing", "", http.StatusNotFound, store.CodeNotFound},
+		{http.MethodDelete, "/sessions/s1", "", http.StatusConflict, store.CodeConflict},
+		{http.MethodPatch, "/observations/9999", `{"title":"x"}`, http.StatusNotFound, store.CodeNotFound},
+		{http.MethodGet, "/timeline?observation_id=9999", "", http.StatusNotFound, store.CodeNotFound},
+		{http.MethodPost, "/observations/1/verify", `{"status":"maybe"}`, http.StatusBadRequest, store.CodeValidation},
+		{http.MethodPost, "/sessions", `{}`, http.StatusBadRequest, store.CodeValidation},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s %s: decode: %v", tc.method, tc.path, err)
+		}
+		if rec.Code != tc.status || body["code"] != tc.code || body["error"] == "" {
+			t.Fatalf("%s %s: expected %d %s, got %d %v", tc.method, tc.path, tc.status, tc.code, rec.Code, body)
+		}
+	}
+}
+
+func TestHandleCompactionEvent(t *testing.T) {
+	st := newServerTestStore(t)
+	h := New(st, 0).Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/events/compaction", strings.NewReader(`{"session_id":"s1","project":"proj","trigger":"auto"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		ObservationID int64 `json:"observation_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	obs, err := st.GetObservation(body.ObservationID)
+	if err != nil || obs.Type != "compaction" || obs.Source == nil || *obs.Source != store.SourceHTTP {
+		t.Fatalf("expected an http compaction marker, got %+v err=%v", obs, err)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/events/compaction", strings.NewReader(`{"project":"proj"}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), store.CodeValidation) {
+		t.Fatalf("expected 400 without a session, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleEventsPagesAndLongPolls(t *testing.T) {
+	st := newServerTestStore(t)
+	h := New(st, 0).Handler()
+	if err := st.CreateSession("s1", "proj", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := st.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "bugfix", Title: "Fix retry", Content: "Cap attempts", Project: "proj"}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+
+	type page struct {
+		Events     []store.Event `json:"events"`
+		NextCursor int64         `json:"next_cursor"`
+	}
+	get := func(url string) (int, page) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		var body page
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	code, first := get("/events?limit=1")
+	if code != http.StatusOK || len(first.Events) != 1 || first.Events[0].Kind != store.EventSessionStarted || first.NextCursor != first.Events[0].Seq {
+		t.Fatalf("expected the first event and its cursor, got %d %+v", code, first)
+	}
+	_, rest := get(fmt.Sprintf("/v1/events?since=%d", first.NextCursor))
+	if len(rest.Events) != 1 || rest.Events[0].Kind != store.EventObservationCreated {
+		t.Fatalf("expected the observation after the cursor, got %+v", rest)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = st.EndSession("s1", "done")
+	}()
+	_, woken := get(fmt.Sprintf("/events?since=%d&wait=10", rest.NextCursor))
+	if len(woken.Events) != 1 || woken.Events[0].Kind != store.EventSessionEnded {
+		t.Fatalf("expected the long poll to return the new event, got %+v", woken)
+	}
+
+	_, empty := get(fmt.Sprintf("/events?since=%d", woken.NextCursor))
+	if empty.Events == nil || len(empty.Events) != 0 || empty.NextCursor != woken.NextCursor {
+		t.Fatalf("expected an empty page keeping the cursor, got %+v", empty)
+	}
+	if code, _ := get("/events?since=-1"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a negative cursor, got %d", code)
+	}
+}
+
+func TestHandleEventsExpiredCursor(t *testing.T) {
+	cfg, err := store.DefaultConfig()
+	if err != nil {
+		t.Fatalf("DefaultConfig: %v", err)
+	}
+	cfg.DataDir = t.TempDir()
+	st, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	for _, id := range []string{"s1", "s2", "s3"} {
+		if err := st.CreateSession(id, "proj", "/tmp"); err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", cfg.DatabasePath())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`UPDATE events SET created_at = '2021-03-01T00:00:00Z' WHERE session_id != 's3'`); err != nil {
+		t.Fatalf("age events: %v", err)
+	}
+	if _, err := st.GC(); err != nil {
+		t.Fatalf("gc: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	New(st, 0).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events?since=1", nil))
+	var body map[string]string
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusGone || body["code"] != "cursor_expired" {
+		t.Fatalf("expected 410 cursor_expired for a pruned cursor, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
 // ─── DELETE /prompts/{id} tests ───────────────────────────────────────────────
 
+func TestHandleSessionTranscript(t *testing.T) {
+	st := newServerTestStore(t)
+	if err := st.CreateSession("sess/1", "proj", "/home/alice/proj"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := st.AddObservation(store.AddObservationParams{
+		SessionID: "sess/1", Type: "config", Title: "Staging creds", Content: "api_key=abcd1234efgh", Project: "proj",
+	}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	h := New(st, 0).Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sessions/sess%2F1/transcript?redact=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/markdown; charset=utf-8" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="session-sess_1.md"` {
+		t.Fatalf("unexpected content disposition %q", cd)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "# Session sess/1") || !strings.Contains(body, "api_key=[REDACTED]") || strings.Contains(body, "alice") {
+		t.Fatalf("unexpected redacted transcript:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sessions/missing/transcript", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing session, got %d", rec.Code)
+	}
+}
+
+func TestHandleToolRuns(t *testing.T) {
+	st := newServerTestStore(t)
+	if err := st.CreateSession("s-runs", "proj", "/tmp/proj"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	h := New(st, 0).Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tool-runs", strings.NewReader(
+		`{"session_id":"s-runs","tool_name":"bash","command":"make test","exit_code":0,"duration_ms":1500,"files":[{"path":"main.go","added":4,"removed":1}]}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tool-runs", strings.NewReader(`{"session_id":"s-runs"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without tool_name, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sessions/s-runs/tool-runs", nil))
+	var runs []store.ToolRun
+	if err := json.Unmarshal(rec.Body.Bytes(), &runs); err != nil {
+		t.Fatalf("decode: %v: %s", err, rec.Body.String())
+	}
+	if len(runs) != 1 || runs[0].ExitCode == nil || *runs[0].ExitCode != 0 || len(runs[0].Files) != 1 || runs[0].Files[0].Added != 4 {
+		t.Fatalf("unexpected tool runs: %+v", runs)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sessions/missing/tool-runs", nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("expected empty list, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleVerifyObservation(t *testing.T) {
+	st := newServerTestStore(t)
+	if err := st.CreateSession("s1", "proj", "/tmp/proj"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	id, err := st.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "decision", Title: "Use Redis", Content: "cache in redis", Project: "proj"})
+	if err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	h := New(st, 0).Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/observations/%d/verify", id),
+		strings.NewReader(`{"status":"stale","evidence":"redis client removed"}`)))
+	var obs store.Observation
+	if err := json.Unmarshal(rec.Body.Bytes(), &obs); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with observation, got %d %s", rec.Code, rec.Body.String())
+	}
+	if obs.StaleAt == nil || obs.VerifiedAt == nil {
+		t.Fatalf("expected stale and verified timestamps, got %+v", obs)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/observations/%d/verify", id), strings.NewReader(`{"status":"maybe"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid status, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/observations/9999/verify", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing observation, got %d", rec.Code)
+	}
+}
+
+func TestHandleAddObservationRecordsHTTPSource(t *testing.T) {
+	st := newServerTestStore(t)
+	if err := st.CreateSession("s1", "proj", "/tmp/proj"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	h := New(st, 0).Handler()
+
+	// A client cannot claim a different entry path.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/observations",
+		strings.NewReader(`{"session_id":"s1","title":"Hook save","content":"saved by a hook","project":"proj","source":"cli"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?source=http", nil))
+	var results []store.SearchResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil || len(results) != 1 {
+		t.Fatalf("expected one http result, got %d %s", rec.Code, rec.Body.String())
+	}
+	if results[0].Source == nil || *results[0].Source != store.SourceHTTP {
+		t.Fatalf("expected source http, got %+v", results[0].Source)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?source=cli", nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Fatalf("expected no cli results, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleSessionSummaryTagsCISource(t *testing.T) {
+	st := newServerTestStore(t)
+	srv := New(st, 0)
+	srv.Use(TokenAuth("ci-secret"))
+	h := srv.Handler()
+
+	post := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/sessions/ci-nightly/summary", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := post("", `{"project":"engram","content":"nightly"}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", rec.Code)
+	}
+	if rec := post("ci-secret", `{"project":"engram"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without content, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := post("ci-secret", `{"project":"engram","content":"## Changed\n- Bumped Go","source":"mcp"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	obs, err := st.GetObservation(resp.ID)
+	if err != nil || obs.Type != "session_summary" || obs.Source == nil || *obs.Source != store.SourceCI {
+		t.Fatalf("expected a ci session summary, got %+v err=%v", obs, err)
+	}
+	if sess, err := st.GetSession("ci-nightly"); err != nil || sess.EndedAt == nil {
+		t.Fatalf("expected the session ended, got %+v err=%v", sess, err)
+	}
+}
+
+func TestHandleExportFollowsPersonalSyncPolicy(t *testing.T) {
+	st := newServerTestStore(t)
+	if err := st.CreateSession("s1", "proj", "/tmp/proj"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	for _, p := range []store.AddObservationParams{
+		{SessionID: "s1", Type: "preference", Title: "Prefer tabs", Content: "I like tabs", Project: "proj", Scope: "personal"},
+		{SessionID: "s1", Type: "decision", Title: "Use WAL", Content: "Team decision", Project: "proj"},
+	} {
+		if _, err := st.AddObservation(p); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+	h := New(st, 0).Handler()
+
+	export := func(url string) (int, store.ExportData) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		var data store.ExportData
+		_ = json.Unmarshal(rec.Body.Bytes(), &data)
+		return rec.Code, data
+	}
+	if code, data := export("/export"); code != http.StatusOK || len(data.Observations) != 1 || data.Observations[0].Title != "Use WAL" {
+		t.Fatalf("expected the personal memory left out, got %d %+v", code, data.Observations)
+	}
+	if code, data := export("/export?include_personal=true"); code != http.StatusOK || len(data.Observations) != 2 {
+		t.Fatalf("expected include_personal to share it, got %d %+v", code, data.Observations)
+	}
+}
+
+func TestHandleSearchServesCSVAndTSV(t *testing.T) {
+	st := newServerTestStore(t)
+	if err := st.CreateSession("s1", "proj", "/tmp/proj"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := st.AddObservation(store.AddObservationParams{SessionID: "s1", Type: "decision", Title: "Cache, then retry", Content: "line one\nline two", Project: "proj"}); err != nil {
+		t.Fatalf("add observation: %v", err)
+	}
+	h := New(st, 0).Handler()
+
+	search := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/search?q=cache", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := search("text/csv")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("expected csv, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil || len(records) != 2 || records[0][0] != "id" || records[1][2] != "Cache, then retry" || records[1][6] != "line one\nline two" {
+		t.Fatalf("unexpected csv %q err=%v", records, err)
+	}
+
+	rec = search("text/tab-separated-values, application/json")
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/tab-separated-values") || !strings.HasPrefix(rec.Body.String(), "id\ttype\ttitle") {
+		t.Fatalf("expected tsv, got %q %q", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	if rec := search("application/json, text/csv"); !strings.HasPrefix(rec.Body.String(), "[") {
+		t.Fatalf("expected json when preferred, got %q", rec.Body.String())
+	}
+}
+
+func TestHandleSearchFiltersBySessionAndWindow(t *testing.T) {
+	st := newServerTestStore(t)
+	for _, id := range []string{"s1", "s2"} {
+		if err := st.CreateSession(id, "proj", "/tmp/proj"); err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+		if _, err := st.AddObservation(store.AddObservationParams{SessionID: id, Type: "decision", Title: "Caching in " + id, Content: "cache notes", Project: "proj"}); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+	h := New(st, 0).Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?session_id=s2", nil))
+	var results []store.SearchResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil || len(results) != 1 || results[0].SessionID != "s2" {
+		t.Fatalf("expected one result from s2, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?q=caching&started_after=yesterday", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "started_after") {
+		t.Fatalf("expected 400 for an invalid bound, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
 func TestHandleDeletePrompt_Success(t *testing.T) {
 	st := newServerTestStore(t)
 	srv := New(st, 0)
@@ -538,3 +1059,95 @@ func TestHandleDeletePrompt_BadID(t *testing.T) {
 		t.Fatalf("expected 400 for invalid prompt id, got %d", rec.Code)
 	}
 }
+
+func TestRequestLoggerRecordsStatusAndPath(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	srv := New(newServerTestStore(t), 0)
+	srv.Use(RequestLogger(logger))
+	h := srv.Handler()
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/search", nil))
+
+	out := buf.String()
+	if !strings.Contains(out, "level=INFO") || !strings.Contains(out, "path=/health") || !strings.Contains(out, "status=200") {
+		t.Fatalf("expected info record for /health, got %q", out)
+	}
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "path=/search") || !strings.Contains(out, "status=400") {
+		t.Fatalf("expected warn record for bad /search, got %q", out)
+	}
+}
+
+func TestHandleBatch(t *testing.T) {
+	st := newServerTestStore(t)
+	h := New(st, 0).Handler()
+
+	body := `{"items":[
+		{"kind":"session","session":{"id":"s-batch","project":"proj","directory":"/tmp/proj"}},
+		{"kind":"prompt","prompt":{"session_id":"s-batch","content":"start","project":"proj"}},
+		{"kind":"observation","observation":{"session_id":"s-batch","type":"decision","title":"Batch","content":"saved in a batch","project":"proj","source":"cli"}}
+	]}`
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d %s", rec.Code, rec.Body.String())
+	}
+	var result store.BatchResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || result.Applied != 3 || len(result.Items) != 3 {
+		t.Fatalf("unexpected result: %s", rec.Body.String())
+	}
+	obs, err := st.GetObservation(result.Items[2].ID)
+	if err != nil || obs.Source == nil || *obs.Source != store.SourceHTTP {
+		t.Fatalf("expected an http-sourced observation, got %+v err=%v", obs, err)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(
+		`{"items":[{"kind":"prompt","prompt":{"session_id":"s-batch","content":"ok"}},{"kind":"observation","observation":{"session_id":"s-batch"}}]}`)))
+	if rec.Code != http.StatusMultiStatus || !strings.Contains(rec.Body.String(), "title, and content are required") {
+		t.Fatalf("expected 207 with a per-item error, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(
+		`{"atomic":true,"items":[{"kind":"prompt","prompt":{"session_id":"s-batch","content":"undone"}},{"kind":"bogus"}]}`)))
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), `"rolled_back":true`) {
+		t.Fatalf("expected 422 rolled back, got %d %s", rec.Code, rec.Body.String())
+	}
+	if prompts, _ := st.RecentPrompts("", 10); len(prompts) != 2 {
+		t.Fatalf("expected the atomic batch to leave prompts alone, got %d", len(prompts))
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`{"items":[]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an empty batch, got %d", rec.Code)
+	}
+}
+
+func TestCreateSessionWithParent(t *testing.T) {
+	st := newServerTestStore(t)
+	h := New(st, 0).Handler()
+
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/sessions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := post(`{"id":"s1","project":"engram"}`); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if code := post(`{"id":"s2","project":"engram","parent_session_id":"s1"}`); code != http.StatusCreated {
+		t.Fatalf("expected 201 for a continuation, got %d", code)
+	}
+	if code := post(`{"id":"s3","project":"engram","parent_session_id":"missing"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a missing parent, got %d", code)
+	}
+	if sess, err := st.GetSession("s2"); err != nil || sess.ParentSessionID == nil || *sess.ParentSessionID != "s1" {
+		t.Fatalf("expected s2 to continue s1, got %+v err=%v", sess, err)
+	}
+}
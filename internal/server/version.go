@@ -0,0 +1,129 @@
+package server
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// ─── API Versioning ──────────────────────────────────────────────────────────
+//
+// Every route is served under /v1. The bare paths plugins were written
+// against stay as aliases for a deprecation period: they answer exactly like
+// /v1 but carry a Deprecation header and a Link to their successor.
+//
+// Clients may also ask for a version with
+// "Accept: application/vnd.engram.v1+json". A request for a version this
+// server does not speak gets 406 with the supported list, instead of a
+// response in a shape the client does not expect.
+
+// APIVersion is the current HTTP API version and route prefix.
+const APIVersion = "v1"
+
+// APIVersions lists every API version this server speaks.
+var APIVersions = []string{APIVersion}
+
+// APIVersionHeader reports the version a response was served as.
+const APIVersionHeader = "Engram-API-Version"
+
+const vendorMediaPrefix = "application/vnd.engram."
+
+// handle registers h under /v1 and as a deprecated legacy alias.
+func (s *Server) handle(pattern string, h http.HandlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+	s.mux.Handle(method+" /"+APIVersion+path, negotiate(h))
+	s.mux.Handle(pattern, negotiate(deprecated(h)))
+}
+
+// handleStable registers h under /v1 and at its bare path with no
+// deprecation: probes and discovery endpoints keep a fixed address.
+func (s *Server) handleStable(pattern string, h http.HandlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+	s.mux.Handle(method+" /"+APIVersion+path, negotiate(h))
+	s.mux.Handle(pattern, negotiate(h))
+}
+
+// negotiate honors a vendor media type in Accept and stamps the version
+// served.
+func negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requested := requestedVersions(r.Header.Get("Accept")); len(requested) > 0 &&
+			!slices.ContainsFunc(requested, func(v string) bool { return slices.Contains(APIVersions, v) }) {
+			jsonResponse(w, http.StatusNotAcceptable, map[string]any{
+				"error":     "unsupported API version " + strings.Join(requested, ", "),
+				"code":      "unsupported_version",
+				"supported": APIVersions,
+			})
+			return
+		}
+		w.Header().Set(APIVersionHeader, APIVersion)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestedVersions returns the engram versions named in an Accept header.
+// It is empty when the client accepts plain JSON or anything else, so only
+// clients that opted into versioned media types can be refused.
+func requestedVersions(accept string) []string {
+	var versions []string
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		v, ok := strings.CutPrefix(mediaType, vendorMediaPrefix)
+		if !ok {
+			return nil
+		}
+		versions = append(versions, strings.TrimSuffix(v, "+json"))
+	}
+	return versions
+}
+
+// deprecated marks a legacy alias. The successor link keeps any mount
+// prefix the request came through, e.g. /u/alice/v1/search.
+func deprecated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		prefix := ""
+		if original, err := url.ParseRequestURI(r.RequestURI); err == nil {
+			prefix = strings.TrimSuffix(original.Path, r.URL.Path)
+		}
+		successor := prefix + "/" + APIVersion + r.URL.Path
+		w.Header().Set("Deprecation", "true")
+		w.Header().Add("Link", "<"+successor+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
+
+// VersionInfo is the body of GET /version.
+type VersionInfo struct {
+	Server        string   `json:"server"`
+	APIVersion    string   `json:"api_version"`
+	APIVersions   []string `json:"api_versions"`
+	SchemaVersion int      `json:"schema_version"`
+}
+
+// SetVersion records the engram release reported by /version.
+func (s *Server) SetVersion(v string) {
+	s.version = v
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, versionInfo(s.version))
+}
+
+func versionInfo(server string) VersionInfo {
+	if server == "" {
+		server = "dev"
+	}
+	return VersionInfo{
+		Server:        server,
+		APIVersion:    APIVersion,
+		APIVersions:   APIVersions,
+		SchemaVersion: store.SchemaVersion,
+	}
+}
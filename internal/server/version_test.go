@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+func TestVersionedRoutesAndLegacyAliases(t *testing.T) {
+	srv := New(newServerTestStore(t), 0)
+	srv.SetVersion("1.2.3")
+	h := srv.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/sessions", strings.NewReader(`{"id":"s1","project":"engram"}`)))
+	if rec.Code != http.StatusCreated || rec.Header().Get(APIVersionHeader) != "v1" || rec.Header().Get("Deprecation") != "" {
+		t.Fatalf("expected an undeprecated v1 response, got %d %v", rec.Code, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sessions/recent?project=engram", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"s1"`) {
+		t.Fatalf("expected the legacy alias to keep working, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Deprecation") != "true" || rec.Header().Get("Link") != `</v1/sessions/recent>; rel="successor-version"` {
+		t.Fatalf("expected deprecation headers on the legacy alias, got %v", rec.Header())
+	}
+
+	for _, path := range []string{"/health", "/v1/health"} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("Deprecation") != "" {
+			t.Fatalf("expected %s to stay undeprecated, got %d %v", path, rec.Code, rec.Header())
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	var info VersionInfo
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+		t.Fatalf("decode version: %v", err)
+	}
+	if info.Server != "1.2.3" || info.APIVersion != "v1" || info.SchemaVersion != store.SchemaVersion {
+		t.Fatalf("unexpected version info: %+v", info)
+	}
+}
+
+func TestAcceptHeaderNegotiatesVersion(t *testing.T) {
+	h := New(newServerTestStore(t), 0).Handler()
+
+	for accept, want := range map[string]int{
+		"":                                    http.StatusOK,
+		"application/json":                    http.StatusOK,
+		"application/vnd.engram.v1+json":      http.StatusOK,
+		"application/vnd.engram.v2+json":      http.StatusNotAcceptable,
+		"application/vnd.engram.v2+json, */*": http.StatusOK,
+		"application/vnd.engram.v2+json, application/vnd.engram.v1+json": http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/stats", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Fatalf("Accept %q: expected %d, got %d: %s", accept, want, rec.Code, rec.Body.String())
+		}
+		if want == http.StatusNotAcceptable && !strings.Contains(rec.Body.String(), `"supported":["v1"]`) {
+			t.Fatalf("expected the supported versions in the 406 body, got %s", rec.Body.String())
+		}
+	}
+}
+
+func TestMultiServerVersioningKeepsMountPrefix(t *testing.T) {
+	ms, err := NewMulti([]Mount{{Name: "alice", Store: newServerTestStore(t)}}, 0)
+	if err != nil {
+		t.Fatalf("NewMulti: %v", err)
+	}
+	h := ms.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/u/alice/search?q=x", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Link") != `</u/alice/v1/search>; rel="successor-version"` {
+		t.Fatalf("expected a mount-relative successor link, got %d %v", rec.Code, rec.Header())
+	}
+
+	for _, path := range []string{"/u/alice/v1/search?q=x", "/v1/stats", "/v1/health", "/version"} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("Deprecation") != "" {
+			t.Fatalf("expected %s to answer without deprecation, got %d %v", path, rec.Code, rec.Header())
+		}
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if rec.Header().Get("Deprecation") != "true" {
+		t.Fatalf("expected the legacy combined /stats to be deprecated, got %v", rec.Header())
+	}
+}
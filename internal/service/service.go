@@ -0,0 +1,364 @@
+// Package service installs `engram serve` as a per-user background service:
+// a systemd user unit on Linux, a launchd agent on macOS, and a logon task
+// in the Task Scheduler on Windows.
+//
+// `engram serve` does not speak the Windows Service Control Manager
+// protocol, so on Windows the task runs a small .cmd wrapper at logon
+// instead of registering an SCM service. Every platform passes the data
+// dir and port through ENGRAM_DATA_DIR and ENGRAM_PORT.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Name is the systemd unit and Windows task name.
+	Name = "engram"
+
+	// LaunchdLabel identifies the launchd agent.
+	LaunchdLabel = "com.gentleman-programming.engram"
+)
+
+var (
+	runtimeGOOS = runtime.GOOS
+	runCommand  = func(name string, args ...string) ([]byte, error) {
+		return exec.Command(name, args...).CombinedOutput()
+	}
+	writeFileFn = os.WriteFile
+	removeFn    = os.Remove
+	mkdirAllFn  = os.MkdirAll
+)
+
+// Spec describes the service to install.
+type Spec struct {
+	Executable string // absolute path of the engram binary
+	DataDir    string
+	Port       int
+	LogDir     string // where launchd and the Windows wrapper send output
+}
+
+// Status is the result of Manager.Status.
+type Status struct {
+	Installed bool   `json:"installed"`
+	Running   bool   `json:"running"`
+	Path      string `json:"path"`   // unit, plist, or wrapper script
+	Detail    string `json:"detail"` // raw state reported by the platform tool
+}
+
+// Manager drives the platform's service manager.
+type Manager interface {
+	// Install writes the service definition and registers it. It does not
+	// start the service.
+	Install(spec Spec) (path string, err error)
+	Uninstall() error
+	Start() error
+	Stop() error
+	Status() (Status, error)
+}
+
+// ErrUnsupported is returned by New on platforms without a backend.
+var ErrUnsupported = errors.New("service management is not supported on this platform")
+
+// New returns the manager for the current platform. home is the user's home
+// directory (unit and plist locations); dataDir holds the Windows wrapper.
+func New(home, dataDir string) (Manager, error) {
+	switch runtimeGOOS {
+	case "linux":
+		return &systemd{path: filepath.Join(home, ".config", "systemd", "user", Name+".service")}, nil
+	case "darwin":
+		return &launchd{path: filepath.Join(home, "Library", "LaunchAgents", LaunchdLabel+".plist")}, nil
+	case "windows":
+		return &taskScheduler{path: filepath.Join(dataDir, "service", Name+"-serve.cmd")}, nil
+	default:
+		return nil, fmt.Errorf("%w (%s)", ErrUnsupported, runtimeGOOS)
+	}
+}
+
+func (s Spec) validate() error {
+	if s.Executable == "" || !filepath.IsAbs(s.Executable) {
+		return fmt.Errorf("service: executable must be an absolute path, got %q", s.Executable)
+	}
+	if s.DataDir == "" {
+		return errors.New("service: data dir is required")
+	}
+	if s.Port <= 0 || s.Port > 65535 {
+		return fmt.Errorf("service: invalid port %d", s.Port)
+	}
+	return nil
+}
+
+func writeDefinition(path, content string) error {
+	if err := mkdirAllFn(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("service: %w", err)
+	}
+	if err := writeFileFn(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("service: %w", err)
+	}
+	return nil
+}
+
+func run(name string, args ...string) (string, error) {
+	out, err := runCommand(name, args...)
+	text := strings.TrimSpace(string(out))
+	if err != nil {
+		if text != "" {
+			return text, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, text)
+		}
+		return text, fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return text, nil
+}
+
+func installed(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// ─── systemd (Linux) ─────────────────────────────────────────────────────────
+
+type systemd struct{ path string }
+
+// SystemdUnit renders the user unit for spec.
+func SystemdUnit(spec Spec) string {
+	return fmt.Sprintf(`[Unit]
+Description=Engram memory server
+After=network.target
+
+[Service]
+Type=simple
+ExecStart=%s serve
+ExecReload=/bin/kill -HUP $MAINPID
+Environment=%s
+Environment=%s
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=default.target
+`, systemdQuote(spec.Executable),
+		systemdQuote("ENGRAM_DATA_DIR="+spec.DataDir),
+		systemdQuote("ENGRAM_PORT="+strconv.Itoa(spec.Port)))
+}
+
+// systemdQuote double-quotes a value when it contains spaces or quotes.
+func systemdQuote(v string) string {
+	if !strings.ContainsAny(v, " \t\"\\") {
+		return v
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+}
+
+func (m *systemd) Install(spec Spec) (string, error) {
+	if err := spec.validate(); err != nil {
+		return "", err
+	}
+	if err := writeDefinition(m.path, SystemdUnit(spec)); err != nil {
+		return "", err
+	}
+	if _, err := run("systemctl", "--user", "daemon-reload"); err != nil {
+		return m.path, err
+	}
+	_, err := run("systemctl", "--user", "enable", Name)
+	return m.path, err
+}
+
+func (m *systemd) Uninstall() error {
+	_, _ = run("systemctl", "--user", "disable", "--now", Name)
+	if err := removeFn(m.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("service: %w", err)
+	}
+	_, err := run("systemctl", "--user", "daemon-reload")
+	return err
+}
+
+func (m *systemd) Start() error {
+	_, err := run("systemctl", "--user", "start", Name)
+	return err
+}
+
+func (m *systemd) Stop() error {
+	_, err := run("systemctl", "--user", "stop", Name)
+	return err
+}
+
+func (m *systemd) Status() (Status, error) {
+	st := Status{Installed: installed(m.path), Path: m.path}
+	if !st.Installed {
+		return st, nil
+	}
+	// is-active exits non-zero for every state but "active".
+	out, _ := runCommand("systemctl", "--user", "is-active", Name)
+	st.Detail = strings.TrimSpace(string(out))
+	st.Running = st.Detail == "active"
+	return st, nil
+}
+
+// ─── launchd (macOS) ─────────────────────────────────────────────────────────
+
+type launchd struct{ path string }
+
+// LaunchdPlist renders the launch agent for spec.
+func LaunchdPlist(spec Spec) string {
+	logDir := spec.LogDir
+	if logDir == "" {
+		logDir = spec.DataDir
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+		<string>serve</string>
+	</array>
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>ENGRAM_DATA_DIR</key>
+		<string>%s</string>
+		<key>ENGRAM_PORT</key>
+		<string>%d</string>
+	</dict>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, LaunchdLabel, xmlEscape(spec.Executable), xmlEscape(spec.DataDir), spec.Port,
+		xmlEscape(filepath.Join(logDir, "service.log")), xmlEscape(filepath.Join(logDir, "service.log")))
+}
+
+func xmlEscape(v string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(v)
+}
+
+func (m *launchd) Install(spec Spec) (string, error) {
+	if err := spec.validate(); err != nil {
+		return "", err
+	}
+	return m.path, writeDefinition(m.path, LaunchdPlist(spec))
+}
+
+func (m *launchd) Uninstall() error {
+	_, _ = run("launchctl", "unload", "-w", m.path)
+	if err := removeFn(m.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("service: %w", err)
+	}
+	return nil
+}
+
+// Start loads the agent; RunAtLoad starts the server and -w keeps it
+// loaded across logins.
+func (m *launchd) Start() error {
+	_, err := run("launchctl", "load", "-w", m.path)
+	return err
+}
+
+func (m *launchd) Stop() error {
+	_, err := run("launchctl", "unload", "-w", m.path)
+	return err
+}
+
+func (m *launchd) Status() (Status, error) {
+	st := Status{Installed: installed(m.path), Path: m.path}
+	if !st.Installed {
+		return st, nil
+	}
+	out, err := runCommand("launchctl", "list", LaunchdLabel)
+	if err != nil {
+		st.Detail = "not loaded"
+		return st, nil
+	}
+	// A loaded agent reports "PID" = N; without a PID it is loaded but not running.
+	st.Running = strings.Contains(string(out), `"PID" =`)
+	st.Detail = "loaded"
+	if st.Running {
+		st.Detail = "running"
+	}
+	return st, nil
+}
+
+// ─── Task Scheduler (Windows) ────────────────────────────────────────────────
+
+type taskScheduler struct{ path string }
+
+// WindowsScript renders the .cmd wrapper the logon task runs.
+func WindowsScript(spec Spec) string {
+	logDir := spec.LogDir
+	if logDir == "" {
+		logDir = spec.DataDir
+	}
+	return strings.Join([]string{
+		"@echo off",
+		`set "ENGRAM_DATA_DIR=` + spec.DataDir + `"`,
+		`set "ENGRAM_PORT=` + strconv.Itoa(spec.Port) + `"`,
+		`"` + spec.Executable + `" serve >> "` + filepath.Join(logDir, "service.log") + `" 2>&1`,
+		"",
+	}, "\r\n")
+}
+
+func (m *taskScheduler) Install(spec Spec) (string, error) {
+	if err := spec.validate(); err != nil {
+		return "", err
+	}
+	if err := writeDefinition(m.path, WindowsScript(spec)); err != nil {
+		return "", err
+	}
+	_, err := run("schtasks", "/Create", "/F", "/TN", Name, "/SC", "ONLOGON", "/RL", "LIMITED", "/TR", `"`+m.path+`"`)
+	return m.path, err
+}
+
+func (m *taskScheduler) Uninstall() error {
+	_, _ = run("schtasks", "/End", "/TN", Name)
+	if _, err := run("schtasks", "/Delete", "/F", "/TN", Name); err != nil {
+		return err
+	}
+	if err := removeFn(m.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("service: %w", err)
+	}
+	return nil
+}
+
+func (m *taskScheduler) Start() error {
+	_, err := run("schtasks", "/Run", "/TN", Name)
+	return err
+}
+
+func (m *taskScheduler) Stop() error {
+	_, err := run("schtasks", "/End", "/TN", Name)
+	return err
+}
+
+func (m *taskScheduler) Status() (Status, error) {
+	st := Status{Path: m.path}
+	out, err := runCommand("schtasks", "/Query", "/TN", Name, "/FO", "LIST")
+	if err != nil {
+		return st, nil
+	}
+	st.Installed = true
+	for _, line := range strings.Split(string(out), "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(key) == "Status" {
+			st.Detail = strings.TrimSpace(value)
+		}
+	}
+	st.Running = strings.EqualFold(st.Detail, "Running")
+	return st, nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type commandLog struct {
+	calls  []string
+	output map[string]string
+	fail   map[string]bool
+}
+
+func stubCommands(t *testing.T) *commandLog {
+	t.Helper()
+	log := &commandLog{output: map[string]string{}, fail: map[string]bool{}}
+	old := runCommand
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		call := name + " " + strings.Join(args, " ")
+		log.calls = append(log.calls, call)
+		if log.fail[call] {
+			return []byte(log.output[call]), errors.New("exit status 3")
+		}
+		return []byte(log.output[call]), nil
+	}
+	t.Cleanup(func() { runCommand = old })
+	return log
+}
+
+func withGOOS(t *testing.T, goos string) {
+	t.Helper()
+	old := runtimeGOOS
+	runtimeGOOS = goos
+	t.Cleanup(func() { runtimeGOOS = old })
+}
+
+func TestSystemdInstallWritesUnitAndEnables(t *testing.T) {
+	withGOOS(t, "linux")
+	cmds := stubCommands(t)
+	home := t.TempDir()
+
+	mgr, err := New(home, "/data")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	spec := Spec{Executable: "/opt/engram bin/engram", DataDir: "/home/me/.engram", Port: 8080}
+	path, err := mgr.Install(spec)
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if path != filepath.Join(home, ".config", "systemd", "user", "engram.service") {
+		t.Fatalf("unexpected unit path %q", path)
+	}
+	unit, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read unit: %v", err)
+	}
+	for _, want := range []string{`ExecStart="/opt/engram bin/engram" serve`, "ExecReload=/bin/kill -HUP $MAINPID", "Environment=ENGRAM_DATA_DIR=/home/me/.engram", "Environment=ENGRAM_PORT=8080", "WantedBy=default.target"} {
+		if !strings.Contains(string(unit), want) {
+			t.Fatalf("unit missing %q:\n%s", want, unit)
+		}
+	}
+	if strings.Join(cmds.calls, "; ") != "systemctl --user daemon-reload; systemctl --user enable engram" {
+		t.Fatalf("unexpected commands: %v", cmds.calls)
+	}
+
+	cmds.output["systemctl --user is-active engram"] = "inactive"
+	cmds.fail["systemctl --user is-active engram"] = true
+	st, err := mgr.Status()
+	if err != nil || !st.Installed || st.Running || st.Detail != "inactive" {
+		t.Fatalf("unexpected status: %+v err=%v", st, err)
+	}
+
+	if err := mgr.Uninstall(); err != nil {
+		t.Fatalf("Uninstall: %v", err)
+	}
+	if st, _ := mgr.Status(); st.Installed {
+		t.Fatalf("expected the unit removed")
+	}
+}
+
+func TestInstallRejectsIncompleteSpec(t *testing.T) {
+	withGOOS(t, "darwin")
+	stubCommands(t)
+	mgr, _ := New(t.TempDir(), "/data")
+	for _, spec := range []Spec{
+		{Executable: "engram", DataDir: "/d", Port: 7437},
+		{Executable: "/bin/engram", Port: 7437},
+		{Executable: "/bin/engram", DataDir: "/d", Port: 70000},
+	} {
+		if _, err := mgr.Install(spec); err == nil {
+			t.Fatalf("expected %+v to be rejected", spec)
+		}
+	}
+}
+
+func TestLaunchdPlistAndStatus(t *testing.T) {
+	withGOOS(t, "darwin")
+	cmds := stubCommands(t)
+	home := t.TempDir()
+	mgr, _ := New(home, "/data")
+
+	path, err := mgr.Install(Spec{Executable: "/usr/local/bin/engram", DataDir: "/Users/me/R&D/.engram", Port: 7437})
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	plist, _ := os.ReadFile(path)
+	for _, want := range []string{"<string>" + LaunchdLabel + "</string>", "<string>/Users/me/R&amp;D/.engram</string>", "<string>7437</string>", "<key>RunAtLoad</key>"} {
+		if !strings.Contains(string(plist), want) {
+			t.Fatalf("plist missing %q:\n%s", want, plist)
+		}
+	}
+
+	cmds.output["launchctl list "+LaunchdLabel] = "{\n\t\"PID\" = 4242;\n\t\"Label\" = \"" + LaunchdLabel + "\";\n};"
+	if st, _ := mgr.Status(); !st.Running || st.Detail != "running" {
+		t.Fatalf("expected running status, got %+v", st)
+	}
+	if err := mgr.Start(); err != nil || cmds.calls[len(cmds.calls)-1] != "launchctl load -w "+path {
+		t.Fatalf("unexpected start: %v %v", err, cmds.calls)
+	}
+}
+
+func TestWindowsTaskAndUnsupportedPlatform(t *testing.T) {
+	withGOOS(t, "windows")
+	cmds := stubCommands(t)
+	dataDir := t.TempDir()
+	mgr, _ := New(`C:\Users\me`, dataDir)
+
+	path, err := mgr.Install(Spec{Executable: "/engram/engram.exe", DataDir: dataDir, Port: 7437})
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	script, _ := os.ReadFile(path)
+	if !strings.Contains(string(script), `set "ENGRAM_PORT=7437"`) || !strings.Contains(string(script), "\r\n") {
+		t.Fatalf("unexpected wrapper script:\n%s", script)
+	}
+	if !strings.HasPrefix(cmds.calls[0], "schtasks /Create /F /TN engram /SC ONLOGON") {
+		t.Fatalf("unexpected commands: %v", cmds.calls)
+	}
+
+	cmds.output["schtasks /Query /TN engram /FO LIST"] = "TaskName:      \\engram\r\nStatus:        Running\r\n"
+	if st, _ := mgr.Status(); !st.Installed || !st.Running {
+		t.Fatalf("expected running task, got %+v", st)
+	}
+
+	withGOOS(t, "plan9")
+	if _, err := New("/home", "/data"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+}
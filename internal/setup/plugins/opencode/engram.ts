@@ -29,7 +29,10 @@ const ENGRAM_TOOLS = new Set([
   "mem_update",
   "mem_delete",
   "mem_suggest_topic_key",
+  "mem_topics",
   "mem_save_prompt",
+  "mem_search_prompts",
+  "mem_recent_prompts",
   "mem_session_summary",
   "mem_context",
   "mem_stats",
@@ -77,12 +77,16 @@ var claudeCodeMCPTools = []string{
 	"mcp__plugin_engram_engram__mem_context",
 	"mcp__plugin_engram_engram__mem_get_observation",
 	"mcp__plugin_engram_engram__mem_save",
+	"mcp__plugin_engram_engram__mem_recent_prompts",
 	"mcp__plugin_engram_engram__mem_save_prompt",
+	"mcp__plugin_engram_engram__mem_search_prompts",
 	"mcp__plugin_engram_engram__mem_search",
 	"mcp__plugin_engram_engram__mem_session_end",
 	"mcp__plugin_engram_engram__mem_session_start",
 	"mcp__plugin_engram_engram__mem_session_summary",
 	"mcp__plugin_engram_engram__mem_suggest_topic_key",
+	"mcp__plugin_engram_engram__mem_topic_search",
+	"mcp__plugin_engram_engram__mem_topics",
 	"mcp__plugin_engram_engram__mem_update",
 }
 
@@ -0,0 +1,897 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ─── Postgres Backend ────────────────────────────────────────────────────────
+//
+// PostgresStore implements Backend on PostgreSQL for teams that want one
+// shared memory server instead of a SQLite file per machine. It covers
+// sessions, observations, prompts, search, and context; everything else
+// (sync, archive, file links, quotas, working memory, ...) stays SQLite-only.
+//
+// Tables live in their own "engram" schema. Full-text search uses a stored
+// tsvector column (title and topic key weighted above content) with a GIN
+// index, queried with websearch_to_tsquery, so the query syntax is the one
+// people know from web search: words, "quoted phrases", -exclusions, OR.
+//
+// The backend is library-only: the engram binary never opens it, and the
+// module does not depend on a Postgres driver. Programs that want it import
+// one (`_ "github.com/jackc/pgx/v5/stdlib"` registers PostgresDriver), or
+// pass an already open *sql.DB to NewPostgres. Requires PostgreSQL 12 or
+// newer (generated columns).
+
+// PostgresDriver is the database/sql driver name OpenPostgres uses.
+var PostgresDriver = "pgx"
+
+// ErrUnsupported is returned by PostgresStore for options only the SQLite
+// store implements.
+var ErrUnsupported = errors.New("not supported by the postgres backend")
+
+type PostgresStore struct {
+	db  *sql.DB
+	cfg Config
+}
+
+var _ Backend = (*PostgresStore)(nil)
+
+// IsPostgresDSN reports whether dsn selects the Postgres backend.
+func IsPostgresDSN(dsn string) bool {
+	dsn = strings.ToLower(strings.TrimSpace(dsn))
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// OpenBackend opens the backend cfg.DSN selects: PostgresStore for a
+// postgres:// URL, the SQLite Store otherwise.
+func OpenBackend(cfg Config) (Backend, error) {
+	var backend Backend
+	var err error
+	switch {
+	case strings.TrimSpace(cfg.DSN) == "":
+		backend, err = New(cfg)
+	case IsPostgresDSN(cfg.DSN):
+		backend, err = OpenPostgres(cfg.DSN, cfg)
+	default:
+		return nil, fmt.Errorf("engram: unsupported storage DSN %q (want postgres:// or postgresql://)", redactDSN(cfg.DSN))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
+
+// OpenPostgres connects to dsn through PostgresDriver and creates the
+// schema if needed.
+func OpenPostgres(dsn string, cfg Config) (*PostgresStore, error) {
+	if !slices.Contains(sql.Drivers(), PostgresDriver) {
+		return nil, fmt.Errorf("engram: postgres driver %q is not registered; import github.com/jackc/pgx/v5/stdlib or set store.PostgresDriver", PostgresDriver)
+	}
+	db, err := sql.Open(PostgresDriver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("engram: open postgres %s: %w", redactDSN(dsn), err)
+	}
+	s, err := NewPostgres(db, cfg)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewPostgres wraps an open database and creates the schema if needed.
+// The store owns db from then on; Close closes it.
+func NewPostgres(db *sql.DB, cfg Config) (*PostgresStore, error) {
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("engram: connect postgres: %w", err)
+	}
+	s := &PostgresStore{db: db, cfg: cfg}
+	if err := s.migrate(); err != nil {
+		return nil, fmt.Errorf("engram: migrate postgres: %w", err)
+	}
+	return s, nil
+}
+
+// redactDSN hides the password in a connection URL for error messages.
+func redactDSN(dsn string) string {
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok {
+		return dsn
+	}
+	userinfo, host, ok := strings.Cut(rest, "@")
+	if !ok {
+		return dsn
+	}
+	if user, _, hasPassword := strings.Cut(userinfo, ":"); hasPassword {
+		userinfo = user + ":xxxxx"
+	}
+	return scheme + "://" + userinfo + "@" + host
+}
+
+var postgresSchema = []string{
+	`CREATE SCHEMA IF NOT EXISTS engram`,
+	`CREATE TABLE IF NOT EXISTS engram.sessions (
+		id         TEXT PRIMARY KEY,
+		project    TEXT NOT NULL DEFAULT '',
+		directory  TEXT NOT NULL DEFAULT '',
+		started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		ended_at   TIMESTAMPTZ,
+		summary    TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS engram.observations (
+		id                BIGSERIAL PRIMARY KEY,
+		sync_id           TEXT UNIQUE,
+		session_id        TEXT NOT NULL REFERENCES engram.sessions(id),
+		type              TEXT NOT NULL,
+		title             TEXT NOT NULL,
+		content           TEXT NOT NULL,
+		tool_name         TEXT,
+		project           TEXT,
+		scope             TEXT NOT NULL DEFAULT 'project',
+		topic_key         TEXT,
+		refs              TEXT,
+		normalized_hash   TEXT,
+		source            TEXT,
+		revision_count    INTEGER NOT NULL DEFAULT 1,
+		duplicate_count   INTEGER NOT NULL DEFAULT 1,
+		last_seen_at      TIMESTAMPTZ,
+		created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+		deleted_at        TIMESTAMPTZ,
+		verified_at       TIMESTAMPTZ,
+		stale_at          TIMESTAMPTZ,
+		verification_note TEXT,
+		search_vector     TSVECTOR GENERATED ALWAYS AS (
+			setweight(to_tsvector('simple', title), 'A') ||
+			setweight(to_tsvector('simple', coalesce(topic_key, '')), 'A') ||
+			setweight(to_tsvector('simple', content), 'B') ||
+			setweight(to_tsvector('simple', type || ' ' || coalesce(tool_name, '') || ' ' || coalesce(project, '')), 'C')
+		) STORED
+	)`,
+	`CREATE INDEX IF NOT EXISTS observations_search_idx ON engram.observations USING GIN (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS observations_project_idx ON engram.observations (project, created_at DESC)`,
+	`CREATE INDEX IF NOT EXISTS observations_topic_idx ON engram.observations (topic_key, project, scope)`,
+	`CREATE INDEX IF NOT EXISTS observations_hash_idx ON engram.observations (normalized_hash, created_at)`,
+	`CREATE TABLE IF NOT EXISTS engram.prompts (
+		id            BIGSERIAL PRIMARY KEY,
+		sync_id       TEXT UNIQUE,
+		session_id    TEXT NOT NULL,
+		content       TEXT NOT NULL,
+		project       TEXT,
+		created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+		search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED
+	)`,
+	`CREATE INDEX IF NOT EXISTS prompts_search_idx ON engram.prompts USING GIN (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS prompts_project_idx ON engram.prompts (project, created_at DESC)`,
+	`ALTER TABLE engram.prompts ADD COLUMN IF NOT EXISTS normalized_hash TEXT`,
+	`ALTER TABLE engram.prompts ADD COLUMN IF NOT EXISTS duplicate_count INTEGER NOT NULL DEFAULT 1`,
+	`ALTER TABLE engram.prompts ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ`,
+	`CREATE INDEX IF NOT EXISTS prompts_hash_idx ON engram.prompts (normalized_hash, created_at)`,
+	`ALTER TABLE engram.observations ADD COLUMN IF NOT EXISTS superseded_by BIGINT`,
+	`ALTER TABLE engram.observations ADD COLUMN IF NOT EXISTS metadata TEXT`,
+}
+
+func (s *PostgresStore) migrate() error {
+	for _, stmt := range postgresSchema {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// ─── Postgres SQL helpers ────────────────────────────────────────────────────
+
+// pgTime renders a timestamptz column the way SQLite stores timestamps
+// (TimestampLayout, UTC), so callers see the same strings from both.
+func pgTime(column string) string {
+	return `to_char(` + column + ` AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')`
+}
+
+// pgObservationColumns selects the columns queryObservations scans, in order.
+var pgObservationColumns = `o.id, coalesce(o.sync_id, ''), o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+	o.scope, o.topic_key, o.revision_count, o.duplicate_count, ` + pgTime("o.last_seen_at") + `, ` +
+	pgTime("o.created_at") + `, ` + pgTime("o.updated_at") + `, ` + pgTime("o.deleted_at") + `, o.refs, ` +
+	pgTime("o.verified_at") + `, ` + pgTime("o.stale_at") + `, o.verification_note, o.source, o.metadata`
+
+// rebind turns the ? placeholders shared with the SQLite queries and
+// filter helpers into Postgres $n parameters.
+func rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type pgQuerier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func (s *PostgresStore) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) queryObservations(q pgQuerier, query string, args ...any) ([]Observation, error) {
+	rows, err := q.Query(rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Observation
+	for rows.Next() {
+		var o Observation
+		if err := rows.Scan(
+			&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, &o.Content,
+			&o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt,
+			&o.CreatedAt, &o.UpdatedAt, &o.DeletedAt, &o.Refs, &o.VerifiedAt, &o.StaleAt, &o.VerificationNote, &o.Source, &o.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, o)
+	}
+	return results, rows.Err()
+}
+
+func (s *PostgresStore) getObservation(q pgQuerier, id int64) (*Observation, error) {
+	observations, err := s.queryObservations(q,
+		`SELECT `+pgObservationColumns+` FROM engram.observations o WHERE o.id = ? AND o.deleted_at IS NULL`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(observations) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &observations[0], nil
+}
+
+// pgRefFilterSQL is refFilterSQL for refs stored as a JSON text array.
+func pgRefFilterSQL(column, ref string) (string, []any) {
+	patterns := refLikePatterns(ref)
+	if len(patterns) == 0 {
+		return "", nil
+	}
+	conds := make([]string, len(patterns))
+	args := make([]any, len(patterns))
+	for i, p := range patterns {
+		conds[i] = `r.value LIKE ? ESCAPE '\'`
+		args[i] = p
+	}
+	return " AND EXISTS (SELECT 1 FROM jsonb_array_elements_text(" + column + "::jsonb) r(value) WHERE " + strings.Join(conds, " OR ") + ")", args
+}
+
+// pgMetadataFilterSQL is metadataFilterSQL for metadata stored as a JSON
+// text object; ->> renders numbers and booleans as text the same way.
+func pgMetadataFilterSQL(column string, filter map[string]string) (string, []any) {
+	var clause string
+	var args []any
+	for _, key := range slices.Sorted(maps.Keys(filter)) {
+		clause += " AND (" + column + "::jsonb ->> ?) = ?"
+		args = append(args, key, filter[key])
+	}
+	return clause, args
+}
+
+// pgObservationFilterSQL builds the SearchOptions filters PostgresStore
+// supports.
+func pgObservationFilterSQL(opts SearchOptions) (string, []any, error) {
+	if opts.File != "" {
+		return "", nil, fmt.Errorf("file filter: %w", ErrUnsupported)
+	}
+	if opts.IncludeArchive {
+		return "", nil, fmt.Errorf("archive search: %w", ErrUnsupported)
+	}
+	var query string
+	var args []any
+	if clause, typeArgs := typeFilterSQL("o.type", opts.Type, opts.Types); clause != "" {
+		query += clause
+		args = append(args, typeArgs...)
+	}
+	if opts.Project != "" {
+		clause, clauseArgs := projectFilterSQL("o.project", opts.Project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	if opts.Scope != "" {
+		query += " AND o.scope = ?"
+		args = append(args, normalizeScope(opts.Scope))
+	}
+	if clause, refArgs := pgRefFilterSQL("o.refs", opts.Ref); clause != "" {
+		query += clause
+		args = append(args, refArgs...)
+	}
+	if clause, metaArgs := pgMetadataFilterSQL("o.metadata", opts.Metadata); clause != "" {
+		query += clause
+		args = append(args, metaArgs...)
+	}
+	if clause, sourceArgs := sourceFilterSQL("o.source", opts.Source); clause != "" {
+		query += clause
+		args = append(args, sourceArgs...)
+	}
+	return query, args, nil
+}
+
+// ─── Postgres Sessions ───────────────────────────────────────────────────────
+
+func (s *PostgresStore) CreateSession(id, project, directory string) error {
+	project, _ = NormalizeProject(project)
+	_, err := s.db.Exec(rebind(
+		`INSERT INTO engram.sessions AS sessions (id, project, directory) VALUES (?, ?, ?)
+		 ON CONFLICT (id) DO UPDATE SET
+		   project   = CASE WHEN sessions.project = '' THEN excluded.project ELSE sessions.project END,
+		   directory = CASE WHEN sessions.directory = '' THEN excluded.directory ELSE sessions.directory END`),
+		id, project, directory,
+	)
+	return err
+}
+
+func (s *PostgresStore) EndSession(id string, summary string) error {
+	_, err := s.db.Exec(rebind(`UPDATE engram.sessions SET ended_at = now(), summary = ? WHERE id = ?`),
+		nullableString(summary), id)
+	return err
+}
+
+func (s *PostgresStore) GetSession(id string) (*Session, error) {
+	row := s.db.QueryRow(rebind(
+		`SELECT id, project, directory, `+pgTime("started_at")+`, `+pgTime("ended_at")+`, summary
+		 FROM engram.sessions WHERE id = ?`), id,
+	)
+	var sess Session
+	if err := row.Scan(&sess.ID, &sess.Project, &sess.Directory, &sess.StartedAt, &sess.EndedAt, &sess.Summary); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+func (s *PostgresStore) RecentSessions(project string, limit int) ([]SessionSummary, error) {
+	project, _ = NormalizeProject(project)
+	if limit <= 0 {
+		limit = 5
+	}
+
+	query := `
+		SELECT s.id, s.project, ` + pgTime("s.started_at") + `, ` + pgTime("s.ended_at") + `, s.summary,
+		       COUNT(o.id) AS observation_count
+		FROM engram.sessions s
+		LEFT JOIN engram.observations o ON o.session_id = s.id AND o.deleted_at IS NULL
+		WHERE 1=1
+	`
+	args := []any{}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("s.project", project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	query += " GROUP BY s.id ORDER BY MAX(COALESCE(o.created_at, s.started_at)) DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SessionSummary
+	for rows.Next() {
+		var ss SessionSummary
+		if err := rows.Scan(&ss.ID, &ss.Project, &ss.StartedAt, &ss.EndedAt, &ss.Summary, &ss.ObservationCount); err != nil {
+			return nil, err
+		}
+		results = append(results, ss)
+	}
+	return results, rows.Err()
+}
+
+// ─── Postgres Observations ───────────────────────────────────────────────────
+
+// AddObservation saves an observation with the same topic key upsert,
+// private tag stripping, and dedupe policy as Store.AddObservation.
+func (s *PostgresStore) AddObservation(p AddObservationParams) (int64, error) {
+	p.Project, _ = NormalizeProject(p.Project)
+	title := stripPrivateTags(p.Title)
+	content := stripPrivateTags(p.Content)
+	if len(content) > s.cfg.MaxObservationLength {
+		content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
+	}
+	scope := normalizeScope(p.Scope)
+	normHash := hashNormalized(content)
+	topicKey := normalizeTopicKey(p.TopicKey)
+	refs := observationRefs(p.Refs, title, content)
+	metadata, err := normalizeMetadata(p.Metadata)
+	if err != nil {
+		return 0, err
+	}
+
+	var observationID int64
+	err = s.withTx(func(tx *sql.Tx) error {
+		if topicKey != "" {
+			err := tx.QueryRow(rebind(
+				`SELECT id FROM engram.observations
+				 WHERE topic_key = ?
+				   AND coalesce(project, '') = coalesce(?, '')
+				   AND scope = ?
+				   AND deleted_at IS NULL
+				 ORDER BY updated_at DESC, created_at DESC
+				 LIMIT 1`),
+				topicKey, nullableString(p.Project), scope,
+			).Scan(&observationID)
+			if err == nil && s.cfg.AppendOnly {
+				existing, err := s.getObservation(tx, observationID)
+				if err != nil {
+					return err
+				}
+				if metadata == nil {
+					metadata = existing.Metadata
+				}
+				observationID, err = s.supersedeTx(tx, existing, Observation{
+					SessionID: p.SessionID, Type: p.Type, Title: title, Content: content,
+					ToolName: nullableString(p.ToolName), Project: existing.Project, Scope: scope,
+					TopicKey: existing.TopicKey, Refs: refs, Metadata: metadata, Source: nullableString(p.Source),
+				})
+				return err
+			}
+			if err == nil {
+				_, err = tx.Exec(rebind(
+					`UPDATE engram.observations
+					 SET type = ?, title = ?, content = ?, tool_name = ?, topic_key = ?, refs = ?,
+					     metadata = coalesce(?, metadata),
+					     normalized_hash = ?, stale_at = NULL, revision_count = revision_count + 1,
+					     last_seen_at = now(), updated_at = now()
+					 WHERE id = ?`),
+					p.Type, title, content, nullableString(p.ToolName), nullableString(topicKey), refs, metadata, normHash, observationID,
+				)
+				return err
+			}
+			if err != sql.ErrNoRows {
+				return err
+			}
+		}
+
+		if policy := s.cfg.dedupePolicy(p.Type); policy.Strategy != DedupeOff {
+			window := policy.Window
+			if window <= 0 {
+				window = 15 * time.Minute
+			}
+			err := tx.QueryRow(rebind(
+				`SELECT id FROM engram.observations
+				 WHERE normalized_hash = ?
+				   AND coalesce(project, '') = coalesce(?, '')
+				   AND scope = ?
+				   AND type = ?
+				   AND (? OR title = ?)
+				   AND deleted_at IS NULL
+				   AND created_at >= now() - make_interval(secs => ?)
+				 ORDER BY created_at DESC
+				 LIMIT 1`),
+				normHash, nullableString(p.Project), scope, p.Type, policy.Strategy == DedupeContent, title, window.Seconds(),
+			).Scan(&observationID)
+			if err == nil {
+				_, err = tx.Exec(rebind(
+					`UPDATE engram.observations
+					 SET duplicate_count = duplicate_count + 1, last_seen_at = now(), updated_at = now()
+					 WHERE id = ?`), observationID)
+				return err
+			}
+			if err != sql.ErrNoRows {
+				return err
+			}
+		}
+
+		return tx.QueryRow(rebind(
+			`INSERT INTO engram.observations (sync_id, session_id, type, title, content, tool_name, project, scope, topic_key, refs, metadata, normalized_hash, source, last_seen_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, now())
+			 RETURNING id`),
+			newSyncID("obs"), p.SessionID, p.Type, title, content,
+			nullableString(p.ToolName), nullableString(p.Project), scope, nullableString(topicKey), refs, metadata, normHash, nullableString(p.Source),
+		).Scan(&observationID)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return observationID, nil
+}
+
+func (s *PostgresStore) GetObservation(id int64) (*Observation, error) {
+	return s.getObservation(s.db, id)
+}
+
+func (s *PostgresStore) RecentObservations(project, scope string, limit int) ([]Observation, error) {
+	project, _ = NormalizeProject(project)
+	if limit <= 0 {
+		limit = s.cfg.MaxContextResults
+	}
+
+	query := `SELECT ` + pgObservationColumns + ` FROM engram.observations o WHERE o.deleted_at IS NULL`
+	args := []any{}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("o.project", project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	if scope != "" {
+		query += " AND o.scope = ?"
+		args = append(args, normalizeScope(scope))
+	}
+	clause, ruleArgs := s.cfg.ContextExclude.filterSQL()
+	query += clause
+	args = append(args, ruleArgs...)
+	query += " ORDER BY o.created_at DESC, o.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	return s.queryObservations(s.db, query, args...)
+}
+
+func (s *PostgresStore) UpdateObservation(id int64, p UpdateObservationParams) (*Observation, error) {
+	var updated *Observation
+	err := s.withTx(func(tx *sql.Tx) error {
+		obs, err := s.getObservation(tx, id)
+		if err != nil {
+			return err
+		}
+
+		typ, title, content, scope := obs.Type, obs.Title, obs.Content, obs.Scope
+		project, topicKey := derefString(obs.Project), derefString(obs.TopicKey)
+		if p.Type != nil {
+			typ = *p.Type
+		}
+		if p.Title != nil {
+			title = stripPrivateTags(*p.Title)
+		}
+		if p.Content != nil {
+			content = stripPrivateTags(*p.Content)
+			if len(content) > s.cfg.MaxObservationLength {
+				content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
+			}
+		}
+		if p.Project != nil {
+			project, _ = NormalizeProject(*p.Project)
+		}
+		if p.Scope != nil {
+			scope = normalizeScope(*p.Scope)
+		}
+		if p.TopicKey != nil {
+			topicKey = normalizeTopicKey(*p.TopicKey)
+		}
+		explicitRefs := []string(obs.Refs)
+		if p.Refs != nil {
+			explicitRefs = *p.Refs
+		}
+		metadata := obs.Metadata
+		if p.Metadata != nil {
+			if metadata, err = normalizeMetadata(*p.Metadata); err != nil {
+				return err
+			}
+		}
+
+		if s.cfg.AppendOnly {
+			next, err := s.supersedeTx(tx, obs, Observation{
+				SessionID: obs.SessionID, Type: typ, Title: title, Content: content,
+				ToolName: obs.ToolName, Project: nullableString(project), Scope: scope,
+				TopicKey: nullableString(topicKey), Refs: observationRefs(explicitRefs, title, content), Metadata: metadata, Source: obs.Source,
+			})
+			if err != nil {
+				return err
+			}
+			updated, err = s.getObservation(tx, next)
+			return err
+		}
+
+		if _, err := tx.Exec(rebind(
+			`UPDATE engram.observations
+			 SET type = ?, title = ?, content = ?, project = ?, scope = ?, topic_key = ?, refs = ?, metadata = ?,
+			     normalized_hash = ?, stale_at = NULL, revision_count = revision_count + 1, updated_at = now()
+			 WHERE id = ? AND deleted_at IS NULL`),
+			typ, title, content, nullableString(project), scope, nullableString(topicKey),
+			observationRefs(explicitRefs, title, content), metadata, hashNormalized(content), id,
+		); err != nil {
+			return err
+		}
+		updated, err = s.getObservation(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// supersedeTx is the append-only edit, as in Store.supersedeObservationTx:
+// next becomes a new row and old is soft-deleted with superseded_by set.
+func (s *PostgresStore) supersedeTx(tx *sql.Tx, old *Observation, next Observation) (int64, error) {
+	var id int64
+	if err := tx.QueryRow(rebind(
+		`INSERT INTO engram.observations (sync_id, session_id, type, title, content, tool_name, project, scope, topic_key, refs, metadata, normalized_hash, source, revision_count, last_seen_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, now())
+		 RETURNING id`),
+		newSyncID("obs"), next.SessionID, next.Type, next.Title, next.Content, next.ToolName, next.Project,
+		normalizeScope(next.Scope), next.TopicKey, next.Refs, next.Metadata, hashNormalized(next.Content), next.Source, old.RevisionCount+1,
+	).Scan(&id); err != nil {
+		return 0, fmt.Errorf("supersede #%d: %w", old.ID, err)
+	}
+	if _, err := tx.Exec(rebind(
+		`UPDATE engram.observations SET deleted_at = now(), updated_at = now(), superseded_by = ? WHERE id = ?`),
+		id, old.ID,
+	); err != nil {
+		return 0, fmt.Errorf("supersede #%d: %w", old.ID, err)
+	}
+	return id, nil
+}
+
+func (s *PostgresStore) DeleteObservation(id int64, hardDelete bool) error {
+	if hardDelete && s.cfg.AppendOnly {
+		return ErrAppendOnly
+	}
+	query := `UPDATE engram.observations SET deleted_at = now(), updated_at = now() WHERE id = ? AND deleted_at IS NULL`
+	if hardDelete {
+		query = `DELETE FROM engram.observations WHERE id = ?`
+	}
+	_, err := s.db.Exec(rebind(query), id)
+	return err
+}
+
+// ─── Postgres Prompts ────────────────────────────────────────────────────────
+
+func (s *PostgresStore) AddPrompt(p AddPromptParams) (int64, error) {
+	p.Project, _ = NormalizeProject(p.Project)
+	content := stripPrivateTags(p.Content)
+	if len(content) > s.cfg.MaxObservationLength {
+		content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
+	}
+
+	normHash := hashNormalized(content)
+	window := s.cfg.DedupeWindow
+	if window <= 0 {
+		window = 15 * time.Minute
+	}
+
+	var id int64
+	err := s.withTx(func(tx *sql.Tx) error {
+		err := tx.QueryRow(rebind(
+			`SELECT id FROM engram.prompts
+			 WHERE normalized_hash = ?
+			   AND coalesce(project, '') = coalesce(?, '')
+			   AND created_at >= now() - make_interval(secs => ?)
+			 ORDER BY created_at DESC
+			 LIMIT 1`),
+			normHash, nullableString(p.Project), window.Seconds(),
+		).Scan(&id)
+		if err == nil {
+			_, err = tx.Exec(rebind(
+				`UPDATE engram.prompts SET duplicate_count = duplicate_count + 1, last_seen_at = now() WHERE id = ?`), id)
+			return err
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+		return tx.QueryRow(rebind(
+			`INSERT INTO engram.prompts (sync_id, session_id, content, project, normalized_hash) VALUES (?, ?, ?, ?, ?) RETURNING id`),
+			newSyncID("prompt"), p.SessionID, content, nullableString(p.Project), normHash,
+		).Scan(&id)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *PostgresStore) RecentPrompts(project string, limit int) ([]Prompt, error) {
+	project, _ = NormalizeProject(project)
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := `SELECT p.id, coalesce(p.sync_id, ''), p.session_id, p.content, coalesce(p.project, ''), ` + pgTime("p.created_at") + `, p.duplicate_count
+		FROM engram.prompts p
+		WHERE NOT EXISTS (
+			SELECT 1 FROM engram.prompts d
+			WHERE d.normalized_hash = p.normalized_hash
+			  AND coalesce(d.project, '') = coalesce(p.project, '')
+			  AND (d.created_at, d.id) > (p.created_at, p.id)
+		)`
+	args := []any{}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("p.project", project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	query += " ORDER BY p.created_at DESC, p.id DESC LIMIT ?"
+	args = append(args, limit)
+	return s.queryPrompts(query, args...)
+}
+
+// SearchPrompts matches prompts with websearch_to_tsquery, best first.
+func (s *PostgresStore) SearchPrompts(query string, project string, limit int) ([]Prompt, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	sqlQ := `SELECT p.id, coalesce(p.sync_id, ''), p.session_id, p.content, coalesce(p.project, ''), ` + pgTime("p.created_at") + `, p.duplicate_count
+		FROM engram.prompts p, websearch_to_tsquery('simple', ?) q
+		WHERE p.search_vector @@ q`
+	args := []any{query}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("p.project", project)
+		sqlQ += clause
+		args = append(args, clauseArgs...)
+	}
+	sqlQ += " ORDER BY ts_rank_cd(p.search_vector, q) DESC LIMIT ?"
+	args = append(args, limit)
+
+	results, err := s.queryPrompts(sqlQ, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search prompts: %w", err)
+	}
+	return results, nil
+}
+
+func (s *PostgresStore) queryPrompts(query string, args ...any) ([]Prompt, error) {
+	rows, err := s.db.Query(rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Prompt
+	for rows.Next() {
+		var p Prompt
+		if err := rows.Scan(&p.ID, &p.SyncID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt, &p.DuplicateCount); err != nil {
+			return nil, err
+		}
+		results = append(results, p)
+	}
+	return results, rows.Err()
+}
+
+// ─── Postgres Search & Context ───────────────────────────────────────────────
+
+// Search ranks observations with ts_rank_cd over the tsvector column. As
+// with Store.Search, a query containing "/" also matches topic keys
+// exactly (those hits come first), an empty query with Ref or Source lists
+// the linked observations, and lower Rank is better. The File and
+// IncludeArchive options return ErrUnsupported.
+func (s *PostgresStore) Search(query string, opts SearchOptions) ([]SearchResult, error) {
+	opts.Project, _ = NormalizeProject(opts.Project)
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > s.cfg.MaxSearchResults {
+		limit = s.cfg.MaxSearchResults
+	}
+	offset := max(opts.Offset, 0)
+	window := offset + limit
+	filter, filterArgs, err := pgObservationFilterSQL(opts)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+
+	var results []SearchResult
+	seen := make(map[int64]bool)
+	if strings.Contains(query, "/") {
+		direct, err := s.queryObservations(s.db,
+			`SELECT `+pgObservationColumns+` FROM engram.observations o
+			 WHERE o.topic_key = ? AND o.deleted_at IS NULL`+filter+`
+			 ORDER BY o.updated_at DESC LIMIT ?`,
+			append(append([]any{query}, filterArgs...), window)...)
+		if err != nil {
+			return nil, fmt.Errorf("search: %w", err)
+		}
+		for _, o := range direct {
+			seen[o.ID] = true
+			results = append(results, SearchResult{Observation: o, Rank: topicKeyMatchRank})
+		}
+	}
+
+	if strings.TrimSpace(query) == "" {
+		if opts.Ref == "" && opts.Source == "" {
+			return pageOf(results, offset, limit), nil
+		}
+		linked, err := s.queryObservations(s.db,
+			`SELECT `+pgObservationColumns+` FROM engram.observations o
+			 WHERE o.deleted_at IS NULL`+filter+`
+			 ORDER BY (o.stale_at IS NOT NULL), o.updated_at DESC LIMIT ?`,
+			append(filterArgs, window)...)
+		if err != nil {
+			return nil, fmt.Errorf("search by link: %w", err)
+		}
+		for _, o := range linked {
+			results = append(results, SearchResult{Observation: o})
+		}
+		return pageOf(results, offset, limit), nil
+	}
+
+	rows, err := s.db.Query(rebind(
+		`SELECT `+pgObservationColumns+`, -ts_rank_cd(o.search_vector, q) AS score
+		 FROM engram.observations o, websearch_to_tsquery('simple', ?) q
+		 WHERE o.search_vector @@ q AND o.deleted_at IS NULL`+filter+`
+		 ORDER BY (o.stale_at IS NOT NULL), score, o.id DESC LIMIT ?`),
+		append(append([]any{query}, filterArgs...), window)...)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sr SearchResult
+		if err := rows.Scan(
+			&sr.ID, &sr.SyncID, &sr.SessionID, &sr.Type, &sr.Title, &sr.Content,
+			&sr.ToolName, &sr.Project, &sr.Scope, &sr.TopicKey, &sr.RevisionCount, &sr.DuplicateCount,
+			&sr.LastSeenAt, &sr.CreatedAt, &sr.UpdatedAt, &sr.DeletedAt, &sr.Refs, &sr.VerifiedAt, &sr.StaleAt, &sr.VerificationNote, &sr.Source,
+			&sr.Rank,
+		); err != nil {
+			return nil, err
+		}
+		if !seen[sr.ID] {
+			results = append(results, sr)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	freshFirst(results, func(r SearchResult) bool { return r.StaleAt != nil })
+	return pageOf(results, offset, limit), nil
+}
+
+// pageOf returns at most limit results starting at offset.
+func pageOf(results []SearchResult, offset, limit int) []SearchResult {
+	results = results[min(offset, len(results)):]
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// FormatContext builds the same context block as Store.FormatContext.
+func (s *PostgresStore) FormatContext(project, scope string) (string, error) {
+	project, _ = NormalizeProject(project)
+	sessions, err := s.RecentSessions(project, 5)
+	if err != nil {
+		return "", err
+	}
+	observations, err := s.RecentObservations(project, scope, s.cfg.MaxContextResults)
+	if err != nil {
+		return "", err
+	}
+	prompts, err := s.RecentPrompts(project, 10)
+	if err != nil {
+		return "", err
+	}
+	if len(sessions) == 0 && len(observations) == 0 && len(prompts) == 0 {
+		return "", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("## Memory from Previous Sessions\n\n")
+	writeContextSessions(&b, sessions, nil, s.cfg.FormatTime)
+	writeContextPrompts(&b, prompts, s.cfg.FormatTime)
+	writeContextObservations(&b, "Recent Observations", observations)
+	return b.String(), nil
+}
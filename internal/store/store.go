@@ -6,19 +6,38 @@
 package store
 
 import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Gentleman-Programming/engram/internal/project"
 
 	sqlite "modernc.org/sqlite"
 )
@@ -30,13 +49,76 @@ var openDB = sql.Open
 // See https://www.sqlite.org/rescode.html#constraint_foreignkey
 const sqliteConstraintForeignKey = 787
 
-// Sentinel errors returned by delete operations so callers can use errors.Is.
+// Error categories. Every sentinel below, and every input error the store
+// returns, matches one of them under errors.Is, so callers can tell a missing
+// record or a bad request from a genuine failure without knowing each
+// sentinel. ErrorCode turns an error into a machine-readable code.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrValidation = errors.New("invalid input")
+	ErrConflict   = errors.New("conflict")
+)
+
+// Sentinel errors returned by store operations so callers can use errors.Is.
 var (
-	ErrSessionNotFound        = errors.New("session not found")
-	ErrSessionHasObservations = errors.New("session still has observations")
-	ErrPromptNotFound         = errors.New("prompt not found")
+	ErrSessionNotFound        = categorized(ErrNotFound, "session not found")
+	ErrSessionHasObservations = categorized(ErrConflict, "session still has observations")
+	ErrPromptNotFound         = categorized(ErrNotFound, "prompt not found")
+	ErrSessionExists          = categorized(ErrConflict, "session already exists")
+	ErrQuotaExceeded          = errors.New("project quota exceeded")
+	ErrObservationNotFound    = categorized(ErrNotFound, "observation not found")
+	ErrSessionCycle           = categorized(ErrValidation, "session continuation would form a cycle")
+	ErrNothingToSummarize     = categorized(ErrConflict, "session has no decisions, bugfixes, or file changes to summarize")
+	ErrAppendOnly             = categorized(ErrConflict, "append-only mode: observations cannot be removed or rewritten")
+	ErrEventCursorExpired     = categorized(ErrNotFound, "event cursor expired")
 )
 
+// Error codes returned by ErrorCode.
+const (
+	CodeNotFound      = "not_found"
+	CodeValidation    = "validation"
+	CodeConflict      = "conflict"
+	CodeQuotaExceeded = "quota_exceeded"
+	CodeInternal      = "internal"
+)
+
+// categoryError is an error that also matches its category.
+type categoryError struct {
+	msg      string
+	category error
+}
+
+func (e *categoryError) Error() string        { return e.msg }
+func (e *categoryError) Is(target error) bool { return target == e.category }
+
+func categorized(category error, msg string) error {
+	return &categoryError{msg: msg, category: category}
+}
+
+// invalidf returns an input error matching ErrValidation.
+func invalidf(format string, args ...any) error {
+	return categorized(ErrValidation, fmt.Sprintf(format, args...))
+}
+
+// ErrorCode classifies err as one of the Code constants. A missing row
+// (sql.ErrNoRows) is not_found; anything uncategorized is internal.
+func ErrorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotFound), errors.Is(err, sql.ErrNoRows):
+		return CodeNotFound
+	case errors.Is(err, ErrValidation):
+		return CodeValidation
+	case errors.Is(err, ErrConflict):
+		return CodeConflict
+	case errors.Is(err, ErrQuotaExceeded):
+		return CodeQuotaExceeded
+	default:
+		return CodeInternal
+	}
+}
+
 // ─── Types ───────────────────────────────────────────────────────────────────
 
 type Session struct {
@@ -46,30 +128,57 @@ type Session struct {
 	StartedAt string  `json:"started_at"`
 	EndedAt   *string `json:"ended_at,omitempty"`
 	Summary   *string `json:"summary,omitempty"`
+	// ParentSessionID is the session this one continues (after a
+	// compaction or crash); see CreateSessionWithParent.
+	ParentSessionID *string `json:"parent_session_id,omitempty"`
 }
 
 type Observation struct {
-	ID             int64   `json:"id"`
-	SyncID         string  `json:"sync_id"`
-	SessionID      string  `json:"session_id"`
-	Type           string  `json:"type"`
-	Title          string  `json:"title"`
-	Content        string  `json:"content"`
-	ToolName       *string `json:"tool_name,omitempty"`
-	Project        *string `json:"project,omitempty"`
-	Scope          string  `json:"scope"`
-	TopicKey       *string `json:"topic_key,omitempty"`
-	RevisionCount  int     `json:"revision_count"`
-	DuplicateCount int     `json:"duplicate_count"`
-	LastSeenAt     *string `json:"last_seen_at,omitempty"`
-	CreatedAt      string  `json:"created_at"`
-	UpdatedAt      string  `json:"updated_at"`
-	DeletedAt      *string `json:"deleted_at,omitempty"`
+	ID               int64   `json:"id"`
+	SyncID           string  `json:"sync_id"`
+	SessionID        string  `json:"session_id"`
+	Type             string  `json:"type"`
+	Title            string  `json:"title"`
+	Content          string  `json:"content"`
+	ToolName         *string `json:"tool_name,omitempty"`
+	Project          *string `json:"project,omitempty"`
+	Scope            string  `json:"scope"`
+	TopicKey         *string `json:"topic_key,omitempty"`
+	Refs             RefList `json:"refs,omitempty"`
+	RevisionCount    int     `json:"revision_count"`
+	DuplicateCount   int     `json:"duplicate_count"`
+	LastSeenAt       *string `json:"last_seen_at,omitempty"`
+	CreatedAt        string  `json:"created_at"`
+	UpdatedAt        string  `json:"updated_at"`
+	DeletedAt        *string `json:"deleted_at,omitempty"`
+	VerifiedAt       *string `json:"verified_at,omitempty"` // last time an agent checked it against the code
+	StaleAt          *string `json:"stale_at,omitempty"`    // set while the memory is known to be outdated
+	VerificationNote *string `json:"verification_note,omitempty"`
+	Source           *string `json:"source,omitempty"` // entry path that created it (see Source* constants)
+
+	Metadata Metadata `json:"metadata,omitempty"` // client-attached key-value data (see Metadata)
 }
 
 type SearchResult struct {
 	Observation
-	Rank float64 `json:"rank"`
+	Rank     float64        `json:"rank"`
+	Archived bool           `json:"archived,omitempty"` // found in the archive database
+	Explain  *SearchExplain `json:"explain,omitempty"`  // set with SearchOptions.Explain
+}
+
+// SearchExplain says why a search result ranked where it did. Results are
+// ordered by Rank (weighted bm25, lower is better), with exact topic_key
+// matches first and stale results after fresh ones; Modifiers names the
+// rules that moved this result. Recency and dedupe counts are reported for
+// context only: they do not change the order.
+type SearchExplain struct {
+	Matched        []string `json:"matched"` // FTS columns the query matched: title, content, tool_name, type, project, topic_key
+	TopicKeyMatch  bool     `json:"topic_key_match,omitempty"`
+	Stale          bool     `json:"stale,omitempty"`
+	Modifiers      []string `json:"modifiers,omitempty"`
+	AgeDays        int      `json:"age_days"`
+	DuplicateCount int      `json:"duplicate_count"` // saves absorbed by dedupe
+	RevisionCount  int      `json:"revision_count"`  // topic_key upserts and edits
 }
 
 type SessionSummary struct {
@@ -78,6 +187,7 @@ type SessionSummary struct {
 	StartedAt        string  `json:"started_at"`
 	EndedAt          *string `json:"ended_at,omitempty"`
 	Summary          *string `json:"summary,omitempty"`
+	ParentSessionID  *string `json:"parent_session_id,omitempty"`
 	ObservationCount int     `json:"observation_count"`
 }
 
@@ -86,6 +196,37 @@ type Stats struct {
 	TotalObservations int      `json:"total_observations"`
 	TotalPrompts      int      `json:"total_prompts"`
 	Projects          []string `json:"projects"`
+
+	// Health and storage details.
+	ObservationsByType  map[string]int `json:"observations_by_type,omitempty"`
+	OldestObservationAt *string        `json:"oldest_observation_at,omitempty"`
+	NewestObservationAt *string        `json:"newest_observation_at,omitempty"`
+	DuplicatesAvoided   int            `json:"duplicates_avoided"` // saves absorbed by dedupe (sum of duplicate_count - 1)
+	DBSizeBytes         int64          `json:"db_size_bytes"`
+	WALSizeBytes        int64          `json:"wal_size_bytes"`
+	FTSSizeBytes        int64          `json:"fts_size_bytes"` // observations_fts + prompts_fts shadow tables
+	Quarantined         int            `json:"quarantined"`    // passive captures awaiting review
+
+	// Quotas reports usage for every project with a configured quota.
+	Quotas []QuotaUsage `json:"quotas,omitempty"`
+
+	// Cache counts hits and misses of the recent-query cache behind
+	// FormatContext since the store was opened.
+	Cache CacheStats `json:"cache"`
+
+	// FTS counts full-text queries FTS5 rejected since the store was opened.
+	FTS FTSStats `json:"fts"`
+
+	// Compression reports observation content stored gzip-compressed.
+	Compression CompressionStats `json:"compression"`
+}
+
+// FTSStats counts search queries whose sanitized FTS5 expression was
+// rejected. Fallbacks were answered by a more conservative rewrite;
+// Failures were rejected even then and surfaced as errors.
+type FTSStats struct {
+	Fallbacks int64 `json:"fallbacks"`
+	Failures  int64 `json:"failures"`
 }
 
 type TimelineEntry struct {
@@ -98,6 +239,7 @@ type TimelineEntry struct {
 	Project        *string `json:"project,omitempty"`
 	Scope          string  `json:"scope"`
 	TopicKey       *string `json:"topic_key,omitempty"`
+	Refs           RefList `json:"refs,omitempty"`
 	RevisionCount  int     `json:"revision_count"`
 	DuplicateCount int     `json:"duplicate_count"`
 	LastSeenAt     *string `json:"last_seen_at,omitempty"`
@@ -113,13 +255,86 @@ type TimelineResult struct {
 	After        []TimelineEntry `json:"after"`        // Observations after the focus (chronological)
 	SessionInfo  *Session        `json:"session_info"` // Session that contains the focus observation
 	TotalInRange int             `json:"total_in_range"`
+	// SessionChain lists the sessions SessionInfo continues, nearest parent
+	// first (see SessionChain).
+	SessionChain []Session `json:"session_chain,omitempty"`
+	// ToolRuns are the session's tool runs within the time span covered by
+	// Before, Focus, and After. When the window reaches the first or last
+	// observation of the session, the span stays open on that side.
+	ToolRuns []ToolRun `json:"tool_runs,omitempty"`
 }
 
 type SearchOptions struct {
-	Type    string `json:"type,omitempty"`
-	Project string `json:"project,omitempty"`
-	Scope   string `json:"scope,omitempty"`
-	Limit   int    `json:"limit,omitempty"`
+	Type string `json:"type,omitempty"`
+	// Types keeps observations of any of these types. It narrows further
+	// when Type is also set.
+	Types   []string `json:"types,omitempty"`
+	Project string   `json:"project,omitempty"`
+	Scope   string   `json:"scope,omitempty"`
+	Limit   int      `json:"limit,omitempty"`
+	// Ref keeps only observations linked to a tracker ref ("#123",
+	// "owner/repo#123", or a full URL). With an empty query, Search lists
+	// every observation carrying the ref.
+	Ref string `json:"ref,omitempty"`
+	// File keeps only observations that mention a file path (see
+	// ExtractFilePaths). A bare name or partial path matches any stored path
+	// ending with it; an absolute path also matches the stored relative one.
+	// With an empty query, Search lists every observation linked to the file.
+	File string `json:"file,omitempty"`
+	// IncludeQuarantined also returns passive captures held in quarantine.
+	IncludeQuarantined bool `json:"include_quarantined,omitempty"`
+	// Source keeps only observations that entered through one path. "mcp"
+	// matches every MCP client; "mcp:opencode" matches one.
+	Source string `json:"source,omitempty"`
+	// IncludeArchive also searches observations moved out by
+	// ArchiveObservations. Archived hits are merged by rank; the File filter
+	// does not apply to them.
+	IncludeArchive bool `json:"include_archive,omitempty"`
+	// Offset skips that many results; see SearchPage.
+	Offset int `json:"offset,omitempty"`
+	// SessionID keeps only observations saved in one session.
+	SessionID string `json:"session_id,omitempty"`
+	// StartedAfter and StartedBefore keep only observations created in a
+	// window: a date ("2026-01-02", midnight UTC) or an RFC3339 timestamp.
+	// StartedAfter is inclusive, StartedBefore exclusive.
+	StartedAfter  string `json:"started_after,omitempty"`
+	StartedBefore string `json:"started_before,omitempty"`
+	// Explain attaches a SearchExplain to every result of the page.
+	Explain bool `json:"explain,omitempty"`
+	// Metadata keeps only observations whose metadata has every key set to
+	// the value, compared as text (see ParseMetadataFilter).
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// normalize converts StartedAfter and StartedBefore to TimestampLayout, so
+// they compare with created_at as strings, and checks the metadata filter
+// keys.
+func (o *SearchOptions) normalize() error {
+	for key := range o.Metadata {
+		if !metadataKeyRegex.MatchString(key) {
+			return invalidf("metadata filter: invalid key %q", key)
+		}
+	}
+	for _, bound := range []struct {
+		name  string
+		value *string
+	}{{"started_after", &o.StartedAfter}, {"started_before", &o.StartedBefore}} {
+		if strings.TrimSpace(*bound.value) == "" {
+			*bound.value = ""
+			continue
+		}
+		t, err := ParseSearchTime(*bound.value)
+		if err != nil {
+			return invalidf("%s: %v", bound.name, err)
+		}
+		*bound.value = t.Format(TimestampLayout)
+	}
+	return nil
+}
+
+// windowed reports whether the session or time window filters are set.
+func (o SearchOptions) windowed() bool {
+	return o.SessionID != "" || o.StartedAfter != "" || o.StartedBefore != ""
 }
 
 type AddObservationParams struct {
@@ -131,7 +346,36 @@ type AddObservationParams struct {
 	Project   string `json:"project,omitempty"`
 	Scope     string `json:"scope,omitempty"`
 	TopicKey  string `json:"topic_key,omitempty"`
-}
+	// Refs are explicit tracker references; refs detected in the title and
+	// content are added automatically.
+	Refs []string `json:"refs,omitempty"`
+	// Metadata is structured data the client attaches (see Metadata). A
+	// topic_key save with no metadata keeps the existing metadata.
+	Metadata Metadata `json:"metadata,omitempty"`
+	// Source records the entry path. It is set by the caller that owns the
+	// path (CLI, MCP, HTTP), never taken from client input.
+	Source string `json:"-"`
+}
+
+// Observation sources. MCP saves are recorded as "mcp:<client>" when the
+// client names itself during initialization.
+const (
+	SourceCLI        = "cli"
+	SourceMCP        = "mcp"
+	SourceHTTP       = "http"
+	SourcePassive    = "passive"
+	SourceScratch    = "scratch"
+	SourceImport     = "import"
+	SourceSyncImport = "sync-import"
+	SourceAPI        = "api"
+	SourceSeed       = "seed"
+	// SourceAutoSummary marks a session summary engram composed itself
+	// because the session ended without one.
+	SourceAutoSummary = "auto-summary"
+	// SourceCI marks a session summary pushed by a CI pipeline (see
+	// SaveSessionSummary).
+	SourceCI = "ci"
+)
 
 type UpdateObservationParams struct {
 	Type     *string `json:"type,omitempty"`
@@ -140,6 +384,10 @@ type UpdateObservationParams struct {
 	Project  *string `json:"project,omitempty"`
 	Scope    *string `json:"scope,omitempty"`
 	TopicKey *string `json:"topic_key,omitempty"`
+	// Refs replaces the explicit refs; detected refs are always kept.
+	Refs *[]string `json:"refs,omitempty"`
+	// Metadata replaces the metadata; an empty map clears it.
+	Metadata *Metadata `json:"metadata,omitempty"`
 }
 
 type Prompt struct {
@@ -149,6 +397,9 @@ type Prompt struct {
 	Content   string `json:"content"`
 	Project   string `json:"project,omitempty"`
 	CreatedAt string `json:"created_at"`
+	// DuplicateCount is how many saves the prompt absorbed, itself
+	// included (see AddPrompt). RecentPrompts and SearchPrompts fill it in.
+	DuplicateCount int `json:"duplicate_count,omitempty"`
 }
 
 type AddPromptParams struct {
@@ -211,11 +462,12 @@ type EnrolledProject struct {
 }
 
 type syncSessionPayload struct {
-	ID        string  `json:"id"`
-	Project   string  `json:"project"`
-	Directory string  `json:"directory"`
-	EndedAt   *string `json:"ended_at,omitempty"`
-	Summary   *string `json:"summary,omitempty"`
+	ID              string  `json:"id"`
+	Project         string  `json:"project"`
+	Directory       string  `json:"directory"`
+	EndedAt         *string `json:"ended_at,omitempty"`
+	Summary         *string `json:"summary,omitempty"`
+	ParentSessionID *string `json:"parent_session_id,omitempty"`
 }
 
 type syncObservationPayload struct {
@@ -228,9 +480,12 @@ type syncObservationPayload struct {
 	Project    *string `json:"project,omitempty"`
 	Scope      string  `json:"scope"`
 	TopicKey   *string `json:"topic_key,omitempty"`
+	Refs       RefList `json:"refs,omitempty"`
 	Deleted    bool    `json:"deleted,omitempty"`
 	DeletedAt  *string `json:"deleted_at,omitempty"`
 	HardDelete bool    `json:"hard_delete,omitempty"`
+
+	Metadata Metadata `json:"metadata,omitempty"`
 }
 
 type syncPromptPayload struct {
@@ -252,11 +507,189 @@ type ExportData struct {
 // ─── Config ──────────────────────────────────────────────────────────────────
 
 type Config struct {
-	DataDir              string
+	DataDir string
+	// DBPath overrides the database file, which otherwise lives at
+	// {DataDir}/engram.db. MemoryDBPath keeps the whole store in memory.
+	DBPath               string
 	MaxObservationLength int
 	MaxContextResults    int
 	MaxSearchResults     int
 	DedupeWindow         time.Duration
+	// DedupeByType overrides the dedupe behavior for specific observation
+	// types. Types without an entry use DedupeHash with DedupeWindow.
+	DedupeByType map[string]DedupePolicy
+	// LearningHeaders are extra section-title regexes for passive capture,
+	// on top of DefaultLearningHeaders.
+	LearningHeaders []string
+	// SearchWeights are the bm25() column weights for observation search.
+	// The zero value uses DefaultSearchWeights.
+	SearchWeights SearchWeights
+	// DisplayLocation is the zone timestamps are shown in; nil means the
+	// system zone. Storage is always UTC.
+	DisplayLocation *time.Location
+
+	// Locale is the language of human-readable CLI, TUI, and MCP text ("en"
+	// or "es"); see internal/i18n. The store itself does not use it.
+	Locale string
+	// DefaultQuota caps every project without an entry in Quotas. The zero
+	// value is unlimited.
+	DefaultQuota Quota
+	// Quotas caps specific projects, keyed by normalized project name.
+	Quotas map[string]Quota
+	// ScratchTTL is how long a working memory item lives when set without
+	// its own TTL. Zero means DefaultScratchTTL.
+	ScratchTTL time.Duration
+	// CompressAbove stores observation content longer than this many bytes
+	// gzip-compressed (see storedContent). Zero stores all content as text.
+	CompressAbove int64
+	// EventRetention is how long GC keeps changefeed events (see Events).
+	// Zero means DefaultEventRetention.
+	EventRetention time.Duration
+	// Templates adds or replaces observation templates by name, on top of
+	// DefaultTemplates.
+	Templates map[string]Template
+	// ContextGroups splits the observations of FormatContext into headed
+	// sections, in order. Empty keeps the single "Recent Observations" list.
+	ContextGroups []ContextGroup
+	// ContextExclude keeps noisy observations out of RecentObservations
+	// and FormatContext. The zero value excludes nothing.
+	ContextExclude ContextExclusion
+	// PersonalSync decides whether team sharing (engram sync, GET /export)
+	// may carry personal-scope observations. Empty means
+	// PersonalSyncExclude.
+	PersonalSync PersonalSyncPolicy
+	// AppendOnly never rewrites or removes an observation row: edits and
+	// topic_key saves add a superseding revision and soft-delete the old
+	// row (see supersedeObservationTx), and hard deletes, archiving,
+	// project or session merges, and merging imports fail with ErrAppendOnly.
+	AppendOnly bool
+
+	// ManualMigrations opens the database without migrating it, so `engram
+	// migrate` can inspect or roll back the schema. Nothing else should set
+	// it: the other methods assume the latest schema.
+	ManualMigrations bool
+
+	// DSN selects the backend for OpenBackend: a postgres:// or
+	// postgresql:// URL opens a PostgresStore, empty opens the SQLite
+	// database at DBPath. New always uses SQLite.
+	DSN string
+}
+
+// SearchWeights weigh FTS5 matches per observations_fts column when ranking
+// search results. A match in a column with weight 10 counts ten times as
+// much as one in a column with weight 1; 0 ignores the column for ranking
+// (it still matches).
+type SearchWeights struct {
+	Title    float64 `json:"title"`
+	Content  float64 `json:"content"`
+	ToolName float64 `json:"tool_name"`
+	Type     float64 `json:"type"`
+	Project  float64 `json:"project"`
+	TopicKey float64 `json:"topic_key"`
+}
+
+// DefaultSearchWeights boost title and topic_key so a memory titled "Auth
+// model" outranks a long tool output that mentions auth and model often.
+func DefaultSearchWeights() SearchWeights {
+	return SearchWeights{Title: 10, Content: 1, ToolName: 1, Type: 2, Project: 1, TopicKey: 8}
+}
+
+// bm25 returns the ranking expression for observations_fts, in column order.
+func (w SearchWeights) bm25() string {
+	if w == (SearchWeights{}) {
+		w = DefaultSearchWeights()
+	}
+	weights := []float64{w.Title, w.Content, w.ToolName, w.Type, w.Project, w.TopicKey}
+	args := make([]string, len(weights))
+	for i, weight := range weights {
+		args[i] = strconv.FormatFloat(weight, 'f', -1, 64)
+	}
+	return "bm25(observations_fts, " + strings.Join(args, ", ") + ")"
+}
+
+func (w SearchWeights) validate() error {
+	for _, weight := range []float64{w.Title, w.Content, w.ToolName, w.Type, w.Project, w.TopicKey} {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("search weights must be finite and not negative, got %+v", w)
+		}
+	}
+	return nil
+}
+
+// DedupeStrategy controls how AddObservation detects duplicate saves.
+type DedupeStrategy string
+
+const (
+	// DedupeHash collapses saves with the same normalized content, title,
+	// type, project and scope inside the window. This is the default.
+	DedupeHash DedupeStrategy = "hash"
+	// DedupeContent collapses saves with the same normalized content, type,
+	// project and scope inside the window, regardless of title.
+	DedupeContent DedupeStrategy = "content"
+	// DedupeOff never collapses saves; every call inserts a new row.
+	DedupeOff DedupeStrategy = "off"
+)
+
+// DedupePolicy is the per-type dedupe override. A zero Window falls back to
+// Config.DedupeWindow; an empty Strategy falls back to DedupeHash.
+type DedupePolicy struct {
+	Strategy DedupeStrategy
+	Window   time.Duration
+}
+
+// ParseDedupeStrategy validates a strategy name from user configuration.
+func ParseDedupeStrategy(value string) (DedupeStrategy, error) {
+	switch DedupeStrategy(strings.ToLower(strings.TrimSpace(value))) {
+	case "", DedupeHash:
+		return DedupeHash, nil
+	case DedupeContent:
+		return DedupeContent, nil
+	case DedupeOff:
+		return DedupeOff, nil
+	default:
+		return "", fmt.Errorf("unknown dedupe strategy %q (want hash, content or off)", value)
+	}
+}
+
+// dedupePolicy resolves the effective policy for an observation type.
+func (c Config) dedupePolicy(obsType string) DedupePolicy {
+	policy := DedupePolicy{Strategy: DedupeHash, Window: c.DedupeWindow}
+	if override, ok := c.DedupeByType[obsType]; ok {
+		if override.Strategy != "" {
+			policy.Strategy = override.Strategy
+		}
+		if override.Window > 0 {
+			policy.Window = override.Window
+		}
+	}
+	return policy
+}
+
+// MemoryDBPath is the DBPath for a throwaway store: a shared-cache in-memory
+// database that nothing is written to disk for and that is gone on Close.
+const MemoryDBPath = ":memory:"
+
+// memoryDBSeq names in-memory databases so stores opened in the same
+// process never share one.
+var memoryDBSeq atomic.Int64
+
+// DatabasePath returns the database file the config points at.
+func (c Config) DatabasePath() string {
+	if c.DBPath != "" {
+		return c.DBPath
+	}
+	return filepath.Join(c.DataDir, "engram.db")
+}
+
+// ArchivePath is the archive database ArchiveObservations moves old
+// observations into. It sits next to the main database.
+func (c Config) ArchivePath() string {
+	return filepath.Join(filepath.Dir(c.DatabasePath()), "engram-archive.db")
+}
+
+// InMemory reports whether the config selects an in-memory store.
+func (c Config) InMemory() bool {
+	return c.DBPath == MemoryDBPath
 }
 
 func DefaultConfig() (Config, error) {
@@ -270,6 +703,7 @@ func DefaultConfig() (Config, error) {
 		MaxContextResults:    20,
 		MaxSearchResults:     20,
 		DedupeWindow:         15 * time.Minute,
+		DedupeByType:         DefaultDedupeByType(),
 	}, nil
 }
 
@@ -283,6 +717,18 @@ func FallbackConfig(dataDir string) Config {
 		MaxContextResults:    20,
 		MaxSearchResults:     20,
 		DedupeWindow:         15 * time.Minute,
+		DedupeByType:         DefaultDedupeByType(),
+	}
+}
+
+// DefaultDedupeByType returns the built-in per-type dedupe overrides:
+// tool_use output is noisy and repeats under varying titles, so it dedupes
+// on content alone over a longer window; decisions are deliberate records
+// and are never collapsed.
+func DefaultDedupeByType() map[string]DedupePolicy {
+	return map[string]DedupePolicy{
+		"tool_use": {Strategy: DedupeContent, Window: time.Hour},
+		"decision": {Strategy: DedupeOff},
 	}
 }
 
@@ -297,8 +743,58 @@ type Store struct {
 	db    *sql.DB
 	cfg   Config
 	hooks storeHooks
+
+	learningHeaders *regexp.Regexp
+
+	// pinned holds a connection open for in-memory stores: a shared-cache
+	// database is dropped as soon as its last connection closes.
+	pinned *sql.Conn
+
+	archiveMu sync.Mutex
+	archive   *sql.DB // opened on first use; see ArchiveObservations
+
+	cache *queryCache // nil until migrated; see Query Cache
+
+	// ftsFallbacks and ftsFailures back Stats.FTS; see queryFTSOn.
+	ftsFallbacks atomic.Int64
+	ftsFailures  atomic.Int64
+
+	// eventsWake is closed on every commit to wake WaitEvents.
+	eventsMu   sync.Mutex
+	eventsWake chan struct{}
+
+	// limitsMu guards the cfg fields SetLimits swaps while the store is open.
+	limitsMu sync.RWMutex
+}
+
+// Backend is the storage surface shared by every backend: sessions,
+// observations, prompts, search, and the context block built from them.
+// Store (SQLite) is the default and implements much more; PostgresStore
+// implements only this. Code that must run on either takes a Backend.
+type Backend interface {
+	CreateSession(id, project, directory string) error
+	EndSession(id, summary string) error
+	GetSession(id string) (*Session, error)
+	RecentSessions(project string, limit int) ([]SessionSummary, error)
+
+	AddObservation(p AddObservationParams) (int64, error)
+	GetObservation(id int64) (*Observation, error)
+	RecentObservations(project, scope string, limit int) ([]Observation, error)
+	UpdateObservation(id int64, p UpdateObservationParams) (*Observation, error)
+	DeleteObservation(id int64, hardDelete bool) error
+
+	AddPrompt(p AddPromptParams) (int64, error)
+	RecentPrompts(project string, limit int) ([]Prompt, error)
+	SearchPrompts(query, project string, limit int) ([]Prompt, error)
+
+	Search(query string, opts SearchOptions) ([]SearchResult, error)
+	FormatContext(project, scope string) (string, error)
+
+	Close() error
 }
 
+var _ Backend = (*Store)(nil)
+
 type execer interface {
 	Exec(query string, args ...any) (sql.Result, error)
 }
@@ -367,6 +863,11 @@ func defaultStoreHooks() storeHooks {
 }
 
 func (s *Store) execHook(db execer, query string, args ...any) (sql.Result, error) {
+	// Statements outside a transaction commit on their own; withTx
+	// invalidates the cache for the rest.
+	if _, inTx := db.(*sql.Tx); !inTx {
+		defer s.cache.flush()
+	}
 	if s.hooks.exec != nil {
 		return s.hooks.exec(db, query, args...)
 	}
@@ -399,25 +900,41 @@ func (s *Store) beginTxHook() (*sql.Tx, error) {
 }
 
 func (s *Store) commitHook(tx *sql.Tx) error {
+	var err error
 	if s.hooks.commit != nil {
-		return s.hooks.commit(tx)
+		err = s.hooks.commit(tx)
+	} else {
+		err = tx.Commit()
 	}
-	return tx.Commit()
+	if err == nil {
+		s.wakeEventWaiters()
+	}
+	return err
 }
 
 func New(cfg Config) (*Store, error) {
-	if !filepath.IsAbs(cfg.DataDir) {
-		return nil, fmt.Errorf("engram: data directory must be an absolute path, got %q — set ENGRAM_DATA_DIR or ensure your home directory is resolvable", cfg.DataDir)
+	learningHeaders, err := LearningHeaderPattern(cfg.LearningHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("engram: %w", err)
 	}
-	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
-		return nil, fmt.Errorf("engram: create data dir: %w", err)
+	if err := cfg.SearchWeights.validate(); err != nil {
+		return nil, fmt.Errorf("engram: %w", err)
 	}
-
-	dbPath := filepath.Join(cfg.DataDir, "engram.db")
-	db, err := openDB("sqlite", dbPath)
+	dsn, err := databaseDSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := openDB("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("engram: open database: %w", err)
 	}
+	var pinned *sql.Conn
+	if cfg.InMemory() {
+		if pinned, err = db.Conn(context.Background()); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("engram: open in-memory database: %w", err)
+		}
+	}
 
 	// SQLite performance pragmas
 	pragmas := []string{
@@ -432,30 +949,280 @@ func New(cfg Config) (*Store, error) {
 		}
 	}
 
-	s := &Store{db: db, cfg: cfg, hooks: defaultStoreHooks()}
+	s := &Store{db: db, cfg: cfg, hooks: defaultStoreHooks(), learningHeaders: learningHeaders, pinned: pinned}
+	if cfg.ManualMigrations {
+		return s, nil
+	}
 	if err := s.migrate(); err != nil {
 		return nil, fmt.Errorf("engram: migration: %w", err)
 	}
 	if err := s.repairEnrolledProjectSyncMutations(); err != nil {
 		return nil, fmt.Errorf("engram: repair enrolled sync journal: %w", err)
 	}
+	cachePath := ""
+	if !cfg.InMemory() {
+		cachePath = cfg.DatabasePath()
+	}
+	s.cache = newQueryCache(cachePath)
 
 	return s, nil
 }
 
+// databaseDSN validates the configured location, creates its directory, and
+// returns the data source name to open.
+func databaseDSN(cfg Config) (string, error) {
+	if cfg.InMemory() {
+		return fmt.Sprintf("file:engram-memory-%d?mode=memory&cache=shared", memoryDBSeq.Add(1)), nil
+	}
+	if cfg.DBPath != "" {
+		if !filepath.IsAbs(cfg.DBPath) {
+			return "", fmt.Errorf("engram: database path must be an absolute path, got %q — check ENGRAM_DB_PATH", cfg.DBPath)
+		}
+		if err := os.MkdirAll(filepath.Dir(cfg.DBPath), 0755); err != nil {
+			return "", fmt.Errorf("engram: create database dir: %w", err)
+		}
+		return cfg.DBPath, nil
+	}
+	if !filepath.IsAbs(cfg.DataDir) {
+		return "", fmt.Errorf("engram: data directory must be an absolute path, got %q — set ENGRAM_DATA_DIR or ensure your home directory is resolvable", cfg.DataDir)
+	}
+	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
+		return "", fmt.Errorf("engram: create data dir: %w", err)
+	}
+	return cfg.DatabasePath(), nil
+}
+
 func (s *Store) Close() error {
+	if s.pinned != nil {
+		s.pinned.Close()
+	}
+	s.archiveMu.Lock()
+	if s.archive != nil {
+		s.archive.Close()
+		s.archive = nil
+	}
+	s.archiveMu.Unlock()
 	return s.db.Close()
 }
 
+// ─── Query Cache ─────────────────────────────────────────────────────────────
+//
+// mem_context runs RecentSessions, RecentObservations, and RecentPrompts on
+// every call, usually with the same project and limits. queryCache keeps
+// their last results until a write makes them stale:
+//
+//   - the hot writes (CreateSession, AddObservation, AddPrompt) drop only the
+//     entries for the project they wrote, and its parent projects
+//   - every other write in this process drops every entry
+//   - writes by other processes (the MCP server, `engram serve`, and the CLI
+//     share one database) are noticed by the database and WAL files
+//     changing size or modification time
+//
+// cacheTTL bounds how stale an entry can get if a change is ever missed.
+
+const cacheTTL = time.Minute
+
+const (
+	cacheSessions     = "sessions"
+	cacheObservations = "observations"
+	cachePrompts      = "prompts"
+)
+
+// CacheStats counts query cache activity since the store was opened.
+type CacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Invalidations int64 `json:"invalidations"` // entries dropped by writes
+	Entries       int   `json:"entries"`
+}
+
+type cacheEntry struct {
+	kind    string
+	project string
+	value   any
+	at      time.Time
+}
+
+type queryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	gen     uint64 // bumped on every invalidation; see cachedQuery
+
+	// path is the database file watched for writes by other processes,
+	// and version its state when last checked. In-memory stores have no
+	// other writers and no path.
+	path    string
+	version fileVersion
+
+	stats CacheStats
+}
+
+// fileVersion is what a commit changes on disk: in WAL mode the WAL file,
+// and after a checkpoint the database file.
+type fileVersion struct {
+	dbSize, walSize   int64
+	dbMTime, walMTime int64 // UnixNano
+}
+
+func statFileVersion(path string) fileVersion {
+	var v fileVersion
+	if info, err := os.Stat(path); err == nil {
+		v.dbSize, v.dbMTime = info.Size(), info.ModTime().UnixNano()
+	}
+	if info, err := os.Stat(path + "-wal"); err == nil {
+		v.walSize, v.walMTime = info.Size(), info.ModTime().UnixNano()
+	}
+	return v
+}
+
+func newQueryCache(path string) *queryCache {
+	c := &queryCache{entries: make(map[string]cacheEntry), path: path}
+	if path != "" {
+		c.version = statFileVersion(path)
+	}
+	return c
+}
+
+// checkExternal drops every entry if the database files changed since the
+// last check. c.mu must be held.
+func (c *queryCache) checkExternal() {
+	if c.path == "" {
+		return
+	}
+	if v := statFileVersion(c.path); v != c.version {
+		c.dropLocked(func(cacheEntry) bool { return true })
+		c.version = v
+	}
+}
+
+func (c *queryCache) dropLocked(match func(cacheEntry) bool) {
+	for key, e := range c.entries {
+		if match(e) {
+			delete(c.entries, key)
+			c.stats.Invalidations++
+		}
+	}
+	c.gen++
+}
+
+// flush drops every entry after a write it cannot attribute to a project.
+func (c *queryCache) flush() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dropLocked(func(cacheEntry) bool { return true })
+}
+
+// syncExternal drops every entry if another process wrote. A targeted
+// write calls it before committing, while it holds the write lock, so
+// invalidate can then treat the next change to the files as its own.
+func (c *queryCache) syncExternal() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkExternal()
+}
+
+// invalidate drops the entries of the given kinds that can include rows of
+// project: unfiltered entries, the project's own, and its parents'.
+func (c *queryCache) invalidate(project string, kinds ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dropLocked(func(e cacheEntry) bool {
+		return slices.Contains(kinds, e.kind) &&
+			(e.project == "" || e.project == project || strings.HasPrefix(project, e.project+ProjectSeparator))
+	})
+	if c.path != "" {
+		c.version = statFileVersion(c.path)
+	}
+}
+
+// invalidateKind drops every entry of kind.
+func (c *queryCache) invalidateKind(kind string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dropLocked(func(e cacheEntry) bool { return e.kind == kind })
+}
+
+func (c *queryCache) snapshot() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+// cachedQuery returns the cached result for key, or runs load and caches
+// its result. A result loaded while a write invalidated the cache may
+// already be stale, so it is returned but not kept. Callers get their own
+// copy of the slice.
+func cachedQuery[T any](c *queryCache, kind, project, key string, load func() ([]T, error)) ([]T, error) {
+	if c == nil {
+		return load()
+	}
+	key = kind + "|" + project + "|" + key
+
+	c.mu.Lock()
+	c.checkExternal()
+	if e, ok := c.entries[key]; ok && time.Since(e.at) < cacheTTL {
+		c.stats.Hits++
+		c.mu.Unlock()
+		return slices.Clone(e.value.([]T)), nil
+	}
+	c.stats.Misses++
+	gen := c.gen
+	c.mu.Unlock()
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.gen == gen {
+		c.entries[key] = cacheEntry{kind: kind, project: project, value: slices.Clone(value), at: time.Now()}
+	}
+	c.mu.Unlock()
+	return value, nil
+}
+
 // ─── Migrations ──────────────────────────────────────────────────────────────
 
 func (s *Store) migrate() error {
+	version, err := s.AppliedSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("%w: database is at version %d, this engram knows up to %d — upgrade engram, or run `engram migrate down --to %d` with the newer binary first", ErrSchemaTooNew, version, SchemaVersion, SchemaVersion)
+	}
+	return s.MigrateUp()
+}
+
+// migrateBaseline is schema version 1: the tables and repairs every
+// database has had since before schema_migrations existed. Each statement
+// is idempotent and it runs on every open, so databases written by any
+// older engram are brought up to the same starting point before the
+// numbered steps run.
+func (s *Store) migrateBaseline() error {
 	schema := `
 			CREATE TABLE IF NOT EXISTS sessions (
 				id         TEXT PRIMARY KEY,
 			project    TEXT NOT NULL,
 			directory  TEXT NOT NULL,
-			started_at TEXT NOT NULL DEFAULT (datetime('now')),
+			started_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
 			ended_at   TEXT,
 			summary    TEXT
 		);
@@ -471,12 +1238,13 @@ func (s *Store) migrate() error {
 			project    TEXT,
 			scope      TEXT    NOT NULL DEFAULT 'project',
 			topic_key  TEXT,
+			refs       TEXT,
 			normalized_hash TEXT,
 			revision_count INTEGER NOT NULL DEFAULT 1,
 			duplicate_count INTEGER NOT NULL DEFAULT 1,
 			last_seen_at TEXT,
-			created_at TEXT    NOT NULL DEFAULT (datetime('now')),
-			updated_at TEXT    NOT NULL DEFAULT (datetime('now')),
+			created_at TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			updated_at TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
 			deleted_at TEXT,
 			FOREIGN KEY (session_id) REFERENCES sessions(id)
 		);
@@ -503,7 +1271,7 @@ func (s *Store) migrate() error {
 				session_id TEXT    NOT NULL,
 			content    TEXT    NOT NULL,
 			project    TEXT,
-			created_at TEXT    NOT NULL DEFAULT (datetime('now')),
+			created_at TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
 			FOREIGN KEY (session_id) REFERENCES sessions(id)
 		);
 
@@ -520,7 +1288,7 @@ func (s *Store) migrate() error {
 
 			CREATE TABLE IF NOT EXISTS sync_chunks (
 				chunk_id    TEXT PRIMARY KEY,
-				imported_at TEXT NOT NULL DEFAULT (datetime('now'))
+				imported_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
 			);
 
 			CREATE TABLE IF NOT EXISTS sync_state (
@@ -534,7 +1302,7 @@ func (s *Store) migrate() error {
 				lease_owner          TEXT,
 				lease_until          TEXT,
 				last_error           TEXT,
-				updated_at           TEXT NOT NULL DEFAULT (datetime('now'))
+				updated_at           TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
 			);
 
 			CREATE TABLE IF NOT EXISTS sync_mutations (
@@ -545,10 +1313,45 @@ func (s *Store) migrate() error {
 				op          TEXT NOT NULL,
 				payload     TEXT NOT NULL,
 				source      TEXT NOT NULL DEFAULT 'local',
-				occurred_at TEXT NOT NULL DEFAULT (datetime('now')),
+				occurred_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
 				acked_at    TEXT,
 				FOREIGN KEY (target_key) REFERENCES sync_state(target_key)
 			);
+
+			CREATE TABLE IF NOT EXISTS working_memory (
+				session_id TEXT NOT NULL,
+				key        TEXT NOT NULL,
+				value      TEXT NOT NULL,
+				durable    INTEGER NOT NULL DEFAULT 0,
+				type       TEXT,
+				expires_at TEXT,
+				updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+				PRIMARY KEY (session_id, key)
+			);
+
+			CREATE TABLE IF NOT EXISTS tool_runs (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				session_id  TEXT    NOT NULL,
+				tool_name   TEXT    NOT NULL,
+				command     TEXT,
+				exit_code   INTEGER,
+				duration_ms INTEGER NOT NULL DEFAULT 0,
+				files       TEXT,
+				summary     TEXT,
+				project     TEXT,
+				created_at  TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+				FOREIGN KEY (session_id) REFERENCES sessions(id)
+			);
+			CREATE INDEX IF NOT EXISTS idx_tool_runs_session ON tool_runs(session_id, created_at);
+
+			CREATE TABLE IF NOT EXISTS replication_state (
+				target     TEXT NOT NULL,
+				entity     TEXT NOT NULL,
+				watermark  TEXT NOT NULL,
+				updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+				PRIMARY KEY (target, entity)
+			);
+
 		`
 	if _, err := s.execHook(s.db, schema); err != nil {
 		return err
@@ -561,12 +1364,18 @@ func (s *Store) migrate() error {
 		{name: "sync_id", definition: "TEXT"},
 		{name: "scope", definition: "TEXT NOT NULL DEFAULT 'project'"},
 		{name: "topic_key", definition: "TEXT"},
+		{name: "refs", definition: "TEXT"},
 		{name: "normalized_hash", definition: "TEXT"},
 		{name: "revision_count", definition: "INTEGER NOT NULL DEFAULT 1"},
 		{name: "duplicate_count", definition: "INTEGER NOT NULL DEFAULT 1"},
 		{name: "last_seen_at", definition: "TEXT"},
 		{name: "updated_at", definition: "TEXT NOT NULL DEFAULT ''"},
 		{name: "deleted_at", definition: "TEXT"},
+		{name: "quarantine_reason", definition: "TEXT"},
+		{name: "verified_at", definition: "TEXT"},
+		{name: "stale_at", definition: "TEXT"},
+		{name: "verification_note", definition: "TEXT"},
+		{name: "source", definition: "TEXT"},
 	}
 	for _, c := range observationColumns {
 		if err := s.addColumnIfNotExists("observations", c.name, c.definition); err != nil {
@@ -581,14 +1390,19 @@ func (s *Store) migrate() error {
 	if err := s.addColumnIfNotExists("user_prompts", "sync_id", "TEXT"); err != nil {
 		return err
 	}
+	if err := s.addColumnIfNotExists("sessions", "parent_session_id", "TEXT"); err != nil {
+		return err
+	}
 
 	if _, err := s.execHook(s.db, `
 		CREATE INDEX IF NOT EXISTS idx_obs_scope ON observations(scope);
 		CREATE INDEX IF NOT EXISTS idx_obs_sync_id ON observations(sync_id);
 		CREATE INDEX IF NOT EXISTS idx_obs_topic ON observations(topic_key, project, scope, updated_at DESC);
 		CREATE INDEX IF NOT EXISTS idx_obs_deleted ON observations(deleted_at);
+		CREATE INDEX IF NOT EXISTS idx_obs_quarantine ON observations(quarantine_reason);
 		CREATE INDEX IF NOT EXISTS idx_obs_dedupe ON observations(normalized_hash, project, scope, type, title, created_at DESC);
 		CREATE INDEX IF NOT EXISTS idx_prompts_sync_id ON user_prompts(sync_id);
+		CREATE INDEX IF NOT EXISTS idx_sessions_parent ON sessions(parent_session_id);
 		CREATE INDEX IF NOT EXISTS idx_sync_mutations_target_seq ON sync_mutations(target_key, seq);
 		CREATE INDEX IF NOT EXISTS idx_sync_mutations_pending ON sync_mutations(target_key, acked_at, seq);
 	`); err != nil {
@@ -602,7 +1416,7 @@ func (s *Store) migrate() error {
 	if _, err := s.execHook(s.db, `
 		CREATE TABLE IF NOT EXISTS sync_enrolled_projects (
 			project     TEXT PRIMARY KEY,
-			enrolled_at TEXT NOT NULL DEFAULT (datetime('now'))
+			enrolled_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
 		);
 		CREATE INDEX IF NOT EXISTS idx_sync_mutations_project ON sync_mutations(project);
 	`); err != nil {
@@ -642,7 +1456,10 @@ func (s *Store) migrate() error {
 	if _, err := s.execHook(s.db, `UPDATE user_prompts SET sync_id = 'prompt-' || lower(hex(randomblob(16))) WHERE sync_id IS NULL OR sync_id = ''`); err != nil {
 		return err
 	}
-	if _, err := s.execHook(s.db, `INSERT OR IGNORE INTO sync_state (target_key, lifecycle, updated_at) VALUES ('cloud', 'idle', datetime('now'))`); err != nil {
+	if _, err := s.execHook(s.db, `INSERT OR IGNORE INTO sync_state (target_key, lifecycle, updated_at) VALUES ('cloud', 'idle', strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))`); err != nil {
+		return err
+	}
+	if err := s.migrateTimestamps(); err != nil {
 		return err
 	}
 
@@ -709,1657 +1526,8022 @@ func (s *Store) migrate() error {
 			return err
 		}
 	}
-
 	return nil
 }
 
-func (s *Store) migrateFTSTopicKey() error {
-	var colCount int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM pragma_table_xinfo('observations_fts') WHERE name = 'topic_key'").Scan(&colCount)
-	if err != nil || colCount > 0 {
-		return nil
+// SchemaVersion is the newest numbered migration this engram knows. New
+// refuses a database whose schema_migrations records a higher version: an
+// older binary would misread tables it has never seen.
+const SchemaVersion = 13
+
+// migrations are the numbered schema steps after the baseline, in order.
+// Each up must be idempotent: databases created before schema_migrations
+// existed already have some of these tables and run every step once on
+// their first open. A nil down marks a step that cannot be rolled back.
+var migrations = []migration{
+	{version: 1, name: "baseline", up: (*Store).migrateBaseline, always: true},
+	{version: 2, name: "observation_files", up: (*Store).migrateObservationFiles, down: (*Store).dropObservationFiles},
+	{version: 3, name: "enrichment_suggestions", up: (*Store).migrateEnrichmentSuggestions, down: (*Store).dropEnrichmentSuggestions},
+	{version: 4, name: "translations", up: (*Store).migrateTranslations, down: (*Store).dropTranslations},
+	{version: 5, name: "advisory_locks", up: (*Store).migrateAdvisoryLocks, down: (*Store).dropAdvisoryLocks},
+	{version: 6, name: "observation_parts", up: (*Store).migrateObservationParts, down: (*Store).dropObservationParts},
+	{version: 7, name: "compaction_events", up: (*Store).migrateCompactionEvents, down: (*Store).dropCompactionEvents},
+	{version: 8, name: "events", up: (*Store).migrateEvents, down: (*Store).dropEvents},
+	{version: 9, name: "ordering_seq", up: (*Store).migrateOrderingSeq, down: (*Store).dropOrderingSeq},
+	{version: 10, name: "prompt_dedupe", up: (*Store).migratePromptDedupe, down: (*Store).dropPromptDedupe},
+	{version: 11, name: "observation_supersede", up: (*Store).migrateObservationSupersede, down: (*Store).dropObservationSupersede},
+	{version: 12, name: "observation_metadata", up: (*Store).migrateObservationMetadata, down: (*Store).dropObservationMetadata},
+	{version: 13, name: "observation_compression", up: (*Store).migrateObservationCompression, down: (*Store).dropObservationCompression},
+}
+
+type migration struct {
+	version int
+	name    string
+	up      func(*Store) error
+	down    func(*Store) error
+	// always re-runs up on every open, even once recorded.
+	always bool
+}
+
+// ErrSchemaTooNew is returned by New for a database migrated by a newer
+// engram.
+var ErrSchemaTooNew = errors.New("database schema is newer than this engram")
+
+// Migration reports one schema step and whether this database has it.
+type Migration struct {
+	Version    int    `json:"version"`
+	Name       string `json:"name"`
+	Applied    bool   `json:"applied"`
+	AppliedAt  string `json:"applied_at,omitempty"`
+	Reversible bool   `json:"reversible"`
+	// Unknown marks a step recorded by a newer engram.
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+func (s *Store) ensureMigrationsTable() error {
+	_, err := s.execHook(s.db, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		)
+	`)
+	return err
+}
+
+// AppliedSchemaVersion returns the highest migration recorded in this
+// database, or 0 for one that predates schema_migrations.
+func (s *Store) AppliedSchemaVersion() (int, error) {
+	if err := s.ensureMigrationsTable(); err != nil {
+		return 0, err
+	}
+	var version int
+	if err := s.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, err
 	}
+	return version, nil
+}
 
-	if _, err := s.execHook(s.db, `
-		DROP TRIGGER IF EXISTS obs_fts_insert;
-		DROP TRIGGER IF EXISTS obs_fts_update;
-		DROP TRIGGER IF EXISTS obs_fts_delete;
-		DROP TABLE IF EXISTS observations_fts;
-		CREATE VIRTUAL TABLE observations_fts USING fts5(
-			title,
-			content,
-			tool_name,
-			type,
-			project,
-			topic_key,
-			content='observations',
-			content_rowid='id'
-		);
-		INSERT INTO observations_fts(rowid, title, content, tool_name, type, project, topic_key)
-		SELECT id, title, content, tool_name, type, project, topic_key
-		FROM observations
-		WHERE deleted_at IS NULL;
+// Migrations lists every known step, then any applied step this engram
+// does not know, in version order.
+func (s *Store) Migrations() ([]Migration, error) {
+	if err := s.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query("SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[int]Migration{}
+	for rows.Next() {
+		var m Migration
+		if err := rows.Scan(&m.Version, &m.Name, &m.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[m.Version] = m
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		CREATE TRIGGER obs_fts_insert AFTER INSERT ON observations BEGIN
-			INSERT INTO observations_fts(rowid, title, content, tool_name, type, project, topic_key)
-			VALUES (new.id, new.title, new.content, new.tool_name, new.type, new.project, new.topic_key);
-		END;
+	out := make([]Migration, 0, len(migrations))
+	for _, step := range migrations {
+		m := Migration{Version: step.version, Name: step.name, Reversible: step.down != nil}
+		if a, ok := applied[step.version]; ok {
+			m.Applied, m.AppliedAt = true, a.AppliedAt
+			delete(applied, step.version)
+		}
+		out = append(out, m)
+	}
+	for _, a := range applied {
+		a.Applied, a.Unknown = true, true
+		out = append(out, a)
+	}
+	slices.SortFunc(out, func(a, b Migration) int { return a.Version - b.Version })
+	return out, nil
+}
 
-		CREATE TRIGGER obs_fts_delete AFTER DELETE ON observations BEGIN
-			INSERT INTO observations_fts(observations_fts, rowid, title, content, tool_name, type, project, topic_key)
-			VALUES ('delete', old.id, old.title, old.content, old.tool_name, old.type, old.project, old.topic_key);
-		END;
+// MigrateUp applies every step not yet recorded, after re-running the
+// baseline. New calls it on open, so it only has work to do on a store
+// opened with ManualMigrations.
+func (s *Store) MigrateUp() error {
+	if err := s.ensureMigrationsTable(); err != nil {
+		return err
+	}
+	for _, step := range migrations {
+		var done int
+		if err := s.db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", step.version).Scan(&done); err != nil {
+			return err
+		}
+		if done > 0 && !step.always {
+			continue
+		}
+		if err := step.up(s); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", step.version, step.name, err)
+		}
+		if done > 0 {
+			continue
+		}
+		if _, err := s.execHook(s.db, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", step.version, step.name); err != nil {
+			return fmt.Errorf("migration %d (%s): record: %w", step.version, step.name, err)
+		}
+	}
+	return nil
+}
 
-		CREATE TRIGGER obs_fts_update AFTER UPDATE ON observations BEGIN
-			INSERT INTO observations_fts(observations_fts, rowid, title, content, tool_name, type, project, topic_key)
-			VALUES ('delete', old.id, old.title, old.content, old.tool_name, old.type, old.project, old.topic_key);
-			INSERT INTO observations_fts(rowid, title, content, tool_name, type, project, topic_key)
-			VALUES (new.id, new.title, new.content, new.tool_name, new.type, new.project, new.topic_key);
+// MigrateDown rolls back applied steps above version to, newest first, and
+// returns the steps it reverted. It stops at the first step that cannot be
+// rolled back. Run it with the newer binary before downgrading engram; any
+// command run by this binary afterwards migrates the database up again.
+func (s *Store) MigrateDown(to int) ([]Migration, error) {
+	if to < 1 || to > SchemaVersion {
+		return nil, fmt.Errorf("migrate down: target version must be between 1 and %d, got %d", SchemaVersion, to)
+	}
+	version, err := s.AppliedSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("migrate down: %w: version %d can only be rolled back by the engram that applied it", ErrSchemaTooNew, version)
+	}
+	var reverted []Migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		step := migrations[i]
+		if step.version <= to {
+			break
+		}
+		var done int
+		if err := s.db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", step.version).Scan(&done); err != nil {
+			return reverted, err
+		}
+		if done == 0 {
+			continue
+		}
+		if step.down == nil {
+			return reverted, fmt.Errorf("migrate down: migration %d (%s) cannot be rolled back", step.version, step.name)
+		}
+		if err := step.down(s); err != nil {
+			return reverted, fmt.Errorf("migrate down: migration %d (%s): %w", step.version, step.name, err)
+		}
+		if _, err := s.execHook(s.db, "DELETE FROM schema_migrations WHERE version = ?", step.version); err != nil {
+			return reverted, fmt.Errorf("migrate down: migration %d (%s): unrecord: %w", step.version, step.name, err)
+		}
+		reverted = append(reverted, Migration{Version: step.version, Name: step.name, Reversible: true})
+	}
+	return reverted, nil
+}
+
+// migrateObservationFiles creates the file-link table and, the first time,
+// links the paths mentioned by existing observations.
+func (s *Store) migrateObservationFiles() error {
+	var existing int
+	if err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='observation_files'",
+	).Scan(&existing); err != nil {
+		return err
+	}
+
+	if _, err := s.execHook(s.db, `
+		CREATE TABLE IF NOT EXISTS observation_files (
+			observation_id INTEGER NOT NULL,
+			path           TEXT    NOT NULL,
+			PRIMARY KEY (observation_id, path)
+		);
+		CREATE INDEX IF NOT EXISTS idx_obs_files_path ON observation_files(path);
+		CREATE TRIGGER IF NOT EXISTS obs_files_delete AFTER DELETE ON observations BEGIN
+			DELETE FROM observation_files WHERE observation_id = old.id;
 		END;
 	`); err != nil {
-		return fmt.Errorf("migrate fts topic_key: %w", err)
+		return fmt.Errorf("migrate observation files: %w", err)
+	}
+
+	if existing == 0 {
+		if err := s.backfillObservationFiles(); err != nil {
+			return fmt.Errorf("migrate observation files: %w", err)
+		}
 	}
 	return nil
 }
 
-// ─── Sessions ────────────────────────────────────────────────────────────────
+func (s *Store) dropObservationFiles() error {
+	_, err := s.execHook(s.db, `
+		DROP TRIGGER IF EXISTS obs_files_delete;
+		DROP TABLE IF EXISTS observation_files;
+	`)
+	return err
+}
 
-func (s *Store) CreateSession(id, project, directory string) error {
-	// Normalize project name before storing
-	project, _ = NormalizeProject(project)
+// migrateEnrichmentSuggestions creates the queue `engram enrich` fills.
+func (s *Store) migrateEnrichmentSuggestions() error {
+	_, err := s.execHook(s.db, `
+		CREATE TABLE IF NOT EXISTS enrichment_suggestions (
+			id             INTEGER PRIMARY KEY AUTOINCREMENT,
+			observation_id INTEGER NOT NULL UNIQUE,
+			title          TEXT,
+			topic_key      TEXT,
+			tags           TEXT,
+			model          TEXT,
+			status         TEXT    NOT NULL DEFAULT 'pending',
+			error          TEXT,
+			created_at     TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			reviewed_at    TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_enrich_status ON enrichment_suggestions(status, id);
+		CREATE TRIGGER IF NOT EXISTS obs_enrich_delete AFTER DELETE ON observations BEGIN
+			DELETE FROM enrichment_suggestions WHERE observation_id = old.id;
+		END;
+	`)
+	return err
+}
 
-	return s.withTx(func(tx *sql.Tx) error {
-		if err := s.createSessionTx(tx, id, project, directory); err != nil {
-			return err
-		}
-		return s.enqueueSyncMutationTx(tx, SyncEntitySession, id, SyncOpUpsert, syncSessionPayload{
-			ID:        id,
-			Project:   project,
-			Directory: directory,
-		})
-	})
+func (s *Store) dropEnrichmentSuggestions() error {
+	_, err := s.execHook(s.db, `
+		DROP TRIGGER IF EXISTS obs_enrich_delete;
+		DROP TABLE IF EXISTS enrichment_suggestions;
+	`)
+	return err
 }
 
-func (s *Store) EndSession(id string, summary string) error {
-	return s.withTx(func(tx *sql.Tx) error {
-		res, err := s.execHook(tx,
-			`UPDATE sessions SET ended_at = datetime('now'), summary = ? WHERE id = ?`,
-			nullableString(summary), id,
-		)
-		if err != nil {
-			return err
-		}
-		rows, err := res.RowsAffected()
-		if err != nil {
-			return err
-		}
-		if rows == 0 {
-			return nil
-		}
+// migrateTranslations creates the translation cache used by search.
+func (s *Store) migrateTranslations() error {
+	_, err := s.execHook(s.db, `
+		CREATE TABLE IF NOT EXISTS translations (
+			source_hash TEXT NOT NULL,
+			lang        TEXT NOT NULL,
+			text        TEXT NOT NULL,
+			model       TEXT,
+			created_at  TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			PRIMARY KEY (source_hash, lang)
+		);
+	`)
+	return err
+}
 
-		var endedAt string
-		var project, directory string
-		var storedSummary *string
-		if err := tx.QueryRow(
-			`SELECT project, directory, ended_at, summary FROM sessions WHERE id = ?`,
-			id,
-		).Scan(&project, &directory, &endedAt, &storedSummary); err != nil {
-			return err
-		}
+func (s *Store) dropTranslations() error {
+	_, err := s.execHook(s.db, "DROP TABLE IF EXISTS translations")
+	return err
+}
 
-		return s.enqueueSyncMutationTx(tx, SyncEntitySession, id, SyncOpUpsert, syncSessionPayload{
-			ID:        id,
-			Project:   project,
-			Directory: directory,
-			EndedAt:   &endedAt,
-			Summary:   storedSummary,
-		})
-	})
+// migrateAdvisoryLocks creates the table behind AcquireLock.
+func (s *Store) migrateAdvisoryLocks() error {
+	_, err := s.execHook(s.db, `
+		CREATE TABLE IF NOT EXISTS advisory_locks (
+			name        TEXT PRIMARY KEY,
+			holder      TEXT NOT NULL,
+			acquired_at TEXT NOT NULL,
+			expires_at  TEXT NOT NULL
+		);
+	`)
+	return err
 }
 
-func (s *Store) GetSession(id string) (*Session, error) {
-	row := s.db.QueryRow(
-		`SELECT id, project, directory, started_at, ended_at, summary FROM sessions WHERE id = ?`, id,
-	)
-	var sess Session
-	if err := row.Scan(&sess.ID, &sess.Project, &sess.Directory, &sess.StartedAt, &sess.EndedAt, &sess.Summary); err != nil {
-		return nil, err
-	}
-	return &sess, nil
+func (s *Store) dropAdvisoryLocks() error {
+	_, err := s.execHook(s.db, "DROP TABLE IF EXISTS advisory_locks")
+	return err
 }
 
-func (s *Store) RecentSessions(project string, limit int) ([]SessionSummary, error) {
-	// Normalize project filter for case-insensitive matching
-	project, _ = NormalizeProject(project)
+// migrateObservationParts creates the table linking the pieces of content
+// too long for one observation.
+func (s *Store) migrateObservationParts() error {
+	_, err := s.execHook(s.db, `
+		CREATE TABLE IF NOT EXISTS observation_parts (
+			observation_id INTEGER PRIMARY KEY,
+			group_id       TEXT    NOT NULL,
+			part           INTEGER NOT NULL,
+			parts          INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_obs_parts_group ON observation_parts(group_id, part);
+		CREATE TRIGGER IF NOT EXISTS obs_parts_delete AFTER DELETE ON observations BEGIN
+			DELETE FROM observation_parts WHERE observation_id = old.id;
+		END;
+	`)
+	return err
+}
 
-	if limit <= 0 {
-		limit = 5
-	}
+func (s *Store) dropObservationParts() error {
+	_, err := s.execHook(s.db, `
+		DROP TRIGGER IF EXISTS obs_parts_delete;
+		DROP TABLE IF EXISTS observation_parts;
+	`)
+	return err
+}
 
-	query := `
-		SELECT s.id, s.project, s.started_at, s.ended_at, s.summary,
-		       COUNT(o.id) as observation_count
-		FROM sessions s
-		LEFT JOIN observations o ON o.session_id = s.id AND o.deleted_at IS NULL
-		WHERE 1=1
-	`
-	args := []any{}
+// migrateCompactionEvents creates the table of compactions reported by
+// plugins, which mem_context resurfaces once.
+func (s *Store) migrateCompactionEvents() error {
+	_, err := s.execHook(s.db, `
+		CREATE TABLE IF NOT EXISTS compaction_events (
+			id             INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id     TEXT    NOT NULL,
+			project        TEXT    NOT NULL,
+			observation_id INTEGER,
+			created_at     TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			resurfaced_at  TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_compaction_pending ON compaction_events(project, resurfaced_at);
+	`)
+	return err
+}
 
-	if project != "" {
-		query += " AND s.project = ?"
-		args = append(args, project)
-	}
+func (s *Store) dropCompactionEvents() error {
+	_, err := s.execHook(s.db, "DROP TABLE IF EXISTS compaction_events")
+	return err
+}
 
-	query += " GROUP BY s.id ORDER BY MAX(COALESCE(o.created_at, s.started_at)) DESC LIMIT ?"
-	args = append(args, limit)
+// migrateEvents creates the changefeed table and the triggers that fill it
+// (see Events). Quarantined observations stay out of the feed until they
+// are approved, and writes to an already deleted observation are not
+// reported.
+func (s *Store) migrateEvents() error {
+	_, err := s.execHook(s.db, `
+		CREATE TABLE IF NOT EXISTS events (
+			seq            INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind           TEXT    NOT NULL,
+			session_id     TEXT,
+			observation_id INTEGER,
+			sync_id        TEXT,
+			project        TEXT,
+			data           TEXT,
+			created_at     TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		);
 
-	rows, err := s.queryItHook(s.db, query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+		CREATE TRIGGER IF NOT EXISTS events_obs_insert AFTER INSERT ON observations
+		WHEN new.quarantine_reason IS NULL AND new.deleted_at IS NULL BEGIN
+			INSERT INTO events (kind, session_id, observation_id, sync_id, project, data)
+			VALUES ('observation.created', new.session_id, new.id, new.sync_id, new.project,
+				json_object('type', new.type, 'title', new.title, 'scope', new.scope, 'topic_key', new.topic_key));
+		END;
 
-	var results []SessionSummary
-	for rows.Next() {
-		var ss SessionSummary
-		if err := rows.Scan(&ss.ID, &ss.Project, &ss.StartedAt, &ss.EndedAt, &ss.Summary, &ss.ObservationCount); err != nil {
-			return nil, err
+		CREATE TRIGGER IF NOT EXISTS events_obs_update AFTER UPDATE ON observations
+		WHEN new.quarantine_reason IS NULL AND NOT (old.deleted_at IS NOT NULL AND new.deleted_at IS NOT NULL) BEGIN
+			INSERT INTO events (kind, session_id, observation_id, sync_id, project, data)
+			VALUES (
+				CASE
+					WHEN new.deleted_at IS NOT NULL THEN 'observation.deleted'
+					WHEN old.quarantine_reason IS NOT NULL THEN 'observation.created'
+					ELSE 'observation.updated'
+				END,
+				new.session_id, new.id, new.sync_id, new.project,
+				json_object('type', new.type, 'title', new.title, 'scope', new.scope, 'topic_key', new.topic_key));
+		END;
+
+		CREATE TRIGGER IF NOT EXISTS events_obs_delete AFTER DELETE ON observations
+		WHEN old.quarantine_reason IS NULL AND old.deleted_at IS NULL BEGIN
+			INSERT INTO events (kind, session_id, observation_id, sync_id, project, data)
+			VALUES ('observation.deleted', old.session_id, old.id, old.sync_id, old.project, json_object('hard_delete', json('true')));
+		END;
+
+		CREATE TRIGGER IF NOT EXISTS events_session_insert AFTER INSERT ON sessions BEGIN
+			INSERT INTO events (kind, session_id, project, data)
+			VALUES ('session.started', new.id, new.project, json_object('directory', new.directory));
+			INSERT INTO events (kind, session_id, project, data)
+			SELECT 'session.ended', new.id, new.project, json_object('summary', new.summary)
+			WHERE new.ended_at IS NOT NULL;
+		END;
+
+		CREATE TRIGGER IF NOT EXISTS events_session_end AFTER UPDATE OF ended_at ON sessions
+		WHEN old.ended_at IS NULL AND new.ended_at IS NOT NULL BEGIN
+			INSERT INTO events (kind, session_id, project, data)
+			VALUES ('session.ended', new.id, new.project, json_object('summary', new.summary));
+		END;
+	`)
+	return err
+}
+
+// Observations and prompts are ordered by seq, a counter assigned on
+// insert, rather than by created_at: imports and sync pulls bring rows
+// whose timestamps tie or come from skewed clocks, and created_at is kept
+// for display only. Every insert assigns seq inline (nextObservationSeq,
+// nextPromptSeq). A trigger cannot do it: updating the new row would fire
+// the FTS update trigger before the FTS insert one. The migration numbers
+// rows saved before it by created_at, then id.
+const (
+	nextObservationSeq = `(SELECT ifnull(MAX(seq), 0) + 1 FROM observations)`
+	nextPromptSeq      = `(SELECT ifnull(MAX(seq), 0) + 1 FROM user_prompts)`
+)
+
+// migrateOrderingSeq adds seq to observations and user_prompts and
+// backfills it. It also recreates events_obs_update, unchanged, after the
+// session triggers, so databases that ran migration 8 before this one
+// shipped end with the same triggers, in the same order, as new ones.
+func (s *Store) migrateOrderingSeq() error {
+	for _, table := range []string{"observations", "user_prompts"} {
+		if err := s.addColumnIfNotExists(table, "seq", "INTEGER"); err != nil {
+			return err
 		}
-		results = append(results, ss)
 	}
-	return results, rows.Err()
+	if _, err := s.execHook(s.db, `
+		CREATE INDEX IF NOT EXISTS idx_obs_seq ON observations(seq);
+		CREATE INDEX IF NOT EXISTS idx_prompts_seq ON user_prompts(seq);
+	`); err != nil {
+		return err
+	}
+	return s.withTx(func(tx *sql.Tx) error {
+		for _, table := range []string{"observations", "user_prompts"} {
+			if err := s.backfillSeqTx(tx, table); err != nil {
+				return fmt.Errorf("backfill %s.seq: %w", table, err)
+			}
+		}
+		_, err := s.execHook(tx, `
+			DROP TRIGGER IF EXISTS events_obs_update;
+
+			CREATE TRIGGER events_obs_update AFTER UPDATE ON observations
+			WHEN new.quarantine_reason IS NULL AND NOT (old.deleted_at IS NOT NULL AND new.deleted_at IS NOT NULL) BEGIN
+				INSERT INTO events (kind, session_id, observation_id, sync_id, project, data)
+				VALUES (
+					CASE
+						WHEN new.deleted_at IS NOT NULL THEN 'observation.deleted'
+						WHEN old.quarantine_reason IS NOT NULL THEN 'observation.created'
+						ELSE 'observation.updated'
+					END,
+					new.session_id, new.id, new.sync_id, new.project,
+					json_object('type', new.type, 'title', new.title, 'scope', new.scope, 'topic_key', new.topic_key));
+			END;
+		`)
+		return err
+	})
 }
 
-// AllSessions returns recent sessions ordered by most recent first (for TUI browsing).
-func (s *Store) AllSessions(project string, limit int) ([]SessionSummary, error) {
-	if limit <= 0 {
-		limit = 50
+// backfillSeqTx numbers the rows of table that have no seq yet before the
+// ones that do, oldest created_at first; rows that already have a seq keep
+// their relative order. The table's triggers are set aside meanwhile:
+// renumbering is not an edit for the events feed, and legacy full-text
+// indexes may lack rows for the FTS update trigger to replace.
+func (s *Store) backfillSeqTx(tx *sql.Tx, table string) error {
+	var missing bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM ` + table + ` WHERE seq IS NULL)`).Scan(&missing); err != nil {
+		return err
 	}
-
-	query := `
-		SELECT s.id, s.project, s.started_at, s.ended_at, s.summary,
-		       COUNT(o.id) as observation_count
-		FROM sessions s
-		LEFT JOIN observations o ON o.session_id = s.id AND o.deleted_at IS NULL
-		WHERE 1=1
-	`
-	args := []any{}
-
-	if project != "" {
-		query += " AND s.project = ?"
-		args = append(args, project)
+	if !missing {
+		return nil
 	}
 
-	query += " GROUP BY s.id ORDER BY MAX(COALESCE(o.created_at, s.started_at)) DESC LIMIT ?"
-	args = append(args, limit)
-
-	rows, err := s.queryItHook(s.db, query, args...)
+	// SQLite fires the triggers on one event in an order set by when they
+	// were created, so they are put back in that order.
+	rows, err := tx.Query(`SELECT name, sql FROM sqlite_master WHERE type = 'trigger' AND tbl_name = ? ORDER BY rowid`, table)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
-
-	var results []SessionSummary
+	var names, triggers []string
 	for rows.Next() {
-		var ss SessionSummary
-		if err := rows.Scan(&ss.ID, &ss.Project, &ss.StartedAt, &ss.EndedAt, &ss.Summary, &ss.ObservationCount); err != nil {
-			return nil, err
+		var name, ddl string
+		if err := rows.Scan(&name, &ddl); err != nil {
+			rows.Close()
+			return err
 		}
-		results = append(results, ss)
+		names = append(names, name)
+		triggers = append(triggers, ddl)
 	}
-	return results, rows.Err()
-}
-
-// AllObservations returns recent observations ordered by most recent first (for TUI browsing).
-func (s *Store) AllObservations(project, scope string, limit int) ([]Observation, error) {
-	if limit <= 0 {
-		limit = s.cfg.MaxContextResults
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
 	}
-
-	query := `
-		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
-		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at
-		FROM observations o
-		WHERE o.deleted_at IS NULL
-	`
-	args := []any{}
-
-	if project != "" {
-		query += " AND o.project = ?"
-		args = append(args, project)
+	for _, name := range names {
+		if _, err := s.execHook(tx, `DROP TRIGGER `+name); err != nil {
+			return err
+		}
 	}
-	if scope != "" {
-		query += " AND o.scope = ?"
-		args = append(args, normalizeScope(scope))
+
+	if _, err := s.execHook(tx, `
+		UPDATE `+table+` SET seq = ranked.n
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY seq IS NOT NULL, seq, datetime(created_at), id) AS n
+			FROM `+table+`
+		) AS ranked
+		WHERE `+table+`.id = ranked.id`); err != nil {
+		return err
 	}
 
-	query += " ORDER BY o.created_at DESC LIMIT ?"
-	args = append(args, limit)
+	for _, ddl := range triggers {
+		if _, err := s.execHook(tx, ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	return s.queryObservations(query, args...)
+func (s *Store) dropOrderingSeq() error {
+	_, err := s.execHook(s.db, `
+		DROP INDEX IF EXISTS idx_obs_seq;
+		DROP INDEX IF EXISTS idx_prompts_seq;
+		ALTER TABLE observations DROP COLUMN seq;
+		ALTER TABLE user_prompts DROP COLUMN seq;
+	`)
+	return err
 }
 
-// SessionObservations returns all observations for a specific session.
-func (s *Store) SessionObservations(sessionID string, limit int) ([]Observation, error) {
-	if limit <= 0 {
-		limit = 200
+// migratePromptDedupe adds the dedupe columns AddPrompt uses. Prompts
+// saved before it have no normalized_hash and never absorb a repeat.
+func (s *Store) migratePromptDedupe() error {
+	for _, col := range []struct{ name, definition string }{
+		{"normalized_hash", "TEXT"},
+		{"duplicate_count", "INTEGER NOT NULL DEFAULT 1"},
+		{"last_seen_at", "TEXT"},
+	} {
+		if err := s.addColumnIfNotExists("user_prompts", col.name, col.definition); err != nil {
+			return err
+		}
 	}
+	_, err := s.execHook(s.db, `CREATE INDEX IF NOT EXISTS idx_prompts_dedupe ON user_prompts(normalized_hash, project, created_at DESC)`)
+	return err
+}
 
-	query := `
-		SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
-		       scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at
-		FROM observations
-		WHERE session_id = ? AND deleted_at IS NULL
-		ORDER BY created_at ASC
-		LIMIT ?
-	`
-	return s.queryObservations(query, sessionID, limit)
+func (s *Store) dropPromptDedupe() error {
+	_, err := s.execHook(s.db, `
+		DROP INDEX IF EXISTS idx_prompts_dedupe;
+		ALTER TABLE user_prompts DROP COLUMN normalized_hash;
+		ALTER TABLE user_prompts DROP COLUMN duplicate_count;
+		ALTER TABLE user_prompts DROP COLUMN last_seen_at;
+	`)
+	return err
 }
 
-// ─── Observations ────────────────────────────────────────────────────────────
+// migrateObservationSupersede adds superseded_by, the revision that
+// replaced an observation in append-only mode.
+func (s *Store) migrateObservationSupersede() error {
+	return s.addColumnIfNotExists("observations", "superseded_by", "INTEGER")
+}
 
-func (s *Store) AddObservation(p AddObservationParams) (int64, error) {
-	// Normalize project name (lowercase + trim) before any persistence
-	p.Project, _ = NormalizeProject(p.Project)
+func (s *Store) dropObservationSupersede() error {
+	_, err := s.execHook(s.db, `ALTER TABLE observations DROP COLUMN superseded_by`)
+	return err
+}
 
-	// Strip <private>...</private> tags before persisting ANYTHING
-	title := stripPrivateTags(p.Title)
-	content := stripPrivateTags(p.Content)
-
-	if len(content) > s.cfg.MaxObservationLength {
-		content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
-	}
-	scope := normalizeScope(p.Scope)
-	normHash := hashNormalized(content)
-	topicKey := normalizeTopicKey(p.TopicKey)
+func (s *Store) migrateObservationMetadata() error {
+	return s.addColumnIfNotExists("observations", "metadata", "TEXT")
+}
 
-	var observationID int64
-	err := s.withTx(func(tx *sql.Tx) error {
-		var obs *Observation
-		if topicKey != "" {
-			var existingID int64
-			err := tx.QueryRow(
-				`SELECT id FROM observations
-				 WHERE topic_key = ?
-				   AND ifnull(project, '') = ifnull(?, '')
-				   AND scope = ?
-				   AND deleted_at IS NULL
-				 ORDER BY datetime(updated_at) DESC, datetime(created_at) DESC
-				 LIMIT 1`,
-				topicKey, nullableString(p.Project), scope,
-			).Scan(&existingID)
-			if err == nil {
-				if _, err := s.execHook(tx,
-					`UPDATE observations
-					 SET type = ?,
-					     title = ?,
-					     content = ?,
-					     tool_name = ?,
-					     topic_key = ?,
-					     normalized_hash = ?,
-					     revision_count = revision_count + 1,
-					     last_seen_at = datetime('now'),
-					     updated_at = datetime('now')
-					 WHERE id = ?`,
-					p.Type,
-					title,
-					content,
-					nullableString(p.ToolName),
-					nullableString(topicKey),
-					normHash,
-					existingID,
-				); err != nil {
-					return err
-				}
-				obs, err = s.getObservationTx(tx, existingID)
-				if err != nil {
-					return err
-				}
-				observationID = existingID
-				return s.enqueueSyncMutationTx(tx, SyncEntityObservation, obs.SyncID, SyncOpUpsert, observationPayloadFromObservation(obs))
-			}
-			if err != sql.ErrNoRows {
-				return err
-			}
-		}
+func (s *Store) dropObservationMetadata() error {
+	_, err := s.execHook(s.db, `ALTER TABLE observations DROP COLUMN metadata`)
+	return err
+}
 
-		window := dedupeWindowExpression(s.cfg.DedupeWindow)
-		var existingID int64
-		err := tx.QueryRow(
-			`SELECT id FROM observations
-			 WHERE normalized_hash = ?
-			   AND ifnull(project, '') = ifnull(?, '')
-			   AND scope = ?
-			   AND type = ?
-			   AND title = ?
-			   AND deleted_at IS NULL
-			   AND datetime(created_at) >= datetime('now', ?)
-			 ORDER BY created_at DESC
-			 LIMIT 1`,
-			normHash, nullableString(p.Project), scope, p.Type, title, window,
-		).Scan(&existingID)
-		if err == nil {
-			if _, err := s.execHook(tx,
-				`UPDATE observations
-				 SET duplicate_count = duplicate_count + 1,
-				     last_seen_at = datetime('now'),
-				     updated_at = datetime('now')
-				 WHERE id = ?`,
-				existingID,
-			); err != nil {
-				return err
-			}
-			obs, err = s.getObservationTx(tx, existingID)
-			if err != nil {
-				return err
-			}
-			observationID = existingID
-			return s.enqueueSyncMutationTx(tx, SyncEntityObservation, obs.SyncID, SyncOpUpsert, observationPayloadFromObservation(obs))
-		}
-		if err != sql.ErrNoRows {
+// migrateObservationCompression adds the compressed flag, derived from how
+// content is stored, and recreates the FTS triggers to index content
+// through engram_inflate: a compressed row is indexed by its text, not its
+// gzip bytes. The column is generated, so pragma table_info hides it.
+func (s *Store) migrateObservationCompression() error {
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_xinfo('observations') WHERE name = 'compressed'`).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := s.execHook(s.db, `ALTER TABLE observations ADD COLUMN compressed INTEGER GENERATED ALWAYS AS (typeof(content) = 'blob') VIRTUAL`); err != nil {
 			return err
 		}
+	}
+	_, err := s.execHook(s.db, observationFTSTriggers("engram_inflate(%s.content)"))
+	return err
+}
 
-		syncID := newSyncID("obs")
-		res, err := s.execHook(tx,
-			`INSERT INTO observations (sync_id, session_id, type, title, content, tool_name, project, scope, topic_key, normalized_hash, revision_count, duplicate_count, last_seen_at, updated_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, 1, datetime('now'), datetime('now'))`,
-			syncID, p.SessionID, p.Type, title, content,
-			nullableString(p.ToolName), nullableString(p.Project), scope, nullableString(topicKey), normHash,
-		)
-		if err != nil {
-			return err
-		}
-		observationID, err = res.LastInsertId()
-		if err != nil {
-			return err
-		}
-		obs, err = s.getObservationTx(tx, observationID)
-		if err != nil {
+// dropObservationCompression stores every row as text again before
+// dropping the flag, so an older engram can read and index it.
+func (s *Store) dropObservationCompression() error {
+	err := s.withTx(func(tx *sql.Tx) error {
+		return s.withoutEvents(tx, func() error {
+			_, err := s.execHook(tx, `UPDATE observations SET content = engram_inflate(content) WHERE typeof(content) = 'blob'`)
 			return err
-		}
-		return s.enqueueSyncMutationTx(tx, SyncEntityObservation, obs.SyncID, SyncOpUpsert, observationPayloadFromObservation(obs))
+		})
 	})
 	if err != nil {
-		return 0, err
+		return err
 	}
-	return observationID, nil
+	if _, err := s.execHook(s.db, `ALTER TABLE observations DROP COLUMN compressed`); err != nil {
+		return err
+	}
+	_, err = s.execHook(s.db, observationFTSTriggers("%s.content"))
+	return err
 }
 
-func (s *Store) RecentObservations(project, scope string, limit int) ([]Observation, error) {
-	// Normalize project filter for case-insensitive matching
-	project, _ = NormalizeProject(project)
+// observationFTSTriggers replaces the triggers that keep observations_fts
+// in step with observations. content is the expression indexed for the
+// content column, with %s standing for new or old.
+func observationFTSTriggers(content string) string {
+	oldContent, newContent := fmt.Sprintf(content, "old"), fmt.Sprintf(content, "new")
+	return `
+		DROP TRIGGER IF EXISTS obs_fts_insert;
+		DROP TRIGGER IF EXISTS obs_fts_delete;
+		DROP TRIGGER IF EXISTS obs_fts_update;
 
-	if limit <= 0 {
-		limit = s.cfg.MaxContextResults
-	}
+		CREATE TRIGGER obs_fts_insert AFTER INSERT ON observations BEGIN
+			INSERT INTO observations_fts(rowid, title, content, tool_name, type, project, topic_key)
+			VALUES (new.id, new.title, ` + newContent + `, new.tool_name, new.type, new.project, new.topic_key);
+		END;
 
-	query := `
-		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
-		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at
-		FROM observations o
-		WHERE o.deleted_at IS NULL
+		CREATE TRIGGER obs_fts_delete AFTER DELETE ON observations BEGIN
+			INSERT INTO observations_fts(observations_fts, rowid, title, content, tool_name, type, project, topic_key)
+			VALUES ('delete', old.id, old.title, ` + oldContent + `, old.tool_name, old.type, old.project, old.topic_key);
+		END;
+
+		CREATE TRIGGER obs_fts_update AFTER UPDATE ON observations BEGIN
+			INSERT INTO observations_fts(observations_fts, rowid, title, content, tool_name, type, project, topic_key)
+			VALUES ('delete', old.id, old.title, ` + oldContent + `, old.tool_name, old.type, old.project, old.topic_key);
+			INSERT INTO observations_fts(rowid, title, content, tool_name, type, project, topic_key)
+			VALUES (new.id, new.title, ` + newContent + `, new.tool_name, new.type, new.project, new.topic_key);
+		END;
 	`
-	args := []any{}
+}
 
-	if project != "" {
-		query += " AND o.project = ?"
-		args = append(args, project)
-	}
-	if scope != "" {
-		query += " AND o.scope = ?"
-		args = append(args, normalizeScope(scope))
+func (s *Store) dropEvents() error {
+	_, err := s.execHook(s.db, `
+		DROP TRIGGER IF EXISTS events_obs_insert;
+		DROP TRIGGER IF EXISTS events_obs_update;
+		DROP TRIGGER IF EXISTS events_obs_delete;
+		DROP TRIGGER IF EXISTS events_session_insert;
+		DROP TRIGGER IF EXISTS events_session_end;
+		DROP TABLE IF EXISTS events;
+	`)
+	return err
+}
+
+func (s *Store) migrateFTSTopicKey() error {
+	var colCount int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM pragma_table_xinfo('observations_fts') WHERE name = 'topic_key'").Scan(&colCount)
+	if err != nil || colCount > 0 {
+		return nil
 	}
 
-	query += " ORDER BY o.created_at DESC LIMIT ?"
-	args = append(args, limit)
+	if _, err := s.execHook(s.db, `
+		DROP TRIGGER IF EXISTS obs_fts_insert;
+		DROP TRIGGER IF EXISTS obs_fts_update;
+		DROP TRIGGER IF EXISTS obs_fts_delete;
+		DROP TABLE IF EXISTS observations_fts;
+		CREATE VIRTUAL TABLE observations_fts USING fts5(
+			title,
+			content,
+			tool_name,
+			type,
+			project,
+			topic_key,
+			content='observations',
+			content_rowid='id'
+		);
+		INSERT INTO observations_fts(rowid, title, content, tool_name, type, project, topic_key)
+		SELECT id, title, content, tool_name, type, project, topic_key
+		FROM observations
+		WHERE deleted_at IS NULL;
 
-	return s.queryObservations(query, args...)
+		CREATE TRIGGER obs_fts_insert AFTER INSERT ON observations BEGIN
+			INSERT INTO observations_fts(rowid, title, content, tool_name, type, project, topic_key)
+			VALUES (new.id, new.title, new.content, new.tool_name, new.type, new.project, new.topic_key);
+		END;
+
+		CREATE TRIGGER obs_fts_delete AFTER DELETE ON observations BEGIN
+			INSERT INTO observations_fts(observations_fts, rowid, title, content, tool_name, type, project, topic_key)
+			VALUES ('delete', old.id, old.title, old.content, old.tool_name, old.type, old.project, old.topic_key);
+		END;
+
+		CREATE TRIGGER obs_fts_update AFTER UPDATE ON observations BEGIN
+			INSERT INTO observations_fts(observations_fts, rowid, title, content, tool_name, type, project, topic_key)
+			VALUES ('delete', old.id, old.title, old.content, old.tool_name, old.type, old.project, old.topic_key);
+			INSERT INTO observations_fts(rowid, title, content, tool_name, type, project, topic_key)
+			VALUES (new.id, new.title, new.content, new.tool_name, new.type, new.project, new.topic_key);
+		END;
+	`); err != nil {
+		return fmt.Errorf("migrate fts topic_key: %w", err)
+	}
+	return nil
 }
 
-// ─── User Prompts ────────────────────────────────────────────────────────────
+// ─── Sessions ────────────────────────────────────────────────────────────────
 
-func (s *Store) AddPrompt(p AddPromptParams) (int64, error) {
+func (s *Store) CreateSession(id, project, directory string) error {
 	// Normalize project name before storing
-	p.Project, _ = NormalizeProject(p.Project)
+	project, _ = NormalizeProject(project)
 
-	content := stripPrivateTags(p.Content)
-	if len(content) > s.cfg.MaxObservationLength {
-		content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
+	return s.withTxInvalidating(func(tx *sql.Tx) error {
+		return s.createSyncedSessionTx(tx, id, project, directory)
+	}, project, cacheSessions)
+}
+
+// createSyncedSessionTx creates a session and journals it for sync. project
+// must already be normalized.
+func (s *Store) createSyncedSessionTx(tx *sql.Tx, id, project, directory string) error {
+	if err := s.createSessionTx(tx, id, project, directory); err != nil {
+		return err
 	}
+	return s.enqueueSyncMutationTx(tx, SyncEntitySession, id, SyncOpUpsert, syncSessionPayload{
+		ID:        id,
+		Project:   project,
+		Directory: directory,
+	})
+}
 
-	var promptID int64
-	err := s.withTx(func(tx *sql.Tx) error {
-		syncID := newSyncID("prompt")
+// EndSession marks a session completed and promotes its durable working
+// memory items into observations (see PromoteScratch).
+func (s *Store) EndSession(id string, summary string) error {
+	if err := s.endSession(id, summary); err != nil {
+		return err
+	}
+	if _, err := s.PromoteScratch(id); err != nil {
+		return fmt.Errorf("session %q ended, but promoting working memory failed: %w", id, err)
+	}
+	if summary != "" {
+		return nil
+	}
+	if _, _, err := s.SummarizeSession(id); err != nil &&
+		!errors.Is(err, ErrNothingToSummarize) && !errors.Is(err, ErrSessionNotFound) {
+		return fmt.Errorf("session %q ended, but composing its summary failed: %w", id, err)
+	}
+	return nil
+}
+
+func (s *Store) endSession(id string, summary string) error {
+	return s.withTx(func(tx *sql.Tx) error {
 		res, err := s.execHook(tx,
-			`INSERT INTO user_prompts (sync_id, session_id, content, project) VALUES (?, ?, ?, ?)`,
-			syncID, p.SessionID, content, nullableString(p.Project),
+			`UPDATE sessions SET ended_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), summary = ? WHERE id = ?`,
+			nullableString(summary), id,
 		)
 		if err != nil {
 			return err
 		}
-		promptID, err = res.LastInsertId()
+		rows, err := res.RowsAffected()
 		if err != nil {
 			return err
 		}
-		return s.enqueueSyncMutationTx(tx, SyncEntityPrompt, syncID, SyncOpUpsert, syncPromptPayload{
-			SyncID:    syncID,
-			SessionID: p.SessionID,
-			Content:   content,
-			Project:   nullableString(p.Project),
+		if rows == 0 {
+			return nil
+		}
+
+		var endedAt string
+		var project, directory string
+		var storedSummary, parentID *string
+		if err := tx.QueryRow(
+			`SELECT project, directory, ended_at, summary, parent_session_id FROM sessions WHERE id = ?`,
+			id,
+		).Scan(&project, &directory, &endedAt, &storedSummary, &parentID); err != nil {
+			return err
+		}
+
+		return s.enqueueSyncMutationTx(tx, SyncEntitySession, id, SyncOpUpsert, syncSessionPayload{
+			ID:              id,
+			Project:         project,
+			Directory:       directory,
+			EndedAt:         &endedAt,
+			Summary:         storedSummary,
+			ParentSessionID: parentID,
 		})
 	})
-	if err != nil {
-		return 0, err
-	}
-	return promptID, nil
 }
 
-func (s *Store) RecentPrompts(project string, limit int) ([]Prompt, error) {
-	// Normalize project filter for case-insensitive matching
-	project, _ = NormalizeProject(project)
-
-	if limit <= 0 {
-		limit = 20
+func (s *Store) GetSession(id string) (*Session, error) {
+	row := s.db.QueryRow(
+		`SELECT id, project, directory, started_at, ended_at, summary, parent_session_id FROM sessions WHERE id = ?`, id,
+	)
+	var sess Session
+	if err := row.Scan(&sess.ID, &sess.Project, &sess.Directory, &sess.StartedAt, &sess.EndedAt, &sess.Summary, &sess.ParentSessionID); err != nil {
+		return nil, err
 	}
+	return &sess, nil
+}
 
-	query := `SELECT id, ifnull(sync_id, '') as sync_id, session_id, content, ifnull(project, '') as project, created_at FROM user_prompts`
-	args := []any{}
-
-	if project != "" {
-		query += " WHERE project = ?"
-		args = append(args, project)
-	}
+// maxSessionChain bounds how far SessionChain walks, so a corrupted link
+// can never loop forever.
+const maxSessionChain = 50
 
-	query += " ORDER BY created_at DESC LIMIT ?"
-	args = append(args, limit)
+// CreateSessionWithParent creates a session that continues parentID, the
+// session an agent was in before a compaction or crash. An empty parentID
+// behaves like CreateSession. The parent must exist, and linking must not
+// make a session continue itself (ErrSessionCycle).
+func (s *Store) CreateSessionWithParent(id, project, directory, parentID string) error {
+	project, _ = NormalizeProject(project)
+	parentID = strings.TrimSpace(parentID)
 
-	rows, err := s.queryItHook(s.db, query, args...)
-	if err != nil {
+	return s.withTx(func(tx *sql.Tx) error {
+		if parentID == "" {
+			return s.createSyncedSessionTx(tx, id, project, directory)
+		}
+		if parentID == id {
+			return fmt.Errorf("%w: %q cannot continue itself", ErrSessionCycle, id)
+		}
+		if _, err := s.getSessionTx(tx, parentID); err != nil {
+			return fmt.Errorf("parent: %w", err)
+		}
+		// Walk up from the parent: reaching id means the parent already
+		// (indirectly) continues this session.
+		next := parentID
+		for range maxSessionChain {
+			var up *string
+			if err := tx.QueryRow(`SELECT parent_session_id FROM sessions WHERE id = ?`, next).Scan(&up); err != nil || up == nil {
+				break
+			}
+			if *up == id {
+				return fmt.Errorf("%w: %q already continues %q", ErrSessionCycle, parentID, id)
+			}
+			next = *up
+		}
+
+		if err := s.createSessionTx(tx, id, project, directory); err != nil {
+			return err
+		}
+		if _, err := s.execHook(tx, `UPDATE sessions SET parent_session_id = ? WHERE id = ?`, parentID, id); err != nil {
+			return err
+		}
+		return s.enqueueSessionContentsTx(tx, id)
+	})
+}
+
+// SessionChain returns the sessions id continues, nearest parent first. It
+// stops at a missing parent, a repeated session, or maxSessionChain links.
+func (s *Store) SessionChain(id string) ([]Session, error) {
+	sess, err := s.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	var chain []Session
+	seen := map[string]bool{id: true}
+	for sess.ParentSessionID != nil && !seen[*sess.ParentSessionID] && len(chain) < maxSessionChain {
+		seen[*sess.ParentSessionID] = true
+		sess, err = s.GetSession(*sess.ParentSessionID)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, *sess)
+	}
+	return chain, nil
+}
+
+// FormatSessionChain renders a SessionChain as "parent ← grandparent ← ...".
+func FormatSessionChain(chain []Session) string {
+	ids := make([]string, len(chain))
+	for i, sess := range chain {
+		ids[i] = sess.ID
+	}
+	return strings.Join(ids, " ← ")
+}
+
+// continuationNotes describes, for every session in sessions that continues
+// another, its parent and the nearest summary up the chain, so the context
+// of a continued session still carries what came before the compaction.
+func (s *Store) continuationNotes(sessions []SessionSummary) (map[string]string, error) {
+	notes := make(map[string]string)
+	for _, sess := range sessions {
+		if sess.ParentSessionID == nil {
+			continue
+		}
+		chain, err := s.SessionChain(sess.ID)
+		if err != nil {
+			return nil, err
+		}
+		note := "continues " + *sess.ParentSessionID
+		for _, ancestor := range chain {
+			if ancestor.Summary == nil || strings.TrimSpace(*ancestor.Summary) == "" {
+				continue
+			}
+			if ancestor.ID == *sess.ParentSessionID {
+				note += ": " + truncate(*ancestor.Summary, 200)
+			} else {
+				note += fmt.Sprintf(" (%s: %s)", ancestor.ID, truncate(*ancestor.Summary, 200))
+			}
+			break
+		}
+		notes[sess.ID] = note
+	}
+	return notes, nil
+}
+
+// sessionRecency orders grouped sessions by their newest observation's
+// seq. Sessions without observations follow, newest started first.
+const sessionRecency = "MAX(o.seq) IS NULL, MAX(o.seq) DESC, s.started_at DESC"
+
+func (s *Store) RecentSessions(project string, limit int) ([]SessionSummary, error) {
+	// Normalize project filter for case-insensitive matching
+	project, _ = NormalizeProject(project)
+
+	if limit <= 0 {
+		limit = 5
+	}
+
+	return cachedQuery(s.cache, cacheSessions, project, strconv.Itoa(limit), func() ([]SessionSummary, error) {
+		return s.recentSessions(project, limit)
+	})
+}
+
+func (s *Store) recentSessions(project string, limit int) ([]SessionSummary, error) {
+	query := `
+		SELECT s.id, s.project, s.started_at, s.ended_at, s.summary, s.parent_session_id,
+		       COUNT(o.id) as observation_count
+		FROM sessions s
+		LEFT JOIN observations o ON o.session_id = s.id AND o.deleted_at IS NULL
+		WHERE 1=1
+	`
+	args := []any{}
+
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("s.project", project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+
+	query += " GROUP BY s.id ORDER BY " + sessionRecency + " LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.queryItHook(s.db, query, args...)
+	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var results []Prompt
+	var results []SessionSummary
 	for rows.Next() {
-		var p Prompt
-		if err := rows.Scan(&p.ID, &p.SyncID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt); err != nil {
+		var ss SessionSummary
+		if err := rows.Scan(&ss.ID, &ss.Project, &ss.StartedAt, &ss.EndedAt, &ss.Summary, &ss.ParentSessionID, &ss.ObservationCount); err != nil {
 			return nil, err
 		}
-		results = append(results, p)
+		results = append(results, ss)
 	}
 	return results, rows.Err()
 }
 
-func (s *Store) SearchPrompts(query string, project string, limit int) ([]Prompt, error) {
+// AllSessions returns recent sessions ordered by most recent first (for TUI browsing).
+func (s *Store) AllSessions(project string, limit int) ([]SessionSummary, error) {
 	if limit <= 0 {
-		limit = 10
+		limit = 50
 	}
 
-	ftsQuery := sanitizeFTS(query)
-
-	sql := `
-		SELECT p.id, ifnull(p.sync_id, '') as sync_id, p.session_id, p.content, ifnull(p.project, '') as project, p.created_at
-		FROM prompts_fts fts
-		JOIN user_prompts p ON p.id = fts.rowid
-		WHERE prompts_fts MATCH ?
+	query := `
+		SELECT s.id, s.project, s.started_at, s.ended_at, s.summary, s.parent_session_id,
+		       COUNT(o.id) as observation_count
+		FROM sessions s
+		LEFT JOIN observations o ON o.session_id = s.id AND o.deleted_at IS NULL
+		WHERE 1=1
 	`
-	args := []any{ftsQuery}
+	args := []any{}
 
 	if project != "" {
-		sql += " AND p.project = ?"
-		args = append(args, project)
+		clause, clauseArgs := projectFilterSQL("s.project", project)
+		query += clause
+		args = append(args, clauseArgs...)
 	}
 
-	sql += " ORDER BY fts.rank LIMIT ?"
+	query += " GROUP BY s.id ORDER BY " + sessionRecency + " LIMIT ?"
 	args = append(args, limit)
 
-	rows, err := s.queryItHook(s.db, sql, args...)
+	rows, err := s.queryItHook(s.db, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("search prompts: %w", err)
+		return nil, err
 	}
 	defer rows.Close()
 
-	var results []Prompt
+	var results []SessionSummary
 	for rows.Next() {
-		var p Prompt
-		if err := rows.Scan(&p.ID, &p.SyncID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt); err != nil {
+		var ss SessionSummary
+		if err := rows.Scan(&ss.ID, &ss.Project, &ss.StartedAt, &ss.EndedAt, &ss.Summary, &ss.ParentSessionID, &ss.ObservationCount); err != nil {
 			return nil, err
 		}
-		results = append(results, p)
+		results = append(results, ss)
 	}
 	return results, rows.Err()
 }
 
-// ─── Delete Session ──────────────────────────────────────────────────────────
+// AllObservations returns recent observations ordered by most recent first (for TUI browsing).
+func (s *Store) AllObservations(project, scope string, limit int) ([]Observation, error) {
+	if limit <= 0 {
+		limit = s.cfg.MaxContextResults
+	}
 
-// DeleteSession hard-deletes a session and its prompts.
-// It returns ErrSessionHasObservations if the session has any observations
-// (including soft-deleted ones) to prevent orphaned rows.
-// It returns ErrSessionNotFound if no session with that ID exists.
-//
-// Note: this delete only removes local rows. It does not enqueue a delete
-// sync mutation, but any previously enqueued mutations for the session or its
-// prompts may still be synced later if autosync is enabled, and a later pull
-// may recreate the deleted rows locally.
-func (s *Store) DeleteSession(id string) error {
-	return s.withTx(func(tx *sql.Tx) error {
-		// Count ALL observations for the session, including soft-deleted ones,
-		// because the FK constraint on observations.session_id has no ON DELETE CASCADE.
-		var count int
-		rows, err := s.queryItHook(tx, `SELECT COUNT(*) FROM observations WHERE session_id = ?`, id)
-		if err != nil {
-			return fmt.Errorf("delete session: count observations: %w", err)
-		}
-		if rows.Next() {
-			if err := rows.Scan(&count); err != nil {
-				_ = rows.Close()
-				return fmt.Errorf("delete session: count observations: %w", err)
-			}
-		}
-		_ = rows.Close()
-		if count > 0 {
-			return fmt.Errorf("%w: session %q has %d observation(s)", ErrSessionHasObservations, id, count)
-		}
+	query := `
+		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at, o.refs, o.verified_at, o.stale_at, o.verification_note, o.source, o.metadata
+		FROM observations o
+		WHERE o.deleted_at IS NULL AND o.quarantine_reason IS NULL
+	`
+	args := []any{}
 
-		if _, err := s.execHook(tx, `DELETE FROM user_prompts WHERE session_id = ?`, id); err != nil {
-			return fmt.Errorf("delete session: remove prompts: %w", err)
-		}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("o.project", project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	if scope != "" {
+		query += " AND o.scope = ?"
+		args = append(args, normalizeScope(scope))
+	}
 
-		res, err := s.execHook(tx, `DELETE FROM sessions WHERE id = ?`, id)
-		if err != nil {
-			var sqliteErr *sqlite.Error
-			if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqliteConstraintForeignKey {
-				return fmt.Errorf("%w: session %q has observation(s)", ErrSessionHasObservations, id)
-			}
-			return fmt.Errorf("delete session: %w", err)
-		}
-		n, err := res.RowsAffected()
-		if err != nil {
-			return fmt.Errorf("delete session: rows affected: %w", err)
-		}
-		if n == 0 {
-			return fmt.Errorf("%w: %q", ErrSessionNotFound, id)
-		}
+	query += " ORDER BY o.seq DESC, o.id DESC LIMIT ?"
+	args = append(args, limit)
 
-		return nil
-	})
+	return s.queryObservations(query, args...)
 }
 
-// ─── Delete Prompt ───────────────────────────────────────────────────────────
+// ListObservations returns the newest observations matching the filters in
+// opts, with no query text. It backs list views that narrow by type,
+// project, and scope; Limit defaults to MaxContextResults.
+func (s *Store) ListObservations(opts SearchOptions) ([]Observation, error) {
+	opts.Project, _ = NormalizeProject(opts.Project)
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = s.cfg.MaxContextResults
+	}
 
-// DeletePrompt hard-deletes a single prompt by ID.
-// It returns ErrPromptNotFound if no prompt with that ID exists.
-//
-// Note: this delete only removes local rows. It does not enqueue a delete
-// sync mutation, but any previously enqueued mutations for the prompt
-// may still be synced later if autosync is enabled, and a later pull
-// may recreate the deleted row locally.
-func (s *Store) DeletePrompt(id int64) error {
-	return s.withTx(func(tx *sql.Tx) error {
-		res, err := s.execHook(tx, `DELETE FROM user_prompts WHERE id = ?`, id)
-		if err != nil {
-			return fmt.Errorf("delete prompt: %w", err)
-		}
-		n, err := res.RowsAffected()
-		if err != nil {
-			return fmt.Errorf("delete prompt: rows affected: %w", err)
-		}
-		if n == 0 {
-			return fmt.Errorf("%w: prompt #%d", ErrPromptNotFound, id)
-		}
-		return nil
-	})
-}
+	clause, args := typeFilterSQL("o.type", opts.Type, opts.Types)
+	query := `
+		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at, o.refs, o.verified_at, o.stale_at, o.verification_note, o.source, o.metadata
+		FROM observations o
+		WHERE o.deleted_at IS NULL` + clause
 
-// ─── Get Single Observation ──────────────────────────────────────────────────
+	if !opts.IncludeQuarantined {
+		query += " AND o.quarantine_reason IS NULL"
+	}
+	if opts.Project != "" {
+		clause, clauseArgs := projectFilterSQL("o.project", opts.Project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	if opts.Scope != "" {
+		query += " AND o.scope = ?"
+		args = append(args, normalizeScope(opts.Scope))
+	}
+	if clause, refArgs := refFilterSQL("o.refs", opts.Ref); clause != "" {
+		query += clause
+		args = append(args, refArgs...)
+	}
+	if clause, metaArgs := metadataFilterSQL("o.metadata", opts.Metadata); clause != "" {
+		query += clause
+		args = append(args, metaArgs...)
+	}
+	if clause, fileArgs := fileFilterSQL("o.id", opts.File); clause != "" {
+		query += clause
+		args = append(args, fileArgs...)
+	}
+	if clause, sourceArgs := sourceFilterSQL("o.source", opts.Source); clause != "" {
+		query += clause
+		args = append(args, sourceArgs...)
+	}
+	if clause, windowArgs := windowFilterSQL("o.", opts); clause != "" {
+		query += clause
+		args = append(args, windowArgs...)
+	}
+	query += " ORDER BY o.seq DESC, o.id DESC LIMIT ?"
+	args = append(args, limit)
 
-func (s *Store) GetObservation(id int64) (*Observation, error) {
-	row := s.db.QueryRow(
-		`SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
-		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at
-		 FROM observations WHERE id = ? AND deleted_at IS NULL`, id,
-	)
-	var o Observation
-	if err := row.Scan(
-		&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, &o.Content,
-		&o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt,
-		&o.CreatedAt, &o.UpdatedAt, &o.DeletedAt,
-	); err != nil {
-		return nil, err
+	return s.queryObservations(query, args...)
+}
+
+// SessionObservations returns all observations for a specific session.
+func (s *Store) SessionObservations(sessionID string, limit int) ([]Observation, error) {
+	if limit <= 0 {
+		limit = 200
 	}
-	return &o, nil
+	return s.sessionObservations(sessionID, limit)
 }
 
-func (s *Store) UpdateObservation(id int64, p UpdateObservationParams) (*Observation, error) {
-	var updated *Observation
-	err := s.withTx(func(tx *sql.Tx) error {
-		obs, err := s.getObservationTx(tx, id)
-		if err != nil {
-			return err
-		}
+// sessionObservations is SessionObservations without the default cap: a
+// negative limit returns every row.
+func (s *Store) sessionObservations(sessionID string, limit int) ([]Observation, error) {
+	query := `
+		SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
+		       scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata
+		FROM observations
+		WHERE session_id = ? AND deleted_at IS NULL AND quarantine_reason IS NULL
+		ORDER BY seq ASC, id ASC
+		LIMIT ?
+	`
+	return s.queryObservations(query, sessionID, limit)
+}
 
-		typ := obs.Type
-		title := obs.Title
-		content := obs.Content
-		project := derefString(obs.Project)
-		scope := obs.Scope
-		topicKey := derefString(obs.TopicKey)
-
-		if p.Type != nil {
-			typ = *p.Type
-		}
-		if p.Title != nil {
-			title = stripPrivateTags(*p.Title)
-		}
-		if p.Content != nil {
-			content = stripPrivateTags(*p.Content)
-			if len(content) > s.cfg.MaxObservationLength {
-				content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
-			}
-		}
-		if p.Project != nil {
-			project, _ = NormalizeProject(*p.Project)
-		}
-		if p.Scope != nil {
-			scope = normalizeScope(*p.Scope)
-		}
-		if p.TopicKey != nil {
-			topicKey = normalizeTopicKey(*p.TopicKey)
-		}
+// ─── Observations ────────────────────────────────────────────────────────────
 
-		if _, err := s.execHook(tx,
-			`UPDATE observations
-			 SET type = ?,
-			     title = ?,
-			     content = ?,
-			     project = ?,
-			     scope = ?,
-			     topic_key = ?,
-			     normalized_hash = ?,
-			     revision_count = revision_count + 1,
-			     updated_at = datetime('now')
-			 WHERE id = ? AND deleted_at IS NULL`,
-			typ,
-			title,
-			content,
-			nullableString(project),
-			scope,
-			nullableString(topicKey),
-			hashNormalized(content),
-			id,
-		); err != nil {
-			return err
-		}
+func (s *Store) AddObservation(p AddObservationParams) (int64, error) {
+	var observationID int64
+	// Observation counts change for whichever session the observation
+	// joins, whatever its project, so sessions are dropped for every project.
+	project, _ := NormalizeProject(p.Project)
+	err := s.withTxInvalidating(func(tx *sql.Tx) error {
+		var err error
+		observationID, err = s.addObservationTx(tx, p)
+		return err
+	}, project, cacheObservations)
+	s.cache.invalidateKind(cacheSessions)
+	if err != nil {
+		return 0, err
+	}
+	return observationID, nil
+}
 
-		updated, err = s.getObservationTx(tx, id)
-		if err != nil {
-			return err
+// AddObservationBatch saves several observations in one transaction, which
+// is much cheaper than one transaction each under bursty ingestion. Every
+// observation runs in its own savepoint, so a failure (a quota, a missing
+// session) drops only that one: ids[i] and errs[i] report each outcome. The
+// returned error is for the transaction as a whole.
+func (s *Store) AddObservationBatch(params []AddObservationParams) (ids []int64, errs []error, err error) {
+	ids = make([]int64, len(params))
+	errs = make([]error, len(params))
+	err = s.withTx(func(tx *sql.Tx) error {
+		for i, p := range params {
+			if _, err := s.execHook(tx, "SAVEPOINT add_observation"); err != nil {
+				return err
+			}
+			ids[i], errs[i] = s.addObservationTx(tx, p)
+			release := "RELEASE add_observation"
+			if errs[i] != nil {
+				release = "ROLLBACK TO add_observation; RELEASE add_observation"
+			}
+			if _, err := s.execHook(tx, release); err != nil {
+				return err
+			}
 		}
-		return s.enqueueSyncMutationTx(tx, SyncEntityObservation, updated.SyncID, SyncOpUpsert, observationPayloadFromObservation(updated))
+		return nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return updated, nil
+	return ids, errs, nil
 }
 
-func (s *Store) DeleteObservation(id int64, hardDelete bool) error {
-	return s.withTx(func(tx *sql.Tx) error {
-		obs, err := s.getObservationTx(tx, id)
-		if err == sql.ErrNoRows {
-			return nil
-		}
-		if err != nil {
-			return err
-		}
+func (s *Store) addObservationTx(tx *sql.Tx, p AddObservationParams) (int64, error) {
+	// Normalize project name (lowercase + trim) before any persistence
+	p.Project, _ = NormalizeProject(p.Project)
+
+	// Strip <private>...</private> tags before persisting ANYTHING
+	title := stripPrivateTags(p.Title)
+	content := stripPrivateTags(p.Content)
 
-		deletedAt := Now()
-		if hardDelete {
-			if _, err := s.execHook(tx, `DELETE FROM observations WHERE id = ?`, id); err != nil {
+	// Over-long content is split into continuation observations; the first
+	// part is saved here and the rest by replaceObservationPartsTx.
+	parts := splitObservationContent(content, s.cfg.MaxObservationLength)
+	content = parts[0]
+	scope := normalizeScope(p.Scope)
+	normHash := hashNormalized(content)
+	topicKey := normalizeTopicKey(p.TopicKey)
+	refs := observationRefs(p.Refs, title, content)
+	metadata, err := normalizeMetadata(p.Metadata)
+	if err != nil {
+		return 0, err
+	}
+
+	var observationID int64
+	err = func() error {
+		var obs *Observation
+		if topicKey != "" {
+			var existingID int64
+			err := tx.QueryRow(
+				`SELECT id FROM observations
+				 WHERE topic_key = ?
+				   AND ifnull(project, '') = ifnull(?, '')
+				   AND scope = ?
+				   AND deleted_at IS NULL
+				   AND quarantine_reason IS NULL
+				 ORDER BY datetime(updated_at) DESC, datetime(created_at) DESC
+				 LIMIT 1`,
+				topicKey, nullableString(p.Project), scope,
+			).Scan(&existingID)
+			if err == nil && s.cfg.AppendOnly {
+				existing, err := s.getObservationTx(tx, existingID)
+				if err != nil {
+					return err
+				}
+				if metadata == nil {
+					metadata = existing.Metadata
+				}
+				obs, err = s.supersedeObservationTx(tx, existing, Observation{
+					SyncID: newSyncID("obs"), SessionID: p.SessionID, Type: p.Type, Title: title, Content: content,
+					ToolName: nullableString(p.ToolName), Project: existing.Project, Scope: scope,
+					TopicKey: existing.TopicKey, Refs: refs, Metadata: metadata, Source: nullableString(p.Source),
+				})
+				if err != nil {
+					return err
+				}
+				observationID = obs.ID
+				return s.enqueueSupersedeTx(tx, existing, obs)
+			}
+			if err == nil {
+				if _, err := s.execHook(tx,
+					`UPDATE observations
+					 SET type = ?,
+					     title = ?,
+					     content = ?,
+					     tool_name = ?,
+					     topic_key = ?,
+					     refs = ?,
+					     metadata = coalesce(?, metadata),
+					     normalized_hash = ?,
+					     stale_at = NULL,
+					     revision_count = revision_count + 1,
+					     last_seen_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+					     updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+					 WHERE id = ?`,
+					p.Type,
+					title,
+					s.storedContent(content),
+					nullableString(p.ToolName),
+					nullableString(topicKey),
+					refs,
+					metadata,
+					normHash,
+					existingID,
+				); err != nil {
+					return err
+				}
+				if err := s.linkObservationFiles(tx, existingID, title, content); err != nil {
+					return err
+				}
+				obs, err = s.getObservationTx(tx, existingID)
+				if err != nil {
+					return err
+				}
+				observationID = existingID
+				return s.enqueueSyncMutationTx(tx, SyncEntityObservation, obs.SyncID, SyncOpUpsert, observationPayloadFromObservation(obs))
+			}
+			if err != sql.ErrNoRows {
 				return err
 			}
-		} else {
+		}
+
+		policy := s.limits().dedupePolicy(p.Type)
+		err := sql.ErrNoRows
+		var existingID int64
+		if policy.Strategy != DedupeOff {
+			anyTitle := policy.Strategy == DedupeContent
+			err = tx.QueryRow(
+				`SELECT id FROM observations
+				 WHERE normalized_hash = ?
+				   AND ifnull(project, '') = ifnull(?, '')
+				   AND scope = ?
+				   AND type = ?
+				   AND (? OR title = ?)
+				   AND deleted_at IS NULL
+				   AND quarantine_reason IS NULL
+				   AND datetime(created_at) >= datetime('now', ?)
+				 ORDER BY seq DESC, id DESC
+				 LIMIT 1`,
+				normHash, nullableString(p.Project), scope, p.Type, anyTitle, title,
+				dedupeWindowExpression(policy.Window),
+			).Scan(&existingID)
+		}
+		if err == nil {
 			if _, err := s.execHook(tx,
 				`UPDATE observations
-				 SET deleted_at = datetime('now'),
-				     updated_at = datetime('now')
-				 WHERE id = ? AND deleted_at IS NULL`,
-				id,
+				 SET duplicate_count = duplicate_count + 1,
+				     last_seen_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+				     updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+				 WHERE id = ?`,
+				existingID,
 			); err != nil {
 				return err
 			}
-			if err := tx.QueryRow(`SELECT deleted_at FROM observations WHERE id = ?`, id).Scan(&deletedAt); err != nil {
+			obs, err = s.getObservationTx(tx, existingID)
+			if err != nil {
 				return err
 			}
+			observationID = existingID
+			return s.enqueueSyncMutationTx(tx, SyncEntityObservation, obs.SyncID, SyncOpUpsert, observationPayloadFromObservation(obs))
+		}
+		if err != sql.ErrNoRows {
+			return err
 		}
 
-		return s.enqueueSyncMutationTx(tx, SyncEntityObservation, obs.SyncID, SyncOpDelete, syncObservationPayload{
-			SyncID:     obs.SyncID,
-			Deleted:    true,
-			DeletedAt:  &deletedAt,
-			HardDelete: hardDelete,
-		})
-	})
-}
-
-// ─── Timeline ────────────────────────────────────────────────────────────────
-//
-// Timeline provides chronological context around a specific observation.
-// Given an observation ID, it returns N observations before and M after,
-// all within the same session. This is the "progressive disclosure" pattern
-// from claude-mem — agents first search, then use timeline to drill into
-// the chronological neighborhood of a result.
+		if err := s.checkQuota(tx, p.Project, int64(len(title)+len(content))); err != nil {
+			return err
+		}
 
-func (s *Store) Timeline(observationID int64, before, after int) (*TimelineResult, error) {
-	if before <= 0 {
-		before = 5
-	}
-	if after <= 0 {
-		after = 5
+		syncID := newSyncID("obs")
+		res, err := s.execHook(tx,
+			`INSERT INTO observations (sync_id, session_id, type, title, content, tool_name, project, scope, topic_key, refs, metadata, normalized_hash, source, revision_count, duplicate_count, last_seen_at, updated_at, seq)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, 1, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), `+nextObservationSeq+`)`,
+			syncID, p.SessionID, p.Type, title, s.storedContent(content),
+			nullableString(p.ToolName), nullableString(p.Project), scope, nullableString(topicKey), refs, metadata, normHash, nullableString(p.Source),
+		)
+		if err != nil {
+			return err
+		}
+		observationID, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		if err := s.linkObservationFiles(tx, observationID, title, content); err != nil {
+			return err
+		}
+		obs, err = s.getObservationTx(tx, observationID)
+		if err != nil {
+			return err
+		}
+		return s.enqueueSyncMutationTx(tx, SyncEntityObservation, obs.SyncID, SyncOpUpsert, observationPayloadFromObservation(obs))
+	}()
+	if err == nil {
+		err = s.replaceObservationPartsTx(tx, observationID, p, title, scope, parts)
 	}
-
-	// 1. Get the focus observation
-	focus, err := s.GetObservation(observationID)
 	if err != nil {
-		return nil, fmt.Errorf("timeline: observation #%d not found: %w", observationID, err)
+		return 0, err
 	}
+	return observationID, nil
+}
 
-	// 2. Get session info
-	session, err := s.GetSession(focus.SessionID)
-	if err != nil {
-		// Session might be missing for manual-save observations — non-fatal
-		session = nil
-	}
+func (s *Store) RecentObservations(project, scope string, limit int) ([]Observation, error) {
+	return s.RecentObservationsWith(project, scope, limit, ContextOptions{})
+}
 
-	// 3. Get observations BEFORE the focus (same session, older, chronological order)
-	beforeRows, err := s.queryItHook(s.db, `
-		SELECT id, session_id, type, title, content, tool_name, project,
-		       scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at
-		FROM observations
-		WHERE session_id = ? AND id < ? AND deleted_at IS NULL
-		ORDER BY id DESC
-		LIMIT ?
-	`, focus.SessionID, observationID, before)
-	if err != nil {
-		return nil, fmt.Errorf("timeline: before query: %w", err)
+// RecentObservationsWith is RecentObservations with options; only
+// IncludeAll applies.
+func (s *Store) RecentObservationsWith(project, scope string, limit int, opts ContextOptions) ([]Observation, error) {
+	// Normalize project filter for case-insensitive matching
+	project, _ = NormalizeProject(project)
+
+	if limit <= 0 {
+		limit = s.cfg.MaxContextResults
 	}
-	defer beforeRows.Close()
 
-	var beforeEntries []TimelineEntry
-	for beforeRows.Next() {
-		var e TimelineEntry
-		if err := beforeRows.Scan(
-			&e.ID, &e.SessionID, &e.Type, &e.Title, &e.Content,
-			&e.ToolName, &e.Project, &e.Scope, &e.TopicKey, &e.RevisionCount, &e.DuplicateCount, &e.LastSeenAt,
-			&e.CreatedAt, &e.UpdatedAt, &e.DeletedAt,
-		); err != nil {
-			return nil, err
-		}
-		beforeEntries = append(beforeEntries, e)
+	key := scope + "|" + strconv.Itoa(limit)
+	if opts.IncludeAll {
+		key += "|all"
 	}
-	if err := beforeRows.Err(); err != nil {
-		return nil, err
+	return cachedQuery(s.cache, cacheObservations, project, key, func() ([]Observation, error) {
+		return s.recentObservations(project, scope, limit, s.contextExclusion(opts))
+	})
+}
+
+func (s *Store) recentObservations(project, scope string, limit int, rules ContextExclusion) ([]Observation, error) {
+	query := `
+		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at, o.refs, o.verified_at, o.stale_at, o.verification_note, o.source, o.metadata
+		FROM observations o
+		WHERE o.deleted_at IS NULL AND o.quarantine_reason IS NULL
+	`
+	args := []any{}
+
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("o.project", project)
+		query += clause
+		args = append(args, clauseArgs...)
 	}
-	// Reverse to get chronological order (oldest first)
-	for i, j := 0, len(beforeEntries)-1; i < j; i, j = i+1, j-1 {
-		beforeEntries[i], beforeEntries[j] = beforeEntries[j], beforeEntries[i]
+	if scope != "" {
+		query += " AND o.scope = ?"
+		args = append(args, normalizeScope(scope))
 	}
+	clause, ruleArgs := rules.filterSQL()
+	query += clause
+	args = append(args, ruleArgs...)
 
-	// 4. Get observations AFTER the focus (same session, newer, chronological order)
-	afterRows, err := s.queryItHook(s.db, `
-		SELECT id, session_id, type, title, content, tool_name, project,
-		       scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at
-		FROM observations
-		WHERE session_id = ? AND id > ? AND deleted_at IS NULL
-		ORDER BY id ASC
-		LIMIT ?
-	`, focus.SessionID, observationID, after)
-	if err != nil {
-		return nil, fmt.Errorf("timeline: after query: %w", err)
-	}
-	defer afterRows.Close()
+	query += " ORDER BY o.seq DESC, o.id DESC LIMIT ?"
+	args = append(args, limit)
 
-	var afterEntries []TimelineEntry
-	for afterRows.Next() {
-		var e TimelineEntry
-		if err := afterRows.Scan(
-			&e.ID, &e.SessionID, &e.Type, &e.Title, &e.Content,
-			&e.ToolName, &e.Project, &e.Scope, &e.TopicKey, &e.RevisionCount, &e.DuplicateCount, &e.LastSeenAt,
-			&e.CreatedAt, &e.UpdatedAt, &e.DeletedAt,
-		); err != nil {
-			return nil, err
-		}
-		afterEntries = append(afterEntries, e)
-	}
-	if err := afterRows.Err(); err != nil {
-		return nil, err
-	}
+	return s.queryObservations(query, args...)
+}
 
-	// 5. Count total observations in the session for context
-	var totalInRange int
-	s.db.QueryRow(
-		"SELECT COUNT(*) FROM observations WHERE session_id = ? AND deleted_at IS NULL", focus.SessionID,
-	).Scan(&totalInRange)
+// ─── User Prompts ────────────────────────────────────────────────────────────
 
-	return &TimelineResult{
-		Focus:        *focus,
-		Before:       beforeEntries,
-		After:        afterEntries,
-		SessionInfo:  session,
-		TotalInRange: totalInRange,
-	}, nil
+// AddPrompt saves a user prompt and returns its ID. A prompt repeated in
+// the same project within DedupeWindow returns the original's ID instead.
+func (s *Store) AddPrompt(p AddPromptParams) (int64, error) {
+	var promptID int64
+	project, _ := NormalizeProject(p.Project)
+	err := s.withTxInvalidating(func(tx *sql.Tx) error {
+		var err error
+		promptID, err = s.addPromptTx(tx, p)
+		return err
+	}, project, cachePrompts)
+	if err != nil {
+		return 0, err
+	}
+	return promptID, nil
 }
 
-// ─── Search (FTS5) ───────────────────────────────────────────────────────────
+func (s *Store) addPromptTx(tx *sql.Tx, p AddPromptParams) (int64, error) {
+	// Normalize project name before storing
+	p.Project, _ = NormalizeProject(p.Project)
 
-func (s *Store) Search(query string, opts SearchOptions) ([]SearchResult, error) {
-	// Normalize project filter so "Engram" finds records stored as "engram"
-	opts.Project, _ = NormalizeProject(opts.Project)
+	content := stripPrivateTags(p.Content)
+	if len(content) > s.cfg.MaxObservationLength {
+		content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
+	}
 
-	limit := opts.Limit
-	if limit <= 0 {
-		limit = 10
+	// Agents re-send prompts when they recover from a compaction; a repeat
+	// inside the dedupe window bumps the original instead of adding a row.
+	normHash := hashNormalized(content)
+	var existingID int64
+	err := tx.QueryRow(
+		`SELECT id FROM user_prompts
+		 WHERE normalized_hash = ?
+		   AND ifnull(project, '') = ifnull(?, '')
+		   AND datetime(created_at) >= datetime('now', ?)
+		 ORDER BY seq DESC, id DESC
+		 LIMIT 1`,
+		normHash, nullableString(p.Project), dedupeWindowExpression(s.limits().DedupeWindow),
+	).Scan(&existingID)
+	if err == nil {
+		_, err = s.execHook(tx,
+			`UPDATE user_prompts
+			 SET duplicate_count = duplicate_count + 1,
+			     last_seen_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+			 WHERE id = ?`,
+			existingID,
+		)
+		return existingID, err
 	}
-	if limit > s.cfg.MaxSearchResults {
-		limit = s.cfg.MaxSearchResults
+	if err != sql.ErrNoRows {
+		return 0, err
 	}
 
-	var directResults []SearchResult
-	if strings.Contains(query, "/") {
-		tkSQL := `
-			SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
-			       scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at
-			FROM observations
-			WHERE topic_key = ? AND deleted_at IS NULL
-		`
-		tkArgs := []any{query}
-
-		if opts.Type != "" {
-			tkSQL += " AND type = ?"
-			tkArgs = append(tkArgs, opts.Type)
-		}
-		if opts.Project != "" {
-			tkSQL += " AND project = ?"
-			tkArgs = append(tkArgs, opts.Project)
-		}
-		if opts.Scope != "" {
-			tkSQL += " AND scope = ?"
-			tkArgs = append(tkArgs, normalizeScope(opts.Scope))
-		}
-
-		tkSQL += " ORDER BY updated_at DESC LIMIT ?"
-		tkArgs = append(tkArgs, limit)
-
-		tkRows, err := s.queryItHook(s.db, tkSQL, tkArgs...)
-		if err == nil {
-			defer tkRows.Close()
-			for tkRows.Next() {
-				var sr SearchResult
-				if err := tkRows.Scan(
-					&sr.ID, &sr.SyncID, &sr.SessionID, &sr.Type, &sr.Title, &sr.Content,
-					&sr.ToolName, &sr.Project, &sr.Scope, &sr.TopicKey, &sr.RevisionCount, &sr.DuplicateCount,
-					&sr.LastSeenAt, &sr.CreatedAt, &sr.UpdatedAt, &sr.DeletedAt,
-				); err != nil {
-					break
-				}
-				sr.Rank = -1000
-				directResults = append(directResults, sr)
-			}
-		}
+	syncID := newSyncID("prompt")
+	res, err := s.execHook(tx,
+		`INSERT INTO user_prompts (sync_id, session_id, content, project, normalized_hash, created_at, seq) VALUES (?, ?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), `+nextPromptSeq+`)`,
+		syncID, p.SessionID, content, nullableString(p.Project), normHash,
+	)
+	if err != nil {
+		return 0, err
 	}
+	promptID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return promptID, s.enqueueSyncMutationTx(tx, SyncEntityPrompt, syncID, SyncOpUpsert, syncPromptPayload{
+		SyncID:    syncID,
+		SessionID: p.SessionID,
+		Content:   content,
+		Project:   nullableString(p.Project),
+	})
+}
 
-	// Sanitize query for FTS5 — wrap each term in quotes to avoid syntax errors
-	ftsQuery := sanitizeFTS(query)
-
-	sqlQ := `
-		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
-		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at,
-		       fts.rank
-		FROM observations_fts fts
-		JOIN observations o ON o.id = fts.rowid
-		WHERE observations_fts MATCH ? AND o.deleted_at IS NULL
-	`
-	args := []any{ftsQuery}
+func (s *Store) RecentPrompts(project string, limit int) ([]Prompt, error) {
+	// Normalize project filter for case-insensitive matching
+	project, _ = NormalizeProject(project)
 
-	if opts.Type != "" {
-		sqlQ += " AND o.type = ?"
-		args = append(args, opts.Type)
+	if limit <= 0 {
+		limit = 20
 	}
 
-	if opts.Project != "" {
-		sqlQ += " AND o.project = ?"
-		args = append(args, opts.Project)
-	}
+	return cachedQuery(s.cache, cachePrompts, project, strconv.Itoa(limit), func() ([]Prompt, error) {
+		return s.recentPrompts(project, limit)
+	})
+}
 
-	if opts.Scope != "" {
-		sqlQ += " AND o.scope = ?"
-		args = append(args, normalizeScope(opts.Scope))
+func (s *Store) recentPrompts(project string, limit int) ([]Prompt, error) {
+	// A prompt repeated outside the dedupe window, or brought in by an
+	// import, is listed once: as its newest copy.
+	query := `SELECT p.id, ifnull(p.sync_id, '') as sync_id, p.session_id, p.content, ifnull(p.project, '') as project, p.created_at, p.duplicate_count
+		FROM user_prompts p
+		WHERE NOT EXISTS (
+			SELECT 1 FROM user_prompts d
+			WHERE d.normalized_hash = p.normalized_hash
+			  AND ifnull(d.project, '') = ifnull(p.project, '')
+			  AND (ifnull(d.seq, 0), d.id) > (ifnull(p.seq, 0), p.id)
+		)`
+	args := []any{}
+
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("p.project", project)
+		query += clause
+		args = append(args, clauseArgs...)
 	}
 
-	sqlQ += " ORDER BY fts.rank LIMIT ?"
+	query += " ORDER BY p.seq DESC, p.id DESC LIMIT ?"
 	args = append(args, limit)
 
-	rows, err := s.queryItHook(s.db, sqlQ, args...)
+	rows, err := s.queryItHook(s.db, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("search: %w", err)
+		return nil, err
 	}
 	defer rows.Close()
 
-	seen := make(map[int64]bool)
-	for _, dr := range directResults {
-		seen[dr.ID] = true
-	}
-
-	var results []SearchResult
-	results = append(results, directResults...)
+	var results []Prompt
 	for rows.Next() {
-		var sr SearchResult
-		if err := rows.Scan(
-			&sr.ID, &sr.SyncID, &sr.SessionID, &sr.Type, &sr.Title, &sr.Content,
-			&sr.ToolName, &sr.Project, &sr.Scope, &sr.TopicKey, &sr.RevisionCount, &sr.DuplicateCount,
-			&sr.LastSeenAt, &sr.CreatedAt, &sr.UpdatedAt, &sr.DeletedAt,
-			&sr.Rank,
-		); err != nil {
+		var p Prompt
+		if err := rows.Scan(&p.ID, &p.SyncID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt, &p.DuplicateCount); err != nil {
 			return nil, err
 		}
-		if !seen[sr.ID] {
-			results = append(results, sr)
-		}
+		results = append(results, p)
 	}
-	if err := rows.Err(); err != nil {
+	return results, rows.Err()
+}
+
+func (s *Store) SearchPrompts(query string, project string, limit int) ([]Prompt, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	ranked, err := s.searchPrompts(query, SearchOptions{Project: project}, limit)
+	if err != nil {
 		return nil, err
 	}
-
-	if len(results) > limit {
-		results = results[:limit]
+	results := make([]Prompt, 0, len(ranked))
+	for _, r := range ranked {
+		results = append(results, *r.Prompt)
 	}
 	return results, nil
 }
 
-// ─── Stats ───────────────────────────────────────────────────────────────────
+// searchPrompts ranks prompts matching query, filtered by opts.Project and
+// the session and time window options.
+func (s *Store) searchPrompts(query string, opts SearchOptions, limit int) ([]SearchAllResult, error) {
+	sql := `
+		SELECT p.id, ifnull(p.sync_id, '') as sync_id, p.session_id, p.content, ifnull(p.project, '') as project, p.created_at, p.duplicate_count, fts.rank
+		FROM prompts_fts fts
+		JOIN user_prompts p ON p.id = fts.rowid
+		WHERE prompts_fts MATCH ?
+	`
+	args := []any{query}
 
-func (s *Store) Stats() (*Stats, error) {
-	stats := &Stats{}
+	if opts.Project != "" {
+		clause, clauseArgs := projectFilterSQL("p.project", opts.Project)
+		sql += clause
+		args = append(args, clauseArgs...)
+	}
+	if clause, windowArgs := windowFilterSQL("p.", opts); clause != "" {
+		sql += clause
+		args = append(args, windowArgs...)
+	}
 
-	s.db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&stats.TotalSessions)
-	s.db.QueryRow("SELECT COUNT(*) FROM observations WHERE deleted_at IS NULL").Scan(&stats.TotalObservations)
-	s.db.QueryRow("SELECT COUNT(*) FROM user_prompts").Scan(&stats.TotalPrompts)
+	sql += " ORDER BY fts.rank LIMIT ?"
+	args = append(args, limit)
 
-	rows, err := s.queryItHook(s.db, "SELECT project FROM observations WHERE project IS NOT NULL AND deleted_at IS NULL GROUP BY project ORDER BY MAX(created_at) DESC")
+	rows, err := s.queryFTS(query, sql, args)
 	if err != nil {
-		return stats, nil
+		return nil, fmt.Errorf("search prompts: %w", err)
 	}
 	defer rows.Close()
 
+	var results []SearchAllResult
 	for rows.Next() {
-		var p string
-		if err := rows.Scan(&p); err == nil {
-			stats.Projects = append(stats.Projects, p)
+		var p Prompt
+		var rank float64
+		if err := rows.Scan(&p.ID, &p.SyncID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt, &p.DuplicateCount, &rank); err != nil {
+			return nil, err
 		}
+		results = append(results, SearchAllResult{Kind: "prompt", Prompt: &p, Rank: rank})
 	}
-
-	return stats, nil
+	return results, rows.Err()
 }
 
-// ─── Context Formatting ─────────────────────────────────────────────────────
-
-func (s *Store) FormatContext(project, scope string) (string, error) {
-	sessions, err := s.RecentSessions(project, 5)
-	if err != nil {
-		return "", err
-	}
-
-	observations, err := s.RecentObservations(project, scope, s.cfg.MaxContextResults)
-	if err != nil {
-		return "", err
+// ─── Templates ───────────────────────────────────────────────────────────────
+//
+// Templates give recurring write-ups (ADRs, incident reports) a fixed set of
+// "## Section" headings, an observation type, and a topic family, so every
+// ADR reads alike and upserts under architecture/<title>.
+
+// Template is a named content skeleton for an observation.
+type Template struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type"`
+	// TopicFamily prefixes the topic key: "<family>/<normalized title>".
+	// Empty leaves the topic key unset.
+	TopicFamily string   `json:"topic_family,omitempty"`
+	Sections    []string `json:"sections"`
+}
+
+// DefaultTemplates are the templates every store knows.
+var DefaultTemplates = []Template{
+	{
+		Name:        "adr",
+		Description: "Architecture decision record",
+		Type:        "architecture",
+		TopicFamily: "architecture",
+		Sections:    []string{"Status", "Context", "Decision", "Consequences"},
+	},
+	{
+		Name:        "incident",
+		Description: "Incident report with root cause and follow-ups",
+		Type:        "bugfix",
+		TopicFamily: "bug",
+		Sections:    []string{"Summary", "Impact", "Timeline", "Root Cause", "Resolution", "Follow-ups"},
+	},
+}
+
+// Templates returns DefaultTemplates merged with Config.Templates, sorted
+// by name.
+func (s *Store) Templates() []Template {
+	byName := make(map[string]Template, len(DefaultTemplates)+len(s.cfg.Templates))
+	for _, t := range DefaultTemplates {
+		byName[t.Name] = t
+	}
+	for name, t := range s.cfg.Templates {
+		t.Name = name
+		byName[name] = t
+	}
+	templates := make([]Template, 0, len(byName))
+	for _, t := range byName {
+		templates = append(templates, t)
+	}
+	slices.SortFunc(templates, func(a, b Template) int { return cmp.Compare(a.Name, b.Name) })
+	return templates
+}
+
+// Template returns the template called name. An unknown name is a
+// validation error listing the known ones.
+func (s *Store) Template(name string) (Template, error) {
+	templates := s.Templates()
+	names := make([]string, 0, len(templates))
+	for _, t := range templates {
+		if t.Name == strings.ToLower(strings.TrimSpace(name)) {
+			return t, nil
+		}
+		names = append(names, t.Name)
 	}
+	return Template{}, invalidf("unknown template %q (want one of %s)", name, strings.Join(names, ", "))
+}
 
-	prompts, err := s.RecentPrompts(project, 10)
-	if err != nil {
-		return "", err
+// TopicKey returns the topic key for an observation titled title, or ""
+// when the template has no topic family.
+func (t Template) TopicKey(title string) string {
+	if t.TopicFamily == "" {
+		return ""
 	}
-
-	if len(sessions) == 0 && len(observations) == 0 && len(prompts) == 0 {
-		return "", nil
+	segment := normalizeTopicSegment(stripPrivateTags(title))
+	if segment == "" {
+		segment = "general"
 	}
-
-	var b strings.Builder
-	b.WriteString("## Memory from Previous Sessions\n\n")
-
-	if len(sessions) > 0 {
-		b.WriteString("### Recent Sessions\n")
-		for _, sess := range sessions {
-			summary := ""
-			if sess.Summary != nil {
-				summary = fmt.Sprintf(": %s", truncate(*sess.Summary, 200))
+	return t.TopicFamily + "/" + segment
+}
+
+// Render lays content out under the template's sections. Text under a
+// "## Heading" matching a section (case-insensitively) fills that section;
+// text before the first heading fills the first section. Sections left
+// empty keep their heading, and headings the template does not list are
+// appended after its sections.
+func (t Template) Render(content string) string {
+	bodies := map[string]string{}
+	var extra []string
+	current, lines := "", []string{}
+	flush := func() {
+		body := strings.TrimSpace(strings.Join(lines, "\n"))
+		key := strings.ToLower(current)
+		switch {
+		case current == "" && len(t.Sections) > 0:
+			key = strings.ToLower(t.Sections[0])
+		case !slices.ContainsFunc(t.Sections, func(s string) bool { return strings.EqualFold(s, current) }):
+			if current != "" {
+				extra = append(extra, strings.TrimSpace("## "+current+"\n"+body))
 			}
-			fmt.Fprintf(&b, "- **%s** (%s)%s [%d observations]\n",
-				sess.Project, sess.StartedAt, summary, sess.ObservationCount)
+			return
+		}
+		if body != "" {
+			bodies[key] = strings.TrimSpace(bodies[key] + "\n\n" + body)
 		}
-		b.WriteString("\n")
 	}
-
-	if len(prompts) > 0 {
-		b.WriteString("### Recent User Prompts\n")
-		for _, p := range prompts {
-			fmt.Fprintf(&b, "- %s: %s\n", p.CreatedAt, truncate(p.Content, 200))
+	for line := range strings.SplitSeq(content, "\n") {
+		if heading, ok := strings.CutPrefix(strings.TrimSpace(line), "## "); ok {
+			flush()
+			current, lines = strings.TrimSpace(heading), nil
+			continue
 		}
-		b.WriteString("\n")
+		lines = append(lines, line)
 	}
+	flush()
 
-	if len(observations) > 0 {
-		b.WriteString("### Recent Observations\n")
-		for _, obs := range observations {
-			fmt.Fprintf(&b, "- [%s] **%s**: %s\n",
-				obs.Type, obs.Title, truncate(obs.Content, 300))
+	parts := make([]string, 0, len(t.Sections)+len(extra))
+	for _, section := range t.Sections {
+		part := "## " + section
+		if body := bodies[strings.ToLower(section)]; body != "" {
+			part += "\n" + body
 		}
-		b.WriteString("\n")
+		parts = append(parts, part)
 	}
-
-	return b.String(), nil
+	parts = append(parts, extra...)
+	return strings.Join(parts, "\n\n")
 }
 
-// ─── Export / Import ─────────────────────────────────────────────────────────
+// ─── Working Memory ──────────────────────────────────────────────────────────
+//
+// Working memory is a per-session scratchpad (task state, todo lists) kept
+// out of search, context, export, and sync. Items expire after a TTL; items
+// marked durable never expire and become observations when the session ends.
 
-func (s *Store) Export() (*ExportData, error) {
-	data := &ExportData{
-		Version:    "0.1.0",
-		ExportedAt: Now(),
+// DefaultScratchTTL is how long a working memory item lives by default.
+const DefaultScratchTTL = 24 * time.Hour
+
+// ScratchItem is one working memory entry.
+type ScratchItem struct {
+	SessionID string `json:"session_id"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	// Durable items are promoted into observations at session end.
+	Durable bool `json:"durable"`
+	// Type is the observation type used on promotion; empty infers one.
+	Type      string  `json:"type,omitempty"`
+	ExpiresAt *string `json:"expires_at,omitempty"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+// SetScratchParams describes a working memory write.
+type SetScratchParams struct {
+	SessionID string
+	Key       string
+	Value     string
+	Durable   bool
+	Type      string
+	// TTL overrides Config.ScratchTTL for this item. Ignored for durable
+	// items, which live until the session ends.
+	TTL time.Duration
+}
+
+// SetScratch creates or replaces a working memory item.
+func (s *Store) SetScratch(p SetScratchParams) (*ScratchItem, error) {
+	key := strings.TrimSpace(p.Key)
+	if p.SessionID == "" || key == "" {
+		return nil, invalidf("working memory: session id and key are required")
+	}
+	value := stripPrivateTags(p.Value)
+	if len(value) > s.cfg.MaxObservationLength {
+		value = value[:s.cfg.MaxObservationLength] + "... [truncated]"
+	}
+
+	var expiresAt *string
+	if !p.Durable {
+		ttl := p.TTL
+		if ttl <= 0 {
+			ttl = s.limits().ScratchTTL
+		}
+		if ttl <= 0 {
+			ttl = DefaultScratchTTL
+		}
+		expiry := time.Now().UTC().Add(ttl).Format(TimestampLayout)
+		expiresAt = &expiry
 	}
 
-	// Sessions
-	rows, err := s.queryItHook(s.db,
-		"SELECT id, project, directory, started_at, ended_at, summary FROM sessions ORDER BY started_at",
-	)
+	if err := s.purgeExpiredScratch(); err != nil {
+		return nil, err
+	}
+	if _, err := s.execHook(s.db,
+		`INSERT INTO working_memory (session_id, key, value, durable, type, expires_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		 ON CONFLICT(session_id, key) DO UPDATE SET value = excluded.value, durable = excluded.durable,
+		     type = excluded.type, expires_at = excluded.expires_at, updated_at = excluded.updated_at`,
+		p.SessionID, key, value, p.Durable, nullableString(strings.TrimSpace(p.Type)), expiresAt,
+	); err != nil {
+		return nil, err
+	}
+	items, err := s.Scratch(p.SessionID, key)
 	if err != nil {
-		return nil, fmt.Errorf("export sessions: %w", err)
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("working memory: %q vanished after write", key)
+	}
+	return &items[0], nil
+}
+
+// Scratch returns the live working memory items of a session ordered by
+// key, or only the one named key when key is not empty.
+func (s *Store) Scratch(sessionID, key string) ([]ScratchItem, error) {
+	query := `SELECT session_id, key, value, durable, ifnull(type, ''), expires_at, updated_at
+		 FROM working_memory
+		 WHERE session_id = ?
+		   AND (expires_at IS NULL OR expires_at > strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))`
+	args := []any{sessionID}
+	if key = strings.TrimSpace(key); key != "" {
+		query += " AND key = ?"
+		args = append(args, key)
+	}
+	rows, err := s.queryItHook(s.db, query+" ORDER BY key", args...)
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
+	var items []ScratchItem
 	for rows.Next() {
-		var sess Session
-		if err := rows.Scan(&sess.ID, &sess.Project, &sess.Directory, &sess.StartedAt, &sess.EndedAt, &sess.Summary); err != nil {
+		var item ScratchItem
+		if err := rows.Scan(&item.SessionID, &item.Key, &item.Value, &item.Durable, &item.Type, &item.ExpiresAt, &item.UpdatedAt); err != nil {
 			return nil, err
 		}
-		data.Sessions = append(data.Sessions, sess)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		items = append(items, item)
 	}
+	return items, rows.Err()
+}
 
-	// Observations
-	obsRows, err := s.queryItHook(s.db,
-		`SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
-		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at
-		 FROM observations ORDER BY id`,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("export observations: %w", err)
-	}
-	defer obsRows.Close()
-	for obsRows.Next() {
-		var o Observation
-		if err := obsRows.Scan(
-			&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, &o.Content,
-			&o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt,
-			&o.CreatedAt, &o.UpdatedAt, &o.DeletedAt,
-		); err != nil {
-			return nil, err
-		}
-		data.Observations = append(data.Observations, o)
-	}
-	if err := obsRows.Err(); err != nil {
-		return nil, err
+// ClearScratch deletes one working memory item, or every item of the
+// session when key is empty, and returns how many were removed.
+func (s *Store) ClearScratch(sessionID, key string) (int64, error) {
+	query := `DELETE FROM working_memory WHERE session_id = ?`
+	args := []any{sessionID}
+	if key = strings.TrimSpace(key); key != "" {
+		query += " AND key = ?"
+		args = append(args, key)
 	}
-
-	// Prompts
-	promptRows, err := s.queryItHook(s.db,
-		"SELECT id, ifnull(sync_id, '') as sync_id, session_id, content, ifnull(project, '') as project, created_at FROM user_prompts ORDER BY id",
-	)
+	res, err := s.execHook(s.db, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("export prompts: %w", err)
-	}
-	defer promptRows.Close()
-	for promptRows.Next() {
-		var p Prompt
-		if err := promptRows.Scan(&p.ID, &p.SyncID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt); err != nil {
-			return nil, err
-		}
-		data.Prompts = append(data.Prompts, p)
-	}
-	if err := promptRows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-
-	return data, nil
+	return res.RowsAffected()
 }
 
-func (s *Store) Import(data *ExportData) (*ImportResult, error) {
-	tx, err := s.beginTxHook()
+// PromoteScratch saves the durable working memory items of a session as
+// observations in the session's project, titled by key, then clears the
+// session's working memory. It returns the IDs of the saved observations.
+// Items stay in place when a save fails, so promotion can be retried.
+func (s *Store) PromoteScratch(sessionID string) ([]int64, error) {
+	items, err := s.Scratch(sessionID, "")
 	if err != nil {
-		return nil, fmt.Errorf("import: begin tx: %w", err)
+		return nil, err
 	}
-	defer tx.Rollback()
-
-	result := &ImportResult{}
-
-	// Import sessions (skip duplicates)
-	for _, sess := range data.Sessions {
-		res, err := s.execHook(tx,
-			`INSERT OR IGNORE INTO sessions (id, project, directory, started_at, ended_at, summary)
-			 VALUES (?, ?, ?, ?, ?, ?)`,
-			sess.ID, sess.Project, sess.Directory, sess.StartedAt, sess.EndedAt, sess.Summary,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("import session %s: %w", sess.ID, err)
+	sess, err := s.GetSession(sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
 		}
-		n, _ := res.RowsAffected()
-		result.SessionsImported += int(n)
+		return nil, err
 	}
 
-	// Import observations (use new IDs — AUTOINCREMENT)
-	for _, obs := range data.Observations {
-		_, err := s.execHook(tx,
-			`INSERT INTO observations (sync_id, session_id, type, title, content, tool_name, project, scope, topic_key, normalized_hash, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			normalizeExistingSyncID(obs.SyncID, "obs"),
-			obs.SessionID,
-			obs.Type,
-			obs.Title,
-			obs.Content,
-			obs.ToolName,
-			obs.Project,
-			normalizeScope(obs.Scope),
-			nullableString(normalizeTopicKey(derefString(obs.TopicKey))),
-			hashNormalized(obs.Content),
-			maxInt(obs.RevisionCount, 1),
-			maxInt(obs.DuplicateCount, 1),
-			obs.LastSeenAt,
-			obs.CreatedAt,
-			obs.UpdatedAt,
-			obs.DeletedAt,
-		)
+	var ids []int64
+	for _, item := range items {
+		if !item.Durable {
+			continue
+		}
+		typ := item.Type
+		if typ == "" {
+			typ = InferObservationType(item.Key, item.Value)
+		}
+		id, err := s.AddObservation(AddObservationParams{
+			SessionID: sessionID,
+			Type:      typ,
+			Title:     item.Key,
+			Content:   item.Value,
+			Project:   sess.Project,
+			ToolName:  "working_memory",
+			Source:    SourceScratch,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+			return ids, fmt.Errorf("promote %q: %w", item.Key, err)
 		}
-		result.ObservationsImported++
+		if _, err := s.ClearScratch(sessionID, item.Key); err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
 	}
+	_, err = s.ClearScratch(sessionID, "")
+	return ids, err
+}
 
-	// Import prompts
-	for _, p := range data.Prompts {
-		_, err := s.execHook(tx,
-			`INSERT INTO user_prompts (sync_id, session_id, content, project, created_at)
-			 VALUES (?, ?, ?, ?, ?)`,
-			normalizeExistingSyncID(p.SyncID, "prompt"), p.SessionID, p.Content, p.Project, p.CreatedAt,
-		)
+func (s *Store) purgeExpiredScratch() error {
+	_, err := s.execHook(s.db,
+		`DELETE FROM working_memory WHERE expires_at IS NOT NULL AND expires_at <= strftime('%Y-%m-%dT%H:%M:%SZ', 'now')`)
+	return err
+}
+
+// ─── Tool Runs ───────────────────────────────────────────────────────────────
+//
+// Tool runs are structured records of what a tool did — exit code, duration,
+// files touched — reported by plugins alongside (or instead of) a free-text
+// observation. They stay out of search and context; timelines and session
+// transcripts show them as one-line summaries.
+
+// FileDelta is one file a tool run changed.
+type FileDelta struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added,omitempty"`
+	Removed int    `json:"removed,omitempty"`
+}
+
+// ToolRun is one recorded tool invocation.
+type ToolRun struct {
+	ID         int64       `json:"id"`
+	SessionID  string      `json:"session_id"`
+	ToolName   string      `json:"tool_name"`
+	Command    string      `json:"command,omitempty"`
+	ExitCode   *int        `json:"exit_code,omitempty"`
+	DurationMs int64       `json:"duration_ms,omitempty"`
+	Files      []FileDelta `json:"files,omitempty"`
+	Summary    string      `json:"summary,omitempty"`
+	Project    string      `json:"project,omitempty"`
+	CreatedAt  string      `json:"created_at"`
+}
+
+// AddToolRunParams describes a tool run to record.
+type AddToolRunParams struct {
+	SessionID  string      `json:"session_id"`
+	ToolName   string      `json:"tool_name"`
+	Command    string      `json:"command,omitempty"`
+	ExitCode   *int        `json:"exit_code,omitempty"`
+	DurationMs int64       `json:"duration_ms,omitempty"`
+	Files      []FileDelta `json:"files,omitempty"`
+	Summary    string      `json:"summary,omitempty"`
+	Project    string      `json:"project,omitempty"`
+}
+
+// maxToolRunCommand caps the stored command line.
+const maxToolRunCommand = 500
+
+// AddToolRun records a tool run. The command and summary are stripped of
+// <private> content like observations are.
+func (s *Store) AddToolRun(p AddToolRunParams) (int64, error) {
+	toolName := strings.TrimSpace(p.ToolName)
+	if p.SessionID == "" || toolName == "" {
+		return 0, invalidf("tool run: session id and tool name are required")
+	}
+	if p.DurationMs < 0 {
+		return 0, invalidf("tool run: duration must not be negative")
+	}
+	project, _ := NormalizeProject(p.Project)
+	command := truncate(stripPrivateTags(p.Command), maxToolRunCommand)
+	summary := stripPrivateTags(p.Summary)
+	if len(summary) > s.cfg.MaxObservationLength {
+		summary = summary[:s.cfg.MaxObservationLength] + "... [truncated]"
+	}
+
+	var files any
+	if len(p.Files) > 0 {
+		encoded, err := json.Marshal(p.Files)
 		if err != nil {
-			return nil, fmt.Errorf("import prompt %d: %w", p.ID, err)
+			return 0, fmt.Errorf("tool run: encode files: %w", err)
 		}
-		result.PromptsImported++
+		files = string(encoded)
 	}
 
-	if err := s.commitHook(tx); err != nil {
-		return nil, fmt.Errorf("import: commit: %w", err)
+	res, err := s.execHook(s.db,
+		`INSERT INTO tool_runs (session_id, tool_name, command, exit_code, duration_ms, files, summary, project)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.SessionID, toolName, nullableString(command), p.ExitCode, p.DurationMs, files,
+		nullableString(summary), nullableString(project),
+	)
+	if err != nil {
+		return 0, err
 	}
-
-	return result, nil
+	return res.LastInsertId()
 }
 
-type ImportResult struct {
-	SessionsImported     int `json:"sessions_imported"`
-	ObservationsImported int `json:"observations_imported"`
-	PromptsImported      int `json:"prompts_imported"`
+// SessionToolRuns returns a session's tool runs, oldest first. A limit of 0
+// returns all of them.
+func (s *Store) SessionToolRuns(sessionID string, limit int) ([]ToolRun, error) {
+	return s.queryToolRuns(`WHERE session_id = ?`, limit, sessionID)
 }
 
-// ─── Sync Chunk Tracking ─────────────────────────────────────────────────────
+// toolRunsBetween returns a session's tool runs created in [from, to]; an
+// empty bound leaves that side open.
+func (s *Store) toolRunsBetween(sessionID, from, to string) ([]ToolRun, error) {
+	where, args := `WHERE session_id = ?`, []any{sessionID}
+	if from != "" {
+		where += ` AND created_at >= ?`
+		args = append(args, from)
+	}
+	if to != "" {
+		where += ` AND created_at <= ?`
+		args = append(args, to)
+	}
+	return s.queryToolRuns(where, 0, args...)
+}
 
-// GetSyncedChunks returns a set of chunk IDs that have been imported/exported.
-func (s *Store) GetSyncedChunks() (map[string]bool, error) {
-	rows, err := s.queryItHook(s.db, "SELECT chunk_id FROM sync_chunks")
+func (s *Store) queryToolRuns(where string, limit int, args ...any) ([]ToolRun, error) {
+	query := `SELECT id, session_id, tool_name, ifnull(command, ''), exit_code, duration_ms, ifnull(files, ''),
+		       ifnull(summary, ''), ifnull(project, ''), created_at
+		FROM tool_runs ` + where + ` ORDER BY created_at ASC, id ASC`
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := s.queryItHook(s.db, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("get synced chunks: %w", err)
+		return nil, err
 	}
 	defer rows.Close()
 
-	chunks := make(map[string]bool)
+	var runs []ToolRun
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
+		var r ToolRun
+		var exitCode sql.NullInt64
+		var files string
+		if err := rows.Scan(&r.ID, &r.SessionID, &r.ToolName, &r.Command, &exitCode, &r.DurationMs, &files,
+			&r.Summary, &r.Project, &r.CreatedAt); err != nil {
 			return nil, err
 		}
-		chunks[id] = true
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			r.ExitCode = &code
+		}
+		if files != "" {
+			if err := json.Unmarshal([]byte(files), &r.Files); err != nil {
+				return nil, fmt.Errorf("tool run %d: decode files: %w", r.ID, err)
+			}
+		}
+		runs = append(runs, r)
 	}
-	return chunks, rows.Err()
+	return runs, rows.Err()
 }
 
-// RecordSyncedChunk marks a chunk as imported/exported so it won't be processed again.
-func (s *Store) RecordSyncedChunk(chunkID string) error {
-	_, err := s.execHook(s.db,
-		"INSERT OR IGNORE INTO sync_chunks (chunk_id) VALUES (?)",
-		chunkID,
-	)
-	return err
+// Headline summarizes a run in one line, e.g.
+// "bash `go test ./...` → exit 1 in 3.2s · 2 files (+10/-3)".
+func (r ToolRun) Headline() string {
+	var b strings.Builder
+	b.WriteString(r.ToolName)
+	if r.Command != "" {
+		fmt.Fprintf(&b, " `%s`", truncate(r.Command, 80))
+	}
+	var outcome []string
+	if r.ExitCode != nil {
+		outcome = append(outcome, fmt.Sprintf("exit %d", *r.ExitCode))
+	}
+	if r.DurationMs > 0 {
+		outcome = append(outcome, "in "+(time.Duration(r.DurationMs)*time.Millisecond).Round(100*time.Millisecond).String())
+	}
+	if len(outcome) > 0 {
+		b.WriteString(" → " + strings.Join(outcome, " "))
+	}
+	if len(r.Files) > 0 {
+		added, removed := 0, 0
+		for _, f := range r.Files {
+			added += f.Added
+			removed += f.Removed
+		}
+		noun := "files"
+		if len(r.Files) == 1 {
+			noun = "file"
+		}
+		fmt.Fprintf(&b, " · %d %s (+%d/-%d)", len(r.Files), noun, added, removed)
+	}
+	return b.String()
 }
 
-// ─── Local Sync State & Mutation Journal ─────────────────────────────────────
-
-func (s *Store) GetSyncState(targetKey string) (*SyncState, error) {
-	targetKey = normalizeSyncTargetKey(targetKey)
-	if err := s.ensureSyncState(targetKey); err != nil {
+// ─── Compaction Events ───────────────────────────────────────────────────────
+//
+// Plugins see context compaction happen; engram otherwise only learns of it
+// when the agent follows the recovery protocol. RecordCompaction saves a
+// "compaction" marker observation and a pending event. The next context
+// built with ContextOptions.ResurfaceCompaction for that project opens with
+// the latest session summary and clears the pending events.
+
+// CompactionParams describes a compaction reported by a plugin or agent.
+type CompactionParams struct {
+	SessionID string `json:"session_id"`
+	Project   string `json:"project"`
+	// Trigger is what caused it as the client reports it, e.g. "auto" or
+	// "manual".
+	Trigger string `json:"trigger,omitempty"`
+	Note    string `json:"note,omitempty"`
+	// Source is the entry path of the marker observation (see SourceCLI).
+	Source string `json:"-"`
+}
+
+// CompactionEvent is a recorded compaction.
+type CompactionEvent struct {
+	ID            int64  `json:"id"`
+	SessionID     string `json:"session_id"`
+	Project       string `json:"project"`
+	ObservationID int64  `json:"observation_id"`
+}
+
+// RecordCompaction saves a compaction marker in the session, creating the
+// session when it does not exist yet.
+func (s *Store) RecordCompaction(p CompactionParams) (*CompactionEvent, error) {
+	project, _ := NormalizeProject(p.Project)
+	if strings.TrimSpace(p.SessionID) == "" || project == "" {
+		return nil, invalidf("compaction: session_id and project are required")
+	}
+	if err := s.CreateSession(p.SessionID, project, ""); err != nil {
 		return nil, err
 	}
-	return s.getSyncState(targetKey)
-}
 
-func (s *Store) ListPendingSyncMutations(targetKey string, limit int) ([]SyncMutation, error) {
-	targetKey = normalizeSyncTargetKey(targetKey)
-	if limit <= 0 {
-		limit = 100
+	content := "Context was compacted"
+	if trigger := strings.TrimSpace(p.Trigger); trigger != "" {
+		content += " (" + trigger + ")"
 	}
-	// Only return mutations for enrolled projects or empty-project (global) mutations.
-	// Empty-project mutations always sync regardless of enrollment.
-	rows, err := s.queryItHook(s.db, `
-		SELECT sm.seq, sm.target_key, sm.entity, sm.entity_key, sm.op, sm.payload, sm.source, sm.project, sm.occurred_at, sm.acked_at
-		FROM sync_mutations sm
-		LEFT JOIN sync_enrolled_projects sep ON sm.project = sep.project
-		WHERE sm.target_key = ? AND sm.acked_at IS NULL
-		  AND (sm.project = '' OR sep.project IS NOT NULL)
-		ORDER BY sm.seq ASC
-		LIMIT ?`, targetKey, limit)
+	content += "."
+	if note := strings.TrimSpace(p.Note); note != "" {
+		content += "\n\n" + note
+	}
+	obsID, err := s.AddObservation(AddObservationParams{
+		SessionID: p.SessionID,
+		Type:      "compaction",
+		Title:     "Context compacted: " + project,
+		Content:   content,
+		Project:   project,
+		Source:    p.Source,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var mutations []SyncMutation
-	for rows.Next() {
-		var mutation SyncMutation
-		if err := rows.Scan(&mutation.Seq, &mutation.TargetKey, &mutation.Entity, &mutation.EntityKey, &mutation.Op, &mutation.Payload, &mutation.Source, &mutation.Project, &mutation.OccurredAt, &mutation.AckedAt); err != nil {
-			return nil, err
-		}
-		mutations = append(mutations, mutation)
-	}
-	return mutations, rows.Err()
-}
 
-// SkipAckNonEnrolledMutations acks (marks as skipped) all pending mutations
-// that belong to non-enrolled projects, preventing journal bloat. Empty-project
-// mutations are never skipped — they always sync regardless of enrollment.
-func (s *Store) SkipAckNonEnrolledMutations(targetKey string) (int64, error) {
-	targetKey = normalizeSyncTargetKey(targetKey)
-	res, err := s.execHook(s.db, `
-		UPDATE sync_mutations
-		SET acked_at = datetime('now')
-		WHERE target_key = ?
-		  AND acked_at IS NULL
-		  AND project != ''
-		  AND project NOT IN (SELECT project FROM sync_enrolled_projects)`,
-		targetKey,
+	res, err := s.execHook(s.db,
+		`INSERT INTO compaction_events (session_id, project, observation_id) VALUES (?, ?, ?)`,
+		p.SessionID, project, obsID,
 	)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	return res.RowsAffected()
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &CompactionEvent{ID: id, SessionID: p.SessionID, Project: project, ObservationID: obsID}, nil
 }
 
-func (s *Store) AckSyncMutations(targetKey string, lastAckedSeq int64) error {
-	if lastAckedSeq <= 0 {
+// resurfaceCompaction writes the latest session summary of project when a
+// compaction is pending there and, with clear, marks the pending events
+// resurfaced. It writes nothing otherwise.
+func (s *Store) resurfaceCompaction(b *strings.Builder, project string, clear bool) error {
+	var compactedAt string
+	err := s.db.QueryRow(
+		`SELECT created_at FROM compaction_events
+		 WHERE project = ? AND resurfaced_at IS NULL
+		 ORDER BY id DESC LIMIT 1`, project,
+	).Scan(&compactedAt)
+	if err == sql.ErrNoRows {
 		return nil
 	}
-	targetKey = normalizeSyncTargetKey(targetKey)
-	return s.withTx(func(tx *sql.Tx) error {
-		state, err := s.getSyncStateTx(tx, targetKey)
+	if err != nil {
+		return err
+	}
+
+	b.WriteString("### Resumed After Compaction\n")
+	fmt.Fprintf(b, "Context was compacted at %s.", s.FormatTime(compactedAt))
+	var summaryID int64
+	err = s.db.QueryRow(
+		`SELECT id FROM observations
+		 WHERE project = ? AND type = 'session_summary' AND deleted_at IS NULL
+		 ORDER BY seq DESC, id DESC LIMIT 1`, project,
+	).Scan(&summaryID)
+	switch {
+	case err == sql.ErrNoRows:
+		b.WriteString(" No session summary was saved before it: call mem_session_summary with the compacted summary.\n\n")
+	case err != nil:
+		return err
+	default:
+		summary, _, err := s.GetFullObservation(summaryID)
 		if err != nil {
 			return err
 		}
-		if _, err := s.execHook(tx,
-			`UPDATE sync_mutations SET acked_at = datetime('now') WHERE target_key = ? AND seq <= ? AND acked_at IS NULL`,
-			targetKey, lastAckedSeq,
-		); err != nil {
-			return err
+		fmt.Fprintf(b, " Latest session summary (%s):\n\n%s\n\n", s.FormatTime(summary.CreatedAt), summary.Content)
+	}
+
+	if !clear {
+		return nil
+	}
+	_, err = s.execHook(s.db,
+		`UPDATE compaction_events SET resurfaced_at = ? WHERE project = ? AND resurfaced_at IS NULL`,
+		Now(), project,
+	)
+	return err
+}
+
+// ─── Events ──────────────────────────────────────────────────────────────────
+//
+// The events table is an append-only changefeed for downstream consumers
+// such as search indexers and dashboards. Triggers on sessions and
+// observations fill it, so every writer is covered: MCP, HTTP, imports,
+// sync pulls, and other processes sharing the database. An event's Seq is
+// the cursor to resume from; it only grows.
+
+// Event kinds.
+const (
+	EventObservationCreated = "observation.created"
+	EventObservationUpdated = "observation.updated"
+	EventObservationDeleted = "observation.deleted"
+	EventSessionStarted     = "session.started"
+	EventSessionEnded       = "session.ended"
+)
+
+// Event is one entry of the changefeed. Data holds a small kind-specific
+// summary (an observation's type, title, scope, and topic key; a session's
+// directory or summary); fetch the record itself for the rest.
+type Event struct {
+	Seq           int64           `json:"seq"`
+	Kind          string          `json:"kind"`
+	SessionID     string          `json:"session_id,omitempty"`
+	ObservationID int64           `json:"observation_id,omitempty"`
+	SyncID        string          `json:"sync_id,omitempty"`
+	Project       string          `json:"project,omitempty"`
+	Data          json.RawMessage `json:"data,omitempty"`
+	CreatedAt     string          `json:"created_at"`
+}
+
+// MaxEventsPage caps one Events call.
+const MaxEventsPage = 1000
+
+// DefaultEventRetention is how long GC keeps events when
+// Config.EventRetention is zero.
+const DefaultEventRetention = 30 * 24 * time.Hour
+
+// eventPollInterval is how often WaitEvents re-reads the table while
+// waiting. Commits through this Store wake it at once; writes from other
+// processes are only seen on the next poll.
+const eventPollInterval = 500 * time.Millisecond
+
+// Events returns up to limit events after the cursor since, oldest first.
+// since 0 reads from the oldest event kept. GC prunes events older than
+// Config.EventRetention, so a cursor from before the oldest kept event
+// fails with ErrEventCursorExpired: the consumer missed events and should
+// start over.
+func (s *Store) Events(since int64, limit int) ([]Event, error) {
+	if limit <= 0 || limit > MaxEventsPage {
+		limit = MaxEventsPage
+	}
+	if since > 0 {
+		var oldest sql.NullInt64
+		if err := s.db.QueryRow(`SELECT MIN(seq) FROM events`).Scan(&oldest); err != nil {
+			return nil, err
 		}
-		acked := state.LastAckedSeq
-		if lastAckedSeq > acked {
-			acked = lastAckedSeq
+		if oldest.Valid && since < oldest.Int64-1 {
+			return nil, fmt.Errorf("%w: events after %d were pruned; the oldest kept is %d, read from 0 to start over",
+				ErrEventCursorExpired, since, oldest.Int64)
 		}
-		lifecycle := SyncLifecyclePending
-		if acked >= state.LastEnqueuedSeq {
-			lifecycle = SyncLifecycleHealthy
+	}
+	rows, err := s.queryHook(s.db,
+		`SELECT seq, kind, ifnull(session_id, ''), ifnull(observation_id, 0), ifnull(sync_id, ''), ifnull(project, ''), data, created_at
+		 FROM events WHERE seq > ? ORDER BY seq LIMIT ?`,
+		since, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var data *string
+		if err := rows.Scan(&e.Seq, &e.Kind, &e.SessionID, &e.ObservationID, &e.SyncID, &e.Project, &data, &e.CreatedAt); err != nil {
+			return nil, err
 		}
-		_, err = s.execHook(tx,
-			`UPDATE sync_state
-			 SET last_acked_seq = ?, lifecycle = ?, updated_at = datetime('now')
-			 WHERE target_key = ?`,
-			acked, lifecycle, targetKey,
-		)
-		return err
-	})
+		if data != nil {
+			e.Data = json.RawMessage(*data)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// WaitEvents is Events that long-polls: when nothing is newer than since,
+// it blocks until an event is appended, wait elapses, or ctx is done, and
+// returns no events on timeout.
+func (s *Store) WaitEvents(ctx context.Context, since int64, limit int, wait time.Duration) ([]Event, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		// Take the wake channel before reading, so a commit in between is
+		// not missed.
+		wake := s.eventWake()
+		events, err := s.Events(since, limit)
+		if err != nil || len(events) > 0 {
+			return events, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, nil
+		}
+		timer := time.NewTimer(min(remaining, eventPollInterval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
 }
 
-// AckSyncMutationSeqs acknowledges specific mutation sequence numbers without
-// requiring them to be contiguous.
-func (s *Store) AckSyncMutationSeqs(targetKey string, seqs []int64) error {
-	if len(seqs) == 0 {
-		return nil
+func (s *Store) eventWake() <-chan struct{} {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	if s.eventsWake == nil {
+		s.eventsWake = make(chan struct{})
 	}
-	targetKey = normalizeSyncTargetKey(targetKey)
-	return s.withTx(func(tx *sql.Tx) error {
-		state, err := s.getSyncStateTx(tx, targetKey)
-		if err != nil {
-			return err
-		}
-		maxSeq := state.LastAckedSeq
-		for _, seq := range seqs {
-			if seq <= 0 {
-				continue
-			}
-			if _, err := s.execHook(tx,
-				`UPDATE sync_mutations SET acked_at = datetime('now') WHERE target_key = ? AND seq = ? AND acked_at IS NULL`,
-				targetKey, seq,
-			); err != nil {
+	return s.eventsWake
+}
+
+func (s *Store) wakeEventWaiters() {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	if s.eventsWake != nil {
+		close(s.eventsWake)
+		s.eventsWake = nil
+	}
+}
+
+// ─── Batch ───────────────────────────────────────────────────────────────────
+//
+// BatchApply lets a plugin write a session, its prompts, and its
+// observations in one round trip and one transaction. Items run in order,
+// each in its own savepoint, so later items can reference a session created
+// earlier in the same batch.
+
+// Batch item kinds.
+const (
+	BatchSession     = "session"
+	BatchPrompt      = "prompt"
+	BatchObservation = "observation"
+)
+
+// ErrBatchRolledBack is reported for items that succeeded but were undone
+// because another item of an atomic batch failed.
+var ErrBatchRolledBack = errors.New("rolled back: another item in the atomic batch failed")
+
+// BatchItem is one write. Kind selects which payload is used.
+type BatchItem struct {
+	Kind        string                `json:"kind"`
+	Session     *BatchSessionParams   `json:"session,omitempty"`
+	Prompt      *AddPromptParams      `json:"prompt,omitempty"`
+	Observation *AddObservationParams `json:"observation,omitempty"`
+}
+
+// BatchSessionParams creates (or fills in) a session, like CreateSession.
+type BatchSessionParams struct {
+	ID        string `json:"id"`
+	Project   string `json:"project"`
+	Directory string `json:"directory,omitempty"`
+}
+
+// BatchOptions controls failure handling.
+type BatchOptions struct {
+	// Atomic rolls back every item when any item fails. Without it, failed
+	// items are skipped and the rest are committed.
+	Atomic bool
+}
+
+// BatchItemResult is the outcome of one item, in request order.
+type BatchItemResult struct {
+	Index int    `json:"index"`
+	Kind  string `json:"kind"`
+	// ID is the new prompt or observation ID; SessionID names the session
+	// for session items.
+	ID        int64  `json:"id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+
+	err error
+}
+
+// Err returns the item's failure, or nil.
+func (r BatchItemResult) Err() error { return r.err }
+
+// BatchResult reports every item and the totals.
+type BatchResult struct {
+	Applied    int               `json:"applied"`
+	Failed     int               `json:"failed"`
+	RolledBack bool              `json:"rolled_back,omitempty"`
+	Items      []BatchItemResult `json:"items"`
+}
+
+// BatchApply runs items in one transaction. The returned error is for the
+// transaction as a whole; per-item failures are in the result.
+func (s *Store) BatchApply(items []BatchItem, opts BatchOptions) (*BatchResult, error) {
+	result := &BatchResult{Items: make([]BatchItemResult, len(items))}
+	err := s.withTx(func(tx *sql.Tx) error {
+		for i, item := range items {
+			if _, err := s.execHook(tx, "SAVEPOINT batch_item"); err != nil {
 				return err
 			}
-			if seq > maxSeq {
-				maxSeq = seq
+			res := s.applyBatchItemTx(tx, item)
+			res.Index = i
+			release := "RELEASE batch_item"
+			if res.err != nil {
+				release = "ROLLBACK TO batch_item; RELEASE batch_item"
+				result.Failed++
 			}
+			if _, err := s.execHook(tx, release); err != nil {
+				return err
+			}
+			result.Items[i] = res
 		}
-		var remaining int
-		if err := tx.QueryRow(`SELECT COUNT(*) FROM sync_mutations WHERE target_key = ? AND acked_at IS NULL`, targetKey).Scan(&remaining); err != nil {
-			return err
-		}
-		lifecycle := SyncLifecyclePending
-		if remaining == 0 {
-			lifecycle = SyncLifecycleHealthy
+		if opts.Atomic && result.Failed > 0 {
+			return ErrBatchRolledBack
 		}
-		_, err = s.execHook(tx,
-			`UPDATE sync_state SET last_acked_seq = ?, lifecycle = ?, updated_at = datetime('now') WHERE target_key = ?`,
-			maxSeq, lifecycle, targetKey,
-		)
-		return err
+		return nil
 	})
+	if errors.Is(err, ErrBatchRolledBack) {
+		result.RolledBack = true
+		for i := range result.Items {
+			if result.Items[i].err == nil {
+				result.Items[i].ID = 0
+				result.Items[i].fail(ErrBatchRolledBack)
+			}
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Applied = len(items) - result.Failed
+	return result, nil
 }
 
-func (s *Store) AcquireSyncLease(targetKey, owner string, ttl time.Duration, now time.Time) (bool, error) {
-	targetKey = normalizeSyncTargetKey(targetKey)
-	if ttl <= 0 {
-		ttl = time.Minute
+func (r *BatchItemResult) fail(err error) {
+	r.OK = false
+	r.err = err
+	r.Error = err.Error()
+}
+
+func (s *Store) applyBatchItemTx(tx *sql.Tx, item BatchItem) BatchItemResult {
+	res := BatchItemResult{Kind: item.Kind, OK: true}
+	var err error
+	switch item.Kind {
+	case BatchSession:
+		p := item.Session
+		if p == nil || p.ID == "" || p.Project == "" {
+			err = invalidf("session: id and project are required")
+			break
+		}
+		project, _ := NormalizeProject(p.Project)
+		res.SessionID = p.ID
+		err = s.createSyncedSessionTx(tx, p.ID, project, p.Directory)
+	case BatchPrompt:
+		p := item.Prompt
+		if p == nil || p.SessionID == "" || p.Content == "" {
+			err = invalidf("prompt: session_id and content are required")
+			break
+		}
+		res.ID, err = s.addPromptTx(tx, *p)
+	case BatchObservation:
+		p := item.Observation
+		if p == nil || p.SessionID == "" || p.Title == "" || p.Content == "" {
+			err = invalidf("observation: session_id, title, and content are required")
+			break
+		}
+		res.ID, err = s.addObservationTx(tx, *p)
+	default:
+		err = invalidf("unknown kind %q (want session, prompt, or observation)", item.Kind)
 	}
-	if now.IsZero() {
-		now = time.Now().UTC()
+	if err != nil {
+		res.ID = 0
+		res.fail(err)
 	}
+	return res
+}
 
-	var acquired bool
-	err := s.withTx(func(tx *sql.Tx) error {
-		state, err := s.getSyncStateTx(tx, targetKey)
+// ─── Delete Session ──────────────────────────────────────────────────────────
+
+// DeleteSession hard-deletes a session and its prompts.
+// It returns ErrSessionHasObservations if the session has any observations
+// (including soft-deleted ones) to prevent orphaned rows.
+// It returns ErrSessionNotFound if no session with that ID exists.
+//
+// Note: this delete only removes local rows. It does not enqueue a delete
+// sync mutation, but any previously enqueued mutations for the session or its
+// prompts may still be synced later if autosync is enabled, and a later pull
+// may recreate the deleted rows locally.
+func (s *Store) DeleteSession(id string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		// Count ALL observations for the session, including soft-deleted ones,
+		// because the FK constraint on observations.session_id has no ON DELETE CASCADE.
+		var count int
+		rows, err := s.queryItHook(tx, `SELECT COUNT(*) FROM observations WHERE session_id = ?`, id)
 		if err != nil {
-			return err
+			return fmt.Errorf("delete session: count observations: %w", err)
 		}
-		if state.LeaseUntil != nil {
-			leaseUntil, err := time.Parse(time.RFC3339, *state.LeaseUntil)
-			if err == nil && leaseUntil.After(now) && derefString(state.LeaseOwner) != "" && derefString(state.LeaseOwner) != owner {
-				acquired = false
-				return nil
+		if rows.Next() {
+			if err := rows.Scan(&count); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("delete session: count observations: %w", err)
 			}
 		}
-		leaseUntil := now.Add(ttl).UTC().Format(time.RFC3339)
-		_, err = s.execHook(tx,
-			`UPDATE sync_state
-			 SET lease_owner = ?, lease_until = ?, updated_at = datetime('now')
-			 WHERE target_key = ?`,
-			owner, leaseUntil, targetKey,
-		)
-		if err == nil {
-			acquired = true
+		_ = rows.Close()
+		if count > 0 {
+			return fmt.Errorf("%w: session %q has %d observation(s)", ErrSessionHasObservations, id, count)
 		}
-		return err
-	})
-	return acquired, err
-}
 
-func (s *Store) ReleaseSyncLease(targetKey, owner string) error {
-	targetKey = normalizeSyncTargetKey(targetKey)
-	_, err := s.execHook(s.db,
-		`UPDATE sync_state
-		 SET lease_owner = NULL, lease_until = NULL, updated_at = datetime('now')
-		 WHERE target_key = ? AND (lease_owner = ? OR lease_owner IS NULL OR lease_owner = '')`,
-		targetKey, owner,
-	)
-	return err
-}
+		if _, err := s.execHook(tx, `DELETE FROM user_prompts WHERE session_id = ?`, id); err != nil {
+			return fmt.Errorf("delete session: remove prompts: %w", err)
+		}
+		if _, err := s.execHook(tx, `DELETE FROM working_memory WHERE session_id = ?`, id); err != nil {
+			return fmt.Errorf("delete session: remove working memory: %w", err)
+		}
+		if _, err := s.execHook(tx, `DELETE FROM tool_runs WHERE session_id = ?`, id); err != nil {
+			return fmt.Errorf("delete session: remove tool runs: %w", err)
+		}
 
-func (s *Store) MarkSyncFailure(targetKey, message string, backoffUntil time.Time) error {
-	targetKey = normalizeSyncTargetKey(targetKey)
-	backoff := backoffUntil.UTC().Format(time.RFC3339)
-	return s.withTx(func(tx *sql.Tx) error {
-		state, err := s.getSyncStateTx(tx, targetKey)
+		res, err := s.execHook(tx, `DELETE FROM sessions WHERE id = ?`, id)
 		if err != nil {
-			return err
+			var sqliteErr *sqlite.Error
+			if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqliteConstraintForeignKey {
+				return fmt.Errorf("%w: session %q has observation(s)", ErrSessionHasObservations, id)
+			}
+			return fmt.Errorf("delete session: %w", err)
 		}
-		_, err = s.execHook(tx,
-			`UPDATE sync_state
-			 SET lifecycle = ?, consecutive_failures = ?, backoff_until = ?, last_error = ?, updated_at = datetime('now')
-			 WHERE target_key = ?`,
-			SyncLifecycleDegraded, state.ConsecutiveFailures+1, backoff, message, targetKey,
-		)
-		return err
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("delete session: rows affected: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: %q", ErrSessionNotFound, id)
+		}
+
+		return nil
 	})
 }
 
-func (s *Store) MarkSyncHealthy(targetKey string) error {
-	targetKey = normalizeSyncTargetKey(targetKey)
-	_, err := s.execHook(s.db,
-		`UPDATE sync_state
-		 SET lifecycle = ?, consecutive_failures = 0, backoff_until = NULL, last_error = NULL, updated_at = datetime('now')
-		 WHERE target_key = ?`,
-		SyncLifecycleHealthy, targetKey,
-	)
-	return err
+// ─── Merge / Split Sessions ──────────────────────────────────────────────────
+
+// SessionMergeResult summarizes a MergeSessions call.
+type SessionMergeResult struct {
+	Target            string   `json:"target"`
+	SessionsMerged    []string `json:"sessions_merged"`
+	ObservationsMoved int64    `json:"observations_moved"`
+	PromptsMoved      int64    `json:"prompts_moved"`
 }
 
-func (s *Store) ApplyPulledMutation(targetKey string, mutation SyncMutation) error {
-	targetKey = normalizeSyncTargetKey(targetKey)
-	return s.withTx(func(tx *sql.Tx) error {
-		state, err := s.getSyncStateTx(tx, targetKey)
+// MergeSessions re-parents every observation and prompt of the source
+// sessions onto target, then deletes the emptied sources. Sources equal to
+// target are skipped. The target keeps the earliest started_at and adopts a
+// source summary when it has none of its own. Sources must belong to the
+// target's project (or have no project). All work happens in one transaction.
+//
+// Like DeleteSession, removing the source sessions does not enqueue a
+// delete sync mutation; the moved observations and prompts are re-enqueued
+// as upserts so peers see their new session_id.
+func (s *Store) MergeSessions(ids []string, target string) (*SessionMergeResult, error) {
+	if s.cfg.AppendOnly {
+		return nil, fmt.Errorf("merge sessions: %w", ErrAppendOnly)
+	}
+	result := &SessionMergeResult{Target: target}
+
+	err := s.withTx(func(tx *sql.Tx) error {
+		targetSess, err := s.getSessionTx(tx, target)
 		if err != nil {
-			return err
-		}
-		if mutation.Seq <= state.LastPulledSeq {
-			return nil
+			return fmt.Errorf("merge sessions: target: %w", err)
 		}
 
-		switch mutation.Entity {
-		case SyncEntitySession:
-			var payload syncSessionPayload
-			if err := decodeSyncPayload([]byte(mutation.Payload), &payload); err != nil {
-				return err
+		seen := map[string]bool{target: true}
+		for _, id := range ids {
+			if seen[id] {
+				continue
 			}
-			if err := s.applySessionPayloadTx(tx, payload); err != nil {
-				return err
+			seen[id] = true
+
+			src, err := s.getSessionTx(tx, id)
+			if err != nil {
+				return fmt.Errorf("merge sessions: source: %w", err)
 			}
-		case SyncEntityObservation:
-			var payload syncObservationPayload
-			if err := decodeSyncPayload([]byte(mutation.Payload), &payload); err != nil {
-				return err
+			if src.Project != "" && src.Project != targetSess.Project {
+				return fmt.Errorf("merge sessions: %q belongs to project %q, target %q to %q", id, src.Project, target, targetSess.Project)
 			}
-			if mutation.Op == SyncOpDelete {
-				if err := s.applyObservationDeleteTx(tx, payload); err != nil {
-					return err
-				}
-			} else {
-				if err := s.applyObservationUpsertTx(tx, payload); err != nil {
-					return err
-				}
+
+			// Every observation moves, soft-deleted ones included: the FK on
+			// observations.session_id has no ON DELETE CASCADE.
+			res, err := s.execHook(tx, `UPDATE observations SET session_id = ? WHERE session_id = ?`, target, id)
+			if err != nil {
+				return fmt.Errorf("merge sessions: move observations from %q: %w", id, err)
 			}
-		case SyncEntityPrompt:
-			var payload syncPromptPayload
-			if err := decodeSyncPayload([]byte(mutation.Payload), &payload); err != nil {
-				return err
+			n, _ := res.RowsAffected()
+			result.ObservationsMoved += n
+
+			res, err = s.execHook(tx, `UPDATE user_prompts SET session_id = ? WHERE session_id = ?`, target, id)
+			if err != nil {
+				return fmt.Errorf("merge sessions: move prompts from %q: %w", id, err)
 			}
-			if err := s.applyPromptUpsertTx(tx, payload); err != nil {
-				return err
+			n, _ = res.RowsAffected()
+			result.PromptsMoved += n
+
+			if _, err := s.execHook(tx, `UPDATE tool_runs SET session_id = ? WHERE session_id = ?`, target, id); err != nil {
+				return fmt.Errorf("merge sessions: move tool runs from %q: %w", id, err)
 			}
-		default:
-			return fmt.Errorf("unknown sync entity %q", mutation.Entity)
+
+			if _, err := s.execHook(tx,
+				`UPDATE sessions
+				 SET started_at = min(started_at, ?),
+				     summary = COALESCE(summary, ?)
+				 WHERE id = ?`,
+				src.StartedAt, src.Summary, target,
+			); err != nil {
+				return fmt.Errorf("merge sessions: update target: %w", err)
+			}
+
+			// Continuations of the merged session now continue the target;
+			// the target itself must not end up continuing itself.
+			if _, err := s.execHook(tx,
+				`UPDATE sessions SET parent_session_id = CASE WHEN id = ? THEN NULL ELSE ? END WHERE parent_session_id = ?`,
+				target, target, id,
+			); err != nil {
+				return fmt.Errorf("merge sessions: relink continuations of %q: %w", id, err)
+			}
+
+			if _, err := s.execHook(tx, `DELETE FROM sessions WHERE id = ?`, id); err != nil {
+				return fmt.Errorf("merge sessions: delete %q: %w", id, err)
+			}
+			result.SessionsMerged = append(result.SessionsMerged, id)
 		}
 
-		_, err = s.execHook(tx,
-			`UPDATE sync_state
-			 SET last_pulled_seq = ?, lifecycle = ?, consecutive_failures = 0, backoff_until = NULL, last_error = NULL, updated_at = datetime('now')
-			 WHERE target_key = ?`,
-			mutation.Seq, SyncLifecycleHealthy, targetKey,
-		)
-		return err
+		if len(result.SessionsMerged) == 0 {
+			return nil
+		}
+		return s.enqueueSessionContentsTx(tx, target)
 	})
-}
-
-func (s *Store) GetObservationBySyncID(syncID string) (*Observation, error) {
-	row := s.db.QueryRow(
-		`SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
-		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at
-		 FROM observations WHERE sync_id = ? AND deleted_at IS NULL ORDER BY id DESC LIMIT 1`,
-		syncID,
-	)
-	var o Observation
-	if err := row.Scan(&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, &o.Content, &o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt); err != nil {
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SessionSplitResult summarizes a SplitSession call.
+type SessionSplitResult struct {
+	Source            string `json:"source"`
+	NewSession        string `json:"new_session"`
+	ObservationsMoved int64  `json:"observations_moved"`
+}
+
+// SplitSession moves the given observations out of session id into a new
+// session newID, which inherits the source's project and directory and
+// starts at the earliest moved observation. Every observation must belong
+// to the source session. Prompts are not linked to individual observations,
+// so they stay with the source.
+func (s *Store) SplitSession(id string, observationIDs []int64, newID string) (*SessionSplitResult, error) {
+	if s.cfg.AppendOnly {
+		return nil, fmt.Errorf("split session: %w", ErrAppendOnly)
+	}
+	if newID == "" {
+		return nil, invalidf("split session: new session id must not be empty")
+	}
+	if len(observationIDs) == 0 {
+		return nil, fmt.Errorf("split session: no observations to move")
+	}
+
+	result := &SessionSplitResult{Source: id, NewSession: newID}
+
+	err := s.withTx(func(tx *sql.Tx) error {
+		src, err := s.getSessionTx(tx, id)
+		if err != nil {
+			return fmt.Errorf("split session: %w", err)
+		}
+		if _, err := s.getSessionTx(tx, newID); err == nil {
+			return fmt.Errorf("split session: %w: %q", ErrSessionExists, newID)
+		} else if !errors.Is(err, ErrSessionNotFound) {
+			return fmt.Errorf("split session: %w", err)
+		}
+
+		startedAt := ""
+		for _, obsID := range observationIDs {
+			var sessionID, createdAt string
+			err := tx.QueryRow(`SELECT session_id, created_at FROM observations WHERE id = ?`, obsID).Scan(&sessionID, &createdAt)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("split session: observation #%d not found", obsID)
+			}
+			if err != nil {
+				return fmt.Errorf("split session: observation #%d: %w", obsID, err)
+			}
+			if sessionID != id {
+				return fmt.Errorf("split session: observation #%d belongs to session %q, not %q", obsID, sessionID, id)
+			}
+			if startedAt == "" || createdAt < startedAt {
+				startedAt = createdAt
+			}
+		}
+
+		if _, err := s.execHook(tx,
+			`INSERT INTO sessions (id, project, directory, started_at) VALUES (?, ?, ?, ?)`,
+			newID, src.Project, src.Directory, startedAt,
+		); err != nil {
+			return fmt.Errorf("split session: create %q: %w", newID, err)
+		}
+
+		for _, obsID := range observationIDs {
+			res, err := s.execHook(tx, `UPDATE observations SET session_id = ? WHERE id = ? AND session_id = ?`, newID, obsID, id)
+			if err != nil {
+				return fmt.Errorf("split session: move observation #%d: %w", obsID, err)
+			}
+			n, _ := res.RowsAffected()
+			result.ObservationsMoved += n
+		}
+
+		return s.enqueueSessionContentsTx(tx, newID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Store) getSessionTx(tx *sql.Tx, id string) (*Session, error) {
+	var sess Session
+	err := tx.QueryRow(
+		`SELECT id, project, directory, started_at, ended_at, summary, parent_session_id FROM sessions WHERE id = ?`, id,
+	).Scan(&sess.ID, &sess.Project, &sess.Directory, &sess.StartedAt, &sess.EndedAt, &sess.Summary, &sess.ParentSessionID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %q", ErrSessionNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// enqueueSessionContentsTx enqueues upserts for a session and its live
+// observations and prompts, so a re-parenting reaches sync peers.
+func (s *Store) enqueueSessionContentsTx(tx *sql.Tx, id string) error {
+	sess, err := s.getSessionTx(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.enqueueSyncMutationTx(tx, SyncEntitySession, sess.ID, SyncOpUpsert, syncSessionPayload{
+		ID:              sess.ID,
+		Project:         sess.Project,
+		Directory:       sess.Directory,
+		EndedAt:         sess.EndedAt,
+		Summary:         sess.Summary,
+		ParentSessionID: sess.ParentSessionID,
+	}); err != nil {
+		return err
+	}
+
+	rows, err := s.queryItHook(tx, `SELECT id FROM observations WHERE session_id = ? AND deleted_at IS NULL ORDER BY id ASC`, id)
+	if err != nil {
+		return err
+	}
+	var obsIDs []int64
+	for rows.Next() {
+		var obsID int64
+		if err := rows.Scan(&obsID); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		obsIDs = append(obsIDs, obsID)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	_ = rows.Close()
+
+	for _, obsID := range obsIDs {
+		obs, err := s.getObservationTx(tx, obsID)
+		if err != nil {
+			return err
+		}
+		if err := s.enqueueSyncMutationTx(tx, SyncEntityObservation, obs.SyncID, SyncOpUpsert, observationPayloadFromObservation(obs)); err != nil {
+			return err
+		}
+	}
+
+	prows, err := s.queryItHook(tx, `SELECT ifnull(sync_id, ''), session_id, content, project FROM user_prompts WHERE session_id = ? ORDER BY id ASC`, id)
+	if err != nil {
+		return err
+	}
+	var prompts []syncPromptPayload
+	for prows.Next() {
+		var payload syncPromptPayload
+		if err := prows.Scan(&payload.SyncID, &payload.SessionID, &payload.Content, &payload.Project); err != nil {
+			_ = prows.Close()
+			return err
+		}
+		prompts = append(prompts, payload)
+	}
+	if err := prows.Err(); err != nil {
+		_ = prows.Close()
+		return err
+	}
+	_ = prows.Close()
+
+	for _, payload := range prompts {
+		if err := s.enqueueSyncMutationTx(tx, SyncEntityPrompt, payload.SyncID, SyncOpUpsert, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ─── Delete Prompt ───────────────────────────────────────────────────────────
+
+// DeletePrompt hard-deletes a single prompt by ID.
+// It returns ErrPromptNotFound if no prompt with that ID exists.
+//
+// Note: this delete only removes local rows. It does not enqueue a delete
+// sync mutation, but any previously enqueued mutations for the prompt
+// may still be synced later if autosync is enabled, and a later pull
+// may recreate the deleted row locally.
+func (s *Store) DeletePrompt(id int64) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		res, err := s.execHook(tx, `DELETE FROM user_prompts WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("delete prompt: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("delete prompt: rows affected: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: prompt #%d", ErrPromptNotFound, id)
+		}
+		return nil
+	})
+}
+
+// ─── Get Single Observation ──────────────────────────────────────────────────
+
+func (s *Store) GetObservation(id int64) (*Observation, error) {
+	row := s.db.QueryRow(
+		`SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
+		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata
+		 FROM observations WHERE id = ? AND deleted_at IS NULL`, id,
+	)
+	var o Observation
+	if err := row.Scan(
+		&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, (*contentColumn)(&o.Content),
+		&o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt,
+		&o.CreatedAt, &o.UpdatedAt, &o.DeletedAt, &o.Refs, &o.VerifiedAt, &o.StaleAt, &o.VerificationNote, &o.Source, &o.Metadata,
+	); err != nil {
 		return nil, err
 	}
 	return &o, nil
 }
 
-// ─── Project Enrollment for Cloud Sync ───────────────────────────────────────
+func (s *Store) UpdateObservation(id int64, p UpdateObservationParams) (*Observation, error) {
+	var updated *Observation
+	err := s.withTx(func(tx *sql.Tx) error {
+		obs, err := s.getObservationTx(tx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: #%d: %w", ErrObservationNotFound, id, err)
+		}
+		if err != nil {
+			return err
+		}
+
+		typ := obs.Type
+		title := obs.Title
+		content := obs.Content
+		project := derefString(obs.Project)
+		scope := obs.Scope
+		topicKey := derefString(obs.TopicKey)
+
+		if p.Type != nil {
+			typ = *p.Type
+		}
+		if p.Title != nil {
+			title = stripPrivateTags(*p.Title)
+		}
+		// The edit is applied to every part of a chunked save: new content
+		// is split again, and otherwise the stored parts are carried over so
+		// the continuations follow the head's new fields.
+		var parts []string
+		if p.Content != nil {
+			parts = splitObservationContent(stripPrivateTags(*p.Content), s.cfg.MaxObservationLength)
+		} else if parts, err = s.partContentsTx(tx, obs); err != nil {
+			return err
+		}
+		content = parts[0]
+		if p.Project != nil {
+			project, _ = NormalizeProject(*p.Project)
+		}
+		if p.Scope != nil {
+			scope = normalizeScope(*p.Scope)
+		}
+		if p.TopicKey != nil {
+			topicKey = normalizeTopicKey(*p.TopicKey)
+		}
+		explicitRefs := []string(obs.Refs)
+		if p.Refs != nil {
+			explicitRefs = *p.Refs
+		}
+		refs := observationRefs(explicitRefs, title, content)
+		metadata := obs.Metadata
+		if p.Metadata != nil {
+			if metadata, err = normalizeMetadata(*p.Metadata); err != nil {
+				return err
+			}
+		}
+
+		partParams := AddObservationParams{
+			SessionID: obs.SessionID, Type: typ, ToolName: derefString(obs.ToolName),
+			Project: project, Source: derefString(obs.Source),
+		}
+
+		if s.cfg.AppendOnly {
+			updated, err = s.supersedeObservationTx(tx, obs, Observation{
+				SyncID: newSyncID("obs"), SessionID: obs.SessionID, Type: typ, Title: title, Content: content,
+				ToolName: obs.ToolName, Project: nullableString(project), Scope: scope,
+				TopicKey: nullableString(topicKey), Refs: refs, Metadata: metadata, Source: obs.Source,
+			})
+			if err != nil {
+				return err
+			}
+			if err := s.enqueueSupersedeTx(tx, obs, updated); err != nil {
+				return err
+			}
+			return s.replaceObservationPartsTx(tx, updated.ID, partParams, title, scope, parts)
+		}
+
+		if _, err := s.execHook(tx,
+			`UPDATE observations
+			 SET type = ?,
+			     title = ?,
+			     content = ?,
+			     project = ?,
+			     scope = ?,
+			     topic_key = ?,
+			     refs = ?,
+			     metadata = ?,
+			     normalized_hash = ?,
+			     stale_at = NULL,
+			     revision_count = revision_count + 1,
+			     updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+			 WHERE id = ? AND deleted_at IS NULL`,
+			typ,
+			title,
+			s.storedContent(content),
+			nullableString(project),
+			scope,
+			nullableString(topicKey),
+			refs,
+			metadata,
+			hashNormalized(content),
+			id,
+		); err != nil {
+			return err
+		}
+		if err := s.linkObservationFiles(tx, id, title, content); err != nil {
+			return err
+		}
+
+		updated, err = s.getObservationTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.enqueueSyncMutationTx(tx, SyncEntityObservation, updated.SyncID, SyncOpUpsert, observationPayloadFromObservation(updated)); err != nil {
+			return err
+		}
+		return s.replaceObservationPartsTx(tx, id, partParams, title, scope, parts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *Store) DeleteObservation(id int64, hardDelete bool) error {
+	if hardDelete && s.cfg.AppendOnly {
+		return ErrAppendOnly
+	}
+	return s.withTx(func(tx *sql.Tx) error {
+		return s.deleteObservationTx(tx, id, hardDelete)
+	})
+}
+
+func (s *Store) deleteObservationTx(tx *sql.Tx, id int64, hardDelete bool) error {
+	obs, err := s.getObservationTx(tx, id)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Continuations go with their first part. A soft delete keeps their
+	// observation_parts rows so RestoreObservation can bring the whole save
+	// back; a hard delete drops them through the obs_parts_delete trigger.
+	continuations, err := s.continuationIDsTx(tx, id)
+	if err != nil {
+		return err
+	}
+	for _, partID := range continuations {
+		if err := s.deleteObservationTx(tx, partID, hardDelete); err != nil {
+			return err
+		}
+	}
+
+	deletedAt := Now()
+	if hardDelete {
+		if _, err := s.execHook(tx, `DELETE FROM observations WHERE id = ?`, id); err != nil {
+			return err
+		}
+	} else {
+		if _, err := s.execHook(tx,
+			`UPDATE observations
+			 SET deleted_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+			     updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+			 WHERE id = ? AND deleted_at IS NULL`,
+			id,
+		); err != nil {
+			return err
+		}
+		if err := tx.QueryRow(`SELECT deleted_at FROM observations WHERE id = ?`, id).Scan(&deletedAt); err != nil {
+			return err
+		}
+	}
+
+	return s.enqueueSyncMutationTx(tx, SyncEntityObservation, obs.SyncID, SyncOpDelete, syncObservationPayload{
+		SyncID:     obs.SyncID,
+		Deleted:    true,
+		DeletedAt:  &deletedAt,
+		HardDelete: hardDelete,
+	})
+}
+
+// supersedeObservationTx is how append-only mode (Config.AppendOnly) edits:
+// it saves next as a new row, one revision past old, then soft-deletes old,
+// along with its continuation parts, and points old's superseded_by at the
+// new row. old keeps its content, and the events feed records the new row's
+// creation and the old one's deletion. next.SyncID names the new row.
+func (s *Store) supersedeObservationTx(tx *sql.Tx, old *Observation, next Observation) (*Observation, error) {
+	res, err := s.execHook(tx,
+		`INSERT INTO observations (sync_id, session_id, type, title, content, tool_name, project, scope, topic_key, refs, metadata, normalized_hash, source, revision_count, duplicate_count, last_seen_at, updated_at, seq)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), `+nextObservationSeq+`)`,
+		next.SyncID, next.SessionID, next.Type, next.Title, s.storedContent(next.Content), next.ToolName, next.Project,
+		normalizeScope(next.Scope), next.TopicKey, next.Refs, next.Metadata, hashNormalized(next.Content), next.Source, old.RevisionCount+1,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("supersede #%d: %w", old.ID, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("supersede #%d: %w", old.ID, err)
+	}
+	if err := s.linkObservationFiles(tx, id, next.Title, next.Content); err != nil {
+		return nil, err
+	}
+	// The new row does not inherit old's continuations; a chunked save adds
+	// its own parts after this.
+	if err := s.dropContinuationsTx(tx, old.ID, false); err != nil {
+		return nil, fmt.Errorf("supersede #%d: %w", old.ID, err)
+	}
+	if _, err := s.execHook(tx,
+		`UPDATE observations
+		 SET deleted_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+		     updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+		     superseded_by = ?
+		 WHERE id = ?`,
+		id, old.ID,
+	); err != nil {
+		return nil, fmt.Errorf("supersede #%d: %w", old.ID, err)
+	}
+	return s.getObservationTx(tx, id)
+}
+
+// enqueueSupersedeTx syncs a local supersede: peers get the new row and a
+// soft delete of the old one.
+func (s *Store) enqueueSupersedeTx(tx *sql.Tx, old, next *Observation) error {
+	if err := s.enqueueSyncMutationTx(tx, SyncEntityObservation, next.SyncID, SyncOpUpsert, observationPayloadFromObservation(next)); err != nil {
+		return err
+	}
+	var deletedAt string
+	if err := tx.QueryRow(`SELECT deleted_at FROM observations WHERE id = ?`, old.ID).Scan(&deletedAt); err != nil {
+		return err
+	}
+	return s.enqueueSyncMutationTx(tx, SyncEntityObservation, old.SyncID, SyncOpDelete, syncObservationPayload{
+		SyncID:    old.SyncID,
+		Deleted:   true,
+		DeletedAt: &deletedAt,
+	})
+}
+
+// BulkDeleteFilter selects observations for DeleteObservations. At least one
+// of TopicKey, SessionID, or Query is required; Project, Scope, and Type only
+// narrow the match.
+type BulkDeleteFilter struct {
+	TopicKey  string `json:"topic_key,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Query     string `json:"query,omitempty"` // FTS5 search, as in Search
+	Project   string `json:"project,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Type      string `json:"type,omitempty"`
+}
+
+// BulkDeleteResult reports what a bulk delete matched and, unless it was a
+// dry run, soft-deleted.
+type BulkDeleteResult struct {
+	Matched int           `json:"matched"`
+	Deleted int           `json:"deleted"`
+	DryRun  bool          `json:"dry_run"`
+	Sample  []Observation `json:"sample,omitempty"` // first matches, newest first
+}
+
+// bulkDeleteSampleSize caps BulkDeleteResult.Sample.
+const bulkDeleteSampleSize = 10
+
+// DeleteObservations soft-deletes every live observation matching f, in one
+// transaction. With dryRun it only counts the matches, so callers can show
+// the count before asking for confirmation.
+func (s *Store) DeleteObservations(f BulkDeleteFilter, dryRun bool) (*BulkDeleteResult, error) {
+	f.TopicKey = strings.TrimSpace(f.TopicKey)
+	f.SessionID = strings.TrimSpace(f.SessionID)
+	f.Query = strings.TrimSpace(f.Query)
+	if f.TopicKey == "" && f.SessionID == "" && f.Query == "" {
+		return nil, invalidf("bulk delete: topic_key, session_id, or query is required")
+	}
+	project, _ := NormalizeProject(f.Project)
+
+	query := `
+		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at, o.refs, o.verified_at, o.stale_at, o.verification_note, o.source, o.metadata
+		FROM observations o`
+	args := []any{}
+	if f.Query != "" {
+		query += ` JOIN observations_fts fts ON fts.rowid = o.id WHERE observations_fts MATCH ?`
+		args = append(args, sanitizeFTS(f.Query))
+	} else {
+		query += ` WHERE 1 = 1`
+	}
+	query += ` AND o.deleted_at IS NULL`
+	if f.TopicKey != "" {
+		query += " AND o.topic_key = ?"
+		args = append(args, f.TopicKey)
+	}
+	if f.SessionID != "" {
+		query += " AND o.session_id = ?"
+		args = append(args, f.SessionID)
+	}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("o.project", project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	if f.Scope != "" {
+		query += " AND o.scope = ?"
+		args = append(args, normalizeScope(f.Scope))
+	}
+	if f.Type != "" {
+		query += " AND o.type = ?"
+		args = append(args, f.Type)
+	}
+	query += " ORDER BY o.seq DESC, o.id DESC"
+
+	matches, err := s.queryObservations(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("bulk delete: %w", err)
+	}
+	result := &BulkDeleteResult{Matched: len(matches), DryRun: dryRun}
+	result.Sample = matches[:min(len(matches), bulkDeleteSampleSize)]
+	if dryRun || len(matches) == 0 {
+		return result, nil
+	}
+
+	err = s.withTx(func(tx *sql.Tx) error {
+		for _, o := range matches {
+			if err := s.deleteObservationTx(tx, o.ID, false); err != nil {
+				return fmt.Errorf("delete #%d: %w", o.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bulk delete: %w", err)
+	}
+	result.Deleted = len(matches)
+	return result, nil
+}
+
+// ─── Archive ─────────────────────────────────────────────────────────────────
+
+// DefaultArchiveAge is how many days an observation must go untouched before
+// ArchiveObservations moves it out of the main database.
+const DefaultArchiveAge = 180
+
+// ErrArchiveUnavailable is returned for in-memory stores, which have no
+// directory to keep an archive file in.
+var ErrArchiveUnavailable = errors.New("archive: not available for in-memory stores")
+
+// archiveSchema keeps only what search needs: the observation rows and their
+// FTS index. There are no dedupe, sync, or file-link indexes, and the file
+// uses a rollback journal instead of WAL, so it stays small on disk.
+const archiveSchema = `
+	CREATE TABLE IF NOT EXISTS observations (
+		id                INTEGER PRIMARY KEY,
+		sync_id           TEXT,
+		session_id        TEXT NOT NULL,
+		type              TEXT NOT NULL,
+		title             TEXT NOT NULL,
+		content           TEXT NOT NULL,
+		tool_name         TEXT,
+		project           TEXT,
+		scope             TEXT NOT NULL DEFAULT 'project',
+		topic_key         TEXT,
+		refs              TEXT,
+		revision_count    INTEGER NOT NULL DEFAULT 1,
+		duplicate_count   INTEGER NOT NULL DEFAULT 1,
+		last_seen_at      TEXT,
+		created_at        TEXT NOT NULL,
+		updated_at        TEXT NOT NULL,
+		verified_at       TEXT,
+		stale_at          TEXT,
+		verification_note TEXT,
+		source            TEXT,
+		metadata          TEXT,
+		archived_at       TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+	);
+
+	CREATE VIRTUAL TABLE IF NOT EXISTS observations_fts USING fts5(
+		title,
+		content,
+		tool_name,
+		type,
+		project,
+		topic_key,
+		content='observations',
+		content_rowid='id'
+	);
+
+	CREATE TRIGGER IF NOT EXISTS obs_fts_insert AFTER INSERT ON observations BEGIN
+		INSERT INTO observations_fts(rowid, title, content, tool_name, type, project, topic_key)
+		VALUES (new.id, new.title, new.content, new.tool_name, new.type, new.project, new.topic_key);
+	END;
+`
+
+// ArchiveOptions selects observations for ArchiveObservations.
+type ArchiveOptions struct {
+	// OlderThanDays is the minimum age, measured from the last update or
+	// duplicate sighting; <= 0 means DefaultArchiveAge.
+	OlderThanDays int    `json:"older_than_days,omitempty"`
+	Project       string `json:"project,omitempty"`
+	DryRun        bool   `json:"dry_run,omitempty"`
+}
+
+// ArchiveResult reports what ArchiveObservations matched and, unless it was
+// a dry run, moved.
+type ArchiveResult struct {
+	Matched  int           `json:"matched"`
+	Archived int           `json:"archived"`
+	DryRun   bool          `json:"dry_run"`
+	Path     string        `json:"path"`
+	Sample   []Observation `json:"sample,omitempty"` // first matches, oldest first
+}
+
+// archiveDB opens the archive database. With create false a missing file is
+// not an error: it returns nil, because there is nothing to search yet.
+func (s *Store) archiveDB(create bool) (*sql.DB, error) {
+	s.archiveMu.Lock()
+	defer s.archiveMu.Unlock()
+	if s.archive != nil {
+		return s.archive, nil
+	}
+	if s.cfg.InMemory() {
+		return nil, ErrArchiveUnavailable
+	}
+	path := s.cfg.ArchivePath()
+	if !create {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+	}
+	db, err := openDB("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("archive: open: %w", err)
+	}
+	for _, stmt := range []string{"PRAGMA busy_timeout = 5000", archiveSchema} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("archive: init: %w", err)
+		}
+	}
+	// Archives written before observation metadata lack the column.
+	var hasMetadata int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('observations') WHERE name = 'metadata'`).Scan(&hasMetadata); err == nil && hasMetadata == 0 {
+		if _, err := db.Exec(`ALTER TABLE observations ADD COLUMN metadata TEXT`); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("archive: init: %w", err)
+		}
+	}
+	s.archive = db
+	return db, nil
+}
+
+// ArchiveObservations moves live observations that have not been touched in
+// opts.OlderThanDays into the archive database (Config.ArchivePath). Moved
+// rows keep their IDs, leave the main database's search, context, and
+// timelines, and stay searchable with SearchOptions.IncludeArchive.
+//
+// Archiving is local housekeeping: it is not synced, so other machines keep
+// their copies. The archive is written before the main database, and rows
+// already archived are skipped, so an interrupted run can simply be repeated.
+func (s *Store) ArchiveObservations(opts ArchiveOptions) (*ArchiveResult, error) {
+	if s.cfg.InMemory() {
+		return nil, ErrArchiveUnavailable
+	}
+	if s.cfg.AppendOnly && !opts.DryRun {
+		return nil, fmt.Errorf("archive: %w", ErrAppendOnly)
+	}
+	days := opts.OlderThanDays
+	if days <= 0 {
+		days = DefaultArchiveAge
+	}
+	project, _ := NormalizeProject(opts.Project)
+
+	query := `
+		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at, o.refs, o.verified_at, o.stale_at, o.verification_note, o.source, o.metadata
+		FROM observations o
+		WHERE o.deleted_at IS NULL AND o.quarantine_reason IS NULL
+		  AND datetime(max(o.updated_at, ifnull(o.last_seen_at, ''))) < datetime('now', ?)`
+	args := []any{fmt.Sprintf("-%d days", days)}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("o.project", project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	query += " ORDER BY o.updated_at, o.id"
+
+	matches, err := s.queryObservations(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("archive: %w", err)
+	}
+	result := &ArchiveResult{Matched: len(matches), DryRun: opts.DryRun, Path: s.cfg.ArchivePath()}
+	result.Sample = matches[:min(len(matches), bulkDeleteSampleSize)]
+	if opts.DryRun || len(matches) == 0 {
+		return result, nil
+	}
+
+	archive, err := s.archiveDB(true)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := archive.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("archive: begin: %w", err)
+	}
+	defer tx.Rollback()
+	for _, o := range matches {
+		if _, err := s.execHook(tx,
+			`INSERT OR IGNORE INTO observations (id, sync_id, session_id, type, title, content, tool_name, project, scope, topic_key, refs,
+			   revision_count, duplicate_count, last_seen_at, created_at, updated_at, verified_at, stale_at, verification_note, source, metadata)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			o.ID, nullableString(o.SyncID), o.SessionID, o.Type, o.Title, o.Content, o.ToolName, o.Project, o.Scope, o.TopicKey, o.Refs,
+			o.RevisionCount, o.DuplicateCount, o.LastSeenAt, o.CreatedAt, o.UpdatedAt, o.VerifiedAt, o.StaleAt, o.VerificationNote, o.Source, o.Metadata,
+		); err != nil {
+			return nil, fmt.Errorf("archive #%d: %w", o.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("archive: commit: %w", err)
+	}
+
+	// The delete triggers drop the FTS entries and file links. No sync
+	// mutation is queued: the observation is archived, not deleted.
+	err = s.withTx(func(tx *sql.Tx) error {
+		for _, o := range matches {
+			if _, err := s.execHook(tx, `DELETE FROM observations WHERE id = ?`, o.ID); err != nil {
+				return fmt.Errorf("remove #%d: %w", o.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("archive: %w", err)
+	}
+	result.Archived = len(matches)
+	return result, nil
+}
+
+// searchArchive runs an FTS query against the archive database. It returns
+// nothing when no archive has been written yet.
+func (s *Store) searchArchive(query string, opts SearchOptions, limit int) ([]SearchResult, error) {
+	archive, err := s.archiveDB(false)
+	if err != nil || archive == nil {
+		return nil, err
+	}
+
+	sqlQ := `
+		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, NULL, o.refs, o.verified_at, o.stale_at, o.verification_note, o.source, o.metadata,
+		       ` + s.cfg.SearchWeights.bm25() + ` AS score
+		FROM observations_fts fts
+		JOIN observations o ON o.id = fts.rowid
+		WHERE observations_fts MATCH ?`
+	args := []any{query}
+	if clause, typeArgs := typeFilterSQL("o.type", opts.Type, opts.Types); clause != "" {
+		sqlQ += clause
+		args = append(args, typeArgs...)
+	}
+	if opts.Project != "" {
+		clause, clauseArgs := projectFilterSQL("o.project", opts.Project)
+		sqlQ += clause
+		args = append(args, clauseArgs...)
+	}
+	if opts.Scope != "" {
+		sqlQ += " AND o.scope = ?"
+		args = append(args, normalizeScope(opts.Scope))
+	}
+	if clause, refArgs := refFilterSQL("o.refs", opts.Ref); clause != "" {
+		sqlQ += clause
+		args = append(args, refArgs...)
+	}
+	if clause, metaArgs := metadataFilterSQL("o.metadata", opts.Metadata); clause != "" {
+		sqlQ += clause
+		args = append(args, metaArgs...)
+	}
+	if clause, sourceArgs := sourceFilterSQL("o.source", opts.Source); clause != "" {
+		sqlQ += clause
+		args = append(args, sourceArgs...)
+	}
+	if clause, windowArgs := windowFilterSQL("o.", opts); clause != "" {
+		sqlQ += clause
+		args = append(args, windowArgs...)
+	}
+	sqlQ += " ORDER BY score LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.queryFTSOn(archive, query, sqlQ, args)
+	if err != nil {
+		return nil, fmt.Errorf("search archive: %w", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		sr := SearchResult{Archived: true}
+		if err := rows.Scan(
+			&sr.ID, &sr.SyncID, &sr.SessionID, &sr.Type, &sr.Title, &sr.Content,
+			&sr.ToolName, &sr.Project, &sr.Scope, &sr.TopicKey, &sr.RevisionCount, &sr.DuplicateCount,
+			&sr.LastSeenAt, &sr.CreatedAt, &sr.UpdatedAt, &sr.DeletedAt, &sr.Refs, &sr.VerifiedAt, &sr.StaleAt, &sr.VerificationNote, &sr.Source, &sr.Metadata,
+			&sr.Rank,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, sr)
+	}
+	return results, rows.Err()
+}
+
+// ─── Verification ────────────────────────────────────────────────────────────
+
+// Verification outcomes accepted by VerifyObservation.
+const (
+	VerifyConfirmed = "confirmed"
+	VerifyStale     = "stale"
+)
+
+// VerifyParams records the outcome of checking a memory against reality.
+type VerifyParams struct {
+	Status   string   `json:"status"`             // "confirmed" or "stale"
+	Evidence string   `json:"evidence,omitempty"` // what was checked, e.g. "go.mod no longer requires X"
+	Paths    []string `json:"paths,omitempty"`    // files looked at
+}
+
+// VerifyObservation marks an observation confirmed or stale. Both stamp
+// verified_at; "stale" also sets stale_at, which pushes the memory below
+// fresh ones in search and context until it is confirmed again or its
+// content is rewritten. Verification is local metadata: it does not bump
+// updated_at and is not synced.
+func (s *Store) VerifyObservation(id int64, p VerifyParams) (*Observation, error) {
+	status := strings.ToLower(strings.TrimSpace(p.Status))
+	if status == "" {
+		status = VerifyConfirmed
+	}
+	if status != VerifyConfirmed && status != VerifyStale {
+		return nil, invalidf("verify: status must be %q or %q, got %q", VerifyConfirmed, VerifyStale, p.Status)
+	}
+
+	note := strings.TrimSpace(stripPrivateTags(p.Evidence))
+	var paths []string
+	for _, path := range p.Paths {
+		if path = NormalizeFilePath(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) > 0 {
+		checked := "Checked: " + strings.Join(paths, ", ")
+		if note == "" {
+			note = checked
+		} else {
+			note += "\n" + checked
+		}
+	}
+	note = truncate(note, 1000)
+
+	staleAt := "NULL"
+	if status == VerifyStale {
+		staleAt = "ifnull(stale_at, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))"
+	}
+	res, err := s.execHook(s.db,
+		`UPDATE observations
+		 SET verified_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+		     stale_at = `+staleAt+`,
+		     verification_note = ?
+		 WHERE id = ? AND deleted_at IS NULL`,
+		nullableString(note), id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("%w: #%d", ErrObservationNotFound, id)
+	}
+	return s.GetObservation(id)
+}
+
+// freshFirst stably moves stale results below fresh ones, keeping each
+// group's order.
+func freshFirst[T any](items []T, stale func(T) bool) {
+	slices.SortStableFunc(items, func(a, b T) int {
+		switch sa, sb := stale(a), stale(b); {
+		case sa == sb:
+			return 0
+		case sb:
+			return -1
+		default:
+			return 1
+		}
+	})
+}
+
+// ─── Enrichment ──────────────────────────────────────────────────────────────
+//
+// An enrichment worker (internal/enrich) asks an LLM for a better title,
+// topic key, and tags for each new observation. Its answers land in
+// enrichment_suggestions and never touch the observation until someone
+// accepts them, so agent-provided values are only replaced on review.
+
+// Enrichment suggestion states.
+const (
+	EnrichPending  = "pending"
+	EnrichAccepted = "accepted"
+	EnrichRejected = "rejected"
+	EnrichFailed   = "failed"
+)
+
+// EnrichmentSuggestion is an LLM proposal for one observation, next to the
+// observation's current values.
+type EnrichmentSuggestion struct {
+	ID            int64    `json:"id"`
+	ObservationID int64    `json:"observation_id"`
+	Title         string   `json:"title,omitempty"`
+	TopicKey      string   `json:"topic_key,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Model         string   `json:"model,omitempty"`
+	Status        string   `json:"status"`
+	Error         string   `json:"error,omitempty"`
+	CreatedAt     string   `json:"created_at"`
+	ReviewedAt    *string  `json:"reviewed_at,omitempty"`
+
+	CurrentTitle    string  `json:"current_title"`
+	CurrentTopicKey *string `json:"current_topic_key,omitempty"`
+	Project         *string `json:"project,omitempty"`
+}
+
+// EnrichmentParams records the outcome of enriching one observation. A
+// non-empty Error stores a failed attempt, so the worker does not retry
+// the same observation on every pass.
+type EnrichmentParams struct {
+	ObservationID int64
+	Title         string
+	TopicKey      string
+	Tags          []string
+	Model         string
+	Error         string
+}
+
+// EnrichmentCandidates returns live, non-quarantined observations that have
+// no suggestion yet, newest first.
+func (s *Store) EnrichmentCandidates(limit int) ([]Observation, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	return s.queryObservations(
+		`SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+		        o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at,
+		        o.refs, o.verified_at, o.stale_at, o.verification_note, o.source, o.metadata
+		 FROM observations o
+		 LEFT JOIN enrichment_suggestions e ON e.observation_id = o.id
+		 WHERE o.deleted_at IS NULL AND o.quarantine_reason IS NULL AND e.id IS NULL
+		 ORDER BY o.id DESC
+		 LIMIT ?`, limit)
+}
+
+// AddEnrichmentSuggestion stores a suggestion for an observation, replacing
+// an earlier one. Title and topic key are normalized like agent input;
+// suggestions that change nothing are stored as already rejected.
+func (s *Store) AddEnrichmentSuggestion(p EnrichmentParams) (int64, error) {
+	obs, err := s.GetObservation(p.ObservationID)
+	if err != nil {
+		return 0, err
+	}
+
+	title := strings.TrimSpace(stripPrivateTags(p.Title))
+	topicKey := normalizeTopicKey(p.TopicKey)
+	var tags []string
+	for _, tag := range p.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	status := EnrichPending
+	switch {
+	case p.Error != "":
+		status = EnrichFailed
+	case (title == "" || title == obs.Title) &&
+		(topicKey == "" || (obs.TopicKey != nil && topicKey == *obs.TopicKey)) && len(tags) == 0:
+		status = EnrichRejected
+	}
+
+	var tagsJSON *string
+	if len(tags) > 0 {
+		raw, _ := json.Marshal(tags)
+		encoded := string(raw)
+		tagsJSON = &encoded
+	}
+
+	if _, err := s.execHook(s.db,
+		`INSERT INTO enrichment_suggestions (observation_id, title, topic_key, tags, model, status, error, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		 ON CONFLICT(observation_id) DO UPDATE SET title = excluded.title, topic_key = excluded.topic_key,
+		     tags = excluded.tags, model = excluded.model, status = excluded.status, error = excluded.error,
+		     created_at = excluded.created_at, reviewed_at = NULL`,
+		p.ObservationID, nullableString(title), nullableString(topicKey), tagsJSON,
+		nullableString(p.Model), status, nullableString(truncate(p.Error, 500)),
+	); err != nil {
+		return 0, err
+	}
+	var id int64
+	err = s.db.QueryRow(`SELECT id FROM enrichment_suggestions WHERE observation_id = ?`, p.ObservationID).Scan(&id)
+	return id, err
+}
+
+// EnrichmentSuggestions lists suggestions in a state (pending when empty),
+// oldest first.
+func (s *Store) EnrichmentSuggestions(status string, limit int) ([]EnrichmentSuggestion, error) {
+	if status == "" {
+		status = EnrichPending
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	return s.queryEnrichment(`WHERE e.status = ? ORDER BY e.id ASC LIMIT ?`, status, limit)
+}
+
+// EnrichmentSuggestion returns one suggestion by ID.
+func (s *Store) EnrichmentSuggestion(id int64) (*EnrichmentSuggestion, error) {
+	items, err := s.queryEnrichment(`WHERE e.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("enrichment suggestion #%d not found", id)
+	}
+	return &items[0], nil
+}
+
+// AcceptEnrichment applies a pending suggestion's title and topic key to its
+// observation through UpdateObservation, so the change is revisioned and
+// synced like any edit. Tags stay on the suggestion.
+func (s *Store) AcceptEnrichment(id int64) (*Observation, error) {
+	sug, err := s.EnrichmentSuggestion(id)
+	if err != nil {
+		return nil, err
+	}
+	if sug.Status != EnrichPending {
+		return nil, fmt.Errorf("enrichment suggestion #%d is %s, not pending", id, sug.Status)
+	}
+
+	var update UpdateObservationParams
+	if sug.Title != "" {
+		update.Title = &sug.Title
+	}
+	if sug.TopicKey != "" {
+		update.TopicKey = &sug.TopicKey
+	}
+	obs, err := s.GetObservation(sug.ObservationID)
+	if err != nil {
+		return nil, err
+	}
+	if update.Title != nil || update.TopicKey != nil {
+		if obs, err = s.UpdateObservation(sug.ObservationID, update); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.reviewEnrichment(id, EnrichAccepted); err != nil {
+		return nil, err
+	}
+	return obs, nil
+}
+
+// RejectEnrichment discards a pending suggestion.
+func (s *Store) RejectEnrichment(id int64) error {
+	sug, err := s.EnrichmentSuggestion(id)
+	if err != nil {
+		return err
+	}
+	if sug.Status != EnrichPending {
+		return fmt.Errorf("enrichment suggestion #%d is %s, not pending", id, sug.Status)
+	}
+	return s.reviewEnrichment(id, EnrichRejected)
+}
+
+func (s *Store) reviewEnrichment(id int64, status string) error {
+	_, err := s.execHook(s.db,
+		`UPDATE enrichment_suggestions SET status = ?, reviewed_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') WHERE id = ?`,
+		status, id)
+	return err
+}
+
+func (s *Store) queryEnrichment(where string, args ...any) ([]EnrichmentSuggestion, error) {
+	rows, err := s.queryItHook(s.db,
+		`SELECT e.id, e.observation_id, ifnull(e.title, ''), ifnull(e.topic_key, ''), e.tags, ifnull(e.model, ''),
+		        e.status, ifnull(e.error, ''), e.created_at, e.reviewed_at, o.title, o.topic_key, o.project
+		 FROM enrichment_suggestions e
+		 JOIN observations o ON o.id = e.observation_id
+		 `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []EnrichmentSuggestion
+	for rows.Next() {
+		var e EnrichmentSuggestion
+		var tags *string
+		if err := rows.Scan(
+			&e.ID, &e.ObservationID, &e.Title, &e.TopicKey, &tags, &e.Model,
+			&e.Status, &e.Error, &e.CreatedAt, &e.ReviewedAt, &e.CurrentTitle, &e.CurrentTopicKey, &e.Project,
+		); err != nil {
+			return nil, err
+		}
+		if tags != nil {
+			_ = json.Unmarshal([]byte(*tags), &e.Tags)
+		}
+		results = append(results, e)
+	}
+	return results, rows.Err()
+}
+
+// ─── Translations ────────────────────────────────────────────────────────────
+//
+// The retrieval translator (internal/translate) caches every translation
+// here, keyed by the exact source text and target language, so reading the
+// same memory twice in another language costs one model call. Entries are
+// never invalidated: edited content hashes differently and simply misses.
+
+// translationKey hashes the exact source text. Unlike hashNormalized it
+// keeps case and whitespace, which a translation may depend on.
+func translationKey(text string) string {
+	h := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(h[:])
+}
+
+// CachedTranslation returns the stored translation of text into lang.
+func (s *Store) CachedTranslation(text, lang string) (string, bool, error) {
+	var translated string
+	err := s.db.QueryRow(`SELECT text FROM translations WHERE source_hash = ? AND lang = ?`,
+		translationKey(text), strings.ToLower(strings.TrimSpace(lang))).Scan(&translated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return translated, true, nil
+}
+
+// SaveTranslation caches the translation of text into lang, replacing an
+// earlier one. model records which model produced it.
+func (s *Store) SaveTranslation(text, lang, translated, model string) error {
+	_, err := s.execHook(s.db,
+		`INSERT INTO translations (source_hash, lang, text, model, created_at)
+		 VALUES (?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		 ON CONFLICT(source_hash, lang) DO UPDATE SET text = excluded.text, model = excluded.model,
+		     created_at = excluded.created_at`,
+		translationKey(text), strings.ToLower(strings.TrimSpace(lang)), translated, nullableString(model))
+	return err
+}
+
+// ─── Timeline ────────────────────────────────────────────────────────────────
+//
+// Timeline provides chronological context around a specific observation.
+// Given an observation ID, it returns N observations before and M after,
+// all within the same session. This is the "progressive disclosure" pattern
+// from claude-mem — agents first search, then use timeline to drill into
+// the chronological neighborhood of a result.
+
+func (s *Store) Timeline(observationID int64, before, after int) (*TimelineResult, error) {
+	if before <= 0 {
+		before = 5
+	}
+	if after <= 0 {
+		after = 5
+	}
+
+	// 1. Get the focus observation
+	focus, err := s.GetObservation(observationID)
+	if err != nil {
+		return nil, fmt.Errorf("timeline: observation #%d not found: %w", observationID, err)
+	}
+
+	// 2. Get session info
+	session, err := s.GetSession(focus.SessionID)
+	if err != nil {
+		// Session might be missing for manual-save observations — non-fatal
+		session = nil
+	}
+
+	// 3. Get observations BEFORE the focus (same session, older, chronological order)
+	beforeRows, err := s.queryItHook(s.db, `
+		SELECT id, session_id, type, title, content, tool_name, project,
+		       scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs
+		FROM observations
+		WHERE session_id = ? AND (ifnull(seq, 0), id) < (SELECT ifnull(seq, 0), id FROM observations WHERE id = ?) AND deleted_at IS NULL AND quarantine_reason IS NULL
+		ORDER BY seq DESC, id DESC
+		LIMIT ?
+	`, focus.SessionID, observationID, before)
+	if err != nil {
+		return nil, fmt.Errorf("timeline: before query: %w", err)
+	}
+	defer beforeRows.Close()
+
+	var beforeEntries []TimelineEntry
+	for beforeRows.Next() {
+		var e TimelineEntry
+		if err := beforeRows.Scan(
+			&e.ID, &e.SessionID, &e.Type, &e.Title, (*contentColumn)(&e.Content),
+			&e.ToolName, &e.Project, &e.Scope, &e.TopicKey, &e.RevisionCount, &e.DuplicateCount, &e.LastSeenAt,
+			&e.CreatedAt, &e.UpdatedAt, &e.DeletedAt, &e.Refs,
+		); err != nil {
+			return nil, err
+		}
+		beforeEntries = append(beforeEntries, e)
+	}
+	if err := beforeRows.Err(); err != nil {
+		return nil, err
+	}
+	// Reverse to get chronological order (oldest first)
+	for i, j := 0, len(beforeEntries)-1; i < j; i, j = i+1, j-1 {
+		beforeEntries[i], beforeEntries[j] = beforeEntries[j], beforeEntries[i]
+	}
+
+	// 4. Get observations AFTER the focus (same session, newer, chronological order)
+	afterRows, err := s.queryItHook(s.db, `
+		SELECT id, session_id, type, title, content, tool_name, project,
+		       scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs
+		FROM observations
+		WHERE session_id = ? AND (ifnull(seq, 0), id) > (SELECT ifnull(seq, 0), id FROM observations WHERE id = ?) AND deleted_at IS NULL AND quarantine_reason IS NULL
+		ORDER BY seq ASC, id ASC
+		LIMIT ?
+	`, focus.SessionID, observationID, after)
+	if err != nil {
+		return nil, fmt.Errorf("timeline: after query: %w", err)
+	}
+	defer afterRows.Close()
+
+	var afterEntries []TimelineEntry
+	for afterRows.Next() {
+		var e TimelineEntry
+		if err := afterRows.Scan(
+			&e.ID, &e.SessionID, &e.Type, &e.Title, (*contentColumn)(&e.Content),
+			&e.ToolName, &e.Project, &e.Scope, &e.TopicKey, &e.RevisionCount, &e.DuplicateCount, &e.LastSeenAt,
+			&e.CreatedAt, &e.UpdatedAt, &e.DeletedAt, &e.Refs,
+		); err != nil {
+			return nil, err
+		}
+		afterEntries = append(afterEntries, e)
+	}
+	if err := afterRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 5. Count total observations in the session for context
+	var totalInRange int
+	s.db.QueryRow(
+		"SELECT COUNT(*) FROM observations WHERE session_id = ? AND deleted_at IS NULL AND quarantine_reason IS NULL", focus.SessionID,
+	).Scan(&totalInRange)
+
+	// 6. Tool runs in the same window
+	from, to := focus.CreatedAt, focus.CreatedAt
+	if len(beforeEntries) > 0 {
+		from = beforeEntries[0].CreatedAt
+	}
+	if len(beforeEntries) < before {
+		from = ""
+	}
+	if len(afterEntries) > 0 {
+		to = afterEntries[len(afterEntries)-1].CreatedAt
+	}
+	if len(afterEntries) < after {
+		to = ""
+	}
+	toolRuns, err := s.toolRunsBetween(focus.SessionID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("timeline: tool runs: %w", err)
+	}
+
+	var chain []Session
+	if session != nil && session.ParentSessionID != nil {
+		if chain, err = s.SessionChain(session.ID); err != nil {
+			return nil, fmt.Errorf("timeline: session chain: %w", err)
+		}
+	}
+
+	return &TimelineResult{
+		Focus:        *focus,
+		Before:       beforeEntries,
+		After:        afterEntries,
+		SessionInfo:  session,
+		TotalInRange: totalInRange,
+		ToolRuns:     toolRuns,
+		SessionChain: chain,
+	}, nil
+}
+
+// ─── Search (FTS5) ───────────────────────────────────────────────────────────
+
+func (s *Store) Search(query string, opts SearchOptions) ([]SearchResult, error) {
+	page, err := s.SearchPage(query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
+
+// SearchPage is one page of search results.
+type SearchPage struct {
+	Results []SearchResult `json:"results"`
+	Offset  int            `json:"offset"`
+	// HasMore is set when another page exists at Offset + len(Results).
+	HasMore bool `json:"has_more"`
+}
+
+// SearchPage returns the results of Search starting at opts.Offset. The
+// page size is opts.Limit, capped at MaxSearchResults as in Search; deeper
+// pages re-run the query and skip what earlier pages returned, so results
+// saved between calls can shift the boundary by a row.
+func (s *Store) SearchPage(query string, opts SearchOptions) (*SearchPage, error) {
+	// Normalize project filter so "Engram" finds records stored as "engram"
+	opts.Project, _ = NormalizeProject(opts.Project)
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > s.cfg.MaxSearchResults {
+		limit = s.cfg.MaxSearchResults
+	}
+	offset := max(opts.Offset, 0)
+
+	// One extra row tells whether another page exists.
+	results, err := s.search(query, opts, offset+limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &SearchPage{Offset: offset}
+	results = results[min(offset, len(results)):]
+	if len(results) > limit {
+		results = results[:limit]
+		page.HasMore = true
+	}
+	page.Results = results
+	if opts.Explain {
+		if err := s.explainResults(query, results); err != nil {
+			return nil, fmt.Errorf("search: explain: %w", err)
+		}
+	}
+	return page, nil
+}
+
+// topicKeyMatchRank is the Rank of a result whose topic_key equals the
+// query; it sorts above every bm25 score.
+const topicKeyMatchRank = -1000
+
+// explainColumns are the observations_fts columns, in table order.
+var explainColumns = []string{"title", "content", "tool_name", "type", "project", "topic_key"}
+
+// explainResults sets Explain on each result. Matched columns come from
+// re-running the query against one FTS column at a time, restricted to the
+// result IDs; archived results are not in this database and report none.
+func (s *Store) explainResults(query string, results []SearchResult) error {
+	var ids []any
+	for _, r := range results {
+		if !r.Archived {
+			ids = append(ids, r.ID)
+		}
+	}
+	matched := make(map[int64][]string)
+	if strings.TrimSpace(query) != "" && len(ids) > 0 {
+		for _, col := range explainColumns {
+			hits, err := s.ftsColumnHits(query, col, ids)
+			if err != nil {
+				return err
+			}
+			for _, id := range hits {
+				matched[id] = append(matched[id], col)
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	for i := range results {
+		r := &results[i]
+		e := &SearchExplain{
+			Matched:        matched[r.ID],
+			Stale:          r.StaleAt != nil,
+			DuplicateCount: r.DuplicateCount,
+			RevisionCount:  r.RevisionCount,
+		}
+		if e.Matched == nil {
+			e.Matched = []string{}
+		}
+		if r.Rank == topicKeyMatchRank {
+			e.TopicKeyMatch = true
+			e.Modifiers = append(e.Modifiers, "exact topic_key match: ranked above full-text hits")
+		}
+		if e.Stale {
+			e.Modifiers = append(e.Modifiers, "stale: ranked after fresh results")
+		}
+		if r.Archived {
+			e.Modifiers = append(e.Modifiers, "archived: merged in from the archive by rank")
+		}
+		if created, err := ParseTimestamp(r.CreatedAt); err == nil {
+			e.AgeDays = max(int(now.Sub(created).Hours()/24), 0)
+		}
+		r.Explain = e
+	}
+	return nil
+}
+
+// ftsColumnHits returns which of ids match query within one FTS column. It
+// tries the same rewrites as queryFTS, without counting them.
+func (s *Store) ftsColumnHits(query, column string, ids []any) ([]int64, error) {
+	sqlQ := `SELECT rowid FROM observations_fts WHERE observations_fts MATCH ? AND rowid IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+	var err error
+	for _, rewrite := range []func(string) string{sanitizeFTS, quoteFTSTerms, conservativeFTS} {
+		expr := rewrite(query)
+		if expr == "" {
+			continue
+		}
+		var rows *sql.Rows
+		rows, err = s.queryHook(s.db, sqlQ, append([]any{"{" + column + "} : (" + expr + ")"}, ids...)...)
+		if err != nil {
+			if strings.Contains(err.Error(), "fts5:") {
+				continue
+			}
+			return nil, err
+		}
+		defer rows.Close()
+		var hits []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return nil, err
+			}
+			hits = append(hits, id)
+		}
+		return hits, rows.Err()
+	}
+	return nil, err
+}
+
+// search returns the first limit results in rank order.
+func (s *Store) search(query string, opts SearchOptions, limit int) ([]SearchResult, error) {
+	var directResults []SearchResult
+	if strings.Contains(query, "/") {
+		tkSQL := `
+			SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
+			       scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata
+			FROM observations
+			WHERE topic_key = ? AND deleted_at IS NULL
+		`
+		tkArgs := []any{query}
+
+		if !opts.IncludeQuarantined {
+			tkSQL += " AND quarantine_reason IS NULL"
+		}
+		if clause, typeArgs := typeFilterSQL("type", opts.Type, opts.Types); clause != "" {
+			tkSQL += clause
+			tkArgs = append(tkArgs, typeArgs...)
+		}
+		if opts.Project != "" {
+			clause, clauseArgs := projectFilterSQL("project", opts.Project)
+			tkSQL += clause
+			tkArgs = append(tkArgs, clauseArgs...)
+		}
+		if opts.Scope != "" {
+			tkSQL += " AND scope = ?"
+			tkArgs = append(tkArgs, normalizeScope(opts.Scope))
+		}
+		if clause, refArgs := refFilterSQL("refs", opts.Ref); clause != "" {
+			tkSQL += clause
+			tkArgs = append(tkArgs, refArgs...)
+		}
+		if clause, metaArgs := metadataFilterSQL("metadata", opts.Metadata); clause != "" {
+			tkSQL += clause
+			tkArgs = append(tkArgs, metaArgs...)
+		}
+		if clause, fileArgs := fileFilterSQL("id", opts.File); clause != "" {
+			tkSQL += clause
+			tkArgs = append(tkArgs, fileArgs...)
+		}
+		if clause, sourceArgs := sourceFilterSQL("source", opts.Source); clause != "" {
+			tkSQL += clause
+			tkArgs = append(tkArgs, sourceArgs...)
+		}
+		if clause, windowArgs := windowFilterSQL("", opts); clause != "" {
+			tkSQL += clause
+			tkArgs = append(tkArgs, windowArgs...)
+		}
+
+		tkSQL += " ORDER BY updated_at DESC LIMIT ?"
+		tkArgs = append(tkArgs, limit)
+
+		tkRows, err := s.queryItHook(s.db, tkSQL, tkArgs...)
+		if err == nil {
+			defer tkRows.Close()
+			for tkRows.Next() {
+				var sr SearchResult
+				if err := tkRows.Scan(
+					&sr.ID, &sr.SyncID, &sr.SessionID, &sr.Type, &sr.Title, (*contentColumn)(&sr.Content),
+					&sr.ToolName, &sr.Project, &sr.Scope, &sr.TopicKey, &sr.RevisionCount, &sr.DuplicateCount,
+					&sr.LastSeenAt, &sr.CreatedAt, &sr.UpdatedAt, &sr.DeletedAt, &sr.Refs, &sr.VerifiedAt, &sr.StaleAt, &sr.VerificationNote, &sr.Source, &sr.Metadata,
+				); err != nil {
+					break
+				}
+				sr.Rank = topicKeyMatchRank
+				directResults = append(directResults, sr)
+			}
+		}
+	}
+
+	if strings.TrimSpace(query) == "" && (opts.Ref != "" || opts.File != "" || opts.Source != "" || len(opts.Metadata) > 0 || opts.windowed()) {
+		return s.searchByLink(opts, limit)
+	}
+
+	sqlQ := `
+		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at, o.refs, o.verified_at, o.stale_at, o.verification_note, o.source, o.metadata,
+		       ` + s.cfg.SearchWeights.bm25() + ` AS score
+		FROM observations_fts fts
+		JOIN observations o ON o.id = fts.rowid
+		WHERE observations_fts MATCH ? AND o.deleted_at IS NULL
+	`
+	args := []any{query} // replaced by the FTS5 expression in queryFTS
+
+	if !opts.IncludeQuarantined {
+		sqlQ += " AND o.quarantine_reason IS NULL"
+	}
+
+	if clause, typeArgs := typeFilterSQL("o.type", opts.Type, opts.Types); clause != "" {
+		sqlQ += clause
+		args = append(args, typeArgs...)
+	}
+
+	if opts.Project != "" {
+		clause, clauseArgs := projectFilterSQL("o.project", opts.Project)
+		sqlQ += clause
+		args = append(args, clauseArgs...)
+	}
+
+	if opts.Scope != "" {
+		sqlQ += " AND o.scope = ?"
+		args = append(args, normalizeScope(opts.Scope))
+	}
+
+	if clause, refArgs := refFilterSQL("o.refs", opts.Ref); clause != "" {
+		sqlQ += clause
+		args = append(args, refArgs...)
+	}
+	if clause, metaArgs := metadataFilterSQL("o.metadata", opts.Metadata); clause != "" {
+		sqlQ += clause
+		args = append(args, metaArgs...)
+	}
+
+	if clause, fileArgs := fileFilterSQL("o.id", opts.File); clause != "" {
+		sqlQ += clause
+		args = append(args, fileArgs...)
+	}
+
+	if clause, sourceArgs := sourceFilterSQL("o.source", opts.Source); clause != "" {
+		sqlQ += clause
+		args = append(args, sourceArgs...)
+	}
+
+	if clause, windowArgs := windowFilterSQL("o.", opts); clause != "" {
+		sqlQ += clause
+		args = append(args, windowArgs...)
+	}
+
+	sqlQ += " ORDER BY (o.stale_at IS NOT NULL), score LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.queryFTS(query, sqlQ, args)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[int64]bool)
+	for _, dr := range directResults {
+		seen[dr.ID] = true
+	}
+
+	var results []SearchResult
+	results = append(results, directResults...)
+	for rows.Next() {
+		var sr SearchResult
+		if err := rows.Scan(
+			&sr.ID, &sr.SyncID, &sr.SessionID, &sr.Type, &sr.Title, (*contentColumn)(&sr.Content),
+			&sr.ToolName, &sr.Project, &sr.Scope, &sr.TopicKey, &sr.RevisionCount, &sr.DuplicateCount,
+			&sr.LastSeenAt, &sr.CreatedAt, &sr.UpdatedAt, &sr.DeletedAt, &sr.Refs, &sr.VerifiedAt, &sr.StaleAt, &sr.VerificationNote, &sr.Source, &sr.Metadata,
+			&sr.Rank,
+		); err != nil {
+			return nil, err
+		}
+		if !seen[sr.ID] {
+			results = append(results, sr)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if opts.IncludeArchive && opts.File == "" {
+		archived, err := s.searchArchive(query, opts, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range results {
+			seen[r.ID] = true
+		}
+		for _, ar := range archived {
+			if !seen[ar.ID] {
+				results = append(results, ar)
+			}
+		}
+		if len(archived) > 0 {
+			slices.SortStableFunc(results, func(a, b SearchResult) int { return cmp.Compare(a.Rank, b.Rank) })
+		}
+	}
+
+	freshFirst(results, func(r SearchResult) bool { return r.StaleAt != nil })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// SearchAllResult is one hit of SearchAll: an observation or a prompt,
+// told apart by Kind.
+type SearchAllResult struct {
+	Kind        string        `json:"kind"` // "observation" or "prompt"
+	Observation *SearchResult `json:"observation,omitempty"`
+	Prompt      *Prompt       `json:"prompt,omitempty"`
+	Rank        float64       `json:"rank"`
+}
+
+// SearchAll searches observations and user prompts in one call and merges
+// both lists by FTS5 rank, lower first. Prompts honor the project, session,
+// and time window options; they have no type, scope, refs, files, source,
+// or metadata, so setting any of those leaves prompts out. opts.Limit caps the
+// merged list, and opts.Offset is ignored.
+func (s *Store) SearchAll(query string, opts SearchOptions) ([]SearchAllResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, invalidf("search query is required")
+	}
+	opts.Project, _ = NormalizeProject(opts.Project)
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > s.cfg.MaxSearchResults {
+		limit = s.cfg.MaxSearchResults
+	}
+	opts.Limit, opts.Offset = limit, 0
+
+	observations, err := s.Search(query, opts)
+	if err != nil {
+		return nil, err
+	}
+	var prompts []SearchAllResult
+	if opts.Type == "" && len(opts.Types) == 0 && opts.Scope == "" && opts.Ref == "" && opts.File == "" && opts.Source == "" && len(opts.Metadata) == 0 {
+		if prompts, err = s.searchPrompts(query, opts, limit); err != nil {
+			return nil, err
+		}
+	}
+
+	// Both lists are already in rank order; merging keeps that order
+	// within each kind.
+	results := make([]SearchAllResult, 0, min(limit, len(observations)+len(prompts)))
+	for len(results) < limit && (len(observations) > 0 || len(prompts) > 0) {
+		if len(prompts) == 0 || (len(observations) > 0 && observations[0].Rank <= prompts[0].Rank) {
+			obs := observations[0]
+			results = append(results, SearchAllResult{Kind: "observation", Observation: &obs, Rank: obs.Rank})
+			observations = observations[1:]
+			continue
+		}
+		results = append(results, prompts[0])
+		prompts = prompts[1:]
+	}
+	return results, nil
+}
+
+// SearchCSVColumns are the columns WriteSearchCSV writes, in order.
+var SearchCSVColumns = []string{"id", "type", "title", "project", "scope", "created_at", "content"}
+
+// WriteSearchCSV writes results as a header row plus one row per result,
+// separated by comma: ',' for CSV, '\t' for TSV. Fields holding the
+// separator, quotes, or newlines are quoted, so multi-line content stays
+// in one cell.
+func WriteSearchCSV(w io.Writer, results []SearchResult, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(SearchCSVColumns); err != nil {
+		return err
+	}
+	for _, r := range results {
+		project := ""
+		if r.Project != nil {
+			project = *r.Project
+		}
+		record := []string{strconv.FormatInt(r.ID, 10), r.Type, r.Title, project, r.Scope, r.CreatedAt, r.Content}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// searchByLink lists observations linked to opts.Ref and/or opts.File, or
+// saved through opts.Source, carrying opts.Metadata, or in a session or
+// time window, most recently updated first. It backs Search when no query text is given.
+func (s *Store) searchByLink(opts SearchOptions, limit int) ([]SearchResult, error) {
+	clause, args := refFilterSQL("o.refs", opts.Ref)
+	fileClause, fileArgs := fileFilterSQL("o.id", opts.File)
+	args = append(args, fileArgs...)
+	query := `
+		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at, o.refs, o.verified_at, o.stale_at, o.verification_note, o.source, o.metadata
+		FROM observations o
+		WHERE o.deleted_at IS NULL` + clause + fileClause
+
+	if !opts.IncludeQuarantined {
+		query += " AND o.quarantine_reason IS NULL"
+	}
+	if clause, typeArgs := typeFilterSQL("o.type", opts.Type, opts.Types); clause != "" {
+		query += clause
+		args = append(args, typeArgs...)
+	}
+	if opts.Project != "" {
+		clause, clauseArgs := projectFilterSQL("o.project", opts.Project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	if opts.Scope != "" {
+		query += " AND o.scope = ?"
+		args = append(args, normalizeScope(opts.Scope))
+	}
+	if clause, sourceArgs := sourceFilterSQL("o.source", opts.Source); clause != "" {
+		query += clause
+		args = append(args, sourceArgs...)
+	}
+	if clause, metaArgs := metadataFilterSQL("o.metadata", opts.Metadata); clause != "" {
+		query += clause
+		args = append(args, metaArgs...)
+	}
+	if clause, windowArgs := windowFilterSQL("o.", opts); clause != "" {
+		query += clause
+		args = append(args, windowArgs...)
+	}
+	query += " ORDER BY (o.stale_at IS NOT NULL), o.updated_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	observations, err := s.queryObservations(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search by link: %w", err)
+	}
+	results := make([]SearchResult, len(observations))
+	for i, o := range observations {
+		results[i] = SearchResult{Observation: o}
+	}
+	return results, nil
+}
+
+// ─── Topics ──────────────────────────────────────────────────────────────────
+
+// TopicSummary describes one evolving topic: a topic_key within a project and
+// scope, plus the observation that currently holds it.
+type TopicSummary struct {
+	TopicKey         string  `json:"topic_key"`
+	Project          *string `json:"project,omitempty"`
+	Scope            string  `json:"scope"`
+	ObservationCount int     `json:"observation_count"` // >1 only for rows saved before upserts existed
+	LatestID         int64   `json:"latest_id"`
+	LatestType       string  `json:"latest_type"`
+	LatestTitle      string  `json:"latest_title"`
+	RevisionCount    int     `json:"revision_count"`
+	UpdatedAt        string  `json:"updated_at"`
+}
+
+// Topics lists the topic keys in use, one entry per topic_key + project +
+// scope, ordered by topic key. The latest_* fields describe the observation a
+// mem_save with that topic_key would upsert. Empty project or scope means no
+// filter.
+func (s *Store) Topics(project, scope string) ([]TopicSummary, error) {
+	project, _ = NormalizeProject(project)
+
+	filter := ""
+	args := []any{}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("project", project)
+		filter += clause
+		args = append(args, clauseArgs...)
+	}
+	if scope != "" {
+		filter += " AND scope = ?"
+		args = append(args, normalizeScope(scope))
+	}
+
+	query := `
+		SELECT topic_key, project, scope, cnt, id, type, title, revision_count, updated_at
+		FROM (
+			SELECT topic_key, project, scope, id, type, title, revision_count, updated_at,
+			       COUNT(*) OVER topic AS cnt,
+			       ROW_NUMBER() OVER (topic ORDER BY datetime(updated_at) DESC, datetime(created_at) DESC, id DESC) AS rn
+			FROM observations
+			WHERE topic_key IS NOT NULL AND topic_key != '' AND deleted_at IS NULL AND quarantine_reason IS NULL` + filter + `
+			WINDOW topic AS (PARTITION BY topic_key, ifnull(project, ''), scope)
+		)
+		WHERE rn = 1
+		ORDER BY topic_key, ifnull(project, ''), scope`
+
+	rows, err := s.queryItHook(s.db, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list topics: %w", err)
+	}
+	defer rows.Close()
+
+	var results []TopicSummary
+	for rows.Next() {
+		var t TopicSummary
+		if err := rows.Scan(&t.TopicKey, &t.Project, &t.Scope, &t.ObservationCount,
+			&t.LatestID, &t.LatestType, &t.LatestTitle, &t.RevisionCount, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, t)
+	}
+	return results, rows.Err()
+}
+
+// TopicRevision is one recorded change to a topic, read from the events
+// feed: the save that created it, each revision, and deletions.
+type TopicRevision struct {
+	ObservationID int64  `json:"observation_id"`
+	Kind          string `json:"kind"` // An observation Event* kind
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	At            string `json:"at"`
+}
+
+// TopicHistory returns the recorded changes to the observations holding
+// topicKey within project and scope, newest first. Changes made before the
+// events feed existed (schema version 8) are not recorded, so a topic can
+// list fewer entries than its revision count.
+func (s *Store) TopicHistory(topicKey, project, scope string) ([]TopicRevision, error) {
+	project, _ = NormalizeProject(project)
+	rows, err := s.queryItHook(s.db, `
+		SELECT e.observation_id, e.kind,
+		       ifnull(json_extract(e.data, '$.type'), ''), ifnull(json_extract(e.data, '$.title'), ''), e.created_at
+		FROM events e
+		JOIN observations o ON o.id = e.observation_id
+		WHERE o.topic_key = ? AND ifnull(o.project, '') = ? AND o.scope = ?
+		ORDER BY e.seq DESC`,
+		normalizeTopicKey(topicKey), project, normalizeScope(scope))
+	if err != nil {
+		return nil, fmt.Errorf("topic history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []TopicRevision
+	for rows.Next() {
+		var r TopicRevision
+		if err := rows.Scan(&r.ObservationID, &r.Kind, &r.Type, &r.Title, &r.At); err != nil {
+			return nil, fmt.Errorf("topic history: %w", err)
+		}
+		history = append(history, r)
+	}
+	return history, rows.Err()
+}
+
+// TopicMatch is a topic key suggested for a partial or misspelled query.
+type TopicMatch struct {
+	TopicSummary
+	// Score is 1 for an exact match and falls towards 0 for weaker ones.
+	Score float64 `json:"score"`
+	// MatchType is "exact", "prefix", "substring", or "fuzzy".
+	MatchType string `json:"match_type"`
+}
+
+// minTopicMatchScore drops suggestions that share too little with the query.
+const minTopicMatchScore = 0.3
+
+// SuggestTopics fuzzy-matches query against the topic keys in use, best
+// first, so a caller about to invent "architecture/auth-design" finds the
+// existing "architecture/auth-model". Each query word is matched against
+// the key's words (split on "/", "-", "_", ".") by equality, prefix, or a
+// small edit distance, and against the latest title's words at a lower
+// weight. Empty project or scope means no filter.
+func (s *Store) SuggestTopics(query, project, scope string, limit int) ([]TopicMatch, error) {
+	query = normalizeTopicKey(query)
+	if query == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	topics, err := s.Topics(project, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []TopicMatch
+	for _, t := range topics {
+		score, matchType := topicMatchScore(query, t)
+		if score >= minTopicMatchScore {
+			matches = append(matches, TopicMatch{TopicSummary: t, Score: math.Round(score*100) / 100, MatchType: matchType})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b TopicMatch) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.RevisionCount, a.RevisionCount); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.TopicKey, b.TopicKey)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+func topicMatchScore(query string, t TopicSummary) (float64, string) {
+	key := t.TopicKey
+	switch {
+	case key == query:
+		return 1, "exact"
+	case strings.HasPrefix(key, query):
+		return 0.9, "prefix"
+	case strings.Contains(key, query):
+		return 0.8, "substring"
+	}
+
+	queryWords := topicWords(query)
+	if len(queryWords) == 0 {
+		return 0, ""
+	}
+	keyWords := topicWords(key)
+	titleWords := topicWords(strings.ToLower(t.LatestTitle))
+	total := 0.0
+	for _, q := range queryWords {
+		best := 0.0
+		for _, k := range keyWords {
+			best = max(best, wordMatchScore(q, k))
+		}
+		for _, w := range titleWords {
+			best = max(best, wordMatchScore(q, w)/2)
+		}
+		total += best
+	}
+	// Below the substring tier, however many words match.
+	return 0.75 * total / float64(len(queryWords)), "fuzzy"
+}
+
+// topicWords splits a topic key or title into lowercase words.
+func topicWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func wordMatchScore(q, w string) float64 {
+	switch {
+	case q == w:
+		return 1
+	case len(q) >= 3 && len(w) >= 3 && (strings.HasPrefix(w, q) || strings.HasPrefix(q, w)):
+		return 0.8
+	case len(q) >= 4 && project.Levenshtein(q, w) <= max(1, len(q)/4):
+		return 0.6
+	}
+	return 0
+}
+
+// reuseTopicMatchScore is the score from which PreferExistingTopic trusts a
+// match to be the same topic: plurals and typos pass, while keys sharing
+// only their family and one word ("bug/login-crash" and "bug/logout-crash")
+// do not.
+const reuseTopicMatchScore = 0.6
+
+// PreferExistingTopic picks, among matches for a freshly suggested key, the
+// existing topic a save should reuse instead: the best match that scores at
+// least reuseTopicMatchScore and lives in project itself, since a topic_key
+// only upserts within its own project. matches must be sorted best first,
+// as SuggestTopics returns them.
+func PreferExistingTopic(project string, matches []TopicMatch) (TopicMatch, bool) {
+	project, _ = NormalizeProject(project)
+	for _, m := range matches {
+		if m.Score < reuseTopicMatchScore {
+			break
+		}
+		if project == "" || derefString(m.Project) == project {
+			return m, true
+		}
+	}
+	return TopicMatch{}, false
+}
+
+// ─── Stats ───────────────────────────────────────────────────────────────────
+
+// FormatBytes renders a byte count for people, in binary units: "512 B",
+// "1.5 KB", "5.0 MB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func (s *Store) Stats() (*Stats, error) {
+	stats := &Stats{}
+
+	s.db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&stats.TotalSessions)
+	s.db.QueryRow("SELECT COUNT(*) FROM observations WHERE deleted_at IS NULL").Scan(&stats.TotalObservations)
+	s.db.QueryRow("SELECT COUNT(*) FROM user_prompts").Scan(&stats.TotalPrompts)
+
+	rows, err := s.queryItHook(s.db, "SELECT project FROM observations WHERE project IS NOT NULL AND deleted_at IS NULL GROUP BY project ORDER BY MAX(seq) DESC")
+	if err != nil {
+		return stats, nil
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err == nil {
+			stats.Projects = append(stats.Projects, p)
+		}
+	}
+
+	s.fillHealthStats(stats)
+	stats.Cache = s.cache.snapshot()
+	stats.FTS = FTSStats{Fallbacks: s.ftsFallbacks.Load(), Failures: s.ftsFailures.Load()}
+	return stats, nil
+}
+
+// fillHealthStats adds storage and health details to stats. Every probe is
+// best-effort: a failing query or missing file leaves its field zeroed.
+func (s *Store) fillHealthStats(stats *Stats) {
+	s.db.QueryRow(
+		"SELECT MIN(created_at), MAX(created_at), ifnull(SUM(duplicate_count - 1), 0) FROM observations WHERE deleted_at IS NULL",
+	).Scan(&stats.OldestObservationAt, &stats.NewestObservationAt, &stats.DuplicatesAvoided)
+	s.db.QueryRow(
+		"SELECT COUNT(*) FROM observations WHERE deleted_at IS NULL AND quarantine_reason IS NOT NULL",
+	).Scan(&stats.Quarantined)
+	stats.Compression = s.compressionStats()
+
+	if rows, err := s.queryItHook(s.db, "SELECT type, COUNT(*) FROM observations WHERE deleted_at IS NULL GROUP BY type"); err == nil {
+		for rows.Next() {
+			var typ string
+			var n int
+			if err := rows.Scan(&typ, &n); err == nil {
+				if stats.ObservationsByType == nil {
+					stats.ObservationsByType = make(map[string]int)
+				}
+				stats.ObservationsByType[typ] = n
+			}
+		}
+		rows.Close()
+	}
+
+	// dbstat is compiled into modernc.org/sqlite; page sizes of the FTS
+	// shadow tables give the on-disk index footprint.
+	s.db.QueryRow(
+		"SELECT ifnull(SUM(pgsize), 0) FROM dbstat WHERE name LIKE 'observations_fts%' OR name LIKE 'prompts_fts%'",
+	).Scan(&stats.FTSSizeBytes)
+
+	dbPath := s.cfg.DatabasePath()
+	if info, err := os.Stat(dbPath); err == nil && !s.cfg.InMemory() {
+		stats.DBSizeBytes = info.Size()
+	}
+	if info, err := os.Stat(dbPath + "-wal"); err == nil {
+		stats.WALSizeBytes = info.Size()
+	}
+
+	for _, project := range stats.Projects {
+		if s.quotaFor(project) == (Quota{}) {
+			continue
+		}
+		if usage, err := s.QuotaUsage(project); err == nil {
+			stats.Quotas = append(stats.Quotas, *usage)
+		}
+	}
+}
+
+// ─── Dedupe Report ───────────────────────────────────────────────────────────
+
+// DedupeReportOptions filters DedupeReport.
+type DedupeReportOptions struct {
+	// Project limits the report to a project and its sub-projects.
+	Project string
+	// Limit caps Clusters (default 10).
+	Limit int
+}
+
+// DedupeReport shows how much deduplication is absorbing. Every stored
+// observation accounts for duplicate_count saves: the insert plus each
+// later save of the same content that bumped the counter instead of adding
+// a row. SavedBytes estimates the title and content those saves did not
+// store.
+type DedupeReport struct {
+	Saves      int           `json:"saves"`
+	Inserted   int           `json:"inserted"`
+	Deduped    int           `json:"deduped"`
+	SavedBytes int64         `json:"saved_bytes"`
+	Groups     []DedupeGroup `json:"groups"`
+	// Clusters are the most repeated contents, largest first.
+	Clusters []DedupeCluster `json:"clusters"`
+}
+
+// DedupeGroup is the dedupe tally of one project and observation type.
+type DedupeGroup struct {
+	Project    string `json:"project"`
+	Type       string `json:"type"`
+	Saves      int    `json:"saves"`
+	Inserted   int    `json:"inserted"`
+	Deduped    int    `json:"deduped"`
+	SavedBytes int64  `json:"saved_bytes"`
+}
+
+// Rate is the share of saves that were deduped.
+func (g DedupeGroup) Rate() float64 {
+	if g.Saves == 0 {
+		return 0
+	}
+	return float64(g.Deduped) / float64(g.Saves)
+}
+
+// DedupeCluster is one normalized content saved more than once. Rows above
+// one are copies stored anyway, typically because the repeats fell outside
+// the dedupe window or used another title; RedundantBytes is what they take.
+type DedupeCluster struct {
+	Hash           string `json:"hash"`
+	Project        string `json:"project"`
+	Type           string `json:"type"`
+	Title          string `json:"title"`
+	LatestID       int64  `json:"latest_id"`
+	Saves          int    `json:"saves"`
+	Rows           int    `json:"rows"`
+	RedundantBytes int64  `json:"redundant_bytes"`
+}
+
+// DedupeReport aggregates duplicate_count and normalized_hash over the
+// observations that are not deleted.
+func (s *Store) DedupeReport(opts DedupeReportOptions) (*DedupeReport, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+	where := "WHERE deleted_at IS NULL"
+	var args []any
+	if opts.Project != "" {
+		clause, projectArgs := projectFilterSQL("project", opts.Project)
+		where += clause
+		args = append(args, projectArgs...)
+	}
+	const size = "(length(CAST(title AS BLOB)) + length(CAST(content AS BLOB)))"
+
+	report := &DedupeReport{Groups: []DedupeGroup{}, Clusters: []DedupeCluster{}}
+	rows, err := s.queryItHook(s.db,
+		`SELECT ifnull(project, ''), type, SUM(duplicate_count), COUNT(*),
+		        ifnull(SUM((duplicate_count - 1) * `+size+`), 0)
+		 FROM observations `+where+`
+		 GROUP BY 1, 2
+		 ORDER BY SUM(duplicate_count) - COUNT(*) DESC, 1, 2`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var g DedupeGroup
+		if err := rows.Scan(&g.Project, &g.Type, &g.Saves, &g.Inserted, &g.SavedBytes); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		g.Deduped = g.Saves - g.Inserted
+		report.Groups = append(report.Groups, g)
+		report.Saves += g.Saves
+		report.Inserted += g.Inserted
+		report.Deduped += g.Deduped
+		report.SavedBytes += g.SavedBytes
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.queryItHook(s.db,
+		`SELECT normalized_hash, ifnull(project, ''), type, MAX(id), SUM(duplicate_count), COUNT(*),
+		        ifnull(SUM(`+size+`) - MAX(`+size+`), 0)
+		 FROM observations `+where+` AND normalized_hash IS NOT NULL
+		 GROUP BY 1, 2, 3
+		 HAVING SUM(duplicate_count) > 1
+		 ORDER BY 5 DESC, 6 DESC, 4 DESC
+		 LIMIT ?`, append(args, opts.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c DedupeCluster
+		if err := rows.Scan(&c.Hash, &c.Project, &c.Type, &c.LatestID, &c.Saves, &c.Rows, &c.RedundantBytes); err != nil {
+			return nil, err
+		}
+		report.Clusters = append(report.Clusters, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range report.Clusters {
+		if err := s.db.QueryRow("SELECT title FROM observations WHERE id = ?", report.Clusters[i].LatestID).Scan(&report.Clusters[i].Title); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// ─── Quotas ──────────────────────────────────────────────────────────────────
+
+// QuotaWarnRatio is the share of a quota at which usage counts as near the
+// limit and stats start suggesting a prune.
+const QuotaWarnRatio = 0.8
+
+// Quota caps how much one project may store. Zero fields are unlimited.
+// Bytes are the title and content of live (not deleted) observations,
+// quarantined captures included.
+type Quota struct {
+	MaxObservations int   `json:"max_observations,omitempty"`
+	MaxBytes        int64 `json:"max_bytes,omitempty"`
+}
+
+// QuotaUsage is how much of its quota a project uses.
+type QuotaUsage struct {
+	Project      string `json:"project"`
+	Observations int    `json:"observations"`
+	Bytes        int64  `json:"bytes"`
+	Quota        Quota  `json:"quota"`
+	// LargestType is the observation type taking the most bytes, the first
+	// candidate to prune.
+	LargestType      string `json:"largest_type,omitempty"`
+	LargestTypeCount int    `json:"largest_type_count,omitempty"`
+	LargestTypeBytes int64  `json:"largest_type_bytes,omitempty"`
+	// NearLimit is set once usage reaches QuotaWarnRatio of a limit.
+	NearLimit bool `json:"near_limit"`
+}
+
+// Ratio returns the highest used share of any configured limit.
+func (u QuotaUsage) Ratio() float64 {
+	ratio := 0.0
+	if u.Quota.MaxObservations > 0 {
+		ratio = max(ratio, float64(u.Observations)/float64(u.Quota.MaxObservations))
+	}
+	if u.Quota.MaxBytes > 0 {
+		ratio = max(ratio, float64(u.Bytes)/float64(u.Quota.MaxBytes))
+	}
+	return ratio
+}
+
+// quotaFor returns the quota that applies to a normalized project name.
+func (s *Store) quotaFor(project string) Quota {
+	limits := s.limits()
+	if q, ok := limits.Quotas[project]; ok {
+		return q
+	}
+	return limits.DefaultQuota
+}
+
+// SetLimits swaps the settings that are safe to change on an open store —
+// dedupe windows, quotas, and the working memory TTL — for the ones in cfg.
+// The rest of cfg is ignored. `engram serve` calls it on a config reload.
+func (s *Store) SetLimits(cfg Config) {
+	s.limitsMu.Lock()
+	defer s.limitsMu.Unlock()
+	s.cfg.DedupeWindow = cfg.DedupeWindow
+	s.cfg.DedupeByType = cfg.DedupeByType
+	s.cfg.DefaultQuota = cfg.DefaultQuota
+	s.cfg.Quotas = cfg.Quotas
+	s.cfg.ScratchTTL = cfg.ScratchTTL
+}
+
+// limits returns a snapshot of the config for reading the fields SetLimits
+// may swap.
+func (s *Store) limits() Config {
+	s.limitsMu.RLock()
+	defer s.limitsMu.RUnlock()
+	return s.cfg
+}
+
+// QuotaUsage reports how much of its quota a project uses. Projects without
+// a quota report a zero Quota.
+func (s *Store) QuotaUsage(project string) (*QuotaUsage, error) {
+	project, _ = NormalizeProject(project)
+	usage := &QuotaUsage{Project: project, Quota: s.quotaFor(project)}
+
+	rows, err := s.queryItHook(s.db,
+		`SELECT type, COUNT(*), ifnull(SUM(length(CAST(title AS BLOB)) + length(CAST(content AS BLOB))), 0)
+		 FROM observations
+		 WHERE ifnull(project, '') = ? AND deleted_at IS NULL
+		 GROUP BY type`, project)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var typ string
+		var count int
+		var size int64
+		if err := rows.Scan(&typ, &count, &size); err != nil {
+			return nil, err
+		}
+		usage.Observations += count
+		usage.Bytes += size
+		if size > usage.LargestTypeBytes || (size == usage.LargestTypeBytes && typ < usage.LargestType) {
+			usage.LargestType, usage.LargestTypeCount, usage.LargestTypeBytes = typ, count, size
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	usage.NearLimit = usage.Quota != (Quota{}) && usage.Ratio() >= QuotaWarnRatio
+	return usage, nil
+}
+
+type rowQueryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// checkQuota returns ErrQuotaExceeded when adding one observation of
+// addBytes to project would break its quota.
+func (s *Store) checkQuota(db rowQueryer, project string, addBytes int64) error {
+	quota := s.quotaFor(project)
+	if quota == (Quota{}) {
+		return nil
+	}
+	var count int
+	var size int64
+	if err := db.QueryRow(
+		`SELECT COUNT(*), ifnull(SUM(length(CAST(title AS BLOB)) + length(CAST(content AS BLOB))), 0)
+		 FROM observations
+		 WHERE ifnull(project, '') = ? AND deleted_at IS NULL`, project,
+	).Scan(&count, &size); err != nil {
+		return err
+	}
+	name := project
+	if name == "" {
+		name = "(no project)"
+	}
+	if quota.MaxObservations > 0 && count+1 > quota.MaxObservations {
+		return fmt.Errorf("%w: %s already has %d of %d observations; delete old ones or raise the quota", ErrQuotaExceeded, name, count, quota.MaxObservations)
+	}
+	if quota.MaxBytes > 0 && size+addBytes > quota.MaxBytes {
+		return fmt.Errorf("%w: %s uses %d of %d bytes and this observation needs %d more; delete old ones or raise the quota", ErrQuotaExceeded, name, size, quota.MaxBytes, addBytes)
+	}
+	return nil
+}
+
+// ─── Health ──────────────────────────────────────────────────────────────────
+
+// Health is a cheap liveness report for /health, /ready, and engram status.
+type Health struct {
+	DBReachable  bool    `json:"db_reachable"`
+	JournalMode  string  `json:"journal_mode,omitempty"`
+	WALOK        bool    `json:"wal_ok"`
+	WALSizeBytes int64   `json:"wal_size_bytes"`
+	LastWriteAt  *string `json:"last_write_at,omitempty"` // newest session, observation, or prompt change
+	Error        string  `json:"error,omitempty"`
+}
+
+// Ready reports whether the store can serve reads and writes.
+func (h Health) Ready() bool {
+	return h.DBReachable && h.WALOK
+}
+
+// Health pings the database, confirms it is still in WAL mode, and reports
+// the most recent write. It never returns an error: failures are described
+// in the report so callers can always render it.
+func (s *Store) Health() Health {
+	var h Health
+	if err := s.db.QueryRow("PRAGMA journal_mode").Scan(&h.JournalMode); err != nil {
+		h.Error = err.Error()
+		return h
+	}
+	h.DBReachable = true
+	h.WALOK = strings.EqualFold(h.JournalMode, "wal")
+	if s.cfg.InMemory() {
+		// In-memory databases cannot use WAL; their journal is always memory.
+		h.WALOK = strings.EqualFold(h.JournalMode, "memory")
+	}
+	if !h.WALOK {
+		h.Error = fmt.Sprintf("journal_mode is %q, expected wal", h.JournalMode)
+	}
+
+	if info, err := os.Stat(s.cfg.DatabasePath() + "-wal"); err == nil && !s.cfg.InMemory() {
+		h.WALSizeBytes = info.Size()
+	}
+
+	var lastWrite sql.NullString
+	if err := s.db.QueryRow(
+		`SELECT MAX(ts) FROM (
+			SELECT MAX(MAX(updated_at), ifnull(MAX(deleted_at), '')) AS ts FROM observations
+			UNION ALL SELECT MAX(created_at) FROM user_prompts
+			UNION ALL SELECT MAX(MAX(started_at), ifnull(MAX(ended_at), '')) FROM sessions
+		)`,
+	).Scan(&lastWrite); err == nil && lastWrite.Valid && lastWrite.String != "" {
+		h.LastWriteAt = &lastWrite.String
+	}
+	return h
+}
+
+// ─── Activity ────────────────────────────────────────────────────────────────
+
+// DefaultActivityDays is the histogram range used when none is given: 26
+// weeks, what the TUI activity calendar shows.
+const DefaultActivityDays = 182
+
+// maxActivityDays caps ActivityHistogram at two years.
+const maxActivityDays = 730
+
+// ActivityDay is the number of observations created on one UTC day.
+type ActivityDay struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Count int    `json:"count"`
+}
+
+// ActivityHistogram counts observations per day for the last days days,
+// ending today (UTC). Every day in the range is present, oldest first, so
+// callers can lay it out as a calendar. An empty project counts all
+// projects. Deleted and quarantined observations are not counted.
+func (s *Store) ActivityHistogram(project string, days int) ([]ActivityDay, error) {
+	if days <= 0 {
+		days = DefaultActivityDays
+	}
+	days = min(days, maxActivityDays)
+	project, _ = NormalizeProject(project)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -(days - 1))
+
+	query := `SELECT date(created_at), COUNT(*) FROM observations
+		WHERE deleted_at IS NULL AND quarantine_reason IS NULL AND created_at >= ?`
+	args := []any{start.Format("2006-01-02")}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("project", project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	query += " GROUP BY date(created_at)"
+
+	rows, err := s.queryItHook(s.db, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var day string
+		var n int
+		if err := rows.Scan(&day, &n); err != nil {
+			return nil, err
+		}
+		counts[day] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	histogram := make([]ActivityDay, days)
+	for i := range histogram {
+		day := start.AddDate(0, 0, i).Format("2006-01-02")
+		histogram[i] = ActivityDay{Date: day, Count: counts[day]}
+	}
+	return histogram, nil
+}
+
+// DayObservations returns the observations created on one UTC day
+// (YYYY-MM-DD), oldest first.
+func (s *Store) DayObservations(project, day string, limit int) ([]Observation, error) {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return nil, invalidf("invalid day %q, want YYYY-MM-DD", day)
+	}
+	if limit <= 0 {
+		limit = 200
+	}
+	project, _ = NormalizeProject(project)
+
+	query := `
+		SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
+		       scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata
+		FROM observations
+		WHERE date(created_at) = ? AND deleted_at IS NULL AND quarantine_reason IS NULL
+	`
+	args := []any{day}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("project", project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	query += " ORDER BY seq ASC, id ASC LIMIT ?"
+	args = append(args, limit)
+	return s.queryObservations(query, args...)
+}
+
+// ─── Context Formatting ─────────────────────────────────────────────────────
+
+// ContextOptions tunes FormatContextWith.
+type ContextOptions struct {
+	// IncludeParents adds recent decisions and architecture notes saved on
+	// the parent projects of a hierarchical project ("platform" for
+	// "platform/api").
+	IncludeParents bool
+	// Translate, when set, rewrites observation titles and contents and
+	// session summaries before they are formatted (see internal/translate).
+	Translate func(string) string
+	// ResurfaceCompaction opens the context with the latest session summary
+	// when a compaction is pending for the project, and clears it (see
+	// RecordCompaction). mem_context sets it; other readers leave the
+	// event for the agent.
+	ResurfaceCompaction bool
+	// ReadOnly shows a pending compaction without clearing it, so building
+	// the context writes nothing. Read-only MCP servers set it.
+	ReadOnly bool
+	// IncludeAll ignores Config.ContextExclude, for the reader who asked
+	// for every observation.
+	IncludeAll bool
+}
+
+// ContextExclusion leaves noisy observations out of RecentObservations and
+// FormatContext. An observation matching any rule is skipped.
+type ContextExclusion struct {
+	Types     []string // observation types, such as "file_read"
+	ToolNames []string // tool_name values, such as "Grep"
+	// MinContentLength skips observations with shorter content, in
+	// characters. Zero keeps every length.
+	MinContentLength int
+}
+
+// filterSQL returns the " AND ..." clauses that skip the observations of
+// alias o matching e.
+func (e ContextExclusion) filterSQL() (string, []any) {
+	var clause string
+	var args []any
+	if len(e.Types) > 0 {
+		clause += " AND o.type NOT IN (" + placeholders(len(e.Types)) + ")"
+		for _, t := range e.Types {
+			args = append(args, t)
+		}
+	}
+	if len(e.ToolNames) > 0 {
+		clause += " AND coalesce(o.tool_name, '') NOT IN (" + placeholders(len(e.ToolNames)) + ")"
+		for _, name := range e.ToolNames {
+			args = append(args, name)
+		}
+	}
+	if e.MinContentLength > 0 {
+		clause += " AND length(engram_inflate(o.content)) >= ?"
+		args = append(args, e.MinContentLength)
+	}
+	return clause, args
+}
+
+// contextExclusion returns the exclusion rules opts reads with.
+func (s *Store) contextExclusion(opts ContextOptions) ContextExclusion {
+	if opts.IncludeAll {
+		return ContextExclusion{}
+	}
+	return s.cfg.ContextExclude
+}
+
+// ContextGroup is one observation section of FormatContext: the newest
+// Limit observations of Types under a "### Title" heading. A group without
+// Types collects every type no other group lists, such as a trailing
+// "Recent Activity". Limit defaults to MaxContextResults.
+type ContextGroup struct {
+	Title string
+	Types []string
+	Limit int
+}
+
+// contextGroupObservations is one formatted group of FormatContext.
+type contextGroupObservations struct {
+	title        string
+	observations []Observation
+}
+
+// contextObservations loads the observation groups of FormatContext:
+// Config.ContextGroups when set, else one "Recent Observations" list.
+func (s *Store) contextObservations(project, scope string, opts ContextOptions) ([]contextGroupObservations, error) {
+	if len(s.cfg.ContextGroups) == 0 {
+		observations, err := s.RecentObservationsWith(project, scope, s.cfg.MaxContextResults, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []contextGroupObservations{{title: "Recent Observations", observations: observations}}, nil
+	}
+
+	var listed []string
+	for _, g := range s.cfg.ContextGroups {
+		listed = append(listed, g.Types...)
+	}
+	groups := make([]contextGroupObservations, 0, len(s.cfg.ContextGroups))
+	for _, g := range s.cfg.ContextGroups {
+		limit := g.Limit
+		if limit <= 0 {
+			limit = s.cfg.MaxContextResults
+		}
+		types, exclude := g.Types, []string(nil)
+		if len(types) == 0 {
+			exclude = listed
+		}
+		key := scope + "|" + strings.Join(types, ",") + "|" + strings.Join(exclude, ",") + "|" + strconv.Itoa(limit)
+		if opts.IncludeAll {
+			key += "|all"
+		}
+		observations, err := cachedQuery(s.cache, cacheObservations, project, key, func() ([]Observation, error) {
+			return s.recentObservationsOfTypes(project, scope, types, exclude, s.contextExclusion(opts), limit)
+		})
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, contextGroupObservations{title: g.Title, observations: observations})
+	}
+	return groups, nil
+}
+
+// recentObservationsOfTypes is recentObservations narrowed to types, or to
+// every type except exclude, skipping observations matching rules.
+func (s *Store) recentObservationsOfTypes(project, scope string, types, exclude []string, rules ContextExclusion, limit int) ([]Observation, error) {
+	query := `
+		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at, o.refs, o.verified_at, o.stale_at, o.verification_note, o.source, o.metadata
+		FROM observations o
+		WHERE o.deleted_at IS NULL AND o.quarantine_reason IS NULL
+	`
+	args := []any{}
+	if clause, typeArgs := typeFilterSQL("o.type", "", types); clause != "" {
+		query += clause
+		args = append(args, typeArgs...)
+	}
+	if len(exclude) > 0 {
+		query += " AND o.type NOT IN (" + placeholders(len(exclude)) + ")"
+		for _, t := range exclude {
+			args = append(args, t)
+		}
+	}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("o.project", project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	if scope != "" {
+		query += " AND o.scope = ?"
+		args = append(args, normalizeScope(scope))
+	}
+	clause, ruleArgs := rules.filterSQL()
+	query += clause
+	args = append(args, ruleArgs...)
+	query += " ORDER BY o.seq DESC, o.id DESC LIMIT ?"
+	args = append(args, limit)
+	return s.queryObservations(query, args...)
+}
+
+// parentContextTypes are the observation types carried down from parent
+// projects.
+var parentContextTypes = []string{"decision", "architecture"}
+
+func (s *Store) FormatContext(project, scope string) (string, error) {
+	return s.FormatContextWith(project, scope, ContextOptions{})
+}
+
+// FormatContextWith is FormatContext with options.
+func (s *Store) FormatContextWith(project, scope string, opts ContextOptions) (string, error) {
+	blocks, err := s.contextBlocks(project, scope, opts, false)
+	if err != nil || len(blocks) == 0 {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(contextHeading)
+	for _, block := range blocks {
+		b.WriteString(block.text)
+	}
+	return b.String(), nil
+}
+
+// contextHeading opens every FormatContext block.
+const contextHeading = "## Memory from Previous Sessions\n\n"
+
+// contextBlock is one rendered section of FormatContext.
+type contextBlock struct {
+	title string
+	items int
+	text  string
+}
+
+// contextBlocks renders the sections of FormatContextWith in order, leaving
+// out empty ones. It returns nothing when there is no memory to show. With
+// dryRun a pending compaction is rendered but not cleared.
+func (s *Store) contextBlocks(project, scope string, opts ContextOptions, dryRun bool) ([]contextBlock, error) {
+	project, _ = NormalizeProject(project)
+	sessions, err := s.RecentSessions(project, 5)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.contextObservations(project, scope, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	prompts, err := s.RecentPrompts(project, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	var parents []Observation
+	if opts.IncludeParents {
+		if parents, err = s.parentDecisions(project, scope, s.contextExclusion(opts), s.cfg.MaxContextResults); err != nil {
+			return nil, err
+		}
+	}
+
+	empty := len(sessions) == 0 && len(prompts) == 0 && len(parents) == 0
+	for _, g := range groups {
+		empty = empty && len(g.observations) == 0
+	}
+	if empty {
+		return nil, nil
+	}
+
+	notes, err := s.continuationNotes(sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Translate != nil {
+		sessions = slices.Clone(sessions)
+		for i := range sessions {
+			if sessions[i].Summary != nil {
+				summary := opts.Translate(*sessions[i].Summary)
+				sessions[i].Summary = &summary
+			}
+		}
+		for i := range groups {
+			groups[i].observations = translateObservations(groups[i].observations, opts.Translate)
+		}
+		parents = translateObservations(parents, opts.Translate)
+	}
+
+	var blocks []contextBlock
+	add := func(title string, items int, write func(*strings.Builder)) {
+		var b strings.Builder
+		write(&b)
+		if b.Len() > 0 {
+			blocks = append(blocks, contextBlock{title: title, items: items, text: b.String()})
+		}
+	}
+	if opts.ResurfaceCompaction {
+		var resurfaceErr error
+		add("Resumed After Compaction", 1, func(b *strings.Builder) {
+			resurfaceErr = s.resurfaceCompaction(b, project, !dryRun && !opts.ReadOnly)
+		})
+		if resurfaceErr != nil {
+			return nil, resurfaceErr
+		}
+	}
+	add("Recent Sessions", len(sessions), func(b *strings.Builder) { writeContextSessions(b, sessions, notes, s.FormatTime) })
+	add("Recent User Prompts", len(prompts), func(b *strings.Builder) { writeContextPrompts(b, prompts, s.FormatTime) })
+	for _, g := range groups {
+		add(g.title, len(g.observations), func(b *strings.Builder) { writeContextObservations(b, g.title, g.observations) })
+	}
+	add("Parent Project Decisions", len(parents), func(b *strings.Builder) { writeContextObservations(b, "Parent Project Decisions", parents) })
+	return blocks, nil
+}
+
+// ContextEstimate is the size of the block FormatContextWith would return,
+// per section, so an agent can decide whether to load it.
+type ContextEstimate struct {
+	Project  string                   `json:"project,omitempty"`
+	Chars    int                      `json:"chars"`
+	Tokens   int                      `json:"tokens"`
+	Sections []ContextEstimateSection `json:"sections"`
+}
+
+// ContextEstimateSection is the size of one section. Chars and Tokens
+// include its heading.
+type ContextEstimateSection struct {
+	Title  string `json:"title"`
+	Items  int    `json:"items"`
+	Chars  int    `json:"chars"`
+	Tokens int    `json:"tokens"`
+}
+
+// EstimateTokens approximates how many LLM tokens text takes: one per four
+// characters, rounded up. Real tokenizers vary by model and language, so
+// treat it as a budget, not a count.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// EstimateContext measures what FormatContextWith would return for the
+// same arguments without handing back the text. Totals include the opening
+// heading, so they match the full block. A pending compaction is measured
+// but left pending.
+func (s *Store) EstimateContext(project, scope string, opts ContextOptions) (*ContextEstimate, error) {
+	blocks, err := s.contextBlocks(project, scope, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	project, _ = NormalizeProject(project)
+	estimate := &ContextEstimate{Project: project, Sections: []ContextEstimateSection{}}
+	if len(blocks) == 0 {
+		return estimate, nil
+	}
+	full := contextHeading
+	for _, block := range blocks {
+		full += block.text
+		estimate.Sections = append(estimate.Sections, ContextEstimateSection{
+			Title:  block.title,
+			Items:  block.items,
+			Chars:  utf8.RuneCountInString(block.text),
+			Tokens: EstimateTokens(block.text),
+		})
+	}
+	estimate.Chars = utf8.RuneCountInString(full)
+	estimate.Tokens = EstimateTokens(full)
+	return estimate, nil
+}
+
+// translateObservations returns copies of observations with title and
+// content passed through translate.
+func translateObservations(observations []Observation, translate func(string) string) []Observation {
+	out := slices.Clone(observations)
+	for i := range out {
+		out[i].Title = translate(out[i].Title)
+		out[i].Content = translate(out[i].Content)
+	}
+	return out
+}
+
+// parentDecisions returns recent decision and architecture observations
+// saved directly on the ancestors of project. Sibling projects are not
+// included. Observations matching rules are skipped.
+func (s *Store) parentDecisions(project, scope string, rules ContextExclusion, limit int) ([]Observation, error) {
+	ancestors := ProjectAncestors(project)
+	if len(ancestors) == 0 {
+		return nil, nil
+	}
+	query := `
+		SELECT o.id, ifnull(o.sync_id, '') as sync_id, o.session_id, o.type, o.title, o.content, o.tool_name, o.project,
+		       o.scope, o.topic_key, o.revision_count, o.duplicate_count, o.last_seen_at, o.created_at, o.updated_at, o.deleted_at, o.refs, o.verified_at, o.stale_at, o.verification_note, o.source, o.metadata
+		FROM observations o
+		WHERE o.deleted_at IS NULL AND o.quarantine_reason IS NULL
+		  AND o.project IN (` + placeholders(len(ancestors)) + `)
+		  AND o.type IN (` + placeholders(len(parentContextTypes)) + `)`
+	args := []any{}
+	for _, a := range ancestors {
+		args = append(args, a)
+	}
+	for _, t := range parentContextTypes {
+		args = append(args, t)
+	}
+	if scope != "" {
+		query += " AND o.scope = ?"
+		args = append(args, normalizeScope(scope))
+	}
+	clause, ruleArgs := rules.filterSQL()
+	query += clause
+	args = append(args, ruleArgs...)
+	query += " ORDER BY o.seq DESC, o.id DESC LIMIT ?"
+	args = append(args, limit)
+	return s.queryObservations(query, args...)
+}
+
+// writeContextSessions lists sessions; notes adds a line under a session
+// (see continuationNotes) and may be nil.
+func writeContextSessions(b *strings.Builder, sessions []SessionSummary, notes map[string]string, formatTime func(string) string) {
+	if len(sessions) == 0 {
+		return
+	}
+	b.WriteString("### Recent Sessions\n")
+	for _, sess := range sessions {
+		summary := ""
+		if sess.Summary != nil {
+			summary = fmt.Sprintf(": %s", truncate(*sess.Summary, 200))
+		}
+		fmt.Fprintf(b, "- **%s** (%s)%s [%d observations]\n",
+			sess.Project, formatTime(sess.StartedAt), summary, sess.ObservationCount)
+		if note := notes[sess.ID]; note != "" {
+			fmt.Fprintf(b, "  - %s\n", note)
+		}
+	}
+	b.WriteString("\n")
+}
+
+func writeContextPrompts(b *strings.Builder, prompts []Prompt, formatTime func(string) string) {
+	if len(prompts) == 0 {
+		return
+	}
+	b.WriteString("### Recent User Prompts\n")
+	for _, p := range prompts {
+		fmt.Fprintf(b, "- %s: %s\n", formatTime(p.CreatedAt), truncate(p.Content, 200))
+	}
+	b.WriteString("\n")
+}
+
+func writeContextObservations(b *strings.Builder, heading string, observations []Observation) {
+	if len(observations) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "### %s\n", heading)
+	observations = slices.Clone(observations)
+	freshFirst(observations, func(o Observation) bool { return o.StaleAt != nil })
+	for _, obs := range observations {
+		refs := ""
+		if len(obs.Refs) > 0 {
+			refs = fmt.Sprintf(" (refs: %s)", strings.Join(obs.Refs, ", "))
+		}
+		stale := ""
+		if obs.StaleAt != nil {
+			stale = " [stale]"
+		}
+		fmt.Fprintf(b, "- [%s] **%s**%s: %s%s\n",
+			obs.Type, obs.Title, stale, truncate(obs.Content, 300), refs)
+	}
+	b.WriteString("\n")
+}
+
+// ─── Context Outline ─────────────────────────────────────────────────────────
+
+// Context section IDs. Per-item IDs join a prefix and a value, e.g.
+// "session:abc", "type:bugfix", "topic:architecture/auth-model".
+const (
+	ContextSectionSessions     = "sessions"
+	ContextSectionPrompts      = "prompts"
+	ContextSectionObservations = "observations"
+	ContextSectionTopics       = "topics"
+
+	contextSessionPrefix = "session:"
+	contextTypePrefix    = "type:"
+	contextTopicPrefix   = "topic:"
+)
+
+// maxOutlineTopics caps the topics listed in an outline.
+const maxOutlineTopics = 20
+
+// ContextOutline is a compact table of contents for FormatContext: the
+// headings and items an agent can expand one at a time with ContextSection
+// instead of loading the whole context block.
+type ContextOutline struct {
+	Project  string                  `json:"project,omitempty"`
+	Sections []ContextOutlineSection `json:"sections"`
+}
+
+// ContextOutlineSection is one top-level heading of the outline.
+type ContextOutlineSection struct {
+	ID    string               `json:"id"`
+	Title string               `json:"title"`
+	Count int                  `json:"count"`
+	Items []ContextOutlineItem `json:"items,omitempty"`
+}
+
+// ContextOutlineItem is an expandable entry under a section.
+type ContextOutlineItem struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// ContextOutline lists what FormatContext would include for project and
+// scope — sessions, prompts, observations grouped by type, and topic keys —
+// as one-line labels with section IDs. It returns an empty outline when
+// there is no memory yet.
+func (s *Store) ContextOutline(project, scope string) (*ContextOutline, error) {
+	project, _ = NormalizeProject(project)
+	outline := &ContextOutline{Project: project, Sections: []ContextOutlineSection{}}
+
+	sessions, err := s.RecentSessions(project, 5)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) > 0 {
+		section := ContextOutlineSection{ID: ContextSectionSessions, Title: "Recent Sessions", Count: len(sessions)}
+		for _, sess := range sessions {
+			label := fmt.Sprintf("%s (%s) [%d observations]", sess.Project, s.FormatTime(sess.StartedAt), sess.ObservationCount)
+			if sess.Summary != nil {
+				label += ": " + truncate(summaryHeadline(*sess.Summary), 80)
+			}
+			section.Items = append(section.Items, ContextOutlineItem{ID: contextSessionPrefix + sess.ID, Label: label})
+		}
+		outline.Sections = append(outline.Sections, section)
+	}
+
+	prompts, err := s.RecentPrompts(project, 10)
+	if err != nil {
+		return nil, err
+	}
+	if len(prompts) > 0 {
+		outline.Sections = append(outline.Sections, ContextOutlineSection{ID: ContextSectionPrompts, Title: "Recent User Prompts", Count: len(prompts)})
+	}
+
+	observations, err := s.RecentObservations(project, scope, s.cfg.MaxContextResults)
+	if err != nil {
+		return nil, err
+	}
+	if len(observations) > 0 {
+		section := ContextOutlineSection{ID: ContextSectionObservations, Title: "Recent Observations", Count: len(observations)}
+		var types []string
+		titles := map[string][]string{}
+		for _, obs := range observations {
+			if _, ok := titles[obs.Type]; !ok {
+				types = append(types, obs.Type)
+			}
+			titles[obs.Type] = append(titles[obs.Type], fmt.Sprintf("#%d %s", obs.ID, truncate(obs.Title, 60)))
+		}
+		for _, typ := range types {
+			section.Items = append(section.Items, ContextOutlineItem{
+				ID:    contextTypePrefix + typ,
+				Label: fmt.Sprintf("%s (%d): %s", typ, len(titles[typ]), strings.Join(titles[typ], "; ")),
+			})
+		}
+		outline.Sections = append(outline.Sections, section)
+	}
+
+	topics, err := s.Topics(project, scope)
+	if err != nil {
+		return nil, err
+	}
+	if len(topics) > 0 {
+		slices.SortStableFunc(topics, func(a, b TopicSummary) int { return strings.Compare(b.UpdatedAt, a.UpdatedAt) })
+		section := ContextOutlineSection{ID: ContextSectionTopics, Title: "Topics", Count: len(topics)}
+		for i, t := range topics {
+			if i == maxOutlineTopics {
+				break
+			}
+			section.Items = append(section.Items, ContextOutlineItem{
+				ID:    contextTopicPrefix + t.TopicKey,
+				Label: fmt.Sprintf("#%d %s (rev %d)", t.LatestID, truncate(t.LatestTitle, 60), t.RevisionCount),
+			})
+		}
+		outline.Sections = append(outline.Sections, section)
+	}
+
+	return outline, nil
+}
+
+// summaryHeadline is the first line of a session summary that is not a
+// markdown heading, so "## Goal\nShip auth" yields "Ship auth".
+func summaryHeadline(summary string) string {
+	for _, line := range strings.Split(summary, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+// Markdown renders the outline as a heading list with the IDs to pass to
+// ContextSection.
+func (o *ContextOutline) Markdown() string {
+	if len(o.Sections) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("## Memory Outline\n\n")
+	for _, section := range o.Sections {
+		fmt.Fprintf(&b, "### %s (%d) — id: %s\n", section.Title, section.Count, section.ID)
+		for _, item := range section.Items {
+			fmt.Fprintf(&b, "- %s — id: %s\n", item.Label, item.ID)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ContextSection expands one outline ID into markdown. Top-level IDs render
+// the same block FormatContext would; "session:ID" adds the session's full
+// summary and observations, "type:T" the recent observations of type T, and
+// "topic:KEY" the full content of the memory holding that topic key.
+func (s *Store) ContextSection(project, scope, id string) (string, error) {
+	project, _ = NormalizeProject(project)
+	id = strings.TrimSpace(id)
+
+	var b strings.Builder
+	switch {
+	case id == ContextSectionSessions:
+		sessions, err := s.RecentSessions(project, 5)
+		if err != nil {
+			return "", err
+		}
+		notes, err := s.continuationNotes(sessions)
+		if err != nil {
+			return "", err
+		}
+		writeContextSessions(&b, sessions, notes, s.FormatTime)
+
+	case id == ContextSectionPrompts:
+		prompts, err := s.RecentPrompts(project, 10)
+		if err != nil {
+			return "", err
+		}
+		writeContextPrompts(&b, prompts, s.FormatTime)
+
+	case id == ContextSectionObservations:
+		observations, err := s.RecentObservations(project, scope, s.cfg.MaxContextResults)
+		if err != nil {
+			return "", err
+		}
+		writeContextObservations(&b, "Recent Observations", observations)
+
+	case id == ContextSectionTopics:
+		topics, err := s.Topics(project, scope)
+		if err != nil {
+			return "", err
+		}
+		if len(topics) > 0 {
+			b.WriteString("### Topics\n")
+			for _, t := range topics {
+				fmt.Fprintf(&b, "- **%s** → #%d [%s] %s (rev %d, updated %s)\n",
+					t.TopicKey, t.LatestID, t.LatestType, t.LatestTitle, t.RevisionCount, s.FormatTime(t.UpdatedAt))
+			}
+			b.WriteString("\n")
+		}
+
+	case strings.HasPrefix(id, contextSessionPrefix):
+		sess, err := s.GetSession(strings.TrimPrefix(id, contextSessionPrefix))
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("context section %q: session not found", id)
+		}
+		if err != nil {
+			return "", err
+		}
+		observations, err := s.SessionObservations(sess.ID, s.cfg.MaxContextResults)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "### Session %s (%s, %s)\n", sess.ID, sess.Project, s.FormatTime(sess.StartedAt))
+		if sess.Summary != nil {
+			b.WriteString(*sess.Summary + "\n")
+		}
+		b.WriteString("\n")
+		writeContextObservations(&b, "Observations", observations)
+
+	case strings.HasPrefix(id, contextTypePrefix):
+		typ := strings.TrimPrefix(id, contextTypePrefix)
+		observations, err := s.RecentObservations(project, scope, s.cfg.MaxContextResults)
+		if err != nil {
+			return "", err
+		}
+		var matching []Observation
+		for _, obs := range observations {
+			if obs.Type == typ {
+				matching = append(matching, obs)
+			}
+		}
+		writeContextObservations(&b, "Recent "+typ+" Observations", matching)
+
+	case strings.HasPrefix(id, contextTopicPrefix):
+		key := strings.TrimPrefix(id, contextTopicPrefix)
+		topics, err := s.Topics(project, scope)
+		if err != nil {
+			return "", err
+		}
+		for _, t := range topics {
+			if t.TopicKey != key {
+				continue
+			}
+			obs, err := s.GetObservation(t.LatestID)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "### %s — #%d [%s] %s (rev %d)\n%s\n\n", key, obs.ID, obs.Type, obs.Title, obs.RevisionCount, obs.Content)
+		}
+
+	default:
+		return "", fmt.Errorf("unknown context section %q", id)
+	}
+	return b.String(), nil
+}
+
+// ─── Session Transcripts ─────────────────────────────────────────────────────
+
+// TranscriptOptions controls SessionTranscript.
+type TranscriptOptions struct {
+	// Redact runs RedactForSharing over the transcript so it can be posted
+	// outside the team.
+	Redact bool
+}
+
+// SessionTranscript assembles a session's summary, prompts, and observations
+// into a readable markdown document, with prompts and observations
+// interleaved in the order they were recorded. It returns
+// ErrSessionNotFound when the session does not exist.
+func (s *Store) SessionTranscript(id string, opts TranscriptOptions) (string, error) {
+	sess, err := s.GetSession(id)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("%w: %q", ErrSessionNotFound, id)
+	}
+	if err != nil {
+		return "", err
+	}
+	observations, err := s.SessionObservations(id, 0)
+	if err != nil {
+		return "", err
+	}
+	if observations, err = s.joinSessionParts(id, observations); err != nil {
+		return "", err
+	}
+	prompts, err := s.SessionPrompts(id)
+	if err != nil {
+		return "", err
+	}
+	toolRuns, err := s.SessionToolRuns(id, 0)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Session %s\n\n", sess.ID)
+	fmt.Fprintf(&b, "- **Project**: %s\n", sess.Project)
+	if sess.Directory != "" {
+		fmt.Fprintf(&b, "- **Directory**: %s\n", sess.Directory)
+	}
+	fmt.Fprintf(&b, "- **Started**: %s\n", s.FormatTime(sess.StartedAt))
+	if sess.EndedAt != nil {
+		fmt.Fprintf(&b, "- **Ended**: %s\n", s.FormatTime(*sess.EndedAt))
+	}
+	fmt.Fprintf(&b, "- **Prompts**: %d · **Observations**: %d", len(prompts), len(observations))
+	if len(toolRuns) > 0 {
+		fmt.Fprintf(&b, " · **Tool runs**: %d", len(toolRuns))
+	}
+	b.WriteString("\n\n")
+
+	if sess.Summary != nil && strings.TrimSpace(*sess.Summary) != "" {
+		b.WriteString("## Summary\n\n")
+		b.WriteString(strings.TrimSpace(*sess.Summary))
+		b.WriteString("\n\n")
+	}
+
+	if len(prompts)+len(observations)+len(toolRuns) > 0 {
+		b.WriteString("## Transcript\n\n")
+	}
+	// Prompts, observations, and tool runs are each sorted by time; at equal
+	// timestamps prompts come first, then observations, then tool runs.
+	pi, oi, ri := 0, 0, 0
+	for pi < len(prompts) || oi < len(observations) || ri < len(toolRuns) {
+		next := ""
+		if pi < len(prompts) {
+			next = "prompt"
+		}
+		if oi < len(observations) && (next == "" || observations[oi].CreatedAt < prompts[pi].CreatedAt) {
+			next = "observation"
+		}
+		if ri < len(toolRuns) {
+			switch {
+			case next == "",
+				next == "prompt" && toolRuns[ri].CreatedAt < prompts[pi].CreatedAt,
+				next == "observation" && toolRuns[ri].CreatedAt < observations[oi].CreatedAt:
+				next = "tool_run"
+			}
+		}
+
+		switch next {
+		case "prompt":
+			p := prompts[pi]
+			fmt.Fprintf(&b, "### %s — User\n\n", s.FormatTime(p.CreatedAt))
+			for _, line := range strings.Split(strings.TrimSpace(p.Content), "\n") {
+				b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+			}
+			b.WriteString("\n")
+			pi++
+		case "observation":
+			o := observations[oi]
+			fmt.Fprintf(&b, "### %s — [%s] %s (#%d)\n\n", s.FormatTime(o.CreatedAt), o.Type, o.Title, o.ID)
+			b.WriteString(strings.TrimSpace(o.Content))
+			b.WriteString("\n\n")
+			oi++
+		case "tool_run":
+			r := toolRuns[ri]
+			fmt.Fprintf(&b, "### %s — Tool run: %s\n\n", s.FormatTime(r.CreatedAt), r.Headline())
+			if summary := strings.TrimSpace(r.Summary); summary != "" {
+				b.WriteString(truncate(summary, 500))
+				b.WriteString("\n\n")
+			}
+			ri++
+		}
+	}
+
+	out := b.String()
+	if opts.Redact {
+		out = RedactForSharing(out)
+	}
+	return out, nil
+}
+
+// SessionPrompts returns a session's prompts, oldest first.
+func (s *Store) SessionPrompts(sessionID string) ([]Prompt, error) {
+	rows, err := s.queryItHook(s.db,
+		`SELECT id, ifnull(sync_id, '') as sync_id, session_id, content, ifnull(project, '') as project, created_at
+		 FROM user_prompts WHERE session_id = ? ORDER BY seq ASC, id ASC`, sessionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Prompt
+	for rows.Next() {
+		var p Prompt
+		if err := rows.Scan(&p.ID, &p.SyncID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, p)
+	}
+	return results, rows.Err()
+}
+
+// redactions are applied in order by RedactForSharing. Key/value secrets keep
+// their key so the transcript still reads naturally.
+var redactions = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`), "[REDACTED PRIVATE KEY]"},
+	{regexp.MustCompile(`\beyJ[\w-]{8,}\.[\w-]{8,}\.[\w-]{8,}`), "[REDACTED]"},
+	{regexp.MustCompile(`\b(?:sk|pk|rk)-(?:[A-Za-z0-9_-]*-)?[A-Za-z0-9]{20,}\b`), "[REDACTED]"},
+	{regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{30,}|github_pat_[A-Za-z0-9_]{30,}|glpat-[A-Za-z0-9_-]{20,}|xox[abprs]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16})\b`), "[REDACTED]"},
+	{regexp.MustCompile(`(?i)\b(Bearer|Basic)\s+[A-Za-z0-9._~+/=-]{8,}`), "$1 [REDACTED]"},
+	{regexp.MustCompile(`(?i)\b([\w.-]*(?:password|passwd|secret|token|api[_-]?key|access[_-]?key)[\w.-]*["']?\s*[:=]\s*)["']?[^\s"',;]{4,}["']?`), "${1}[REDACTED]"},
+	{regexp.MustCompile(`\b([A-Za-z][A-Za-z0-9+.-]*://)[^\s/:@]+:[^\s/@]+@`), "${1}[REDACTED]@"},
+	{regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`), "[EMAIL]"},
+	{regexp.MustCompile(`(?:/Users|/home)/[^/\s]+`), "~"},
+	{regexp.MustCompile(`(?i)\b[A-Z]:\\Users\\[^\\\s]+`), "~"},
+}
+
+// RedactForSharing masks what should not leave the machine when a memory is
+// shared: private keys, JWTs, common API token formats, bearer credentials,
+// password/secret/token assignments, credentials in URLs, email addresses,
+// and home directories (replaced by "~"). Content inside <private> tags is
+// already stripped at save time.
+func RedactForSharing(text string) string {
+	for _, r := range redactions {
+		text = r.pattern.ReplaceAllString(text, r.replacement)
+	}
+	return text
+}
+
+// ─── Auto Summaries ──────────────────────────────────────────────────────────
+
+// SummarizeSession saves a session_summary composed from the session's
+// decisions, bugfixes, and file changes, for sessions whose agent never
+// called mem_session_summary. The summary is marked auto-generated in its
+// title, first section, and source (SourceAutoSummary). A session that
+// already has a summary keeps it: its ID is returned with created false.
+// EndSession calls this when it is given no summary.
+func (s *Store) SummarizeSession(sessionID string) (id int64, created bool, err error) {
+	sess, err := s.GetSession(sessionID)
+	if err == sql.ErrNoRows {
+		return 0, false, fmt.Errorf("%w: %q", ErrSessionNotFound, sessionID)
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	err = s.db.QueryRow(
+		`SELECT id FROM observations
+		 WHERE session_id = ? AND type = 'session_summary' AND deleted_at IS NULL
+		 ORDER BY id DESC LIMIT 1`, sessionID,
+	).Scan(&id)
+	if err == nil {
+		return id, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	// Every row is read and chunked saves are joined, so a long session is
+	// summarized whole and a long save counts once.
+	observations, err := s.sessionObservations(sessionID, -1)
+	if err != nil {
+		return 0, false, err
+	}
+	if observations, err = s.joinSessionParts(sessionID, observations); err != nil {
+		return 0, false, err
+	}
+	var decisions, bugfixes, changes []Observation
+	for _, o := range observations {
+		switch o.Type {
+		case "decision":
+			decisions = append(decisions, o)
+		case "bugfix":
+			bugfixes = append(bugfixes, o)
+		case "file_change":
+			changes = append(changes, o)
+		}
+	}
+	if len(decisions)+len(bugfixes)+len(changes) == 0 {
+		return 0, false, ErrNothingToSummarize
+	}
+
+	id, err = s.AddObservation(AddObservationParams{
+		SessionID: sessionID,
+		Type:      "session_summary",
+		Title:     "Session summary (auto-generated): " + sess.Project,
+		Content:   autoSummary(decisions, bugfixes, changes),
+		Project:   sess.Project,
+		Source:    SourceAutoSummary,
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+// autoSummary fills the mem_session_summary template, so a composed
+// summary reads and is parsed like one an agent wrote. Sections without
+// material are left out.
+func autoSummary(decisions, bugfixes, changes []Observation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Goal\nNot recorded — auto-generated by engram from %d observations because the session ended without a summary.\n",
+		len(decisions)+len(bugfixes)+len(changes))
+
+	if len(bugfixes)+len(changes) > 0 {
+		b.WriteString("\n## Accomplished\n")
+		for _, o := range bugfixes {
+			fmt.Fprintf(&b, "- Fixed: %s\n", summaryItem(o))
+		}
+		for _, o := range changes {
+			fmt.Fprintf(&b, "- %s\n", summaryItem(o))
+		}
+	}
+	if len(decisions) > 0 {
+		b.WriteString("\n## Decisions\n")
+		for _, o := range decisions {
+			fmt.Fprintf(&b, "- %s\n", summaryItem(o))
+		}
+	}
+
+	var files []string
+	seen := map[string]bool{}
+	for _, group := range [][]Observation{changes, bugfixes, decisions} {
+		for _, o := range group {
+			for _, path := range ExtractFilePaths(o.Title + "\n" + o.Content) {
+				if !seen[path] {
+					seen[path] = true
+					files = append(files, path)
+				}
+			}
+		}
+	}
+	if len(files) > 0 {
+		b.WriteString("\n## Relevant Files\n")
+		for _, path := range files {
+			fmt.Fprintf(&b, "- %s\n", path)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// SessionSummaryParams is a machine-written session summary, such as a
+// nightly "what changed" report from CI.
+type SessionSummaryParams struct {
+	SessionID string `json:"session_id"`
+	Project   string `json:"project"`
+	// Title defaults to "Session summary: <project>".
+	Title   string `json:"title,omitempty"`
+	Content string `json:"content"`
+	// Source is set by the entry path, like AddObservationParams.Source;
+	// empty means SourceCI.
+	Source string `json:"-"`
+}
+
+// SaveSessionSummary records a summary for a session in one call: it
+// creates the session when missing, saves the summary as a session_summary
+// observation, and ends the session with it. Pipelines that reuse a session
+// ID (say, ci-nightly) add one summary per run and move its end time.
+func (s *Store) SaveSessionSummary(p SessionSummaryParams) (int64, error) {
+	project, _ := NormalizeProject(p.Project)
+	switch {
+	case strings.TrimSpace(p.SessionID) == "":
+		return 0, invalidf("session summary: session_id is required")
+	case project == "":
+		return 0, invalidf("session summary: project is required")
+	case strings.TrimSpace(p.Content) == "":
+		return 0, invalidf("session summary: content is required")
+	}
+	title := p.Title
+	if title == "" {
+		title = "Session summary: " + project
+	}
+	source := p.Source
+	if source == "" {
+		source = SourceCI
+	}
+
+	if err := s.CreateSession(p.SessionID, project, ""); err != nil {
+		return 0, err
+	}
+	id, err := s.AddObservation(AddObservationParams{
+		SessionID: p.SessionID,
+		Type:      "session_summary",
+		Title:     title,
+		Content:   p.Content,
+		Project:   project,
+		Source:    source,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, s.EndSession(p.SessionID, p.Content)
+}
+
+// summaryItemPrefix matches list markers, headings, and a bold label such as
+// "**What**:" at the start of a line.
+var summaryItemPrefix = regexp.MustCompile(`^\s*(?:[#>*-]+\s+)*(?:\*\*[^*]+\*\*:?\s*)?`)
+
+// summaryItem is an observation's title followed by the first line of its
+// content, shortened to one line.
+func summaryItem(o Observation) string {
+	for line := range strings.SplitSeq(o.Content, "\n") {
+		line = strings.TrimSpace(summaryItemPrefix.ReplaceAllString(line, ""))
+		if line == "" || line == o.Title {
+			continue
+		}
+		return o.Title + " — " + truncate(line, 160)
+	}
+	return o.Title
+}
+
+// ─── Replication ─────────────────────────────────────────────────────────────
+
+// ListSessions returns every session, oldest first.
+func (s *Store) ListSessions() ([]Session, error) {
+	rows, err := s.queryItHook(s.db,
+		"SELECT id, project, directory, started_at, ended_at, summary, parent_session_id FROM sessions ORDER BY started_at, id",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sessions []Session
+	for rows.Next() {
+		var sess Session
+		if err := rows.Scan(&sess.ID, &sess.Project, &sess.Directory, &sess.StartedAt, &sess.EndedAt, &sess.Summary, &sess.ParentSessionID); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, rows.Err()
+}
+
+// ObservationsChangedSince pages through observations updated at or after
+// since, soft-deleted ones included, ordered by (updated_at, id). Pass the
+// last row's UpdatedAt and ID as since and afterID to get the next page.
+// Quarantined captures are left out until they are approved.
+func (s *Store) ObservationsChangedSince(since string, afterID int64, limit int) ([]Observation, error) {
+	return s.queryObservations(
+		`SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
+		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata
+		 FROM observations
+		 WHERE quarantine_reason IS NULL
+		   AND (updated_at > ? OR (updated_at = ? AND id > ?))
+		 ORDER BY updated_at, id
+		 LIMIT ?`,
+		since, since, afterID, limit,
+	)
+}
+
+// ObservationsAfter returns live observations with an ID above afterID, in
+// insertion order. Watchers keep the last ID they saw and poll with it.
+func (s *Store) ObservationsAfter(afterID int64, limit int) ([]Observation, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return s.queryObservations(
+		`SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
+		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata
+		 FROM observations
+		 WHERE id > ? AND deleted_at IS NULL AND quarantine_reason IS NULL
+		 ORDER BY id
+		 LIMIT ?`,
+		afterID, limit,
+	)
+}
+
+// LastObservationID returns the highest observation ID, or 0 for an empty
+// store.
+func (s *Store) LastObservationID() (int64, error) {
+	var id int64
+	err := s.db.QueryRow(`SELECT ifnull(max(id), 0) FROM observations`).Scan(&id)
+	return id, err
+}
+
+// PromptsCreatedSince pages through prompts like ObservationsChangedSince,
+// keyed on created_at since prompts are never edited.
+func (s *Store) PromptsCreatedSince(since string, afterID int64, limit int) ([]Prompt, error) {
+	rows, err := s.queryItHook(s.db,
+		`SELECT id, ifnull(sync_id, '') as sync_id, session_id, content, ifnull(project, '') as project, created_at
+		 FROM user_prompts
+		 WHERE created_at > ? OR (created_at = ? AND id > ?)
+		 ORDER BY created_at, id
+		 LIMIT ?`,
+		since, since, afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var prompts []Prompt
+	for rows.Next() {
+		var p Prompt
+		if err := rows.Scan(&p.ID, &p.SyncID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, p)
+	}
+	return prompts, rows.Err()
+}
+
+// ReplicationWatermark returns the last timestamp replicated to target for
+// entity, or "" when nothing was replicated yet.
+func (s *Store) ReplicationWatermark(target, entity string) (string, error) {
+	var watermark string
+	err := s.db.QueryRow(
+		"SELECT watermark FROM replication_state WHERE target = ? AND entity = ?", target, entity,
+	).Scan(&watermark)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return watermark, err
+}
+
+// SetReplicationWatermark records how far entity has been replicated to
+// target. An empty watermark forgets it, so the next run starts over.
+func (s *Store) SetReplicationWatermark(target, entity, watermark string) error {
+	if watermark == "" {
+		_, err := s.execHook(s.db, "DELETE FROM replication_state WHERE target = ? AND entity = ?", target, entity)
+		return err
+	}
+	_, err := s.execHook(s.db,
+		`INSERT INTO replication_state (target, entity, watermark) VALUES (?, ?, ?)
+		 ON CONFLICT(target, entity) DO UPDATE SET watermark = excluded.watermark,
+		     updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')`,
+		target, entity, watermark,
+	)
+	return err
+}
+
+// ─── Export / Import ─────────────────────────────────────────────────────────
+
+func (s *Store) Export() (*ExportData, error) {
+	data := &ExportData{
+		Version:    "0.1.0",
+		ExportedAt: Now(),
+	}
+
+	// Sessions
+	rows, err := s.queryItHook(s.db,
+		"SELECT id, project, directory, started_at, ended_at, summary, parent_session_id FROM sessions ORDER BY started_at",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("export sessions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sess Session
+		if err := rows.Scan(&sess.ID, &sess.Project, &sess.Directory, &sess.StartedAt, &sess.EndedAt, &sess.Summary, &sess.ParentSessionID); err != nil {
+			return nil, err
+		}
+		data.Sessions = append(data.Sessions, sess)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Observations
+	obsRows, err := s.queryItHook(s.db,
+		`SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
+		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata
+		 FROM observations WHERE quarantine_reason IS NULL ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("export observations: %w", err)
+	}
+	defer obsRows.Close()
+	for obsRows.Next() {
+		var o Observation
+		if err := obsRows.Scan(
+			&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, (*contentColumn)(&o.Content),
+			&o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt,
+			&o.CreatedAt, &o.UpdatedAt, &o.DeletedAt, &o.Refs, &o.VerifiedAt, &o.StaleAt, &o.VerificationNote, &o.Source, &o.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		data.Observations = append(data.Observations, o)
+	}
+	if err := obsRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Prompts
+	promptRows, err := s.queryItHook(s.db,
+		"SELECT id, ifnull(sync_id, '') as sync_id, session_id, content, ifnull(project, '') as project, created_at FROM user_prompts ORDER BY id",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("export prompts: %w", err)
+	}
+	defer promptRows.Close()
+	for promptRows.Next() {
+		var p Prompt
+		if err := promptRows.Scan(&p.ID, &p.SyncID, &p.SessionID, &p.Content, &p.Project, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		data.Prompts = append(data.Prompts, p)
+	}
+	if err := promptRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// PersonalSyncPolicy controls personal-scope observations in team sharing:
+// the sync chunks committed to a repo and GET /export. Personal sync and
+// local backups are not affected.
+type PersonalSyncPolicy string
+
+const (
+	// PersonalSyncExclude leaves personal observations out unless the
+	// caller asks for them (default).
+	PersonalSyncExclude PersonalSyncPolicy = "exclude"
+	// PersonalSyncInclude always shares them, as engram did before the
+	// policy existed.
+	PersonalSyncInclude PersonalSyncPolicy = "include"
+	// PersonalSyncDeny never shares them, and refuses callers that ask.
+	PersonalSyncDeny PersonalSyncPolicy = "deny"
+)
+
+// ErrPersonalSyncDenied is returned by SharedExport when personal
+// observations are requested under PersonalSyncDeny.
+var ErrPersonalSyncDenied = categorized(ErrValidation, `personal memories may not be shared ([sync] personal = "deny")`)
+
+// ParsePersonalSyncPolicy validates a [sync] personal value.
+func ParsePersonalSyncPolicy(value string) (PersonalSyncPolicy, error) {
+	switch PersonalSyncPolicy(strings.ToLower(strings.TrimSpace(value))) {
+	case "", PersonalSyncExclude:
+		return PersonalSyncExclude, nil
+	case PersonalSyncInclude:
+		return PersonalSyncInclude, nil
+	case PersonalSyncDeny:
+		return PersonalSyncDeny, nil
+	default:
+		return "", fmt.Errorf("unknown personal sync policy %q (want exclude, include or deny)", value)
+	}
+}
+
+// SharedExport returns the part of data that may be shared with a team
+// under the configured PersonalSync policy. Personal observations are
+// dropped unless includePersonal is set (or the policy is include);
+// sessions and prompts have no scope and are kept.
+func (s *Store) SharedExport(data *ExportData, includePersonal bool) (*ExportData, error) {
+	policy, err := ParsePersonalSyncPolicy(string(s.cfg.PersonalSync))
+	if err != nil {
+		return nil, categorized(ErrValidation, err.Error())
+	}
+	switch {
+	case policy == PersonalSyncDeny && includePersonal:
+		return nil, ErrPersonalSyncDenied
+	case policy == PersonalSyncInclude || includePersonal:
+		return data, nil
+	}
+
+	shared := *data
+	shared.Observations = nil
+	for _, o := range data.Observations {
+		if o.Scope != "personal" {
+			shared.Observations = append(shared.Observations, o)
+		}
+	}
+	return &shared, nil
+}
+
+// ImportConflict selects what Import does with a record that already exists
+// locally. An observation matches when it has the same sync_id, or the same
+// session and normalized content hash; a prompt matches on sync_id, or the
+// same session and content.
+type ImportConflict string
+
+const (
+	// ImportSkip keeps the local record and drops the imported one (default).
+	ImportSkip ImportConflict = "skip"
+	// ImportMerge updates the local observation from the imported one when
+	// the import is newer, keeping the larger revision and duplicate counts.
+	ImportMerge ImportConflict = "merge"
+	// ImportDuplicate inserts every record, matching or not.
+	ImportDuplicate ImportConflict = "duplicate"
+)
+
+// ParseImportConflict validates an --on-conflict value.
+func ParseImportConflict(value string) (ImportConflict, error) {
+	switch ImportConflict(strings.ToLower(strings.TrimSpace(value))) {
+	case "", ImportSkip:
+		return ImportSkip, nil
+	case ImportMerge:
+		return ImportMerge, nil
+	case ImportDuplicate:
+		return ImportDuplicate, nil
+	default:
+		return "", fmt.Errorf("unknown conflict mode %q (want skip, merge or duplicate)", value)
+	}
+}
+
+// ImportOptions configures ImportWithOptions.
+type ImportOptions struct {
+	OnConflict ImportConflict
+	// Source is recorded on inserted observations; empty means SourceImport.
+	Source string
+}
+
+// Import loads an export, skipping records that already exist locally.
+func (s *Store) Import(data *ExportData) (*ImportResult, error) {
+	return s.ImportWithOptions(data, ImportOptions{OnConflict: ImportSkip})
+}
+
+// ImportWithOptions loads an export using the given conflict strategy.
+func (s *Store) ImportWithOptions(data *ExportData, opts ImportOptions) (*ImportResult, error) {
+	if opts.OnConflict == "" {
+		opts.OnConflict = ImportSkip
+	}
+	if opts.Source == "" {
+		opts.Source = SourceImport
+	}
+	if opts.OnConflict == ImportMerge && s.cfg.AppendOnly {
+		return nil, fmt.Errorf("import: merge: %w", ErrAppendOnly)
+	}
+
+	tx, err := s.beginTxHook()
+	if err != nil {
+		return nil, fmt.Errorf("import: begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &ImportResult{}
+
+	// Import sessions (skip duplicates)
+	for _, sess := range data.Sessions {
+		sess.StartedAt = NormalizeTimestamp(sess.StartedAt)
+		sess.EndedAt = normalizeTimestampPtr(sess.EndedAt)
+		res, err := s.execHook(tx,
+			`INSERT OR IGNORE INTO sessions (id, project, directory, started_at, ended_at, summary, parent_session_id)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			sess.ID, sess.Project, sess.Directory, sess.StartedAt, sess.EndedAt, sess.Summary, sess.ParentSessionID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("import session %s: %w", sess.ID, err)
+		}
+		n, _ := res.RowsAffected()
+		result.SessionsImported += int(n)
+		if n == 0 {
+			result.SessionsSkipped++
+		}
+	}
+
+	// Import observations (use new IDs — AUTOINCREMENT)
+	for _, obs := range data.Observations {
+		obs.CreatedAt = NormalizeTimestamp(obs.CreatedAt)
+		obs.UpdatedAt = NormalizeTimestamp(obs.UpdatedAt)
+		obs.LastSeenAt = normalizeTimestampPtr(obs.LastSeenAt)
+		obs.DeletedAt = normalizeTimestampPtr(obs.DeletedAt)
+		normHash := hashNormalized(obs.Content)
+		metadata, err := normalizeMetadata(obs.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+		}
+		if opts.OnConflict != ImportDuplicate {
+			existingID, existingUpdatedAt, err := findImportedObservationTx(tx, obs.SyncID, obs.SessionID, normHash)
+			if err != nil {
+				return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+			}
+			if existingID != 0 {
+				if opts.OnConflict == ImportMerge && obs.UpdatedAt > existingUpdatedAt {
+					if _, err := s.execHook(tx,
+						`UPDATE observations
+						 SET type = ?, title = ?, content = ?, tool_name = ?, project = ?, scope = ?, topic_key = ?,
+						     refs = ?, metadata = ?, normalized_hash = ?,
+						     revision_count = max(revision_count, ?),
+						     duplicate_count = max(duplicate_count, ?),
+						     last_seen_at = max(ifnull(last_seen_at, ''), ifnull(?, '')),
+						     updated_at = ?,
+						     deleted_at = ?
+						 WHERE id = ?`,
+						obs.Type, obs.Title, s.storedContent(obs.Content), obs.ToolName, obs.Project, normalizeScope(obs.Scope),
+						nullableString(normalizeTopicKey(derefString(obs.TopicKey))), mergeRefs(obs.Refs), metadata, normHash,
+						maxInt(obs.RevisionCount, 1), maxInt(obs.DuplicateCount, 1), obs.LastSeenAt,
+						obs.UpdatedAt, obs.DeletedAt, existingID,
+					); err != nil {
+						return nil, fmt.Errorf("import observation %d: merge: %w", obs.ID, err)
+					}
+					if err := s.linkObservationFiles(tx, existingID, obs.Title, obs.Content); err != nil {
+						return nil, fmt.Errorf("import observation %d: merge: %w", obs.ID, err)
+					}
+					result.ObservationsMerged++
+					continue
+				}
+				result.ObservationsSkipped++
+				continue
+			}
+		}
+
+		created, err := s.ensureSessionTx(tx, obs.SessionID, derefString(obs.Project), obs.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+		}
+		if created {
+			result.PlaceholderSessions++
+		}
+
+		res, err := s.execHook(tx,
+			`INSERT INTO observations (sync_id, session_id, type, title, content, tool_name, project, scope, topic_key, refs, metadata, normalized_hash, source, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, seq)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+nextObservationSeq+`)`,
+			normalizeExistingSyncID(obs.SyncID, "obs"),
+			obs.SessionID,
+			obs.Type,
+			obs.Title,
+			s.storedContent(obs.Content),
+			obs.ToolName,
+			obs.Project,
+			normalizeScope(obs.Scope),
+			nullableString(normalizeTopicKey(derefString(obs.TopicKey))),
+			mergeRefs(obs.Refs),
+			metadata,
+			normHash,
+			opts.Source,
+			maxInt(obs.RevisionCount, 1),
+			maxInt(obs.DuplicateCount, 1),
+			obs.LastSeenAt,
+			obs.CreatedAt,
+			obs.UpdatedAt,
+			obs.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+		}
+		newID, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+		}
+		if err := s.linkObservationFiles(tx, newID, obs.Title, obs.Content); err != nil {
+			return nil, fmt.Errorf("import observation %d: %w", obs.ID, err)
+		}
+		result.ObservationsImported++
+	}
+
+	// Import prompts
+	for _, p := range data.Prompts {
+		p.CreatedAt = NormalizeTimestamp(p.CreatedAt)
+		if opts.OnConflict != ImportDuplicate {
+			exists, err := importedPromptExistsTx(tx, p.SyncID, p.SessionID, p.Content)
+			if err != nil {
+				return nil, fmt.Errorf("import prompt %d: %w", p.ID, err)
+			}
+			if exists {
+				result.PromptsSkipped++
+				continue
+			}
+		}
+
+		created, err := s.ensureSessionTx(tx, p.SessionID, p.Project, p.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("import prompt %d: %w", p.ID, err)
+		}
+		if created {
+			result.PlaceholderSessions++
+		}
+
+		_, err = s.execHook(tx,
+			`INSERT INTO user_prompts (sync_id, session_id, content, project, normalized_hash, created_at, seq)
+			 VALUES (?, ?, ?, ?, ?, ?, `+nextPromptSeq+`)`,
+			normalizeExistingSyncID(p.SyncID, "prompt"), p.SessionID, p.Content, p.Project, hashNormalized(p.Content), p.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("import prompt %d: %w", p.ID, err)
+		}
+		result.PromptsImported++
+	}
+
+	if err := s.commitHook(tx); err != nil {
+		return nil, fmt.Errorf("import: commit: %w", err)
+	}
+
+	return result, nil
+}
+
+type ImportResult struct {
+	SessionsImported     int `json:"sessions_imported"`
+	ObservationsImported int `json:"observations_imported"`
+	PromptsImported      int `json:"prompts_imported"`
+	SessionsSkipped      int `json:"sessions_skipped"`
+	ObservationsSkipped  int `json:"observations_skipped"`
+	ObservationsMerged   int `json:"observations_merged"`
+	PromptsSkipped       int `json:"prompts_skipped"`
+	// PlaceholderSessions counts sessions created because imported
+	// observations or prompts referenced one the export did not include.
+	PlaceholderSessions int `json:"placeholder_sessions"`
+}
+
+// findImportedObservationTx returns the local observation an imported one
+// collides with (0 when none), matching on sync_id first and then on
+// session + normalized content hash. Soft-deleted rows count, so a re-import
+// does not resurrect something deleted locally.
+func findImportedObservationTx(tx *sql.Tx, syncID, sessionID, normHash string) (int64, string, error) {
+	var id int64
+	var updatedAt string
+	err := tx.QueryRow(
+		`SELECT id, updated_at FROM observations
+		 WHERE (? != '' AND sync_id = ?)
+		    OR (session_id = ? AND normalized_hash = ?)
+		 ORDER BY id ASC
+		 LIMIT 1`,
+		syncID, syncID, sessionID, normHash,
+	).Scan(&id, &updatedAt)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	return id, updatedAt, err
+}
+
+func importedPromptExistsTx(tx *sql.Tx, syncID, sessionID, content string) (bool, error) {
+	var id int64
+	err := tx.QueryRow(
+		`SELECT id FROM user_prompts
+		 WHERE (? != '' AND sync_id = ?)
+		    OR (session_id = ? AND content = ?)
+		 LIMIT 1`,
+		syncID, syncID, sessionID, content,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ensureSessionTx creates a placeholder session for sessionID when none
+// exists, so imported rows never reference a missing session. Placeholders
+// have no directory and start at startedAt (now when empty). It reports
+// whether one was created.
+func (s *Store) ensureSessionTx(tx *sql.Tx, sessionID, project, startedAt string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	if startedAt == "" {
+		startedAt = Now()
+	}
+	res, err := s.execHook(tx,
+		`INSERT OR IGNORE INTO sessions (id, project, directory, started_at) VALUES (?, ?, '', ?)`,
+		sessionID, project, startedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("create placeholder session %s: %w", sessionID, err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ─── Session Repair ──────────────────────────────────────────────────────────
+
+// OrphanedSession is a session ID that observations or prompts reference
+// but the sessions table lacks, typically left by a partial sync chunk or a
+// database written with foreign keys off.
+type OrphanedSession struct {
+	ID           string `json:"id"`
+	Project      string `json:"project"`       // Project of the oldest referencing row
+	FirstSeenAt  string `json:"first_seen_at"` // created_at of the oldest referencing row
+	Observations int    `json:"observations"`
+	Prompts      int    `json:"prompts"`
+}
+
+// OrphanedSessions lists session IDs referenced by observations or prompts
+// that have no session row, oldest first.
+func (s *Store) OrphanedSessions() ([]OrphanedSession, error) {
+	return s.orphanedSessions(s.db)
+}
+
+func (s *Store) orphanedSessions(db queryer) ([]OrphanedSession, error) {
+	// With MIN(), SQLite takes the bare project column from the row holding
+	// the minimum, so Project is the oldest row's.
+	rows, err := s.queryItHook(db, `
+		WITH refs AS (
+			SELECT session_id, project, created_at, 1 AS obs, 0 AS prompts FROM observations
+			UNION ALL
+			SELECT session_id, project, created_at, 0, 1 FROM user_prompts
+		)
+		SELECT session_id, ifnull(project, ''), MIN(created_at), SUM(obs), SUM(prompts)
+		FROM refs
+		WHERE session_id IS NOT NULL AND session_id != ''
+		  AND NOT EXISTS (SELECT 1 FROM sessions WHERE sessions.id = refs.session_id)
+		GROUP BY session_id
+		ORDER BY MIN(created_at), session_id`)
+	if err != nil {
+		return nil, fmt.Errorf("orphaned sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var orphans []OrphanedSession
+	for rows.Next() {
+		var o OrphanedSession
+		if err := rows.Scan(&o.ID, &o.Project, &o.FirstSeenAt, &o.Observations, &o.Prompts); err != nil {
+			return nil, fmt.Errorf("orphaned sessions: %w", err)
+		}
+		orphans = append(orphans, o)
+	}
+	return orphans, rows.Err()
+}
+
+// RepairOrphanedSessions creates a placeholder session for every orphaned
+// session ID, started at its oldest row, and returns the sessions created.
+func (s *Store) RepairOrphanedSessions() ([]OrphanedSession, error) {
+	var orphans []OrphanedSession
+	err := s.withTx(func(tx *sql.Tx) error {
+		var err error
+		if orphans, err = s.orphanedSessions(tx); err != nil {
+			return err
+		}
+		for _, o := range orphans {
+			if _, err := s.ensureSessionTx(tx, o.ID, o.Project, o.FirstSeenAt); err != nil {
+				return fmt.Errorf("repair sessions: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orphans, nil
+}
+
+// ─── Backups ─────────────────────────────────────────────────────────────────
+
+// Backup writes a consistent snapshot of the database to dest using
+// VACUUM INTO. It is safe to call while the store is serving writes; dest
+// must not exist yet.
+func (s *Store) Backup(dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("engram: backup destination %s already exists", dest)
+	}
+	if _, err := s.execHook(s.db, "VACUUM INTO ?", dest); err != nil {
+		return fmt.Errorf("engram: backup to %s: %w", dest, err)
+	}
+	return nil
+}
+
+// VerifyDatabase opens the SQLite file at path and runs PRAGMA
+// integrity_check, returning an error unless SQLite reports "ok".
+func VerifyDatabase(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("engram: verify %s: %w", path, err)
+	}
+	db, err := openDB("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("engram: verify %s: %w", path, err)
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("engram: verify %s: %w", path, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("engram: verify %s: integrity check failed: %s", path, result)
+	}
+	return nil
+}
+
+// ─── Garbage Collection ──────────────────────────────────────────────────────
+
+// GCResult reports what Store.GC found and reclaimed.
+type GCResult struct {
+	// OrphanedFTSRows counts index entries whose observation or prompt row
+	// no longer exists; a non-zero count triggers a full index rebuild.
+	OrphanedFTSRows int   `json:"orphaned_fts_rows"`
+	Rebuilt         bool  `json:"rebuilt"`
+	FTSBytesBefore  int64 `json:"fts_bytes_before"`
+	FTSBytesAfter   int64 `json:"fts_bytes_after"`
+	DBBytesBefore   int64 `json:"db_bytes_before"`
+	DBBytesAfter    int64 `json:"db_bytes_after"`
+	// VacuumMode is "incremental" when free pages were released with
+	// incremental_vacuum, or "full" when this run switched the database to
+	// auto_vacuum=INCREMENTAL with a one-time VACUUM.
+	VacuumMode string `json:"vacuum_mode,omitempty"`
+	// PrunedEvents counts changefeed events dropped for being older than
+	// Config.EventRetention.
+	PrunedEvents int64 `json:"pruned_events"`
+}
+
+// ReclaimedBytes is how much smaller the database got, never negative.
+func (r *GCResult) ReclaimedBytes() int64 {
+	return max(r.DBBytesBefore-r.DBBytesAfter, 0)
+}
+
+// ftsTables pairs each FTS5 index with the table it indexes. rebuild
+// replaces FTS5's own 'rebuild' for an index whose table stores text it
+// cannot read directly: observations keeps compressed content as gzip.
+var ftsTables = []struct{ fts, content, rebuild string }{
+	{"observations_fts", "observations", `
+		INSERT INTO observations_fts(observations_fts) VALUES('delete-all');
+		INSERT INTO observations_fts(rowid, title, content, tool_name, type, project, topic_key)
+		SELECT id, title, engram_inflate(content), tool_name, type, project, topic_key FROM observations;
+	`},
+	{"prompts_fts", "user_prompts", ""},
+}
+
+// GC cleans up the full-text indexes and returns free pages to the file
+// system: changefeed events older than Config.EventRetention are pruned
+// (the newest is always kept), orphaned FTS rows are dropped with a
+// rebuild, both indexes are merged with 'optimize', and free pages are
+// released with incremental_vacuum. A database created without auto_vacuum
+// is converted once with a full VACUUM, which needs as much free disk as
+// the database itself. In-memory stores only get the pruning and index
+// maintenance.
+func (s *Store) GC() (*GCResult, error) {
+	result := &GCResult{}
+	result.DBBytesBefore, result.FTSBytesBefore = s.gcSizes()
+
+	// The newest event stays so Events can still tell an expired cursor
+	// from a current one once everything else is pruned.
+	cutoff := time.Now().UTC().Add(-cmp.Or(s.cfg.EventRetention, DefaultEventRetention)).Format(TimestampLayout)
+	res, err := s.execHook(s.db,
+		`DELETE FROM events WHERE created_at < ? AND seq < (SELECT MAX(seq) FROM events)`, cutoff,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("engram: gc: prune events: %w", err)
+	}
+	if result.PrunedEvents, err = res.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("engram: gc: prune events: %w", err)
+	}
+
+	for _, t := range ftsTables {
+		var orphans int
+		if err := s.db.QueryRow(fmt.Sprintf(
+			"SELECT COUNT(*) FROM %s_docsize WHERE id NOT IN (SELECT id FROM %s)", t.fts, t.content,
+		)).Scan(&orphans); err != nil {
+			return nil, fmt.Errorf("engram: gc: count orphaned %s rows: %w", t.fts, err)
+		}
+		result.OrphanedFTSRows += orphans
+		if orphans > 0 {
+			rebuild := cmp.Or(t.rebuild, fmt.Sprintf("INSERT INTO %s(%s) VALUES('rebuild')", t.fts, t.fts))
+			if err := s.withTx(func(tx *sql.Tx) error {
+				_, err := s.execHook(tx, rebuild)
+				return err
+			}); err != nil {
+				return nil, fmt.Errorf("engram: gc: rebuild %s: %w", t.fts, err)
+			}
+			result.Rebuilt = true
+		}
+		if _, err := s.execHook(s.db, fmt.Sprintf("INSERT INTO %s(%s) VALUES('optimize')", t.fts, t.fts)); err != nil {
+			return nil, fmt.Errorf("engram: gc: optimize %s: %w", t.fts, err)
+		}
+	}
+
+	if !s.cfg.InMemory() {
+		mode, err := s.vacuum()
+		if err != nil {
+			return nil, fmt.Errorf("engram: gc: %w", err)
+		}
+		result.VacuumMode = mode
+	}
+
+	result.DBBytesAfter, result.FTSBytesAfter = s.gcSizes()
+	return result, nil
+}
+
+// vacuum releases free pages and returns the vacuum mode it used. The
+// auto_vacuum change only takes effect on the connection that runs VACUUM,
+// so both statements share one.
+func (s *Store) vacuum() (string, error) {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	var autoVacuum int
+	if err := conn.QueryRowContext(ctx, "PRAGMA auto_vacuum").Scan(&autoVacuum); err != nil {
+		return "", fmt.Errorf("read auto_vacuum: %w", err)
+	}
+	mode := "incremental"
+	if autoVacuum == 0 {
+		mode = "full"
+		for _, stmt := range []string{"PRAGMA auto_vacuum = INCREMENTAL", "VACUUM"} {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return "", fmt.Errorf("%s: %w", stmt, err)
+			}
+		}
+	} else if _, err := conn.ExecContext(ctx, "PRAGMA incremental_vacuum"); err != nil {
+		return "", fmt.Errorf("incremental_vacuum: %w", err)
+	}
+	// Fold the WAL back into the main file so the reclaimed space shows.
+	if _, err := conn.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return "", fmt.Errorf("wal_checkpoint: %w", err)
+	}
+	return mode, nil
+}
+
+// gcSizes returns the database size (page_count × page_size) and the FTS
+// shadow table footprint. Failed probes read as zero.
+func (s *Store) gcSizes() (dbBytes, ftsBytes int64) {
+	s.db.QueryRow(
+		"SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()",
+	).Scan(&dbBytes)
+	s.db.QueryRow(
+		"SELECT ifnull(SUM(pgsize), 0) FROM dbstat WHERE name LIKE 'observations_fts%' OR name LIKE 'prompts_fts%'",
+	).Scan(&ftsBytes)
+	return dbBytes, ftsBytes
+}
+
+// ─── Compression ─────────────────────────────────────────────────────────────
+//
+// Observation content longer than Config.CompressAbove is stored as a gzip
+// BLOB instead of TEXT; the generated compressed column flags those rows.
+// Reads inflate it as they scan (contentColumn) and SQL that needs the text
+// calls engram_inflate(content), as the FTS triggers do. Title, metadata,
+// and prompts are never compressed.
+
+// DefaultCompressAbove is the size CompressObservations compresses above
+// when neither its options nor Config.CompressAbove set one.
+const DefaultCompressAbove = 4 << 10
+
+func init() {
+	if err := sqlite.RegisterDeterministicScalarFunction("engram_inflate", 1, sqlInflate); err != nil {
+		panic(err)
+	}
+}
+
+// sqlInflate is engram_inflate(content): the text of a compressed value,
+// anything else unchanged.
+func sqlInflate(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	packed, ok := args[0].([]byte)
+	if !ok {
+		return args[0], nil
+	}
+	return inflateContent(packed)
+}
+
+// compressContent gzips content longer than above bytes. It reports false
+// when above is not positive, content is short enough, or gzip would not
+// make it smaller.
+func compressContent(content string, above int64) ([]byte, bool) {
+	if above <= 0 || int64(len(content)) <= above {
+		return nil, false
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(zw, content); err != nil {
+		return nil, false
+	}
+	if err := zw.Close(); err != nil || buf.Len() >= len(content) {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// inflateContent returns the text of stored content. A BLOB that is not
+// gzip was written by something other than engram and is read as text.
+func inflateContent(stored []byte) (string, error) {
+	if len(stored) < 2 || stored[0] != 0x1f || stored[1] != 0x8b {
+		return string(stored), nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(stored))
+	if err != nil {
+		return "", fmt.Errorf("engram: inflate content: %w", err)
+	}
+	text, err := io.ReadAll(zr)
+	if err != nil {
+		return "", fmt.Errorf("engram: inflate content: %w", err)
+	}
+	return string(text), nil
+}
+
+// storedContent is the value written to observations.content for content.
+func (s *Store) storedContent(content string) any {
+	if packed, ok := compressContent(content, s.cfg.CompressAbove); ok {
+		return packed
+	}
+	return content
+}
+
+// contentColumn scans observations.content into a string, inflating the
+// rows stored compressed.
+type contentColumn string
+
+func (c *contentColumn) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*c = ""
+	case string:
+		*c = contentColumn(v)
+	case []byte:
+		text, err := inflateContent(v)
+		if err != nil {
+			return err
+		}
+		*c = contentColumn(text)
+	default:
+		return fmt.Errorf("engram: unexpected content type %T", src)
+	}
+	return nil
+}
+
+// withoutEvents runs fn, which must only change how content is stored, and
+// drops the observation.updated events its updates fired: no memory
+// changed, so the feed has nothing to report. The sequence is rewound too,
+// so seq stays gapless and Events can tell a pruned cursor by it.
+func (s *Store) withoutEvents(tx *sql.Tx, fn func() error) error {
+	var last int64
+	if err := tx.QueryRow(`SELECT ifnull(MAX(seq), 0) FROM events`).Scan(&last); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	if _, err := s.execHook(tx, `DELETE FROM events WHERE seq > ?`, last); err != nil {
+		return err
+	}
+	_, err := s.execHook(tx, `UPDATE sqlite_sequence SET seq = ? WHERE name = 'events'`, last)
+	return err
+}
+
+// CompressionStats sums up the observations stored compressed.
+// OriginalBytes comes from the gzip trailer, so it costs no inflating.
+type CompressionStats struct {
+	Rows          int   `json:"rows"`
+	StoredBytes   int64 `json:"stored_bytes"`
+	OriginalBytes int64 `json:"original_bytes"`
+	SavedBytes    int64 `json:"saved_bytes"`
+}
+
+func (s *Store) compressionStats() CompressionStats {
+	var c CompressionStats
+	rows, err := s.queryItHook(s.db, `SELECT length(content), substr(content, -4) FROM observations WHERE compressed`)
+	if err != nil {
+		return c
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var stored int64
+		var trailer []byte
+		if err := rows.Scan(&stored, &trailer); err != nil || len(trailer) != 4 {
+			continue
+		}
+		c.Rows++
+		c.StoredBytes += stored
+		c.OriginalBytes += int64(binary.LittleEndian.Uint32(trailer))
+	}
+	c.SavedBytes = max(c.OriginalBytes-c.StoredBytes, 0)
+	return c
+}
+
+// CompressOptions selects observations for CompressObservations.
+type CompressOptions struct {
+	// Above is the content size in bytes to compress above; <= 0 means
+	// Config.CompressAbove, or DefaultCompressAbove when that is unset.
+	Above  int64 `json:"above,omitempty"`
+	DryRun bool  `json:"dry_run,omitempty"`
+}
+
+// CompressResult reports what CompressObservations matched and, unless it
+// was a dry run, compressed.
+type CompressResult struct {
+	Above int64 `json:"above"`
+	// Matched counts rows stored as text above the threshold; Compressed
+	// the ones gzip made smaller, whose sizes BytesBefore and BytesAfter sum.
+	Matched     int   `json:"matched"`
+	Compressed  int   `json:"compressed"`
+	BytesBefore int64 `json:"bytes_before"`
+	BytesAfter  int64 `json:"bytes_after"`
+	DryRun      bool  `json:"dry_run,omitempty"`
+}
+
+// SavedBytes is how much less content the compressed rows store.
+func (r *CompressResult) SavedBytes() int64 {
+	return r.BytesBefore - r.BytesAfter
+}
+
+// CompressObservations compresses the content of rows saved as text, such
+// as those written before compression was enabled. Soft-deleted rows are
+// included: the point is the size of the file. The text, and so
+// search, exports, and sync, are unchanged, and the events feed is not
+// told. Append-only stores refuse it, as they refuse archiving.
+func (s *Store) CompressObservations(opts CompressOptions) (*CompressResult, error) {
+	if s.cfg.AppendOnly && !opts.DryRun {
+		return nil, fmt.Errorf("compress: %w", ErrAppendOnly)
+	}
+	above := opts.Above
+	if above <= 0 {
+		above = cmp.Or(s.cfg.CompressAbove, DefaultCompressAbove)
+	}
+	result := &CompressResult{Above: above, DryRun: opts.DryRun}
+
+	rows, err := s.queryItHook(s.db,
+		`SELECT id, content FROM observations WHERE typeof(content) = 'text' AND length(CAST(content AS BLOB)) > ? ORDER BY id`, above)
+	if err != nil {
+		return nil, fmt.Errorf("compress: %w", err)
+	}
+	type packedRow struct {
+		id     int64
+		packed []byte
+	}
+	var packed []packedRow
+	for rows.Next() {
+		var id int64
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("compress: %w", err)
+		}
+		result.Matched++
+		p, ok := compressContent(content, above)
+		if !ok {
+			continue
+		}
+		result.Compressed++
+		result.BytesBefore += int64(len(content))
+		result.BytesAfter += int64(len(p))
+		packed = append(packed, packedRow{id: id, packed: p})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("compress: %w", err)
+	}
+	if opts.DryRun || len(packed) == 0 {
+		return result, nil
+	}
+
+	err = s.withTx(func(tx *sql.Tx) error {
+		return s.withoutEvents(tx, func() error {
+			for _, row := range packed {
+				if _, err := s.execHook(tx, `UPDATE observations SET content = ? WHERE id = ? AND typeof(content) = 'text'`, row.packed, row.id); err != nil {
+					return fmt.Errorf("compress #%d: %w", row.id, err)
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ─── Read-only SQL ───────────────────────────────────────────────────────────
+
+// QueryResult is the output of Query. Text and BLOB columns come back as
+// strings, integers as int64, reals as float64, and NULL as nil.
+type QueryResult struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+	// Truncated is set when the statement had more than the requested rows.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Query runs ad-hoc SQL against the database and returns up to limit rows
+// (all of them when limit is 0). It uses its own connection, opened
+// read-only with query_only set, so any write — including one smuggled in
+// after a semicolon — is refused by SQLite rather than by inspecting the
+// text.
+func (s *Store) Query(query string, limit int) (*QueryResult, error) {
+	if s.cfg.InMemory() {
+		return nil, fmt.Errorf("engram query: not supported on an in-memory store")
+	}
+	dsn := "file:" + (&url.URL{Path: s.cfg.DatabasePath()}).EscapedPath() +
+		"?mode=ro&_pragma=busy_timeout(5000)&_pragma=query_only(1)"
+	db, err := openDB("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("engram query: open read-only connection: %w", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &QueryResult{Columns: columns, Rows: [][]any{}}
+	for rows.Next() {
+		if limit > 0 && len(result.Rows) == limit {
+			result.Truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
+
+// ─── Sync Chunk Tracking ─────────────────────────────────────────────────────
+
+// GetSyncedChunks returns a set of chunk IDs that have been imported/exported.
+func (s *Store) GetSyncedChunks() (map[string]bool, error) {
+	rows, err := s.queryItHook(s.db, "SELECT chunk_id FROM sync_chunks")
+	if err != nil {
+		return nil, fmt.Errorf("get synced chunks: %w", err)
+	}
+	defer rows.Close()
+
+	chunks := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		chunks[id] = true
+	}
+	return chunks, rows.Err()
+}
+
+// RecordSyncedChunk marks a chunk as imported/exported so it won't be processed again.
+func (s *Store) RecordSyncedChunk(chunkID string) error {
+	_, err := s.execHook(s.db,
+		"INSERT OR IGNORE INTO sync_chunks (chunk_id, imported_at) VALUES (?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))",
+		chunkID,
+	)
+	return err
+}
+
+// ─── Advisory Locks ──────────────────────────────────────────────────────────
+//
+// Advisory locks serialize work that spans several statements across
+// processes sharing one database, such as a sync export writing a chunk and
+// then recording it. A lock expires after its TTL so a crashed holder
+// cannot wedge every later run.
+
+// ErrLockHeld is returned by AcquireLock while another holder has the lock.
+var ErrLockHeld = errors.New("lock held by another process")
+
+// AcquireLock takes the named lock for holder until ttl elapses or
+// ReleaseLock is called. It fails with ErrLockHeld, naming the current
+// holder, when someone else has an unexpired lock.
+func (s *Store) AcquireLock(name, holder string, ttl time.Duration) error {
+	now := time.Now().UTC()
+	res, err := s.execHook(s.db, `
+		INSERT INTO advisory_locks (name, holder, acquired_at, expires_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			holder      = excluded.holder,
+			acquired_at = excluded.acquired_at,
+			expires_at  = excluded.expires_at
+		WHERE advisory_locks.expires_at <= excluded.acquired_at`,
+		name, holder, now.Format(TimestampLayout), now.Add(ttl).Format(TimestampLayout),
+	)
+	if err != nil {
+		return fmt.Errorf("acquire lock %s: %w", name, err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	var current, since string
+	if err := s.db.QueryRow(
+		"SELECT holder, acquired_at FROM advisory_locks WHERE name = ?", name,
+	).Scan(&current, &since); err != nil {
+		return fmt.Errorf("%w: %s", ErrLockHeld, name)
+	}
+	return fmt.Errorf("%w: %s held by %s since %s", ErrLockHeld, name, current, since)
+}
+
+// ReleaseLock drops the named lock if holder still has it.
+func (s *Store) ReleaseLock(name, holder string) error {
+	_, err := s.execHook(s.db, "DELETE FROM advisory_locks WHERE name = ? AND holder = ?", name, holder)
+	if err != nil {
+		return fmt.Errorf("release lock %s: %w", name, err)
+	}
+	return nil
+}
+
+// ─── Local Sync State & Mutation Journal ─────────────────────────────────────
+
+func (s *Store) GetSyncState(targetKey string) (*SyncState, error) {
+	targetKey = normalizeSyncTargetKey(targetKey)
+	if err := s.ensureSyncState(targetKey); err != nil {
+		return nil, err
+	}
+	return s.getSyncState(targetKey)
+}
+
+func (s *Store) ListPendingSyncMutations(targetKey string, limit int) ([]SyncMutation, error) {
+	targetKey = normalizeSyncTargetKey(targetKey)
+	if limit <= 0 {
+		limit = 100
+	}
+	// Only return mutations for enrolled projects or empty-project (global) mutations.
+	// Empty-project mutations always sync regardless of enrollment.
+	rows, err := s.queryItHook(s.db, `
+		SELECT sm.seq, sm.target_key, sm.entity, sm.entity_key, sm.op, sm.payload, sm.source, sm.project, sm.occurred_at, sm.acked_at
+		FROM sync_mutations sm
+		LEFT JOIN sync_enrolled_projects sep ON sm.project = sep.project
+		WHERE sm.target_key = ? AND sm.acked_at IS NULL
+		  AND (sm.project = '' OR sep.project IS NOT NULL)
+		ORDER BY sm.seq ASC
+		LIMIT ?`, targetKey, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mutations []SyncMutation
+	for rows.Next() {
+		var mutation SyncMutation
+		if err := rows.Scan(&mutation.Seq, &mutation.TargetKey, &mutation.Entity, &mutation.EntityKey, &mutation.Op, &mutation.Payload, &mutation.Source, &mutation.Project, &mutation.OccurredAt, &mutation.AckedAt); err != nil {
+			return nil, err
+		}
+		mutations = append(mutations, mutation)
+	}
+	return mutations, rows.Err()
+}
+
+// SkipAckNonEnrolledMutations acks (marks as skipped) all pending mutations
+// that belong to non-enrolled projects, preventing journal bloat. Empty-project
+// mutations are never skipped — they always sync regardless of enrollment.
+func (s *Store) SkipAckNonEnrolledMutations(targetKey string) (int64, error) {
+	targetKey = normalizeSyncTargetKey(targetKey)
+	res, err := s.execHook(s.db, `
+		UPDATE sync_mutations
+		SET acked_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE target_key = ?
+		  AND acked_at IS NULL
+		  AND project != ''
+		  AND project NOT IN (SELECT project FROM sync_enrolled_projects)`,
+		targetKey,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) AckSyncMutations(targetKey string, lastAckedSeq int64) error {
+	if lastAckedSeq <= 0 {
+		return nil
+	}
+	targetKey = normalizeSyncTargetKey(targetKey)
+	return s.withTx(func(tx *sql.Tx) error {
+		state, err := s.getSyncStateTx(tx, targetKey)
+		if err != nil {
+			return err
+		}
+		if _, err := s.execHook(tx,
+			`UPDATE sync_mutations SET acked_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') WHERE target_key = ? AND seq <= ? AND acked_at IS NULL`,
+			targetKey, lastAckedSeq,
+		); err != nil {
+			return err
+		}
+		acked := state.LastAckedSeq
+		if lastAckedSeq > acked {
+			acked = lastAckedSeq
+		}
+		lifecycle := SyncLifecyclePending
+		if acked >= state.LastEnqueuedSeq {
+			lifecycle = SyncLifecycleHealthy
+		}
+		_, err = s.execHook(tx,
+			`UPDATE sync_state
+			 SET last_acked_seq = ?, lifecycle = ?, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+			 WHERE target_key = ?`,
+			acked, lifecycle, targetKey,
+		)
+		return err
+	})
+}
+
+// AckSyncMutationSeqs acknowledges specific mutation sequence numbers without
+// requiring them to be contiguous.
+func (s *Store) AckSyncMutationSeqs(targetKey string, seqs []int64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+	targetKey = normalizeSyncTargetKey(targetKey)
+	return s.withTx(func(tx *sql.Tx) error {
+		state, err := s.getSyncStateTx(tx, targetKey)
+		if err != nil {
+			return err
+		}
+		maxSeq := state.LastAckedSeq
+		for _, seq := range seqs {
+			if seq <= 0 {
+				continue
+			}
+			if _, err := s.execHook(tx,
+				`UPDATE sync_mutations SET acked_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') WHERE target_key = ? AND seq = ? AND acked_at IS NULL`,
+				targetKey, seq,
+			); err != nil {
+				return err
+			}
+			if seq > maxSeq {
+				maxSeq = seq
+			}
+		}
+		var remaining int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM sync_mutations WHERE target_key = ? AND acked_at IS NULL`, targetKey).Scan(&remaining); err != nil {
+			return err
+		}
+		lifecycle := SyncLifecyclePending
+		if remaining == 0 {
+			lifecycle = SyncLifecycleHealthy
+		}
+		_, err = s.execHook(tx,
+			`UPDATE sync_state SET last_acked_seq = ?, lifecycle = ?, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') WHERE target_key = ?`,
+			maxSeq, lifecycle, targetKey,
+		)
+		return err
+	})
+}
+
+func (s *Store) AcquireSyncLease(targetKey, owner string, ttl time.Duration, now time.Time) (bool, error) {
+	targetKey = normalizeSyncTargetKey(targetKey)
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+
+	var acquired bool
+	err := s.withTx(func(tx *sql.Tx) error {
+		state, err := s.getSyncStateTx(tx, targetKey)
+		if err != nil {
+			return err
+		}
+		if state.LeaseUntil != nil {
+			leaseUntil, err := time.Parse(time.RFC3339, *state.LeaseUntil)
+			if err == nil && leaseUntil.After(now) && derefString(state.LeaseOwner) != "" && derefString(state.LeaseOwner) != owner {
+				acquired = false
+				return nil
+			}
+		}
+		leaseUntil := now.Add(ttl).UTC().Format(time.RFC3339)
+		_, err = s.execHook(tx,
+			`UPDATE sync_state
+			 SET lease_owner = ?, lease_until = ?, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+			 WHERE target_key = ?`,
+			owner, leaseUntil, targetKey,
+		)
+		if err == nil {
+			acquired = true
+		}
+		return err
+	})
+	return acquired, err
+}
+
+func (s *Store) ReleaseSyncLease(targetKey, owner string) error {
+	targetKey = normalizeSyncTargetKey(targetKey)
+	_, err := s.execHook(s.db,
+		`UPDATE sync_state
+		 SET lease_owner = NULL, lease_until = NULL, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		 WHERE target_key = ? AND (lease_owner = ? OR lease_owner IS NULL OR lease_owner = '')`,
+		targetKey, owner,
+	)
+	return err
+}
+
+func (s *Store) MarkSyncFailure(targetKey, message string, backoffUntil time.Time) error {
+	targetKey = normalizeSyncTargetKey(targetKey)
+	backoff := backoffUntil.UTC().Format(time.RFC3339)
+	return s.withTx(func(tx *sql.Tx) error {
+		state, err := s.getSyncStateTx(tx, targetKey)
+		if err != nil {
+			return err
+		}
+		_, err = s.execHook(tx,
+			`UPDATE sync_state
+			 SET lifecycle = ?, consecutive_failures = ?, backoff_until = ?, last_error = ?, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+			 WHERE target_key = ?`,
+			SyncLifecycleDegraded, state.ConsecutiveFailures+1, backoff, message, targetKey,
+		)
+		return err
+	})
+}
+
+func (s *Store) MarkSyncHealthy(targetKey string) error {
+	targetKey = normalizeSyncTargetKey(targetKey)
+	_, err := s.execHook(s.db,
+		`UPDATE sync_state
+		 SET lifecycle = ?, consecutive_failures = 0, backoff_until = NULL, last_error = NULL, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		 WHERE target_key = ?`,
+		SyncLifecycleHealthy, targetKey,
+	)
+	return err
+}
+
+func (s *Store) ApplyPulledMutation(targetKey string, mutation SyncMutation) error {
+	targetKey = normalizeSyncTargetKey(targetKey)
+	return s.withTx(func(tx *sql.Tx) error {
+		state, err := s.getSyncStateTx(tx, targetKey)
+		if err != nil {
+			return err
+		}
+		if mutation.Seq <= state.LastPulledSeq {
+			return nil
+		}
+
+		switch mutation.Entity {
+		case SyncEntitySession:
+			var payload syncSessionPayload
+			if err := decodeSyncPayload([]byte(mutation.Payload), &payload); err != nil {
+				return err
+			}
+			if err := s.applySessionPayloadTx(tx, payload); err != nil {
+				return err
+			}
+		case SyncEntityObservation:
+			var payload syncObservationPayload
+			if err := decodeSyncPayload([]byte(mutation.Payload), &payload); err != nil {
+				return err
+			}
+			if mutation.Op == SyncOpDelete {
+				if err := s.applyObservationDeleteTx(tx, payload); err != nil {
+					return err
+				}
+			} else {
+				if err := s.applyObservationUpsertTx(tx, payload); err != nil {
+					return err
+				}
+			}
+		case SyncEntityPrompt:
+			var payload syncPromptPayload
+			if err := decodeSyncPayload([]byte(mutation.Payload), &payload); err != nil {
+				return err
+			}
+			if err := s.applyPromptUpsertTx(tx, payload); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown sync entity %q", mutation.Entity)
+		}
+
+		_, err = s.execHook(tx,
+			`UPDATE sync_state
+			 SET last_pulled_seq = ?, lifecycle = ?, consecutive_failures = 0, backoff_until = NULL, last_error = NULL, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+			 WHERE target_key = ?`,
+			mutation.Seq, SyncLifecycleHealthy, targetKey,
+		)
+		return err
+	})
+}
+
+func (s *Store) GetObservationBySyncID(syncID string) (*Observation, error) {
+	row := s.db.QueryRow(
+		`SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
+		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata
+		 FROM observations WHERE sync_id = ? AND deleted_at IS NULL ORDER BY id DESC LIMIT 1`,
+		syncID,
+	)
+	var o Observation
+	if err := row.Scan(&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, (*contentColumn)(&o.Content), &o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt, &o.Refs, &o.VerifiedAt, &o.StaleAt, &o.VerificationNote, &o.Source, &o.Metadata); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// ─── Project Enrollment for Cloud Sync ───────────────────────────────────────
+
+// EnrollProject registers a project for cloud sync. Idempotent — re-enrolling
+// an already-enrolled project is a no-op.
+func (s *Store) EnrollProject(project string) error {
+	if project == "" {
+		return invalidf("project name must not be empty")
+	}
+	return s.withTx(func(tx *sql.Tx) error {
+		res, err := s.execHook(tx,
+			`INSERT OR IGNORE INTO sync_enrolled_projects (project, enrolled_at) VALUES (?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))`,
+			project,
+		)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+		return s.backfillProjectSyncMutationsTx(tx, project)
+	})
+}
+
+// UnenrollProject removes a project from cloud sync enrollment. Idempotent —
+// unenrolling a non-enrolled project is a no-op.
+func (s *Store) UnenrollProject(project string) error {
+	if project == "" {
+		return invalidf("project name must not be empty")
+	}
+	_, err := s.execHook(s.db,
+		`DELETE FROM sync_enrolled_projects WHERE project = ?`,
+		project,
+	)
+	return err
+}
+
+// ListEnrolledProjects returns all projects currently enrolled for cloud sync,
+// ordered alphabetically by project name.
+func (s *Store) ListEnrolledProjects() ([]EnrolledProject, error) {
+	rows, err := s.queryItHook(s.db,
+		`SELECT project, enrolled_at FROM sync_enrolled_projects ORDER BY project ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []EnrolledProject
+	for rows.Next() {
+		var ep EnrolledProject
+		if err := rows.Scan(&ep.Project, &ep.EnrolledAt); err != nil {
+			return nil, err
+		}
+		projects = append(projects, ep)
+	}
+	return projects, rows.Err()
+}
+
+// IsProjectEnrolled returns true if the given project is enrolled for cloud sync.
+func (s *Store) IsProjectEnrolled(project string) (bool, error) {
+	var exists int
+	err := s.db.QueryRow(
+		`SELECT 1 FROM sync_enrolled_projects WHERE project = ? LIMIT 1`,
+		project,
+	).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ─── Project Migration ───────────────────────────────────────────────────────
+
+type MigrateResult struct {
+	Migrated            bool  `json:"migrated"`
+	ObservationsUpdated int64 `json:"observations_updated"`
+	SessionsUpdated     int64 `json:"sessions_updated"`
+	PromptsUpdated      int64 `json:"prompts_updated"`
+}
+
+func (s *Store) MigrateProject(oldName, newName string) (*MigrateResult, error) {
+	if oldName == "" || newName == "" || oldName == newName {
+		return &MigrateResult{}, nil
+	}
+	if s.cfg.AppendOnly {
+		return nil, fmt.Errorf("migrate project: %w", ErrAppendOnly)
+	}
+
+	// Check if old project has any records (short-circuit on first match)
+	var exists bool
+	err := s.db.QueryRow(
+		`SELECT EXISTS(
+			SELECT 1 FROM observations WHERE project = ?
+			UNION ALL
+			SELECT 1 FROM sessions WHERE project = ?
+			UNION ALL
+			SELECT 1 FROM user_prompts WHERE project = ?
+		)`, oldName, oldName, oldName,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("check old project: %w", err)
+	}
+	if !exists {
+		return &MigrateResult{}, nil
+	}
+
+	result := &MigrateResult{Migrated: true}
+
+	err = s.withTx(func(tx *sql.Tx) error {
+		// FTS triggers handle index updates automatically on UPDATE
+		res, err := s.execHook(tx, `UPDATE observations SET project = ? WHERE project = ?`, newName, oldName)
+		if err != nil {
+			return fmt.Errorf("migrate observations: %w", err)
+		}
+		result.ObservationsUpdated, _ = res.RowsAffected()
+
+		res, err = s.execHook(tx, `UPDATE sessions SET project = ? WHERE project = ?`, newName, oldName)
+		if err != nil {
+			return fmt.Errorf("migrate sessions: %w", err)
+		}
+		result.SessionsUpdated, _ = res.RowsAffected()
+
+		res, err = s.execHook(tx, `UPDATE user_prompts SET project = ? WHERE project = ?`, newName, oldName)
+		if err != nil {
+			return fmt.Errorf("migrate prompts: %w", err)
+		}
+		result.PromptsUpdated, _ = res.RowsAffected()
+
+		// Enqueue sync mutations so cloud sync picks up the migrated records.
+		// Same pattern used by EnrollProject and MergeProjects.
+		return s.backfillProjectSyncMutationsTx(tx, newName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ─── Project Queries ──────────────────────────────────────────────────────────
+
+// ProjectNameCount holds a project name and how many observations it has.
+type ProjectNameCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// ListProjectNames returns all distinct project names from observations,
+// ordered alphabetically. Used for fuzzy matching and consolidation.
+func (s *Store) ListProjectNames() ([]string, error) {
+	rows, err := s.queryItHook(s.db,
+		`SELECT DISTINCT project FROM observations
+		 WHERE project IS NOT NULL AND project != '' AND deleted_at IS NULL
+		 ORDER BY project`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		results = append(results, name)
+	}
+	return results, rows.Err()
+}
+
+// ProjectStats holds aggregate statistics for a single project.
+type ProjectStats struct {
+	Name             string   `json:"name"`
+	ObservationCount int      `json:"observation_count"`
+	SessionCount     int      `json:"session_count"`
+	PromptCount      int      `json:"prompt_count"`
+	Directories      []string `json:"directories"` // unique directories from sessions
+}
+
+// ListProjectsWithStats returns all projects with aggregated counts.
+// Ordered by observation count descending.
+func (s *Store) ListProjectsWithStats() ([]ProjectStats, error) {
+	// Observation counts per project
+	obsRows, err := s.queryItHook(s.db,
+		`SELECT project, COUNT(*) as cnt
+		 FROM observations
+		 WHERE project IS NOT NULL AND project != '' AND deleted_at IS NULL
+		 GROUP BY project`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list projects obs: %w", err)
+	}
+	defer obsRows.Close()
+
+	statsMap := make(map[string]*ProjectStats)
+	for obsRows.Next() {
+		var name string
+		var cnt int
+		if err := obsRows.Scan(&name, &cnt); err != nil {
+			return nil, err
+		}
+		statsMap[name] = &ProjectStats{Name: name, ObservationCount: cnt}
+	}
+	if err := obsRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Session counts + directories per project
+	sessRows, err := s.queryItHook(s.db,
+		`SELECT project, COUNT(*) as cnt, directory
+		 FROM sessions
+		 WHERE project IS NOT NULL AND project != ''
+		 GROUP BY project, directory`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list projects sessions: %w", err)
+	}
+	defer sessRows.Close()
+
+	type projDir struct {
+		count int
+		dirs  map[string]bool
+	}
+	sessData := make(map[string]*projDir)
+	for sessRows.Next() {
+		var name, dir string
+		var cnt int
+		if err := sessRows.Scan(&name, &cnt, &dir); err != nil {
+			return nil, err
+		}
+		if sessData[name] == nil {
+			sessData[name] = &projDir{dirs: make(map[string]bool)}
+		}
+		sessData[name].count += cnt
+		if dir != "" {
+			sessData[name].dirs[dir] = true
+		}
+	}
+	if err := sessRows.Err(); err != nil {
+		return nil, err
+	}
+
+	for name, sd := range sessData {
+		if statsMap[name] == nil {
+			statsMap[name] = &ProjectStats{Name: name}
+		}
+		statsMap[name].SessionCount = sd.count
+		for d := range sd.dirs {
+			statsMap[name].Directories = append(statsMap[name].Directories, d)
+		}
+	}
+
+	// Prompt counts per project
+	promptRows, err := s.queryItHook(s.db,
+		`SELECT project, COUNT(*) as cnt
+		 FROM user_prompts
+		 WHERE project IS NOT NULL AND project != ''
+		 GROUP BY project`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list projects prompts: %w", err)
+	}
+	defer promptRows.Close()
+
+	for promptRows.Next() {
+		var name string
+		var cnt int
+		if err := promptRows.Scan(&name, &cnt); err != nil {
+			return nil, err
+		}
+		if statsMap[name] == nil {
+			statsMap[name] = &ProjectStats{Name: name}
+		}
+		statsMap[name].PromptCount = cnt
+	}
+	if err := promptRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Convert to slice, sorted by observation count descending
+	results := make([]ProjectStats, 0, len(statsMap))
+	for _, ps := range statsMap {
+		results = append(results, *ps)
+	}
+	// Simple insertion sort — project lists are small
+	for i := 1; i < len(results); i++ {
+		for j := i; j > 0 && results[j].ObservationCount > results[j-1].ObservationCount; j-- {
+			results[j], results[j-1] = results[j-1], results[j]
+		}
+	}
+
+	return results, nil
+}
+
+// CountObservationsForProject returns the number of non-deleted observations
+// for the given project name. Used by handleSave for the similar-project
+// warning instead of the heavier ListProjectsWithStats.
+func (s *Store) CountObservationsForProject(name string) (int, error) {
+	var count int
+	err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM observations WHERE project = ? AND deleted_at IS NULL`,
+		name,
+	).Scan(&count)
+	return count, err
+}
+
+// MergeResult summarizes the result of merging multiple project name variants
+// into a single canonical project name.
+type MergeResult struct {
+	Canonical           string   `json:"canonical"`
+	SourcesMerged       []string `json:"sources_merged"`
+	ObservationsUpdated int64    `json:"observations_updated"`
+	SessionsUpdated     int64    `json:"sessions_updated"`
+	PromptsUpdated      int64    `json:"prompts_updated"`
+}
+
+// MergeProjects migrates all records from each source project name into the
+// canonical name. Sources that equal the canonical (after normalization) or
+// have no records are silently skipped — the operation is idempotent.
+// All updates are performed inside a single transaction for atomicity.
+func (s *Store) MergeProjects(sources []string, canonical string) (*MergeResult, error) {
+	if s.cfg.AppendOnly {
+		return nil, fmt.Errorf("merge projects: %w", ErrAppendOnly)
+	}
+	canonical, _ = NormalizeProject(canonical)
+	if canonical == "" {
+		return nil, invalidf("canonical project name must not be empty")
+	}
+
+	result := &MergeResult{Canonical: canonical}
+
+	err := s.withTx(func(tx *sql.Tx) error {
+		for _, src := range sources {
+			src, _ = NormalizeProject(src)
+			if src == "" || src == canonical {
+				continue
+			}
+
+			res, err := s.execHook(tx, `UPDATE observations SET project = ? WHERE project = ?`, canonical, src)
+			if err != nil {
+				return fmt.Errorf("merge observations %q → %q: %w", src, canonical, err)
+			}
+			n, _ := res.RowsAffected()
+			result.ObservationsUpdated += n
+
+			res, err = s.execHook(tx, `UPDATE sessions SET project = ? WHERE project = ?`, canonical, src)
+			if err != nil {
+				return fmt.Errorf("merge sessions %q → %q: %w", src, canonical, err)
+			}
+			n, _ = res.RowsAffected()
+			result.SessionsUpdated += n
+
+			res, err = s.execHook(tx, `UPDATE user_prompts SET project = ? WHERE project = ?`, canonical, src)
+			if err != nil {
+				return fmt.Errorf("merge prompts %q → %q: %w", src, canonical, err)
+			}
+			n, _ = res.RowsAffected()
+			result.PromptsUpdated += n
 
-// EnrollProject registers a project for cloud sync. Idempotent — re-enrolling
-// an already-enrolled project is a no-op.
-func (s *Store) EnrollProject(project string) error {
-	if project == "" {
-		return fmt.Errorf("project name must not be empty")
-	}
-	return s.withTx(func(tx *sql.Tx) error {
-		res, err := s.execHook(tx,
-			`INSERT OR IGNORE INTO sync_enrolled_projects (project) VALUES (?)`,
-			project,
-		)
-		if err != nil {
-			return err
-		}
-		rowsAffected, err := res.RowsAffected()
-		if err != nil {
-			return err
-		}
-		if rowsAffected == 0 {
-			return nil
+			result.SourcesMerged = append(result.SourcesMerged, src)
 		}
-		return s.backfillProjectSyncMutationsTx(tx, project)
+		// Enqueue sync mutations so cloud sync picks up the merged records.
+		// Same pattern used by EnrollProject.
+		return s.backfillProjectSyncMutationsTx(tx, canonical)
 	})
-}
-
-// UnenrollProject removes a project from cloud sync enrollment. Idempotent —
-// unenrolling a non-enrolled project is a no-op.
-func (s *Store) UnenrollProject(project string) error {
-	if project == "" {
-		return fmt.Errorf("project name must not be empty")
-	}
-	_, err := s.execHook(s.db,
-		`DELETE FROM sync_enrolled_projects WHERE project = ?`,
-		project,
-	)
-	return err
-}
-
-// ListEnrolledProjects returns all projects currently enrolled for cloud sync,
-// ordered alphabetically by project name.
-func (s *Store) ListEnrolledProjects() ([]EnrolledProject, error) {
-	rows, err := s.queryItHook(s.db,
-		`SELECT project, enrolled_at FROM sync_enrolled_projects ORDER BY project ASC`)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var projects []EnrolledProject
-	for rows.Next() {
-		var ep EnrolledProject
-		if err := rows.Scan(&ep.Project, &ep.EnrolledAt); err != nil {
-			return nil, err
-		}
-		projects = append(projects, ep)
-	}
-	return projects, rows.Err()
-}
 
-// IsProjectEnrolled returns true if the given project is enrolled for cloud sync.
-func (s *Store) IsProjectEnrolled(project string) (bool, error) {
-	var exists int
-	err := s.db.QueryRow(
-		`SELECT 1 FROM sync_enrolled_projects WHERE project = ? LIMIT 1`,
-		project,
-	).Scan(&exists)
-	if err == sql.ErrNoRows {
-		return false, nil
-	}
-	if err != nil {
-		return false, err
-	}
-	return true, nil
+	return result, nil
 }
 
-// ─── Project Migration ───────────────────────────────────────────────────────
+// ─── Project Pruning ─────────────────────────────────────────────────────────
 
-type MigrateResult struct {
-	Migrated            bool  `json:"migrated"`
-	ObservationsUpdated int64 `json:"observations_updated"`
-	SessionsUpdated     int64 `json:"sessions_updated"`
-	PromptsUpdated      int64 `json:"prompts_updated"`
+// PruneResult holds the outcome of pruning a single project.
+type PruneResult struct {
+	Project         string `json:"project"`
+	SessionsDeleted int64  `json:"sessions_deleted"`
+	PromptsDeleted  int64  `json:"prompts_deleted"`
 }
 
-func (s *Store) MigrateProject(oldName, newName string) (*MigrateResult, error) {
-	if oldName == "" || newName == "" || oldName == newName {
-		return &MigrateResult{}, nil
+// PruneProject removes all sessions and prompts for a project that has zero
+// (non-deleted) observations. Returns an error if the project still has
+// observations — the caller must verify first.
+func (s *Store) PruneProject(project string) (*PruneResult, error) {
+	if project == "" {
+		return nil, invalidf("project name must not be empty")
 	}
 
-	// Check if old project has any records (short-circuit on first match)
-	var exists bool
-	err := s.db.QueryRow(
-		`SELECT EXISTS(
-			SELECT 1 FROM observations WHERE project = ?
-			UNION ALL
-			SELECT 1 FROM sessions WHERE project = ?
-			UNION ALL
-			SELECT 1 FROM user_prompts WHERE project = ?
-		)`, oldName, oldName, oldName,
-	).Scan(&exists)
+	// Safety check: refuse to prune if observations exist.
+	count, err := s.CountObservationsForProject(project)
 	if err != nil {
-		return nil, fmt.Errorf("check old project: %w", err)
+		return nil, fmt.Errorf("count observations: %w", err)
 	}
-	if !exists {
-		return &MigrateResult{}, nil
+	if count > 0 {
+		return nil, fmt.Errorf("project %q still has %d observations — cannot prune", project, count)
 	}
 
-	result := &MigrateResult{Migrated: true}
+	result := &PruneResult{Project: project}
 
 	err = s.withTx(func(tx *sql.Tx) error {
-		// FTS triggers handle index updates automatically on UPDATE
-		res, err := s.execHook(tx, `UPDATE observations SET project = ? WHERE project = ?`, newName, oldName)
-		if err != nil {
-			return fmt.Errorf("migrate observations: %w", err)
-		}
-		result.ObservationsUpdated, _ = res.RowsAffected()
-
-		res, err = s.execHook(tx, `UPDATE sessions SET project = ? WHERE project = ?`, newName, oldName)
+		res, err := s.execHook(tx, `DELETE FROM user_prompts WHERE project = ?`, project)
 		if err != nil {
-			return fmt.Errorf("migrate sessions: %w", err)
+			return fmt.Errorf("prune prompts: %w", err)
 		}
-		result.SessionsUpdated, _ = res.RowsAffected()
+		result.PromptsDeleted, _ = res.RowsAffected()
 
-		res, err = s.execHook(tx, `UPDATE user_prompts SET project = ? WHERE project = ?`, newName, oldName)
+		res, err = s.execHook(tx, `DELETE FROM sessions WHERE project = ?`, project)
 		if err != nil {
-			return fmt.Errorf("migrate prompts: %w", err)
+			return fmt.Errorf("prune sessions: %w", err)
 		}
-		result.PromptsUpdated, _ = res.RowsAffected()
+		result.SessionsDeleted, _ = res.RowsAffected()
 
-		// Enqueue sync mutations so cloud sync picks up the migrated records.
-		// Same pattern used by EnrollProject and MergeProjects.
-		return s.backfillProjectSyncMutationsTx(tx, newName)
+		return nil
 	})
 	if err != nil {
 		return nil, err
@@ -2368,290 +9550,897 @@ func (s *Store) MigrateProject(oldName, newName string) (*MigrateResult, error)
 	return result, nil
 }
 
-// ─── Project Queries ──────────────────────────────────────────────────────────
+// ─── Tracker Refs ────────────────────────────────────────────────────────────
 
-// ProjectNameCount holds a project name and how many observations it has.
-type ProjectNameCount struct {
-	Name  string `json:"name"`
-	Count int    `json:"count"`
+// RefList holds the issue/PR references attached to an observation: GitHub
+// and GitLab URLs or shorthands like #123, !45, and owner/repo#123. It is
+// stored as a JSON array in observations.refs.
+type RefList []string
+
+func (r *RefList) Scan(src any) error {
+	var raw string
+	switch v := src.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("refs: unsupported column type %T", src)
+	}
+	if raw == "" {
+		*r = nil
+		return nil
+	}
+	var refs []string
+	if err := json.Unmarshal([]byte(raw), &refs); err != nil {
+		return fmt.Errorf("refs: %w", err)
+	}
+	*r = refs
+	return nil
 }
 
-// ListProjectNames returns all distinct project names from observations,
-// ordered alphabetically. Used for fuzzy matching and consolidation.
-func (s *Store) ListProjectNames() ([]string, error) {
-	rows, err := s.queryItHook(s.db,
-		`SELECT DISTINCT project FROM observations
-		 WHERE project IS NOT NULL AND project != '' AND deleted_at IS NULL
-		 ORDER BY project`,
-	)
+func (r RefList) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal([]string(r))
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return string(encoded), nil
+}
 
-	var results []string
-	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
-			return nil, err
+var (
+	githubRefURLRegex = regexp.MustCompile(`https?://github\.com/[\w.-]+/[\w.-]+/(?:issues|pull)/\d+`)
+	gitlabRefURLRegex = regexp.MustCompile(`https?://[\w.-]+(?:/[\w.-]+)+/-/(?:issues|merge_requests)/\d+`)
+	// Shorthands must start a word so "page#12" or "v1!2" are not refs.
+	shortRefRegex = regexp.MustCompile(`(?:^|[\s(\[])((?:[\w.-]+/[\w.-]+)?[#!]\d+)\b`)
+)
+
+// DetectRefs returns the tracker references found in text, in order of
+// first appearance and without duplicates.
+func DetectRefs(text string) []string {
+	var refs []string
+	refs = append(refs, githubRefURLRegex.FindAllString(text, -1)...)
+	refs = append(refs, gitlabRefURLRegex.FindAllString(text, -1)...)
+	for _, m := range shortRefRegex.FindAllStringSubmatch(text, -1) {
+		refs = append(refs, m[1])
+	}
+	return mergeRefs(refs)
+}
+
+// mergeRefs concatenates ref lists, trimming blanks and dropping duplicates.
+func mergeRefs(lists ...[]string) RefList {
+	var out RefList
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, ref := range list {
+			ref = strings.TrimSpace(ref)
+			if ref == "" || seen[ref] {
+				continue
+			}
+			seen[ref] = true
+			out = append(out, ref)
 		}
-		results = append(results, name)
 	}
-	return results, rows.Err()
+	return out
 }
 
-// ProjectStats holds aggregate statistics for a single project.
-type ProjectStats struct {
-	Name             string   `json:"name"`
-	ObservationCount int      `json:"observation_count"`
-	SessionCount     int      `json:"session_count"`
-	PromptCount      int      `json:"prompt_count"`
-	Directories      []string `json:"directories"` // unique directories from sessions
+// observationRefs is the refs column value for an observation: the explicit
+// refs plus everything detected in its title and content.
+func observationRefs(explicit []string, title, content string) RefList {
+	return mergeRefs(explicit, DetectRefs(title), DetectRefs(content))
 }
 
-// ListProjectsWithStats returns all projects with aggregated counts.
-// Ordered by observation count descending.
-func (s *Store) ListProjectsWithStats() ([]ProjectStats, error) {
-	// Observation counts per project
-	obsRows, err := s.queryItHook(s.db,
-		`SELECT project, COUNT(*) as cnt
-		 FROM observations
-		 WHERE project IS NOT NULL AND project != '' AND deleted_at IS NULL
-		 GROUP BY project`,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("list projects obs: %w", err)
+// refLikePatterns expands a ref filter into LIKE patterns so shorthands
+// also match full URLs: "#123" matches .../issues/123 and .../pull/123,
+// "!45" matches .../merge_requests/45, and "owner/repo#7" matches that
+// repo's issue, PR, or MR URLs.
+func refLikePatterns(ref string) []string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil
 	}
-	defer obsRows.Close()
+	patterns := []string{escapeLike(ref)}
+	switch {
+	case strings.HasPrefix(ref, "#"):
+		n := escapeLike(ref[1:])
+		// "%_#N" matches owner/repo#N but not #1N.
+		patterns = append(patterns, "%_#"+n, "%/issues/"+n, "%/pull/"+n)
+	case strings.HasPrefix(ref, "!"):
+		n := escapeLike(ref[1:])
+		patterns = append(patterns, "%_!"+n, "%/merge_requests/"+n)
+	default:
+		if repo, n, ok := strings.Cut(ref, "#"); ok && strings.Contains(repo, "/") {
+			repo, n = escapeLike(repo), escapeLike(n)
+			patterns = append(patterns,
+				"%/"+repo+"/issues/"+n, "%/"+repo+"/pull/"+n,
+				"%/"+repo+"/-/issues/"+n, "%/"+repo+"/-/merge_requests/"+n)
+		}
+	}
+	return patterns
+}
 
-	statsMap := make(map[string]*ProjectStats)
-	for obsRows.Next() {
-		var name string
-		var cnt int
-		if err := obsRows.Scan(&name, &cnt); err != nil {
-			return nil, err
+// refFilterSQL returns an "AND EXISTS (...)" clause matching observations
+// (aliased by column, e.g. "o.refs") that carry ref.
+func refFilterSQL(column, ref string) (string, []any) {
+	patterns := refLikePatterns(ref)
+	if len(patterns) == 0 {
+		return "", nil
+	}
+	conds := make([]string, len(patterns))
+	args := make([]any, len(patterns))
+	for i, p := range patterns {
+		conds[i] = `r.value LIKE ? ESCAPE '\'`
+		args[i] = p
+	}
+	return " AND EXISTS (SELECT 1 FROM json_each(" + column + ") r WHERE " + strings.Join(conds, " OR ") + ")", args
+}
+
+// typeFilterSQL matches one type and/or any of a set of types.
+func typeFilterSQL(column, typ string, types []string) (string, []any) {
+	var clause string
+	var args []any
+	if typ != "" {
+		clause += " AND " + column + " = ?"
+		args = append(args, typ)
+	}
+	if len(types) > 0 {
+		clause += " AND " + column + " IN (" + placeholders(len(types)) + ")"
+		for _, t := range types {
+			args = append(args, t)
 		}
-		statsMap[name] = &ProjectStats{Name: name, ObservationCount: cnt}
 	}
-	if err := obsRows.Err(); err != nil {
-		return nil, err
+	return clause, args
+}
+
+// projectFilterSQL matches project and every sub-project under it, so
+// "platform" also matches "platform/api" and "platform/web/ui".
+func projectFilterSQL(column, project string) (string, []any) {
+	return " AND (" + column + " = ? OR " + column + ` LIKE ? ESCAPE '\')`, []any{project, escapeLike(project) + ProjectSeparator + "%"}
+}
+
+// sourceFilterSQL matches an entry path. A bare source also matches its
+// qualified forms, so "mcp" matches "mcp:opencode" and "mcp:claude-code".
+func sourceFilterSQL(column, source string) (string, []any) {
+	source = strings.ToLower(strings.TrimSpace(source))
+	if source == "" {
+		return "", nil
 	}
+	return " AND (" + column + " = ? OR " + column + ` LIKE ? ESCAPE '\')`, []any{source, escapeLike(source) + ":%"}
+}
 
-	// Session counts + directories per project
-	sessRows, err := s.queryItHook(s.db,
-		`SELECT project, COUNT(*) as cnt, directory
-		 FROM sessions
-		 WHERE project IS NOT NULL AND project != ''
-		 GROUP BY project, directory`,
-	)
+// windowFilterSQL matches opts.SessionID and the normalized StartedAfter /
+// StartedBefore window. alias prefixes the columns, e.g. "o.".
+func windowFilterSQL(alias string, opts SearchOptions) (string, []any) {
+	var clause string
+	var args []any
+	if opts.SessionID != "" {
+		clause += " AND " + alias + "session_id = ?"
+		args = append(args, opts.SessionID)
+	}
+	if opts.StartedAfter != "" {
+		clause += " AND " + alias + "created_at >= ?"
+		args = append(args, opts.StartedAfter)
+	}
+	if opts.StartedBefore != "" {
+		clause += " AND " + alias + "created_at < ?"
+		args = append(args, opts.StartedBefore)
+	}
+	return clause, args
+}
+
+// placeholders returns "?, ?, ..." with n markers for an IN list.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// ─── Metadata ────────────────────────────────────────────────────────────────
+
+// Limits on Metadata, so it stays a small side table rather than a second
+// content field.
+const (
+	MaxMetadataKeys     = 32
+	MaxMetadataValueLen = 512
+)
+
+var metadataKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,64}$`)
+
+// Metadata holds structured key-value data a client attaches to an
+// observation (a PR number, the model that wrote it, token counts) without
+// putting it in the content. Values are JSON scalars: strings, numbers, or
+// booleans. It is stored as a JSON object in observations.metadata, and
+// numbers read back as json.Number; use the typed accessors rather than
+// type-asserting values.
+type Metadata map[string]any
+
+func (m *Metadata) Scan(src any) error {
+	var raw string
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("metadata: unsupported column type %T", src)
+	}
+	if raw == "" {
+		*m = nil
+		return nil
+	}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var meta map[string]any
+	if err := dec.Decode(&meta); err != nil {
+		return fmt.Errorf("metadata: %w", err)
+	}
+	*m = meta
+	return nil
+}
+
+func (m Metadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(map[string]any(m))
 	if err != nil {
-		return nil, fmt.Errorf("list projects sessions: %w", err)
+		return nil, err
 	}
-	defer sessRows.Close()
+	return string(encoded), nil
+}
+
+// String returns the value of key as text. Numbers and booleans are
+// formatted the way they appear in JSON.
+func (m Metadata) String(key string) (string, bool) {
+	v, ok := m[key]
+	if !ok {
+		return "", false
+	}
+	return metadataText(v), true
+}
+
+// Int returns the value of key if it is a whole number.
+func (m Metadata) Int(key string) (int64, bool) {
+	switch v := m[key].(type) {
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), v == float64(int64(v))
+	}
+	return 0, false
+}
+
+// Float returns the value of key if it is a number.
+func (m Metadata) Float(key string) (float64, bool) {
+	switch v := m[key].(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// Bool returns the value of key if it is a boolean.
+func (m Metadata) Bool(key string) (bool, bool) {
+	b, ok := m[key].(bool)
+	return b, ok
+}
+
+// Pairs returns the metadata as "key=value" strings sorted by key, for
+// display.
+func (m Metadata) Pairs() []string {
+	pairs := make([]string, 0, len(m))
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		pairs = append(pairs, key+"="+metadataText(m[key]))
+	}
+	return pairs
+}
+
+// metadataText formats a metadata value the way metadata filters compare
+// it.
+func metadataText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
 
-	type projDir struct {
-		count int
-		dirs  map[string]bool
+// normalizeMetadata validates client metadata and returns it with values
+// converted to the types it reads back as. Empty metadata is nil.
+func normalizeMetadata(m Metadata) (Metadata, error) {
+	if len(m) == 0 {
+		return nil, nil
 	}
-	sessData := make(map[string]*projDir)
-	for sessRows.Next() {
-		var name, dir string
-		var cnt int
-		if err := sessRows.Scan(&name, &cnt, &dir); err != nil {
-			return nil, err
-		}
-		if sessData[name] == nil {
-			sessData[name] = &projDir{dirs: make(map[string]bool)}
+	if len(m) > MaxMetadataKeys {
+		return nil, invalidf("metadata: at most %d keys, got %d", MaxMetadataKeys, len(m))
+	}
+	out := make(Metadata, len(m))
+	for key, v := range m {
+		if !metadataKeyRegex.MatchString(key) {
+			return nil, invalidf("metadata: invalid key %q (letters, digits, _ . : - only, up to 64)", key)
 		}
-		sessData[name].count += cnt
-		if dir != "" {
-			sessData[name].dirs[dir] = true
+		switch v := v.(type) {
+		case string:
+			if len(v) > MaxMetadataValueLen {
+				return nil, invalidf("metadata: %s: value longer than %d bytes", key, MaxMetadataValueLen)
+			}
+			out[key] = v
+		case bool, json.Number:
+			out[key] = v
+		case int:
+			out[key] = json.Number(strconv.Itoa(v))
+		case int64:
+			out[key] = json.Number(strconv.FormatInt(v, 10))
+		case float64:
+			out[key] = json.Number(strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			return nil, invalidf("metadata: %s: value must be a string, number, or boolean", key)
 		}
 	}
-	if err := sessRows.Err(); err != nil {
-		return nil, err
-	}
+	return out, nil
+}
 
-	for name, sd := range sessData {
-		if statsMap[name] == nil {
-			statsMap[name] = &ProjectStats{Name: name}
+// ParseMetadata parses "key=value" pairs, as given on the command line,
+// into Metadata. Values that read back unchanged as a number or a boolean
+// are stored as one, so "pr=412" saves the number 412 but "build=007"
+// keeps the string "007".
+func ParseMetadata(pairs []string) (Metadata, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	meta := make(Metadata, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, invalidf("metadata %q: expected key=value", pair)
 		}
-		statsMap[name].SessionCount = sd.count
-		for d := range sd.dirs {
-			statsMap[name].Directories = append(statsMap[name].Directories, d)
+		meta[key] = value
+		if b, err := strconv.ParseBool(value); err == nil && strconv.FormatBool(b) == value {
+			meta[key] = b
+		} else if f, err := strconv.ParseFloat(value, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == value {
+			meta[key] = json.Number(value)
 		}
 	}
+	return normalizeMetadata(meta)
+}
 
-	// Prompt counts per project
-	promptRows, err := s.queryItHook(s.db,
-		`SELECT project, COUNT(*) as cnt
-		 FROM user_prompts
-		 WHERE project IS NOT NULL AND project != ''
-		 GROUP BY project`,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("list projects prompts: %w", err)
+// ParseMetadataFilter parses "key=value" pairs, as given on the command
+// line or in a query string, into a SearchOptions.Metadata filter.
+func ParseMetadataFilter(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
 	}
-	defer promptRows.Close()
+	filter := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !metadataKeyRegex.MatchString(key) {
+			return nil, invalidf("metadata filter %q: expected key=value", pair)
+		}
+		filter[key] = value
+	}
+	return filter, nil
+}
 
-	for promptRows.Next() {
-		var name string
-		var cnt int
-		if err := promptRows.Scan(&name, &cnt); err != nil {
-			return nil, err
+// metadataFilterSQL returns clauses keeping observations (aliased by
+// column, e.g. "o.metadata") whose metadata has every key of filter set to
+// its value. Values compare as text, so "42" matches the number 42 and
+// "true" the boolean true. Keys are checked by SearchOptions.normalize.
+func metadataFilterSQL(column string, filter map[string]string) (string, []any) {
+	keys := slices.Sorted(maps.Keys(filter))
+	var clause string
+	var args []any
+	for _, key := range keys {
+		path := `$."` + key + `"`
+		clause += " AND (CASE json_type(" + column + ", ?) WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' ELSE CAST(json_extract(" + column + ", ?) AS TEXT) END) = ?"
+		args = append(args, path, path, filter[key])
+	}
+	return clause, args
+}
+
+// ─── File Links ──────────────────────────────────────────────────────────────
+
+// sourceExtensions are the extensions a bare file name (no directory) needs
+// to count as a path, so prose like "e.g." or "v1.2" is not linked.
+var sourceExtensions = map[string]bool{
+	"c": true, "cc": true, "cpp": true, "cs": true, "css": true, "dart": true, "ex": true, "exs": true,
+	"go": true, "gradle": true, "h": true, "hpp": true, "html": true, "java": true, "js": true, "json": true,
+	"jsx": true, "kt": true, "lock": true, "lua": true, "md": true, "mjs": true, "mod": true, "php": true,
+	"proto": true, "py": true, "rb": true, "rs": true, "scss": true, "sh": true, "sql": true, "sum": true,
+	"svelte": true, "swift": true, "tf": true, "toml": true, "ts": true, "tsx": true, "txt": true,
+	"vue": true, "xml": true, "yaml": true, "yml": true, "zig": true,
+}
+
+var (
+	filePathPattern   = regexp.MustCompile(`^(?:/|\.{1,2}/|[A-Za-z]:/)?(?:[\w@.+-]+/)*[\w@.+-]*[\w-]\.([A-Za-z][A-Za-z0-9]{0,7})$`)
+	pathLineSuffix    = regexp.MustCompile(`(?::\d+){1,2}$`)
+	pathTokenSplitter = regexp.MustCompile("[\\s`'\"()\\[\\]{}<>,;|*]+")
+)
+
+// ExtractFilePaths returns the file paths mentioned in text — typically the
+// **Where** line of a memory — in order of appearance without duplicates.
+// Paths need a file extension; bare names without a directory also need a
+// known source extension. Line suffixes (handler.go:88) and leading "./"
+// are dropped, and URLs are ignored.
+func ExtractFilePaths(text string) []string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, token := range pathTokenSplitter.Split(text, -1) {
+		if strings.Contains(token, "://") {
+			continue
 		}
-		if statsMap[name] == nil {
-			statsMap[name] = &ProjectStats{Name: name}
+		path := NormalizeFilePath(strings.TrimRight(token, ".:!?"))
+		m := filePathPattern.FindStringSubmatch(path)
+		if m == nil {
+			continue
+		}
+		if !strings.Contains(path, "/") && !sourceExtensions[strings.ToLower(m[1])] {
+			continue
+		}
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
 		}
-		statsMap[name].PromptCount = cnt
 	}
-	if err := promptRows.Err(); err != nil {
-		return nil, err
+	return paths
+}
+
+// NormalizeFilePath canonicalizes a path for linking and lookup: forward
+// slashes, no leading "./", and no trailing line or column numbers.
+func NormalizeFilePath(path string) string {
+	path = strings.ReplaceAll(strings.TrimSpace(path), `\`, "/")
+	path = pathLineSuffix.ReplaceAllString(path, "")
+	for strings.HasPrefix(path, "./") {
+		path = path[2:]
 	}
+	return path
+}
 
-	// Convert to slice, sorted by observation count descending
-	results := make([]ProjectStats, 0, len(statsMap))
-	for _, ps := range statsMap {
-		results = append(results, *ps)
+// linkObservationFiles replaces the file links of an observation with the
+// paths mentioned in its title and content.
+func (s *Store) linkObservationFiles(db execer, id int64, title, content string) error {
+	if _, err := s.execHook(db, `DELETE FROM observation_files WHERE observation_id = ?`, id); err != nil {
+		return fmt.Errorf("link files: %w", err)
 	}
-	// Simple insertion sort — project lists are small
-	for i := 1; i < len(results); i++ {
-		for j := i; j > 0 && results[j].ObservationCount > results[j-1].ObservationCount; j-- {
-			results[j], results[j-1] = results[j-1], results[j]
+	for _, path := range ExtractFilePaths(title + "\n" + content) {
+		if _, err := s.execHook(db,
+			`INSERT OR IGNORE INTO observation_files (observation_id, path) VALUES (?, ?)`, id, path,
+		); err != nil {
+			return fmt.Errorf("link files: %w", err)
 		}
 	}
+	return nil
+}
 
-	return results, nil
+// backfillObservationFiles links every observation saved before the
+// observation_files table existed.
+func (s *Store) backfillObservationFiles() error {
+	rows, err := s.queryItHook(s.db, `SELECT id, title, content FROM observations`)
+	if err != nil {
+		return err
+	}
+	type pending struct {
+		id             int64
+		title, content string
+	}
+	var all []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.title, (*contentColumn)(&p.content)); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, p)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	return s.withTx(func(tx *sql.Tx) error {
+		for _, p := range all {
+			if err := s.linkObservationFiles(tx, p.id, p.title, p.content); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// CountObservationsForProject returns the number of non-deleted observations
-// for the given project name. Used by handleSave for the similar-project
-// warning instead of the heavier ListProjectsWithStats.
-func (s *Store) CountObservationsForProject(name string) (int, error) {
-	var count int
-	err := s.db.QueryRow(
-		`SELECT COUNT(*) FROM observations WHERE project = ? AND deleted_at IS NULL`,
-		name,
-	).Scan(&count)
-	return count, err
+// fileFilterSQL restricts observations (by their id column) to those linked
+// to path. A path matches the same path, a stored path it is a suffix of
+// ("middleware.ts" finds "src/auth/middleware.ts"), or a stored relative
+// path that is its suffix (an absolute path finds the relative one).
+func fileFilterSQL(column, path string) (string, []any) {
+	path = strings.TrimPrefix(NormalizeFilePath(path), "/")
+	if path == "" {
+		return "", nil
+	}
+	abs := "/" + path
+	return ` AND EXISTS (SELECT 1 FROM observation_files f WHERE f.observation_id = ` + column + ` AND (
+		f.path = ? OR f.path = ?
+		OR substr(f.path, -length(?)) = ?
+		OR (instr(f.path, '/') > 0 AND substr(?, -length(f.path) - 1) = '/' || f.path)))`,
+		[]any{path, abs, abs, abs, abs}
 }
 
-// MergeResult summarizes the result of merging multiple project name variants
-// into a single canonical project name.
-type MergeResult struct {
-	Canonical           string   `json:"canonical"`
-	SourcesMerged       []string `json:"sources_merged"`
-	ObservationsUpdated int64    `json:"observations_updated"`
-	SessionsUpdated     int64    `json:"sessions_updated"`
-	PromptsUpdated      int64    `json:"prompts_updated"`
+// ─── Observation Parts ───────────────────────────────────────────────────────
+//
+// Content longer than Config.MaxObservationLength is not truncated: it is
+// split at line breaks into up to maxObservationParts observations. The
+// first keeps the save's title, topic key, and id; the others are titled
+// "<title> (part 2/3)" and so on. All of them share a group id (the first
+// part's sync id) in observation_parts, so GetFullObservation can put the
+// content back together from any part. Deleting or restoring the first part
+// takes the continuations with it; re-saving it replaces them.
+
+// maxObservationParts caps one save; content past the last part is
+// truncated as before.
+const maxObservationParts = 10
+
+// ObservationPart places an observation within a chunked save.
+type ObservationPart struct {
+	ObservationID int64  `json:"observation_id"`
+	GroupID       string `json:"group_id"`
+	Part          int    `json:"part"`
+	Parts         int    `json:"parts"`
+}
+
+// splitObservationContent cuts content into pieces of at most max bytes,
+// preferring a line break in the second half of each piece and never
+// splitting a UTF-8 sequence. Content that fits is returned as is.
+func splitObservationContent(content string, max int) []string {
+	if max <= 0 || len(content) <= max {
+		return []string{content}
+	}
+	var parts []string
+	for len(content) > max {
+		if len(parts) == maxObservationParts-1 {
+			content = content[:runeBoundary(content, max)] + "... [truncated]"
+			break
+		}
+		cut := runeBoundary(content, max)
+		if nl := strings.LastIndexByte(content[:cut], '\n'); nl >= max/2 {
+			cut = nl + 1
+		}
+		parts = append(parts, content[:cut])
+		content = content[cut:]
+	}
+	return append(parts, content)
 }
 
-// MergeProjects migrates all records from each source project name into the
-// canonical name. Sources that equal the canonical (after normalization) or
-// have no records are silently skipped — the operation is idempotent.
-// All updates are performed inside a single transaction for atomicity.
-func (s *Store) MergeProjects(sources []string, canonical string) (*MergeResult, error) {
-	canonical, _ = NormalizeProject(canonical)
-	if canonical == "" {
-		return nil, fmt.Errorf("canonical project name must not be empty")
+// runeBoundary returns the largest index <= n that starts a rune in s.
+func runeBoundary(s string, n int) int {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
 	}
+	return n
+}
 
-	result := &MergeResult{Canonical: canonical}
+func continuationTitle(title string, part, parts int) string {
+	return fmt.Sprintf("%s (part %d/%d)", title, part, parts)
+}
 
-	err := s.withTx(func(tx *sql.Tx) error {
-		for _, src := range sources {
-			src, _ = NormalizeProject(src)
-			if src == "" || src == canonical {
-				continue
-			}
+// replaceObservationPartsTx makes headID's continuations match parts: any
+// previous ones are dropped, and parts[1:] are saved as new observations.
+func (s *Store) replaceObservationPartsTx(tx *sql.Tx, headID int64, p AddObservationParams, title, scope string, parts []string) error {
+	if err := s.dropContinuationsTx(tx, headID, false); err != nil {
+		return err
+	}
+	if len(parts) < 2 {
+		return nil
+	}
+	head, err := s.getObservationTx(tx, headID)
+	if err != nil {
+		return err
+	}
+	group := head.SyncID
+	if _, err := s.execHook(tx,
+		"INSERT OR REPLACE INTO observation_parts (observation_id, group_id, part, parts) VALUES (?, ?, 1, ?)",
+		headID, group, len(parts),
+	); err != nil {
+		return err
+	}
 
-			res, err := s.execHook(tx, `UPDATE observations SET project = ? WHERE project = ?`, canonical, src)
-			if err != nil {
-				return fmt.Errorf("merge observations %q → %q: %w", src, canonical, err)
-			}
-			n, _ := res.RowsAffected()
-			result.ObservationsUpdated += n
+	for i, content := range parts[1:] {
+		partTitle := continuationTitle(title, i+2, len(parts))
+		if err := s.checkQuota(tx, p.Project, int64(len(partTitle)+len(content))); err != nil {
+			return err
+		}
+		res, err := s.execHook(tx,
+			`INSERT INTO observations (sync_id, session_id, type, title, content, tool_name, project, scope, refs, normalized_hash, source, revision_count, duplicate_count, last_seen_at, updated_at, seq)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, 1, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), `+nextObservationSeq+`)`,
+			newSyncID("obs"), p.SessionID, p.Type, partTitle, s.storedContent(content),
+			nullableString(p.ToolName), nullableString(p.Project), scope, observationRefs(nil, "", content), hashNormalized(content), nullableString(p.Source),
+		)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		if _, err := s.execHook(tx,
+			"INSERT INTO observation_parts (observation_id, group_id, part, parts) VALUES (?, ?, ?, ?)",
+			id, group, i+2, len(parts),
+		); err != nil {
+			return err
+		}
+		if err := s.linkObservationFiles(tx, id, partTitle, content); err != nil {
+			return err
+		}
+		obs, err := s.getObservationTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.enqueueSyncMutationTx(tx, SyncEntityObservation, obs.SyncID, SyncOpUpsert, observationPayloadFromObservation(obs)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			res, err = s.execHook(tx, `UPDATE sessions SET project = ? WHERE project = ?`, canonical, src)
-			if err != nil {
-				return fmt.Errorf("merge sessions %q → %q: %w", src, canonical, err)
-			}
-			n, _ = res.RowsAffected()
-			result.SessionsUpdated += n
+// dropContinuationsTx deletes the continuation observations of headID, if
+// it is the first part of a chunked save, and forgets the group.
+func (s *Store) dropContinuationsTx(tx *sql.Tx, headID int64, hardDelete bool) error {
+	ids, err := s.continuationIDsTx(tx, headID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.deleteObservationTx(tx, id, hardDelete); err != nil {
+			return err
+		}
+	}
+	_, err = s.execHook(tx,
+		`DELETE FROM observation_parts
+		 WHERE group_id = (SELECT group_id FROM observation_parts WHERE observation_id = ? AND part = 1)`, headID,
+	)
+	return err
+}
 
-			res, err = s.execHook(tx, `UPDATE user_prompts SET project = ? WHERE project = ?`, canonical, src)
-			if err != nil {
-				return fmt.Errorf("merge prompts %q → %q: %w", src, canonical, err)
-			}
-			n, _ = res.RowsAffected()
-			result.PromptsUpdated += n
+// continuationIDsTx returns the ids of headID's continuations, second part
+// first, whether or not they are deleted. It is empty unless headID is the
+// first part of a chunked save.
+func (s *Store) continuationIDsTx(tx *sql.Tx, headID int64) ([]int64, error) {
+	rows, err := tx.Query(
+		`SELECT c.observation_id FROM observation_parts h
+		 JOIN observation_parts c ON c.group_id = h.group_id AND c.part > 1
+		 WHERE h.observation_id = ? AND h.part = 1
+		 ORDER BY c.part`, headID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-			result.SourcesMerged = append(result.SourcesMerged, src)
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
 		}
-		// Enqueue sync mutations so cloud sync picks up the merged records.
-		// Same pattern used by EnrollProject.
-		return s.backfillProjectSyncMutationsTx(tx, canonical)
-	})
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// partContentsTx returns the content of obs followed by that of its
+// continuations, the pieces a chunked save was split into. It is just
+// obs.Content for an observation saved whole.
+func (s *Store) partContentsTx(tx *sql.Tx, obs *Observation) ([]string, error) {
+	ids, err := s.continuationIDsTx(tx, obs.ID)
 	if err != nil {
 		return nil, err
 	}
-
-	return result, nil
+	parts := []string{obs.Content}
+	for _, id := range ids {
+		part, err := s.getObservationTx(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		if part.DeletedAt == nil {
+			parts = append(parts, part.Content)
+		}
+	}
+	return parts, nil
 }
 
-// ─── Project Pruning ─────────────────────────────────────────────────────────
+// ObservationParts returns every part of the chunked save id belongs to,
+// first part first. It is empty when id was saved whole.
+func (s *Store) ObservationParts(id int64) ([]ObservationPart, error) {
+	rows, err := s.queryItHook(s.db,
+		`SELECT p.observation_id, p.group_id, p.part, p.parts
+		 FROM observation_parts p
+		 JOIN observations o ON o.id = p.observation_id AND o.deleted_at IS NULL
+		 WHERE p.group_id = (SELECT group_id FROM observation_parts WHERE observation_id = ?)
+		 ORDER BY p.part`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-// PruneResult holds the outcome of pruning a single project.
-type PruneResult struct {
-	Project         string `json:"project"`
-	SessionsDeleted int64  `json:"sessions_deleted"`
-	PromptsDeleted  int64  `json:"prompts_deleted"`
+	var parts []ObservationPart
+	for rows.Next() {
+		var part ObservationPart
+		if err := rows.Scan(&part.ObservationID, &part.GroupID, &part.Part, &part.Parts); err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, rows.Err()
 }
-
-// PruneProject removes all sessions and prompts for a project that has zero
-// (non-deleted) observations. Returns an error if the project still has
-// observations — the caller must verify first.
-func (s *Store) PruneProject(project string) (*PruneResult, error) {
-	if project == "" {
-		return nil, fmt.Errorf("project name must not be empty")
+
+// GetFullObservation returns the observation id starts, or belongs to,
+// with the content of every part joined back together, and the number of
+// parts it was assembled from (1 for an observation saved whole). Asking
+// for a continuation returns the first part's observation.
+func (s *Store) GetFullObservation(id int64) (*Observation, int, error) {
+	parts, err := s.ObservationParts(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(parts) == 0 || parts[0].Part != 1 {
+		obs, err := s.GetObservation(id)
+		return obs, 1, err
 	}
 
-	// Safety check: refuse to prune if observations exist.
-	count, err := s.CountObservationsForProject(project)
+	head, err := s.GetObservation(parts[0].ObservationID)
 	if err != nil {
-		return nil, fmt.Errorf("count observations: %w", err)
+		return nil, 0, err
 	}
-	if count > 0 {
-		return nil, fmt.Errorf("project %q still has %d observations — cannot prune", project, count)
+	var b strings.Builder
+	b.WriteString(head.Content)
+	for _, part := range parts[1:] {
+		obs, err := s.GetObservation(part.ObservationID)
+		if err != nil {
+			return nil, 0, err
+		}
+		b.WriteString(obs.Content)
 	}
+	head.Content = b.String()
+	return head, len(parts), nil
+}
 
-	result := &PruneResult{Project: project}
+// joinSessionParts folds the continuations of chunked saves in a session's
+// observations into their first part, so each save reads as one entry.
+func (s *Store) joinSessionParts(sessionID string, observations []Observation) ([]Observation, error) {
+	rows, err := s.queryItHook(s.db,
+		`SELECT p.observation_id, p.group_id, p.part
+		 FROM observation_parts p
+		 JOIN observations o ON o.id = p.observation_id
+		 WHERE o.session_id = ? AND o.deleted_at IS NULL
+		 ORDER BY p.group_id, p.part`, sessionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	err = s.withTx(func(tx *sql.Tx) error {
-		res, err := s.execHook(tx, `DELETE FROM user_prompts WHERE project = ?`, project)
-		if err != nil {
-			return fmt.Errorf("prune prompts: %w", err)
+	heads := map[string]int64{}
+	continuations := map[int64][]int64{} // first part → the others, in order
+	for rows.Next() {
+		var id int64
+		var group string
+		var part int
+		if err := rows.Scan(&id, &group, &part); err != nil {
+			return nil, err
 		}
-		result.PromptsDeleted, _ = res.RowsAffected()
+		if part == 1 {
+			heads[group] = id
+		} else if head, ok := heads[group]; ok {
+			continuations[head] = append(continuations[head], id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(continuations) == 0 {
+		return observations, nil
+	}
 
-		res, err = s.execHook(tx, `DELETE FROM sessions WHERE project = ?`, project)
-		if err != nil {
-			return fmt.Errorf("prune sessions: %w", err)
+	content := make(map[int64]string, len(observations))
+	for _, o := range observations {
+		content[o.ID] = o.Content
+	}
+	// Continuations of a head in the slice may fall past its limit; their
+	// content is read here so the save is joined whole.
+	var missing []any
+	folded := map[int64]bool{}
+	for _, o := range observations {
+		for _, id := range continuations[o.ID] {
+			if _, ok := content[id]; !ok {
+				missing = append(missing, id)
+			}
+			folded[id] = true
 		}
-		result.SessionsDeleted, _ = res.RowsAffected()
+	}
+	if len(missing) > 0 {
+		if err := s.loadPartContent(content, missing); err != nil {
+			return nil, err
+		}
+	}
+	inSlice := 0
+	for _, o := range observations {
+		if folded[o.ID] {
+			inSlice++
+		}
+	}
 
-		return nil
-	})
-	if err != nil {
-		return nil, err
+	joined := make([]Observation, 0, len(observations)-inSlice)
+	for _, o := range observations {
+		if folded[o.ID] {
+			continue
+		}
+		for _, id := range continuations[o.ID] {
+			o.Content += content[id]
+		}
+		joined = append(joined, o)
 	}
+	return joined, nil
+}
 
-	return result, nil
+// loadPartContent adds the content of the observations with the given ids
+// to content.
+func (s *Store) loadPartContent(content map[int64]string, ids []any) error {
+	rows, err := s.queryItHook(s.db,
+		`SELECT id, engram_inflate(content) FROM observations WHERE id IN (`+placeholders(len(ids))+`)`, ids...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var text string
+		if err := rows.Scan(&id, &text); err != nil {
+			return err
+		}
+		content[id] = text
+	}
+	return rows.Err()
 }
 
 // ─── Helpers ─────────────────────────────────────────────────────────────────
 
 func (s *Store) withTx(fn func(tx *sql.Tx) error) error {
+	return s.withTxInvalidating(fn, "")
+}
+
+// withTxInvalidating runs fn in a transaction and then drops the cached
+// query results it may have changed: with kinds, only those kinds for
+// project (see queryCache.invalidate); without, every entry.
+func (s *Store) withTxInvalidating(fn func(tx *sql.Tx) error, project string, kinds ...string) error {
 	tx, err := s.beginTxHook()
 	if err != nil {
 		return err
@@ -2660,12 +10449,22 @@ func (s *Store) withTx(fn func(tx *sql.Tx) error) error {
 	if err := fn(tx); err != nil {
 		return err
 	}
-	return s.commitHook(tx)
+	if len(kinds) == 0 {
+		defer s.cache.flush()
+		return s.commitHook(tx)
+	}
+	s.cache.syncExternal()
+	if err := s.commitHook(tx); err != nil {
+		s.cache.flush()
+		return err
+	}
+	s.cache.invalidate(project, kinds...)
+	return nil
 }
 
 func (s *Store) createSessionTx(tx *sql.Tx, id, project, directory string) error {
 	_, err := s.execHook(tx,
-		`INSERT INTO sessions (id, project, directory) VALUES (?, ?, ?)
+		`INSERT INTO sessions (id, project, directory, started_at) VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
 		 ON CONFLICT(id) DO UPDATE SET
 		   project   = CASE WHEN sessions.project = '' THEN excluded.project ELSE sessions.project END,
 		   directory = CASE WHEN sessions.directory = '' THEN excluded.directory ELSE sessions.directory END`,
@@ -2676,7 +10475,7 @@ func (s *Store) createSessionTx(tx *sql.Tx, id, project, directory string) error
 
 func (s *Store) ensureSyncState(targetKey string) error {
 	_, err := s.execHook(s.db,
-		`INSERT OR IGNORE INTO sync_state (target_key, lifecycle, updated_at) VALUES (?, ?, datetime('now'))`,
+		`INSERT OR IGNORE INTO sync_state (target_key, lifecycle, updated_at) VALUES (?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))`,
 		targetKey, SyncLifecycleIdle,
 	)
 	return err
@@ -2696,7 +10495,7 @@ func (s *Store) getSyncState(targetKey string) (*SyncState, error) {
 
 func (s *Store) getSyncStateTx(tx *sql.Tx, targetKey string) (*SyncState, error) {
 	if _, err := s.execHook(tx,
-		`INSERT OR IGNORE INTO sync_state (target_key, lifecycle, updated_at) VALUES (?, ?, datetime('now'))`,
+		`INSERT OR IGNORE INTO sync_state (target_key, lifecycle, updated_at) VALUES (?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))`,
 		targetKey, SyncLifecycleIdle,
 	); err != nil {
 		return nil, err
@@ -2792,6 +10591,7 @@ func (s *Store) backfillObservationSyncMutationsTx(tx *sql.Tx, project string) e
 		FROM observations
 		WHERE ifnull(project, '') = ?
 		  AND deleted_at IS NULL
+		  AND quarantine_reason IS NULL
 		  AND NOT EXISTS (
 			SELECT 1
 			FROM sync_mutations sm
@@ -2810,7 +10610,7 @@ func (s *Store) backfillObservationSyncMutationsTx(tx *sql.Tx, project string) e
 
 	for rows.Next() {
 		var payload syncObservationPayload
-		if err := rows.Scan(&payload.SyncID, &payload.SessionID, &payload.Type, &payload.Title, &payload.Content, &payload.ToolName, &payload.Project, &payload.Scope, &payload.TopicKey); err != nil {
+		if err := rows.Scan(&payload.SyncID, &payload.SessionID, &payload.Type, &payload.Title, (*contentColumn)(&payload.Content), &payload.ToolName, &payload.Project, &payload.Scope, &payload.TopicKey); err != nil {
 			return err
 		}
 		if err := s.enqueueSyncMutationTx(tx, SyncEntityObservation, payload.SyncID, SyncOpUpsert, payload); err != nil {
@@ -2860,14 +10660,14 @@ func (s *Store) enqueueSyncMutationTx(tx *sql.Tx, entity, entityKey, op string,
 	}
 	project := extractProjectFromPayload(payload)
 	if _, err := s.execHook(tx,
-		`INSERT OR IGNORE INTO sync_state (target_key, lifecycle, updated_at) VALUES (?, ?, datetime('now'))`,
+		`INSERT OR IGNORE INTO sync_state (target_key, lifecycle, updated_at) VALUES (?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))`,
 		DefaultSyncTargetKey, SyncLifecycleIdle,
 	); err != nil {
 		return err
 	}
 	res, err := s.execHook(tx,
-		`INSERT INTO sync_mutations (target_key, entity, entity_key, op, payload, source, project)
-		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO sync_mutations (target_key, entity, entity_key, op, payload, source, project, occurred_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))`,
 		DefaultSyncTargetKey, entity, entityKey, op, string(encoded), SyncSourceLocal, project,
 	)
 	if err != nil {
@@ -2879,7 +10679,7 @@ func (s *Store) enqueueSyncMutationTx(tx *sql.Tx, entity, entityKey, op string,
 	}
 	_, err = s.execHook(tx,
 		`UPDATE sync_state
-		 SET lifecycle = ?, last_enqueued_seq = ?, updated_at = datetime('now')
+		 SET lifecycle = ?, last_enqueued_seq = ?, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
 		 WHERE target_key = ?`,
 		SyncLifecyclePending, seq, DefaultSyncTargetKey,
 	)
@@ -2937,11 +10737,11 @@ func decodeSyncPayload(payload []byte, dest any) error {
 func (s *Store) getObservationTx(tx *sql.Tx, id int64) (*Observation, error) {
 	row := tx.QueryRow(
 		`SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
-		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at
+		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata
 		 FROM observations WHERE id = ? AND deleted_at IS NULL`, id,
 	)
 	var o Observation
-	if err := row.Scan(&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, &o.Content, &o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt); err != nil {
+	if err := row.Scan(&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, (*contentColumn)(&o.Content), &o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt, &o.Refs, &o.VerifiedAt, &o.StaleAt, &o.VerificationNote, &o.Source, &o.Metadata); err != nil {
 		return nil, err
 	}
 	return &o, nil
@@ -2949,7 +10749,7 @@ func (s *Store) getObservationTx(tx *sql.Tx, id int64) (*Observation, error) {
 
 func (s *Store) getObservationBySyncIDTx(tx *sql.Tx, syncID string, includeDeleted bool) (*Observation, error) {
 	query := `SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
-		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at
+		        scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata
 		 FROM observations WHERE sync_id = ?`
 	if !includeDeleted {
 		query += ` AND deleted_at IS NULL`
@@ -2957,7 +10757,7 @@ func (s *Store) getObservationBySyncIDTx(tx *sql.Tx, syncID string, includeDelet
 	query += ` ORDER BY id DESC LIMIT 1`
 	row := tx.QueryRow(query, syncID)
 	var o Observation
-	if err := row.Scan(&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, &o.Content, &o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt); err != nil {
+	if err := row.Scan(&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, (*contentColumn)(&o.Content), &o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt, &o.Refs, &o.VerifiedAt, &o.StaleAt, &o.VerificationNote, &o.Source, &o.Metadata); err != nil {
 		return nil, err
 	}
 	return &o, nil
@@ -2974,43 +10774,69 @@ func observationPayloadFromObservation(obs *Observation) syncObservationPayload
 		Project:   obs.Project,
 		Scope:     obs.Scope,
 		TopicKey:  obs.TopicKey,
+		Refs:      obs.Refs,
+		Metadata:  obs.Metadata,
 	}
 }
 
 func (s *Store) applySessionPayloadTx(tx *sql.Tx, payload syncSessionPayload) error {
 	_, err := s.execHook(tx,
-		`INSERT INTO sessions (id, project, directory, ended_at, summary)
-		 VALUES (?, ?, ?, ?, ?)
+		`INSERT INTO sessions (id, project, directory, started_at, ended_at, summary, parent_session_id)
+		 VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), ?, ?, ?)
 		 ON CONFLICT(id) DO UPDATE SET
 		   project = excluded.project,
 		   directory = excluded.directory,
 		   ended_at = COALESCE(excluded.ended_at, sessions.ended_at),
-		   summary = COALESCE(excluded.summary, sessions.summary)`,
-		payload.ID, payload.Project, payload.Directory, payload.EndedAt, payload.Summary,
+		   summary = COALESCE(excluded.summary, sessions.summary),
+		   parent_session_id = COALESCE(excluded.parent_session_id, sessions.parent_session_id)`,
+		payload.ID, payload.Project, payload.Directory, normalizeTimestampPtr(payload.EndedAt), payload.Summary, payload.ParentSessionID,
 	)
 	return err
 }
 
 func (s *Store) applyObservationUpsertTx(tx *sql.Tx, payload syncObservationPayload) error {
+	if _, err := s.ensureSessionTx(tx, payload.SessionID, derefString(payload.Project), ""); err != nil {
+		return err
+	}
 	existing, err := s.getObservationBySyncIDTx(tx, payload.SyncID, true)
 	if err == sql.ErrNoRows {
-		_, err = s.execHook(tx,
-			`INSERT INTO observations (sync_id, session_id, type, title, content, tool_name, project, scope, topic_key, normalized_hash, revision_count, duplicate_count, updated_at, deleted_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, 1, datetime('now'), NULL)`,
-			payload.SyncID, payload.SessionID, payload.Type, payload.Title, payload.Content, payload.ToolName, payload.Project, normalizeScope(payload.Scope), payload.TopicKey, hashNormalized(payload.Content),
+		res, err := s.execHook(tx,
+			`INSERT INTO observations (sync_id, session_id, type, title, content, tool_name, project, scope, topic_key, refs, metadata, normalized_hash, source, revision_count, duplicate_count, updated_at, deleted_at, seq)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, 1, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), NULL, `+nextObservationSeq+`)`,
+			payload.SyncID, payload.SessionID, payload.Type, payload.Title, s.storedContent(payload.Content), payload.ToolName, payload.Project, normalizeScope(payload.Scope), payload.TopicKey, payload.Refs, payload.Metadata, hashNormalized(payload.Content), SourceSyncImport,
 		)
-		return err
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		return s.linkObservationFiles(tx, id, payload.Title, payload.Content)
 	}
 	if err != nil {
 		return err
 	}
+	if s.cfg.AppendOnly {
+		// The pulled revision keeps its sync ID, so the peer's later
+		// mutations find it (getObservationBySyncIDTx takes the newest row).
+		_, err = s.supersedeObservationTx(tx, existing, Observation{
+			SyncID: payload.SyncID, SessionID: payload.SessionID, Type: payload.Type, Title: payload.Title, Content: payload.Content,
+			ToolName: payload.ToolName, Project: payload.Project, Scope: payload.Scope,
+			TopicKey: payload.TopicKey, Refs: payload.Refs, Metadata: payload.Metadata, Source: existing.Source,
+		})
+		return err
+	}
 	_, err = s.execHook(tx,
 		`UPDATE observations
-		 SET session_id = ?, type = ?, title = ?, content = ?, tool_name = ?, project = ?, scope = ?, topic_key = ?, normalized_hash = ?, revision_count = revision_count + 1, updated_at = datetime('now'), deleted_at = NULL
+		 SET session_id = ?, type = ?, title = ?, content = ?, tool_name = ?, project = ?, scope = ?, topic_key = ?, refs = ?, metadata = ?, normalized_hash = ?, revision_count = revision_count + 1, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), deleted_at = NULL
 		 WHERE id = ?`,
-		payload.SessionID, payload.Type, payload.Title, payload.Content, payload.ToolName, payload.Project, normalizeScope(payload.Scope), payload.TopicKey, hashNormalized(payload.Content), existing.ID,
+		payload.SessionID, payload.Type, payload.Title, s.storedContent(payload.Content), payload.ToolName, payload.Project, normalizeScope(payload.Scope), payload.TopicKey, payload.Refs, payload.Metadata, hashNormalized(payload.Content), existing.ID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	return s.linkObservationFiles(tx, existing.ID, payload.Title, payload.Content)
 }
 
 func (s *Store) applyObservationDeleteTx(tx *sql.Tx, payload syncObservationPayload) error {
@@ -3021,29 +10847,34 @@ func (s *Store) applyObservationDeleteTx(tx *sql.Tx, payload syncObservationPayl
 	if err != nil {
 		return err
 	}
-	if payload.HardDelete {
+	// Append-only stores keep the row and apply a pulled hard delete as a
+	// soft one.
+	if payload.HardDelete && !s.cfg.AppendOnly {
 		_, err = s.execHook(tx, `DELETE FROM observations WHERE id = ?`, existing.ID)
 		return err
 	}
-	deletedAt := payload.DeletedAt
+	deletedAt := normalizeTimestampPtr(payload.DeletedAt)
 	if deletedAt == nil {
 		now := Now()
 		deletedAt = &now
 	}
 	_, err = s.execHook(tx,
-		`UPDATE observations SET deleted_at = ?, updated_at = datetime('now') WHERE id = ?`,
+		`UPDATE observations SET deleted_at = ?, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') WHERE id = ?`,
 		deletedAt, existing.ID,
 	)
 	return err
 }
 
 func (s *Store) applyPromptUpsertTx(tx *sql.Tx, payload syncPromptPayload) error {
+	if _, err := s.ensureSessionTx(tx, payload.SessionID, derefString(payload.Project), ""); err != nil {
+		return err
+	}
 	var existingID int64
 	err := tx.QueryRow(`SELECT id FROM user_prompts WHERE sync_id = ? ORDER BY id DESC LIMIT 1`, payload.SyncID).Scan(&existingID)
 	if err == sql.ErrNoRows {
 		_, err = s.execHook(tx,
-			`INSERT INTO user_prompts (sync_id, session_id, content, project) VALUES (?, ?, ?, ?)`,
-			payload.SyncID, payload.SessionID, payload.Content, payload.Project,
+			`INSERT INTO user_prompts (sync_id, session_id, content, project, normalized_hash, created_at, seq) VALUES (?, ?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), `+nextPromptSeq+`)`,
+			payload.SyncID, payload.SessionID, payload.Content, payload.Project, hashNormalized(payload.Content),
 		)
 		return err
 	}
@@ -3051,8 +10882,8 @@ func (s *Store) applyPromptUpsertTx(tx *sql.Tx, payload syncPromptPayload) error
 		return err
 	}
 	_, err = s.execHook(tx,
-		`UPDATE user_prompts SET session_id = ?, content = ?, project = ? WHERE id = ?`,
-		payload.SessionID, payload.Content, payload.Project, existingID,
+		`UPDATE user_prompts SET session_id = ?, content = ?, project = ?, normalized_hash = ? WHERE id = ?`,
+		payload.SessionID, payload.Content, payload.Project, hashNormalized(payload.Content), existingID,
 	)
 	return err
 }
@@ -3068,9 +10899,9 @@ func (s *Store) queryObservations(query string, args ...any) ([]Observation, err
 	for rows.Next() {
 		var o Observation
 		if err := rows.Scan(
-			&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, &o.Content,
+			&o.ID, &o.SyncID, &o.SessionID, &o.Type, &o.Title, (*contentColumn)(&o.Content),
 			&o.ToolName, &o.Project, &o.Scope, &o.TopicKey, &o.RevisionCount, &o.DuplicateCount, &o.LastSeenAt,
-			&o.CreatedAt, &o.UpdatedAt, &o.DeletedAt,
+			&o.CreatedAt, &o.UpdatedAt, &o.DeletedAt, &o.Refs, &o.VerifiedAt, &o.StaleAt, &o.VerificationNote, &o.Source, &o.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -3079,6 +10910,41 @@ func (s *Store) queryObservations(query string, args ...any) ([]Observation, err
 	return results, rows.Err()
 }
 
+// timestampColumns lists every timestamp column, per table.
+var timestampColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"sessions", []string{"started_at", "ended_at"}},
+	{"observations", []string{"created_at", "updated_at", "last_seen_at", "deleted_at"}},
+	{"user_prompts", []string{"created_at"}},
+	{"sync_chunks", []string{"imported_at"}},
+	{"sync_state", []string{"updated_at", "backoff_until", "lease_until"}},
+	{"sync_mutations", []string{"occurred_at", "acked_at"}},
+	{"sync_enrolled_projects", []string{"enrolled_at"}},
+}
+
+// migrateTimestamps rewrites timestamps written by older versions (naive
+// UTC, or RFC3339 with an offset) as RFC3339 UTC. Values already in
+// TimestampLayout are skipped, so it is cheap to run on every start.
+func (s *Store) migrateTimestamps() error {
+	for _, tc := range timestampColumns {
+		for _, col := range tc.columns {
+			converted := fmt.Sprintf(`strftime('%%Y-%%m-%%dT%%H:%%M:%%SZ', %s)`, col)
+			if _, err := s.execHook(s.db, fmt.Sprintf(
+				`UPDATE %s SET %s = %s
+				 WHERE %s IS NOT NULL
+				   AND %s NOT GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]T[0-9][0-9]:[0-9][0-9]:[0-9][0-9]Z'
+				   AND %s IS NOT NULL`,
+				tc.table, col, converted, col, col, converted,
+			)); err != nil {
+				return fmt.Errorf("migrate %s.%s timestamps: %w", tc.table, col, err)
+			}
+		}
+	}
+	return nil
+}
+
 func (s *Store) addColumnIfNotExists(tableName, columnName, definition string) error {
 	rows, err := s.queryItHook(s.db, fmt.Sprintf("PRAGMA table_info(%s)", tableName))
 	if err != nil {
@@ -3157,13 +11023,19 @@ func (s *Store) migrateLegacyObservationsTable() error {
 			project    TEXT,
 			scope      TEXT    NOT NULL DEFAULT 'project',
 			topic_key  TEXT,
+			refs       TEXT,
 			normalized_hash TEXT,
 			revision_count INTEGER NOT NULL DEFAULT 1,
 			duplicate_count INTEGER NOT NULL DEFAULT 1,
 			last_seen_at TEXT,
-			created_at TEXT    NOT NULL DEFAULT (datetime('now')),
-			updated_at TEXT    NOT NULL DEFAULT (datetime('now')),
+			created_at TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			updated_at TEXT    NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
 			deleted_at TEXT,
+			quarantine_reason TEXT,
+			verified_at TEXT,
+			stale_at TEXT,
+			verification_note TEXT,
+			source TEXT,
 			FOREIGN KEY (session_id) REFERENCES sessions(id)
 		);
 	`); err != nil {
@@ -3195,8 +11067,8 @@ func (s *Store) migrateLegacyObservationsTable() error {
 			CASE WHEN revision_count IS NULL OR revision_count < 1 THEN 1 ELSE revision_count END,
 			CASE WHEN duplicate_count IS NULL OR duplicate_count < 1 THEN 1 ELSE duplicate_count END,
 			last_seen_at,
-			COALESCE(NULLIF(created_at, ''), datetime('now')),
-			COALESCE(NULLIF(updated_at, ''), NULLIF(created_at, ''), datetime('now')),
+			COALESCE(NULLIF(created_at, ''), strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			COALESCE(NULLIF(updated_at, ''), NULLIF(created_at, ''), strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
 			deleted_at
 		FROM observations
 		ORDER BY rowid;
@@ -3265,8 +11137,24 @@ func normalizeScope(scope string) string {
 	return "project"
 }
 
+// ProjectSeparator splits hierarchical project names such as
+// "platform/api". Filters on a project also match its sub-projects.
+const ProjectSeparator = "/"
+
+// ProjectAncestors returns the parent projects of project, nearest first:
+// "platform/api/v2" → ["platform/api", "platform"].
+func ProjectAncestors(project string) []string {
+	var ancestors []string
+	for i := strings.LastIndex(project, ProjectSeparator); i > 0; i = strings.LastIndex(project, ProjectSeparator) {
+		project = project[:i]
+		ancestors = append(ancestors, project)
+	}
+	return ancestors
+}
+
 // NormalizeProject applies canonical project name normalization:
-// lowercase + trim whitespace + collapse consecutive hyphens/underscores.
+// lowercase + trim whitespace + collapse consecutive hyphens/underscores +
+// tidy hierarchical "/" separators.
 // Returns the normalized name and a warning message if the name was changed
 // (empty string if no change was needed).
 // Exported so MCP and CLI handlers can surface the warning to users.
@@ -3283,6 +11171,17 @@ func NormalizeProject(project string) (normalized string, warning string) {
 	for strings.Contains(n, "__") {
 		n = strings.ReplaceAll(n, "__", "_")
 	}
+	// Hierarchical paths: backslashes become slashes, and empty or padded
+	// segments are dropped ("Platform\ API/" → "platform/api").
+	if strings.ContainsAny(n, "/\\") {
+		var segments []string
+		for _, seg := range strings.Split(strings.ReplaceAll(n, "\\", ProjectSeparator), ProjectSeparator) {
+			if seg = strings.TrimSpace(seg); seg != "" {
+				segments = append(segments, seg)
+			}
+		}
+		n = strings.Join(segments, ProjectSeparator)
+	}
 	if n == project {
 		return n, ""
 	}
@@ -3320,6 +11219,29 @@ func SuggestTopicKey(typ, title, content string) string {
 	return family + "/" + segment
 }
 
+// familyObservationTypes maps inferred topic families to the observation
+// types agents are asked to use.
+var familyObservationTypes = map[string]string{
+	"architecture": "architecture",
+	"bug":          "bugfix",
+	"decision":     "decision",
+	"pattern":      "pattern",
+	"config":       "config",
+	"discovery":    "discovery",
+	"learning":     "learning",
+}
+
+// InferObservationType picks an observation type from title and content
+// using the same family heuristic as SuggestTopicKey. It returns "manual"
+// when nothing matches.
+func InferObservationType(title, content string) string {
+	family := inferTopicFamily("", stripPrivateTags(title), stripPrivateTags(content))
+	if typ, ok := familyObservationTypes[family]; ok {
+		return typ
+	}
+	return "manual"
+}
+
 func inferTopicFamily(typ, title, content string) string {
 	t := strings.TrimSpace(strings.ToLower(typ))
 	switch t {
@@ -3354,280 +11276,1032 @@ func inferTopicFamily(typ, title, content string) string {
 	if hasAny(text, "pattern", "convention", "naming", "guideline") {
 		return "pattern"
 	}
-	if hasAny(text, "config", "setup", "environment", "env", "docker", "pipeline") {
-		return "config"
+	if hasAny(text, "config", "setup", "environment", "env", "docker", "pipeline") {
+		return "config"
+	}
+	if hasAny(text, "discovery", "investigate", "investigation", "found", "root cause") {
+		return "discovery"
+	}
+	if hasAny(text, "learned", "learning") {
+		return "learning"
+	}
+
+	if t != "" && t != "manual" {
+		return normalizeTopicSegment(t)
+	}
+
+	return "topic"
+}
+
+func hasAny(text string, words ...string) bool {
+	for _, w := range words {
+		if strings.Contains(text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeTopicSegment(s string) string {
+	v := strings.ToLower(strings.TrimSpace(s))
+	if v == "" {
+		return ""
+	}
+	re := regexp.MustCompile(`[^a-z0-9]+`)
+	v = re.ReplaceAllString(v, " ")
+	v = strings.Join(strings.Fields(v), "-")
+	if len(v) > 100 {
+		v = v[:100]
+	}
+	return v
+}
+
+func normalizeTopicKey(topic string) string {
+	v := strings.TrimSpace(strings.ToLower(topic))
+	if v == "" {
+		return ""
+	}
+	v = strings.Join(strings.Fields(v), "-")
+	if len(v) > 120 {
+		v = v[:120]
+	}
+	return v
+}
+
+func derefString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func hashNormalized(content string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(content), " "))
+	h := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(h[:])
+}
+
+func dedupeWindowExpression(window time.Duration) string {
+	if window <= 0 {
+		window = 15 * time.Minute
+	}
+	minutes := int(window.Minutes())
+	if minutes < 1 {
+		minutes = 1
+	}
+	return "-" + strconv.Itoa(minutes) + " minutes"
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func normalizeSyncTargetKey(targetKey string) string {
+	if strings.TrimSpace(targetKey) == "" {
+		return DefaultSyncTargetKey
+	}
+	return strings.TrimSpace(strings.ToLower(targetKey))
+}
+
+func newSyncID(prefix string) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%s-%d", prefix, time.Now().UTC().UnixNano())
+	}
+	return prefix + "-" + hex.EncodeToString(b)
+}
+
+func normalizeExistingSyncID(existing, prefix string) string {
+	if strings.TrimSpace(existing) != "" {
+		return existing
+	}
+	return newSyncID(prefix)
+}
+
+// privateTagRegex matches <private>...</private> tags and their contents.
+// Supports multiline and nested content. Case-insensitive.
+var privateTagRegex = regexp.MustCompile(`(?is)<private>.*?</private>`)
+
+// stripPrivateTags removes all <private>...</private> content from a string.
+// This ensures sensitive information (API keys, passwords, personal data)
+// is never persisted to the memory database.
+func stripPrivateTags(s string) string {
+	result := privateTagRegex.ReplaceAllString(s, "[REDACTED]")
+	// Clean up multiple consecutive [REDACTED] and excessive whitespace
+	result = strings.TrimSpace(result)
+	return result
+}
+
+// sanitizeFTS turns a user query into a safe FTS5 expression. Plain words
+// are quoted so punctuation can't break the syntax, while a few operators
+// survive:
+//
+//	"exact phrase"   phrase match
+//	a OR b           either term (uppercase OR only)
+//	NOT a, -a        exclude the term or phrase
+//	auth*            prefix match
+//
+// Excluded terms are applied to the whole expression, so
+// `jwt OR session -legacy` becomes `("jwt" OR "session") NOT "legacy"`.
+// A query made only of exclusions has nothing to subtract from; its terms
+// are searched literally instead.
+func sanitizeFTS(query string) string {
+	type ftsTerm struct {
+		expr string
+		or   bool // joined to the previous term with OR
+	}
+	var include []ftsTerm
+	var exclude []string
+	pendingOr, pendingNot := false, false
+
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		negate := pendingNot
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != '-' {
+			negate = true
+			i++
+		}
+
+		var text string
+		prefix := false
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i+1 : end])
+			i = min(end+1, len(runes))
+			if i < len(runes) && runes[i] == '*' {
+				prefix = true
+				i++
+			}
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			text = string(runes[i:end])
+			i = end
+			if !negate {
+				switch text {
+				case "OR":
+					pendingOr = len(include) > 0
+					continue
+				case "NOT":
+					pendingNot = true
+					continue
+				case "AND":
+					continue
+				}
+			}
+			text = strings.ReplaceAll(text, `"`, "")
+			if trimmed := strings.TrimRight(text, "*"); trimmed != text {
+				text, prefix = trimmed, true
+			}
+		}
+		pendingNot = false
+
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		expr := `"` + strings.ReplaceAll(text, `"`, "") + `"`
+		if prefix {
+			expr += "*"
+		}
+		if negate {
+			exclude = append(exclude, expr)
+			continue
+		}
+		include = append(include, ftsTerm{expr: expr, or: pendingOr})
+		pendingOr = false
+	}
+
+	if len(include) == 0 {
+		return strings.Join(exclude, " ")
+	}
+
+	var b strings.Builder
+	hasOr := false
+	for i, t := range include {
+		if i > 0 {
+			if t.or {
+				b.WriteString(" OR ")
+				hasOr = true
+			} else {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(t.expr)
+	}
+	expr := b.String()
+	if len(exclude) == 0 {
+		return expr
 	}
-	if hasAny(text, "discovery", "investigate", "investigation", "found", "root cause") {
-		return "discovery"
+	if hasOr {
+		expr = "(" + expr + ")"
 	}
-	if hasAny(text, "learned", "learning") {
-		return "learning"
+	return expr + " NOT " + strings.Join(exclude, " NOT ")
+}
+
+// quoteFTSTerms quotes every word literally, ignoring operators. It is the
+// fallback when FTS5 rejects the expression built by sanitizeFTS.
+// "fix auth bug" → `"fix" "auth" "bug"`
+func quoteFTSTerms(query string) string {
+	words := strings.Fields(query)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, "") + `"`
 	}
+	return strings.Join(words, " ")
+}
 
-	if t != "" && t != "manual" {
-		return normalizeTopicSegment(t)
+// queryFTS runs an FTS5 query whose first argument is the MATCH expression
+// for query. If FTS5 still rejects the expression, the original query is
+// logged and retried with every term quoted literally, then with only its
+// plain words (see conservativeFTS). Both outcomes are counted in
+// Stats.FTS.
+func (s *Store) queryFTS(query, sqlQ string, args []any) (rowScanner, error) {
+	return s.queryFTSOn(s.db, query, sqlQ, args)
+}
+
+func (s *Store) queryFTSOn(db queryer, query, sqlQ string, args []any) (rowScanner, error) {
+	args[0] = sanitizeFTS(query)
+	rows, err := s.queryItHook(db, sqlQ, args...)
+	if err == nil || !strings.Contains(err.Error(), "fts5:") {
+		return rows, err
 	}
+	slog.Warn("fts query rejected, retrying with a safer expression", "query", query, "match", args[0], "error", err)
 
-	return "topic"
+	for _, fallback := range []func(string) string{quoteFTSTerms, conservativeFTS} {
+		expr := fallback(query)
+		if expr == "" {
+			continue
+		}
+		args[0] = expr
+		rows, err = s.queryItHook(db, sqlQ, args...)
+		if err == nil {
+			s.ftsFallbacks.Add(1)
+			return rows, nil
+		}
+		if !strings.Contains(err.Error(), "fts5:") {
+			return rows, err
+		}
+	}
+	s.ftsFailures.Add(1)
+	slog.Error("fts query failed after every fallback", "query", query, "error", err)
+	return nil, err
 }
 
-func hasAny(text string, words ...string) bool {
-	for _, w := range words {
-		if strings.Contains(text, w) {
-			return true
+// conservativeFTS is the last-resort fallback: only letters and digits
+// survive, operator words and tokens shorter than three characters are
+// dropped (unless nothing else is left), and every token is quoted.
+// "C++ AND e2e-test: x" → `"e2e" "test"`
+func conservativeFTS(query string) string {
+	tokens := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var kept, short []string
+	for _, tok := range tokens {
+		switch {
+		case tok == "AND" || tok == "OR" || tok == "NOT" || tok == "NEAR":
+		case utf8.RuneCountInString(tok) < 3:
+			short = append(short, `"`+tok+`"`)
+		default:
+			kept = append(kept, `"`+tok+`"`)
 		}
 	}
-	return false
+	if len(kept) == 0 {
+		kept = short
+	}
+	return strings.Join(kept, " ")
 }
 
-func normalizeTopicSegment(s string) string {
-	v := strings.ToLower(strings.TrimSpace(s))
-	if v == "" {
-		return ""
+// ─── Passive Capture ─────────────────────────────────────────────────────────
+
+// PassiveCaptureParams holds the input for passive memory capture.
+type PassiveCaptureParams struct {
+	SessionID string `json:"session_id"`
+	Content   string `json:"content"`
+	Project   string `json:"project,omitempty"`
+	Source    string `json:"source,omitempty"` // e.g. "subagent-stop", "session-end"
+}
+
+// PassiveCaptureResult holds the output of passive memory capture.
+type PassiveCaptureResult struct {
+	Extracted   int `json:"extracted"`   // Total learnings and error findings found in text
+	Errors      int `json:"errors"`      // How many of Extracted were error findings
+	Saved       int `json:"saved"`       // New observations created
+	Quarantined int `json:"quarantined"` // Saved into quarantine for review (low confidence)
+	Duplicates  int `json:"duplicates"`  // Skipped because already existed
+}
+
+// DefaultLearningHeaders are the section titles ExtractLearnings recognizes,
+// as case-insensitive regular expressions. A section starts at a "##" or
+// "###" heading, or a line that is only a bold label ("**TIL:**"), whose
+// text matches one of them.
+var DefaultLearningHeaders = []string{
+	// English
+	`Key\s+Learnings?`, `Learnings?`, `Lessons?\s+Learned`, `(?:Key\s+)?Takeaways?`, `TIL`, `Today\s+I\s+Learned`,
+	// Spanish
+	`Aprendizajes(?:\s+Clave)?`, `Lecciones\s+Aprendidas`,
+	// Portuguese
+	`(?:Principais\s+)?Aprendizados(?:\s+Principais)?`, `Lições\s+Aprendidas`,
+	// French
+	`Apprentissages(?:\s+Clés)?`, `Leçons\s+Apprises`, `Enseignements`, `Ce\s+que\s+j'ai\s+appris`,
+	// German
+	`(?:Wichtige\s+)?Erkenntnisse`, `Gelernte\s+Lektionen`, `Was\s+ich\s+gelernt\s+habe`,
+	// Japanese
+	`(?:重要な)?学び`, `学んだこと`, `教訓`, `得られた知見`,
+}
+
+// learningHeaderPattern matches the built-in learning section headers.
+var learningHeaderPattern = mustLearningHeaderPattern(nil)
+
+// LearningHeaderPattern compiles the built-in headers plus extra custom
+// ones into a single header matcher. Each extra entry is a regular
+// expression for the header text, validated on its own so errors name it.
+func LearningHeaderPattern(extra []string) (*regexp.Regexp, error) {
+	for _, h := range extra {
+		if _, err := regexp.Compile(h); err != nil {
+			return nil, fmt.Errorf("learning header %q: %w", h, err)
+		}
 	}
-	re := regexp.MustCompile(`[^a-z0-9]+`)
-	v = re.ReplaceAllString(v, " ")
-	v = strings.Join(strings.Fields(v), "-")
-	if len(v) > 100 {
-		v = v[:100]
+	alternatives := strings.Join(append(slices.Clone(DefaultLearningHeaders), extra...), "|")
+	return regexp.Compile(`(?im)^(?:#{2,3}\s+(?:` + alternatives + `)\s*[:：]?|\*\*(?:` + alternatives + `)\s*(?:[:：]\*\*|\*\*\s*[:：]?))\s*$`)
+}
+
+func mustLearningHeaderPattern(extra []string) *regexp.Regexp {
+	re, err := LearningHeaderPattern(extra)
+	if err != nil {
+		panic(err)
 	}
-	return v
+	return re
 }
 
-func normalizeTopicKey(topic string) string {
-	v := strings.TrimSpace(strings.ToLower(topic))
-	if v == "" {
-		return ""
+const (
+	minLearningLength = 20
+	minLearningWords  = 4
+)
+
+// ExtractLearnings parses structured learning items from text.
+// It looks for sections like "## Key Learnings:", "## Aprendizajes Clave:",
+// or "**TIL:**" (see DefaultLearningHeaders) and extracts numbered (1. text)
+// or bullet (- text) items.
+// Returns learnings from the LAST matching section (most recent output).
+func ExtractLearnings(text string) []string {
+	return extractLearnings(text, learningHeaderPattern)
+}
+
+func extractLearnings(text string, header *regexp.Regexp) []string {
+	matches := header.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return nil
 	}
-	v = strings.Join(strings.Fields(v), "-")
-	if len(v) > 120 {
-		v = v[:120]
+
+	// Process sections in reverse — use first valid one (most recent)
+	for i := len(matches) - 1; i >= 0; i-- {
+		sectionStart := matches[i][1]
+		sectionText := text[sectionStart:]
+
+		// Cut off at next major section header
+		if nextHeader := regexp.MustCompile(`\n#{1,3} `).FindStringIndex(sectionText); nextHeader != nil {
+			sectionText = sectionText[:nextHeader[0]]
+		}
+
+		var learnings []string
+
+		// Try numbered items: "1. text" or "1) text"
+		numbered := regexp.MustCompile(`(?m)^\s*\d+[.)]\s+(.+)`).FindAllStringSubmatch(sectionText, -1)
+		if len(numbered) > 0 {
+			for _, m := range numbered {
+				cleaned := cleanMarkdown(m[1])
+				if len(cleaned) >= minLearningLength && learningWordCount(cleaned) >= minLearningWords {
+					learnings = append(learnings, cleaned)
+				}
+			}
+		}
+
+		// Fall back to bullet items: "- text" or "* text"
+		if len(learnings) == 0 {
+			bullets := regexp.MustCompile(`(?m)^\s*[-*]\s+(.+)`).FindAllStringSubmatch(sectionText, -1)
+			for _, m := range bullets {
+				cleaned := cleanMarkdown(m[1])
+				if len(cleaned) >= minLearningLength && learningWordCount(cleaned) >= minLearningWords {
+					learnings = append(learnings, cleaned)
+				}
+			}
+		}
+
+		if len(learnings) > 0 {
+			return learnings
+		}
 	}
-	return v
+
+	return nil
 }
 
-func derefString(v *string) string {
-	if v == nil {
-		return ""
+// learningWordCount counts words for the length checks. Scripts written
+// without spaces (Chinese, Japanese) count roughly one word per two
+// characters, so a full Japanese sentence is not mistaken for a fragment.
+func learningWordCount(text string) int {
+	words := 0
+	for _, field := range strings.Fields(text) {
+		cjk := 0
+		for _, r := range field {
+			if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+				cjk++
+			}
+		}
+		words += max(1, cjk/2)
 	}
-	return *v
+	return words
 }
 
-func hashNormalized(content string) string {
-	normalized := strings.ToLower(strings.Join(strings.Fields(content), " "))
-	h := sha256.Sum256([]byte(normalized))
-	return hex.EncodeToString(h[:])
+// cleanMarkdown strips basic markdown formatting and collapses whitespace.
+func cleanMarkdown(text string) string {
+	text = regexp.MustCompile(`\*\*([^*]+)\*\*`).ReplaceAllString(text, "$1") // bold
+	text = regexp.MustCompile("`([^`]+)`").ReplaceAllString(text, "$1")       // inline code
+	text = regexp.MustCompile(`\*([^*]+)\*`).ReplaceAllString(text, "$1")     // italic
+	return strings.TrimSpace(strings.Join(strings.Fields(text), " "))
 }
 
-func dedupeWindowExpression(window time.Duration) string {
-	if window <= 0 {
-		window = 15 * time.Minute
+// PassiveCapture extracts learnings and error findings (see
+// ExtractErrorFindings) from text and saves them as observations. It
+// deduplicates against existing observations using content hash matching.
+// Learnings scoring below QuarantineThreshold are held in quarantine instead;
+// error findings are log output by nature and skip that check.
+func (s *Store) PassiveCapture(p PassiveCaptureParams) (*PassiveCaptureResult, error) {
+	// Normalize project name before storing
+	p.Project, _ = NormalizeProject(p.Project)
+
+	result := &PassiveCaptureResult{}
+
+	header := s.learningHeaders
+	if header == nil {
+		header = learningHeaderPattern
 	}
-	minutes := int(window.Minutes())
-	if minutes < 1 {
-		minutes = 1
+	learnings := extractLearnings(p.Content, header)
+	findings := ExtractErrorFindings(p.Content)
+	result.Extracted = len(learnings) + len(findings)
+	result.Errors = len(findings)
+
+	// Learnings go through the same save loop after the error findings.
+	for _, learning := range learnings {
+		// Truncate for title: first 60 chars
+		title := learning
+		if len(title) > 60 {
+			title = title[:60] + "..."
+		}
+		findings = append(findings, ErrorFinding{Type: "passive", Title: title, Content: learning})
 	}
-	return "-" + strconv.Itoa(minutes) + " minutes"
-}
 
-func maxInt(a, b int) int {
-	if a > b {
-		return a
+	for i, finding := range findings {
+		content := finding.Content
+		isLearning := i >= result.Errors
+
+		// Check if this item already exists (by content hash) within this project
+		normHash := hashNormalized(content)
+		var existingID int64
+		err := s.db.QueryRow(
+			`SELECT id FROM observations
+			 WHERE normalized_hash = ?
+			   AND ifnull(project, '') = ifnull(?, '')
+			   AND deleted_at IS NULL
+			 LIMIT 1`,
+			normHash, nullableString(p.Project),
+		).Scan(&existingID)
+
+		if err == nil {
+			// Already exists — skip
+			result.Duplicates++
+			continue
+		}
+
+		params := AddObservationParams{
+			SessionID: p.SessionID,
+			Type:      finding.Type,
+			Title:     finding.Title,
+			Content:   content,
+			Project:   p.Project,
+			Scope:     "project",
+			ToolName:  p.Source,
+			Source:    SourcePassive,
+		}
+
+		if confidence, reasons := LearningConfidence(content); isLearning && confidence < QuarantineThreshold {
+			if _, err := s.addQuarantined(params, strings.Join(reasons, "; ")); err != nil {
+				return result, fmt.Errorf("passive capture quarantine: %w", err)
+			}
+			result.Quarantined++
+			continue
+		}
+
+		if _, err := s.AddObservation(params); err != nil {
+			return result, fmt.Errorf("passive capture save: %w", err)
+		}
+		result.Saved++
 	}
-	return b
+
+	return result, nil
 }
 
-func normalizeSyncTargetKey(targetKey string) string {
-	if strings.TrimSpace(targetKey) == "" {
-		return DefaultSyncTargetKey
+// ─── Error Findings ──────────────────────────────────────────────────────────
+//
+// Besides learning sections, passive capture mines debugging output: crashes
+// with their stack, failing tests, and explicit "Root cause:" lines. These
+// are saved as discovery (symptoms) and bugfix (causes) observations.
+
+// ErrorFinding is debugging knowledge found in captured text.
+type ErrorFinding struct {
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// maxTraceLines caps how much of a stack trace is kept per finding.
+const maxTraceLines = 12
+
+var (
+	goPanicPattern       = regexp.MustCompile(`^panic: (.+)`)
+	goFramePattern       = regexp.MustCompile(`^\t(\S+\.go):(\d+)`)
+	goTraceLinePattern   = regexp.MustCompile(`^(?:goroutine \d+ \[|\t|\[signal |created by |panic: |\S+\(.*\)$)`)
+	pyTracebackPattern   = regexp.MustCompile(`^Traceback \(most recent call last\):`)
+	pyFramePattern       = regexp.MustCompile(`^\s+File "([^"]+)", line (\d+)`)
+	pyExceptionPattern   = regexp.MustCompile(`^([A-Za-z_][\w.]*(?:Error|Exception|Exit|Interrupt|Warning))(?:: (.*))?$`)
+	thrownErrorPattern   = regexp.MustCompile(`^(?:Uncaught |Exception in thread "[^"]*" )?([A-Za-z_$][\w.$]*(?:Error|Exception))(?:: (.*))?$`)
+	stackFramePattern    = regexp.MustCompile(`^\s+at .*?\(?([^\s():]+):(\d+)(?::\d+)?\)?$`)
+	goTestFailPattern    = regexp.MustCompile(`^\s*--- FAIL: (\S+)`)
+	goTestMessagePattern = regexp.MustCompile(`^\s{4,}(\S+_test\.go:\d+: .+)`)
+	pytestFailPattern    = regexp.MustCompile(`^FAILED (\S+)(?: - (.+))?`)
+	jestFailPattern      = regexp.MustCompile(`^\s*[✕×] (.+?)(?: \(\d+ ?m?s\))?$`)
+	rootCausePattern     = regexp.MustCompile(`(?i)^\s*(?:[-*>]\s+)?\**root[ -]cause\**\s*[:：]\**\s*(.+)`)
+)
+
+// ExtractErrorFindings scans text for Go panics, Python tracebacks, thrown
+// JavaScript/Java errors with "at" frames, failing tests (go test, pytest,
+// jest), and "Root cause:" lines. Failing tests are grouped into one
+// finding; a root cause carries the first crash it explains as context.
+func ExtractErrorFindings(text string) []ErrorFinding {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var crashes, causes []ErrorFinding
+	var failedTests, failureDetails []string
+	seen := make(map[string]bool)
+	addCrash := func(title string, trace []string) {
+		if seen[title] {
+			return
+		}
+		seen[title] = true
+		crashes = append(crashes, ErrorFinding{
+			Type:    "discovery",
+			Title:   truncate(title, 100),
+			Content: strings.Join(trace, "\n"),
+		})
 	}
-	return strings.TrimSpace(strings.ToLower(targetKey))
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+
+		if m := goPanicPattern.FindStringSubmatch(line); m != nil {
+			trace := []string{line}
+			location := ""
+			j := i + 1
+			for ; j < len(lines); j++ {
+				next := strings.TrimRight(lines[j], " \t")
+				if next == "" {
+					// Blank lines separate the signal, goroutine, and frame blocks.
+					if j+1 < len(lines) && goTraceLinePattern.MatchString(lines[j+1]) {
+						continue
+					}
+					break
+				}
+				if !goTraceLinePattern.MatchString(next) {
+					break
+				}
+				if len(trace) <= maxTraceLines {
+					trace = append(trace, next)
+				}
+				if f := goFramePattern.FindStringSubmatch(next); f != nil && location == "" && !strings.Contains(f[1], "/runtime/") {
+					location = filepath.Base(f[1]) + ":" + f[2]
+				}
+			}
+			addCrash(crashTitle("Panic", location, m[1]), trace)
+			i = j - 1
+			continue
+		}
+
+		if pyTracebackPattern.MatchString(line) {
+			var frames []string
+			location := ""
+			j := i + 1
+			for ; j < len(lines); j++ {
+				next := strings.TrimRight(lines[j], " \t")
+				if f := pyFramePattern.FindStringSubmatch(next); f != nil {
+					location = filepath.Base(f[1]) + ":" + f[2]
+				}
+				if m := pyExceptionPattern.FindStringSubmatch(next); m != nil {
+					if len(frames) > maxTraceLines {
+						frames = frames[len(frames)-maxTraceLines:]
+					}
+					trace := append([]string{line}, frames...)
+					addCrash(crashTitle(m[1], location, m[2]), append(trace, next))
+					break
+				}
+				if next != "" && !strings.HasPrefix(next, " ") {
+					break // not a traceback after all
+				}
+				frames = append(frames, next)
+			}
+			i = j
+			continue
+		}
+
+		if m := thrownErrorPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil &&
+			i+1 < len(lines) && stackFramePattern.MatchString(lines[i+1]) {
+			trace := []string{strings.TrimSpace(line)}
+			location := ""
+			j := i + 1
+			for ; j < len(lines) && stackFramePattern.MatchString(lines[j]); j++ {
+				if len(trace) <= maxTraceLines {
+					trace = append(trace, strings.TrimRight(lines[j], " \t"))
+				}
+				if f := stackFramePattern.FindStringSubmatch(lines[j]); location == "" && !strings.Contains(f[1], "node_modules") {
+					location = filepath.Base(f[1]) + ":" + f[2]
+				}
+			}
+			addCrash(crashTitle(m[1], location, m[2]), trace)
+			i = j - 1
+			continue
+		}
+
+		if m := goTestFailPattern.FindStringSubmatch(line); m != nil {
+			detail := "- " + m[1]
+			if i+1 < len(lines) {
+				if msg := goTestMessagePattern.FindStringSubmatch(lines[i+1]); msg != nil {
+					detail += ": " + msg[1]
+				}
+			}
+			failedTests, failureDetails = appendFailure(failedTests, failureDetails, m[1], detail)
+			continue
+		}
+		if m := pytestFailPattern.FindStringSubmatch(line); m != nil {
+			detail := "- " + m[1]
+			if m[2] != "" {
+				detail += ": " + m[2]
+			}
+			failedTests, failureDetails = appendFailure(failedTests, failureDetails, m[1], detail)
+			continue
+		}
+		if m := jestFailPattern.FindStringSubmatch(line); m != nil {
+			failedTests, failureDetails = appendFailure(failedTests, failureDetails, m[1], "- "+m[1])
+			continue
+		}
+
+		if m := rootCausePattern.FindStringSubmatch(line); m != nil {
+			cause := cleanMarkdown(m[1])
+			if learningWordCount(cause) < 3 || seen["cause:"+cause] {
+				continue
+			}
+			seen["cause:"+cause] = true
+			causes = append(causes, ErrorFinding{
+				Type:    "bugfix",
+				Title:   "Root cause: " + truncate(cause, 68),
+				Content: "**Root cause**: " + cause,
+			})
+		}
+	}
+
+	var findings []ErrorFinding
+	findings = append(findings, crashes...)
+	if len(failedTests) > 0 {
+		names := failedTests
+		more := ""
+		if len(names) > 3 {
+			names, more = names[:3], fmt.Sprintf(" (+%d more)", len(failedTests)-3)
+		}
+		findings = append(findings, ErrorFinding{
+			Type:    "discovery",
+			Title:   truncate("Failing tests: "+strings.Join(names, ", ")+more, 100),
+			Content: strings.Join(failureDetails, "\n"),
+		})
+	}
+	for _, cause := range causes {
+		if len(crashes) > 0 {
+			cause.Content += "\n**Symptom**: " + crashes[0].Title
+		} else if len(failedTests) > 0 {
+			cause.Content += "\n**Symptom**: failing " + strings.Join(failedTests, ", ")
+		}
+		findings = append(findings, cause)
+	}
+	return findings
 }
 
-func newSyncID(prefix string) string {
-	b := make([]byte, 8)
-	if _, err := rand.Read(b); err != nil {
-		return fmt.Sprintf("%s-%d", prefix, time.Now().UTC().UnixNano())
+func crashTitle(kind, location, message string) string {
+	title := kind
+	if location != "" {
+		title += " in " + location
 	}
-	return prefix + "-" + hex.EncodeToString(b)
+	if message = strings.TrimSpace(message); message != "" {
+		title += ": " + message
+	}
+	return title
 }
 
-func normalizeExistingSyncID(existing, prefix string) string {
-	if strings.TrimSpace(existing) != "" {
-		return existing
+func appendFailure(names, details []string, name, detail string) ([]string, []string) {
+	if slices.Contains(names, name) {
+		return names, details
 	}
-	return newSyncID(prefix)
+	return append(names, name), append(details, detail)
 }
 
-// privateTagRegex matches <private>...</private> tags and their contents.
-// Supports multiline and nested content. Case-insensitive.
-var privateTagRegex = regexp.MustCompile(`(?is)<private>.*?</private>`)
+// ─── Quarantine ──────────────────────────────────────────────────────────────
+//
+// Passive captures that look like log noise or sentence fragments are saved
+// with a quarantine_reason. Quarantined observations stay local: they are
+// left out of search, context, timelines, topics, exports and sync until
+// approved, and rejecting one deletes it for good.
 
-// stripPrivateTags removes all <private>...</private> content from a string.
-// This ensures sensitive information (API keys, passwords, personal data)
-// is never persisted to the memory database.
-func stripPrivateTags(s string) string {
-	result := privateTagRegex.ReplaceAllString(s, "[REDACTED]")
-	// Clean up multiple consecutive [REDACTED] and excessive whitespace
-	result = strings.TrimSpace(result)
-	return result
+// QuarantineThreshold is the LearningConfidence score below which a passive
+// capture is quarantined.
+const QuarantineThreshold = 70
+
+// QuarantinedObservation is an observation awaiting review.
+type QuarantinedObservation struct {
+	Observation
+	Reason string `json:"quarantine_reason"`
 }
 
-// sanitizeFTS wraps each word in quotes so FTS5 doesn't choke on special chars.
-// "fix auth bug" → `"fix" "auth" "bug"`
-func sanitizeFTS(query string) string {
-	words := strings.Fields(query)
-	for i, w := range words {
-		// Strip existing quotes to avoid double-quoting
-		w = strings.Trim(w, `"`)
-		words[i] = `"` + w + `"`
+var (
+	logLinePattern = regexp.MustCompile(
+		`^\s*\[?(?:\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}|\d{2}:\d{2}:\d{2}|(?:TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|PANIC)\b)` +
+			`|\blevel=\w+|\bgoroutine \d+ \[|\bat [\w.$]+\([\w.]+:\d+\)`,
+	)
+	fragmentStartPattern = regexp.MustCompile(`^(?:and|but|or|so|then|which|because|with|while|when)\b`)
+)
+
+// LearningConfidence scores how likely text is a real learning rather than
+// log output or a half-sentence, from 0 to 100, with the reason for every
+// penalty applied.
+func LearningConfidence(text string) (int, []string) {
+	text = strings.TrimSpace(text)
+	score := 100
+	var reasons []string
+	penalize := func(points int, reason string) {
+		score -= points
+		reasons = append(reasons, reason)
 	}
-	return strings.Join(words, " ")
-}
 
-// ─── Passive Capture ─────────────────────────────────────────────────────────
+	if logLinePattern.MatchString(text) {
+		penalize(50, "looks like log output")
+	}
+	if fragmentStartPattern.MatchString(text) {
+		penalize(40, "starts mid-sentence")
+	}
 
-// PassiveCaptureParams holds the input for passive memory capture.
-type PassiveCaptureParams struct {
-	SessionID string `json:"session_id"`
-	Content   string `json:"content"`
-	Project   string `json:"project,omitempty"`
-	Source    string `json:"source,omitempty"` // e.g. "subagent-stop", "session-end"
-}
+	switch {
+	case strings.HasSuffix(text, "..."), strings.HasSuffix(text, ","), strings.HasSuffix(text, ":"),
+		strings.HasSuffix(text, ";"), strings.HasSuffix(text, "-"):
+		penalize(40, "ends mid-sentence")
+	case !strings.ContainsAny(lastRune(text), ".!?)`\"'。！？」）"):
+		penalize(10, "no sentence ending")
+	}
 
-// PassiveCaptureResult holds the output of passive memory capture.
-type PassiveCaptureResult struct {
-	Extracted  int `json:"extracted"`  // Total learnings found in text
-	Saved      int `json:"saved"`      // New observations created
-	Duplicates int `json:"duplicates"` // Skipped because already existed
+	letters, visible := 0, 0
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		visible++
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if visible > 0 && letters*100/visible < 60 {
+		penalize(40, "mostly symbols, numbers or paths")
+	}
+	if learningWordCount(text) < 6 {
+		penalize(10, "very short")
+	}
+
+	return max(score, 0), reasons
 }
 
-// learningHeaderPattern matches section headers for learnings in both English and Spanish.
-var learningHeaderPattern = regexp.MustCompile(
-	`(?im)^#{2,3}\s+(?:Aprendizajes(?:\s+Clave)?|Key\s+Learnings?|Learnings?):?\s*$`,
-)
+func lastRune(text string) string {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return string(r)
+}
 
-const (
-	minLearningLength = 20
-	minLearningWords  = 4
-)
+// addQuarantined inserts a passive capture in quarantine. No sync mutation is
+// enqueued; ApproveQuarantine does that when the observation is accepted.
+func (s *Store) addQuarantined(p AddObservationParams, reason string) (int64, error) {
+	title := stripPrivateTags(p.Title)
+	content := stripPrivateTags(p.Content)
+	if len(content) > s.cfg.MaxObservationLength {
+		content = content[:s.cfg.MaxObservationLength] + "... [truncated]"
+	}
+	if err := s.checkQuota(s.db, p.Project, int64(len(title)+len(content))); err != nil {
+		return 0, err
+	}
 
-// ExtractLearnings parses structured learning items from text.
-// It looks for sections like "## Key Learnings:" or "## Aprendizajes Clave:"
-// and extracts numbered (1. text) or bullet (- text) items.
-// Returns learnings from the LAST matching section (most recent output).
-func ExtractLearnings(text string) []string {
-	matches := learningHeaderPattern.FindAllStringIndex(text, -1)
-	if len(matches) == 0 {
-		return nil
+	res, err := s.execHook(s.db,
+		`INSERT INTO observations (sync_id, session_id, type, title, content, tool_name, project, scope, refs, normalized_hash, quarantine_reason, source, revision_count, duplicate_count, last_seen_at, updated_at, seq)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, 1, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), `+nextObservationSeq+`)`,
+		newSyncID("obs"), p.SessionID, p.Type, title, s.storedContent(content),
+		nullableString(p.ToolName), nullableString(p.Project), normalizeScope(p.Scope),
+		observationRefs(p.Refs, title, content), hashNormalized(content), reason, nullableString(p.Source),
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
 	}
+	return id, s.linkObservationFiles(s.db, id, title, content)
+}
 
-	// Process sections in reverse — use first valid one (most recent)
-	for i := len(matches) - 1; i >= 0; i-- {
-		sectionStart := matches[i][1]
-		sectionText := text[sectionStart:]
+// ListQuarantine returns quarantined observations, newest first.
+func (s *Store) ListQuarantine(project string, limit int) ([]QuarantinedObservation, error) {
+	project, _ = NormalizeProject(project)
+	if limit <= 0 {
+		limit = 50
+	}
 
-		// Cut off at next major section header
-		if nextHeader := regexp.MustCompile(`\n#{1,3} `).FindStringIndex(sectionText); nextHeader != nil {
-			sectionText = sectionText[:nextHeader[0]]
-		}
+	query := `
+		SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
+		       scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata,
+		       quarantine_reason
+		FROM observations
+		WHERE deleted_at IS NULL AND quarantine_reason IS NOT NULL`
+	args := []any{}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("project", project)
+		query += clause
+		args = append(args, clauseArgs...)
+	}
+	query += " ORDER BY seq DESC, id DESC LIMIT ?"
+	args = append(args, limit)
 
-		var learnings []string
+	rows, err := s.queryItHook(s.db, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-		// Try numbered items: "1. text" or "1) text"
-		numbered := regexp.MustCompile(`(?m)^\s*\d+[.)]\s+(.+)`).FindAllStringSubmatch(sectionText, -1)
-		if len(numbered) > 0 {
-			for _, m := range numbered {
-				cleaned := cleanMarkdown(m[1])
-				if len(cleaned) >= minLearningLength && len(strings.Fields(cleaned)) >= minLearningWords {
-					learnings = append(learnings, cleaned)
-				}
-			}
+	var results []QuarantinedObservation
+	for rows.Next() {
+		var q QuarantinedObservation
+		if err := rows.Scan(
+			&q.ID, &q.SyncID, &q.SessionID, &q.Type, &q.Title, (*contentColumn)(&q.Content),
+			&q.ToolName, &q.Project, &q.Scope, &q.TopicKey, &q.RevisionCount, &q.DuplicateCount, &q.LastSeenAt,
+			&q.CreatedAt, &q.UpdatedAt, &q.DeletedAt, &q.Refs, &q.VerifiedAt, &q.StaleAt, &q.VerificationNote, &q.Source, &q.Metadata,
+			&q.Reason,
+		); err != nil {
+			return nil, err
 		}
+		results = append(results, q)
+	}
+	return results, rows.Err()
+}
 
-		// Fall back to bullet items: "- text" or "* text"
-		if len(learnings) == 0 {
-			bullets := regexp.MustCompile(`(?m)^\s*[-*]\s+(.+)`).FindAllStringSubmatch(sectionText, -1)
-			for _, m := range bullets {
-				cleaned := cleanMarkdown(m[1])
-				if len(cleaned) >= minLearningLength && len(strings.Fields(cleaned)) >= minLearningWords {
-					learnings = append(learnings, cleaned)
-				}
-			}
+// ApproveQuarantine releases a quarantined observation into normal memory
+// and queues it for sync.
+func (s *Store) ApproveQuarantine(id int64) (*Observation, error) {
+	var approved *Observation
+	err := s.withTx(func(tx *sql.Tx) error {
+		res, err := s.execHook(tx,
+			`UPDATE observations
+			 SET quarantine_reason = NULL,
+			     updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+			 WHERE id = ? AND deleted_at IS NULL AND quarantine_reason IS NOT NULL`,
+			id,
+		)
+		if err != nil {
+			return err
 		}
-
-		if len(learnings) > 0 {
-			return learnings
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("observation #%d is not in quarantine", id)
+		}
+		approved, err = s.getObservationTx(tx, id)
+		if err != nil {
+			return err
 		}
+		return s.enqueueSyncMutationTx(tx, SyncEntityObservation, approved.SyncID, SyncOpUpsert, observationPayloadFromObservation(approved))
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return nil
+	return approved, nil
 }
 
-// cleanMarkdown strips basic markdown formatting and collapses whitespace.
-func cleanMarkdown(text string) string {
-	text = regexp.MustCompile(`\*\*([^*]+)\*\*`).ReplaceAllString(text, "$1") // bold
-	text = regexp.MustCompile("`([^`]+)`").ReplaceAllString(text, "$1")       // inline code
-	text = regexp.MustCompile(`\*([^*]+)\*`).ReplaceAllString(text, "$1")     // italic
-	return strings.TrimSpace(strings.Join(strings.Fields(text), " "))
+// RejectQuarantine permanently deletes a quarantined observation, or
+// soft-deletes it in append-only mode. It was never synced, so no delete
+// mutation is needed.
+func (s *Store) RejectQuarantine(id int64) error {
+	query := `DELETE FROM observations WHERE id = ? AND quarantine_reason IS NOT NULL`
+	if s.cfg.AppendOnly {
+		query = `UPDATE observations
+		         SET deleted_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+		             updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		         WHERE id = ? AND quarantine_reason IS NOT NULL AND deleted_at IS NULL`
+	}
+	res, err := s.execHook(s.db, query, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("observation #%d is not in quarantine", id)
+	}
+	return nil
 }
 
-// PassiveCapture extracts learnings from text and saves them as observations.
-// It deduplicates against existing observations using content hash matching.
-func (s *Store) PassiveCapture(p PassiveCaptureParams) (*PassiveCaptureResult, error) {
-	// Normalize project name before storing
-	p.Project, _ = NormalizeProject(p.Project)
-
-	result := &PassiveCaptureResult{}
+// ─── Trash ───────────────────────────────────────────────────────────────────
 
-	learnings := ExtractLearnings(p.Content)
-	result.Extracted = len(learnings)
+// DeletedObservations returns soft-deleted observations, most recently
+// deleted first. Revisions replaced by an append-only edit and rejected
+// quarantine entries are left out: they were never deleted by hand. A
+// chunked save is listed once, by its first part.
+func (s *Store) DeletedObservations(project string, limit int) ([]Observation, error) {
+	project, _ = NormalizeProject(project)
+	if limit <= 0 {
+		limit = 50
+	}
 
-	if len(learnings) == 0 {
-		return result, nil
+	query := `
+		SELECT id, ifnull(sync_id, '') as sync_id, session_id, type, title, content, tool_name, project,
+		       scope, topic_key, revision_count, duplicate_count, last_seen_at, created_at, updated_at, deleted_at, refs, verified_at, stale_at, verification_note, source, metadata
+		FROM observations
+		WHERE deleted_at IS NOT NULL AND superseded_by IS NULL AND quarantine_reason IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM observation_parts p WHERE p.observation_id = observations.id AND p.part > 1)`
+	args := []any{}
+	if project != "" {
+		clause, clauseArgs := projectFilterSQL("project", project)
+		query += clause
+		args = append(args, clauseArgs...)
 	}
+	query += " ORDER BY deleted_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
 
-	for _, learning := range learnings {
-		// Check if this learning already exists (by content hash) within this project
-		normHash := hashNormalized(learning)
-		var existingID int64
-		err := s.db.QueryRow(
-			`SELECT id FROM observations
-			 WHERE normalized_hash = ?
-			   AND ifnull(project, '') = ifnull(?, '')
-			   AND deleted_at IS NULL
-			 LIMIT 1`,
-			normHash, nullableString(p.Project),
-		).Scan(&existingID)
+	return s.queryObservations(query, args...)
+}
 
-		if err == nil {
-			// Already exists — skip
-			result.Duplicates++
-			continue
+// RestoreObservation brings a soft-deleted observation back, along with its
+// continuations when it is the first part of a chunked save, and queues
+// each for sync, which undeletes them on other machines too.
+func (s *Store) RestoreObservation(id int64) (*Observation, error) {
+	var restored *Observation
+	err := s.withTx(func(tx *sql.Tx) error {
+		restoreTx := func(id int64) (*Observation, error) {
+			res, err := s.execHook(tx,
+				`UPDATE observations
+				 SET deleted_at = NULL,
+				     updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+				 WHERE id = ? AND deleted_at IS NOT NULL AND superseded_by IS NULL AND quarantine_reason IS NULL`,
+				id,
+			)
+			if err != nil {
+				return nil, err
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				return nil, nil
+			}
+			obs, err := s.getObservationTx(tx, id)
+			if err != nil {
+				return nil, err
+			}
+			return obs, s.enqueueSyncMutationTx(tx, SyncEntityObservation, obs.SyncID, SyncOpUpsert, observationPayloadFromObservation(obs))
 		}
 
-		// Truncate for title: first 60 chars
-		title := learning
-		if len(title) > 60 {
-			title = title[:60] + "..."
+		var err error
+		restored, err = restoreTx(id)
+		if err != nil {
+			return err
 		}
-
-		_, err = s.AddObservation(AddObservationParams{
-			SessionID: p.SessionID,
-			Type:      "passive",
-			Title:     title,
-			Content:   learning,
-			Project:   p.Project,
-			Scope:     "project",
-			ToolName:  p.Source,
-		})
+		if restored == nil {
+			return fmt.Errorf("observation #%d is not in the trash", id)
+		}
+		continuations, err := s.continuationIDsTx(tx, id)
 		if err != nil {
-			return result, fmt.Errorf("passive capture save: %w", err)
+			return err
 		}
-		result.Saved++
+		for _, partID := range continuations {
+			if _, err := restoreTx(partID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return result, nil
+	return restored, nil
 }
 
 // ClassifyTool returns the observation type for a given tool name.
@@ -3646,7 +12320,105 @@ func ClassifyTool(toolName string) string {
 	}
 }
 
-// Now returns the current time formatted for SQLite.
+// ─── Timestamps ──────────────────────────────────────────────────────────────
+//
+// Timestamps are stored as RFC3339 UTC strings ("2026-03-01T14:05:09Z"), so
+// they sort lexically and carry their zone. Rows written by older versions
+// used naive UTC ("2026-03-01 14:05:09"); migrateTimestamps rewrites them, and
+// imported or synced values are normalized on the way in. Display converts
+// to Config.DisplayLocation.
+
+// TimestampLayout is the storage format of every timestamp column.
+const TimestampLayout = "2006-01-02T15:04:05Z"
+
+// DisplayLayout is how timestamps are shown to people.
+const DisplayLayout = "2006-01-02 15:04:05 MST"
+
+// legacyTimestampLayout is the naive UTC format used before RFC3339.
+const legacyTimestampLayout = "2006-01-02 15:04:05"
+
+// Now returns the current time formatted for storage.
 func Now() string {
-	return time.Now().UTC().Format("2006-01-02 15:04:05")
+	return time.Now().UTC().Format(TimestampLayout)
+}
+
+// ParseTimestamp parses a stored, imported, or legacy timestamp. Values
+// without a zone are UTC.
+func ParseTimestamp(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339Nano, legacyTimestampLayout, "2006-01-02T15:04:05", "2006-01-02 15:04:05.999999999"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
+
+// ParseSearchTime parses a search window bound: a date (midnight UTC) or
+// any timestamp ParseTimestamp accepts.
+func ParseSearchTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, strings.TrimSpace(value)); err == nil {
+		return t, nil
+	}
+	t, err := ParseTimestamp(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC3339, got %q", value)
+	}
+	return t, nil
+}
+
+// NormalizeTimestamp converts value to TimestampLayout, leaving it as-is
+// when it can't be parsed.
+func NormalizeTimestamp(value string) string {
+	t, err := ParseTimestamp(value)
+	if err != nil {
+		return value
+	}
+	return t.Format(TimestampLayout)
+}
+
+func normalizeTimestampPtr(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	normalized := NormalizeTimestamp(*value)
+	return &normalized
+}
+
+// FormatTimestamp renders a stored timestamp in loc (time.Local when nil)
+// using DisplayLayout. Unparseable values are returned unchanged.
+func FormatTimestamp(value string, loc *time.Location) string {
+	t, err := ParseTimestamp(value)
+	if err != nil {
+		return value
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return t.In(loc).Format(DisplayLayout)
+}
+
+// LoadDisplayLocation resolves a configured time zone: an IANA name such
+// as "America/Argentina/Buenos_Aires", "UTC", or "" / "Local" for the
+// system zone.
+func LoadDisplayLocation(name string) (*time.Location, error) {
+	switch strings.TrimSpace(name) {
+	case "", "Local", "local":
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(strings.TrimSpace(name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// FormatTime renders a stored timestamp in the configured display zone.
+func (c Config) FormatTime(value string) string {
+	return FormatTimestamp(value, c.DisplayLocation)
+}
+
+// FormatTime renders a stored timestamp in the store's display zone.
+func (s *Store) FormatTime(value string) string {
+	return s.cfg.FormatTime(value)
 }
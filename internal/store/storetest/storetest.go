@@ -0,0 +1,42 @@
+// Package storetest opens throwaway stores for the tests of packages built
+// on internal/store.
+//
+// Each store lives in its own t.TempDir with the default config and is
+// closed when the test ends.
+package storetest
+
+import (
+	"testing"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// New opens an empty store for the duration of t.
+func New(t testing.TB) *store.Store {
+	t.Helper()
+	cfg, err := store.DefaultConfig()
+	if err != nil {
+		t.Fatalf("DefaultConfig: %v", err)
+	}
+	cfg.DataDir = t.TempDir()
+
+	s, err := store.New(cfg)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+	return s
+}
+
+// NewWithSession opens a store like New with session "s1" already started
+// in project "engram", so a test can save observations right away.
+func NewWithSession(t testing.TB) *store.Store {
+	t.Helper()
+	s := New(t)
+	if err := s.CreateSession("s1", "engram", "/tmp/engram"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	return s
+}
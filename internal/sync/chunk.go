@@ -0,0 +1,176 @@
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// ─── Chunk Format ────────────────────────────────────────────────────────────
+//
+// Format 1 chunks are a bare ChunkData object with no integrity metadata.
+// Format 2 chunks keep the same sessions/observations/prompts keys, so older
+// engram versions still import them, and add:
+//
+//   - "format": the version header (2)
+//   - "manifest": one ChunkItem per record, in record order, with the
+//     SHA-256 of the record's JSON exactly as stored in the chunk
+//   - "checksum": the SHA-256 of the manifest lines, covering every record
+//
+// The chunk ID is the checksum's first 8 hex characters, and the sync
+// manifest entry repeats the checksum, so a chunk swapped or damaged after
+// it was listed is caught on import. Records are hashed as raw bytes rather
+// than re-encoded, so fields added by newer versions do not break
+// verification.
+
+// ChunkFormat is the chunk format Export writes.
+const ChunkFormat = 2
+
+// ErrChunkCorrupt is returned when a chunk fails verification.
+var ErrChunkCorrupt = errors.New("chunk failed verification")
+
+// ChunkItem lists one record of a format 2 chunk.
+type ChunkItem struct {
+	Kind string `json:"kind"` // session, observation, or prompt
+	ID   string `json:"id"`   // Session ID, or the record's sync ID (numeric ID when it has none)
+	Hash string `json:"hash"` // SHA-256 of the record's JSON
+}
+
+// chunkV2 is the on-disk layout of a format 2 chunk.
+type chunkV2 struct {
+	Format       int               `json:"format"`
+	Checksum     string            `json:"checksum"`
+	Manifest     []ChunkItem       `json:"manifest"`
+	Sessions     []json.RawMessage `json:"sessions"`
+	Observations []json.RawMessage `json:"observations"`
+	Prompts      []json.RawMessage `json:"prompts"`
+}
+
+// encodeChunk serializes chunk as format 2 and returns its checksum.
+func encodeChunk(chunk *ChunkData) ([]byte, string, error) {
+	out := chunkV2{Format: ChunkFormat}
+	add := func(kind, id string, record any, dst *[]json.RawMessage) error {
+		raw, err := jsonMarshalChunk(record)
+		if err != nil {
+			return err
+		}
+		*dst = append(*dst, raw)
+		out.Manifest = append(out.Manifest, ChunkItem{Kind: kind, ID: id, Hash: hashHex(raw)})
+		return nil
+	}
+	for _, s := range chunk.Sessions {
+		if err := add("session", s.ID, s, &out.Sessions); err != nil {
+			return nil, "", err
+		}
+	}
+	for _, o := range chunk.Observations {
+		if err := add("observation", recordID(o.SyncID, o.ID), o, &out.Observations); err != nil {
+			return nil, "", err
+		}
+	}
+	for _, p := range chunk.Prompts {
+		if err := add("prompt", recordID(p.SyncID, p.ID), p, &out.Prompts); err != nil {
+			return nil, "", err
+		}
+	}
+	out.Checksum = manifestChecksum(out.Manifest)
+
+	data, err := jsonMarshalChunk(out)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, out.Checksum, nil
+}
+
+// decodeChunk parses a format 1 or 2 chunk. Format 2 chunks are verified
+// against their manifest and checksum, which is returned (empty for
+// format 1).
+func decodeChunk(data []byte) (*ChunkData, string, error) {
+	var head struct {
+		Format int `json:"format"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, "", err
+	}
+	switch {
+	case head.Format <= 1:
+		var chunk ChunkData
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return nil, "", err
+		}
+		return &chunk, "", nil
+	case head.Format > ChunkFormat:
+		return nil, "", fmt.Errorf("chunk format %d is newer than this engram supports (%d); upgrade engram", head.Format, ChunkFormat)
+	}
+
+	var in chunkV2
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, "", err
+	}
+	if got := manifestChecksum(in.Manifest); got != in.Checksum {
+		return nil, "", fmt.Errorf("%w: checksum %s does not match manifest (%s)", ErrChunkCorrupt, in.Checksum, got)
+	}
+	if want := len(in.Sessions) + len(in.Observations) + len(in.Prompts); len(in.Manifest) != want {
+		return nil, "", fmt.Errorf("%w: manifest lists %d records, chunk holds %d", ErrChunkCorrupt, len(in.Manifest), want)
+	}
+
+	chunk := &ChunkData{}
+	items := in.Manifest
+	verify := func(kind string, raw json.RawMessage, dst any) error {
+		item := items[0]
+		items = items[1:]
+		if item.Kind != kind || item.Hash != hashHex(raw) {
+			return fmt.Errorf("%w: %s %s does not match its hash", ErrChunkCorrupt, item.Kind, item.ID)
+		}
+		return json.Unmarshal(raw, dst)
+	}
+	for _, raw := range in.Sessions {
+		var s store.Session
+		if err := verify("session", raw, &s); err != nil {
+			return nil, "", err
+		}
+		chunk.Sessions = append(chunk.Sessions, s)
+	}
+	for _, raw := range in.Observations {
+		var o store.Observation
+		if err := verify("observation", raw, &o); err != nil {
+			return nil, "", err
+		}
+		chunk.Observations = append(chunk.Observations, o)
+	}
+	for _, raw := range in.Prompts {
+		var p store.Prompt
+		if err := verify("prompt", raw, &p); err != nil {
+			return nil, "", err
+		}
+		chunk.Prompts = append(chunk.Prompts, p)
+	}
+	return chunk, in.Checksum, nil
+}
+
+// manifestChecksum hashes one "kind id hash" line per item.
+func manifestChecksum(items []ChunkItem) string {
+	var b strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&b, "%s %s %s\n", item.Kind, item.ID, item.Hash)
+	}
+	return hashHex([]byte(b.String()))
+}
+
+func recordID(syncID string, id int64) string {
+	if syncID != "" {
+		return syncID
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
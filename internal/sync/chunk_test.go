@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+func TestExportWritesVerifiedFormat2Chunks(t *testing.T) {
+	src := newTestStore(t)
+	seedStoreForSync(t, src)
+	syncDir := t.TempDir()
+
+	result, err := New(src, syncDir).Export("alice", "")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	manifest, err := New(nil, syncDir).readManifest()
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	entry := manifest.Chunks[0]
+	if entry.Format != ChunkFormat || !strings.HasPrefix(entry.Checksum, result.ChunkID) {
+		t.Fatalf("expected a format 2 entry addressed by its checksum, got %+v", entry)
+	}
+
+	chunkPath := filepath.Join(syncDir, "chunks", result.ChunkID+".jsonl.gz")
+	raw, err := readGzip(chunkPath)
+	if err != nil {
+		t.Fatalf("read chunk: %v", err)
+	}
+	var onDisk chunkV2
+	if err := json.Unmarshal(raw, &onDisk); err != nil {
+		t.Fatalf("parse chunk: %v", err)
+	}
+	want := result.SessionsExported + result.ObservationsExported + result.PromptsExported
+	if onDisk.Format != ChunkFormat || len(onDisk.Manifest) != want || onDisk.Manifest[0].Kind != "session" {
+		t.Fatalf("expected a manifest of %d records, got format=%d %+v", want, onDisk.Format, onDisk.Manifest)
+	}
+
+	// Older readers see the same record keys.
+	var legacy ChunkData
+	if err := json.Unmarshal(raw, &legacy); err != nil || len(legacy.Observations) != result.ObservationsExported {
+		t.Fatalf("expected a format 1 reader to see the records, got %+v err=%v", legacy, err)
+	}
+
+	dst := newTestStore(t)
+	imported, err := New(dst, syncDir).Import()
+	if err != nil || imported.ObservationsImported != result.ObservationsExported {
+		t.Fatalf("expected a clean import, got %+v err=%v", imported, err)
+	}
+
+	// A record edited after export fails verification.
+	tampered := bytes.Replace(raw, []byte(`"title":"`), []byte(`"title":"x`), 1)
+	if err := writeGzip(chunkPath, tampered); err != nil {
+		t.Fatalf("rewrite chunk: %v", err)
+	}
+	if _, err := New(newTestStore(t), syncDir).Import(); !errors.Is(err, ErrChunkCorrupt) {
+		t.Fatalf("expected ErrChunkCorrupt for an edited record, got %v", err)
+	}
+
+	// So does a valid chunk swapped in under the listed ID.
+	other, _, err := encodeChunk(&ChunkData{Sessions: []store.Session{{ID: "s9", Project: "p", StartedAt: "2025-01-01T00:00:00Z"}}})
+	if err != nil {
+		t.Fatalf("encode chunk: %v", err)
+	}
+	if err := writeGzip(chunkPath, other); err != nil {
+		t.Fatalf("rewrite chunk: %v", err)
+	}
+	if _, err := New(newTestStore(t), syncDir).Import(); !errors.Is(err, ErrChunkCorrupt) {
+		t.Fatalf("expected ErrChunkCorrupt for a swapped chunk, got %v", err)
+	}
+}
+
+func TestDecodeChunkRejectsNewerFormats(t *testing.T) {
+	if _, _, err := decodeChunk([]byte(`{"format":3}`)); err == nil || !strings.Contains(err.Error(), "upgrade engram") {
+		t.Fatalf("expected a newer format to be refused, got %v", err)
+	}
+	chunk, checksum, err := decodeChunk([]byte(`{"sessions":[{"id":"s1","project":"p"}]}`))
+	if err != nil || checksum != "" || len(chunk.Sessions) != 1 {
+		t.Fatalf("expected a format 1 chunk to decode, got %+v %q err=%v", chunk, checksum, err)
+	}
+}
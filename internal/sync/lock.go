@@ -0,0 +1,138 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// ─── Sync Locking ────────────────────────────────────────────────────────────
+//
+// Export, Import, and Prune read the manifest, write chunks or the manifest,
+// and record chunk IDs in the store. Two `engram sync` runs interleaving
+// those steps can drop a manifest entry or record a chunk the other run
+// wrote. Each run therefore takes two locks:
+//
+//   - a sync.lock file in the sync directory, for runs sharing a .engram/
+//   - a store advisory lock, for runs sharing a database (including remote
+//     transports, which have no sync directory)
+//
+// A lock older than lockTTL is treated as left behind by a crashed run and
+// taken over.
+
+// ErrSyncInProgress is returned when another sync holds a lock.
+var ErrSyncInProgress = errors.New("another sync in progress")
+
+const (
+	lockFileName  = "sync.lock"
+	storeLockName = "sync"
+	lockTTL       = 10 * time.Minute
+)
+
+var osGetpid = os.Getpid
+
+// lockInfo is the content of sync.lock.
+type lockInfo struct {
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"started_at"`
+}
+
+func (l lockInfo) holder() string {
+	if l.PID == 0 {
+		return "an unknown process"
+	}
+	return fmt.Sprintf("%s pid %d", l.Host, l.PID)
+}
+
+// lock takes both sync locks and returns the function that releases them.
+func (sy *Syncer) lock() (func(), error) {
+	host, _ := osHostname()
+	info := lockInfo{PID: osGetpid(), Host: host, StartedAt: timeNow().UTC().Format(time.RFC3339)}
+
+	unlockFile, err := sy.lockFile(info)
+	if err != nil {
+		return nil, err
+	}
+	if sy.store == nil {
+		return unlockFile, nil
+	}
+	// Only a held lock stops the run. A store that cannot take the lock at
+	// all, say one left at an older schema, fails at the next step with a
+	// more useful error than the lock's.
+	if err := sy.store.AcquireLock(storeLockName, info.holder(), lockTTL); err != nil {
+		if errors.Is(err, store.ErrLockHeld) {
+			unlockFile()
+			return nil, fmt.Errorf("%w (%w)", ErrSyncInProgress, err)
+		}
+		return unlockFile, nil
+	}
+	return func() {
+		_ = sy.store.ReleaseLock(storeLockName, info.holder())
+		unlockFile()
+	}, nil
+}
+
+// lockFile creates sync.lock exclusively. Without a sync directory on disk
+// there is nothing to protect, so it is a no-op.
+func (sy *Syncer) lockFile(info lockInfo) (func(), error) {
+	if sy.syncDir == "" {
+		return func() {}, nil
+	}
+	if _, err := os.Stat(sy.syncDir); os.IsNotExist(err) {
+		return func() {}, nil
+	}
+	path := filepath.Join(sy.syncDir, lockFileName)
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("marshal sync lock: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, werr := f.Write(data)
+			if cerr := f.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				_ = os.Remove(path)
+				return nil, fmt.Errorf("write sync lock: %w", werr)
+			}
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("create sync lock: %w", err)
+		}
+
+		held, stale := readLockFile(path)
+		if !stale || attempt > 0 {
+			return nil, fmt.Errorf("%w: %s held by %s since %s; remove it if that sync crashed", ErrSyncInProgress, path, held.holder(), held.StartedAt)
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("remove stale sync lock: %w", err)
+		}
+	}
+}
+
+// readLockFile reports who holds path and whether the lock is stale. An
+// unreadable lock file is stale once its modification time is.
+func readLockFile(path string) (lockInfo, bool) {
+	var info lockInfo
+	started := time.Time{}
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &info) == nil {
+		started, _ = time.Parse(time.RFC3339, info.StartedAt)
+	}
+	if started.IsZero() {
+		if st, err := os.Stat(path); err == nil {
+			started = st.ModTime()
+			info.StartedAt = started.UTC().Format(time.RFC3339)
+		}
+	}
+	return info, timeNow().Sub(started) > lockTTL
+}
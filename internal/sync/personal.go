@@ -0,0 +1,526 @@
+package sync
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// ─── Personal Sync ───────────────────────────────────────────────────────────
+//
+// Personal sync carries personal-scope memories between one user's machines
+// without git. It reuses the chunk format and the synced-chunk tracking of
+// team sync, but every blob leaves the machine encrypted with a key derived
+// from a passphrase, so the place they are kept — an engram relay, a WebDAV
+// share, or a synced folder — only ever sees ciphertext:
+//
+//	keyinfo.json          ← KDF salt and a passphrase check (plaintext)
+//	manifest.enc          ← the manifest, encrypted
+//	chunk-<id>.enc        ← one gzipped chunk, encrypted
+//
+// Blobs are sealed with AES-256-GCM, with the blob name as additional data
+// so a relay cannot swap one blob for another.
+
+// PersonalScope is the observation scope personal sync carries.
+const PersonalScope = "personal"
+
+var (
+	// ErrWrongPassphrase is returned when the passphrase does not open the
+	// blobs already in the relay.
+	ErrWrongPassphrase = errors.New("wrong sync passphrase")
+	// ErrBlobNotFound is returned by a BlobStore for a missing blob.
+	ErrBlobNotFound = errors.New("blob not found")
+)
+
+const (
+	keyInfoBlob  = "keyinfo.json"
+	manifestBlob = "manifest.enc"
+	sealMagic    = "ENG1"
+	keyCheck     = "engram personal sync"
+)
+
+// pbkdf2Iterations is the work factor for new relays, and the least one
+// accepted from an existing relay's keyinfo.json. Tests lower it.
+var pbkdf2Iterations = 600_000
+
+// PersonalOptions locate and unlock a personal sync relay.
+type PersonalOptions struct {
+	// Relay is an http(s) URL (engram relay or WebDAV) or a directory.
+	Relay string
+	// Token is sent as a bearer token to an http(s) relay.
+	Token string
+	// Passphrase derives the encryption key; every machine must use the
+	// same one.
+	Passphrase string
+}
+
+// Transport opens the relay and derives the key.
+func (o PersonalOptions) Transport() (*PersonalTransport, error) {
+	blobs, err := OpenBlobStore(o.Relay, o.Token)
+	if err != nil {
+		return nil, err
+	}
+	return NewPersonalTransport(blobs, o.Passphrase)
+}
+
+// NewPersonal creates a Syncer that exports only personal-scope memories,
+// from every project, through transport.
+func NewPersonal(s *store.Store, transport Transport) *Syncer {
+	return &Syncer{store: s, transport: transport, scope: PersonalScope}
+}
+
+// filterByScope keeps the observations in scope and the sessions they
+// belong to. Prompts have no scope and are left out.
+func filterByScope(data *store.ExportData, scope string) *store.ExportData {
+	result := &store.ExportData{Version: data.Version, ExportedAt: data.ExportedAt}
+	sessionIDs := make(map[string]bool)
+	for _, o := range data.Observations {
+		if o.Scope == scope {
+			result.Observations = append(result.Observations, o)
+			sessionIDs[o.SessionID] = true
+		}
+	}
+	for _, s := range data.Sessions {
+		if sessionIDs[s.ID] {
+			result.Sessions = append(result.Sessions, s)
+		}
+	}
+	return result
+}
+
+// ─── Blob Stores ─────────────────────────────────────────────────────────────
+
+// BlobStore keeps named blobs for a PersonalTransport.
+type BlobStore interface {
+	// Get returns ErrBlobNotFound for a missing blob.
+	Get(name string) ([]byte, error)
+	Put(name string, data []byte) error
+	// Delete of a missing blob is not an error.
+	Delete(name string) error
+}
+
+var blobNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func validBlobName(name string) error {
+	if !blobNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid blob name %q", name)
+	}
+	return nil
+}
+
+// OpenBlobStore opens location: an http:// or https:// URL is an engram
+// relay or WebDAV collection, anything else a local directory (say, a
+// folder synced by another tool). token, when set, is sent as a bearer
+// token.
+func OpenBlobStore(location, token string) (BlobStore, error) {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return nil, errors.New("personal sync: no relay configured")
+	}
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("personal sync: relay URL: %w", err)
+		}
+		return NewHTTPBlobStore(u, token), nil
+	}
+	return NewDirBlobStore(location), nil
+}
+
+// DirBlobStore keeps blobs as files in a directory.
+type DirBlobStore struct {
+	dir string
+}
+
+// NewDirBlobStore creates a DirBlobStore; dir is created on the first Put.
+func NewDirBlobStore(dir string) *DirBlobStore {
+	return &DirBlobStore{dir: dir}
+}
+
+func (d *DirBlobStore) Get(name string) ([]byte, error) {
+	if err := validBlobName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(d.dir, name))
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	}
+	return data, err
+}
+
+// Put writes through a temporary file so readers never see half a blob.
+func (d *DirBlobStore) Put(name string, data []byte) error {
+	if err := validBlobName(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(d.dir, 0o700); err != nil {
+		return fmt.Errorf("create blob dir: %w", err)
+	}
+	f, err := os.CreateTemp(d.dir, "."+name+".*")
+	if err != nil {
+		return err
+	}
+	_, werr := f.Write(data)
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		werr = os.Rename(f.Name(), filepath.Join(d.dir, name))
+	}
+	if werr != nil {
+		os.Remove(f.Name())
+	}
+	return werr
+}
+
+func (d *DirBlobStore) Delete(name string) error {
+	if err := validBlobName(name); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(d.dir, name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// HTTPBlobStore keeps blobs under a base URL with GET, PUT, and DELETE,
+// which both `engram relay` and WebDAV servers answer.
+type HTTPBlobStore struct {
+	base  *url.URL
+	token string
+	http  *http.Client
+}
+
+// NewHTTPBlobStore creates an HTTPBlobStore rooted at base.
+func NewHTTPBlobStore(base *url.URL, token string) *HTTPBlobStore {
+	b := *base
+	if !strings.HasSuffix(b.Path, "/") {
+		b.Path += "/"
+	}
+	return &HTTPBlobStore{base: &b, token: token, http: &http.Client{Timeout: time.Minute}}
+}
+
+func (h *HTTPBlobStore) do(method, name string, body []byte) (*http.Response, error) {
+	if err := validBlobName(name); err != nil {
+		return nil, err
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, h.base.JoinPath(name).String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+	resp, err := h.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, name, err)
+	}
+	return resp, nil
+}
+
+func (h *HTTPBlobStore) Get(name string) ([]byte, error) {
+	resp, err := h.do(http.MethodGet, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrBlobNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("GET %s: relay answered %s", name, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (h *HTTPBlobStore) Put(name string, data []byte) error {
+	resp, err := h.do(http.MethodPut, name, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("PUT %s: relay answered %s", name, resp.Status)
+	}
+	return nil
+}
+
+func (h *HTTPBlobStore) Delete(name string) error {
+	resp, err := h.do(http.MethodDelete, name, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("DELETE %s: relay answered %s", name, resp.Status)
+	}
+	return nil
+}
+
+// ─── Relay ───────────────────────────────────────────────────────────────────
+
+// maxRelayBlob caps one uploaded blob.
+const maxRelayBlob = 64 << 20
+
+// RelayHandler serves the blobs of a DirBlobStore over HTTP for
+// HTTPBlobStore clients: GET, PUT, and DELETE .../<name>. Every request must
+// carry token as a bearer token. The relay never sees a key; it stores
+// whatever ciphertext it is given.
+func RelayHandler(blobs *DirBlobStore, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// Blobs live in one flat namespace, so the relay can sit under any
+		// path prefix: only the last segment names the blob.
+		name := path.Base(r.URL.Path)
+		if validBlobName(name) != nil {
+			http.Error(w, "invalid blob name", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			data, err := blobs.Get(name)
+			if errors.Is(err, ErrBlobNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(data)
+		case http.MethodPut:
+			data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRelayBlob))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err := blobs.Put(name, data); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			if err := blobs.Delete(name); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// ─── PersonalTransport ───────────────────────────────────────────────────────
+
+// keyInfo is the plaintext keyinfo.json blob: what another machine needs,
+// besides the passphrase, to derive the same key.
+type keyInfo struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Check      []byte `json:"check"` // keyCheck sealed with the key
+}
+
+// PersonalTransport is a Transport that encrypts the manifest and chunks
+// before handing them to a BlobStore.
+type PersonalTransport struct {
+	blobs BlobStore
+	aead  cipher.AEAD
+}
+
+// NewPersonalTransport derives the key from passphrase. The first machine
+// to sync writes keyinfo.json with a fresh salt; the others read it and
+// get ErrWrongPassphrase if their passphrase does not match.
+func NewPersonalTransport(blobs BlobStore, passphrase string) (*PersonalTransport, error) {
+	if passphrase == "" {
+		return nil, errors.New("personal sync: passphrase is empty")
+	}
+	pt := &PersonalTransport{blobs: blobs}
+
+	raw, err := blobs.Get(keyInfoBlob)
+	switch {
+	case errors.Is(err, ErrBlobNotFound):
+		info := keyInfo{Version: 1, KDF: "pbkdf2-sha256", Iterations: pbkdf2Iterations, Salt: make([]byte, 16)}
+		if _, err := rand.Read(info.Salt); err != nil {
+			return nil, err
+		}
+		if err := pt.deriveKey(passphrase, info); err != nil {
+			return nil, err
+		}
+		if info.Check, err = pt.seal(keyInfoBlob, []byte(keyCheck)); err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(info)
+		if err != nil {
+			return nil, err
+		}
+		if err := blobs.Put(keyInfoBlob, data); err != nil {
+			return nil, fmt.Errorf("personal sync: write key info: %w", err)
+		}
+		return pt, nil
+	case err != nil:
+		return nil, fmt.Errorf("personal sync: read key info: %w", err)
+	}
+
+	var info keyInfo
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return nil, fmt.Errorf("personal sync: parse key info: %w", err)
+	}
+	if info.Version != 1 || info.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("personal sync: unsupported key info version %d (%s); upgrade engram", info.Version, info.KDF)
+	}
+	// keyinfo.json is stored in the clear on the relay, so a relay could
+	// lower the work factor to make the passphrase cheap to guess.
+	if info.Iterations < pbkdf2Iterations || len(info.Salt) < 16 {
+		return nil, fmt.Errorf("personal sync: key info asks for %d KDF iterations and a %d-byte salt, below the %d and 16 engram requires; refusing a weakened key",
+			info.Iterations, len(info.Salt), pbkdf2Iterations)
+	}
+	if err := pt.deriveKey(passphrase, info); err != nil {
+		return nil, err
+	}
+	if check, err := pt.open(keyInfoBlob, info.Check); err != nil || string(check) != keyCheck {
+		return nil, ErrWrongPassphrase
+	}
+	return pt, nil
+}
+
+func (pt *PersonalTransport) deriveKey(passphrase string, info keyInfo) error {
+	key, err := pbkdf2.Key(sha256.New, passphrase, info.Salt, info.Iterations, 32)
+	if err != nil {
+		return fmt.Errorf("personal sync: derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	pt.aead, err = cipher.NewGCM(block)
+	return err
+}
+
+// seal encrypts data for the blob called name: magic, nonce, ciphertext.
+func (pt *PersonalTransport) seal(name string, data []byte) ([]byte, error) {
+	nonce := make([]byte, pt.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append([]byte(sealMagic), nonce...)
+	return pt.aead.Seal(out, nonce, data, []byte(name)), nil
+}
+
+func (pt *PersonalTransport) open(name string, sealed []byte) ([]byte, error) {
+	n := len(sealMagic) + pt.aead.NonceSize()
+	if len(sealed) < n || string(sealed[:len(sealMagic)]) != sealMagic {
+		return nil, fmt.Errorf("%s is not an engram encrypted blob", name)
+	}
+	data, err := pt.aead.Open(nil, sealed[len(sealMagic):n], sealed[n:], []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", name, ErrWrongPassphrase)
+	}
+	return data, nil
+}
+
+func chunkBlob(chunkID string) string {
+	return "chunk-" + chunkID + ".enc"
+}
+
+// ReadManifest returns an empty manifest until the first export.
+func (pt *PersonalTransport) ReadManifest() (*Manifest, error) {
+	sealed, err := pt.blobs.Get(manifestBlob)
+	if errors.Is(err, ErrBlobNotFound) {
+		return &Manifest{Version: 1}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	data, err := pt.open(manifestBlob, sealed)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return &m, nil
+}
+
+func (pt *PersonalTransport) WriteManifest(m *Manifest) error {
+	data, err := jsonMarshalManifest(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+	sealed, err := pt.seal(manifestBlob, data)
+	if err != nil {
+		return err
+	}
+	return pt.blobs.Put(manifestBlob, sealed)
+}
+
+// WriteChunk gzips the chunk, as FileTransport does, then encrypts it.
+func (pt *PersonalTransport) WriteChunk(chunkID string, data []byte, _ ChunkEntry) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	sealed, err := pt.seal(chunkBlob(chunkID), buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return pt.blobs.Put(chunkBlob(chunkID), sealed)
+}
+
+func (pt *PersonalTransport) ReadChunk(chunkID string) ([]byte, error) {
+	sealed, err := pt.blobs.Get(chunkBlob(chunkID))
+	if err != nil {
+		return nil, err
+	}
+	data, err := pt.open(chunkBlob(chunkID), sealed)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
+}
+
+func (pt *PersonalTransport) DeleteChunk(chunkID string) error {
+	return pt.blobs.Delete(chunkBlob(chunkID))
+}
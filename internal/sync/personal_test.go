@@ -0,0 +1,221 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+func lowKDFCost(t *testing.T) {
+	old := pbkdf2Iterations
+	pbkdf2Iterations = 1000
+	t.Cleanup(func() { pbkdf2Iterations = old })
+}
+
+func TestPersonalSyncCarriesOnlyPersonalMemoriesEncrypted(t *testing.T) {
+	lowKDFCost(t)
+	relayDir := t.TempDir()
+
+	laptop := newTestStore(t)
+	if err := laptop.CreateSession("s1", "engram", "/tmp"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	for _, p := range []store.AddObservationParams{
+		{SessionID: "s1", Type: "preference", Title: "Prefer tabs", Content: "I like tabs in Go files", Project: "engram", Scope: "personal"},
+		{SessionID: "s1", Type: "decision", Title: "Team decision", Content: "Use WAL", Project: "engram"},
+	} {
+		if _, err := laptop.AddObservation(p); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+
+	transport, err := NewPersonalTransport(NewDirBlobStore(relayDir), "correct horse")
+	if err != nil {
+		t.Fatalf("new transport: %v", err)
+	}
+	result, err := NewPersonal(laptop, transport).Export("me@laptop", "")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if result.ObservationsExported != 1 || result.SessionsExported != 1 || result.PromptsExported != 0 {
+		t.Fatalf("expected only the personal memory and its session, got %+v", result)
+	}
+
+	entries, _ := os.ReadDir(relayDir)
+	for _, e := range entries {
+		data, _ := os.ReadFile(filepath.Join(relayDir, e.Name()))
+		if bytes.Contains(data, []byte("tabs")) || bytes.Contains(data, []byte("me@laptop")) {
+			t.Fatalf("expected %s to be encrypted, found plaintext", e.Name())
+		}
+	}
+
+	if _, err := NewPersonalTransport(NewDirBlobStore(relayDir), "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("expected ErrWrongPassphrase, got %v", err)
+	}
+
+	desktop := newTestStore(t)
+	transport, err = NewPersonalTransport(NewDirBlobStore(relayDir), "correct horse")
+	if err != nil {
+		t.Fatalf("second machine transport: %v", err)
+	}
+	imported, err := NewPersonal(desktop, transport).Import()
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if imported.ChunksImported != 1 || imported.ObservationsImported != 1 {
+		t.Fatalf("expected one chunk with one observation, got %+v", imported)
+	}
+	obs, err := desktop.RecentObservations("engram", "", 10)
+	if err != nil || len(obs) != 1 || obs[0].Title != "Prefer tabs" || obs[0].Scope != "personal" {
+		t.Fatalf("expected the personal memory on the desktop, got %+v err=%v", obs, err)
+	}
+
+	// A blob swapped for another does not decrypt.
+	chunk := filepath.Join(relayDir, chunkBlob(result.ChunkID))
+	manifest, _ := os.ReadFile(filepath.Join(relayDir, manifestBlob))
+	if err := os.WriteFile(chunk, manifest, 0o600); err != nil {
+		t.Fatalf("swap blob: %v", err)
+	}
+	if _, err := transport.ReadChunk(result.ChunkID); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("expected a swapped blob to fail to decrypt, got %v", err)
+	}
+}
+
+func TestPersonalTransportRejectsWeakenedKeyInfo(t *testing.T) {
+	lowKDFCost(t)
+	blobs := NewDirBlobStore(t.TempDir())
+	if _, err := NewPersonalTransport(blobs, "correct horse"); err != nil {
+		t.Fatalf("new transport: %v", err)
+	}
+	raw, err := blobs.Get(keyInfoBlob)
+	if err != nil {
+		t.Fatalf("read key info: %v", err)
+	}
+	var info keyInfo
+	if err := json.Unmarshal(raw, &info); err != nil {
+		t.Fatalf("parse key info: %v", err)
+	}
+
+	for name, weaken := range map[string]func(*keyInfo){
+		"iterations": func(k *keyInfo) { k.Iterations = 1 },
+		"salt":       func(k *keyInfo) { k.Salt = k.Salt[:4] },
+	} {
+		weak := info
+		weaken(&weak)
+		data, err := json.Marshal(weak)
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", name, err)
+		}
+		if err := blobs.Put(keyInfoBlob, data); err != nil {
+			t.Fatalf("%s: write key info: %v", name, err)
+		}
+		if _, err := NewPersonalTransport(blobs, "correct horse"); err == nil || !strings.Contains(err.Error(), "weakened key") {
+			t.Fatalf("%s: expected a weakened key info to be refused, got %v", name, err)
+		}
+	}
+}
+
+func TestTeamExportLeavesPersonalMemoriesOut(t *testing.T) {
+	open := func(policy store.PersonalSyncPolicy) *store.Store {
+		cfg, err := store.DefaultConfig()
+		if err != nil {
+			t.Fatalf("DefaultConfig: %v", err)
+		}
+		cfg.DataDir = t.TempDir()
+		cfg.PersonalSync = policy
+		s, err := store.New(cfg)
+		if err != nil {
+			t.Fatalf("new store: %v", err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		if err := s.CreateSession("s1", "engram", "/tmp"); err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+		for _, p := range []store.AddObservationParams{
+			{SessionID: "s1", Type: "preference", Title: "Prefer tabs", Content: "I like tabs in Go files", Project: "engram", Scope: "personal"},
+			{SessionID: "s1", Type: "decision", Title: "Team decision", Content: "Use WAL", Project: "engram"},
+		} {
+			if _, err := s.AddObservation(p); err != nil {
+				t.Fatalf("add observation: %v", err)
+			}
+		}
+		return s
+	}
+	export := func(s *store.Store, includePersonal bool) (*SyncResult, error) {
+		sy := New(s, t.TempDir())
+		sy.SetIncludePersonal(includePersonal)
+		return sy.Export("alice", "")
+	}
+
+	s := open("")
+	if result, err := export(s, false); err != nil || result.ObservationsExported != 1 || result.SessionsExported != 1 {
+		t.Fatalf("expected the personal memory left out by default, got %+v err=%v", result, err)
+	}
+	if result, err := export(s, true); err != nil || result.ObservationsExported != 2 {
+		t.Fatalf("expected --include-personal to share it, got %+v err=%v", result, err)
+	}
+	if result, err := export(open(store.PersonalSyncInclude), false); err != nil || result.ObservationsExported != 2 {
+		t.Fatalf("expected the include policy to share it, got %+v err=%v", result, err)
+	}
+
+	denied := open(store.PersonalSyncDeny)
+	if _, err := export(denied, true); !errors.Is(err, store.ErrPersonalSyncDenied) {
+		t.Fatalf("expected ErrPersonalSyncDenied, got %v", err)
+	}
+	if result, err := export(denied, false); err != nil || result.ObservationsExported != 1 {
+		t.Fatalf("expected the deny policy to still export shared memories, got %+v err=%v", result, err)
+	}
+}
+
+func TestRelayHandlerServesHTTPBlobStore(t *testing.T) {
+	lowKDFCost(t)
+	srv := httptest.NewServer(RelayHandler(NewDirBlobStore(t.TempDir()), "s3cret"))
+	defer srv.Close()
+	base, _ := url.Parse(srv.URL + "/engram")
+
+	if _, err := NewPersonalTransport(NewHTTPBlobStore(base, "nope"), "pass"); err == nil {
+		t.Fatal("expected a wrong relay token to be refused")
+	}
+
+	blobs := NewHTTPBlobStore(base, "s3cret")
+	if _, err := blobs.Get("missing"); !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("expected ErrBlobNotFound, got %v", err)
+	}
+	transport, err := NewPersonalTransport(blobs, "pass")
+	if err != nil {
+		t.Fatalf("new transport: %v", err)
+	}
+	if err := transport.WriteChunk("abcd1234", []byte(`{"sessions":[]}`), ChunkEntry{}); err != nil {
+		t.Fatalf("write chunk: %v", err)
+	}
+	got, err := transport.ReadChunk("abcd1234")
+	if err != nil || string(got) != `{"sessions":[]}` {
+		t.Fatalf("expected the chunk back, got %q err=%v", got, err)
+	}
+	if err := transport.DeleteChunk("abcd1234"); err != nil {
+		t.Fatalf("delete chunk: %v", err)
+	}
+	if _, err := blobs.Get(chunkBlob("abcd1234")); !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("expected the chunk gone, got %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/engram/.keyinfo.json.123", bytes.NewReader(nil))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a temporary blob name to be refused, got %d", resp.StatusCode)
+	}
+}
@@ -17,13 +17,18 @@
 //	│   ├── b7d2e4f1.jsonl.gz ← chunk 2
 //	│   └── ...
 //	└── engram.db              ← local working DB (gitignored)
+//
+// Chunks never change, but they can be garbage-collected: Prune deletes
+// chunk files older than a cutoff that this machine has already imported and
+// drops them from the manifest, which then lists only the retained chunks.
+// The manifest keeps the creation time of the newest pruned chunk so exports
+// do not mistake the pruned history for unsynced data.
 package sync
 
 import (
 	"compress/gzip"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -40,10 +45,13 @@ var (
 	osCreateFile        = os.Create
 	gzipWriterFactory   = func(f *os.File) gzipWriter { return gzip.NewWriter(f) }
 	osHostname          = os.Hostname
+	timeNow             = time.Now
 	storeGetSynced      = func(s *store.Store) (map[string]bool, error) { return s.GetSyncedChunks() }
 	storeExportData     = func(s *store.Store) (*store.ExportData, error) { return s.Export() }
-	storeImportData     = func(s *store.Store, d *store.ExportData) (*store.ImportResult, error) { return s.Import(d) }
-	storeRecordSynced   = func(s *store.Store, chunkID string) error { return s.RecordSyncedChunk(chunkID) }
+	storeImportData     = func(s *store.Store, d *store.ExportData) (*store.ImportResult, error) {
+		return s.ImportWithOptions(d, store.ImportOptions{OnConflict: store.ImportSkip, Source: store.SourceSyncImport})
+	}
+	storeRecordSynced = func(s *store.Store, chunkID string) error { return s.RecordSyncedChunk(chunkID) }
 )
 
 type gzipWriter interface {
@@ -58,19 +66,27 @@ type gzipWriter interface {
 type Manifest struct {
 	Version int          `json:"version"`
 	Chunks  []ChunkEntry `json:"chunks"`
+
+	// PrunedBefore is the created_at of the newest chunk removed by Prune.
+	PrunedBefore string `json:"pruned_before,omitempty"`
+	// PrunedChunks counts every chunk removed by Prune so far.
+	PrunedChunks int `json:"pruned_chunks,omitempty"`
 }
 
 // ChunkEntry describes a single chunk in the manifest.
 type ChunkEntry struct {
-	ID        string `json:"id"`         // SHA-256 hash prefix (8 chars) of content
-	CreatedBy string `json:"created_by"` // Username or machine identifier
-	CreatedAt string `json:"created_at"` // ISO timestamp
-	Sessions  int    `json:"sessions"`   // Number of sessions in chunk
-	Memories  int    `json:"memories"`   // Number of observations in chunk
-	Prompts   int    `json:"prompts"`    // Number of prompts in chunk
+	ID        string `json:"id"`                 // SHA-256 hash prefix (8 chars) of content
+	CreatedBy string `json:"created_by"`         // Username or machine identifier
+	CreatedAt string `json:"created_at"`         // ISO timestamp
+	Sessions  int    `json:"sessions"`           // Number of sessions in chunk
+	Memories  int    `json:"memories"`           // Number of observations in chunk
+	Prompts   int    `json:"prompts"`            // Number of prompts in chunk
+	Format    int    `json:"format,omitempty"`   // Chunk format (absent for format 1)
+	Checksum  string `json:"checksum,omitempty"` // Format 2 chunk checksum, verified on import
 }
 
-// ChunkData is the content of a single chunk file (JSONL entries).
+// ChunkData is the content of a single chunk file (see chunk.go for the
+// on-disk formats).
 type ChunkData struct {
 	Sessions     []store.Session     `json:"sessions"`
 	Observations []store.Observation `json:"observations"`
@@ -93,6 +109,15 @@ type ImportResult struct {
 	SessionsImported     int `json:"sessions_imported"`
 	ObservationsImported int `json:"observations_imported"`
 	PromptsImported      int `json:"prompts_imported"`
+	PlaceholderSessions  int `json:"placeholder_sessions"` // Sessions recreated for records whose session was not exported
+}
+
+// PruneResult is returned after pruning chunks.
+type PruneResult struct {
+	Pruned      []string `json:"pruned"`       // Chunk IDs removed (or that would be, on a dry run)
+	Retained    int      `json:"retained"`     // Chunks left in the manifest
+	NotImported int      `json:"not_imported"` // Old chunks kept because this machine never imported them
+	DryRun      bool     `json:"dry_run,omitempty"`
 }
 
 // ─── Syncer ──────────────────────────────────────────────────────────────────
@@ -102,6 +127,9 @@ type Syncer struct {
 	store     *store.Store
 	syncDir   string    // Path to .engram/ in the project repo (kept for backward compat)
 	transport Transport // Pluggable I/O backend (filesystem, remote, etc.)
+	scope     string    // Export only observations in this scope (see NewPersonal)
+
+	includePersonal bool // Team export: also share personal observations (see SetIncludePersonal)
 }
 
 // New creates a Syncer with a FileTransport rooted at syncDir.
@@ -129,6 +157,14 @@ func NewWithTransport(s *store.Store, transport Transport) *Syncer {
 	}
 }
 
+// SetIncludePersonal makes a team Export share personal-scope observations,
+// which it leaves out by default. The store's PersonalSync policy has the
+// last word: under "deny" Export fails instead. Personal syncers (see
+// NewPersonal) ignore it.
+func (sy *Syncer) SetIncludePersonal(include bool) {
+	sy.includePersonal = include
+}
+
 // ─── Export (DB → chunks) ────────────────────────────────────────────────────
 
 // Export creates a new chunk with memories not yet in any chunk.
@@ -145,6 +181,12 @@ func (sy *Syncer) Export(createdBy string, project string) (*SyncResult, error)
 		}
 	}
 
+	unlock, err := sy.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	// Read current manifest (or create empty one)
 	manifest, err := sy.readManifest()
 	if err != nil {
@@ -162,37 +204,22 @@ func (sy *Syncer) Export(createdBy string, project string) (*SyncResult, error)
 		knownChunks[c.ID] = true
 	}
 
-	// Export all data from DB
-	data, err := storeExportData(sy.store)
+	chunk, err := sy.pendingChunk(manifest, project)
 	if err != nil {
-		return nil, fmt.Errorf("export data: %w", err)
-	}
-
-	// Filter by project if specified
-	if project != "" {
-		data = filterByProject(data, project)
+		return nil, err
 	}
 
-	// Get the timestamp of the last chunk to filter "new" data
-	lastChunkTime := sy.lastChunkTime(manifest)
-
-	// Filter to only new data (created after last chunk)
-	chunk := sy.filterNewData(data, lastChunkTime)
-
 	// Nothing new to export
 	if len(chunk.Sessions) == 0 && len(chunk.Observations) == 0 && len(chunk.Prompts) == 0 {
 		return &SyncResult{IsEmpty: true}, nil
 	}
 
-	// Serialize and compress the chunk
-	chunkJSON, err := jsonMarshalChunk(chunk)
+	// Serialize the chunk; its ID is the content checksum's prefix
+	chunkJSON, checksum, err := encodeChunk(chunk)
 	if err != nil {
 		return nil, fmt.Errorf("marshal chunk: %w", err)
 	}
-
-	// Generate chunk ID from content hash
-	hash := sha256.Sum256(chunkJSON)
-	chunkID := hex.EncodeToString(hash[:])[:8]
+	chunkID := checksum[:8]
 
 	// Check if this exact chunk already exists
 	if _, exists := knownChunks[chunkID]; exists {
@@ -207,6 +234,8 @@ func (sy *Syncer) Export(createdBy string, project string) (*SyncResult, error)
 		Sessions:  len(chunk.Sessions),
 		Memories:  len(chunk.Observations),
 		Prompts:   len(chunk.Prompts),
+		Format:    ChunkFormat,
+		Checksum:  checksum,
 	}
 
 	// Write chunk via transport
@@ -234,10 +263,53 @@ func (sy *Syncer) Export(createdBy string, project string) (*SyncResult, error)
 	}, nil
 }
 
+// pendingChunk is the data Export would put in the next chunk: the memories
+// of project (all when empty) created after the newest chunk in manifest.
+func (sy *Syncer) pendingChunk(manifest *Manifest, project string) (*ChunkData, error) {
+	// Export all data from DB
+	data, err := storeExportData(sy.store)
+	if err != nil {
+		return nil, fmt.Errorf("export data: %w", err)
+	}
+
+	// Filter by project if specified
+	if project != "" {
+		data = filterByProject(data, project)
+	}
+	if sy.scope != "" {
+		data = filterByScope(data, sy.scope)
+	} else if data, err = sy.store.SharedExport(data, sy.includePersonal); err != nil {
+		return nil, err
+	}
+
+	// Filter to only new data (created after last chunk)
+	return sy.filterNewData(data, sy.lastChunkTime(manifest)), nil
+}
+
+// PendingExport counts what Export would write for project, without
+// writing anything.
+func (sy *Syncer) PendingExport(project string) (sessions, observations, prompts int, err error) {
+	manifest, err := sy.readManifest()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	chunk, err := sy.pendingChunk(manifest, project)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return len(chunk.Sessions), len(chunk.Observations), len(chunk.Prompts), nil
+}
+
 // ─── Import (chunks → DB) ────────────────────────────────────────────────────
 
 // Import reads the manifest and imports any chunks not yet in the local DB.
 func (sy *Syncer) Import() (*ImportResult, error) {
+	unlock, err := sy.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	manifest, err := sy.readManifest()
 	if err != nil {
 		return nil, err
@@ -262,19 +334,16 @@ func (sy *Syncer) Import() (*ImportResult, error) {
 			continue
 		}
 
-		// Read the chunk via transport
-		chunkJSON, err := sy.transport.ReadChunk(entry.ID)
+		chunk, err := sy.readChunk(entry)
 		if err != nil {
+			return nil, err
+		}
+		if chunk == nil {
 			// Chunk file missing — skip (maybe deleted or not yet pulled)
 			result.ChunksSkipped++
 			continue
 		}
 
-		var chunk ChunkData
-		if err := json.Unmarshal(chunkJSON, &chunk); err != nil {
-			return nil, fmt.Errorf("parse chunk %s: %w", entry.ID, err)
-		}
-
 		// Import into DB
 		exportData := &store.ExportData{
 			Version:      "0.1.0",
@@ -298,11 +367,182 @@ func (sy *Syncer) Import() (*ImportResult, error) {
 		result.SessionsImported += importResult.SessionsImported
 		result.ObservationsImported += importResult.ObservationsImported
 		result.PromptsImported += importResult.PromptsImported
+		result.PlaceholderSessions += importResult.PlaceholderSessions
 	}
 
 	return result, nil
 }
 
+// readChunk reads and verifies the chunk listed by entry. A chunk file that
+// cannot be read returns nil and no error, so callers skip it; a wrong
+// passphrase or a chunk failing verification is an error.
+func (sy *Syncer) readChunk(entry ChunkEntry) (*ChunkData, error) {
+	chunkJSON, err := sy.transport.ReadChunk(entry.ID)
+	if errors.Is(err, ErrWrongPassphrase) {
+		return nil, fmt.Errorf("read chunk %s: %w", entry.ID, err)
+	}
+	if err != nil {
+		return nil, nil
+	}
+
+	chunk, checksum, err := decodeChunk(chunkJSON)
+	if errors.Is(err, ErrChunkCorrupt) {
+		return nil, fmt.Errorf("verify chunk %s: %w", entry.ID, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse chunk %s: %w", entry.ID, err)
+	}
+	if entry.Checksum != "" && checksum != entry.Checksum {
+		return nil, fmt.Errorf("verify chunk %s: %w: checksum does not match the manifest", entry.ID, ErrChunkCorrupt)
+	}
+	return chunk, nil
+}
+
+// ─── Import preview ──────────────────────────────────────────────────────────
+
+// ImportPreview describes what Import would bring in, for
+// `engram sync --import --dry-run`.
+type ImportPreview struct {
+	Chunks        []ChunkPreview  `json:"chunks"`
+	ChunksSkipped int             `json:"chunks_skipped"` // Already imported, or missing
+	Projects      []string        `json:"projects"`       // Projects the pending chunks touch, sorted
+	NewTopics     []TopicRef      `json:"new_topics"`     // Topic keys not yet used locally
+	Conflicts     []TopicConflict `json:"conflicts"`      // Incoming revisions of local topics
+}
+
+// ChunkPreview is one chunk Import would read.
+type ChunkPreview struct {
+	ID           string   `json:"id"`
+	CreatedBy    string   `json:"created_by"`
+	CreatedAt    string   `json:"created_at"`
+	Sessions     int      `json:"sessions"`
+	Observations int      `json:"observations"`
+	Prompts      int      `json:"prompts"`
+	Existing     int      `json:"existing"` // Observations already present locally by sync ID, skipped on import
+	Projects     []string `json:"projects"`
+}
+
+// TopicRef names a topic: a topic_key within a project and scope.
+type TopicRef struct {
+	TopicKey string `json:"topic_key"`
+	Project  string `json:"project"`
+	Scope    string `json:"scope"`
+}
+
+// TopicConflict is an incoming observation whose topic key is already used
+// locally by a different observation. Import keeps both, so the topic ends
+// up with two parallel latest revisions.
+type TopicConflict struct {
+	TopicRef
+	ChunkID    string `json:"chunk_id"`
+	Title      string `json:"title"`
+	CreatedBy  string `json:"created_by"`
+	LocalID    int64  `json:"local_id"`
+	LocalTitle string `json:"local_title"`
+}
+
+// PreviewImport reads the chunks Import would import and reports what they
+// hold, without writing anything. Chunks are verified exactly as Import
+// verifies them, so a preview fails where the import would.
+func (sy *Syncer) PreviewImport() (*ImportPreview, error) {
+	manifest, err := sy.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	known, err := storeGetSynced(sy.store)
+	if err != nil {
+		return nil, fmt.Errorf("get synced chunks: %w", err)
+	}
+	topics, err := sy.store.Topics("", "")
+	if err != nil {
+		return nil, err
+	}
+	local := make(map[TopicRef]store.TopicSummary, len(topics))
+	for _, t := range topics {
+		local[TopicRef{TopicKey: t.TopicKey, Project: deref(t.Project), Scope: t.Scope}] = t
+	}
+
+	preview := &ImportPreview{Chunks: []ChunkPreview{}, Projects: []string{}, NewTopics: []TopicRef{}, Conflicts: []TopicConflict{}}
+	projects := map[string]bool{}
+	seenTopics := map[TopicRef]bool{}
+	for _, entry := range manifest.Chunks {
+		if known[entry.ID] {
+			preview.ChunksSkipped++
+			continue
+		}
+		chunk, err := sy.readChunk(entry)
+		if err != nil {
+			return nil, err
+		}
+		if chunk == nil {
+			preview.ChunksSkipped++
+			continue
+		}
+
+		cp := ChunkPreview{
+			ID:           entry.ID,
+			CreatedBy:    entry.CreatedBy,
+			CreatedAt:    entry.CreatedAt,
+			Sessions:     len(chunk.Sessions),
+			Observations: len(chunk.Observations),
+			Prompts:      len(chunk.Prompts),
+			Projects:     []string{},
+		}
+		chunkProjects := map[string]bool{}
+		for _, sess := range chunk.Sessions {
+			chunkProjects[sess.Project] = true
+		}
+		for _, obs := range chunk.Observations {
+			project := deref(obs.Project)
+			chunkProjects[project] = true
+			if obs.SyncID != "" {
+				if existing, err := sy.store.GetObservationBySyncID(obs.SyncID); err == nil && existing != nil {
+					cp.Existing++
+					continue
+				}
+			}
+			if obs.DeletedAt != nil || deref(obs.TopicKey) == "" {
+				continue
+			}
+			ref := TopicRef{TopicKey: deref(obs.TopicKey), Project: project, Scope: obs.Scope}
+			if ref.Scope == "" {
+				ref.Scope = "project"
+			}
+			t, ok := local[ref]
+			if !ok {
+				if !seenTopics[ref] {
+					seenTopics[ref] = true
+					preview.NewTopics = append(preview.NewTopics, ref)
+				}
+				continue
+			}
+			preview.Conflicts = append(preview.Conflicts, TopicConflict{
+				TopicRef:   ref,
+				ChunkID:    entry.ID,
+				Title:      obs.Title,
+				CreatedBy:  entry.CreatedBy,
+				LocalID:    t.LatestID,
+				LocalTitle: t.LatestTitle,
+			})
+		}
+		for _, prompt := range chunk.Prompts {
+			chunkProjects[prompt.Project] = true
+		}
+		delete(chunkProjects, "")
+		for project := range chunkProjects {
+			cp.Projects = append(cp.Projects, project)
+			projects[project] = true
+		}
+		sort.Strings(cp.Projects)
+		preview.Chunks = append(preview.Chunks, cp)
+	}
+	for project := range projects {
+		preview.Projects = append(preview.Projects, project)
+	}
+	sort.Strings(preview.Projects)
+	return preview, nil
+}
+
 // Status returns information about what would be synced.
 func (sy *Syncer) Status() (localChunks int, remoteChunks int, pendingImport int, err error) {
 	manifest, err := sy.readManifest()
@@ -327,6 +567,123 @@ func (sy *Syncer) Status() (localChunks int, remoteChunks int, pendingImport int
 	return localChunks, remoteChunks, pendingImport, nil
 }
 
+// ─── Discovery ───────────────────────────────────────────────────────────────
+
+// skippedRepoDirs are never searched for repos: they hold dependencies,
+// not checkouts of their own.
+var skippedRepoDirs = map[string]bool{"node_modules": true, "vendor": true}
+
+// FindRepos returns the directories under root (root included) that hold a
+// .engram/manifest.json, sorted by path, for `engram sync --recursive`.
+// Hidden directories and dependency trees are not searched, and unreadable
+// directories are skipped. Repos nested inside other repos are found too.
+func FindRepos(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	var repos []string
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && (strings.HasPrefix(d.Name(), ".") || skippedRepoDirs[d.Name()]) {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, ".engram", "manifest.json")); err == nil {
+			repos = append(repos, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
+
+// ─── Prune (garbage-collect chunks) ──────────────────────────────────────────
+
+// Prune removes chunks created more than olderThan ago that are recorded as
+// imported in the local DB. Chunks this machine has not imported are kept,
+// so pruning never drops data that only exists in the sync directory. With
+// dryRun, nothing is deleted and the manifest is left as is.
+func (sy *Syncer) Prune(olderThan time.Duration, dryRun bool) (*PruneResult, error) {
+	deleter, ok := sy.transport.(ChunkDeleter)
+	if !ok {
+		return nil, fmt.Errorf("prune: transport does not support deleting chunks")
+	}
+	if !dryRun {
+		unlock, err := sy.lock()
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	manifest, err := sy.readManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := storeGetSynced(sy.store)
+	if err != nil {
+		return nil, fmt.Errorf("get synced chunks: %w", err)
+	}
+
+	cutoff := normalizeTime(timeNow().Add(-olderThan).UTC().Format(time.RFC3339))
+	result := &PruneResult{DryRun: dryRun}
+	retained := make([]ChunkEntry, 0, len(manifest.Chunks))
+	prunedBefore := manifest.PrunedBefore
+
+	for _, entry := range manifest.Chunks {
+		if normalizeTime(entry.CreatedAt) >= cutoff {
+			retained = append(retained, entry)
+			continue
+		}
+		if !known[entry.ID] {
+			result.NotImported++
+			retained = append(retained, entry)
+			continue
+		}
+		result.Pruned = append(result.Pruned, entry.ID)
+		if entry.CreatedAt > prunedBefore {
+			prunedBefore = entry.CreatedAt
+		}
+	}
+	result.Retained = len(retained)
+
+	if dryRun || len(result.Pruned) == 0 {
+		return result, nil
+	}
+
+	// Write the manifest first: a chunk file left behind by a failed delete
+	// is harmless, a manifest entry pointing at a deleted file is not.
+	manifest.Chunks = retained
+	manifest.PrunedBefore = prunedBefore
+	manifest.PrunedChunks += len(result.Pruned)
+	if err := sy.writeManifest(manifest); err != nil {
+		return nil, fmt.Errorf("write manifest: %w", err)
+	}
+	for _, id := range result.Pruned {
+		if err := deleter.DeleteChunk(id); err != nil {
+			return nil, fmt.Errorf("delete chunk %s: %w", id, err)
+		}
+	}
+
+	return result, nil
+}
+
 // ─── Manifest I/O ────────────────────────────────────────────────────────────
 
 func (sy *Syncer) readManifest() (*Manifest, error) {
@@ -338,12 +695,10 @@ func (sy *Syncer) writeManifest(m *Manifest) error {
 }
 
 func (sy *Syncer) lastChunkTime(m *Manifest) string {
-	if len(m.Chunks) == 0 {
-		return ""
-	}
+	// Pruned chunks still count: their data was exported.
+	latest := m.PrunedBefore
 	// Find the most recent chunk
-	latest := m.Chunks[0].CreatedAt
-	for _, c := range m.Chunks[1:] {
+	for _, c := range m.Chunks {
 		if c.CreatedAt > latest {
 			latest = c.CreatedAt
 		}
@@ -367,8 +722,9 @@ func (sy *Syncer) filterNewData(data *store.ExportData, lastChunkTime string) *C
 	}
 
 	// Parse the last chunk time for comparison.
-	// Normalize: DB times are "2006-01-02 15:04:05", manifest times are RFC3339.
-	// We compare as strings since both sort lexicographically.
+	// Normalize: DB times are RFC3339 UTC (naive "2006-01-02 15:04:05" in
+	// exports from older versions), manifest times are RFC3339. Both are
+	// brought to one layout and compared as strings.
 	cutoff := normalizeTime(lastChunkTime)
 
 	for _, s := range data.Sessions {
@@ -441,6 +797,14 @@ func filterByProject(data *store.ExportData, project string) *store.ExportData {
 	return result
 }
 
+// deref returns the value of p, or "" when nil.
+func deref(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
 // normalizeTime converts various time formats to a comparable string.
 func normalizeTime(t string) string {
 	// Try RFC3339 first
@@ -1,13 +1,13 @@
 package sync
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -264,12 +264,11 @@ func TestExportErrors(t *testing.T) {
 			t.Fatalf("store export: %v", err)
 		}
 		chunk := sy.filterNewData(data, "")
-		chunkJSON, err := json.Marshal(chunk)
+		_, checksum, err := encodeChunk(chunk)
 		if err != nil {
-			t.Fatalf("marshal chunk: %v", err)
+			t.Fatalf("encode chunk: %v", err)
 		}
-		hash := sha256.Sum256(chunkJSON)
-		chunkID := hex.EncodeToString(hash[:])[:8]
+		chunkID := checksum[:8]
 
 		writeManifestFile(t, sy.syncDir, &Manifest{
 			Version: 1,
@@ -435,10 +434,10 @@ func TestImportBranches(t *testing.T) {
 		}
 	})
 
-	t.Run("store import error", func(t *testing.T) {
+	t.Run("missing session gets a placeholder", func(t *testing.T) {
 		s := newTestStore(t)
 		syncDir := t.TempDir()
-		id := "broken"
+		id := "partial"
 		writeManifestFile(t, syncDir, &Manifest{
 			Version: 1,
 			Chunks:  []ChunkEntry{{ID: id, CreatedBy: "alice", CreatedAt: time.Now().UTC().Format(time.RFC3339)}},
@@ -449,8 +448,8 @@ func TestImportBranches(t *testing.T) {
 				ID:        1,
 				SessionID: "missing-session",
 				Type:      "bugfix",
-				Title:     "broken",
-				Content:   "missing session should violate FK",
+				Title:     "partial",
+				Content:   "the chunk does not carry this session",
 				Scope:     "project",
 				CreatedAt: "2025-01-01 00:00:01",
 				UpdatedAt: "2025-01-01 00:00:01",
@@ -470,7 +469,27 @@ func TestImportBranches(t *testing.T) {
 		}
 
 		sy := New(s, syncDir)
-		if _, err := sy.Import(); err == nil || !strings.Contains(err.Error(), "import chunk") {
+		result, err := sy.Import()
+		if err != nil || result.ObservationsImported != 1 || result.PlaceholderSessions != 1 {
+			t.Fatalf("expected a placeholder session, got %+v err=%v", result, err)
+		}
+		if _, err := s.GetSession("missing-session"); err != nil {
+			t.Fatalf("expected the placeholder session to exist: %v", err)
+		}
+	})
+
+	t.Run("store import error", func(t *testing.T) {
+		resetSyncTestHooks(t)
+		s := newTestStore(t)
+		syncDir := t.TempDir()
+		seedStoreForSync(t, s)
+		if _, err := New(s, syncDir).Export("alice", ""); err != nil {
+			t.Fatalf("export: %v", err)
+		}
+		storeImportData = func(*store.Store, *store.ExportData) (*store.ImportResult, error) {
+			return nil, errors.New("forced import failure")
+		}
+		if _, err := New(newTestStore(t), syncDir).Import(); err == nil || !strings.Contains(err.Error(), "import chunk") {
 			t.Fatalf("expected import chunk error, got %v", err)
 		}
 	})
@@ -525,6 +544,66 @@ func TestImportBranches(t *testing.T) {
 	})
 }
 
+func TestPreviewImportReportsChunksTopicsAndConflicts(t *testing.T) {
+	src := newTestStore(t)
+	seedStoreForSync(t, src)
+	for _, p := range []store.AddObservationParams{
+		{SessionID: "s-proj", Type: "architecture", Title: "Auth via sessions", Content: "teammate's auth model", Project: "proj-a", Scope: "project", TopicKey: "architecture/auth"},
+		{SessionID: "s-proj", Type: "decision", Title: "Queue on Redis", Content: "redis streams", Project: "proj-a", Scope: "project", TopicKey: "decision/queue"},
+	} {
+		if _, err := src.AddObservation(p); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+	syncDir := t.TempDir()
+	exported, err := New(src, syncDir).Export("alice", "")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	dst := newTestStore(t)
+	if err := dst.CreateSession("s-local", "proj-a", "/tmp/proj-a"); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	localID, err := dst.AddObservation(store.AddObservationParams{
+		SessionID: "s-local", Type: "architecture", Title: "Auth via JWT", Content: "my auth model", Project: "proj-a", Scope: "project", TopicKey: "architecture/auth",
+	})
+	if err != nil {
+		t.Fatalf("add local observation: %v", err)
+	}
+
+	sy := New(dst, syncDir)
+	preview, err := sy.PreviewImport()
+	if err != nil {
+		t.Fatalf("PreviewImport: %v", err)
+	}
+	if len(preview.Chunks) != 1 || preview.Chunks[0].ID != exported.ChunkID || preview.Chunks[0].CreatedBy != "alice" ||
+		preview.Chunks[0].Observations != 4 || preview.Chunks[0].Prompts != 2 || preview.Chunks[0].Existing != 0 {
+		t.Fatalf("unexpected chunk preview: %+v", preview.Chunks)
+	}
+	if !slices.Equal(preview.Projects, []string{"proj-a", "proj-b"}) {
+		t.Fatalf("expected both projects affected, got %v", preview.Projects)
+	}
+	if len(preview.NewTopics) != 1 || preview.NewTopics[0] != (TopicRef{TopicKey: "decision/queue", Project: "proj-a", Scope: "project"}) {
+		t.Fatalf("expected decision/queue as the only new topic, got %+v", preview.NewTopics)
+	}
+	if len(preview.Conflicts) != 1 || preview.Conflicts[0].TopicKey != "architecture/auth" || preview.Conflicts[0].LocalID != localID ||
+		preview.Conflicts[0].Title != "Auth via sessions" || preview.Conflicts[0].LocalTitle != "Auth via JWT" {
+		t.Fatalf("expected the auth topic to conflict with the local one, got %+v", preview.Conflicts)
+	}
+
+	// Nothing was written.
+	if _, _, pending, err := sy.Status(); err != nil || pending != 1 {
+		t.Fatalf("expected the chunk still pending after a preview, got %d err=%v", pending, err)
+	}
+	if _, err := sy.Import(); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if preview, err := sy.PreviewImport(); err != nil || len(preview.Chunks) != 0 || preview.ChunksSkipped != 1 {
+		t.Fatalf("expected nothing left to preview, got %+v err=%v", preview, err)
+	}
+}
+
 func TestManifestReadWrite(t *testing.T) {
 	syncDir := t.TempDir()
 	sy := New(nil, syncDir)
@@ -632,6 +711,134 @@ func TestStatus(t *testing.T) {
 	}
 }
 
+func TestPendingExportCountsWithoutWriting(t *testing.T) {
+	s := newTestStore(t)
+	seedStoreForSync(t, s)
+	syncDir := t.TempDir()
+	sy := New(s, syncDir)
+
+	sessions, observations, prompts, err := sy.PendingExport("proj-a")
+	if err != nil || sessions != 1 || observations != 1 || prompts != 1 {
+		t.Fatalf("expected proj-a's session, observation, and prompt pending, got %d/%d/%d err=%v", sessions, observations, prompts, err)
+	}
+	if _, err := os.Stat(filepath.Join(syncDir, "manifest.json")); !os.IsNotExist(err) {
+		t.Fatalf("PendingExport must not write the manifest, stat err=%v", err)
+	}
+
+	if _, err := sy.Export("alice", "proj-a"); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if sessions, observations, prompts, err = sy.PendingExport("proj-a"); err != nil || sessions+observations+prompts != 0 {
+		t.Fatalf("expected nothing pending after export, got %d/%d/%d err=%v", sessions, observations, prompts, err)
+	}
+}
+
+func TestFindReposSkipsHiddenAndDependencyDirs(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"api", "web", "web/packages/ui", "node_modules/lib", ".cache/repo", "docs"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", dir, err)
+		}
+	}
+	for _, dir := range []string{"api", "web", "web/packages/ui", "node_modules/lib", ".cache/repo"} {
+		writeManifestFile(t, filepath.Join(root, dir, ".engram"), &Manifest{Version: 1})
+	}
+	// A data dir with a database but no manifest is not a repo.
+	if err := os.MkdirAll(filepath.Join(root, "docs", ".engram"), 0o755); err != nil {
+		t.Fatalf("mkdir docs/.engram: %v", err)
+	}
+
+	repos, err := FindRepos(root)
+	if err != nil {
+		t.Fatalf("FindRepos: %v", err)
+	}
+	want := []string{filepath.Join(root, "api"), filepath.Join(root, "web"), filepath.Join(root, "web/packages/ui")}
+	if !reflect.DeepEqual(repos, want) {
+		t.Fatalf("repos = %v, want %v", repos, want)
+	}
+	if _, err := FindRepos(filepath.Join(root, "missing")); err == nil {
+		t.Fatal("expected a missing root to fail")
+	}
+}
+
+func TestPruneRemovesOldImportedChunks(t *testing.T) {
+	s := newTestStore(t)
+	syncDir := t.TempDir()
+	sy := New(s, syncDir)
+
+	oldNow := timeNow
+	timeNow = func() time.Time { return time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = oldNow })
+
+	chunks := []ChunkEntry{
+		{ID: "old1", CreatedAt: "2025-01-01T00:00:00Z"},
+		{ID: "old2", CreatedAt: "2025-01-10T00:00:00Z"},
+		{ID: "oldx", CreatedAt: "2025-01-05T00:00:00Z"}, // never imported here
+		{ID: "new1", CreatedAt: "2025-02-25T00:00:00Z"},
+	}
+	for _, c := range chunks {
+		if err := sy.transport.WriteChunk(c.ID, []byte(`{}`), c); err != nil {
+			t.Fatalf("write chunk: %v", err)
+		}
+		if c.ID != "oldx" {
+			if err := s.RecordSyncedChunk(c.ID); err != nil {
+				t.Fatalf("record synced chunk: %v", err)
+			}
+		}
+	}
+	if err := sy.writeManifest(&Manifest{Version: 1, Chunks: chunks}); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+
+	dry, err := sy.Prune(30*24*time.Hour, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(dry.Pruned) != 2 || dry.NotImported != 1 || dry.Retained != 2 {
+		t.Fatalf("unexpected dry run: %+v", dry)
+	}
+	if _, err := os.Stat(filepath.Join(syncDir, "chunks", "old1.jsonl.gz")); err != nil {
+		t.Fatalf("dry run must not delete files: %v", err)
+	}
+
+	result, err := sy.Prune(30*24*time.Hour, false)
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if strings.Join(result.Pruned, ",") != "old1,old2" {
+		t.Fatalf("unexpected pruned chunks: %+v", result)
+	}
+	for id, want := range map[string]bool{"old1": false, "old2": false, "oldx": true, "new1": true} {
+		_, err := os.Stat(filepath.Join(syncDir, "chunks", id+".jsonl.gz"))
+		if (err == nil) != want {
+			t.Fatalf("chunk %s present=%v, want %v", id, err == nil, want)
+		}
+	}
+
+	m, err := sy.readManifest()
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	if len(m.Chunks) != 2 || m.PrunedBefore != "2025-01-10T00:00:00Z" || m.PrunedChunks != 2 {
+		t.Fatalf("unexpected manifest after prune: %+v", m)
+	}
+
+	// A teammate who imported new1 only sees oldx as pending.
+	teammate := newTestStore(t)
+	if err := teammate.RecordSyncedChunk("new1"); err != nil {
+		t.Fatalf("record synced chunk: %v", err)
+	}
+	if _, remote, pending, err := New(teammate, syncDir).Status(); err != nil || remote != 2 || pending != 1 {
+		t.Fatalf("teammate status: remote=%d pending=%d err=%v", remote, pending, err)
+	}
+
+	// Pruned history still counts as exported.
+	m.Chunks = nil
+	if got := sy.lastChunkTime(m); got != "2025-01-10T00:00:00Z" {
+		t.Fatalf("lastChunkTime = %q", got)
+	}
+}
+
 func TestFilterFunctionsAndTimeNormalization(t *testing.T) {
 	data := &store.ExportData{
 		Version:    "0.1.0",
@@ -879,3 +1086,59 @@ func TestGetUsernameAndManifestSummary(t *testing.T) {
 		}
 	})
 }
+
+func TestSyncLocksSerializeConcurrentRuns(t *testing.T) {
+	s := newTestStore(t)
+	seedStoreForSync(t, s)
+	syncDir := filepath.Join(t.TempDir(), ".engram")
+	if err := os.MkdirAll(syncDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	lockPath := filepath.Join(syncDir, lockFileName)
+	sy := New(s, syncDir)
+
+	held := lockInfo{PID: 4242, Host: "laptop", StartedAt: time.Now().UTC().Format(time.RFC3339)}
+	data, _ := json.Marshal(held)
+	if err := os.WriteFile(lockPath, data, 0o644); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+	_, err := sy.Export("alice", "")
+	if !errors.Is(err, ErrSyncInProgress) || !strings.Contains(err.Error(), "laptop pid 4242") {
+		t.Fatalf("expected a held file lock to stop export, got %v", err)
+	}
+	if _, err := sy.Import(); !errors.Is(err, ErrSyncInProgress) {
+		t.Fatalf("expected a held file lock to stop import, got %v", err)
+	}
+
+	// A lock left by a crashed run is taken over and removed afterwards.
+	held.StartedAt = time.Now().Add(-2 * lockTTL).UTC().Format(time.RFC3339)
+	data, _ = json.Marshal(held)
+	if err := os.WriteFile(lockPath, data, 0o644); err != nil {
+		t.Fatalf("write stale lock: %v", err)
+	}
+	if result, err := sy.Export("alice", ""); err != nil || result.IsEmpty {
+		t.Fatalf("expected a stale lock to be taken over, got %+v, %v", result, err)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the lock file removed after export, stat err=%v", err)
+	}
+
+	// A run on another sync dir sharing the database waits on the store lock.
+	if err := s.AcquireLock(storeLockName, "other pid 1", lockTTL); err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	other := New(s, filepath.Join(t.TempDir(), ".engram"))
+	_, err = other.Export("bob", "")
+	if !errors.Is(err, ErrSyncInProgress) || !errors.Is(err, store.ErrLockHeld) || !strings.Contains(err.Error(), "other pid 1") {
+		t.Fatalf("expected the store lock to stop export, got %v", err)
+	}
+	if err := s.ReleaseLock(storeLockName, "other pid 1"); err != nil {
+		t.Fatalf("ReleaseLock: %v", err)
+	}
+	if _, err := other.Export("bob", ""); err != nil {
+		t.Fatalf("expected export after release, got %v", err)
+	}
+	if err := s.AcquireLock(storeLockName, "next", lockTTL); err != nil {
+		t.Fatalf("expected export to release the store lock, got %v", err)
+	}
+}
@@ -29,6 +29,12 @@ type Transport interface {
 	ReadChunk(chunkID string) ([]byte, error)
 }
 
+// ChunkDeleter is implemented by transports that can remove chunks, which
+// Syncer.Prune requires.
+type ChunkDeleter interface {
+	DeleteChunk(chunkID string) error
+}
+
 // ─── FileTransport ──────────────────────────────────────────────────────────
 
 // FileTransport reads/writes chunks to the local filesystem.
@@ -89,3 +95,13 @@ func (ft *FileTransport) ReadChunk(chunkID string) ([]byte, error) {
 	chunkPath := filepath.Join(chunksDir, chunkID+".jsonl.gz")
 	return readGzip(chunkPath)
 }
+
+// DeleteChunk removes a chunk file. A chunk that is already gone is not an
+// error.
+func (ft *FileTransport) DeleteChunk(chunkID string) error {
+	chunkPath := filepath.Join(ft.syncDir, "chunks", chunkID+".jsonl.gz")
+	if err := os.Remove(chunkPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
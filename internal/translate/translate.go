@@ -0,0 +1,308 @@
+// Package translate renders stored memories in the reader's language on
+// retrieval and expands search queries across languages.
+//
+// Teams often save memories in a mix of languages; a search in English
+// misses the notes written in Spanish and vice versa. Nothing is rewritten
+// in the store: translations happen when an observation or the context
+// block is read, and each one is cached in the translations table so the
+// model is asked once per text and language.
+//
+// Translation is off unless an endpoint is configured. Like enrichment it
+// talks to any OpenAI-compatible chat completions endpoint; the text is sent
+// there, so point it at a local model if memories must not leave the
+// machine. A failing endpoint never fails a read: the original text is
+// returned instead.
+package translate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/llm"
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// DefaultTimeout bounds a single request.
+const DefaultTimeout = 20 * time.Second
+
+// Options configures the client and the service.
+type Options struct {
+	// Endpoint is the chat completions URL, e.g.
+	// http://localhost:11434/v1/chat/completions.
+	Endpoint string
+	Model    string
+	// APIKey is sent as a bearer token when set.
+	APIKey string
+	// Target is the language memories are shown in when the caller does not
+	// ask for one ("" leaves them as stored).
+	Target string
+	// Languages are the languages a search query is expanded into, e.g.
+	// ["en", "es"]. Fewer than two disables query expansion.
+	Languages []string
+	// Timeout bounds each request; <= 0 means DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Enabled reports whether an endpoint is configured.
+func (o Options) Enabled() bool { return o.Endpoint != "" }
+
+// Translator translates text into a language given as an ISO 639-1 code
+// ("en", "es"). Text already in that language comes back unchanged.
+type Translator interface {
+	Translate(ctx context.Context, text, lang string) (string, error)
+}
+
+// ─── Client ──────────────────────────────────────────────────────────────────
+
+// Client is a Translator backed by an OpenAI-compatible endpoint.
+type Client struct {
+	chat *llm.Client
+}
+
+// NewClient returns a client for opts.Endpoint.
+func NewClient(opts Options) (*Client, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	chat, err := llm.NewClient(llm.Options{Endpoint: opts.Endpoint, Model: opts.Model, APIKey: opts.APIKey, Timeout: opts.Timeout})
+	if err != nil {
+		return nil, fmt.Errorf("translate: %w", err)
+	}
+	return &Client{chat: chat}, nil
+}
+
+const systemPrompt = `You translate entries of a developer's long-term memory.
+Translate the user's text into the language with ISO 639-1 code %q.
+Keep code, identifiers, file paths, commands, URLs, and Markdown formatting
+exactly as they are. If the text is already in that language, return it
+unchanged. Reply with the translation only, without quotes or commentary.`
+
+// Translate asks the model for a translation of text into lang.
+func (c *Client) Translate(ctx context.Context, text, lang string) (string, error) {
+	reply, err := c.chat.Complete(ctx, fmt.Sprintf(systemPrompt, lang), text)
+	if err != nil {
+		return "", fmt.Errorf("translate: %w", err)
+	}
+	translated := strings.TrimSpace(reply)
+	if translated == "" {
+		return "", errors.New("translate: empty reply")
+	}
+	return translated, nil
+}
+
+// ─── Service ─────────────────────────────────────────────────────────────────
+
+// Service wraps a Translator with the store-backed cache and the retrieval
+// helpers the MCP server uses.
+type Service struct {
+	store      *store.Store
+	translator Translator
+	opts       Options
+	readOnly   bool // see ReadOnly
+}
+
+// New returns a Service caching translations from t in s. opts.Model is
+// recorded with every cached translation.
+func New(s *store.Store, t Translator, opts Options) *Service {
+	langs := make([]string, 0, len(opts.Languages))
+	for _, lang := range opts.Languages {
+		if lang = normalizeLang(lang); lang != "" && !slices.Contains(langs, lang) {
+			langs = append(langs, lang)
+		}
+	}
+	opts.Languages = langs
+	opts.Target = normalizeLang(opts.Target)
+	return &Service{store: s, translator: t, opts: opts}
+}
+
+// ReadOnly returns a copy of svc that reads the cache but never writes to
+// it, for read-only MCP servers. Its translations are not kept, so each one
+// costs a model call until a writable service caches it.
+func (svc *Service) ReadOnly() *Service {
+	ro := *svc
+	ro.readOnly = true
+	return &ro
+}
+
+// Target is the default language to show memories in ("" for none).
+func (svc *Service) Target() string { return svc.opts.Target }
+
+// Lang returns the language to translate into: requested when given,
+// otherwise the configured target. "original" (or "none") turns
+// translation off for one call.
+func (svc *Service) Lang(requested string) string {
+	switch lang := normalizeLang(requested); lang {
+	case "":
+		return svc.opts.Target
+	case "original", "none":
+		return ""
+	default:
+		return lang
+	}
+}
+
+// Translate returns text in lang, from the cache when possible. On error
+// the original text is returned with it, so callers can fall back.
+func (svc *Service) Translate(ctx context.Context, text, lang string) (string, error) {
+	lang = normalizeLang(lang)
+	if strings.TrimSpace(text) == "" || lang == "" {
+		return text, nil
+	}
+	if cached, ok, err := svc.store.CachedTranslation(text, lang); err == nil && ok {
+		return cached, nil
+	}
+	translated, err := svc.translator.Translate(ctx, text, lang)
+	if err != nil {
+		return text, err
+	}
+	if svc.readOnly {
+		return translated, nil
+	}
+	if err := svc.store.SaveTranslation(text, lang, translated, svc.opts.Model); err != nil {
+		return translated, err
+	}
+	return translated, nil
+}
+
+// Func adapts the service to store.ContextOptions.Translate for one
+// language. Failures leave the text as stored, and after the first one the
+// function stops calling the endpoint so a dead model cannot stall a whole
+// context block on timeouts.
+func (svc *Service) Func(ctx context.Context, lang string) func(string) string {
+	failed := false
+	return func(text string) string {
+		if failed {
+			if cached, ok, _ := svc.store.CachedTranslation(text, lang); ok {
+				return cached
+			}
+			return text
+		}
+		translated, err := svc.Translate(ctx, text, lang)
+		failed = err != nil
+		return translated
+	}
+}
+
+// Observation returns a copy of obs with title and content in lang. On
+// error the untranslated fields are kept and the error is returned.
+func (svc *Service) Observation(ctx context.Context, obs store.Observation, lang string) (store.Observation, error) {
+	title, err := svc.Translate(ctx, obs.Title, lang)
+	if err != nil {
+		return obs, err
+	}
+	content, err := svc.Translate(ctx, obs.Content, lang)
+	if err != nil {
+		return obs, err
+	}
+	obs.Title, obs.Content = title, content
+	return obs, nil
+}
+
+// ExpandQuery returns query followed by its translations into the
+// configured languages, without duplicates. Languages that fail to
+// translate are skipped.
+func (svc *Service) ExpandQuery(ctx context.Context, query string) []string {
+	queries := []string{query}
+	if len(svc.opts.Languages) < 2 || strings.TrimSpace(query) == "" {
+		return queries
+	}
+	for _, lang := range svc.opts.Languages {
+		translated, err := svc.Translate(ctx, query, lang)
+		if err != nil {
+			continue
+		}
+		if !slices.ContainsFunc(queries, func(q string) bool { return strings.EqualFold(q, translated) }) {
+			queries = append(queries, translated)
+		}
+	}
+	return queries
+}
+
+// Search runs s.Search for query and each of its translations and merges
+// the results, keeping the best rank of an observation found more than
+// once.
+func (svc *Service) Search(ctx context.Context, query string, opts store.SearchOptions) ([]store.SearchResult, error) {
+	page, err := svc.SearchPage(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
+
+// SearchPage is Search with paging: opts.Offset indexes into the merged
+// results, not into any single query's.
+func (svc *Service) SearchPage(ctx context.Context, query string, opts store.SearchOptions) (*store.SearchPage, error) {
+	queries := svc.ExpandQuery(ctx, query)
+	if len(queries) == 1 {
+		return svc.store.SearchPage(query, opts)
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	offset := max(opts.Offset, 0)
+	// Every query's first offset+limit+1 results are enough to fill this
+	// page of the merge and tell whether another one exists.
+	want := offset + limit + 1
+
+	var merged []store.SearchResult
+	seen := map[int64]int{}
+	for _, q := range queries {
+		results, err := svc.collect(q, opts, want)
+		if err != nil {
+			// A translation can trip the FTS parser where the original did
+			// not; only the original query's errors matter.
+			if q == query {
+				return nil, err
+			}
+			continue
+		}
+		for _, r := range results {
+			if i, ok := seen[r.ID]; ok {
+				merged[i].Rank = min(merged[i].Rank, r.Rank)
+				continue
+			}
+			seen[r.ID] = len(merged)
+			merged = append(merged, r)
+		}
+	}
+	slices.SortStableFunc(merged, func(a, b store.SearchResult) int { return cmp.Compare(a.Rank, b.Rank) })
+
+	page := &store.SearchPage{Offset: offset}
+	merged = merged[min(offset, len(merged)):]
+	if len(merged) > limit {
+		merged = merged[:limit]
+		page.HasMore = true
+	}
+	page.Results = merged
+	return page, nil
+}
+
+// collect pages through s.SearchPage until it has want results for q or
+// runs out.
+func (svc *Service) collect(q string, opts store.SearchOptions, want int) ([]store.SearchResult, error) {
+	var results []store.SearchResult
+	opts.Offset = 0
+	for len(results) < want {
+		page, err := svc.store.SearchPage(q, opts)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, page.Results...)
+		if !page.HasMore || len(page.Results) == 0 {
+			break
+		}
+		opts.Offset += len(page.Results)
+	}
+	return results, nil
+}
+
+func normalizeLang(lang string) string {
+	return strings.ToLower(strings.TrimSpace(lang))
+}
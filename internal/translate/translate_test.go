@@ -0,0 +1,136 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Gentleman-Programming/engram/internal/llm"
+	"github.com/Gentleman-Programming/engram/internal/store"
+	"github.com/Gentleman-Programming/engram/internal/store/storetest"
+)
+
+// dictionary translates whole strings it knows and counts model calls.
+type dictionary struct {
+	calls   int
+	entries map[string]string
+	err     error
+}
+
+func (d *dictionary) Translate(_ context.Context, text, lang string) (string, error) {
+	d.calls++
+	if d.err != nil {
+		return "", d.err
+	}
+	if translated, ok := d.entries[lang+":"+text]; ok {
+		return translated, nil
+	}
+	return text, nil
+}
+
+func TestClientTranslate(t *testing.T) {
+	var gotReq llm.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&gotReq)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"role": "assistant", "content": "  Usamos JWT  \n"}}},
+		})
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Options{Endpoint: srv.URL, Model: "test-model"})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	got, err := client.Translate(context.Background(), "We use JWT", "es")
+	if err != nil || got != "Usamos JWT" {
+		t.Fatalf("translate = %q err=%v", got, err)
+	}
+	if gotReq.Model != "test-model" || !strings.Contains(gotReq.Messages[0].Content, `"es"`) || gotReq.Messages[1].Content != "We use JWT" {
+		t.Fatalf("unexpected request: %+v", gotReq)
+	}
+
+	if _, err := NewClient(Options{Endpoint: srv.URL}); err == nil {
+		t.Fatalf("expected an error without a model")
+	}
+}
+
+func TestServiceCachesTranslations(t *testing.T) {
+	s := storetest.NewWithSession(t)
+	dict := &dictionary{entries: map[string]string{"en:Usamos JWT": "We use JWT"}}
+	svc := New(s, dict, Options{Model: "m", Target: " EN "})
+
+	if svc.Lang("") != "en" || svc.Lang("es") != "es" || svc.Lang("original") != "" {
+		t.Fatalf("unexpected language resolution")
+	}
+	for range 2 {
+		got, err := svc.Translate(context.Background(), "Usamos JWT", "en")
+		if err != nil || got != "We use JWT" {
+			t.Fatalf("translate = %q err=%v", got, err)
+		}
+	}
+	if dict.calls != 1 {
+		t.Fatalf("expected the second read to hit the cache, got %d calls", dict.calls)
+	}
+
+	dict.err = errors.New("connection refused")
+	got, err := svc.Translate(context.Background(), "Otra nota", "en")
+	if err == nil || got != "Otra nota" {
+		t.Fatalf("expected the original text with the error, got %q err=%v", got, err)
+	}
+
+	// After a failure Func stops calling the model but still serves the cache.
+	fn := svc.Func(context.Background(), "en")
+	calls := dict.calls
+	if fn("Tercera nota") != "Tercera nota" || fn("Usamos JWT") != "We use JWT" || dict.calls != calls+1 {
+		t.Fatalf("expected one failed call then cache-only lookups, got %d calls", dict.calls-calls)
+	}
+}
+
+func TestServiceSearchExpandsAcrossLanguages(t *testing.T) {
+	s := storetest.NewWithSession(t)
+	for _, p := range []store.AddObservationParams{
+		{SessionID: "s1", Type: "decision", Title: "Autenticación con JWT", Content: "Elegimos tokens de refresco rotativos", Project: "engram"},
+		{SessionID: "s1", Type: "decision", Title: "Refresh tokens", Content: "Rotating refresh tokens for the gateway", Project: "engram"},
+	} {
+		if _, err := s.AddObservation(p); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+
+	dict := &dictionary{entries: map[string]string{"es:rotating": "rotativos"}}
+	svc := New(s, dict, Options{Languages: []string{"en", "es", "ES"}})
+	if got := svc.ExpandQuery(context.Background(), "rotating"); strings.Join(got, "|") != "rotating|rotativos" {
+		t.Fatalf("ExpandQuery = %v", got)
+	}
+
+	results, err := svc.Search(context.Background(), "rotating", store.SearchOptions{Project: "engram"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected hits in both languages, got %d", len(results))
+	}
+
+	plain, err := New(s, dict, Options{Languages: []string{"en"}}).Search(context.Background(), "rotating", store.SearchOptions{Project: "engram"})
+	if err != nil || len(plain) != 1 {
+		t.Fatalf("expected no expansion with one language, got %d err=%v", len(plain), err)
+	}
+}
+
+func TestServiceObservation(t *testing.T) {
+	s := storetest.NewWithSession(t)
+	dict := &dictionary{entries: map[string]string{
+		"en:Autenticación con JWT":       "Authentication with JWT",
+		"en:Elegimos tokens de refresco": "We chose refresh tokens",
+	}}
+	svc := New(s, dict, Options{})
+	obs, err := svc.Observation(context.Background(), store.Observation{ID: 7, Title: "Autenticación con JWT", Content: "Elegimos tokens de refresco"}, "en")
+	if err != nil || obs.ID != 7 || obs.Title != "Authentication with JWT" || obs.Content != "We chose refresh tokens" {
+		t.Fatalf("unexpected translated observation %+v err=%v", obs, err)
+	}
+}
@@ -10,10 +10,18 @@
 package tui
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
 	"github.com/Gentleman-Programming/engram/internal/setup"
 	"github.com/Gentleman-Programming/engram/internal/store"
 	"github.com/Gentleman-Programming/engram/internal/version"
 
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -34,6 +42,15 @@ const (
 	ScreenSessions
 	ScreenSessionDetail
 	ScreenSetup
+	ScreenQuarantine
+	ScreenActivity
+	ScreenActivityDay
+	ScreenFilter
+	ScreenPalette
+	ScreenTopics
+	ScreenTopicDetail
+	ScreenProfiles
+	ScreenTrash
 )
 
 // ─── Custom Messages ─────────────────────────────────────────────────────────
@@ -78,11 +95,122 @@ type sessionObservationsMsg struct {
 	err          error
 }
 
+type quarantineLoadedMsg struct {
+	items []store.QuarantinedObservation
+	err   error
+}
+
+type quarantineReviewedMsg struct {
+	id       int64
+	approved bool
+	err      error
+}
+
+type trashLoadedMsg struct {
+	items []store.Observation
+	err   error
+}
+
+type trashRestoredMsg struct {
+	id  int64
+	err error
+}
+
+type activityLoadedMsg struct {
+	project string
+	days    []store.ActivityDay
+	err     error
+}
+
+type activityDayMsg struct {
+	day          string
+	observations []store.Observation
+	err          error
+}
+
+type topicsLoadedMsg struct {
+	topics []store.TopicSummary
+	err    error
+}
+
+type topicDetailMsg struct {
+	topic       store.TopicSummary
+	observation *store.Observation
+	history     []store.TopicRevision
+	err         error
+}
+
 type setupInstallMsg struct {
 	result *setup.Result
 	err    error
 }
 
+type clipboardCopiedMsg struct {
+	bytes int
+	via   string // "clipboard" or "osc52"
+	err   error
+}
+
+type editorFinishedMsg struct {
+	observation *store.Observation
+	changed     bool
+	err         error
+}
+
+// ─── List Filter ─────────────────────────────────────────────────────────────
+
+// filterScopes are the scope choices, "" meaning any scope.
+var filterScopes = []string{"", "project", "personal"}
+
+// ListFilter narrows the Recent and Search Results screens. Empty fields
+// match everything.
+type ListFilter struct {
+	Types   []string
+	Project string
+	Scope   string
+}
+
+// Active reports whether any filter is set.
+func (f ListFilter) Active() bool {
+	return len(f.Types) > 0 || f.Project != "" || f.Scope != ""
+}
+
+// HasType reports whether typ is one of the selected types.
+func (f ListFilter) HasType(typ string) bool {
+	return slices.Contains(f.Types, typ)
+}
+
+// ToggleType adds typ to the selection, or removes it if already selected.
+func (f ListFilter) ToggleType(typ string) ListFilter {
+	if i := slices.Index(f.Types, typ); i >= 0 {
+		f.Types = slices.Delete(slices.Clone(f.Types), i, i+1)
+		return f
+	}
+	f.Types = append(slices.Clone(f.Types), typ)
+	slices.Sort(f.Types)
+	return f
+}
+
+// SearchOptions maps the filter onto store options.
+func (f ListFilter) SearchOptions(limit int) store.SearchOptions {
+	return store.SearchOptions{Types: f.Types, Project: f.Project, Scope: f.Scope, Limit: limit}
+}
+
+// String summarizes the active filter for list headers.
+func (f ListFilter) String() string {
+	var parts []string
+	if len(f.Types) > 0 {
+		parts = append(parts, strings.Join(f.Types, ","))
+	}
+	if f.Project != "" {
+		parts = append(parts, "project="+f.Project)
+	}
+	if f.Scope != "" {
+		parts = append(parts, "scope="+f.Scope)
+	}
+	return strings.Join(parts, " • ")
+}
+
 // ─── Model ───────────────────────────────────────────────────────────────────
 
 type Model struct {
@@ -102,6 +230,9 @@ type Model struct {
 	// Error display
 	ErrorMsg string
 
+	// Transient confirmation (e.g. "Copied to clipboard"), cleared on keypress
+	StatusMsg string
+
 	// Dashboard
 	Stats *store.Stats
 
@@ -113,6 +244,12 @@ type Model struct {
 	// Recent observations
 	RecentObservations []store.Observation
 
+	// Filter for Recent and Search Results; it stays set while navigating
+	// until cleared on the filter screen.
+	Filter       ListFilter
+	FilterCursor int
+	FilterReturn Screen // list screen the filter screen applies to
+
 	// Observation detail
 	SelectedObservation *store.Observation
 	DetailScroll        int
@@ -126,6 +263,38 @@ type Model struct {
 	SessionObservations []store.Observation
 	SessionDetailScroll int
 
+	// Quarantine review
+	Quarantine []store.QuarantinedObservation
+
+	// Trash (soft-deleted observations)
+	Trash []store.Observation
+
+	// Activity calendar ("" project = all projects)
+	ActivityProject         string
+	Activity                []store.ActivityDay
+	ActivityCursor          int // index into Activity; -1 selects today on load
+	ActivityDay             string
+	ActivityDayObservations []store.Observation
+
+	// Topic browser: topics sorted by family (see topicFamily), and the
+	// selected topic's current observation and recorded revisions
+	Topics           []store.TopicSummary
+	SelectedTopicIdx int
+	Topic            *store.TopicSummary
+	TopicObservation *store.Observation
+	TopicHistory     []store.TopicRevision
+
+	// Command palette (ctrl+p); PaletteReturn is the screen it opened over
+	PaletteInput  textinput.Model
+	PaletteCursor int
+	PaletteReturn Screen
+
+	// Profiles: the stores the TUI can switch to (see WithProfiles), and
+	// the index of the one open now
+	Profiles       []Profile
+	CurrentProfile int
+	openProfile    ProfileOpener
+
 	// Setup
 	SetupAgents           []setup.Agent
 	SetupResult           *setup.Result
@@ -146,16 +315,23 @@ func New(s *store.Store, version string) Model {
 	ti.CharLimit = 256
 	ti.Width = 60
 
+	pi := textinput.New()
+	pi.Placeholder = "Type a command..."
+	pi.CharLimit = 64
+	pi.Width = 40
+
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
 	sp.Style = lipgloss.NewStyle().Foreground(colorLavender)
 
 	return Model{
-		store:        s,
-		Version:      version,
-		Screen:       ScreenDashboard,
-		SearchInput:  ti,
-		SetupSpinner: sp,
+		store:          s,
+		Version:        version,
+		Screen:         ScreenDashboard,
+		SearchInput:    ti,
+		PaletteInput:   pi,
+		SetupSpinner:   sp,
+		ActivityCursor: -1,
 	}
 }
 
@@ -183,16 +359,16 @@ func loadStats(s *store.Store) tea.Cmd {
 	}
 }
 
-func searchMemories(s *store.Store, query string) tea.Cmd {
+func searchMemories(s *store.Store, query string, filter ListFilter) tea.Cmd {
 	return func() tea.Msg {
-		results, err := s.Search(query, store.SearchOptions{Limit: 50})
+		results, err := s.Search(query, filter.SearchOptions(50))
 		return searchResultsMsg{results: results, query: query, err: err}
 	}
 }
 
-func loadRecentObservations(s *store.Store) tea.Cmd {
+func loadRecentObservations(s *store.Store, filter ListFilter) tea.Cmd {
 	return func() tea.Msg {
-		obs, err := s.AllObservations("", "", 50)
+		obs, err := s.ListObservations(filter.SearchOptions(50))
 		return recentObservationsMsg{observations: obs, err: err}
 	}
 }
@@ -225,6 +401,101 @@ func loadSessionObservations(s *store.Store, sessionID string) tea.Cmd {
 	}
 }
 
+func loadQuarantine(s *store.Store) tea.Cmd {
+	return func() tea.Msg {
+		items, err := s.ListQuarantine("", 200)
+		return quarantineLoadedMsg{items: items, err: err}
+	}
+}
+
+func reviewQuarantine(s *store.Store, id int64, approve bool) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if approve {
+			_, err = s.ApproveQuarantine(id)
+		} else {
+			err = s.RejectQuarantine(id)
+		}
+		return quarantineReviewedMsg{id: id, approved: approve, err: err}
+	}
+}
+
+func loadTrash(s *store.Store) tea.Cmd {
+	return func() tea.Msg {
+		items, err := s.DeletedObservations("", 200)
+		return trashLoadedMsg{items: items, err: err}
+	}
+}
+
+func restoreTrash(s *store.Store, id int64) tea.Cmd {
+	return func() tea.Msg {
+		_, err := s.RestoreObservation(id)
+		return trashRestoredMsg{id: id, err: err}
+	}
+}
+
+func loadActivity(s *store.Store, project string) tea.Cmd {
+	return func() tea.Msg {
+		days, err := s.ActivityHistogram(project, store.DefaultActivityDays)
+		return activityLoadedMsg{project: project, days: days, err: err}
+	}
+}
+
+func loadActivityDay(s *store.Store, project, day string) tea.Cmd {
+	return func() tea.Msg {
+		obs, err := s.DayObservations(project, day, 200)
+		return activityDayMsg{day: day, observations: obs, err: err}
+	}
+}
+
+func loadTopics(s *store.Store) tea.Cmd {
+	return func() tea.Msg {
+		topics, err := s.Topics("", "")
+		sortTopicsByFamily(topics)
+		return topicsLoadedMsg{topics: topics, err: err}
+	}
+}
+
+func loadTopicDetail(s *store.Store, topic store.TopicSummary) tea.Cmd {
+	return func() tea.Msg {
+		obs, err := s.GetObservation(topic.LatestID)
+		if err != nil {
+			return topicDetailMsg{err: err}
+		}
+		project := ""
+		if topic.Project != nil {
+			project = *topic.Project
+		}
+		history, err := s.TopicHistory(topic.TopicKey, project, topic.Scope)
+		return topicDetailMsg{topic: topic, observation: obs, history: history, err: err}
+	}
+}
+
+// topicFamily is the part of a topic key before its first slash
+// ("architecture" for architecture/auth-model), or "" for a key without one.
+func topicFamily(key string) string {
+	family, _, found := strings.Cut(key, "/")
+	if !found {
+		return ""
+	}
+	return family
+}
+
+// sortTopicsByFamily orders topics by family, keys without a family last,
+// so each family is one contiguous run on the topics screen.
+func sortTopicsByFamily(topics []store.TopicSummary) {
+	slices.SortStableFunc(topics, func(a, b store.TopicSummary) int {
+		fa, fb := topicFamily(a.TopicKey), topicFamily(b.TopicKey)
+		if (fa == "") != (fb == "") {
+			if fa == "" {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(fa, fb)
+	})
+}
+
 func installAgent(agentName string) tea.Cmd {
 	return func() tea.Msg {
 		result, err := installAgentFn(agentName)
@@ -234,3 +505,85 @@ func installAgent(agentName string) tea.Cmd {
 
 var installAgentFn = setup.Install
 var addClaudeCodeAllowlistFn = setup.AddClaudeCodeAllowlist
+
+// ─── Clipboard & Editor ──────────────────────────────────────────────────────
+
+// clipboardWriteFn writes to the platform clipboard (pbcopy, xclip, wl-copy,
+// clip.exe). osc52WriteFn is the fallback for SSH sessions and headless
+// hosts: the terminal emulator itself sets the clipboard.
+var clipboardWriteFn = clipboard.WriteAll
+var osc52WriteFn = func(text string) error {
+	_, err := osc52.New(text).WriteTo(os.Stderr)
+	return err
+}
+
+func copyToClipboard(text string) tea.Cmd {
+	return func() tea.Msg {
+		if err := clipboardWriteFn(text); err == nil {
+			return clipboardCopiedMsg{bytes: len(text), via: "clipboard"}
+		}
+		if err := osc52WriteFn(text); err != nil {
+			return clipboardCopiedMsg{err: fmt.Errorf("copy to clipboard: %w", err)}
+		}
+		return clipboardCopiedMsg{bytes: len(text), via: "osc52"}
+	}
+}
+
+// editorCommandFn builds the command that edits path: $VISUAL, then
+// $EDITOR, then vi. The variable may carry arguments ("code --wait").
+var editorCommandFn = func(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	parts := strings.Fields(editor)
+	return exec.Command(parts[0], append(parts[1:], path)...)
+}
+
+var execProcessFn = tea.ExecProcess
+
+// editObservation suspends the TUI, opens the observation content in the
+// user's editor and saves it back through UpdateObservation when it changed.
+func editObservation(s *store.Store, obs *store.Observation) tea.Cmd {
+	f, err := os.CreateTemp("", fmt.Sprintf("engram-obs-%d-*.md", obs.ID))
+	if err != nil {
+		return func() tea.Msg { return editorFinishedMsg{err: err} }
+	}
+	path := f.Name()
+	if _, err := f.WriteString(obs.Content); err != nil {
+		f.Close()
+		os.Remove(path)
+		return func() tea.Msg { return editorFinishedMsg{err: err} }
+	}
+	f.Close()
+
+	return execProcessFn(editorCommandFn(path), func(runErr error) tea.Msg {
+		return finishEdit(s, obs, path, runErr)
+	})
+}
+
+func finishEdit(s *store.Store, obs *store.Observation, path string, runErr error) tea.Msg {
+	defer os.Remove(path)
+	if runErr != nil {
+		return editorFinishedMsg{err: fmt.Errorf("editor: %w", runErr)}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return editorFinishedMsg{err: err}
+	}
+
+	// Editors usually append a trailing newline; don't count it as an edit.
+	content := strings.TrimRight(string(data), "\n")
+	if content == strings.TrimRight(obs.Content, "\n") || strings.TrimSpace(content) == "" {
+		return editorFinishedMsg{observation: obs}
+	}
+
+	updated, err := s.UpdateObservation(obs.ID, store.UpdateObservationParams{Content: &content})
+	if err != nil {
+		return editorFinishedMsg{err: err}
+	}
+	return editorFinishedMsg{observation: updated, changed: true}
+}
@@ -122,7 +122,7 @@ func TestDataLoadingCommands(t *testing.T) {
 	})
 
 	t.Run("searchMemories", func(t *testing.T) {
-		msg := searchMemories(fx.store, "needle")()
+		msg := searchMemories(fx.store, "needle", ListFilter{})()
 		loaded, ok := msg.(searchResultsMsg)
 		if !ok {
 			t.Fatalf("message type = %T", msg)
@@ -139,7 +139,7 @@ func TestDataLoadingCommands(t *testing.T) {
 	})
 
 	t.Run("loadRecentObservations", func(t *testing.T) {
-		msg := loadRecentObservations(fx.store)()
+		msg := loadRecentObservations(fx.store, ListFilter{})()
 		loaded, ok := msg.(recentObservationsMsg)
 		if !ok {
 			t.Fatalf("message type = %T", msg)
@@ -0,0 +1,274 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Gentleman-Programming/engram/internal/i18n"
+	"github.com/Gentleman-Programming/engram/internal/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Command Palette ─────────────────────────────────────────────────────────
+//
+// ctrl+p opens a palette over every screen: typing fuzzy-filters the
+// actions below, enter runs the selected one. A new screen becomes
+// discoverable by adding one entry to paletteActions.
+
+type paletteAction struct {
+	Name     string // English i18n key, translated by the view
+	Keywords string // extra words matched but not shown
+	run      func(m Model) (tea.Model, tea.Cmd)
+}
+
+var paletteActions = []paletteAction{
+	{Name: "Search memories", Keywords: "find query", run: Model.openSearch},
+	{Name: "Recent observations", Keywords: "latest list", run: Model.openRecent},
+	{Name: "Browse sessions", Keywords: "history", run: Model.openSessions},
+	{Name: "New memory", Keywords: "add save create write editor", run: Model.openNewMemory},
+	{Name: "Review quarantine", Keywords: "passive approve reject", run: Model.openQuarantine},
+	{Name: "Trash", Keywords: "deleted restore undelete bin", run: Model.openTrash},
+	{Name: "Activity calendar", Keywords: "heatmap days", run: Model.openActivity},
+	{Name: "Browse topics", Keywords: "topic_key revisions history", run: Model.openTopics},
+	{Name: "Switch profile", Keywords: "store data dir work personal", run: Model.openProfiles},
+	{Name: "Dashboard", Keywords: "home stats", run: Model.openDashboard},
+	{Name: "Setup agent plugin", Keywords: "install", run: Model.openSetup},
+	{Name: "Quit", Keywords: "exit", run: func(m Model) (tea.Model, tea.Cmd) { return m, tea.Quit }},
+}
+
+// paletteMatch is an action that matched the palette query.
+type paletteMatch struct {
+	action paletteAction
+	score  int
+}
+
+// paletteMatches returns the actions matching the palette query, best
+// first. An empty query lists every action in menu order.
+func (m Model) paletteMatches() []paletteMatch {
+	query := strings.TrimSpace(m.PaletteInput.Value())
+	var matches []paletteMatch
+	for _, a := range paletteActions {
+		score, ok := fuzzyScore(query, i18n.T(a.Name))
+		if !ok {
+			// Keywords and the English name match too, at a lower score,
+			// so "add" finds "New memory" in any locale.
+			if score, ok = fuzzyScore(query, a.Name+" "+a.Keywords); ok {
+				score /= 2
+			}
+		}
+		if ok {
+			matches = append(matches, paletteMatch{action: a, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	return matches
+}
+
+// fuzzyScore reports whether every rune of query appears in target in
+// order, ignoring case, and scores the match: consecutive runes and runes
+// at the start of a word count extra, so "se" ranks "Search memories"
+// above "Browse sessions".
+func fuzzyScore(query, target string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return 0, true
+	}
+	t := []rune(strings.ToLower(target))
+	score, qi, prev := 0, 0, -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == prev+1 {
+			score += 3
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) {
+			score += 5
+		}
+		prev = ti
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	return score, true
+}
+
+func (m Model) openPalette() (tea.Model, tea.Cmd) {
+	if m.Screen != ScreenPalette {
+		m.PaletteReturn = m.Screen
+	}
+	m.Screen = ScreenPalette
+	m.PaletteCursor = 0
+	m.PaletteInput.SetValue("")
+	m.PaletteInput.Focus()
+	return m, nil
+}
+
+func (m Model) handlePaletteKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.ErrorMsg = ""
+	m.StatusMsg = ""
+	matches := m.paletteMatches()
+
+	switch msg.String() {
+	case "esc", "ctrl+p":
+		m.PaletteInput.Blur()
+		m.Screen = m.PaletteReturn
+		return m, nil
+	case "up", "ctrl+k":
+		if m.PaletteCursor > 0 {
+			m.PaletteCursor--
+		}
+		return m, nil
+	case "down", "ctrl+j", "tab":
+		if m.PaletteCursor < len(matches)-1 {
+			m.PaletteCursor++
+		}
+		return m, nil
+	case "enter":
+		if len(matches) == 0 {
+			return m, nil
+		}
+		m.PaletteInput.Blur()
+		m.Screen = m.PaletteReturn
+		return matches[min(m.PaletteCursor, len(matches)-1)].action.run(m)
+	}
+
+	var cmd tea.Cmd
+	before := m.PaletteInput.Value()
+	m.PaletteInput, cmd = m.PaletteInput.Update(msg)
+	if m.PaletteInput.Value() != before {
+		m.PaletteCursor = 0
+	}
+	return m, cmd
+}
+
+func (m Model) viewPalette() string {
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(i18n.T("  Commands")))
+	b.WriteString("\n")
+	b.WriteString(searchInputStyle.Render(m.PaletteInput.View()))
+	b.WriteString("\n")
+
+	matches := m.paletteMatches()
+	if len(matches) == 0 {
+		b.WriteString(noResultsStyle.Render(i18n.T("No matching command.")))
+		b.WriteString("\n")
+	}
+	cursor := min(m.PaletteCursor, max(len(matches)-1, 0))
+	for i, match := range matches {
+		if i == cursor {
+			b.WriteString(listSelectedStyle.Render("▸ " + i18n.T(match.action.Name)))
+		} else {
+			b.WriteString(listItemStyle.Render(i18n.T(match.action.Name)))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render(i18n.T("\n  type to filter • ↑/↓ select • enter run • esc close")))
+	return b.String()
+}
+
+// ─── New Memory ──────────────────────────────────────────────────────────────
+
+type memoryCreatedMsg struct {
+	id    int64
+	title string
+	err   error
+}
+
+// newMemoryTemplate is what the editor opens with: header lines, a blank
+// line, then the content.
+const newMemoryTemplate = `Title:
+Type: manual
+Project: %s
+Scope: project
+
+`
+
+// openNewMemory suspends the TUI and opens the user's editor on a memory
+// template; the memory is saved when the editor exits with a title and
+// content filled in.
+func (m Model) openNewMemory() (tea.Model, tea.Cmd) {
+	project := m.Filter.Project
+	if project == "" {
+		project = m.ActivityProject
+	}
+	f, err := os.CreateTemp("", "engram-new-*.md")
+	if err != nil {
+		m.ErrorMsg = err.Error()
+		return m, nil
+	}
+	path := f.Name()
+	_, err = fmt.Fprintf(f, newMemoryTemplate, project)
+	f.Close()
+	if err != nil {
+		os.Remove(path)
+		m.ErrorMsg = err.Error()
+		return m, nil
+	}
+
+	s := m.store
+	return m, execProcessFn(editorCommandFn(path), func(runErr error) tea.Msg {
+		return finishNewMemory(s, path, runErr)
+	})
+}
+
+func finishNewMemory(s *store.Store, path string, runErr error) tea.Msg {
+	defer os.Remove(path)
+	if runErr != nil {
+		return memoryCreatedMsg{err: fmt.Errorf("editor: %w", runErr)}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return memoryCreatedMsg{err: err}
+	}
+	p, ok := parseNewMemory(string(data))
+	if !ok {
+		return memoryCreatedMsg{}
+	}
+
+	p.SessionID = "manual-save"
+	if p.Project != "" {
+		p.SessionID = "manual-save-" + p.Project
+	}
+	if err := s.CreateSession(p.SessionID, p.Project, ""); err != nil {
+		return memoryCreatedMsg{err: err}
+	}
+	p.Source = store.SourceCLI
+	id, err := s.AddObservation(p)
+	return memoryCreatedMsg{id: id, title: p.Title, err: err}
+}
+
+// parseNewMemory reads the filled-in template. It reports false when the
+// title or content was left empty, which cancels the save.
+func parseNewMemory(text string) (store.AddObservationParams, bool) {
+	p := store.AddObservationParams{Type: "manual"}
+	header, content, _ := strings.Cut(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n")
+	for line := range strings.SplitSeq(header, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "title":
+			p.Title = value
+		case "type":
+			if value != "" {
+				p.Type = value
+			}
+		case "project":
+			p.Project, _ = store.NormalizeProject(value)
+		case "scope":
+			p.Scope = value
+		}
+	}
+	p.Content = strings.TrimSpace(content)
+	return p, p.Title != "" && p.Content != ""
+}
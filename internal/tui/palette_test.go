@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFuzzyScoreRanksWordStartsAndRuns(t *testing.T) {
+	search, ok := fuzzyScore("se", "Search memories")
+	if !ok {
+		t.Fatal("expected se to match Search memories")
+	}
+	sessions, ok := fuzzyScore("se", "Browse sessions")
+	if !ok || sessions >= search {
+		t.Fatalf("expected Search memories (%d) to outrank Browse sessions (%d)", search, sessions)
+	}
+	if _, ok := fuzzyScore("zz", "Search memories"); ok {
+		t.Fatal("expected zz not to match")
+	}
+	if _, ok := fuzzyScore("sm", "Search memories"); !ok {
+		t.Fatal("expected initials to match")
+	}
+}
+
+func TestPaletteOpensFiltersAndRuns(t *testing.T) {
+	fx := newTestFixture(t)
+	m := New(fx.store, "")
+	m.Screen = ScreenRecent
+
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	updated := updatedModel.(Model)
+	if updated.Screen != ScreenPalette || updated.PaletteReturn != ScreenRecent {
+		t.Fatalf("expected palette over recent, got screen=%v return=%v", updated.Screen, updated.PaletteReturn)
+	}
+	if got := len(updated.paletteMatches()); got != len(paletteActions) {
+		t.Fatalf("expected every action with an empty query, got %d", got)
+	}
+
+	closedModel, _ := updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if closedModel.(Model).Screen != ScreenRecent {
+		t.Fatalf("expected esc to return to recent, got %v", closedModel.(Model).Screen)
+	}
+
+	updatedModel = updated
+	for _, r := range "sess" {
+		updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	matches := updatedModel.(Model).paletteMatches()
+	if len(matches) == 0 || matches[0].action.Name != "Browse sessions" {
+		t.Fatalf("expected Browse sessions first for sess, got %+v", matches)
+	}
+	ranModel, cmd := updatedModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if ranModel.(Model).Screen != ScreenSessions || cmd == nil {
+		t.Fatalf("expected enter to open sessions with a load command, got %v", ranModel.(Model).Screen)
+	}
+
+	// Keywords find actions whose name does not contain the query.
+	updated.PaletteInput.SetValue("add")
+	if matches := updated.paletteMatches(); len(matches) == 0 || matches[0].action.Name != "New memory" {
+		t.Fatalf("expected add to find New memory, got %+v", matches)
+	}
+	updated.PaletteInput.SetValue("xyzzy")
+	if len(updated.paletteMatches()) != 0 {
+		t.Fatal("expected no matches for xyzzy")
+	}
+	if _, cmd := updated.handlePaletteKeys(tea.KeyMsg{Type: tea.KeyEnter}); cmd != nil {
+		t.Fatal("enter with no matches should do nothing")
+	}
+}
+
+func TestPaletteNewMemorySavesFromEditor(t *testing.T) {
+	fx := newTestFixture(t)
+
+	oldEditor, oldExec := editorCommandFn, execProcessFn
+	t.Cleanup(func() { editorCommandFn, execProcessFn = oldEditor, oldExec })
+
+	var editedPath string
+	editorCommandFn = func(path string) *exec.Cmd {
+		editedPath = path
+		return exec.Command("true")
+	}
+	body := "Title: Prefer table tests\nType: pattern\nProject: engram\nScope: project\n\nTable tests keep cases readable.\n"
+	execProcessFn = func(c *exec.Cmd, fn tea.ExecCallback) tea.Cmd {
+		return func() tea.Msg {
+			if err := os.WriteFile(editedPath, []byte(body), 0o600); err != nil {
+				t.Fatalf("write memory file: %v", err)
+			}
+			return fn(nil)
+		}
+	}
+
+	m := New(fx.store, "")
+	_, cmd := m.openNewMemory()
+	if cmd == nil {
+		t.Fatal("expected an editor command")
+	}
+	updatedModel, _ := m.Update(cmd())
+	updated := updatedModel.(Model)
+	if updated.ErrorMsg != "" || updated.StatusMsg == "" {
+		t.Fatalf("expected a saved memory, got status=%q err=%q", updated.StatusMsg, updated.ErrorMsg)
+	}
+	if _, err := os.Stat(editedPath); !os.IsNotExist(err) {
+		t.Fatalf("expected temp file removed, stat err=%v", err)
+	}
+	created, ok := cmd().(memoryCreatedMsg)
+	if !ok || created.err != nil {
+		t.Fatalf("expected a memoryCreatedMsg, got %+v", created)
+	}
+	obs, err := fx.store.GetObservation(created.id)
+	if err != nil || obs.Title != "Prefer table tests" || obs.Type != "pattern" || obs.SessionID != "manual-save-engram" {
+		t.Fatalf("expected the new memory stored, got %+v err=%v", obs, err)
+	}
+
+	// An untouched template saves nothing.
+	body = newMemoryTemplate
+	updatedModel, _ = m.Update(cmd())
+	if updatedModel.(Model).StatusMsg != "Nothing saved: the memory needs a title and content" {
+		t.Fatalf("expected nothing saved, got %q", updatedModel.(Model).StatusMsg)
+	}
+}
+
+func TestParseNewMemory(t *testing.T) {
+	p, ok := parseNewMemory("Title: Fix\r\nType:\r\nProject: Engram\r\n\r\nbody\n")
+	if !ok || p.Title != "Fix" || p.Type != "manual" || p.Project != "engram" || p.Content != "body" {
+		t.Fatalf("unexpected parse: %+v ok=%v", p, ok)
+	}
+	if _, ok := parseNewMemory("Title: Only a title\n\n"); ok {
+		t.Fatal("expected empty content to cancel")
+	}
+}
@@ -0,0 +1,208 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Picker ──────────────────────────────────────────────────────────────────
+//
+// Picker is the fzf-style incremental search behind `engram search -i`. It is
+// a standalone model (not a Screen of Model): typing re-runs the FTS query
+// after a short debounce, arrows move the cursor, and enter/ctrl+y quit with
+// the selection so the caller can print or copy it.
+
+// PickerDebounce is how long the picker waits after the last keystroke
+// before querying the store.
+const PickerDebounce = 150 * time.Millisecond
+
+// PickAction is what the user asked to do with the selected result.
+type PickAction int
+
+const (
+	PickNone   PickAction = iota // cancelled
+	PickPrint                    // enter: print the full observation
+	PickCopyID                   // ctrl+y: copy the observation ID
+)
+
+type pickerDebounceMsg struct {
+	seq   int
+	query string
+}
+
+type pickerResultsMsg struct {
+	seq     int
+	results []store.SearchResult
+	err     error
+}
+
+type Picker struct {
+	store  *store.Store
+	opts   store.SearchOptions
+	Input  textinput.Model
+	Width  int
+	Height int
+
+	Results []store.SearchResult
+	Cursor  int
+	Err     error
+
+	// seq identifies the latest query; debounce ticks and results carrying an
+	// older seq are stale and dropped.
+	seq int
+
+	Selected *store.SearchResult
+	Action   PickAction
+}
+
+// NewPicker creates a picker over s. opts filters every query (type,
+// project, scope, limit); query pre-fills the input.
+func NewPicker(s *store.Store, opts store.SearchOptions, query string) Picker {
+	ti := textinput.New()
+	ti.Placeholder = "Type to search memories..."
+	ti.CharLimit = 256
+	ti.Width = 60
+	ti.SetValue(query)
+	ti.Focus()
+
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+	return Picker{store: s, opts: opts, Input: ti}
+}
+
+func (p Picker) Init() tea.Cmd {
+	if strings.TrimSpace(p.Input.Value()) == "" {
+		return textinput.Blink
+	}
+	return tea.Batch(textinput.Blink, p.search(p.seq, p.Input.Value()))
+}
+
+func (p Picker) search(seq int, query string) tea.Cmd {
+	s, opts := p.store, p.opts
+	return func() tea.Msg {
+		results, err := s.Search(query, opts)
+		return pickerResultsMsg{seq: seq, results: results, err: err}
+	}
+}
+
+func (p Picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.Width = msg.Width
+		p.Height = msg.Height
+		return p, nil
+
+	case pickerDebounceMsg:
+		if msg.seq != p.seq {
+			return p, nil
+		}
+		if strings.TrimSpace(msg.query) == "" {
+			p.Results, p.Cursor, p.Err = nil, 0, nil
+			return p, nil
+		}
+		return p, p.search(msg.seq, msg.query)
+
+	case pickerResultsMsg:
+		if msg.seq != p.seq {
+			return p, nil
+		}
+		p.Results, p.Err = msg.results, msg.err
+		if p.Cursor >= len(p.Results) {
+			p.Cursor = max(len(p.Results)-1, 0)
+		}
+		return p, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			p.Action = PickNone
+			return p, tea.Quit
+		case "up", "ctrl+p":
+			if p.Cursor > 0 {
+				p.Cursor--
+			}
+			return p, nil
+		case "down", "ctrl+n":
+			if p.Cursor < len(p.Results)-1 {
+				p.Cursor++
+			}
+			return p, nil
+		case "enter", "ctrl+y":
+			if len(p.Results) == 0 {
+				return p, nil
+			}
+			selected := p.Results[p.Cursor]
+			p.Selected = &selected
+			p.Action = PickPrint
+			if msg.String() == "ctrl+y" {
+				p.Action = PickCopyID
+			}
+			return p, tea.Quit
+		}
+
+		before := p.Input.Value()
+		var cmd tea.Cmd
+		p.Input, cmd = p.Input.Update(msg)
+		if value := p.Input.Value(); value != before {
+			p.seq++
+			p.Cursor = 0
+			seq := p.seq
+			return p, tea.Batch(cmd, tea.Tick(PickerDebounce, func(time.Time) tea.Msg {
+				return pickerDebounceMsg{seq: seq, query: value}
+			}))
+		}
+		return p, cmd
+	}
+
+	var cmd tea.Cmd
+	p.Input, cmd = p.Input.Update(msg)
+	return p, cmd
+}
+
+func (p Picker) View() string {
+	var b strings.Builder
+
+	b.WriteString(searchInputStyle.Render(p.Input.View()))
+	b.WriteString("\n")
+
+	switch {
+	case p.Err != nil:
+		b.WriteString(errorStyle.Render("Error: " + p.Err.Error()))
+		b.WriteString("\n")
+	case len(p.Results) == 0 && strings.TrimSpace(p.Input.Value()) != "":
+		b.WriteString(noResultsStyle.Render("No memories found."))
+		b.WriteString("\n")
+	}
+
+	visible := len(p.Results)
+	if p.Height > 0 {
+		visible = min(visible, max(p.Height-6, 3))
+	}
+	start := 0
+	if p.Cursor >= visible {
+		start = p.Cursor - visible + 1
+	}
+	for i := start; i < start+visible && i < len(p.Results); i++ {
+		r := p.Results[i]
+		line := fmt.Sprintf("%s %s %s",
+			idStyle.Render(fmt.Sprintf("#%d", r.ID)),
+			typeBadgeStyle.Render("["+r.Type+"]"),
+			truncateStr(r.Title, 70))
+		if i == p.Cursor {
+			b.WriteString(listSelectedStyle.Render("▸ " + line))
+		} else {
+			b.WriteString(listItemStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("  ↑/↓ select • enter print • ctrl+y copy id • esc cancel"))
+	return b.String()
+}
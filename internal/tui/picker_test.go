@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func typeInto(t *testing.T, p Picker, text string) (Picker, tea.Cmd) {
+	t.Helper()
+	var cmd tea.Cmd
+	for _, r := range text {
+		var next tea.Model
+		next, cmd = p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		p = next.(Picker)
+	}
+	return p, cmd
+}
+
+func TestPickerDebouncesAndDropsStaleQueries(t *testing.T) {
+	fx := newTestFixture(t)
+	p := NewPicker(fx.store, store.SearchOptions{}, "")
+
+	p, _ = typeInto(t, p, "needle")
+	if p.seq != len("needle") {
+		t.Fatalf("expected one seq bump per keystroke, got %d", p.seq)
+	}
+
+	// A debounce tick from an earlier keystroke is ignored.
+	next, cmd := p.Update(pickerDebounceMsg{seq: 1, query: "n"})
+	p = next.(Picker)
+	if cmd != nil {
+		t.Fatalf("expected stale debounce tick to be dropped")
+	}
+
+	// The latest tick runs the search.
+	next, cmd = p.Update(pickerDebounceMsg{seq: p.seq, query: "needle"})
+	p = next.(Picker)
+	if cmd == nil {
+		t.Fatalf("expected current debounce tick to start a search")
+	}
+	msg := cmd().(pickerResultsMsg)
+	next, _ = p.Update(msg)
+	p = next.(Picker)
+	if len(p.Results) != 1 || p.Results[0].ID != fx.obsID {
+		t.Fatalf("expected needle observation in results, got %+v", p.Results)
+	}
+
+	// Results for an older query never overwrite newer ones.
+	next, _ = p.Update(pickerResultsMsg{seq: p.seq - 1, results: nil})
+	p = next.(Picker)
+	if len(p.Results) != 1 {
+		t.Fatalf("expected stale results to be ignored, got %+v", p.Results)
+	}
+	if !strings.Contains(p.View(), "Needle observation") {
+		t.Fatalf("expected view to list the result, got %q", p.View())
+	}
+}
+
+func TestPickerSelectionActions(t *testing.T) {
+	p := NewPicker(nil, store.SearchOptions{}, "")
+	p.Results = []store.SearchResult{
+		{Observation: store.Observation{ID: 10, Type: "bugfix", Title: "first"}},
+		{Observation: store.Observation{ID: 20, Type: "decision", Title: "second"}},
+	}
+
+	next, _ := p.Update(tea.KeyMsg{Type: tea.KeyDown})
+	p = next.(Picker)
+	next, _ = p.Update(tea.KeyMsg{Type: tea.KeyDown})
+	p = next.(Picker)
+	if p.Cursor != 1 {
+		t.Fatalf("expected cursor clamped at last result, got %d", p.Cursor)
+	}
+
+	next, cmd := p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	picked := next.(Picker)
+	if cmd == nil || picked.Action != PickPrint || picked.Selected == nil || picked.Selected.ID != 20 {
+		t.Fatalf("expected enter to select #20 for printing, got action=%v selected=%+v", picked.Action, picked.Selected)
+	}
+
+	next, _ = p.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	if copied := next.(Picker); copied.Action != PickCopyID || copied.Selected.ID != 20 {
+		t.Fatalf("expected ctrl+y to select #20 for copying, got %+v", copied)
+	}
+
+	next, _ = p.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cancelled := next.(Picker); cancelled.Action != PickNone || cancelled.Selected != nil {
+		t.Fatalf("expected esc to cancel without selection, got %+v", cancelled)
+	}
+
+	empty := NewPicker(nil, store.SearchOptions{}, "")
+	if _, cmd := empty.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd != nil {
+		t.Fatalf("expected enter with no results to be a no-op")
+	}
+}
@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Gentleman-Programming/engram/internal/i18n"
+	"github.com/Gentleman-Programming/engram/internal/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Profiles ────────────────────────────────────────────────────────────────
+//
+// P on the dashboard (or "Switch profile" in the palette) lists the stores
+// configured under [profiles] in .engram.toml. Picking one reopens the TUI
+// on that store without restarting, so work and personal memory are one
+// keypress apart.
+
+// Profile is a named store the TUI can switch to.
+type Profile struct {
+	Name    string
+	DataDir string
+}
+
+// ProfileOpener opens the store of a profile. The caller owns the stores it
+// opens and closes the previous one once the switch succeeds.
+type ProfileOpener func(Profile) (*store.Store, error)
+
+type profileSwitchedMsg struct {
+	index int
+	store *store.Store
+	err   error
+}
+
+// WithProfiles lists profiles on the profiles screen; current is the index
+// of the profile the model's store belongs to.
+func (m Model) WithProfiles(profiles []Profile, current int, open ProfileOpener) Model {
+	m.Profiles = profiles
+	m.CurrentProfile = current
+	m.openProfile = open
+	return m
+}
+
+func switchProfile(open ProfileOpener, profiles []Profile, index int) tea.Cmd {
+	return func() tea.Msg {
+		s, err := open(profiles[index])
+		return profileSwitchedMsg{index: index, store: s, err: err}
+	}
+}
+
+func (m Model) openProfiles() (tea.Model, tea.Cmd) {
+	m.PrevScreen = ScreenDashboard
+	m.Screen = ScreenProfiles
+	m.Cursor = m.CurrentProfile
+	return m, nil
+}
+
+func (m Model) handleProfilesKeys(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up", "k":
+		if m.Cursor > 0 {
+			m.Cursor--
+		}
+	case "down", "j":
+		if m.Cursor < len(m.Profiles)-1 {
+			m.Cursor++
+		}
+	case "enter":
+		if m.Cursor >= len(m.Profiles) || m.openProfile == nil {
+			return m, nil
+		}
+		if m.Cursor == m.CurrentProfile {
+			return m.openDashboard()
+		}
+		return m, switchProfile(m.openProfile, m.Profiles, m.Cursor)
+	case "esc", "q":
+		return m.openDashboard()
+	}
+	return m, nil
+}
+
+// profileSwitched points the model at the new store and drops everything
+// loaded from the old one.
+func (m Model) profileSwitched(msg profileSwitchedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.ErrorMsg = msg.err.Error()
+		return m, nil
+	}
+	fresh := New(msg.store, m.Version)
+	fresh.Width, fresh.Height = m.Width, m.Height
+	fresh.UpdateStatus, fresh.UpdateMsg = m.UpdateStatus, m.UpdateMsg
+	fresh = fresh.WithProfiles(m.Profiles, msg.index, m.openProfile)
+	fresh.StatusMsg = i18n.Tf("Switched to profile %s", m.Profiles[msg.index].Name)
+	return fresh, loadStats(fresh.store)
+}
+
+func (m Model) viewProfiles() string {
+	var b strings.Builder
+
+	b.WriteString(headerStyle.Render(i18n.T("  Profiles")))
+	b.WriteString("\n")
+
+	if len(m.Profiles) == 0 {
+		b.WriteString(noResultsStyle.Render(i18n.T("No profiles configured. Add [profiles.<name>] tables with a data_dir to .engram.toml.")))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(i18n.T("  esc back")))
+		return b.String()
+	}
+
+	for i, p := range m.Profiles {
+		cursor := "  "
+		style := listItemStyle
+		if i == m.Cursor {
+			cursor = "▸ "
+			style = listSelectedStyle
+		}
+		current := ""
+		if i == m.CurrentProfile {
+			current = "  " + statusStyle.Render(i18n.T("(current)"))
+		}
+		b.WriteString(fmt.Sprintf("%s%s %s%s\n",
+			cursor,
+			style.Render(fmt.Sprintf("%-16s", truncateStr(p.Name, 16))),
+			timestampStyle.Render(p.DataDir),
+			current))
+	}
+
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k navigate • enter switch • esc back")))
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestProfilesSwitchReopensStore(t *testing.T) {
+	work := newTestFixture(t)
+	personal := newTestFixture(t)
+
+	profiles := []Profile{{Name: "work", DataDir: "/data/work"}, {Name: "personal", DataDir: "/data/personal"}}
+	var opened []string
+	open := func(p Profile) (*store.Store, error) {
+		opened = append(opened, p.Name)
+		if p.Name == "personal" {
+			return personal.store, nil
+		}
+		return nil, errors.New("locked")
+	}
+	m := New(work.store, "").WithProfiles(profiles, 0, open)
+
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("P")})
+	updated := updatedModel.(Model)
+	if updated.Screen != ScreenProfiles || updated.Cursor != 0 || !strings.Contains(updated.View(), "/data/personal") {
+		t.Fatalf("expected the profiles screen on the current profile, got screen=%v cursor=%d", updated.Screen, updated.Cursor)
+	}
+
+	updatedModel, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	updated = updatedModel.(Model)
+	updated.Filter = ListFilter{Project: "engram"}
+	updatedModel, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected enter to switch profiles")
+	}
+	updatedModel, cmd = updatedModel.(Model).Update(cmd())
+	switched := updatedModel.(Model)
+	if switched.store != personal.store || switched.CurrentProfile != 1 || switched.Screen != ScreenDashboard || cmd == nil {
+		t.Fatalf("expected the personal store on the dashboard, got current=%d screen=%v", switched.CurrentProfile, switched.Screen)
+	}
+	if switched.Filter.Active() || !strings.Contains(switched.StatusMsg, "personal") || len(switched.Profiles) != 2 {
+		t.Fatalf("expected state from the old store dropped, got filter=%+v status=%q", switched.Filter, switched.StatusMsg)
+	}
+
+	failed, _ := switched.handleProfilesKeys("k")
+	failedModel, cmd := failed.(Model).handleProfilesKeys("enter")
+	failedModel, _ = failedModel.(Model).Update(cmd())
+	if got := failedModel.(Model); got.store != personal.store || got.ErrorMsg != "locked" {
+		t.Fatalf("expected a failed open to keep the current store, got err=%q", got.ErrorMsg)
+	}
+	if strings.Join(opened, ",") != "personal,work" {
+		t.Fatalf("unexpected opens: %v", opened)
+	}
+}
@@ -49,6 +49,11 @@ var (
 			Bold(true).
 			Padding(0, 1)
 
+	// Transient confirmation message
+	statusStyle = lipgloss.NewStyle().
+			Foreground(colorGreen).
+			Padding(0, 1)
+
 	// Update available banner
 	updateBannerStyle = lipgloss.NewStyle().
 				Foreground(colorYellow).
@@ -200,3 +205,24 @@ var (
 			PaddingLeft(2).
 			MarginTop(1)
 )
+
+// ─── Activity Heatmap Styles ─────────────────────────────────────────────────
+
+var (
+	// heatmapLevelStyles go from no activity (index 0) to the busiest days.
+	heatmapLevelStyles = []lipgloss.Style{
+		lipgloss.NewStyle().Foreground(colorOverlay),
+		lipgloss.NewStyle().Foreground(colorBlue),
+		lipgloss.NewStyle().Foreground(colorTeal),
+		lipgloss.NewStyle().Foreground(colorLavender),
+		lipgloss.NewStyle().Foreground(colorYellow).Bold(true),
+	}
+
+	// Selected day on the activity calendar
+	heatmapCursorStyle = lipgloss.NewStyle().
+				Foreground(colorRed).
+				Bold(true)
+
+	heatmapLabelStyle = lipgloss.NewStyle().
+				Foreground(colorSubtext)
+)
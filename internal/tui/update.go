@@ -1,6 +1,10 @@
 package tui
 
 import (
+	"fmt"
+	"slices"
+	"sort"
+
 	"github.com/Gentleman-Programming/engram/internal/setup"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -21,6 +25,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.String() == "ctrl+c" {
 			return m, tea.Quit
 		}
+		if m.Screen == ScreenPalette {
+			return m.handlePaletteKeys(msg)
+		}
+		if msg.String() == "ctrl+p" {
+			return m.openPalette()
+		}
 		// If search input is focused, let it handle most keys
 		if m.Screen == ScreenSearch && m.SearchInput.Focused() {
 			return m.handleSearchInputKeys(msg)
@@ -39,7 +49,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.Stats = msg.stats
-		return m, nil
+		// The dashboard heatmap refreshes together with the stats.
+		return m, loadActivity(m.store, m.ActivityProject)
 
 	case searchResultsMsg:
 		if msg.err != nil {
@@ -100,6 +111,98 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.SessionDetailScroll = 0
 		return m, nil
 
+	case quarantineLoadedMsg:
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+			return m, nil
+		}
+		m.Quarantine = msg.items
+		if m.Cursor >= len(m.Quarantine) {
+			m.Cursor = max(len(m.Quarantine)-1, 0)
+		}
+		return m, nil
+
+	case quarantineReviewedMsg:
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+			return m, nil
+		}
+		if msg.approved {
+			m.StatusMsg = fmt.Sprintf("Approved #%d", msg.id)
+		} else {
+			m.StatusMsg = fmt.Sprintf("Rejected #%d", msg.id)
+		}
+		return m, loadQuarantine(m.store)
+
+	case trashLoadedMsg:
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+			return m, nil
+		}
+		m.Trash = msg.items
+		if m.Cursor >= len(m.Trash) {
+			m.Cursor = max(len(m.Trash)-1, 0)
+		}
+		return m, nil
+
+	case trashRestoredMsg:
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+			return m, nil
+		}
+		m.StatusMsg = fmt.Sprintf("Restored #%d", msg.id)
+		return m, loadTrash(m.store)
+
+	case activityLoadedMsg:
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+			return m, nil
+		}
+		// A slow load for a project the user already switched away from.
+		if msg.project != m.ActivityProject {
+			return m, nil
+		}
+		m.Activity = msg.days
+		if m.ActivityCursor < 0 || m.ActivityCursor >= len(m.Activity) {
+			m.ActivityCursor = len(m.Activity) - 1
+		}
+		return m, nil
+
+	case activityDayMsg:
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+			return m, nil
+		}
+		m.ActivityDay = msg.day
+		m.ActivityDayObservations = msg.observations
+		m.Screen = ScreenActivityDay
+		m.Cursor = 0
+		m.Scroll = 0
+		return m, nil
+
+	case topicsLoadedMsg:
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+			return m, nil
+		}
+		m.Topics = msg.topics
+		if m.Cursor >= len(m.Topics) {
+			m.Cursor = max(len(m.Topics)-1, 0)
+		}
+		return m, nil
+
+	case topicDetailMsg:
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+			return m, nil
+		}
+		m.Topic = &msg.topic
+		m.TopicObservation = msg.observation
+		m.TopicHistory = msg.history
+		m.Screen = ScreenTopicDetail
+		m.DetailScroll = 0
+		return m, nil
+
 	case setupInstallMsg:
 		m.SetupInstalling = false
 		if msg.err != nil {
@@ -117,6 +220,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.SetupDone = true
 		return m, nil
 
+	case clipboardCopiedMsg:
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+			return m, nil
+		}
+		m.StatusMsg = fmt.Sprintf("Copied %d bytes to %s", msg.bytes, msg.via)
+		return m, nil
+
+	case editorFinishedMsg:
+		if msg.err != nil {
+			m.ErrorMsg = msg.err.Error()
+			return m, nil
+		}
+		m.SelectedObservation = msg.observation
+		if msg.changed {
+			m.StatusMsg = "Observation updated"
+		} else {
+			m.StatusMsg = "No changes"
+		}
+		return m, nil
+
+	case memoryCreatedMsg:
+		switch {
+		case msg.err != nil:
+			m.ErrorMsg = msg.err.Error()
+		case msg.id == 0:
+			m.StatusMsg = "Nothing saved: the memory needs a title and content"
+		default:
+			m.StatusMsg = fmt.Sprintf("Memory saved: #%d %q", msg.id, msg.title)
+		}
+		return m, nil
+
+	case profileSwitchedMsg:
+		return m.profileSwitched(msg)
+
 	case spinner.TickMsg:
 		// Only forward spinner ticks when we're actually installing
 		if m.SetupInstalling {
@@ -133,8 +271,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 // ─── Key Press Router ────────────────────────────────────────────────────────
 
 func (m Model) handleKeyPress(key string) (tea.Model, tea.Cmd) {
-	// Clear error on any keypress
+	// Clear error and status on any keypress
 	m.ErrorMsg = ""
+	m.StatusMsg = ""
 
 	switch m.Screen {
 	case ScreenDashboard:
@@ -155,16 +294,36 @@ func (m Model) handleKeyPress(key string) (tea.Model, tea.Cmd) {
 		return m.handleSessionDetailKeys(key)
 	case ScreenSetup:
 		return m.handleSetupKeys(key)
+	case ScreenQuarantine:
+		return m.handleQuarantineKeys(key)
+	case ScreenTrash:
+		return m.handleTrashKeys(key)
+	case ScreenActivity:
+		return m.handleActivityKeys(key)
+	case ScreenActivityDay:
+		return m.handleActivityDayKeys(key)
+	case ScreenFilter:
+		return m.handleFilterKeys(key)
+	case ScreenTopics:
+		return m.handleTopicsKeys(key)
+	case ScreenTopicDetail:
+		return m.handleTopicDetailKeys(key)
+	case ScreenProfiles:
+		return m.handleProfilesKeys(key)
 	}
 	return m, nil
 }
 
 // ─── Dashboard ───────────────────────────────────────────────────────────────
 
+// dashboardMenuItems are English i18n keys; the view translates them.
 var dashboardMenuItems = []string{
 	"Search memories",
 	"Recent observations",
 	"Browse sessions",
+	"Review quarantine",
+	"Activity calendar",
+	"Browse topics",
 	"Setup agent plugin",
 	"Quit",
 }
@@ -182,12 +341,11 @@ func (m Model) handleDashboardKeys(key string) (tea.Model, tea.Cmd) {
 	case "enter", " ":
 		return m.handleDashboardSelection()
 	case "s", "/":
-		m.PrevScreen = ScreenDashboard
-		m.Screen = ScreenSearch
-		m.Cursor = 0
-		m.SearchInput.SetValue("")
-		m.SearchInput.Focus()
-		return m, nil
+		return m.openSearch()
+	case "p":
+		return m.cycleActivityProject()
+	case "P":
+		return m.openProfiles()
 	case "q":
 		return m, tea.Quit
 	}
@@ -196,42 +354,104 @@ func (m Model) handleDashboardKeys(key string) (tea.Model, tea.Cmd) {
 
 func (m Model) handleDashboardSelection() (tea.Model, tea.Cmd) {
 	switch m.Cursor {
-	case 0: // Search
-		m.PrevScreen = ScreenDashboard
-		m.Screen = ScreenSearch
-		m.Cursor = 0
-		m.SearchInput.SetValue("")
-		m.SearchInput.Focus()
-		return m, nil
-	case 1: // Recent observations
-		m.PrevScreen = ScreenDashboard
-		m.Screen = ScreenRecent
-		m.Cursor = 0
-		m.Scroll = 0
-		return m, loadRecentObservations(m.store)
-	case 2: // Sessions
-		m.PrevScreen = ScreenDashboard
-		m.Screen = ScreenSessions
-		m.Cursor = 0
-		m.Scroll = 0
-		return m, loadRecentSessions(m.store)
-	case 3: // Setup
-		m.PrevScreen = ScreenDashboard
-		m.Screen = ScreenSetup
-		m.Cursor = 0
-		m.SetupAgents = setup.SupportedAgents()
-		m.SetupResult = nil
-		m.SetupError = ""
-		m.SetupDone = false
-		m.SetupInstalling = false
-		m.SetupInstallingName = ""
-		return m, nil
-	case 4: // Quit
+	case 0:
+		return m.openSearch()
+	case 1:
+		return m.openRecent()
+	case 2:
+		return m.openSessions()
+	case 3:
+		return m.openQuarantine()
+	case 4:
+		return m.openActivity()
+	case 5:
+		return m.openTopics()
+	case 6:
+		return m.openSetup()
+	case 7: // Quit
 		return m, tea.Quit
 	}
 	return m, nil
 }
 
+// The open* helpers switch to a top-level screen as if it was picked on
+// the dashboard, so esc leads back there. The dashboard menu and the
+// command palette share them.
+
+func (m Model) openDashboard() (tea.Model, tea.Cmd) {
+	m.Screen = ScreenDashboard
+	m.Cursor = 0
+	return m, loadStats(m.store)
+}
+
+func (m Model) openSearch() (tea.Model, tea.Cmd) {
+	m.PrevScreen = ScreenDashboard
+	m.Screen = ScreenSearch
+	m.Cursor = 0
+	m.SearchInput.SetValue("")
+	m.SearchInput.Focus()
+	return m, nil
+}
+
+func (m Model) openRecent() (tea.Model, tea.Cmd) {
+	m.PrevScreen = ScreenDashboard
+	m.Screen = ScreenRecent
+	m.Cursor = 0
+	m.Scroll = 0
+	return m, loadRecentObservations(m.store, m.Filter)
+}
+
+func (m Model) openSessions() (tea.Model, tea.Cmd) {
+	m.PrevScreen = ScreenDashboard
+	m.Screen = ScreenSessions
+	m.Cursor = 0
+	m.Scroll = 0
+	return m, loadRecentSessions(m.store)
+}
+
+func (m Model) openQuarantine() (tea.Model, tea.Cmd) {
+	m.PrevScreen = ScreenDashboard
+	m.Screen = ScreenQuarantine
+	m.Cursor = 0
+	m.Scroll = 0
+	return m, loadQuarantine(m.store)
+}
+
+func (m Model) openTrash() (tea.Model, tea.Cmd) {
+	m.PrevScreen = ScreenDashboard
+	m.Screen = ScreenTrash
+	m.Cursor = 0
+	m.Scroll = 0
+	return m, loadTrash(m.store)
+}
+
+func (m Model) openActivity() (tea.Model, tea.Cmd) {
+	m.PrevScreen = ScreenDashboard
+	m.Screen = ScreenActivity
+	return m, loadActivity(m.store, m.ActivityProject)
+}
+
+func (m Model) openTopics() (tea.Model, tea.Cmd) {
+	m.PrevScreen = ScreenDashboard
+	m.Screen = ScreenTopics
+	m.Cursor = 0
+	m.Scroll = 0
+	return m, loadTopics(m.store)
+}
+
+func (m Model) openSetup() (tea.Model, tea.Cmd) {
+	m.PrevScreen = ScreenDashboard
+	m.Screen = ScreenSetup
+	m.Cursor = 0
+	m.SetupAgents = setup.SupportedAgents()
+	m.SetupResult = nil
+	m.SetupError = ""
+	m.SetupDone = false
+	m.SetupInstalling = false
+	m.SetupInstallingName = ""
+	return m, nil
+}
+
 // ─── Search Input ────────────────────────────────────────────────────────────
 
 func (m Model) handleSearchInputKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -240,7 +460,7 @@ func (m Model) handleSearchInputKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		query := m.SearchInput.Value()
 		if query != "" {
 			m.SearchInput.Blur()
-			return m, searchMemories(m.store, query)
+			return m, searchMemories(m.store, query, m.Filter)
 		}
 		return m, nil
 	case "esc":
@@ -312,6 +532,8 @@ func (m Model) handleSearchResultsKeys(key string) (tea.Model, tea.Cmd) {
 		m.Screen = ScreenSearch
 		m.SearchInput.Focus()
 		return m, nil
+	case "f":
+		return m.openFilter(ScreenSearchResults)
 	case "esc", "q":
 		m.PrevScreen = ScreenDashboard
 		m.Screen = ScreenSearch
@@ -358,6 +580,8 @@ func (m Model) handleRecentKeys(key string) (tea.Model, tea.Cmd) {
 			m.PrevScreen = ScreenRecent
 			return m, loadTimeline(m.store, obsID)
 		}
+	case "f":
+		return m.openFilter(ScreenRecent)
 	case "esc", "q":
 		m.Screen = ScreenDashboard
 		m.Cursor = 0
@@ -367,6 +591,98 @@ func (m Model) handleRecentKeys(key string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// ─── Filter ──────────────────────────────────────────────────────────────────
+
+// openFilter shows the filter screen for a list screen. Stats are reloaded
+// so the type and project choices reflect what is stored now.
+func (m Model) openFilter(from Screen) (tea.Model, tea.Cmd) {
+	m.FilterReturn = from
+	m.Screen = ScreenFilter
+	m.FilterCursor = 0
+	return m, loadStats(m.store)
+}
+
+// filterTypes lists the types to offer: every type with observations plus
+// any selected type that has none left.
+func (m Model) filterTypes() []string {
+	var types []string
+	if m.Stats != nil {
+		for typ := range m.Stats.ObservationsByType {
+			types = append(types, typ)
+		}
+	}
+	for _, typ := range m.Filter.Types {
+		if !slices.Contains(types, typ) {
+			types = append(types, typ)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// filterProjects lists the project choices, "" meaning all projects.
+func (m Model) filterProjects() []string {
+	projects := []string{""}
+	if m.Stats != nil {
+		projects = append(projects, m.Stats.Projects...)
+	}
+	if m.Filter.Project != "" && !slices.Contains(projects, m.Filter.Project) {
+		projects = append(projects, m.Filter.Project)
+	}
+	return projects
+}
+
+// cycle returns the choice delta steps away from current, wrapping around.
+func cycle(choices []string, current string, delta int) string {
+	i := slices.Index(choices, current)
+	if i < 0 {
+		i = 0
+	}
+	return choices[(i+delta+len(choices))%len(choices)]
+}
+
+func (m Model) handleFilterKeys(key string) (tea.Model, tea.Cmd) {
+	types := m.filterTypes()
+	projectRow, scopeRow := len(types), len(types)+1
+	// The type list can shrink when stats reload.
+	m.FilterCursor = min(m.FilterCursor, scopeRow)
+
+	switch key {
+	case "up", "k":
+		if m.FilterCursor > 0 {
+			m.FilterCursor--
+		}
+	case "down", "j":
+		if m.FilterCursor < scopeRow {
+			m.FilterCursor++
+		}
+	case " ", "enter", "right", "l", "left", "h":
+		delta := 1
+		if key == "left" || key == "h" {
+			delta = -1
+		}
+		switch {
+		case m.FilterCursor < projectRow:
+			m.Filter = m.Filter.ToggleType(types[m.FilterCursor])
+		case m.FilterCursor == projectRow:
+			m.Filter.Project = cycle(m.filterProjects(), m.Filter.Project, delta)
+		default:
+			m.Filter.Scope = cycle(filterScopes, m.Filter.Scope, delta)
+		}
+	case "c":
+		m.Filter = ListFilter{}
+	case "f", "esc", "q":
+		m.Screen = m.FilterReturn
+		m.Cursor = 0
+		m.Scroll = 0
+		if m.FilterReturn == ScreenSearchResults {
+			return m, searchMemories(m.store, m.SearchQuery, m.Filter)
+		}
+		return m, loadRecentObservations(m.store, m.Filter)
+	}
+	return m, nil
+}
+
 // ─── Observation Detail ──────────────────────────────────────────────────────
 
 func (m Model) handleObservationDetailKeys(key string) (tea.Model, tea.Cmd) {
@@ -382,6 +698,14 @@ func (m Model) handleObservationDetailKeys(key string) (tea.Model, tea.Cmd) {
 		if m.SelectedObservation != nil {
 			return m, loadTimeline(m.store, m.SelectedObservation.ID)
 		}
+	case "y":
+		if m.SelectedObservation != nil {
+			return m, copyToClipboard(m.SelectedObservation.Content)
+		}
+	case "e":
+		if m.SelectedObservation != nil {
+			return m, editObservation(m.store, m.SelectedObservation)
+		}
 	case "esc", "q":
 		m.Screen = m.PrevScreen
 		m.Cursor = 0
@@ -493,6 +817,252 @@ func (m Model) handleSessionDetailKeys(key string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// ─── Quarantine ──────────────────────────────────────────────────────────────
+
+func (m Model) handleQuarantineKeys(key string) (tea.Model, tea.Cmd) {
+	visibleItems := (m.Height - 8) / 3 // 3 lines per quarantined item
+	if visibleItems < 3 {
+		visibleItems = 3
+	}
+
+	switch key {
+	case "up", "k":
+		if m.Cursor > 0 {
+			m.Cursor--
+			if m.Cursor < m.Scroll {
+				m.Scroll = m.Cursor
+			}
+		}
+	case "down", "j":
+		if m.Cursor < len(m.Quarantine)-1 {
+			m.Cursor++
+			if m.Cursor >= m.Scroll+visibleItems {
+				m.Scroll = m.Cursor - visibleItems + 1
+			}
+		}
+	case "enter":
+		if len(m.Quarantine) > 0 && m.Cursor < len(m.Quarantine) {
+			m.PrevScreen = ScreenQuarantine
+			return m, loadObservationDetail(m.store, m.Quarantine[m.Cursor].ID)
+		}
+	case "a":
+		if len(m.Quarantine) > 0 && m.Cursor < len(m.Quarantine) {
+			return m, reviewQuarantine(m.store, m.Quarantine[m.Cursor].ID, true)
+		}
+	case "x":
+		if len(m.Quarantine) > 0 && m.Cursor < len(m.Quarantine) {
+			return m, reviewQuarantine(m.store, m.Quarantine[m.Cursor].ID, false)
+		}
+	case "esc", "q":
+		m.Screen = ScreenDashboard
+		m.Cursor = 0
+		m.Scroll = 0
+		return m, loadStats(m.store)
+	}
+	return m, nil
+}
+
+// ─── Trash ───────────────────────────────────────────────────────────────────
+
+func (m Model) handleTrashKeys(key string) (tea.Model, tea.Cmd) {
+	visibleItems := (m.Height - 8) / 3 // 3 lines per deleted item
+	if visibleItems < 3 {
+		visibleItems = 3
+	}
+
+	switch key {
+	case "up", "k":
+		if m.Cursor > 0 {
+			m.Cursor--
+			if m.Cursor < m.Scroll {
+				m.Scroll = m.Cursor
+			}
+		}
+	case "down", "j":
+		if m.Cursor < len(m.Trash)-1 {
+			m.Cursor++
+			if m.Cursor >= m.Scroll+visibleItems {
+				m.Scroll = m.Cursor - visibleItems + 1
+			}
+		}
+	case "r":
+		if len(m.Trash) > 0 && m.Cursor < len(m.Trash) {
+			return m, restoreTrash(m.store, m.Trash[m.Cursor].ID)
+		}
+	case "esc", "q":
+		m.Screen = ScreenDashboard
+		m.Cursor = 0
+		m.Scroll = 0
+		return m, loadStats(m.store)
+	}
+	return m, nil
+}
+
+// ─── Activity ────────────────────────────────────────────────────────────────
+
+// cycleActivityProject switches the heatmap to the next project: all
+// projects first, then each project from the stats.
+func (m Model) cycleActivityProject() (tea.Model, tea.Cmd) {
+	projects := []string{""}
+	if m.Stats != nil {
+		projects = append(projects, m.Stats.Projects...)
+	}
+	next := 0
+	for i, p := range projects {
+		if p == m.ActivityProject {
+			next = (i + 1) % len(projects)
+			break
+		}
+	}
+	m.ActivityProject = projects[next]
+	m.ActivityCursor = -1 // today, once loaded
+	return m, loadActivity(m.store, m.ActivityProject)
+}
+
+// handleActivityKeys moves the selected day on the calendar: rows are
+// weekdays, so up/down step one day and left/right one week.
+func (m Model) handleActivityKeys(key string) (tea.Model, tea.Cmd) {
+	move := func(delta int) {
+		if len(m.Activity) == 0 {
+			return
+		}
+		m.ActivityCursor = min(max(m.ActivityCursor+delta, 0), len(m.Activity)-1)
+	}
+
+	switch key {
+	case "up", "k":
+		move(-1)
+	case "down", "j":
+		move(1)
+	case "left", "h":
+		move(-7)
+	case "right", "l":
+		move(7)
+	case "enter":
+		if m.ActivityCursor >= 0 && m.ActivityCursor < len(m.Activity) {
+			return m, loadActivityDay(m.store, m.ActivityProject, m.Activity[m.ActivityCursor].Date)
+		}
+	case "p":
+		return m.cycleActivityProject()
+	case "esc", "q":
+		m.Screen = ScreenDashboard
+		m.Cursor = 0
+		return m, loadStats(m.store)
+	}
+	return m, nil
+}
+
+func (m Model) handleActivityDayKeys(key string) (tea.Model, tea.Cmd) {
+	visibleItems := (m.Height - 8) / 2 // 2 lines per observation item
+	if visibleItems < 3 {
+		visibleItems = 3
+	}
+
+	switch key {
+	case "up", "k":
+		if m.Cursor > 0 {
+			m.Cursor--
+			if m.Cursor < m.Scroll {
+				m.Scroll = m.Cursor
+			}
+		}
+	case "down", "j":
+		if m.Cursor < len(m.ActivityDayObservations)-1 {
+			m.Cursor++
+			if m.Cursor >= m.Scroll+visibleItems {
+				m.Scroll = m.Cursor - visibleItems + 1
+			}
+		}
+	case "enter":
+		if len(m.ActivityDayObservations) > 0 && m.Cursor < len(m.ActivityDayObservations) {
+			m.PrevScreen = ScreenActivityDay
+			return m, loadObservationDetail(m.store, m.ActivityDayObservations[m.Cursor].ID)
+		}
+	case "t":
+		if len(m.ActivityDayObservations) > 0 && m.Cursor < len(m.ActivityDayObservations) {
+			m.PrevScreen = ScreenActivityDay
+			return m, loadTimeline(m.store, m.ActivityDayObservations[m.Cursor].ID)
+		}
+	case "esc", "q":
+		m.Screen = ScreenActivity
+		m.Cursor = 0
+		m.Scroll = 0
+		return m, loadActivity(m.store, m.ActivityProject)
+	}
+	return m, nil
+}
+
+// ─── Topics ──────────────────────────────────────────────────────────────────
+
+func (m Model) handleTopicsKeys(key string) (tea.Model, tea.Cmd) {
+	visibleItems := m.topicsVisibleItems()
+
+	switch key {
+	case "up", "k":
+		if m.Cursor > 0 {
+			m.Cursor--
+			if m.Cursor < m.Scroll {
+				m.Scroll = m.Cursor
+			}
+		}
+	case "down", "j":
+		if m.Cursor < len(m.Topics)-1 {
+			m.Cursor++
+			if m.Cursor >= m.Scroll+visibleItems {
+				m.Scroll = m.Cursor - visibleItems + 1
+			}
+		}
+	case "enter":
+		if len(m.Topics) > 0 && m.Cursor < len(m.Topics) {
+			m.SelectedTopicIdx = m.Cursor
+			return m, loadTopicDetail(m.store, m.Topics[m.Cursor])
+		}
+	case "esc", "q":
+		m.Screen = ScreenDashboard
+		m.Cursor = 0
+		m.Scroll = 0
+		return m, loadStats(m.store)
+	}
+	return m, nil
+}
+
+// topicsVisibleItems is how many topics fit on the topics screen, leaving
+// room for the family headings.
+func (m Model) topicsVisibleItems() int {
+	return max((m.Height-8)*2/3, 5)
+}
+
+func (m Model) handleTopicDetailKeys(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up", "k":
+		if m.DetailScroll > 0 {
+			m.DetailScroll--
+		}
+	case "down", "j":
+		m.DetailScroll++
+	case "enter":
+		if m.TopicObservation != nil {
+			m.PrevScreen = ScreenTopicDetail
+			return m, loadObservationDetail(m.store, m.TopicObservation.ID)
+		}
+	case "t":
+		if m.TopicObservation != nil {
+			m.PrevScreen = ScreenTopicDetail
+			return m, loadTimeline(m.store, m.TopicObservation.ID)
+		}
+	case "y":
+		if m.TopicObservation != nil {
+			return m, copyToClipboard(m.TopicObservation.Content)
+		}
+	case "esc", "q":
+		m.Screen = ScreenTopics
+		m.Cursor = m.SelectedTopicIdx
+		m.DetailScroll = 0
+		return m, loadTopics(m.store)
+	}
+	return m, nil
+}
+
 // ─── Setup ───────────────────────────────────────────────────────────────────
 
 func (m Model) handleSetupKeys(key string) (tea.Model, tea.Cmd) {
@@ -570,9 +1140,22 @@ func (m Model) refreshScreen(screen Screen) tea.Cmd {
 	case ScreenDashboard:
 		return loadStats(m.store)
 	case ScreenRecent:
-		return loadRecentObservations(m.store)
+		return loadRecentObservations(m.store, m.Filter)
 	case ScreenSessions:
 		return loadRecentSessions(m.store)
+	case ScreenQuarantine:
+		return loadQuarantine(m.store)
+	case ScreenTrash:
+		return loadTrash(m.store)
+	case ScreenActivityDay:
+		return loadActivityDay(m.store, m.ActivityProject, m.ActivityDay)
+	case ScreenTopics:
+		return loadTopics(m.store)
+	case ScreenTopicDetail:
+		if m.Topic != nil {
+			return loadTopicDetail(m.store, *m.Topic)
+		}
+		return nil
 	default:
 		return nil
 	}
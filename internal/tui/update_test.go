@@ -2,6 +2,10 @@ package tui
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/Gentleman-Programming/engram/internal/setup"
@@ -185,6 +189,17 @@ func TestHandleDashboardAndSearchKeyPaths(t *testing.T) {
 	m.Cursor = 3
 	updatedModel, cmd = m.handleDashboardSelection()
 	updated = updatedModel.(Model)
+	if updated.Screen != ScreenQuarantine {
+		t.Fatalf("screen = %v, want %v", updated.Screen, ScreenQuarantine)
+	}
+	if cmd == nil {
+		t.Fatal("quarantine selection should load quarantined captures")
+	}
+
+	m = New(fx.store, "")
+	m.Cursor = 6
+	updatedModel, cmd = m.handleDashboardSelection()
+	updated = updatedModel.(Model)
 	if updated.Screen != ScreenSetup || len(updated.SetupAgents) == 0 {
 		t.Fatal("setup selection should initialize setup screen")
 	}
@@ -483,7 +498,7 @@ func TestHandleDashboardKeysAndSelectionRemainingBranches(t *testing.T) {
 		t.Fatal("cursor should stay at bottom boundary")
 	}
 
-	m.Cursor = 4
+	m.Cursor = 7
 	_, cmd := m.handleDashboardKeys(" ")
 	if cmd == nil {
 		t.Fatal("space on quit item should return quit command")
@@ -501,10 +516,10 @@ func TestHandleDashboardKeysAndSelectionRemainingBranches(t *testing.T) {
 		t.Fatal("cursor 0 selection should open search")
 	}
 
-	m.Cursor = 4
+	m.Cursor = 7
 	_, cmd = m.handleDashboardSelection()
 	if cmd == nil {
-		t.Fatal("cursor 4 selection should quit")
+		t.Fatal("cursor 7 selection should quit")
 	}
 
 	m.Cursor = 99
@@ -1089,3 +1104,414 @@ func TestSetupAllowlistPromptFlow(t *testing.T) {
 		}
 	})
 }
+
+func TestObservationDetailCopyFallsBackToOSC52(t *testing.T) {
+	oldClipboard, oldOSC52 := clipboardWriteFn, osc52WriteFn
+	t.Cleanup(func() { clipboardWriteFn, osc52WriteFn = oldClipboard, oldOSC52 })
+
+	var platform, terminal string
+	clipboardWriteFn = func(text string) error { platform = text; return nil }
+	osc52WriteFn = func(text string) error { terminal = text; return nil }
+
+	m := New(nil, "")
+	m.Screen = ScreenObservationDetail
+	m.SelectedObservation = &store.Observation{ID: 5, Content: "copy me"}
+
+	_, cmd := m.handleObservationDetailKeys("y")
+	if cmd == nil {
+		t.Fatal("y with selected observation should return copy command")
+	}
+	updatedModel, _ := m.Update(cmd())
+	if platform != "copy me" || terminal != "" || updatedModel.(Model).StatusMsg != "Copied 7 bytes to clipboard" {
+		t.Fatalf("expected platform clipboard copy, got platform=%q osc52=%q status=%q", platform, terminal, updatedModel.(Model).StatusMsg)
+	}
+
+	clipboardWriteFn = func(string) error { return errors.New("no xclip") }
+	updatedModel, _ = m.Update(copyToClipboard("copy me")())
+	if terminal != "copy me" || !strings.Contains(updatedModel.(Model).StatusMsg, "osc52") {
+		t.Fatalf("expected OSC52 fallback, got osc52=%q status=%q", terminal, updatedModel.(Model).StatusMsg)
+	}
+
+	osc52WriteFn = func(string) error { return errors.New("no tty") }
+	updatedModel, _ = m.Update(copyToClipboard("copy me")())
+	if !strings.Contains(updatedModel.(Model).ErrorMsg, "copy to clipboard") {
+		t.Fatalf("expected copy error, got %q", updatedModel.(Model).ErrorMsg)
+	}
+
+	// Any keypress clears the status line.
+	withStatus := m
+	withStatus.StatusMsg = "Copied"
+	cleared, _ := withStatus.handleKeyPress("down")
+	if cleared.(Model).StatusMsg != "" {
+		t.Fatal("keypress should clear status message")
+	}
+}
+
+func TestObservationDetailEditSavesChangedContent(t *testing.T) {
+	fx := newTestFixture(t)
+	obs, err := fx.store.GetObservation(fx.obsID)
+	if err != nil {
+		t.Fatalf("GetObservation: %v", err)
+	}
+
+	oldEditor, oldExec := editorCommandFn, execProcessFn
+	t.Cleanup(func() { editorCommandFn, execProcessFn = oldEditor, oldExec })
+
+	var editedPath string
+	editorCommandFn = func(path string) *exec.Cmd {
+		editedPath = path
+		return exec.Command("true")
+	}
+	// Simulate the editor run synchronously: rewrite the file, then invoke
+	// the completion callback like tea.ExecProcess would.
+	newContent := "edited content\n"
+	execProcessFn = func(c *exec.Cmd, fn tea.ExecCallback) tea.Cmd {
+		return func() tea.Msg {
+			if err := os.WriteFile(editedPath, []byte(newContent), 0o600); err != nil {
+				t.Fatalf("write edited file: %v", err)
+			}
+			return fn(nil)
+		}
+	}
+
+	m := New(fx.store, "")
+	m.Screen = ScreenObservationDetail
+	m.SelectedObservation = obs
+
+	_, cmd := m.handleObservationDetailKeys("e")
+	if cmd == nil {
+		t.Fatal("e with selected observation should return editor command")
+	}
+	updatedModel, _ := m.Update(cmd())
+	updated := updatedModel.(Model)
+	if updated.StatusMsg != "Observation updated" || updated.SelectedObservation.Content != "edited content" {
+		t.Fatalf("expected updated observation, got status=%q content=%q err=%q", updated.StatusMsg, updated.SelectedObservation.Content, updated.ErrorMsg)
+	}
+	if _, err := os.Stat(editedPath); !os.IsNotExist(err) {
+		t.Fatalf("expected temp file removed, stat err=%v", err)
+	}
+	stored, _ := fx.store.GetObservation(fx.obsID)
+	if stored.Content != "edited content" {
+		t.Fatalf("expected store content updated, got %q", stored.Content)
+	}
+
+	// Saving without changes does not touch the store.
+	newContent = "edited content\n"
+	updatedModel, _ = updated.Update(editObservation(fx.store, updated.SelectedObservation)())
+	if updatedModel.(Model).StatusMsg != "No changes" {
+		t.Fatalf("expected no-op edit, got %q", updatedModel.(Model).StatusMsg)
+	}
+
+	// Editor failures surface as errors.
+	execProcessFn = func(c *exec.Cmd, fn tea.ExecCallback) tea.Cmd {
+		return func() tea.Msg { return fn(errors.New("exit status 1")) }
+	}
+	updatedModel, _ = updated.Update(editObservation(fx.store, updated.SelectedObservation)())
+	if !strings.Contains(updatedModel.(Model).ErrorMsg, "editor") {
+		t.Fatalf("expected editor error, got %q", updatedModel.(Model).ErrorMsg)
+	}
+}
+
+func TestQuarantineScreenApprovesAndRejects(t *testing.T) {
+	fx := newTestFixture(t)
+	result, err := fx.store.PassiveCapture(store.PassiveCaptureParams{
+		SessionID: fx.sessionID,
+		Project:   "engram",
+		Content: `## Key Learnings:
+
+1. 2026-01-02 10:11:12 ERROR first noisy line from the build log
+2. and then the second fragment trailed off without an ending
+`,
+	})
+	if err != nil || result.Quarantined != 2 {
+		t.Fatalf("expected 2 quarantined captures, got %+v err=%v", result, err)
+	}
+
+	m := New(fx.store, "")
+	m.Cursor = 3
+	updatedModel, cmd := m.handleDashboardSelection()
+	updatedModel, _ = updatedModel.(Model).Update(cmd())
+	updated := updatedModel.(Model)
+	if updated.Screen != ScreenQuarantine || len(updated.Quarantine) != 2 {
+		t.Fatalf("expected quarantine screen with 2 items, got screen=%v items=%d", updated.Screen, len(updated.Quarantine))
+	}
+	if !strings.Contains(updated.View(), "awaiting review") {
+		t.Fatal("expected quarantine header")
+	}
+
+	first := updated.Quarantine[0].ID
+	updatedModel, cmd = updated.handleQuarantineKeys("a")
+	updatedModel, cmd = updatedModel.(Model).Update(cmd())
+	updated = updatedModel.(Model)
+	if updated.StatusMsg != fmt.Sprintf("Approved #%d", first) || cmd == nil {
+		t.Fatalf("expected approve status and reload, got %q", updated.StatusMsg)
+	}
+	updatedModel, _ = updated.Update(cmd())
+	updated = updatedModel.(Model)
+	if len(updated.Quarantine) != 1 {
+		t.Fatalf("expected 1 item left, got %d", len(updated.Quarantine))
+	}
+
+	second := updated.Quarantine[0].ID
+	updatedModel, cmd = updated.handleQuarantineKeys("x")
+	updatedModel, cmd = updatedModel.(Model).Update(cmd())
+	updatedModel, _ = updatedModel.(Model).Update(cmd())
+	updated = updatedModel.(Model)
+	if len(updated.Quarantine) != 0 || updated.Cursor != 0 {
+		t.Fatalf("expected empty quarantine, got %d items cursor=%d", len(updated.Quarantine), updated.Cursor)
+	}
+	if _, err := fx.store.GetObservation(second); err == nil {
+		t.Fatal("expected rejected capture to be deleted")
+	}
+
+	updatedModel, cmd = updated.handleQuarantineKeys("esc")
+	if updatedModel.(Model).Screen != ScreenDashboard || cmd == nil {
+		t.Fatal("esc should return to dashboard and reload stats")
+	}
+}
+
+func TestTrashScreenRestoresDeletedObservations(t *testing.T) {
+	fx := newTestFixture(t)
+	if err := fx.store.DeleteObservation(fx.obsID, false); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	m := New(fx.store, "")
+	m.PaletteInput.SetValue("undelete")
+	matches := m.paletteMatches()
+	if len(matches) == 0 || matches[0].action.Name != "Trash" {
+		t.Fatalf("expected undelete to find Trash, got %+v", matches)
+	}
+	updatedModel, cmd := matches[0].action.run(m)
+	updatedModel, _ = updatedModel.(Model).Update(cmd())
+	updated := updatedModel.(Model)
+	if updated.Screen != ScreenTrash || len(updated.Trash) != 1 || updated.Trash[0].ID != fx.obsID {
+		t.Fatalf("expected trash screen with #%d, got screen=%v items=%+v", fx.obsID, updated.Screen, updated.Trash)
+	}
+	if view := updated.View(); !strings.Contains(view, "1 deleted observations") || !strings.Contains(view, "deleted:") {
+		t.Fatalf("expected trash header and deletion time, got %q", view)
+	}
+
+	updatedModel, cmd = updated.handleTrashKeys("r")
+	updatedModel, cmd = updatedModel.(Model).Update(cmd())
+	updated = updatedModel.(Model)
+	if updated.StatusMsg != fmt.Sprintf("Restored #%d", fx.obsID) || cmd == nil {
+		t.Fatalf("expected restore status and reload, got %q err=%q", updated.StatusMsg, updated.ErrorMsg)
+	}
+	updatedModel, _ = updated.Update(cmd())
+	updated = updatedModel.(Model)
+	if len(updated.Trash) != 0 || !strings.Contains(updated.View(), "The trash is empty") {
+		t.Fatalf("expected an empty trash, got %d items", len(updated.Trash))
+	}
+	if _, err := fx.store.GetObservation(fx.obsID); err != nil {
+		t.Fatalf("expected restored observation to be live: %v", err)
+	}
+
+	updatedModel, cmd = updated.handleTrashKeys("esc")
+	if updatedModel.(Model).Screen != ScreenDashboard || cmd == nil {
+		t.Fatal("esc should return to dashboard and reload stats")
+	}
+}
+
+func TestActivityCalendarDrillDown(t *testing.T) {
+	fx := newTestFixture(t)
+
+	m := New(fx.store, "")
+	m.Height = 40
+	m.Cursor = 4
+	updatedModel, cmd := m.handleDashboardSelection()
+	updatedModel, _ = updatedModel.(Model).Update(cmd())
+	updated := updatedModel.(Model)
+	if updated.Screen != ScreenActivity || len(updated.Activity) != store.DefaultActivityDays {
+		t.Fatalf("expected activity screen with %d days, got screen=%v days=%d", store.DefaultActivityDays, updated.Screen, len(updated.Activity))
+	}
+	today := len(updated.Activity) - 1
+	if updated.ActivityCursor != today || updated.Activity[today].Count == 0 {
+		t.Fatalf("expected cursor on today with observations, got cursor=%d day=%+v", updated.ActivityCursor, updated.Activity[today])
+	}
+	view := updated.View()
+	for _, want := range []string{"Activity — all projects", "Mon", "less", "more"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("activity view missing %q", want)
+		}
+	}
+
+	updatedModel, _ = updated.handleActivityKeys("h")
+	if got := updatedModel.(Model).ActivityCursor; got != today-7 {
+		t.Fatalf("h should move back a week, got cursor %d", got)
+	}
+	updatedModel, _ = updatedModel.(Model).handleActivityKeys("l")
+	updatedModel, _ = updatedModel.(Model).handleActivityKeys("j")
+	if got := updatedModel.(Model).ActivityCursor; got != today {
+		t.Fatalf("cursor should stay clamped to today, got %d", got)
+	}
+
+	updatedModel, cmd = updatedModel.(Model).handleActivityKeys("enter")
+	updatedModel, _ = updatedModel.(Model).Update(cmd())
+	updated = updatedModel.(Model)
+	if updated.Screen != ScreenActivityDay || len(updated.ActivityDayObservations) == 0 {
+		t.Fatalf("expected day drill-down with observations, got screen=%v items=%d", updated.Screen, len(updated.ActivityDayObservations))
+	}
+	if !strings.Contains(updated.View(), updated.ActivityDay) {
+		t.Fatal("day view should show the selected date")
+	}
+
+	updatedModel, cmd = updated.handleActivityDayKeys("enter")
+	if updatedModel.(Model).PrevScreen != ScreenActivityDay || cmd == nil {
+		t.Fatal("enter should open the observation detail")
+	}
+
+	updatedModel, cmd = updated.handleActivityDayKeys("esc")
+	if updatedModel.(Model).Screen != ScreenActivity || cmd == nil {
+		t.Fatal("esc should return to the calendar")
+	}
+
+	updatedModel, cmd = updatedModel.(Model).handleActivityKeys("p")
+	updatedModel, _ = updatedModel.(Model).Update(cmd())
+	updated = updatedModel.(Model)
+	if updated.ActivityProject != "" {
+		t.Fatalf("without stats there is nothing to cycle, got %q", updated.ActivityProject)
+	}
+	updated.Stats = &store.Stats{Projects: []string{"engram"}}
+	updatedModel, cmd = updated.handleActivityKeys("p")
+	updatedModel, _ = updatedModel.(Model).Update(cmd())
+	if got := updatedModel.(Model); got.ActivityProject != "engram" || !strings.Contains(got.View(), "Activity — engram") {
+		t.Fatalf("p should switch to the next project, got %q", got.ActivityProject)
+	}
+}
+
+func TestTopicBrowserGroupsFamiliesAndShowsHistory(t *testing.T) {
+	fx := newTestFixture(t)
+	for _, save := range []struct{ key, title, content string }{
+		{"bug/login-loop", "Login loop", "redirect loop on expired cookies"},
+		{"architecture/auth-model", "Auth model", "sessions in Redis"},
+		{"architecture/auth-model", "Auth model v2", "JWT with refresh tokens"},
+		{"glossary", "Glossary", "terms"},
+	} {
+		if _, err := fx.store.AddObservation(store.AddObservationParams{
+			SessionID: "session-1", Type: "decision", Title: save.title, Content: save.content,
+			Project: "engram", Scope: "project", TopicKey: save.key,
+		}); err != nil {
+			t.Fatalf("save %s: %v", save.key, err)
+		}
+	}
+
+	m := New(fx.store, "")
+	m.Height = 40
+	m.Width = 100
+	m.Cursor = 5
+	updatedModel, cmd := m.handleDashboardSelection()
+	updatedModel, _ = updatedModel.(Model).Update(cmd())
+	updated := updatedModel.(Model)
+	if updated.Screen != ScreenTopics || len(updated.Topics) != 3 {
+		t.Fatalf("expected the topics screen with 3 topics, got screen=%v topics=%d", updated.Screen, len(updated.Topics))
+	}
+	var keys []string
+	for _, topic := range updated.Topics {
+		keys = append(keys, topic.TopicKey)
+	}
+	if strings.Join(keys, ",") != "architecture/auth-model,bug/login-loop,glossary" {
+		t.Fatalf("expected families in order with unfamilied keys last, got %v", keys)
+	}
+	view := updated.View()
+	for _, want := range []string{"3 topics in 3 families", "architecture/* (1)", "bug/* (1)", "other (1)", "rev 2"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("topics view missing %q:\n%s", want, view)
+		}
+	}
+
+	updatedModel, cmd = updated.handleTopicsKeys("enter")
+	updatedModel, _ = updatedModel.(Model).Update(cmd())
+	updated = updatedModel.(Model)
+	if updated.Screen != ScreenTopicDetail || updated.TopicObservation == nil || len(updated.TopicHistory) != 2 {
+		t.Fatalf("expected the topic detail with 2 recorded revisions, got screen=%v history=%+v", updated.Screen, updated.TopicHistory)
+	}
+	view = updated.View()
+	for _, want := range []string{"Topic: architecture/auth-model", "JWT with refresh tokens", "Revision history (2 recorded)", "updated", "created"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("topic detail missing %q:\n%s", want, view)
+		}
+	}
+
+	updatedModel, cmd = updated.handleTopicDetailKeys("enter")
+	if updatedModel.(Model).PrevScreen != ScreenTopicDetail || cmd == nil {
+		t.Fatal("enter should open the topic's observation")
+	}
+	updatedModel, cmd = updated.handleTopicDetailKeys("esc")
+	if updatedModel.(Model).Screen != ScreenTopics || cmd == nil {
+		t.Fatal("esc should return to the topics list")
+	}
+}
+
+func TestFilterScreenNarrowsRecentAndPersists(t *testing.T) {
+	fx := newTestFixture(t)
+	if _, err := fx.store.AddObservation(store.AddObservationParams{
+		SessionID: fx.otherSession, Type: "bugfix", Title: "Personal fix", Content: "personal bugfix", Project: "dotfiles", Scope: "personal",
+	}); err != nil {
+		t.Fatalf("add personal observation: %v", err)
+	}
+
+	m := New(fx.store, "")
+	m.Screen = ScreenRecent
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	m = next.(Model)
+	if m.Screen != ScreenFilter || m.FilterReturn != ScreenRecent || cmd == nil {
+		t.Fatalf("f should open the filter screen and reload stats, got screen %v", m.Screen)
+	}
+	next, _ = m.Update(cmd().(statsLoadedMsg))
+	m = next.(Model)
+	if types := m.filterTypes(); strings.Join(types, ",") != "bugfix,decision" {
+		t.Fatalf("expected stored types as choices, got %v", types)
+	}
+
+	// Toggle bugfix (first row), then move to the scope row and pick "project".
+	press := func(keys ...string) {
+		for _, k := range keys {
+			next, cmd = m.handleKeyPress(k)
+			m = next.(Model)
+		}
+	}
+	press(" ", "j", "j", "j", "l")
+	if !m.Filter.HasType("bugfix") || m.Filter.Scope != "project" || m.Filter.Project != "" {
+		t.Fatalf("unexpected filter: %+v", m.Filter)
+	}
+
+	press("f")
+	if m.Screen != ScreenRecent || cmd == nil {
+		t.Fatalf("f should apply and return to recent, got screen %v", m.Screen)
+	}
+	next, _ = m.Update(cmd())
+	m = next.(Model)
+	if len(m.RecentObservations) != 1 || m.RecentObservations[0].ID != fx.obsID {
+		t.Fatalf("expected only the project-scoped bugfix, got %+v", m.RecentObservations)
+	}
+	if view := m.View(); !strings.Contains(view, "filter: bugfix • scope=project") {
+		t.Fatalf("expected the active filter in the header, got %q", view)
+	}
+
+	// The filter survives leaving the screen and applies to searches too.
+	press("esc")
+	m.Screen = ScreenSearch
+	m.SearchInput.Focus()
+	m.SearchInput.SetValue("bugfix")
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	next, _ = m.Update(cmd())
+	m = next.(Model)
+	if len(m.SearchResults) != 1 || m.SearchResults[0].ID != fx.obsID {
+		t.Fatalf("expected the personal bugfix to stay filtered out, got %+v", m.SearchResults)
+	}
+
+	// Clearing on the filter screen re-runs the search unfiltered.
+	m.Screen = ScreenSearchResults
+	press("f")
+	press("c", "esc")
+	if m.Filter.Active() || cmd == nil {
+		t.Fatalf("expected a cleared filter, got %+v", m.Filter)
+	}
+	next, _ = m.Update(cmd())
+	m = next.(Model)
+	if len(m.SearchResults) != 2 || m.Screen != ScreenSearchResults {
+		t.Fatalf("expected both bugfix observations after clearing, got %d on %v", len(m.SearchResults), m.Screen)
+	}
+}
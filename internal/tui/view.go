@@ -5,6 +5,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Gentleman-Programming/engram/internal/i18n"
+	"github.com/Gentleman-Programming/engram/internal/store"
 	"github.com/Gentleman-Programming/engram/internal/version"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -79,13 +81,33 @@ func (m Model) View() string {
 		content = m.viewSessionDetail()
 	case ScreenSetup:
 		content = m.viewSetup()
+	case ScreenQuarantine:
+		content = m.viewQuarantine()
+	case ScreenTrash:
+		content = m.viewTrash()
+	case ScreenActivity:
+		content = m.viewActivity()
+	case ScreenActivityDay:
+		content = m.viewActivityDay()
+	case ScreenFilter:
+		content = m.viewFilter()
+	case ScreenPalette:
+		content = m.viewPalette()
+	case ScreenTopics:
+		content = m.viewTopics()
+	case ScreenTopicDetail:
+		content = m.viewTopicDetail()
+	case ScreenProfiles:
+		content = m.viewProfiles()
 	default:
-		content = "Unknown screen"
+		content = i18n.T("Unknown screen")
 	}
 
 	// Show error if present
 	if m.ErrorMsg != "" {
-		content += "\n" + errorStyle.Render("Error: "+m.ErrorMsg)
+		content += "\n" + errorStyle.Render(i18n.T("Error: ")+m.ErrorMsg)
+	} else if m.StatusMsg != "" {
+		content += "\n" + statusStyle.Render(m.StatusMsg)
 	}
 
 	return appStyle.Render(content)
@@ -115,19 +137,23 @@ func (m Model) viewDashboard() string {
 		statsContent := fmt.Sprintf(
 			"%s %s\n%s %s\n%s %s\n%s %s",
 			statNumberStyle.Render(fmt.Sprintf("%d", m.Stats.TotalSessions)),
-			statLabelStyle.Render("sessions"),
+			statLabelStyle.Render(i18n.T("sessions")),
 			statNumberStyle.Render(fmt.Sprintf("%d", m.Stats.TotalObservations)),
-			statLabelStyle.Render("observations"),
+			statLabelStyle.Render(i18n.T("observations")),
 			statNumberStyle.Render(fmt.Sprintf("%d", m.Stats.TotalPrompts)),
-			statLabelStyle.Render("prompts"),
+			statLabelStyle.Render(i18n.T("prompts")),
 			statNumberStyle.Render(fmt.Sprintf("%d", len(m.Stats.Projects))),
-			statLabelStyle.Render("projects"),
+			statLabelStyle.Render(i18n.T("projects")),
 		)
 		b.WriteString(statCardStyle.Render(statsContent))
 		b.WriteString("\n")
+		if len(m.Profiles) > 0 {
+			b.WriteString(timestampStyle.Render(i18n.Tf("  Profile: %s", m.Profiles[m.CurrentProfile].Name)))
+			b.WriteString("\n")
+		}
 
 		if len(m.Stats.Projects) > 0 {
-			b.WriteString(titleStyle.Render("  Projects"))
+			b.WriteString(titleStyle.Render(i18n.T("  Projects")))
 			b.WriteString("\n")
 
 			limit := 5
@@ -141,30 +167,38 @@ func (m Model) viewDashboard() string {
 
 			if len(m.Stats.Projects) > limit {
 				remaining := len(m.Stats.Projects) - limit
-				b.WriteString(fmt.Sprintf("    %s\n", timestampStyle.Render(fmt.Sprintf("...and %d more projects", remaining))))
+				b.WriteString(fmt.Sprintf("    %s\n", timestampStyle.Render(i18n.Tf("...and %d more projects", remaining))))
 			}
 			b.WriteString("\n")
 		}
 	} else {
-		b.WriteString(statCardStyle.Render("Loading stats..."))
+		b.WriteString(statCardStyle.Render(i18n.T("Loading stats...")))
+		b.WriteString("\n")
+	}
+
+	// Activity heatmap: the last 12 weeks of the selected project
+	if len(m.Activity) > 0 {
+		b.WriteString(titleStyle.Render(i18n.Tf("  Activity — %s (last 12 weeks)", m.activityProjectLabel())))
+		b.WriteString("\n")
+		b.WriteString(renderHeatmap(m.Activity[max(len(m.Activity)-dashboardHeatmapDays, 0):], -1))
 		b.WriteString("\n")
 	}
 
 	// Menu
-	b.WriteString(titleStyle.Render("  Actions"))
+	b.WriteString(titleStyle.Render(i18n.T("  Actions")))
 	b.WriteString("\n")
 
 	for i, item := range dashboardMenuItems {
 		if i == m.Cursor {
-			b.WriteString(menuSelectedStyle.Render("▸ " + item))
+			b.WriteString(menuSelectedStyle.Render("▸ " + i18n.T(item)))
 		} else {
-			b.WriteString(menuItemStyle.Render("  " + item))
+			b.WriteString(menuItemStyle.Render("  " + i18n.T(item)))
 		}
 		b.WriteString("\n")
 	}
 
 	// Help
-	b.WriteString(helpStyle.Render("\n  j/k navigate • enter select • s search • q quit"))
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k navigate • enter select • s search • p activity project • P profiles • ctrl+p commands • q quit")))
 
 	return b.String()
 }
@@ -174,13 +208,13 @@ func (m Model) viewDashboard() string {
 func (m Model) viewSearch() string {
 	var b strings.Builder
 
-	b.WriteString(headerStyle.Render("  Search Memories"))
+	b.WriteString(headerStyle.Render(i18n.T("  Search Memories")))
 	b.WriteString("\n\n")
 
 	b.WriteString(searchInputStyle.Render(m.SearchInput.View()))
 	b.WriteString("\n\n")
 
-	b.WriteString(helpStyle.Render("  Type a query and press enter • esc go back"))
+	b.WriteString(helpStyle.Render(i18n.T("  Type a query and press enter • esc go back")))
 
 	return b.String()
 }
@@ -191,17 +225,18 @@ func (m Model) viewSearchResults() string {
 	var b strings.Builder
 
 	resultCount := len(m.SearchResults)
-	header := fmt.Sprintf("  Search: %q — %d result", m.SearchQuery, resultCount)
-	if resultCount != 1 {
-		header += "s"
+	header := i18n.Tf("  Search: %q — %d results", m.SearchQuery, resultCount)
+	if resultCount == 1 {
+		header = i18n.Tf("  Search: %q — %d result", m.SearchQuery, resultCount)
 	}
 	b.WriteString(headerStyle.Render(header))
 	b.WriteString("\n")
+	b.WriteString(m.renderFilterLine())
 
 	if resultCount == 0 {
-		b.WriteString(noResultsStyle.Render("No memories found. Try a different query."))
+		b.WriteString(noResultsStyle.Render(i18n.T("No memories found. Try a different query.")))
 		b.WriteString("\n\n")
-		b.WriteString(helpStyle.Render("  / new search • esc back"))
+		b.WriteString(helpStyle.Render(i18n.T("  / new search • f filter • esc back")))
 		return b.String()
 	}
 
@@ -223,10 +258,10 @@ func (m Model) viewSearchResults() string {
 	// Scroll indicator
 	if resultCount > visibleItems {
 		b.WriteString(fmt.Sprintf("\n  %s",
-			timestampStyle.Render(fmt.Sprintf("showing %d-%d of %d", m.Scroll+1, end, resultCount))))
+			timestampStyle.Render(i18n.Tf("showing %d-%d of %d", m.Scroll+1, end, resultCount))))
 	}
 
-	b.WriteString(helpStyle.Render("\n  j/k navigate • enter detail • t timeline • / search • esc back"))
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k navigate • enter detail • t timeline • / search • f filter • esc back")))
 
 	return b.String()
 }
@@ -237,14 +272,19 @@ func (m Model) viewRecent() string {
 	var b strings.Builder
 
 	count := len(m.RecentObservations)
-	header := fmt.Sprintf("  Recent Observations — %d total", count)
+	header := i18n.Tf("  Recent Observations — %d total", count)
 	b.WriteString(headerStyle.Render(header))
 	b.WriteString("\n")
+	b.WriteString(m.renderFilterLine())
 
 	if count == 0 {
-		b.WriteString(noResultsStyle.Render("No observations yet."))
+		empty := i18n.T("No observations yet.")
+		if m.Filter.Active() {
+			empty = i18n.T("No observations match the filter.")
+		}
+		b.WriteString(noResultsStyle.Render(empty))
 		b.WriteString("\n\n")
-		b.WriteString(helpStyle.Render("  esc back"))
+		b.WriteString(helpStyle.Render(i18n.T("  f filter • esc back")))
 		return b.String()
 	}
 
@@ -265,10 +305,503 @@ func (m Model) viewRecent() string {
 
 	if count > visibleItems {
 		b.WriteString(fmt.Sprintf("\n  %s",
-			timestampStyle.Render(fmt.Sprintf("showing %d-%d of %d", m.Scroll+1, end, count))))
+			timestampStyle.Render(i18n.Tf("showing %d-%d of %d", m.Scroll+1, end, count))))
+	}
+
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k navigate • enter detail • t timeline • f filter • esc back")))
+
+	return b.String()
+}
+
+// renderFilterLine shows the active list filter under a list header, or
+// nothing when no filter is set.
+func (m Model) renderFilterLine() string {
+	if !m.Filter.Active() {
+		return ""
+	}
+	return "  " + projectStyle.Render(i18n.T("filter:")+" "+m.Filter.String()) + "\n\n"
+}
+
+// ─── Filter ──────────────────────────────────────────────────────────────────
+
+func (m Model) viewFilter() string {
+	var b strings.Builder
+
+	target := i18n.T("Recent observations")
+	if m.FilterReturn == ScreenSearchResults {
+		target = i18n.Tf("Search: %q", m.SearchQuery)
+	}
+	b.WriteString(headerStyle.Render(i18n.Tf("  Filter — %s", target)))
+	b.WriteString("\n")
+
+	types := m.filterTypes()
+	cursor := min(m.FilterCursor, len(types)+1)
+	row := func(i int, text string) {
+		if i == cursor {
+			b.WriteString(listSelectedStyle.Render("▸ " + text))
+		} else {
+			b.WriteString(listItemStyle.Render(text))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(titleStyle.Render(i18n.T("  Types")))
+	b.WriteString("\n")
+	if len(types) == 0 {
+		b.WriteString(noResultsStyle.Render(i18n.T("No observations yet.")))
+		b.WriteString("\n")
+	}
+	for i, typ := range types {
+		mark := "[ ]"
+		if m.Filter.HasType(typ) {
+			mark = "[x]"
+		}
+		label := mark + " " + typeBadgeStyle.Render(typ)
+		if m.Stats != nil && m.Stats.ObservationsByType[typ] > 0 {
+			label += " " + timestampStyle.Render(fmt.Sprintf("(%d)", m.Stats.ObservationsByType[typ]))
+		}
+		row(i, label)
+	}
+	b.WriteString("\n")
+
+	project := m.Filter.Project
+	if project == "" {
+		project = i18n.T("all projects")
+	}
+	scope := m.Filter.Scope
+	if scope == "" {
+		scope = i18n.T("any")
+	}
+	row(len(types), i18n.T("Project:")+" ‹ "+projectStyle.Render(project)+" ›")
+	row(len(types)+1, i18n.T("Scope:")+" ‹ "+projectStyle.Render(scope)+" ›")
+
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k navigate • space toggle type • h/l change project/scope • c clear • f/esc apply")))
+	return b.String()
+}
+
+// ─── Activity ────────────────────────────────────────────────────────────────
+
+// dashboardHeatmapDays is how much of the histogram the dashboard shows.
+const dashboardHeatmapDays = 12 * 7
+
+func (m Model) activityProjectLabel() string {
+	if m.ActivityProject == "" {
+		return i18n.T("all projects")
+	}
+	return m.ActivityProject
+}
+
+// renderHeatmap lays days out GitHub-style: one column per week, one row
+// per weekday (Monday first), shaded by count relative to the busiest day.
+// The day at index cursor is highlighted; pass -1 for none.
+func renderHeatmap(days []store.ActivityDay, cursor int) string {
+	if len(days) == 0 {
+		return ""
+	}
+	first, err := time.Parse("2006-01-02", days[0].Date)
+	if err != nil {
+		return ""
+	}
+	offset := (int(first.Weekday()) + 6) % 7 // Monday = 0
+	weeks := (offset + len(days) + 6) / 7
+
+	busiest := 0
+	for _, d := range days {
+		busiest = max(busiest, d.Count)
+	}
+
+	grid := make([][]string, 7)
+	for row := range grid {
+		grid[row] = make([]string, weeks)
+		for col := range grid[row] {
+			grid[row][col] = "  "
+		}
+	}
+	months := make([]string, weeks)
+	for i, d := range days {
+		pos := offset + i
+		row, col := pos%7, pos/7
+
+		cell := "■"
+		style := heatmapLevelStyles[0]
+		if d.Count > 0 {
+			level := (d.Count*(len(heatmapLevelStyles)-1) + busiest - 1) / busiest
+			style = heatmapLevelStyles[level]
+		} else {
+			cell = "·"
+		}
+		if i == cursor {
+			cell, style = "◆", heatmapCursorStyle
+		}
+		grid[row][col] = style.Render(cell) + " "
+
+		if date, err := time.Parse("2006-01-02", d.Date); err == nil && (i == 0 || date.Day() == 1) && months[col] == "" {
+			months[col] = date.Format("Jan")
+		}
+	}
+
+	var b strings.Builder
+
+	// Month labels, skipped when they would overlap the previous one
+	header := []rune(strings.Repeat(" ", weeks*2+1))
+	lastEnd := -1
+	for col, label := range months {
+		if label == "" || col*2 <= lastEnd {
+			continue
+		}
+		for i, r := range label {
+			if col*2+i < len(header) {
+				header[col*2+i] = r
+			}
+		}
+		lastEnd = col*2 + len(label)
+	}
+	b.WriteString("      " + heatmapLabelStyle.Render(strings.TrimRight(string(header), " ")) + "\n")
+
+	weekdays := []string{"Mon", "", "Wed", "", "Fri", "", "Sun"}
+	for row := range grid {
+		b.WriteString("  " + heatmapLabelStyle.Render(fmt.Sprintf("%-3s", weekdays[row])) + " ")
+		b.WriteString(strings.Join(grid[row], ""))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("      " + heatmapLabelStyle.Render("less "))
+	for i, style := range heatmapLevelStyles {
+		if i == 0 {
+			b.WriteString(style.Render("·") + " ")
+		} else {
+			b.WriteString(style.Render("■") + " ")
+		}
+	}
+	b.WriteString(heatmapLabelStyle.Render("more"))
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (m Model) viewActivity() string {
+	var b strings.Builder
+
+	total := 0
+	for _, d := range m.Activity {
+		total += d.Count
+	}
+	header := i18n.Tf("  Activity — %s — %d observations in %d days", m.activityProjectLabel(), total, len(m.Activity))
+	b.WriteString(headerStyle.Render(header))
+	b.WriteString("\n")
+
+	if len(m.Activity) == 0 {
+		b.WriteString(noResultsStyle.Render(i18n.T("Loading activity...")))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(i18n.T("  esc back")))
+		return b.String()
+	}
+
+	b.WriteString(renderHeatmap(m.Activity, m.ActivityCursor))
+	b.WriteString("\n")
+
+	if m.ActivityCursor >= 0 && m.ActivityCursor < len(m.Activity) {
+		day := m.Activity[m.ActivityCursor]
+		label := day.Date
+		if date, err := time.Parse("2006-01-02", day.Date); err == nil {
+			label = date.Format("Mon, Jan 2 2006")
+		}
+		count := i18n.Tf("%d observations", day.Count)
+		if day.Count == 1 {
+			count = i18n.T("1 observation")
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s\n",
+			detailValueStyle.Render(label),
+			statNumberStyle.Width(0).Render(count)))
+	}
+
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k day • h/l week • enter day's observations • p project • esc back")))
+
+	return b.String()
+}
+
+func (m Model) viewActivityDay() string {
+	var b strings.Builder
+
+	count := len(m.ActivityDayObservations)
+	header := i18n.Tf("  %s — %s — %d observations", m.ActivityDay, m.activityProjectLabel(), count)
+	b.WriteString(headerStyle.Render(header))
+	b.WriteString("\n")
+
+	if count == 0 {
+		b.WriteString(noResultsStyle.Render(i18n.T("No observations on this day.")))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(i18n.T("  esc back")))
+		return b.String()
+	}
+
+	visibleItems := (m.Height - 8) / 2 // 2 lines per observation item
+	if visibleItems < 3 {
+		visibleItems = 3
+	}
+
+	end := min(m.Scroll+visibleItems, count)
+	for i := m.Scroll; i < end; i++ {
+		o := m.ActivityDayObservations[i]
+		b.WriteString(m.renderObservationListItem(i, o.ID, o.Type, o.Title, o.Content, o.CreatedAt, o.Project))
+	}
+
+	if count > visibleItems {
+		b.WriteString(fmt.Sprintf("\n  %s",
+			timestampStyle.Render(i18n.Tf("showing %d-%d of %d", m.Scroll+1, end, count))))
+	}
+
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k navigate • enter detail • t timeline • esc back")))
+
+	return b.String()
+}
+
+// ─── Quarantine ──────────────────────────────────────────────────────────────
+
+func (m Model) viewQuarantine() string {
+	var b strings.Builder
+
+	count := len(m.Quarantine)
+	header := i18n.Tf("  Quarantine — %d awaiting review", count)
+	b.WriteString(headerStyle.Render(header))
+	b.WriteString("\n")
+
+	if count == 0 {
+		b.WriteString(noResultsStyle.Render(i18n.T("Nothing in quarantine. Low-confidence passive captures land here.")))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(i18n.T("  esc back")))
+		return b.String()
+	}
+
+	visibleItems := (m.Height - 8) / 3 // 3 lines per quarantined item
+	if visibleItems < 3 {
+		visibleItems = 3
+	}
+
+	end := m.Scroll + visibleItems
+	if end > count {
+		end = count
+	}
+
+	for i := m.Scroll; i < end; i++ {
+		q := m.Quarantine[i]
+		b.WriteString(m.renderObservationListItem(i, q.ID, q.Type, q.Title, q.Content, q.CreatedAt, q.Project))
+		b.WriteString(timestampStyle.Render("      " + i18n.T("reason:") + " " + q.Reason))
+		b.WriteString("\n")
+	}
+
+	if count > visibleItems {
+		b.WriteString(fmt.Sprintf("\n  %s",
+			timestampStyle.Render(i18n.Tf("showing %d-%d of %d", m.Scroll+1, end, count))))
+	}
+
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k navigate • enter detail • a approve • x reject (delete) • esc back")))
+
+	return b.String()
+}
+
+// ─── Trash ───────────────────────────────────────────────────────────────────
+
+func (m Model) viewTrash() string {
+	var b strings.Builder
+
+	count := len(m.Trash)
+	b.WriteString(headerStyle.Render(i18n.Tf("  Trash — %d deleted observations", count)))
+	b.WriteString("\n")
+
+	if count == 0 {
+		b.WriteString(noResultsStyle.Render(i18n.T("The trash is empty. Soft-deleted observations land here.")))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(i18n.T("  esc back")))
+		return b.String()
+	}
+
+	visibleItems := (m.Height - 8) / 3 // 3 lines per deleted item
+	if visibleItems < 3 {
+		visibleItems = 3
+	}
+
+	end := m.Scroll + visibleItems
+	if end > count {
+		end = count
+	}
+
+	for i := m.Scroll; i < end; i++ {
+		o := m.Trash[i]
+		b.WriteString(m.renderObservationListItem(i, o.ID, o.Type, o.Title, o.Content, o.CreatedAt, o.Project))
+		if o.DeletedAt != nil {
+			b.WriteString(timestampStyle.Render("      " + i18n.T("deleted:") + " " + *o.DeletedAt))
+		}
+		b.WriteString("\n")
+	}
+
+	if count > visibleItems {
+		b.WriteString(fmt.Sprintf("\n  %s",
+			timestampStyle.Render(i18n.Tf("showing %d-%d of %d", m.Scroll+1, end, count))))
+	}
+
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k navigate • r restore • esc back")))
+
+	return b.String()
+}
+
+// ─── Topics ──────────────────────────────────────────────────────────────────
+
+// topicFamilyLabel is the heading of a topic family.
+func topicFamilyLabel(family string) string {
+	if family == "" {
+		return i18n.T("other")
+	}
+	return family + "/*"
+}
+
+func (m Model) viewTopics() string {
+	var b strings.Builder
+
+	count := len(m.Topics)
+	families := map[string]int{}
+	for _, t := range m.Topics {
+		families[topicFamily(t.TopicKey)]++
+	}
+	b.WriteString(headerStyle.Render(i18n.Tf("  Topics — %d topics in %d families", count, len(families))))
+	b.WriteString("\n")
+
+	if count == 0 {
+		b.WriteString(noResultsStyle.Render(i18n.T("No topics yet. Saves with a topic_key show up here.")))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(i18n.T("  esc back")))
+		return b.String()
+	}
+
+	visibleItems := m.topicsVisibleItems()
+	end := min(m.Scroll+visibleItems, count)
+	for i := m.Scroll; i < end; i++ {
+		t := m.Topics[i]
+		family := topicFamily(t.TopicKey)
+		if i == m.Scroll || family != topicFamily(m.Topics[i-1].TopicKey) {
+			b.WriteString(sectionHeadingStyle.Render(fmt.Sprintf("  %s (%d)", topicFamilyLabel(family), families[family])))
+			b.WriteString("\n")
+		}
+
+		cursor := "  "
+		style := listItemStyle
+		if i == m.Cursor {
+			cursor = "▸ "
+			style = listSelectedStyle
+		}
+		proj := ""
+		if t.Project != nil {
+			proj = "  " + projectStyle.Render(*t.Project)
+		}
+		b.WriteString(fmt.Sprintf("%s%s %s %s%s  %s\n",
+			cursor,
+			style.Render(fmt.Sprintf("%-32s", truncateStr(t.TopicKey, 32))),
+			typeBadgeStyle.Render(fmt.Sprintf("[%-12s]", t.LatestType)),
+			statNumberStyle.Width(0).Render(i18n.Tf("rev %d", t.RevisionCount)),
+			proj,
+			timestampStyle.Render(m.localTime(t.UpdatedAt))))
+	}
+
+	if count > visibleItems {
+		b.WriteString(fmt.Sprintf("\n  %s",
+			timestampStyle.Render(i18n.Tf("showing %d-%d of %d", m.Scroll+1, end, count))))
 	}
 
-	b.WriteString(helpStyle.Render("\n  j/k navigate • enter detail • t timeline • esc back"))
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k navigate • enter content and history • esc back")))
+
+	return b.String()
+}
+
+// topicRevisionLabels name the event kinds in the revision history.
+var topicRevisionLabels = map[string]string{
+	store.EventObservationCreated: "created",
+	store.EventObservationUpdated: "updated",
+	store.EventObservationDeleted: "deleted",
+}
+
+// topicHistoryShown caps the revision history listed under the content.
+const topicHistoryShown = 10
+
+func (m Model) viewTopicDetail() string {
+	var b strings.Builder
+
+	if m.Topic == nil || m.TopicObservation == nil {
+		b.WriteString(headerStyle.Render(i18n.T("  Topic")))
+		b.WriteString("\n")
+		b.WriteString(noResultsStyle.Render(i18n.T("Loading...")))
+		return b.String()
+	}
+
+	t, obs := m.Topic, m.TopicObservation
+	b.WriteString(headerStyle.Render(i18n.Tf("  Topic: %s", t.TopicKey)))
+	b.WriteString("\n")
+
+	b.WriteString(fmt.Sprintf("%s %s\n",
+		detailLabelStyle.Render(i18n.T("Title:")),
+		detailValueStyle.Bold(true).Render(obs.Title)))
+	b.WriteString(fmt.Sprintf("%s %s  %s\n",
+		detailLabelStyle.Render(i18n.T("Type:")),
+		typeBadgeStyle.Render(obs.Type),
+		idStyle.Render(fmt.Sprintf("#%d", obs.ID))))
+	if t.Project != nil {
+		b.WriteString(fmt.Sprintf("%s %s\n",
+			detailLabelStyle.Render(i18n.T("Project:")),
+			projectStyle.Render(*t.Project)))
+	}
+	b.WriteString(fmt.Sprintf("%s %s\n",
+		detailLabelStyle.Render(i18n.T("Scope:")),
+		detailValueStyle.Render(t.Scope)))
+	b.WriteString(fmt.Sprintf("%s %s\n",
+		detailLabelStyle.Render(i18n.T("Revisions:")),
+		statNumberStyle.Width(0).Render(fmt.Sprintf("%d", t.RevisionCount))))
+	b.WriteString(fmt.Sprintf("%s %s\n",
+		detailLabelStyle.Render(i18n.T("Updated:")),
+		timestampStyle.Render(m.localTime(obs.UpdatedAt))))
+
+	// Current content, scrolled with j/k
+	b.WriteString("\n")
+	b.WriteString(sectionHeadingStyle.Render(i18n.T("  Current content")))
+	b.WriteString("\n")
+
+	wrapWidth := max(m.Width-6, 20)
+	contentLines := strings.Split(detailContentStyle.Width(wrapWidth).Render(obs.Content), "\n")
+	maxLines := max(m.Height-22-min(len(m.TopicHistory), topicHistoryShown), 5)
+	scroll := min(m.DetailScroll, max(len(contentLines)-maxLines, 0))
+	end := min(scroll+maxLines, len(contentLines))
+	for i := scroll; i < end; i++ {
+		b.WriteString(contentLines[i])
+		b.WriteString("\n")
+	}
+	if len(contentLines) > maxLines {
+		b.WriteString(fmt.Sprintf("  %s\n",
+			timestampStyle.Render(i18n.Tf("line %d-%d of %d", scroll+1, end, len(contentLines)))))
+	}
+
+	// Revision history, newest first
+	b.WriteString("\n")
+	b.WriteString(sectionHeadingStyle.Render(i18n.Tf("  Revision history (%d recorded)", len(m.TopicHistory))))
+	b.WriteString("\n")
+	for i, r := range m.TopicHistory {
+		if i == topicHistoryShown {
+			b.WriteString(timestampStyle.Render(i18n.Tf("    ...and %d older", len(m.TopicHistory)-topicHistoryShown)))
+			b.WriteString("\n")
+			break
+		}
+		kind := topicRevisionLabels[r.Kind]
+		if kind == "" {
+			kind = r.Kind
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s %s %s\n",
+			timestampStyle.Render(m.localTime(r.At)),
+			typeBadgeStyle.Render(fmt.Sprintf("%-8s", i18n.T(kind))),
+			idStyle.Render(fmt.Sprintf("#%d", r.ObservationID)),
+			listItemStyle.Render(truncateStr(r.Title, 60))))
+	}
+	if len(m.TopicHistory) < t.RevisionCount {
+		b.WriteString(timestampStyle.Render("  " + i18n.T("Earlier revisions were saved before engram recorded change events.")))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k scroll • enter observation • t timeline • y copy • esc back")))
 
 	return b.String()
 }
@@ -279,50 +812,56 @@ func (m Model) viewObservationDetail() string {
 	var b strings.Builder
 
 	if m.SelectedObservation == nil {
-		b.WriteString(headerStyle.Render("  Observation Detail"))
+		b.WriteString(headerStyle.Render(i18n.T("  Observation Detail")))
 		b.WriteString("\n")
-		b.WriteString(noResultsStyle.Render("Loading..."))
+		b.WriteString(noResultsStyle.Render(i18n.T("Loading...")))
 		return b.String()
 	}
 
 	obs := m.SelectedObservation
 
-	header := fmt.Sprintf("  Observation #%d", obs.ID)
+	header := i18n.Tf("  Observation #%d", obs.ID)
 	b.WriteString(headerStyle.Render(header))
 	b.WriteString("\n")
 
 	// Metadata rows
 	b.WriteString(fmt.Sprintf("%s %s\n",
-		detailLabelStyle.Render("Type:"),
+		detailLabelStyle.Render(i18n.T("Type:")),
 		typeBadgeStyle.Render(obs.Type)))
 
 	b.WriteString(fmt.Sprintf("%s %s\n",
-		detailLabelStyle.Render("Title:"),
+		detailLabelStyle.Render(i18n.T("Title:")),
 		detailValueStyle.Bold(true).Render(obs.Title)))
 
 	b.WriteString(fmt.Sprintf("%s %s\n",
-		detailLabelStyle.Render("Session:"),
+		detailLabelStyle.Render(i18n.T("Session:")),
 		idStyle.Render(obs.SessionID)))
 
 	b.WriteString(fmt.Sprintf("%s %s\n",
-		detailLabelStyle.Render("Created:"),
-		timestampStyle.Render(localTime(obs.CreatedAt))))
+		detailLabelStyle.Render(i18n.T("Created:")),
+		timestampStyle.Render(m.localTime(obs.CreatedAt))))
 
 	if obs.ToolName != nil {
 		b.WriteString(fmt.Sprintf("%s %s\n",
-			detailLabelStyle.Render("Tool:"),
+			detailLabelStyle.Render(i18n.T("Tool:")),
 			detailValueStyle.Render(*obs.ToolName)))
 	}
 
+	if obs.Source != nil {
+		b.WriteString(fmt.Sprintf("%s %s\n",
+			detailLabelStyle.Render(i18n.T("Source:")),
+			detailValueStyle.Render(*obs.Source)))
+	}
+
 	if obs.Project != nil {
 		b.WriteString(fmt.Sprintf("%s %s\n",
-			detailLabelStyle.Render("Project:"),
+			detailLabelStyle.Render(i18n.T("Project:")),
 			projectStyle.Render(*obs.Project)))
 	}
 
 	// Content section
 	b.WriteString("\n")
-	b.WriteString(sectionHeadingStyle.Render("  Content"))
+	b.WriteString(sectionHeadingStyle.Render(i18n.T("  Content")))
 	b.WriteString("\n")
 
 	// Wrap content based on terminal width
@@ -360,10 +899,10 @@ func (m Model) viewObservationDetail() string {
 
 	if len(contentLines) > maxLines {
 		b.WriteString(fmt.Sprintf("\n  %s",
-			timestampStyle.Render(fmt.Sprintf("line %d-%d of %d", m.DetailScroll+1, end, len(contentLines)))))
+			timestampStyle.Render(i18n.Tf("line %d-%d of %d", m.DetailScroll+1, end, len(contentLines)))))
 	}
 
-	b.WriteString(helpStyle.Render("\n  j/k scroll • t timeline • esc back"))
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k scroll • t timeline • y copy • e edit • esc back")))
 
 	return b.String()
 }
@@ -374,29 +913,35 @@ func (m Model) viewTimeline() string {
 	var b strings.Builder
 
 	if m.Timeline == nil {
-		b.WriteString(headerStyle.Render("  Timeline"))
+		b.WriteString(headerStyle.Render(i18n.T("  Timeline")))
 		b.WriteString("\n")
-		b.WriteString(noResultsStyle.Render("Loading..."))
+		b.WriteString(noResultsStyle.Render(i18n.T("Loading...")))
 		return b.String()
 	}
 
 	tl := m.Timeline
-	header := fmt.Sprintf("  Timeline — Observation #%d (%d total in session)", tl.Focus.ID, tl.TotalInRange)
+	header := i18n.Tf("  Timeline — Observation #%d (%d total in session)", tl.Focus.ID, tl.TotalInRange)
 	b.WriteString(headerStyle.Render(header))
 	b.WriteString("\n")
 
 	// Session info
 	if tl.SessionInfo != nil {
-		b.WriteString(fmt.Sprintf("  %s %s  %s %s\n\n",
-			detailLabelStyle.Render("Session:"),
+		b.WriteString(fmt.Sprintf("  %s %s  %s %s\n",
+			detailLabelStyle.Render(i18n.T("Session:")),
 			idStyle.Render(tl.SessionInfo.ID),
-			detailLabelStyle.Render("Project:"),
+			detailLabelStyle.Render(i18n.T("Project:")),
 			projectStyle.Render(tl.SessionInfo.Project)))
+		if len(tl.SessionChain) > 0 {
+			b.WriteString(fmt.Sprintf("  %s %s\n",
+				detailLabelStyle.Render(i18n.T("Continues:")),
+				idStyle.Render(store.FormatSessionChain(tl.SessionChain))))
+		}
+		b.WriteString("\n")
 	}
 
 	// Before entries
 	if len(tl.Before) > 0 {
-		b.WriteString(sectionHeadingStyle.Render("  Before"))
+		b.WriteString(sectionHeadingStyle.Render(i18n.T("  Before")))
 		b.WriteString("\n")
 		for _, e := range tl.Before {
 			b.WriteString(fmt.Sprintf("  %s %s %s  %s\n",
@@ -420,7 +965,7 @@ func (m Model) viewTimeline() string {
 	// After entries
 	if len(tl.After) > 0 {
 		b.WriteString(fmt.Sprintf("  %s\n", timelineConnectorStyle.Render("│")))
-		b.WriteString(sectionHeadingStyle.Render("  After"))
+		b.WriteString(sectionHeadingStyle.Render(i18n.T("  After")))
 		b.WriteString("\n")
 		for _, e := range tl.After {
 			b.WriteString(fmt.Sprintf("  %s %s %s  %s\n",
@@ -431,7 +976,7 @@ func (m Model) viewTimeline() string {
 		}
 	}
 
-	b.WriteString(helpStyle.Render("\n  j/k scroll • esc back"))
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k scroll • esc back")))
 
 	return b.String()
 }
@@ -442,14 +987,14 @@ func (m Model) viewSessions() string {
 	var b strings.Builder
 
 	count := len(m.Sessions)
-	header := fmt.Sprintf("  Sessions — %d total", count)
+	header := i18n.Tf("  Sessions — %d total", count)
 	b.WriteString(headerStyle.Render(header))
 	b.WriteString("\n")
 
 	if count == 0 {
-		b.WriteString(noResultsStyle.Render("No sessions yet."))
+		b.WriteString(noResultsStyle.Render(i18n.T("No sessions yet.")))
 		b.WriteString("\n\n")
-		b.WriteString(helpStyle.Render("  esc back"))
+		b.WriteString(helpStyle.Render(i18n.T("  esc back")))
 		return b.String()
 	}
 
@@ -480,7 +1025,7 @@ func (m Model) viewSessions() string {
 		line := fmt.Sprintf("%s%s  %s  %s obs  %s",
 			cursor,
 			projectStyle.Render(fmt.Sprintf("%-20s", s.Project)),
-			timestampStyle.Render(localTime(s.StartedAt)),
+			timestampStyle.Render(m.localTime(s.StartedAt)),
 			statNumberStyle.Render(fmt.Sprintf("%d", s.ObservationCount)),
 			style.Render(summary))
 
@@ -490,10 +1035,10 @@ func (m Model) viewSessions() string {
 
 	if count > visibleItems {
 		b.WriteString(fmt.Sprintf("\n  %s",
-			timestampStyle.Render(fmt.Sprintf("showing %d-%d of %d", m.Scroll+1, end, count))))
+			timestampStyle.Render(i18n.Tf("showing %d-%d of %d", m.Scroll+1, end, count))))
 	}
 
-	b.WriteString(helpStyle.Render("\n  j/k navigate • enter view session • esc back"))
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k navigate • enter view session • esc back")))
 
 	return b.String()
 }
@@ -504,32 +1049,37 @@ func (m Model) viewSessionDetail() string {
 	var b strings.Builder
 
 	if m.SelectedSessionIdx >= len(m.Sessions) {
-		b.WriteString(headerStyle.Render("  Session Detail"))
+		b.WriteString(headerStyle.Render(i18n.T("  Session Detail")))
 		b.WriteString("\n")
-		b.WriteString(noResultsStyle.Render("Session not found."))
+		b.WriteString(noResultsStyle.Render(i18n.T("Session not found.")))
 		return b.String()
 	}
 
 	sess := m.Sessions[m.SelectedSessionIdx]
-	header := fmt.Sprintf("  Session: %s — %s", sess.Project, localTime(sess.StartedAt))
+	header := i18n.Tf("  Session: %s — %s", sess.Project, m.localTime(sess.StartedAt))
 	b.WriteString(headerStyle.Render(header))
 	b.WriteString("\n")
 
 	// Session metadata
+	if sess.ParentSessionID != nil {
+		b.WriteString(fmt.Sprintf("  %s %s\n",
+			detailLabelStyle.Render(i18n.T("Continues:")),
+			idStyle.Render(*sess.ParentSessionID)))
+	}
 	if sess.Summary != nil {
 		b.WriteString(fmt.Sprintf("  %s %s\n\n",
-			detailLabelStyle.Render("Summary:"),
+			detailLabelStyle.Render(i18n.T("Summary:")),
 			detailValueStyle.Render(*sess.Summary)))
 	}
 
 	count := len(m.SessionObservations)
-	b.WriteString(sectionHeadingStyle.Render(fmt.Sprintf("  Observations (%d)", count)))
+	b.WriteString(sectionHeadingStyle.Render(i18n.Tf("  Observations (%d)", count)))
 	b.WriteString("\n")
 
 	if count == 0 {
-		b.WriteString(noResultsStyle.Render("No observations in this session."))
+		b.WriteString(noResultsStyle.Render(i18n.T("No observations in this session.")))
 		b.WriteString("\n\n")
-		b.WriteString(helpStyle.Render("  esc back"))
+		b.WriteString(helpStyle.Render(i18n.T("  esc back")))
 		return b.String()
 	}
 
@@ -550,10 +1100,10 @@ func (m Model) viewSessionDetail() string {
 
 	if count > visibleItems {
 		b.WriteString(fmt.Sprintf("\n  %s",
-			timestampStyle.Render(fmt.Sprintf("showing %d-%d of %d", m.SessionDetailScroll+1, end, count))))
+			timestampStyle.Render(i18n.Tf("showing %d-%d of %d", m.SessionDetailScroll+1, end, count))))
 	}
 
-	b.WriteString(helpStyle.Render("\n  j/k navigate • enter detail • t timeline • esc back"))
+	b.WriteString(helpStyle.Render(i18n.T("\n  j/k navigate • enter detail • t timeline • esc back")))
 
 	return b.String()
 }
@@ -698,7 +1248,7 @@ func (m Model) renderObservationListItem(index int, id int64, obsType, title, co
 		typeBadgeStyle.Render(fmt.Sprintf("[%-12s]", obsType)),
 		style.Render(truncateStr(title, 50)),
 		proj,
-		timestampStyle.Render(localTime(createdAt)))
+		timestampStyle.Render(m.localTime(createdAt)))
 
 	// Content preview on second line
 	preview := truncateStr(content, 80)
@@ -711,18 +1261,13 @@ func (m Model) renderObservationListItem(index int, id int64, obsType, title, co
 
 // ─── Helpers ─────────────────────────────────────────────────────────────────
 
-// localTime converts a UTC timestamp string from SQLite to local time for display.
-func localTime(utc string) string {
-	for _, layout := range []string{
-		"2006-01-02 15:04:05",
-		time.RFC3339,
-		time.RFC3339Nano,
-	} {
-		if t, err := time.Parse(layout, utc); err == nil {
-			return t.UTC().Local().Format("2006-01-02 15:04:05")
-		}
+// localTime converts a stored UTC timestamp to the configured display zone
+// (the system zone unless the store sets one).
+func (m Model) localTime(utc string) string {
+	if m.store != nil {
+		return m.store.FormatTime(utc)
 	}
-	return utc // unparseable — return as-is
+	return store.FormatTimestamp(utc, nil)
 }
 
 func truncateStr(s string, max int) string {
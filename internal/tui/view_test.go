@@ -322,6 +322,7 @@ func TestViewRouterCoversAllScreens(t *testing.T) {
 	m.SelectedSessionIdx = 0
 	m.SessionObservations = []store.Observation{{ID: 1, Type: "bugfix", Title: "t", Content: "c", CreatedAt: "now"}}
 	m.SetupAgents = []setup.Agent{{Name: "opencode", Description: "OpenCode", InstallDir: "/tmp"}}
+	m.Quarantine = []store.QuarantinedObservation{{Observation: store.Observation{ID: 2, Type: "passive", Title: "t", Content: "c", CreatedAt: "now"}, Reason: "looks like log output"}}
 	m.Height = 20
 
 	tests := []struct {
@@ -337,6 +338,7 @@ func TestViewRouterCoversAllScreens(t *testing.T) {
 		{screen: ScreenSessions, want: "Sessions"},
 		{screen: ScreenSessionDetail, want: "Session:"},
 		{screen: ScreenSetup, want: "Setup"},
+		{screen: ScreenQuarantine, want: "reason: looks like log output"},
 	}
 
 	for _, tt := range tests {
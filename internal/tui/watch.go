@@ -0,0 +1,231 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/i18n"
+	"github.com/Gentleman-Programming/engram/internal/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ─── Stats Watch ─────────────────────────────────────────────────────────────
+//
+// StatsWatch is the live dashboard behind `engram stats --watch`. Like the
+// Picker it is a standalone model: it reloads the stats and the newest
+// observations every Interval and shows how fast memories are coming in, so
+// someone babysitting an agent can see captures land without re-running
+// `engram stats`.
+
+// DefaultWatchInterval is how often StatsWatch refreshes when no interval
+// is given.
+const DefaultWatchInterval = 2 * time.Second
+
+// watchRecentLimit is how many observations the ticker shows.
+const watchRecentLimit = 8
+
+// watchRateWindow is the span writes/min is averaged over once enough
+// samples exist.
+const watchRateWindow = time.Minute
+
+type watchTickMsg struct{}
+
+type watchLoadedMsg struct {
+	at     time.Time
+	stats  *store.Stats
+	recent []store.Observation
+	err    error
+}
+
+// watchSample is the write counter at one refresh.
+type watchSample struct {
+	at     time.Time
+	writes int
+}
+
+type StatsWatch struct {
+	store    *store.Store
+	Interval time.Duration
+	Width    int
+	Height   int
+
+	Stats     *store.Stats
+	Recent    []store.Observation
+	Err       error
+	UpdatedAt time.Time
+
+	// samples holds the write counter over the last watchRateWindow.
+	samples []watchSample
+	// started is false until the first load sets the baselines below.
+	started       bool
+	startWrites   int
+	startLatestID int64
+}
+
+// NewStatsWatch creates a live stats view over s refreshing every interval
+// (DefaultWatchInterval when interval <= 0).
+func NewStatsWatch(s *store.Store, interval time.Duration) StatsWatch {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	return StatsWatch{store: s, Interval: interval}
+}
+
+func (w StatsWatch) Init() tea.Cmd {
+	return w.load()
+}
+
+func (w StatsWatch) load() tea.Cmd {
+	s := w.store
+	return func() tea.Msg {
+		stats, err := s.Stats()
+		if err != nil {
+			return watchLoadedMsg{at: time.Now(), err: err}
+		}
+		recent, err := s.RecentObservations("", "", watchRecentLimit)
+		return watchLoadedMsg{at: time.Now(), stats: stats, recent: recent, err: err}
+	}
+}
+
+func (w StatsWatch) tick() tea.Cmd {
+	return tea.Tick(w.Interval, func(time.Time) tea.Msg { return watchTickMsg{} })
+}
+
+// watchWrites counts every save the store has seen: stored observations and
+// prompts plus saves absorbed by dedupe.
+func watchWrites(stats *store.Stats) int {
+	return stats.TotalObservations + stats.TotalPrompts + stats.DuplicatesAvoided
+}
+
+func (w StatsWatch) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		w.Width = msg.Width
+		w.Height = msg.Height
+		return w, nil
+
+	case watchTickMsg:
+		return w, w.load()
+
+	case watchLoadedMsg:
+		// Keep the last good numbers on screen when a refresh fails; the
+		// error is shown until the next successful one.
+		w.Err = msg.err
+		if msg.err != nil {
+			return w, w.tick()
+		}
+		w.Stats, w.Recent, w.UpdatedAt = msg.stats, msg.recent, msg.at
+		writes := watchWrites(msg.stats)
+		if !w.started {
+			w.started = true
+			w.startWrites = writes
+			for _, o := range msg.recent {
+				w.startLatestID = max(w.startLatestID, o.ID)
+			}
+		}
+		w.samples = append(w.samples, watchSample{at: msg.at, writes: writes})
+		for len(w.samples) > 2 && msg.at.Sub(w.samples[1].at) >= watchRateWindow {
+			w.samples = w.samples[1:]
+		}
+		return w, w.tick()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return w, tea.Quit
+		}
+	}
+	return w, nil
+}
+
+// WritesPerMinute averages new writes over the sampled window. It reports
+// false until two refreshes have happened.
+func (w StatsWatch) WritesPerMinute() (float64, bool) {
+	if len(w.samples) < 2 {
+		return 0, false
+	}
+	first, last := w.samples[0], w.samples[len(w.samples)-1]
+	elapsed := last.at.Sub(first.at)
+	if elapsed <= 0 {
+		return 0, false
+	}
+	// Deletes can shrink the counters; they are not negative writes.
+	delta := max(last.writes-first.writes, 0)
+	return float64(delta) / elapsed.Minutes(), true
+}
+
+func (w StatsWatch) localTime(utc string) string {
+	if w.store != nil {
+		return w.store.FormatTime(utc)
+	}
+	return store.FormatTimestamp(utc, nil)
+}
+
+func (w StatsWatch) View() string {
+	var b strings.Builder
+
+	header := i18n.Tf("  Engram Live Stats — every %s", w.Interval)
+	if !w.UpdatedAt.IsZero() {
+		header += "  " + timestampStyle.Render(i18n.Tf("updated %s", w.UpdatedAt.Format("15:04:05")))
+	}
+	b.WriteString(headerStyle.Render(header))
+	b.WriteString("\n")
+
+	if w.Err != nil {
+		b.WriteString(errorStyle.Render(i18n.T("Error: ") + w.Err.Error()))
+		b.WriteString("\n")
+	}
+	if w.Stats == nil {
+		b.WriteString(noResultsStyle.Render(i18n.T("Loading stats...")))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render(i18n.T("  q quit")))
+		return b.String()
+	}
+
+	rate := "—"
+	if perMin, ok := w.WritesPerMinute(); ok {
+		rate = fmt.Sprintf("%.1f", perMin)
+	}
+	statsContent := fmt.Sprintf(
+		"%s %s\n%s %s\n%s %s\n%s %s\n%s %s",
+		statNumberStyle.Render(fmt.Sprintf("%d", w.Stats.TotalSessions)),
+		statLabelStyle.Render(i18n.T("sessions")),
+		statNumberStyle.Render(fmt.Sprintf("%d", w.Stats.TotalObservations)),
+		statLabelStyle.Render(i18n.T("observations")),
+		statNumberStyle.Render(fmt.Sprintf("%d", w.Stats.TotalPrompts)),
+		statLabelStyle.Render(i18n.T("prompts")),
+		statNumberStyle.Render(rate),
+		statLabelStyle.Render(i18n.T("writes/min")),
+		statNumberStyle.Render(fmt.Sprintf("+%d", max(watchWrites(w.Stats)-w.startWrites, 0))),
+		statLabelStyle.Render(i18n.T("writes since start")),
+	)
+	b.WriteString(statCardStyle.Render(statsContent))
+	b.WriteString("\n")
+
+	b.WriteString(titleStyle.Render(i18n.T("  Recent observations")))
+	b.WriteString("\n")
+	if len(w.Recent) == 0 {
+		b.WriteString(noResultsStyle.Render(i18n.T("No observations yet.")))
+		b.WriteString("\n")
+	}
+	for _, o := range w.Recent {
+		line := fmt.Sprintf("%s %s %s %s",
+			timestampStyle.Render(w.localTime(o.CreatedAt)),
+			idStyle.Render(fmt.Sprintf("#%d", o.ID)),
+			typeBadgeStyle.Render("["+o.Type+"]"),
+			truncateStr(o.Title, 60))
+		if o.Project != nil {
+			line += " " + projectStyle.Render(*o.Project)
+		}
+		if o.ID > w.startLatestID {
+			line += " " + statusStyle.Render(i18n.T("new"))
+		}
+		b.WriteString(listItemStyle.Render(line))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render(i18n.T("  q quit")))
+	return b.String()
+}
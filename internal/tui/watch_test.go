@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestStatsWatchRefreshesAndComputesRate(t *testing.T) {
+	fx := newTestFixture(t)
+	w := NewStatsWatch(fx.store, 0)
+	if w.Interval != DefaultWatchInterval {
+		t.Fatalf("expected the default interval, got %s", w.Interval)
+	}
+	if !strings.Contains(w.View(), "Loading stats...") {
+		t.Fatalf("expected a loading view before the first refresh")
+	}
+
+	load := func(w StatsWatch, at time.Time) StatsWatch {
+		t.Helper()
+		msg := w.Init()().(watchLoadedMsg)
+		msg.at = at
+		next, cmd := w.Update(msg)
+		if cmd == nil {
+			t.Fatalf("expected each refresh to schedule the next tick")
+		}
+		return next.(StatsWatch)
+	}
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	w = load(w, start)
+	if _, ok := w.WritesPerMinute(); ok {
+		t.Fatalf("a single sample must not report a rate")
+	}
+	if strings.Contains(w.View(), " new") {
+		t.Fatalf("observations present at start must not be marked new")
+	}
+
+	for _, title := range []string{"Fresh capture", "Another capture"} {
+		if _, err := fx.store.AddObservation(store.AddObservationParams{
+			SessionID: "session-2", Type: "discovery", Title: title, Content: title + " body", Project: "engram",
+		}); err != nil {
+			t.Fatalf("add observation: %v", err)
+		}
+	}
+	w = load(w, start.Add(30*time.Second))
+	perMin, ok := w.WritesPerMinute()
+	if !ok || perMin != 4 {
+		t.Fatalf("expected 2 writes in 30s = 4/min, got %v %v", perMin, ok)
+	}
+	view := w.View()
+	if !strings.Contains(view, "Fresh capture") || !strings.Contains(view, "new") || !strings.Contains(view, "+2") {
+		t.Fatalf("expected the ticker to flag new observations, got %q", view)
+	}
+
+	// Samples older than the window are dropped, so the rate decays.
+	w = load(w, start.Add(3*time.Minute))
+	if perMin, _ := w.WritesPerMinute(); perMin != 0 || len(w.samples) != 2 {
+		t.Fatalf("expected the old burst to leave the window, got %v with %d samples", perMin, len(w.samples))
+	}
+
+	if _, cmd := w.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}}); cmd == nil {
+		t.Fatalf("expected q to quit")
+	}
+}
@@ -0,0 +1,317 @@
+// Package engram embeds the Engram memory engine in other Go programs.
+//
+// It is the supported library surface: everything under internal/ may change
+// between releases, while the types and functions here follow semantic
+// versioning. Breaking changes only land in a new major version; new fields
+// and methods may appear in minor releases.
+//
+//	eng, err := engram.Open(engram.Options{DataDir: dir})
+//	if err != nil {
+//		return err
+//	}
+//	defer eng.Close()
+//
+//	id, err := eng.Save(engram.SaveParams{
+//		Project: "billing",
+//		Title:   "Retry webhooks with backoff",
+//		Content: "**What**: ...",
+//	})
+//	results, err := eng.Search("webhook retry", engram.SearchOptions{Project: "billing"})
+//
+// An Engine opened on the same data directory as the engram binary shares
+// its database, so memories saved from a library are visible to agents over
+// MCP and vice versa.
+package engram
+
+import (
+	"errors"
+
+	"github.com/Gentleman-Programming/engram/internal/store"
+)
+
+// MemoryDBPath as Options.DBPath keeps the whole database in memory. It is
+// discarded when the Engine is closed.
+const MemoryDBPath = store.MemoryDBPath
+
+// Metadata is structured data attached to a memory: string, number, or
+// boolean values under short keys. Read values with its String, Int, Float,
+// and Bool accessors.
+type Metadata = store.Metadata
+
+// ErrClosed is returned by every method called after Close.
+var ErrClosed = errors.New("engram: engine is closed")
+
+// Options configures Open. The zero value uses the same database as the
+// engram binary with its default data directory (~/.engram/engram.db).
+type Options struct {
+	// DataDir holds engram.db. Empty means the default data directory.
+	DataDir string
+	// DBPath overrides the database file; it must be absolute or
+	// MemoryDBPath.
+	DBPath string
+	// MaxSearchResults caps Search. Zero keeps the default (20).
+	MaxSearchResults int
+	// MaxContextResults caps the observations in Context. Zero keeps the
+	// default (20).
+	MaxContextResults int
+	// DSN stores memories in PostgreSQL instead of SQLite when set to a
+	// postgres:// URL; DataDir and DBPath are then ignored. The program
+	// must register a driver, e.g. import _ "github.com/jackc/pgx/v5/stdlib".
+	DSN string
+}
+
+// Engine is an open memory store. Its methods are safe for concurrent use,
+// except Close, which must run after every other call has returned.
+type Engine struct {
+	store store.Backend
+}
+
+// Memory is a saved observation.
+type Memory struct {
+	ID            int64    `json:"id"`
+	SessionID     string   `json:"session_id"`
+	Type          string   `json:"type"`
+	Title         string   `json:"title"`
+	Content       string   `json:"content"`
+	Project       string   `json:"project,omitempty"`
+	Scope         string   `json:"scope"`
+	TopicKey      string   `json:"topic_key,omitempty"`
+	Refs          []string `json:"refs,omitempty"`
+	Metadata      Metadata `json:"metadata,omitempty"`
+	RevisionCount int      `json:"revision_count"`
+	// CreatedAt and UpdatedAt are RFC3339 UTC timestamps, "2006-01-02T15:04:05Z".
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// SearchResult is a Memory matched by Search. Lower Rank is a better match.
+type SearchResult struct {
+	Memory
+	Rank float64 `json:"rank"`
+}
+
+// SaveParams describes a memory to save.
+type SaveParams struct {
+	Title   string
+	Content string
+	// Type is the observation type (decision, bugfix, pattern, ...). Empty
+	// infers one from the title and content, as mem_save does.
+	Type    string
+	Project string
+	// Scope is "project" (default) or "personal".
+	Scope string
+	// TopicKey turns the save into an upsert of the memory with the same
+	// project, scope, and topic key.
+	TopicKey string
+	// SessionID groups the memory with others. Empty uses the same
+	// manual-save-{project} session as `engram save`; the session is
+	// created if it does not exist.
+	SessionID string
+	// Refs are tracker references (#123, owner/repo#123, URLs).
+	Refs []string
+	// Metadata is structured data kept beside the content, such as a PR
+	// number or the model that wrote the memory. A TopicKey upsert without
+	// metadata keeps the memory's existing metadata.
+	Metadata Metadata
+}
+
+// SearchOptions narrows Search. Zero fields do not filter.
+type SearchOptions struct {
+	Project string
+	Type    string
+	Scope   string
+	// Ref keeps only memories linked to a tracker reference.
+	Ref string
+	// Metadata keeps only memories whose metadata has every key set to the
+	// value; numbers and booleans compare as text ("42", "true").
+	Metadata map[string]string
+	Limit    int
+}
+
+// Open opens (creating if needed) the memory database described by opts.
+func Open(opts Options) (*Engine, error) {
+	cfg, err := store.DefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+	if opts.DataDir != "" {
+		cfg.DataDir = opts.DataDir
+	}
+	if opts.DBPath != "" {
+		cfg.DBPath = opts.DBPath
+	}
+	if opts.MaxSearchResults > 0 {
+		cfg.MaxSearchResults = opts.MaxSearchResults
+	}
+	if opts.MaxContextResults > 0 {
+		cfg.MaxContextResults = opts.MaxContextResults
+	}
+	cfg.DSN = opts.DSN
+
+	s, err := store.OpenBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{store: s}, nil
+}
+
+// Close releases the database. Calling it more than once is a no-op.
+func (e *Engine) Close() error {
+	if e.store == nil {
+		return nil
+	}
+	err := e.store.Close()
+	e.store = nil
+	return err
+}
+
+// Save stores a memory and returns its ID. Saves that duplicate a recent
+// memory, or share its topic key, update that memory and return its ID.
+func (e *Engine) Save(p SaveParams) (int64, error) {
+	if e.store == nil {
+		return 0, ErrClosed
+	}
+	if p.Title == "" || p.Content == "" {
+		return 0, errors.New("engram: title and content are required")
+	}
+
+	project, _ := store.NormalizeProject(p.Project)
+	typ := p.Type
+	if typ == "" {
+		typ = store.InferObservationType(p.Title, p.Content)
+	}
+	sessionID := p.SessionID
+	if sessionID == "" {
+		sessionID = "manual-save"
+		if project != "" {
+			sessionID = "manual-save-" + project
+		}
+	}
+	if err := e.store.CreateSession(sessionID, project, ""); err != nil {
+		return 0, err
+	}
+
+	return e.store.AddObservation(store.AddObservationParams{
+		SessionID: sessionID,
+		Type:      typ,
+		Title:     p.Title,
+		Content:   p.Content,
+		Project:   project,
+		Scope:     p.Scope,
+		TopicKey:  p.TopicKey,
+		Refs:      p.Refs,
+		Metadata:  p.Metadata,
+		Source:    store.SourceAPI,
+	})
+}
+
+// Get returns the memory with the given ID.
+func (e *Engine) Get(id int64) (*Memory, error) {
+	if e.store == nil {
+		return nil, ErrClosed
+	}
+	obs, err := e.store.GetObservation(id)
+	if err != nil {
+		return nil, err
+	}
+	m := memoryFrom(*obs)
+	return &m, nil
+}
+
+// Search runs a full-text query. An empty query with opts.Ref lists every
+// memory linked to that reference.
+func (e *Engine) Search(query string, opts SearchOptions) ([]SearchResult, error) {
+	if e.store == nil {
+		return nil, ErrClosed
+	}
+	results, err := e.store.Search(query, store.SearchOptions{
+		Type:     opts.Type,
+		Project:  opts.Project,
+		Scope:    opts.Scope,
+		Limit:    opts.Limit,
+		Ref:      opts.Ref,
+		Metadata: opts.Metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		out = append(out, SearchResult{Memory: memoryFrom(r.Observation), Rank: r.Rank})
+	}
+	return out, nil
+}
+
+// Recent returns the newest memories, optionally for one project and scope.
+func (e *Engine) Recent(project, scope string, limit int) ([]Memory, error) {
+	if e.store == nil {
+		return nil, ErrClosed
+	}
+	observations, err := e.store.RecentObservations(project, scope, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Memory, 0, len(observations))
+	for _, o := range observations {
+		out = append(out, memoryFrom(o))
+	}
+	return out, nil
+}
+
+// Context returns the markdown block agents receive at session start:
+// recent sessions, prompts, and memories for the project.
+func (e *Engine) Context(project, scope string) (string, error) {
+	if e.store == nil {
+		return "", ErrClosed
+	}
+	return e.store.FormatContext(project, scope)
+}
+
+// Delete removes a memory. Soft deletes hide it everywhere but keep the row
+// so sync can propagate the deletion; hard deletes remove it for good.
+func (e *Engine) Delete(id int64, hard bool) error {
+	if e.store == nil {
+		return ErrClosed
+	}
+	return e.store.DeleteObservation(id, hard)
+}
+
+// StartSession registers a working session so memories can be grouped
+// under it. Starting an existing session is a no-op.
+func (e *Engine) StartSession(id, project, directory string) error {
+	if e.store == nil {
+		return ErrClosed
+	}
+	return e.store.CreateSession(id, project, directory)
+}
+
+// EndSession marks a session completed with an optional summary.
+func (e *Engine) EndSession(id, summary string) error {
+	if e.store == nil {
+		return ErrClosed
+	}
+	return e.store.EndSession(id, summary)
+}
+
+func memoryFrom(o store.Observation) Memory {
+	m := Memory{
+		ID:            o.ID,
+		SessionID:     o.SessionID,
+		Type:          o.Type,
+		Title:         o.Title,
+		Content:       o.Content,
+		Scope:         o.Scope,
+		Refs:          []string(o.Refs),
+		Metadata:      o.Metadata,
+		RevisionCount: o.RevisionCount,
+		CreatedAt:     o.CreatedAt,
+		UpdatedAt:     o.UpdatedAt,
+	}
+	if o.Project != nil {
+		m.Project = *o.Project
+	}
+	if o.TopicKey != nil {
+		m.TopicKey = *o.TopicKey
+	}
+	return m
+}
@@ -0,0 +1,111 @@
+package engram
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEngineSaveSearchContextRoundTrip(t *testing.T) {
+	eng, err := Open(Options{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer eng.Close()
+
+	id, err := eng.Save(SaveParams{
+		Project: "Billing",
+		Title:   "Fixed webhook retry storm",
+		Content: "Retries now back off exponentially. Closes #42",
+	})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := eng.Get(id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Project != "billing" || got.SessionID != "manual-save-billing" || got.Type != "bugfix" || got.Scope != "project" {
+		t.Fatalf("unexpected memory: %+v", got)
+	}
+	if len(got.Refs) != 1 || got.Refs[0] != "#42" {
+		t.Fatalf("expected detected ref, got %v", got.Refs)
+	}
+
+	results, err := eng.Search("webhook", SearchOptions{Project: "billing"})
+	if err != nil || len(results) != 1 || results[0].ID != id {
+		t.Fatalf("Search = %+v, %v", results, err)
+	}
+	if results, _ := eng.Search("", SearchOptions{Ref: "#42"}); len(results) != 1 {
+		t.Fatalf("expected ref search hit, got %d", len(results))
+	}
+
+	context, err := eng.Context("billing", "")
+	if err != nil || !strings.Contains(context, "Fixed webhook retry storm") {
+		t.Fatalf("Context = %q, %v", context, err)
+	}
+
+	if err := eng.Delete(id, false); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if recent, err := eng.Recent("billing", "", 10); err != nil || len(recent) != 0 {
+		t.Fatalf("expected deleted memory to be hidden, got %+v, %v", recent, err)
+	}
+}
+
+func TestEngineSessionsTopicUpsertAndClose(t *testing.T) {
+	eng, err := Open(Options{DBPath: MemoryDBPath})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if err := eng.StartSession("s1", "engram", "/work/engram"); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	first, err := eng.Save(SaveParams{SessionID: "s1", Project: "engram", Type: "decision", Title: "Auth model", Content: "Use JWT", TopicKey: "architecture/auth"})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	second, err := eng.Save(SaveParams{SessionID: "s1", Project: "engram", Type: "decision", Title: "Auth model", Content: "Use opaque tokens", TopicKey: "architecture/auth"})
+	if err != nil || second != first {
+		t.Fatalf("topic save should upsert #%d, got #%d (%v)", first, second, err)
+	}
+	if got, _ := eng.Get(first); got.Content != "Use opaque tokens" || got.RevisionCount != 2 || got.TopicKey != "architecture/auth" {
+		t.Fatalf("unexpected upserted memory: %+v", got)
+	}
+	if err := eng.EndSession("s1", "done"); err != nil {
+		t.Fatalf("EndSession: %v", err)
+	}
+
+	if _, err := eng.Save(SaveParams{Title: "no content"}); err == nil {
+		t.Fatal("expected missing content to fail")
+	}
+
+	if err := eng.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := eng.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if _, err := eng.Search("auth", SearchOptions{}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestOpenRejectsRelativeDBPath(t *testing.T) {
+	if _, err := Open(Options{DBPath: filepath.Join("relative", "engram.db")}); err == nil {
+		t.Fatal("expected relative DBPath to fail")
+	}
+}
+
+func TestOpenPostgresDSNNeedsDriver(t *testing.T) {
+	_, err := Open(Options{DSN: "postgres://engram@localhost:5432/engram"})
+	if err == nil || !strings.Contains(err.Error(), "driver") {
+		t.Fatalf("expected a missing driver error, got %v", err)
+	}
+	if _, err := Open(Options{DSN: "mysql://localhost/engram"}); err == nil {
+		t.Fatal("expected an unsupported DSN to fail")
+	}
+}
@@ -25,6 +25,14 @@ if [ -n "$SESSION_ID" ] && [ -n "$PROJECT" ]; then
     -d "$(jq -n --arg id "$SESSION_ID" --arg project "$PROJECT" --arg dir "$CWD" \
       '{id: $id, project: $project, directory: $dir}')" \
     > /dev/null 2>&1
+
+  # Record the compaction so the next mem_context resurfaces the last summary
+  curl -sf "${ENGRAM_URL}/events/compaction" \
+    -X POST \
+    -H "Content-Type: application/json" \
+    -d "$(jq -n --arg sid "$SESSION_ID" --arg project "$PROJECT" \
+      '{session_id: $sid, project: $project, trigger: "auto"}')" \
+    > /dev/null 2>&1
 fi
 
 # Fetch context from previous sessions
@@ -29,7 +29,19 @@ const ENGRAM_TOOLS = new Set([
   "mem_update",
   "mem_delete",
   "mem_suggest_topic_key",
+  "mem_topics",
+  "mem_topic_search",
+  "mem_for_file",
+  "mem_context_outline",
+  "mem_context_section",
+  "mem_scratch_set",
+  "mem_scratch_get",
+  "mem_scratch_clear",
+  "mem_tool_run",
+  "mem_verify",
   "mem_save_prompt",
+  "mem_search_prompts",
+  "mem_recent_prompts",
   "mem_session_summary",
   "mem_context",
   "mem_stats",